@@ -2,34 +2,210 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
-	"homework10/internal/adapters/adrepo"
-	"homework10/internal/adapters/userrepo"
+	"homework10/internal/adapters/storage"
 	"homework10/internal/app"
+	"homework10/internal/auth"
+	"homework10/internal/dispatcher"
+	"homework10/internal/feed"
+	"homework10/internal/health"
+	"homework10/internal/idempotency"
+	"homework10/internal/janitor"
+	"homework10/internal/logging"
+	"homework10/internal/metrics"
 	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/ratelimit"
+	"homework10/internal/tracing"
+	"homework10/internal/users"
 )
 
 const port = ":50054"
 
+var (
+	store       = flag.String("storage", storage.Memory, "storage for ads, users, categories, favorites, messages, audit log, webhooks and images: memory or file")
+	dataDir     = flag.String("data", "data", "directory for the file storage")
+	secret      = flag.String("secret", os.Getenv("AUTH_SECRET"), "secret for signing auth tokens (default $AUTH_SECRET)")
+	admin       = flag.Int64("admin", -1, "ID of an existing user to make an administrator at startup")
+	adTTL       = flag.Duration("ad-ttl", app.DefaultAdTTL, "default lifetime of an ad, after which it is archived")
+	sweep       = flag.Duration("janitor-interval", janitor.DefaultInterval, "how often to archive expired ads")
+	hooks       = flag.Duration("webhook-interval", dispatcher.DefaultInterval, "how often to send new events and retry failed webhook deliveries")
+	limit       = flag.String("rate-limit", "300/1m", "limit of requests of one user or IP address to each route without its own limit, e.g. 300/1m, or off")
+	limits      = flag.String("rate-limits", "/ad.AdService/CreateAd=10/1m", "limits of requests to separate routes: comma separated /package.Service/Method=limit")
+	window      = flag.Duration("idempotency-window", idempotency.DefaultWindow, "how long to keep responses to create requests with an idempotency key")
+	metricsAddr = flag.String("metrics-addr", ":9090", "address of the HTTP server with Prometheus metrics at /metrics")
+	drain       = flag.Duration("shutdown-delay", 0, "how long to keep accepting requests after reporting not ready on shutdown, so that load balancers stop sending new ones")
+	probeEvery  = flag.Duration("health-interval", health.DefaultInterval, "how often to recheck readiness reported by the gRPC health service")
+	traces      = flag.String("trace-exporter", "none", "where to export tracing spans: stdout (as JSON) or none")
+	level       = flag.String("log-level", "info", "minimum level of log records: debug, info, warn or error")
+)
+
+// newLogger создаёт логгер, пишущий в stderr записи не ниже уровня -log-level в формате JSON
+func newLogger() (*slog.Logger, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(*level)); err != nil {
+		return nil, err
+	}
+
+	return logging.New(os.Stderr, l), nil
+}
+
+// newIssuer создаёт выдающего токены; без заданного секрета он генерируется случайно,
+// и выданные токены перестают действовать после перезапуска сервиса
+func newIssuer(logger *slog.Logger) (*auth.Issuer, error) {
+	key := []byte(*secret)
+	if len(key) == 0 {
+		logger.Warn("auth secret is not set, tokens won't survive a restart")
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return auth.NewIssuer(key, auth.DefaultAccessTTL, auth.DefaultRefreshTTL), nil
+}
+
+// newLimiter создаёт ограничитель запросов по флагам -rate-limit и -rate-limits
+func newLimiter() (*ratelimit.Limiter, error) {
+	def, err := ratelimit.ParseLimit(*limit)
+	if err != nil {
+		return nil, err
+	}
+
+	routes, err := ratelimit.ParseRoutes(*limits)
+	if err != nil {
+		return nil, err
+	}
+
+	return ratelimit.New(ratelimit.Rules{Default: def, Routes: routes}, ratelimit.DefaultIdle), nil
+}
+
+// promoteAdmin делает пользователя -admin администратором: первого администратора
+// больше некому назначить, остальных он назначит сам
+func promoteAdmin(userRepo users.Repository) error {
+	if *admin < 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	u, err := userRepo.UserByID(ctx, *admin)
+	if err != nil {
+		return err
+	}
+	if u.Role == users.RoleAdmin {
+		return nil
+	}
+
+	u.Role = users.RoleAdmin
+	return userRepo.UpdateUser(ctx, u)
+}
+
 func main() {
+	flag.Parse()
+
+	logger, err := newLogger()
+	if err != nil {
+		log.Fatalf("failed to create logger: %v", err)
+	}
+	// записи библиотек, пишущих через log, тоже идут в logger
+	slog.SetDefault(logger)
+
+	tp, shutdownTracing, err := tracing.New(*traces, "adapp-grpc", os.Stdout)
+	if err != nil {
+		logger.Error("failed to create tracer provider", "err", err)
+		os.Exit(1)
+	}
+	defer func() {
+		shCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := shutdownTracing(shCtx); err != nil {
+			logger.Error("can't export remaining spans", "err", err)
+		}
+	}()
+
+	r, err := storage.Open(*store, *dataDir, logger)
+	if err != nil {
+		logger.Error("failed to open storage", "err", err)
+		os.Exit(1)
+	}
+	defer r.Close()
+
+	if err = promoteAdmin(r.Users); err != nil {
+		logger.Error("failed to promote user to admin", "user_id", *admin, "err", err)
+		os.Exit(1)
+	}
+
+	issuer, err := newIssuer(logger)
+	if err != nil {
+		logger.Error("failed to create token issuer", "err", err)
+		os.Exit(1)
+	}
+
+	limiter, err := newLimiter()
+	if err != nil {
+		logger.Error("failed to create rate limiter", "err", err)
+		os.Exit(1)
+	}
+
 	lis, err := net.Listen("tcp", port)
 	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+		logger.Error("failed to listen", "err", err)
+		os.Exit(1)
 	}
 
-	server := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcPort.UnaryLogInterceptor, recovery.UnaryServerInterceptor()))
-	service := grpcPort.NewService(app.NewApp(adrepo.New(), userrepo.New()))
+	// лента закрывается до остановки сервера, иначе открытые потоки WatchAds не дадут ему остановиться
+	hub := feed.NewHub(feed.DefaultBuffer)
+	m := metrics.New()
+	m.CountRepos(r.Ads, r.Users)
+	probe := health.New()
+	probe.Add("ads", health.Ads(r.Ads))
+	probe.Add("users", health.Users(r.Users))
+	a := app.NewApp(tracing.Ads(r.Ads, tp), tracing.Users(r.Users, tp), r.Categories, r.Favorites, r.Messages, r.Audit,
+		r.Outbox, r.Webhooks, r.Searches, hub, r.Images, issuer, *adTTL, logger)
+	// спан вызова приложения - внешний, чтобы в него входило всё время вызова
+	a = app.Observe(app.Observe(a, m.AppObserver()), tracing.AppObserver(tp))
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		grpcPort.UnaryTraceInterceptor(tp),
+		grpcPort.UnaryLogInterceptor(logger),
+		grpcPort.UnaryMetricsInterceptor(m),
+		recovery.UnaryServerInterceptor(recovery.WithRecoveryHandlerContext(grpcPort.RecoveryHandler(logger))),
+		grpcPort.UnaryAuthInterceptor(a),
+		grpcPort.UnaryRateLimitInterceptor(limiter),
+		grpcPort.UnaryIdempotencyInterceptor(*window),
+	), grpc.ChainStreamInterceptor(
+		grpcPort.StreamTraceInterceptor(tp),
+		grpcPort.StreamLogInterceptor(logger),
+		grpcPort.StreamMetricsInterceptor(m),
+		recovery.StreamServerInterceptor(recovery.WithRecoveryHandlerContext(grpcPort.RecoveryHandler(logger))),
+		grpcPort.StreamAuthInterceptor(a),
+		grpcPort.StreamRateLimitInterceptor(limiter),
+	))
+	service := grpcPort.NewService(a)
 	grpcPort.RegisterAdServiceServer(server, service)
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+	metricsServer := &http.Server{Addr: *metricsAddr, Handler: mux}
 
 	eg, ctx := errgroup.WithContext(context.Background())
 
@@ -40,7 +216,7 @@ func main() {
 	eg.Go(func() error {
 		select {
 		case s := <-sigQuit:
-			log.Printf("captured signal: %v\n", s)
+			logger.Info("captured signal", "signal", s.String())
 			return fmt.Errorf("captured signal: %v", s)
 		case <-ctx.Done():
 			return nil
@@ -48,12 +224,61 @@ func main() {
 	})
 
 	eg.Go(func() error {
-		log.Printf("starting grpc server, listening on %s\n", port)
-		defer log.Printf("close grpc server listening on %s\n", port)
+		return janitor.New(a, *sweep, logger).Run(ctx)
+	})
+
+	eg.Go(func() error {
+		return grpcPort.WatchHealth(ctx, healthServer, probe, *probeEvery)
+	})
+
+	eg.Go(func() error {
+		return dispatcher.New(r.Outbox, r.Webhooks, dispatcher.Config{Interval: *hooks}, logger).Run(ctx)
+	})
+
+	eg.Go(func() error {
+		logger.Info("starting metrics server", "addr", metricsServer.Addr)
+		defer logger.Info("metrics server was closed", "addr", metricsServer.Addr)
+
+		errCh := make(chan error)
+
+		defer func() {
+			shCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			if err := metricsServer.Shutdown(shCtx); err != nil {
+				logger.Error("can't close metrics server", "addr", metricsServer.Addr, "err", err)
+			}
+
+			close(errCh)
+		}()
+
+		go func() {
+			if err := metricsServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				errCh <- err
+			}
+		}()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-errCh:
+			return fmt.Errorf("metrics server can't listen and serve requests: %w", err)
+		}
+	})
+
+	eg.Go(func() error {
+		logger.Info("starting grpc server", "addr", port)
+		defer logger.Info("grpc server was closed", "addr", port)
 
 		errCh := make(chan error)
 
 		defer func() {
+			// сначала сервис сообщает, что не готов, и ещё -shutdown-delay принимает вызовы, пока балансировщик
+			// не перестанет их присылать, а затем перестаёт принимать новые и дорабатывает начатые
+			probe.Shutdown()
+			healthServer.Shutdown()
+			time.Sleep(*drain)
+			hub.Close()
 			server.GracefulStop()
 			_ = lis.Close()
 
@@ -75,8 +300,8 @@ func main() {
 	})
 
 	if err = eg.Wait(); err != nil {
-		log.Printf("gracefully shutting down the servers: %s\n", err.Error())
+		logger.Info("gracefully shutting down the servers", "reason", err.Error())
 	}
 
-	log.Println("service was successfully shutdown")
+	logger.Info("service was successfully shutdown")
 }
@@ -2,9 +2,12 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -13,16 +16,160 @@ import (
 
 	"golang.org/x/sync/errgroup"
 
-	"homework10/internal/adapters/adrepo"
-	"homework10/internal/adapters/userrepo"
+	"homework10/internal/adapters/storage"
 	"homework10/internal/app"
+	"homework10/internal/auth"
+	"homework10/internal/dispatcher"
+	"homework10/internal/feed"
+	"homework10/internal/health"
+	"homework10/internal/idempotency"
+	"homework10/internal/janitor"
+	"homework10/internal/logging"
+	"homework10/internal/metrics"
 	"homework10/internal/ports/httpgin"
+	"homework10/internal/ratelimit"
+	"homework10/internal/tracing"
+	"homework10/internal/users"
 )
 
 const port = ":18080"
 
+var (
+	store   = flag.String("storage", storage.Memory, "storage for ads, users, categories, favorites, messages, audit log, webhooks and images: memory or file")
+	dataDir = flag.String("data", "data", "directory for the file storage")
+	secret  = flag.String("secret", os.Getenv("AUTH_SECRET"), "secret for signing auth tokens (default $AUTH_SECRET)")
+	admin   = flag.Int64("admin", -1, "ID of an existing user to make an administrator at startup")
+	adTTL   = flag.Duration("ad-ttl", app.DefaultAdTTL, "default lifetime of an ad, after which it is archived")
+	sweep   = flag.Duration("janitor-interval", janitor.DefaultInterval, "how often to archive expired ads")
+	hooks   = flag.Duration("webhook-interval", dispatcher.DefaultInterval, "how often to send new events and retry failed webhook deliveries")
+	limit   = flag.String("rate-limit", "300/1m", "limit of requests of one user or IP address to each route without its own limit, e.g. 300/1m, or off")
+	limits  = flag.String("rate-limits", "POST /api/v1/ads=10/1m", "limits of requests to separate routes: comma separated METHOD /path=limit")
+	window  = flag.Duration("idempotency-window", idempotency.DefaultWindow, "how long to keep responses to create requests with an idempotency key")
+	drain   = flag.Duration("shutdown-delay", 0, "how long to keep accepting requests after reporting not ready on shutdown, so that load balancers stop sending new ones")
+	traces  = flag.String("trace-exporter", "none", "where to export tracing spans: stdout (as JSON) or none")
+	level   = flag.String("log-level", "info", "minimum level of log records: debug, info, warn or error")
+)
+
+// newLogger создаёт логгер, пишущий в stderr записи не ниже уровня -log-level в формате JSON
+func newLogger() (*slog.Logger, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(*level)); err != nil {
+		return nil, err
+	}
+
+	return logging.New(os.Stderr, l), nil
+}
+
+// newIssuer создаёт выдающего токены; без заданного секрета он генерируется случайно,
+// и выданные токены перестают действовать после перезапуска сервиса
+func newIssuer(logger *slog.Logger) (*auth.Issuer, error) {
+	key := []byte(*secret)
+	if len(key) == 0 {
+		logger.Warn("auth secret is not set, tokens won't survive a restart")
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return auth.NewIssuer(key, auth.DefaultAccessTTL, auth.DefaultRefreshTTL), nil
+}
+
+// newLimiter создаёт ограничитель запросов по флагам -rate-limit и -rate-limits
+func newLimiter() (*ratelimit.Limiter, error) {
+	def, err := ratelimit.ParseLimit(*limit)
+	if err != nil {
+		return nil, err
+	}
+
+	routes, err := ratelimit.ParseRoutes(*limits)
+	if err != nil {
+		return nil, err
+	}
+
+	return ratelimit.New(ratelimit.Rules{Default: def, Routes: routes}, ratelimit.DefaultIdle), nil
+}
+
+// promoteAdmin делает пользователя -admin администратором: первого администратора
+// больше некому назначить, остальных он назначит сам
+func promoteAdmin(userRepo users.Repository) error {
+	if *admin < 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	u, err := userRepo.UserByID(ctx, *admin)
+	if err != nil {
+		return err
+	}
+	if u.Role == users.RoleAdmin {
+		return nil
+	}
+
+	u.Role = users.RoleAdmin
+	return userRepo.UpdateUser(ctx, u)
+}
+
 func main() {
-	server := httpgin.NewHTTPServer(port, app.NewApp(adrepo.New(), userrepo.New()))
+	flag.Parse()
+
+	logger, err := newLogger()
+	if err != nil {
+		log.Fatalf("failed to create logger: %v", err)
+	}
+	// записи библиотек, пишущих через log, тоже идут в logger
+	slog.SetDefault(logger)
+
+	tp, shutdownTracing, err := tracing.New(*traces, "adapp-http", os.Stdout)
+	if err != nil {
+		logger.Error("failed to create tracer provider", "err", err)
+		os.Exit(1)
+	}
+	defer func() {
+		shCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := shutdownTracing(shCtx); err != nil {
+			logger.Error("can't export remaining spans", "err", err)
+		}
+	}()
+
+	r, err := storage.Open(*store, *dataDir, logger)
+	if err != nil {
+		logger.Error("failed to open storage", "err", err)
+		os.Exit(1)
+	}
+	defer r.Close()
+
+	if err = promoteAdmin(r.Users); err != nil {
+		logger.Error("failed to promote user to admin", "user_id", *admin, "err", err)
+		os.Exit(1)
+	}
+
+	issuer, err := newIssuer(logger)
+	if err != nil {
+		logger.Error("failed to create token issuer", "err", err)
+		os.Exit(1)
+	}
+
+	limiter, err := newLimiter()
+	if err != nil {
+		logger.Error("failed to create rate limiter", "err", err)
+		os.Exit(1)
+	}
+
+	// лента закрывается до остановки сервера, иначе открытые потоки WatchAds не дадут ему остановиться
+	hub := feed.NewHub(feed.DefaultBuffer)
+	m := metrics.New()
+	m.CountRepos(r.Ads, r.Users)
+	probe := health.New()
+	probe.Add("ads", health.Ads(r.Ads))
+	probe.Add("users", health.Users(r.Users))
+	a := app.NewApp(tracing.Ads(r.Ads, tp), tracing.Users(r.Users, tp), r.Categories, r.Favorites, r.Messages, r.Audit,
+		r.Outbox, r.Webhooks, r.Searches, hub, r.Images, issuer, *adTTL, logger)
+	// спан вызова приложения - внешний, чтобы в него входило всё время вызова
+	a = app.Observe(app.Observe(a, m.AppObserver()), tracing.AppObserver(tp))
+	server := httpgin.NewHTTPServer(port, a, limiter, *window, logger, m, tp, probe)
 
 	eg, ctx := errgroup.WithContext(context.Background())
 
@@ -31,17 +178,41 @@ func main() {
 	signal.Notify(sigQuit, syscall.SIGINT, syscall.SIGTERM)
 
 	eg.Go(func() error {
-		log.Printf("starting http server, listening on %s\n", server.Addr)
-		defer log.Printf("close http server listening on %s\n", server.Addr)
+		select {
+		case s := <-sigQuit:
+			logger.Info("captured signal", "signal", s.String())
+			return fmt.Errorf("captured signal: %v", s)
+		case <-ctx.Done():
+			return nil
+		}
+	})
+
+	eg.Go(func() error {
+		return janitor.New(a, *sweep, logger).Run(ctx)
+	})
+
+	eg.Go(func() error {
+		return dispatcher.New(r.Outbox, r.Webhooks, dispatcher.Config{Interval: *hooks}, logger).Run(ctx)
+	})
+
+	eg.Go(func() error {
+		logger.Info("starting http server", "addr", server.Addr)
+		defer logger.Info("http server was closed", "addr", server.Addr)
 
 		errCh := make(chan error)
 
 		defer func() {
+			// сначала сервис сообщает, что не готов, и ещё -shutdown-delay принимает запросы, пока балансировщик
+			// не перестанет их присылать, а затем перестаёт принимать новые и дорабатывает начатые
+			probe.Shutdown()
+			time.Sleep(*drain)
+			hub.Close()
+
 			shCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 			defer cancel()
 
 			if err := server.Shutdown(shCtx); err != nil {
-				log.Printf("can't close http server listening on %s: %s", server.Addr, err.Error())
+				logger.Error("can't close http server", "addr", server.Addr, "err", err)
 			}
 
 			close(errCh)
@@ -62,8 +233,8 @@ func main() {
 	})
 
 	if err := eg.Wait(); err != nil {
-		log.Printf("gracefully shutting down the servers: %s\n", err.Error())
+		logger.Info("gracefully shutting down the servers", "reason", err.Error())
 	}
 
-	log.Println("server was successfully shutdown")
+	logger.Info("server was successfully shutdown")
 }
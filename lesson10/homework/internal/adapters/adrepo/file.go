@@ -0,0 +1,247 @@
+package adrepo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"homework10/internal/adapters/geo"
+	"homework10/internal/adapters/search"
+	"homework10/internal/adapters/wal"
+	"homework10/internal/ads"
+)
+
+const (
+	opAdd    = "add"
+	opUpdate = "update"
+	opDelete = "delete"
+)
+
+// RepoFile - репозиторий объявлений, переживающий перезапуск сервиса (как и RepoMap, работает с копиями):
+// состояние хранится в памяти, каждое изменение пишется в WAL, периодически делается снимок
+type RepoFile struct {
+	storage map[int64]*ads.Ad
+	index   *search.Index
+	places  *geo.Index
+	nextID  int64
+	log     *wal.Log
+	logger  *slog.Logger
+	m       sync.RWMutex
+}
+
+type fileState struct {
+	NextID int64     `json:"next_id"`
+	Ads    []*ads.Ad `json:"ads"`
+}
+
+func NewFile(dir string, snapshotEvery int, logger *slog.Logger) (*RepoFile, error) {
+	l, err := wal.Open(dir, snapshotEvery)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &RepoFile{
+		storage: make(map[int64]*ads.Ad),
+		index:   search.NewIndex(),
+		places:  geo.NewIndex(),
+		log:     l,
+		logger:  logger,
+		m:       sync.RWMutex{},
+	}
+
+	if err = l.Recover(r.restore, r.apply); err != nil {
+		_ = l.Close()
+		return nil, fmt.Errorf("recover ad repo: %w", err)
+	}
+	for _, a := range r.storage {
+		r.index.Add(a.ID, a.Title, a.Text)
+		place(r.places, a)
+	}
+
+	return r, nil
+}
+
+func (r *RepoFile) AdByID(_ context.Context, ID int64) (*ads.Ad, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	ad, ok := r.storage[ID]
+
+	if !ok {
+		return nil, ErrNoAd
+	}
+
+	a := *ad
+	return &a, nil
+}
+
+func (r *RepoFile) AddAd(ctx context.Context, ad *ads.Ad) (int64, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	_, ok := r.storage[ad.ID]
+	if ok {
+		return -1, ErrAdAlreadyExists
+	}
+
+	a := *ad
+	a.ID = r.nextID
+	a.Version = 1
+	if err := r.log.Append(opAdd, &a); err != nil {
+		return -1, err
+	}
+
+	ad.ID = a.ID
+	ad.Version = a.Version
+	r.storage[a.ID] = &a
+	r.index.Add(a.ID, a.Title, a.Text)
+	place(r.places, &a)
+	r.nextID++
+	r.snapshotIfNeeded(ctx)
+
+	return ad.ID, nil
+}
+
+// AdsByPattern возвращает страницу подходящих под шаблон объявлений и курсор следующей страницы
+func (r *RepoFile) AdsByPattern(_ context.Context, p *ads.Pattern, page ads.Page) ([]*ads.Ad, string, error) {
+	r.m.RLock()
+	adverts, scores := matching(r.storage, r.index, r.places, p)
+	r.m.RUnlock()
+
+	return page.CutRanked(adverts, scores)
+}
+
+func (r *RepoFile) CountByCategory(_ context.Context, p *ads.Pattern) (map[int64]int, error) {
+	r.m.RLock()
+	adverts, _ := matching(r.storage, r.index, r.places, p)
+	r.m.RUnlock()
+
+	return countByCategory(adverts), nil
+}
+
+func (r *RepoFile) UpdateAd(ctx context.Context, ad *ads.Ad) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	old, ok := r.storage[ad.ID]
+	if !ok {
+		return ErrNoAd
+	}
+	if old.Version != ad.Version {
+		return ErrAdVersionConflict
+	}
+
+	a := *ad
+	a.Version++
+	if err := r.log.Append(opUpdate, &a); err != nil {
+		return err
+	}
+
+	ad.Version = a.Version
+	r.storage[a.ID] = &a
+	r.index.Add(a.ID, a.Title, a.Text)
+	place(r.places, &a)
+	r.snapshotIfNeeded(ctx)
+
+	return nil
+}
+
+func (r *RepoFile) DeleteAd(ctx context.Context, ID int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	_, ok := r.storage[ID]
+	if !ok {
+		return ErrNoAd
+	}
+
+	if err := r.log.Append(opDelete, ID); err != nil {
+		return err
+	}
+
+	delete(r.storage, ID)
+	r.index.Remove(ID)
+	r.places.Remove(ID)
+	r.snapshotIfNeeded(ctx)
+
+	return nil
+}
+
+// Close сохраняет итоговый снимок состояния и закрывает журнал
+func (r *RepoFile) Close() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if err := r.log.Snapshot(r.state()); err != nil {
+		_ = r.log.Close()
+		return err
+	}
+
+	return r.log.Close()
+}
+
+// snapshotIfNeeded не возвращает ошибку: операция уже записана в журнал,
+// а неудавшийся снимок будет повторён при следующем изменении
+func (r *RepoFile) snapshotIfNeeded(ctx context.Context) {
+	if !r.log.NeedSnapshot() {
+		return
+	}
+
+	if err := r.log.Snapshot(r.state()); err != nil {
+		r.logger.ErrorContext(ctx, "can't snapshot ad repo", "err", err)
+	}
+}
+
+func (r *RepoFile) state() fileState {
+	s := fileState{NextID: r.nextID, Ads: make([]*ads.Ad, 0, len(r.storage))}
+	for _, a := range r.storage {
+		s.Ads = append(s.Ads, a)
+	}
+
+	return s
+}
+
+func (r *RepoFile) restore(data []byte) error {
+	var s fileState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	r.nextID = s.NextID
+	for _, a := range s.Ads {
+		r.storage[a.ID] = a
+	}
+
+	return nil
+}
+
+func (r *RepoFile) apply(rec wal.Record) error {
+	switch rec.Op {
+	case opAdd:
+		var a ads.Ad
+		if err := json.Unmarshal(rec.Data, &a); err != nil {
+			return err
+		}
+		r.storage[a.ID] = &a
+		if a.ID >= r.nextID {
+			r.nextID = a.ID + 1
+		}
+	case opUpdate:
+		var a ads.Ad
+		if err := json.Unmarshal(rec.Data, &a); err != nil {
+			return err
+		}
+		r.storage[a.ID] = &a
+	case opDelete:
+		var ID int64
+		if err := json.Unmarshal(rec.Data, &ID); err != nil {
+			return err
+		}
+		delete(r.storage, ID)
+	default:
+		return fmt.Errorf("%w: unknown op %q", wal.ErrCorrupted, rec.Op)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package adrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"homework10/internal/ads"
+	"homework10/internal/logging"
+)
+
+func TestRepoFileTestSuite(t *testing.T) {
+	suite.Run(t, &RepoTestSuite{newRepo: func() ads.Repository {
+		r, err := NewFile(t.TempDir(), 5, logging.Discard())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			_ = r.Close()
+		})
+		return r
+	}})
+}
+
+func TestRepoFile_Reopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	now := time.Now().UTC()
+
+	r, err := NewFile(dir, 3, logging.Discard())
+	assert.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		_, err = r.AddAd(ctx, &ads.Ad{ID: -1, Title: "title", Text: "text", UserID: int64(i), Created: now, Updated: now})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, r.DeleteAd(ctx, 1))
+	assert.NoError(t, r.DeleteAd(ctx, 4))
+
+	// без Close: состояние восстанавливается из снимка и хвоста журнала
+	r2, err := NewFile(dir, 3, logging.Discard())
+	assert.NoError(t, err)
+
+	adverts, _, err := r2.AdsByPattern(ctx, ads.DefaultPattern(), ads.DefaultPage())
+	assert.NoError(t, err)
+	assert.Len(t, adverts, 3)
+
+	ad, err := r2.AdByID(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), ad.UserID)
+	assert.True(t, now.Equal(ad.Created))
+
+	_, err = r2.AdByID(ctx, 4)
+	assert.ErrorIs(t, err, ErrNoAd)
+
+	// идентификаторы удалённых объявлений не переиспользуются
+	id, err := r2.AddAd(ctx, &ads.Ad{ID: -1, Title: "title", Text: "text"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), id)
+	assert.NoError(t, r2.Close())
+
+	r3, err := NewFile(dir, 3, logging.Discard())
+	assert.NoError(t, err)
+	adverts, _, err = r3.AdsByPattern(ctx, ads.DefaultPattern(), ads.DefaultPage())
+	assert.NoError(t, err)
+	assert.Len(t, adverts, 4)
+
+	// полнотекстовый индекс не хранится на диске и перестраивается при открытии
+	adverts, _, err = r3.AdsByPattern(ctx, ads.DefaultPattern().SetQuery("titles"), ads.DefaultPage())
+	assert.NoError(t, err)
+	assert.Len(t, adverts, 4)
+	assert.NoError(t, r3.Close())
+}
+
+func TestRepoFile_ReopenPlaces(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	loc := &ads.Location{Point: ads.Point{Lat: 59.9386, Lon: 30.3141}, City: "Санкт-Петербург"}
+
+	r, err := NewFile(dir, 2, logging.Discard())
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = r.AddAd(ctx, &ads.Ad{ID: -1, Title: "title", Text: "text", Location: loc})
+		assert.NoError(t, err)
+	}
+	ad, err := r.AdByID(ctx, 0)
+	assert.NoError(t, err)
+	ad.Location = nil
+	assert.NoError(t, r.UpdateAd(ctx, ad))
+
+	// индекс местоположений, как и полнотекстовый, перестраивается при открытии
+	r2, err := NewFile(dir, 2, logging.Discard())
+	assert.NoError(t, err)
+	near, err := ads.NewCircle(59.94, 30.31, 5)
+	assert.NoError(t, err)
+	adverts, _, err := r2.AdsByPattern(ctx, ads.DefaultPattern().SetNear(near), ads.DefaultPage())
+	assert.NoError(t, err)
+	assert.Len(t, adverts, 2)
+	for _, ad := range adverts {
+		assert.Equal(t, "Санкт-Петербург", ad.Location.City)
+		assert.NotNil(t, ad.Distance)
+	}
+	assert.NoError(t, r2.Close())
+}
@@ -5,22 +5,34 @@ import (
 	"fmt"
 	"sync"
 
+	"homework10/internal/adapters/geo"
+	"homework10/internal/adapters/search"
 	"homework10/internal/ads"
 )
 
 var (
-	ErrNoAd            = fmt.Errorf("ad does not exist")
-	ErrAdAlreadyExists = fmt.Errorf("ad already exists")
+	ErrNoAd              = fmt.Errorf("ad does not exist")
+	ErrAdAlreadyExists   = fmt.Errorf("ad already exists")
+	ErrAdVersionConflict = fmt.Errorf("ad version conflict")
 )
 
+// RepoMap хранит копии объявлений и отдаёт наружу тоже копии,
+// поэтому любое изменение объявления должно проходить через UpdateAd.
+// Заголовки и тексты объявлений поддерживаются в полнотекстовом индексе, а местоположения - в пространственном.
+// ID не переиспользуются: иначе новое объявление заняло бы место чужого живого
 type RepoMap struct {
 	storage map[int64]*ads.Ad
+	nextID  int64
+	index   *search.Index
+	places  *geo.Index
 	m       sync.RWMutex
 }
 
 func New() ads.Repository {
 	return &RepoMap{
 		storage: make(map[int64]*ads.Ad),
+		index:   search.NewIndex(),
+		places:  geo.NewIndex(),
 		m:       sync.RWMutex{},
 	}
 }
@@ -34,7 +46,8 @@ func (r *RepoMap) AdByID(_ context.Context, ID int64) (*ads.Ad, error) {
 		return nil, ErrNoAd
 	}
 
-	return ad, nil
+	a := *ad
+	return &a, nil
 }
 
 func (r *RepoMap) AddAd(_ context.Context, ad *ads.Ad) (int64, error) {
@@ -46,24 +59,55 @@ func (r *RepoMap) AddAd(_ context.Context, ad *ads.Ad) (int64, error) {
 		return -1, ErrAdAlreadyExists
 	}
 
-	ad.ID = int64(len(r.storage))
-	r.storage[ad.ID] = ad
+	ad.ID = r.nextID
+	ad.Version = 1
+	a := *ad
+	r.storage[ad.ID] = &a
+	r.nextID++
+	r.index.Add(a.ID, a.Title, a.Text)
+	place(r.places, &a)
 
 	return ad.ID, nil
 }
 
-func (r *RepoMap) AdsByPattern(_ context.Context, p *ads.Pattern) ([]*ads.Ad, error) {
-	var adverts []*ads.Ad
+// AdsByPattern возвращает страницу подходящих под шаблон объявлений и курсор следующей страницы
+func (r *RepoMap) AdsByPattern(_ context.Context, p *ads.Pattern, page ads.Page) ([]*ads.Ad, string, error) {
+	r.m.RLock()
+	adverts, scores := matching(r.storage, r.index, r.places, p)
+	r.m.RUnlock()
 
+	return page.CutRanked(adverts, scores)
+}
+
+func (r *RepoMap) CountByCategory(_ context.Context, p *ads.Pattern) (map[int64]int, error) {
 	r.m.RLock()
-	for _, a := range r.storage {
-		if p.Fits(a) {
-			adverts = append(adverts, a)
-		}
-	}
+	adverts, _ := matching(r.storage, r.index, r.places, p)
 	r.m.RUnlock()
 
-	return adverts, nil
+	return countByCategory(adverts), nil
+}
+
+// UpdateAd заменяет объявление, если его версия в хранилище совпадает с ad.Version,
+// и увеличивает версию (в том числе у переданного ad)
+func (r *RepoMap) UpdateAd(_ context.Context, ad *ads.Ad) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	old, ok := r.storage[ad.ID]
+	if !ok {
+		return ErrNoAd
+	}
+	if old.Version != ad.Version {
+		return ErrAdVersionConflict
+	}
+
+	ad.Version++
+	a := *ad
+	r.storage[ad.ID] = &a
+	r.index.Add(a.ID, a.Title, a.Text)
+	place(r.places, &a)
+
+	return nil
 }
 
 func (r *RepoMap) DeleteAd(_ context.Context, ID int64) error {
@@ -76,6 +120,81 @@ func (r *RepoMap) DeleteAd(_ context.Context, ID int64) error {
 	}
 
 	delete(r.storage, ID)
+	r.index.Remove(ID)
+	r.places.Remove(ID)
 
 	return nil
 }
+
+// matching отбирает копии подходящих под шаблон объявлений. Если в шаблоне задан полнотекстовый запрос,
+// отбираются только найденные по нему объявления и вторым значением возвращаются их оценки релевантности.
+// Если задан поиск по радиусу, кандидаты берутся из пространственного индекса, а в копиях заполняется Distance.
+func matching(storage map[int64]*ads.Ad, index *search.Index, places *geo.Index, p *ads.Pattern) ([]*ads.Ad, map[int64]float64) {
+	var scores, distances map[int64]float64
+	if p.Query != "" {
+		scores = index.Search(p.Query)
+	}
+	if p.Near != nil {
+		distances = places.Within(*p.Near)
+	}
+
+	var adverts []*ads.Ad
+	fits := func(a *ads.Ad) {
+		if _, ok := scores[a.ID]; scores != nil && !ok {
+			return
+		}
+		d, ok := distances[a.ID]
+		if distances != nil && !ok {
+			return
+		}
+		if !p.Fits(a) {
+			return
+		}
+
+		ad := *a
+		if distances != nil {
+			ad.Distance = &d
+		}
+		adverts = append(adverts, &ad)
+	}
+
+	// перебираем самый узкий из наборов кандидатов
+	switch {
+	case distances != nil && (scores == nil || len(distances) <= len(scores)):
+		for ID := range distances {
+			if a, ok := storage[ID]; ok {
+				fits(a)
+			}
+		}
+	case scores != nil:
+		for ID := range scores {
+			if a, ok := storage[ID]; ok {
+				fits(a)
+			}
+		}
+	default:
+		for _, a := range storage {
+			fits(a)
+		}
+	}
+
+	return adverts, scores
+}
+
+// place обновляет местоположение объявления в пространственном индексе
+func place(places *geo.Index, ad *ads.Ad) {
+	if ad.Location == nil {
+		places.Remove(ad.ID)
+		return
+	}
+	places.Add(ad.ID, ad.Location.Point)
+}
+
+func countByCategory(adverts []*ads.Ad) map[int64]int {
+	counts := make(map[int64]int)
+	for _, ad := range adverts {
+		counts[ad.CategoryID]++
+	}
+
+	return counts
+}
@@ -14,22 +14,27 @@ import (
 
 type RepoTestSuite struct {
 	suite.Suite
-	repo   ads.Repository
-	filled time.Time
+	repo    ads.Repository
+	newRepo func() ads.Repository
+	filled  time.Time
 }
 
 func (s *RepoTestSuite) SetupTest() {
-	s.repo = New()
+	s.repo = s.newRepo()
 	s.filled = time.Now()
 	for i := 0; i < 12; i++ {
+		status := ads.StatusDraft
+		if i%2 == 0 {
+			status = ads.StatusPublished
+		}
 		_, _ = s.repo.AddAd(context.Background(), &ads.Ad{
-			ID:        -1,
-			Title:     fmt.Sprintf("title in group %d", i%3),
-			Text:      fmt.Sprintf("%d ad text", i),
-			UserID:    int64(i / 4),
-			Published: i%2 == 0,
-			Created:   s.filled,
-			Updated:   s.filled,
+			ID:      -1,
+			Title:   fmt.Sprintf("title in group %d", i%3),
+			Text:    fmt.Sprintf("%d ad text", i),
+			UserID:  int64(i / 4),
+			Status:  status,
+			Created: s.filled,
+			Updated: s.filled,
 		})
 	}
 }
@@ -121,13 +126,13 @@ func (s *RepoTestSuite) TestAdByID() {
 				ID:  0,
 			},
 			want: &ads.Ad{
-				ID:        0,
-				Title:     fmt.Sprintf("title in group %d", 0%3),
-				Text:      fmt.Sprintf("%d ad text", 0),
-				UserID:    0,
-				Published: true,
-				Created:   s.filled,
-				Updated:   s.filled,
+				ID:      0,
+				Title:   fmt.Sprintf("title in group %d", 0%3),
+				Text:    fmt.Sprintf("%d ad text", 0),
+				UserID:  0,
+				Status:  ads.StatusPublished,
+				Created: s.filled,
+				Updated: s.filled,
 			},
 			wantErr: false,
 		},
@@ -138,13 +143,13 @@ func (s *RepoTestSuite) TestAdByID() {
 				ID:  5,
 			},
 			want: &ads.Ad{
-				ID:        5,
-				Title:     fmt.Sprintf("title in group %d", 5%3),
-				Text:      fmt.Sprintf("%d ad text", 5),
-				UserID:    1,
-				Published: false,
-				Created:   s.filled,
-				Updated:   s.filled,
+				ID:      5,
+				Title:   fmt.Sprintf("title in group %d", 5%3),
+				Text:    fmt.Sprintf("%d ad text", 5),
+				UserID:  1,
+				Status:  ads.StatusDraft,
+				Created: s.filled,
+				Updated: s.filled,
 			},
 			wantErr: false,
 		},
@@ -155,13 +160,13 @@ func (s *RepoTestSuite) TestAdByID() {
 				ID:  11,
 			},
 			want: &ads.Ad{
-				ID:        11,
-				Title:     fmt.Sprintf("title in group %d", 11%3),
-				Text:      fmt.Sprintf("%d ad text", 11),
-				UserID:    2,
-				Published: false,
-				Created:   s.filled,
-				Updated:   s.filled,
+				ID:      11,
+				Title:   fmt.Sprintf("title in group %d", 11%3),
+				Text:    fmt.Sprintf("%d ad text", 11),
+				UserID:  2,
+				Status:  ads.StatusDraft,
+				Created: s.filled,
+				Updated: s.filled,
 			},
 			wantErr: false,
 		},
@@ -189,7 +194,7 @@ func (s *RepoTestSuite) TestAdByID() {
 				assert.Equal(t, tt.want.Title, ad.Title)
 				assert.Equal(t, tt.want.Text, ad.Text)
 				assert.Equal(t, tt.want.UserID, ad.UserID)
-				assert.Equal(t, tt.want.Published, ad.Published)
+				assert.Equal(t, tt.want.Status, ad.Status)
 			}
 		})
 	}
@@ -212,31 +217,29 @@ func (s *RepoTestSuite) TestAdsByPattern() {
 			args: args{
 				ctx: context.Background(),
 				pat: ads.DefaultPattern().
-					SetTitleFits(func(title string) bool {
-						return title == "title in group 1"
-					}).
-					SetUserIDFits(func(userID int64) bool {
-						return userID == 1
-					}),
+					Where(&ads.Eq{Field: ads.FieldTitle, Value: ads.StringValue("title in group 1")}).
+					Where(&ads.Eq{Field: ads.FieldUserID, Value: ads.IntValue(1)}),
 			},
 			want: []*ads.Ad{
 				{
-					ID:        4,
-					Title:     fmt.Sprintf("title in group %d", 4%3),
-					Text:      fmt.Sprintf("%d ad text", 4),
-					UserID:    1,
-					Published: true,
-					Created:   s.filled,
-					Updated:   s.filled,
+					ID:      4,
+					Title:   fmt.Sprintf("title in group %d", 4%3),
+					Text:    fmt.Sprintf("%d ad text", 4),
+					UserID:  1,
+					Status:  ads.StatusPublished,
+					Created: s.filled,
+					Updated: s.filled,
+					Version: 1,
 				},
 				{
-					ID:        7,
-					Title:     fmt.Sprintf("title in group %d", 7%3),
-					Text:      fmt.Sprintf("%d ad text", 7),
-					UserID:    1,
-					Published: false,
-					Created:   s.filled,
-					Updated:   s.filled,
+					ID:      7,
+					Title:   fmt.Sprintf("title in group %d", 7%3),
+					Text:    fmt.Sprintf("%d ad text", 7),
+					UserID:  1,
+					Status:  ads.StatusDraft,
+					Created: s.filled,
+					Updated: s.filled,
+					Version: 1,
 				},
 			},
 			wantErr: false,
@@ -246,69 +249,69 @@ func (s *RepoTestSuite) TestAdsByPattern() {
 			args: args{
 				ctx: context.Background(),
 				pat: ads.DefaultPattern().
-					SetPublishedFits(func(published bool) bool {
-						return published
-					}).
-					SetCreatedFits(func(created time.Time) bool {
-						pY, pM, pD := s.filled.UTC().Date()
-						y, m, d := created.UTC().Date()
-						return y == pY && m == pM && d == pD
-					}),
+					Where(&ads.Eq{Field: ads.FieldPublished, Value: ads.BoolValue(true)}).
+					Where(&ads.Eq{Field: ads.FieldCreated, Value: ads.DateValue(s.filled)}),
 			},
 			want: []*ads.Ad{
 				{
-					ID:        0,
-					Title:     fmt.Sprintf("title in group %d", 0%3),
-					Text:      fmt.Sprintf("%d ad text", 0),
-					UserID:    0,
-					Published: true,
-					Created:   s.filled,
-					Updated:   s.filled,
+					ID:      0,
+					Title:   fmt.Sprintf("title in group %d", 0%3),
+					Text:    fmt.Sprintf("%d ad text", 0),
+					UserID:  0,
+					Status:  ads.StatusPublished,
+					Created: s.filled,
+					Updated: s.filled,
+					Version: 1,
 				},
 				{
-					ID:        2,
-					Title:     fmt.Sprintf("title in group %d", 2%3),
-					Text:      fmt.Sprintf("%d ad text", 2),
-					UserID:    0,
-					Published: true,
-					Created:   s.filled,
-					Updated:   s.filled,
+					ID:      2,
+					Title:   fmt.Sprintf("title in group %d", 2%3),
+					Text:    fmt.Sprintf("%d ad text", 2),
+					UserID:  0,
+					Status:  ads.StatusPublished,
+					Created: s.filled,
+					Updated: s.filled,
+					Version: 1,
 				},
 				{
-					ID:        4,
-					Title:     fmt.Sprintf("title in group %d", 4%3),
-					Text:      fmt.Sprintf("%d ad text", 4),
-					UserID:    1,
-					Published: true,
-					Created:   s.filled,
-					Updated:   s.filled,
+					ID:      4,
+					Title:   fmt.Sprintf("title in group %d", 4%3),
+					Text:    fmt.Sprintf("%d ad text", 4),
+					UserID:  1,
+					Status:  ads.StatusPublished,
+					Created: s.filled,
+					Updated: s.filled,
+					Version: 1,
 				},
 				{
-					ID:        6,
-					Title:     fmt.Sprintf("title in group %d", 6%3),
-					Text:      fmt.Sprintf("%d ad text", 6),
-					UserID:    1,
-					Published: true,
-					Created:   s.filled,
-					Updated:   s.filled,
+					ID:      6,
+					Title:   fmt.Sprintf("title in group %d", 6%3),
+					Text:    fmt.Sprintf("%d ad text", 6),
+					UserID:  1,
+					Status:  ads.StatusPublished,
+					Created: s.filled,
+					Updated: s.filled,
+					Version: 1,
 				},
 				{
-					ID:        8,
-					Title:     fmt.Sprintf("title in group %d", 8%3),
-					Text:      fmt.Sprintf("%d ad text", 8),
-					UserID:    2,
-					Published: true,
-					Created:   s.filled,
-					Updated:   s.filled,
+					ID:      8,
+					Title:   fmt.Sprintf("title in group %d", 8%3),
+					Text:    fmt.Sprintf("%d ad text", 8),
+					UserID:  2,
+					Status:  ads.StatusPublished,
+					Created: s.filled,
+					Updated: s.filled,
+					Version: 1,
 				},
 				{
-					ID:        10,
-					Title:     fmt.Sprintf("title in group %d", 10%3),
-					Text:      fmt.Sprintf("%d ad text", 10),
-					UserID:    2,
-					Published: true,
-					Created:   s.filled,
-					Updated:   s.filled,
+					ID:      10,
+					Title:   fmt.Sprintf("title in group %d", 10%3),
+					Text:    fmt.Sprintf("%d ad text", 10),
+					UserID:  2,
+					Status:  ads.StatusPublished,
+					Created: s.filled,
+					Updated: s.filled,
+					Version: 1,
 				},
 			},
 			wantErr: false,
@@ -318,51 +321,49 @@ func (s *RepoTestSuite) TestAdsByPattern() {
 			args: args{
 				ctx: context.Background(),
 				pat: ads.DefaultPattern().
-					SetUserIDFits(func(userID int64) bool {
-						return userID == 2
-					}).
-					SetUpdatedFits(func(updated time.Time) bool {
-						pY, pM, pD := s.filled.UTC().Date()
-						y, m, d := updated.UTC().Date()
-						return y == pY && m == pM && d == pD
-					}),
+					Where(&ads.Eq{Field: ads.FieldUserID, Value: ads.IntValue(2)}).
+					Where(&ads.Eq{Field: ads.FieldUpdated, Value: ads.DateValue(s.filled)}),
 			},
 			want: []*ads.Ad{
 				{
-					ID:        8,
-					Title:     fmt.Sprintf("title in group %d", 8%3),
-					Text:      fmt.Sprintf("%d ad text", 8),
-					UserID:    2,
-					Published: true,
-					Created:   s.filled,
-					Updated:   s.filled,
+					ID:      8,
+					Title:   fmt.Sprintf("title in group %d", 8%3),
+					Text:    fmt.Sprintf("%d ad text", 8),
+					UserID:  2,
+					Status:  ads.StatusPublished,
+					Created: s.filled,
+					Updated: s.filled,
+					Version: 1,
 				},
 				{
-					ID:        9,
-					Title:     fmt.Sprintf("title in group %d", 9%3),
-					Text:      fmt.Sprintf("%d ad text", 9),
-					UserID:    2,
-					Published: false,
-					Created:   s.filled,
-					Updated:   s.filled,
+					ID:      9,
+					Title:   fmt.Sprintf("title in group %d", 9%3),
+					Text:    fmt.Sprintf("%d ad text", 9),
+					UserID:  2,
+					Status:  ads.StatusDraft,
+					Created: s.filled,
+					Updated: s.filled,
+					Version: 1,
 				},
 				{
-					ID:        10,
-					Title:     fmt.Sprintf("title in group %d", 10%3),
-					Text:      fmt.Sprintf("%d ad text", 10),
-					UserID:    2,
-					Published: true,
-					Created:   s.filled,
-					Updated:   s.filled,
+					ID:      10,
+					Title:   fmt.Sprintf("title in group %d", 10%3),
+					Text:    fmt.Sprintf("%d ad text", 10),
+					UserID:  2,
+					Status:  ads.StatusPublished,
+					Created: s.filled,
+					Updated: s.filled,
+					Version: 1,
 				},
 				{
-					ID:        11,
-					Title:     fmt.Sprintf("title in group %d", 11%3),
-					Text:      fmt.Sprintf("%d ad text", 11),
-					UserID:    2,
-					Published: false,
-					Created:   s.filled,
-					Updated:   s.filled,
+					ID:      11,
+					Title:   fmt.Sprintf("title in group %d", 11%3),
+					Text:    fmt.Sprintf("%d ad text", 11),
+					UserID:  2,
+					Status:  ads.StatusDraft,
+					Created: s.filled,
+					Updated: s.filled,
+					Version: 1,
 				},
 			},
 			wantErr: false,
@@ -371,7 +372,7 @@ func (s *RepoTestSuite) TestAdsByPattern() {
 
 	for _, tt := range tests {
 		s.T().Run(tt.name, func(t *testing.T) {
-			adverts, err := s.repo.AdsByPattern(tt.args.ctx, tt.args.pat)
+			adverts, _, err := s.repo.AdsByPattern(tt.args.ctx, tt.args.pat, ads.DefaultPage())
 			assert.Len(t, adverts, len(tt.want))
 			if tt.wantErr {
 				assert.ErrorIs(t, err, tt.err)
@@ -384,6 +385,225 @@ func (s *RepoTestSuite) TestAdsByPattern() {
 	}
 }
 
+func (s *RepoTestSuite) TestUpdateAd() {
+	type args struct {
+		ctx context.Context
+		ad  *ads.Ad
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantVersion int64
+		wantErr     bool
+		err         error
+	}{
+		{
+			name: "ok update ad with ID=2",
+			args: args{
+				ctx: context.Background(),
+				ad:  &ads.Ad{ID: 2, Title: "new title", Text: "new text", Version: 1},
+			},
+			wantVersion: 2,
+			wantErr:     false,
+		},
+		{
+			name: "wrong update ad with ID=2 (stale version)",
+			args: args{
+				ctx: context.Background(),
+				ad:  &ads.Ad{ID: 2, Title: "other title", Text: "other text", Version: 1},
+			},
+			wantErr: true,
+			err:     ErrAdVersionConflict,
+		},
+		{
+			name: "wrong update ad with ID=100",
+			args: args{
+				ctx: context.Background(),
+				ad:  &ads.Ad{ID: 100, Version: 1},
+			},
+			wantErr: true,
+			err:     ErrNoAd,
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			err := s.repo.UpdateAd(tt.args.ctx, tt.args.ad)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantVersion, tt.args.ad.Version)
+
+				ad, err := s.repo.AdByID(tt.args.ctx, tt.args.ad.ID)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.args.ad, ad)
+			}
+		})
+	}
+}
+
+func (s *RepoTestSuite) TestAdByID_ReturnsCopy() {
+	ad, err := s.repo.AdByID(context.Background(), 0)
+	assert.NoError(s.T(), err)
+
+	ad.Title = "changed outside the repo"
+
+	ad, err = s.repo.AdByID(context.Background(), 0)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "title in group 0", ad.Title)
+}
+
+func (s *RepoTestSuite) TestAdsByPattern_Query() {
+	ctx := context.Background()
+	for _, ad := range []*ads.Ad{
+		{ID: -1, Title: "Продам горный велосипед", Text: "Почти новый", UserID: 5, Status: ads.StatusPublished},
+		{ID: -1, Title: "Велосипед детский", Text: "Велосипед в хорошем состоянии", UserID: 5, Status: ads.StatusPublished},
+		{ID: -1, Title: "Mountain bike", Text: "Горные велосипеды и запчасти", UserID: 6},
+	} {
+		_, err := s.repo.AddAd(ctx, ad)
+		assert.NoError(s.T(), err)
+	}
+
+	adverts, next, err := s.repo.AdsByPattern(ctx, ads.DefaultPattern().SetQuery("велосипеды"), ads.Page{})
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), next)
+	if assert.Len(s.T(), adverts, 3) {
+		// в детском велосипеде слово встречается чаще всего
+		assert.Equal(s.T(), int64(13), adverts[0].ID)
+	}
+
+	p := ads.DefaultPattern().SetQuery("велосипеды").Where(&ads.Eq{Field: ads.FieldPublished, Value: ads.BoolValue(true)})
+	adverts, _, err = s.repo.AdsByPattern(ctx, p, ads.Page{Sort: ads.Sort{Field: ads.SortByTitle}})
+	assert.NoError(s.T(), err)
+	if assert.Len(s.T(), adverts, 2) {
+		assert.Equal(s.T(), int64(13), adverts[0].ID)
+		assert.Equal(s.T(), int64(12), adverts[1].ID)
+	}
+
+	ad, err := s.repo.AdByID(ctx, 14)
+	assert.NoError(s.T(), err)
+	ad.Text = "Only the frame"
+	assert.NoError(s.T(), s.repo.UpdateAd(ctx, ad))
+	assert.NoError(s.T(), s.repo.DeleteAd(ctx, 13))
+
+	adverts, _, err = s.repo.AdsByPattern(ctx, ads.DefaultPattern().SetQuery("велосипед"), ads.Page{})
+	assert.NoError(s.T(), err)
+	if assert.Len(s.T(), adverts, 1) {
+		assert.Equal(s.T(), int64(12), adverts[0].ID)
+	}
+
+	adverts, _, err = s.repo.AdsByPattern(ctx, ads.DefaultPattern().SetQuery("frames"), ads.Page{})
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), adverts, 1)
+
+	adverts, _, err = s.repo.AdsByPattern(ctx, ads.DefaultPattern().SetQuery("самокат"), ads.Page{})
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), adverts)
+}
+
+func (s *RepoTestSuite) TestCountByCategory() {
+	ctx := context.Background()
+	for _, ad := range []*ads.Ad{
+		{ID: -1, Title: "Продам телефон", Text: "Почти новый", UserID: 5, CategoryID: 2, Status: ads.StatusPublished},
+		{ID: -1, Title: "Продам ноутбук", Text: "С зарядкой", UserID: 5, CategoryID: 3, Status: ads.StatusPublished},
+		{ID: -1, Title: "Куплю телефон", Text: "Недорого", UserID: 6, CategoryID: 2},
+	} {
+		_, err := s.repo.AddAd(ctx, ad)
+		assert.NoError(s.T(), err)
+	}
+
+	counts, err := s.repo.CountByCategory(ctx, ads.DefaultPattern())
+	assert.NoError(s.T(), err)
+	// объявления из SetupTest созданы без категории
+	assert.Equal(s.T(), map[int64]int{0: 12, 2: 2, 3: 1}, counts)
+
+	p := ads.DefaultPattern().Where(&ads.Eq{Field: ads.FieldPublished, Value: ads.BoolValue(true)}).SetQuery("продам")
+	counts, err = s.repo.CountByCategory(ctx, p)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), map[int64]int{2: 1, 3: 1}, counts)
+
+	counts, err = s.repo.CountByCategory(ctx, ads.DefaultPattern().SetCategory(2))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), map[int64]int{2: 2}, counts)
+}
+
+func (s *RepoTestSuite) TestAdsByPattern_Near() {
+	ctx := context.Background()
+	moscow := &ads.Location{Point: ads.Point{Lat: 55.7558, Lon: 37.6173}, City: "Москва"}
+	khimki := &ads.Location{Point: ads.Point{Lat: 55.8970, Lon: 37.4297}, City: "Химки"}
+	tver := &ads.Location{Point: ads.Point{Lat: 56.8587, Lon: 35.9176}, City: "Тверь"}
+	for _, loc := range []*ads.Location{tver, khimki, moscow} {
+		_, err := s.repo.AddAd(ctx, &ads.Ad{ID: -1, Title: "Продам велосипед", Text: "Почти новый", Location: loc})
+		assert.NoError(s.T(), err)
+	}
+
+	// центр Москвы: Химки в ~20 км, Тверь в ~160 км
+	near, err := ads.NewCircle(55.7539, 37.6208, 50)
+	assert.NoError(s.T(), err)
+	page := ads.Page{Limit: 10, Sort: ads.Sort{Field: ads.SortByDistance}}
+	adverts, _, err := s.repo.AdsByPattern(ctx, ads.DefaultPattern().SetNear(near), page)
+	assert.NoError(s.T(), err)
+	if assert.Len(s.T(), adverts, 2) {
+		assert.Equal(s.T(), []int64{14, 13}, []int64{adverts[0].ID, adverts[1].ID})
+		assert.InDelta(s.T(), 0.3, *adverts[0].Distance, 0.1)
+		assert.InDelta(s.T(), 19, *adverts[1].Distance, 1)
+	}
+
+	// курсор при сортировке по расстоянию
+	far, err := ads.NewCircle(55.7539, 37.6208, 300)
+	assert.NoError(s.T(), err)
+	p := ads.DefaultPattern().SetNear(far).SetQuery("велосипед")
+	first, next, err := s.repo.AdsByPattern(ctx, p, ads.Page{Limit: 2, Sort: ads.Sort{Field: ads.SortByDistance}})
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), first, 2)
+	rest, _, err := s.repo.AdsByPattern(ctx, p, ads.Page{Limit: 2, Sort: ads.Sort{Field: ads.SortByDistance}, Cursor: next})
+	assert.NoError(s.T(), err)
+	if assert.Len(s.T(), rest, 1) {
+		assert.Equal(s.T(), int64(12), rest[0].ID)
+	}
+
+	// хранилище отдаёт копии: расстояние не попадает в сохранённое объявление
+	ad, err := s.repo.AdByID(ctx, 14)
+	assert.NoError(s.T(), err)
+	assert.Nil(s.T(), ad.Distance)
+
+	// перенос объявления и удаление местоположения обновляют индекс
+	ad.Location = tver
+	assert.NoError(s.T(), s.repo.UpdateAd(ctx, ad))
+	ad, err = s.repo.AdByID(ctx, 13)
+	assert.NoError(s.T(), err)
+	ad.Location = nil
+	assert.NoError(s.T(), s.repo.UpdateAd(ctx, ad))
+	adverts, _, err = s.repo.AdsByPattern(ctx, ads.DefaultPattern().SetNear(near), page)
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), adverts)
+
+	assert.NoError(s.T(), s.repo.DeleteAd(ctx, 12))
+	adverts, _, err = s.repo.AdsByPattern(ctx, ads.DefaultPattern().SetNear(far), page)
+	assert.NoError(s.T(), err)
+	if assert.Len(s.T(), adverts, 1) {
+		assert.Equal(s.T(), int64(14), adverts[0].ID)
+	}
+}
+
+func (s *RepoTestSuite) TestAddAd_AfterDelete() {
+	ctx := context.Background()
+	assert.NoError(s.T(), s.repo.DeleteAd(ctx, 3))
+
+	// ID удалённого объявления не достаётся новому, и живые объявления не перезаписываются
+	id, err := s.repo.AddAd(ctx, &ads.Ad{ID: -1, Title: "new title", Text: "new text", UserID: 7})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(12), id)
+
+	ad, err := s.repo.AdByID(ctx, 11)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "11 ad text", ad.Text)
+	assert.Equal(s.T(), int64(2), ad.UserID)
+	_, err = s.repo.AdByID(ctx, 3)
+	assert.ErrorIs(s.T(), err, ErrNoAd)
+}
+
 func (s *RepoTestSuite) TestDeleteAd() {
 	type args struct {
 		ctx context.Context
@@ -446,5 +666,5 @@ func (s *RepoTestSuite) TestDeleteAd() {
 }
 
 func TestRepoTestSuite(t *testing.T) {
-	suite.Run(t, new(RepoTestSuite))
+	suite.Run(t, &RepoTestSuite{newRepo: New})
 }
@@ -0,0 +1,129 @@
+package auditrepo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"homework10/internal/adapters/wal"
+	"homework10/internal/audit"
+)
+
+const opAppend = "append"
+
+// RepoFile - журнал аудита, переживающий перезапуск сервиса:
+// записи хранятся в памяти, каждая новая пишется в WAL, периодически делается снимок
+type RepoFile struct {
+	store  store
+	log    *wal.Log
+	logger *slog.Logger
+	m      sync.RWMutex
+}
+
+type fileState struct {
+	Entries []*audit.Entry `json:"entries"`
+}
+
+func NewFile(dir string, snapshotEvery int, logger *slog.Logger) (*RepoFile, error) {
+	l, err := wal.Open(dir, snapshotEvery)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &RepoFile{
+		store:  newStore(),
+		log:    l,
+		logger: logger,
+		m:      sync.RWMutex{},
+	}
+
+	if err = l.Recover(r.restore, r.apply); err != nil {
+		_ = l.Close()
+		return nil, fmt.Errorf("recover audit repo: %w", err)
+	}
+
+	return r, nil
+}
+
+func (r *RepoFile) Append(ctx context.Context, e *audit.Entry) (int64, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	cp := *e
+	r.store.number(&cp)
+	if err := r.log.Append(opAppend, &cp); err != nil {
+		return -1, err
+	}
+
+	e.ID, e.Revision = cp.ID, cp.Revision
+	r.store.add(&cp)
+	r.snapshotIfNeeded(ctx)
+
+	return e.ID, nil
+}
+
+func (r *RepoFile) History(_ context.Context, kind audit.Kind, objectID int64) ([]*audit.Entry, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.history(kind, objectID), nil
+}
+
+// Close сохраняет итоговый снимок состояния и закрывает журнал
+func (r *RepoFile) Close() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if err := r.log.Snapshot(r.state()); err != nil {
+		_ = r.log.Close()
+		return err
+	}
+
+	return r.log.Close()
+}
+
+// snapshotIfNeeded не возвращает ошибку: запись уже сохранена в WAL,
+// а неудавшийся снимок будет повторён при следующем изменении
+func (r *RepoFile) snapshotIfNeeded(ctx context.Context) {
+	if !r.log.NeedSnapshot() {
+		return
+	}
+
+	if err := r.log.Snapshot(r.state()); err != nil {
+		r.logger.ErrorContext(ctx, "can't snapshot audit repo", "err", err)
+	}
+}
+
+func (r *RepoFile) state() fileState {
+	return fileState{Entries: r.store.entries}
+}
+
+func (r *RepoFile) restore(data []byte) error {
+	var s fileState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	for _, e := range s.Entries {
+		r.store.add(e)
+	}
+
+	return nil
+}
+
+func (r *RepoFile) apply(rec wal.Record) error {
+	switch rec.Op {
+	case opAppend:
+		var e audit.Entry
+		if err := json.Unmarshal(rec.Data, &e); err != nil {
+			return err
+		}
+		r.store.add(&e)
+	default:
+		return fmt.Errorf("%w: unknown op %q", wal.ErrCorrupted, rec.Op)
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package auditrepo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"homework10/internal/ads"
+	"homework10/internal/audit"
+	"homework10/internal/logging"
+)
+
+func TestRepoFileTestSuite(t *testing.T) {
+	suite.Run(t, &RepoTestSuite{newRepo: func() audit.Repository {
+		r, err := NewFile(t.TempDir(), 2, logging.Discard())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			_ = r.Close()
+		})
+		return r
+	}})
+}
+
+func TestRepoFile_Reopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	r, err := NewFile(dir, 2, logging.Discard())
+	assert.NoError(t, err)
+	location := &ads.Location{Point: ads.Point{Lat: 55.75, Lon: 37.62}, City: "Москва"}
+	before := &ads.Ad{ID: 3, Title: "title", Text: "text", Status: ads.StatusPublished, Location: location, Price: ads.Price{Amount: 100, Currency: "RUB"}}
+	for _, e := range []*audit.Entry{
+		{Kind: audit.KindAd, ObjectID: 3, ActorID: 1, Op: audit.OpCreate, At: started, After: audit.AdSnapshot(before)},
+		{Kind: audit.KindAd, ObjectID: 3, ActorID: 1, Op: audit.OpDelete, At: started, Before: audit.AdSnapshot(before)},
+		{Kind: audit.KindAd, ObjectID: 4, ActorID: 1, Op: audit.OpCreate, At: started},
+	} {
+		_, err = r.Append(ctx, e)
+		assert.NoError(t, err)
+	}
+
+	// без Close: состояние восстанавливается из снимка и хвоста журнала
+	r2, err := NewFile(dir, 2, logging.Discard())
+	assert.NoError(t, err)
+
+	list, err := r2.History(ctx, audit.KindAd, 3)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, before, list[0].After.Ad)
+		assert.True(t, started.Equal(list[1].At))
+		assert.True(t, list[1].After.Empty())
+	}
+
+	e := &audit.Entry{Kind: audit.KindAd, ObjectID: 4, ActorID: 1, Op: audit.OpUpdate, At: started}
+	ID, err := r2.Append(ctx, e)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), ID)
+	assert.Equal(t, int64(2), e.Revision)
+	assert.NoError(t, r2.Close())
+}
@@ -0,0 +1,86 @@
+package auditrepo
+
+import (
+	"context"
+	"sync"
+
+	"homework10/internal/audit"
+)
+
+// RepoMap хранит журнал аудита в памяти и отдаёт наружу копии записей
+type RepoMap struct {
+	store store
+	m     sync.RWMutex
+}
+
+func New() audit.Repository {
+	return &RepoMap{
+		store: newStore(),
+		m:     sync.RWMutex{},
+	}
+}
+
+func (r *RepoMap) Append(_ context.Context, e *audit.Entry) (int64, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.store.number(e)
+	r.store.add(e)
+
+	return e.ID, nil
+}
+
+func (r *RepoMap) History(_ context.Context, kind audit.Kind, objectID int64) ([]*audit.Entry, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.history(kind, objectID), nil
+}
+
+// objectKey - записи журнала группируются по объектам
+type objectKey struct {
+	Kind     audit.Kind
+	ObjectID int64
+}
+
+// store - записи журнала в порядке добавления; ID записи - её номер в журнале, начиная с 1
+type store struct {
+	entries  []*audit.Entry
+	byObject map[objectKey][]*audit.Entry
+}
+
+func newStore() store {
+	return store{
+		byObject: make(map[objectKey][]*audit.Entry),
+	}
+}
+
+// number назначает записи ID и номер изменения объекта
+func (s *store) number(e *audit.Entry) {
+	e.ID = int64(len(s.entries)) + 1
+	e.Revision = int64(len(s.byObject[objectKey{Kind: e.Kind, ObjectID: e.ObjectID}])) + 1
+}
+
+// add сохраняет копию записи с уже назначенными ID и номером изменения
+func (s *store) add(e *audit.Entry) {
+	cp := copyEntry(e)
+	key := objectKey{Kind: cp.Kind, ObjectID: cp.ObjectID}
+	s.entries = append(s.entries, cp)
+	s.byObject[key] = append(s.byObject[key], cp)
+}
+
+func (s *store) history(kind audit.Kind, objectID int64) []*audit.Entry {
+	list := s.byObject[objectKey{Kind: kind, ObjectID: objectID}]
+	res := make([]*audit.Entry, 0, len(list))
+	for _, e := range list {
+		res = append(res, copyEntry(e))
+	}
+	return res
+}
+
+func copyEntry(e *audit.Entry) *audit.Entry {
+	cp := *e
+	cp.Before = e.Before.Copy()
+	cp.After = e.After.Copy()
+	return &cp
+}
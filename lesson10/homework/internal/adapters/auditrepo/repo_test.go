@@ -0,0 +1,99 @@
+package auditrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"homework10/internal/ads"
+	"homework10/internal/audit"
+	"homework10/internal/users"
+)
+
+var started = time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+
+type RepoTestSuite struct {
+	suite.Suite
+	repo    audit.Repository
+	newRepo func() audit.Repository
+}
+
+// SetupTest заполняет журнал: создание и правка объявления 0 пользователем 1 и создание пользователя 1
+func (s *RepoTestSuite) SetupTest() {
+	ctx := context.Background()
+	s.repo = s.newRepo()
+	first := &ads.Ad{ID: 0, Title: "title", Text: "text", UserID: 1}
+	second := &ads.Ad{ID: 0, Title: "new title", Text: "text", UserID: 1, Version: 1}
+	for i, e := range []audit.Entry{
+		{Kind: audit.KindAd, ObjectID: 0, ActorID: 1, Op: audit.OpCreate, After: audit.AdSnapshot(first)},
+		{Kind: audit.KindUser, ObjectID: 1, ActorID: 1, Op: audit.OpCreate, After: audit.UserSnapshot(&users.User{ID: 1, Nickname: "jenny"})},
+		{Kind: audit.KindAd, ObjectID: 0, ActorID: 1, Op: audit.OpUpdate, Before: audit.AdSnapshot(first), After: audit.AdSnapshot(second)},
+	} {
+		e := e
+		e.At = started.Add(time.Duration(i) * time.Minute)
+		_, _ = s.repo.Append(ctx, &e)
+	}
+}
+
+func (s *RepoTestSuite) TestAppend() {
+	ctx := context.Background()
+
+	e := &audit.Entry{Kind: audit.KindAd, ObjectID: 0, ActorID: ads.NoActor, Op: audit.OpExpire, At: started}
+	ID, err := s.repo.Append(ctx, e)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(4), ID)
+	assert.Equal(s.T(), int64(4), e.ID)
+	assert.Equal(s.T(), int64(3), e.Revision)
+
+	// номера изменений у каждого объекта свои, даже при совпадении ID объектов разных видов
+	e = &audit.Entry{Kind: audit.KindUser, ObjectID: 0, ActorID: 0, Op: audit.OpCreate, At: started}
+	ID, err = s.repo.Append(ctx, e)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(5), ID)
+	assert.Equal(s.T(), int64(1), e.Revision)
+}
+
+func (s *RepoTestSuite) TestHistory() {
+	ctx := context.Background()
+
+	list, err := s.repo.History(ctx, audit.KindAd, 0)
+	assert.NoError(s.T(), err)
+	if assert.Len(s.T(), list, 2) {
+		assert.Equal(s.T(), audit.OpCreate, list[0].Op)
+		assert.Equal(s.T(), int64(1), list[0].Revision)
+		assert.True(s.T(), list[0].Before.Empty())
+		assert.Equal(s.T(), "title", list[0].After.Ad.Title)
+
+		assert.Equal(s.T(), audit.OpUpdate, list[1].Op)
+		assert.Equal(s.T(), int64(3), list[1].ID)
+		assert.Equal(s.T(), int64(2), list[1].Revision)
+		assert.Equal(s.T(), "title", list[1].Before.Ad.Title)
+		assert.Equal(s.T(), "new title", list[1].After.Ad.Title)
+		assert.True(s.T(), started.Add(2*time.Minute).Equal(list[1].At))
+	}
+
+	list, err = s.repo.History(ctx, audit.KindAd, 100)
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), list)
+}
+
+func (s *RepoTestSuite) TestHistory_ReturnsCopies() {
+	ctx := context.Background()
+
+	list, err := s.repo.History(ctx, audit.KindAd, 0)
+	assert.NoError(s.T(), err)
+	list[0].Op = audit.OpDelete
+	list[0].After.Ad.Title = "changed"
+
+	list, err = s.repo.History(ctx, audit.KindAd, 0)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), audit.OpCreate, list[0].Op)
+	assert.Equal(s.T(), "title", list[0].After.Ad.Title)
+}
+
+func TestRepoTestSuite(t *testing.T) {
+	suite.Run(t, &RepoTestSuite{newRepo: New})
+}
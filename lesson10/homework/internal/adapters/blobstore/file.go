@@ -0,0 +1,130 @@
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"homework10/internal/images"
+)
+
+// StoreFile хранит файлы в каталоге: файл с ключом key лежит в dir/key[:2]/key,
+// а число ссылок на него - рядом, в key.refs
+type StoreFile struct {
+	dir string
+	m   sync.RWMutex
+}
+
+func NewFile(dir string) (*StoreFile, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &StoreFile{
+		dir: dir,
+		m:   sync.RWMutex{},
+	}, nil
+}
+
+func (s *StoreFile) path(key string) string {
+	return filepath.Join(s.dir, key[:2], key)
+}
+
+func (s *StoreFile) Put(_ context.Context, data []byte) (string, error) {
+	key := images.Key(data)
+	p := s.path(key)
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	refs, err := s.refs(p)
+	if err != nil {
+		return "", err
+	}
+
+	if refs == 0 {
+		if err = os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			return "", err
+		}
+		if err = writeFile(p, data); err != nil {
+			return "", err
+		}
+	}
+
+	if err = writeFile(p+".refs", []byte(strconv.Itoa(refs+1))); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+func (s *StoreFile) Get(_ context.Context, key string) ([]byte, error) {
+	if !images.ValidKey(key) {
+		return nil, ErrNoBlob
+	}
+
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNoBlob
+	}
+
+	return data, err
+}
+
+func (s *StoreFile) Release(_ context.Context, key string) error {
+	if !images.ValidKey(key) {
+		return ErrNoBlob
+	}
+	p := s.path(key)
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	refs, err := s.refs(p)
+	if err != nil {
+		return err
+	}
+	if refs == 0 {
+		return ErrNoBlob
+	}
+
+	if refs > 1 {
+		return writeFile(p+".refs", []byte(strconv.Itoa(refs-1)))
+	}
+
+	// сначала удаляется счётчик: файл без счётчика считается отсутствующим и будет перезаписан
+	if err = os.Remove(p + ".refs"); err != nil {
+		return err
+	}
+	return os.Remove(p)
+}
+
+// refs читает число ссылок на файл p, 0 - файла нет
+func (s *StoreFile) refs(p string) (int, error) {
+	data, err := os.ReadFile(p + ".refs")
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// writeFile атомарно заменяет содержимое файла: пишет во временный файл и переименовывает его
+func writeFile(p string, data []byte) error {
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, p)
+}
@@ -0,0 +1,73 @@
+package blobstore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"homework10/internal/images"
+)
+
+var ErrNoBlob = fmt.Errorf("blob does not exist")
+
+type blob struct {
+	data []byte
+	refs int
+}
+
+// StoreMap хранит файлы в памяти вместе с числом ссылок на них
+type StoreMap struct {
+	storage map[string]*blob
+	m       sync.RWMutex
+}
+
+func New() images.Store {
+	return &StoreMap{
+		storage: make(map[string]*blob),
+		m:       sync.RWMutex{},
+	}
+}
+
+func (s *StoreMap) Put(_ context.Context, data []byte) (string, error) {
+	key := images.Key(data)
+
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	if b, ok := s.storage[key]; ok {
+		b.refs++
+		return key, nil
+	}
+
+	s.storage[key] = &blob{data: append([]byte(nil), data...), refs: 1}
+	return key, nil
+}
+
+func (s *StoreMap) Get(_ context.Context, key string) ([]byte, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+
+	b, ok := s.storage[key]
+	if !ok {
+		return nil, ErrNoBlob
+	}
+
+	return append([]byte(nil), b.data...), nil
+}
+
+func (s *StoreMap) Release(_ context.Context, key string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	b, ok := s.storage[key]
+	if !ok {
+		return ErrNoBlob
+	}
+
+	b.refs--
+	if b.refs <= 0 {
+		delete(s.storage, key)
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package blobstore
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"homework10/internal/images"
+)
+
+type StoreTestSuite struct {
+	suite.Suite
+	newStore func() images.Store
+	store    images.Store
+}
+
+func (s *StoreTestSuite) SetupTest() {
+	s.store = s.newStore()
+}
+
+func (s *StoreTestSuite) TestPutGet() {
+	ctx := context.Background()
+
+	key, err := s.store.Put(ctx, []byte("photo"))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), images.Key([]byte("photo")), key)
+
+	data, err := s.store.Get(ctx, key)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []byte("photo"), data)
+
+	_, err = s.store.Get(ctx, images.Key([]byte("other")))
+	assert.ErrorIs(s.T(), err, ErrNoBlob)
+	_, err = s.store.Get(ctx, "../../secret")
+	assert.ErrorIs(s.T(), err, ErrNoBlob)
+}
+
+func (s *StoreTestSuite) TestRelease() {
+	ctx := context.Background()
+
+	// одинаковое содержимое хранится один раз, но удаляется только после освобождения всех ссылок
+	key, err := s.store.Put(ctx, []byte("photo"))
+	assert.NoError(s.T(), err)
+	again, err := s.store.Put(ctx, []byte("photo"))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), key, again)
+
+	assert.NoError(s.T(), s.store.Release(ctx, key))
+	_, err = s.store.Get(ctx, key)
+	assert.NoError(s.T(), err)
+
+	assert.NoError(s.T(), s.store.Release(ctx, key))
+	_, err = s.store.Get(ctx, key)
+	assert.ErrorIs(s.T(), err, ErrNoBlob)
+
+	assert.ErrorIs(s.T(), s.store.Release(ctx, key), ErrNoBlob)
+
+	// после удаления файл можно сохранить снова
+	_, err = s.store.Put(ctx, []byte("photo"))
+	assert.NoError(s.T(), err)
+	data, err := s.store.Get(ctx, key)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []byte("photo"), data)
+}
+
+func TestStoreTestSuite(t *testing.T) {
+	suite.Run(t, &StoreTestSuite{newStore: New})
+}
+
+func TestStoreFileTestSuite(t *testing.T) {
+	suite.Run(t, &StoreTestSuite{newStore: func() images.Store {
+		st, err := NewFile(t.TempDir())
+		assert.NoError(t, err)
+		return st
+	}})
+}
+
+func TestStoreFile_Reopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	st, err := NewFile(dir)
+	assert.NoError(t, err)
+	key, err := st.Put(ctx, []byte("photo"))
+	assert.NoError(t, err)
+	_, err = st.Put(ctx, []byte("photo"))
+	assert.NoError(t, err)
+
+	// число ссылок хранится вместе с файлом и переживает перезапуск
+	st, err = NewFile(dir)
+	assert.NoError(t, err)
+	assert.NoError(t, st.Release(ctx, key))
+	data, err := st.Get(ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("photo"), data)
+}
@@ -0,0 +1,217 @@
+package catrepo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"homework10/internal/adapters/wal"
+	"homework10/internal/categories"
+)
+
+const (
+	opAdd    = "add"
+	opUpdate = "update"
+	opDelete = "delete"
+)
+
+// RepoFile - репозиторий категорий, переживающий перезапуск сервиса (как и RepoMap, работает с копиями):
+// состояние хранится в памяти, каждое изменение пишется в WAL, периодически делается снимок
+type RepoFile struct {
+	storage map[int64]*categories.Category
+	nextID  int64
+	log     *wal.Log
+	logger  *slog.Logger
+	m       sync.RWMutex
+}
+
+type fileState struct {
+	NextID     int64                  `json:"next_id"`
+	Categories []*categories.Category `json:"categories"`
+}
+
+func NewFile(dir string, snapshotEvery int, logger *slog.Logger) (*RepoFile, error) {
+	l, err := wal.Open(dir, snapshotEvery)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &RepoFile{
+		storage: make(map[int64]*categories.Category),
+		nextID:  1,
+		log:     l,
+		logger:  logger,
+		m:       sync.RWMutex{},
+	}
+
+	if err = l.Recover(r.restore, r.apply); err != nil {
+		_ = l.Close()
+		return nil, fmt.Errorf("recover category repo: %w", err)
+	}
+
+	return r, nil
+}
+
+func (r *RepoFile) CategoryByID(_ context.Context, ID int64) (*categories.Category, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	c, ok := r.storage[ID]
+
+	if !ok {
+		return nil, ErrNoCategory
+	}
+
+	cp := *c
+	return &cp, nil
+}
+
+func (r *RepoFile) Categories(_ context.Context) ([]*categories.Category, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return copies(r.storage), nil
+}
+
+func (r *RepoFile) AddCategory(ctx context.Context, c *categories.Category) (int64, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	_, ok := r.storage[c.ID]
+	if ok {
+		return -1, ErrCategoryAlreadyExists
+	}
+
+	cp := *c
+	cp.ID = r.nextID
+	cp.Version = 1
+	if err := r.log.Append(opAdd, &cp); err != nil {
+		return -1, err
+	}
+
+	c.ID = cp.ID
+	c.Version = cp.Version
+	r.storage[cp.ID] = &cp
+	r.nextID++
+	r.snapshotIfNeeded(ctx)
+
+	return c.ID, nil
+}
+
+func (r *RepoFile) UpdateCategory(ctx context.Context, c *categories.Category) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	old, ok := r.storage[c.ID]
+	if !ok {
+		return ErrNoCategory
+	}
+	if old.Version != c.Version {
+		return ErrCategoryVersionConflict
+	}
+
+	cp := *c
+	cp.Version++
+	if err := r.log.Append(opUpdate, &cp); err != nil {
+		return err
+	}
+
+	c.Version = cp.Version
+	r.storage[cp.ID] = &cp
+	r.snapshotIfNeeded(ctx)
+
+	return nil
+}
+
+func (r *RepoFile) DeleteCategory(ctx context.Context, ID int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	_, ok := r.storage[ID]
+	if !ok {
+		return ErrNoCategory
+	}
+
+	if err := r.log.Append(opDelete, ID); err != nil {
+		return err
+	}
+
+	delete(r.storage, ID)
+	r.snapshotIfNeeded(ctx)
+
+	return nil
+}
+
+// Close сохраняет итоговый снимок состояния и закрывает журнал
+func (r *RepoFile) Close() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if err := r.log.Snapshot(r.state()); err != nil {
+		_ = r.log.Close()
+		return err
+	}
+
+	return r.log.Close()
+}
+
+// snapshotIfNeeded не возвращает ошибку: операция уже записана в журнал,
+// а неудавшийся снимок будет повторён при следующем изменении
+func (r *RepoFile) snapshotIfNeeded(ctx context.Context) {
+	if !r.log.NeedSnapshot() {
+		return
+	}
+
+	if err := r.log.Snapshot(r.state()); err != nil {
+		r.logger.ErrorContext(ctx, "can't snapshot category repo", "err", err)
+	}
+}
+
+func (r *RepoFile) state() fileState {
+	return fileState{NextID: r.nextID, Categories: copies(r.storage)}
+}
+
+func (r *RepoFile) restore(data []byte) error {
+	var s fileState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	r.nextID = s.NextID
+	for _, c := range s.Categories {
+		r.storage[c.ID] = c
+	}
+
+	return nil
+}
+
+func (r *RepoFile) apply(rec wal.Record) error {
+	switch rec.Op {
+	case opAdd:
+		var c categories.Category
+		if err := json.Unmarshal(rec.Data, &c); err != nil {
+			return err
+		}
+		r.storage[c.ID] = &c
+		if c.ID >= r.nextID {
+			r.nextID = c.ID + 1
+		}
+	case opUpdate:
+		var c categories.Category
+		if err := json.Unmarshal(rec.Data, &c); err != nil {
+			return err
+		}
+		r.storage[c.ID] = &c
+	case opDelete:
+		var ID int64
+		if err := json.Unmarshal(rec.Data, &ID); err != nil {
+			return err
+		}
+		delete(r.storage, ID)
+	default:
+		return fmt.Errorf("%w: unknown op %q", wal.ErrCorrupted, rec.Op)
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package catrepo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"homework10/internal/categories"
+	"homework10/internal/logging"
+)
+
+func TestRepoFileTestSuite(t *testing.T) {
+	suite.Run(t, &RepoTestSuite{newRepo: func() categories.Repository {
+		r, err := NewFile(t.TempDir(), 3, logging.Discard())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			_ = r.Close()
+		})
+		return r
+	}})
+}
+
+func TestRepoFile_Reopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	r, err := NewFile(dir, 2, logging.Discard())
+	assert.NoError(t, err)
+	for _, name := range []string{"Электроника", "Одежда", "Книги"} {
+		_, err = r.AddCategory(ctx, &categories.Category{Name: name})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, r.UpdateCategory(ctx, &categories.Category{ID: 3, ParentID: 1, Name: "Электронные книги", Version: 1}))
+	assert.NoError(t, r.DeleteCategory(ctx, 2))
+
+	r2, err := NewFile(dir, 2, logging.Discard())
+	assert.NoError(t, err)
+
+	_, err = r2.CategoryByID(ctx, 2)
+	assert.ErrorIs(t, err, ErrNoCategory)
+
+	c, err := r2.CategoryByID(ctx, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, &categories.Category{ID: 3, ParentID: 1, Name: "Электронные книги", Version: 2}, c)
+
+	id, err := r2.AddCategory(ctx, &categories.Category{Name: "Обувь"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), id)
+	assert.NoError(t, r2.Close())
+}
@@ -0,0 +1,114 @@
+package catrepo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"homework10/internal/categories"
+)
+
+var (
+	ErrNoCategory              = fmt.Errorf("category does not exist")
+	ErrCategoryAlreadyExists   = fmt.Errorf("category already exists")
+	ErrCategoryVersionConflict = fmt.Errorf("category version conflict")
+)
+
+// RepoMap хранит копии категорий и отдаёт наружу тоже копии,
+// поэтому любое изменение категории должно проходить через UpdateCategory
+type RepoMap struct {
+	storage map[int64]*categories.Category
+	nextID  int64
+	m       sync.RWMutex
+}
+
+func New() categories.Repository {
+	return &RepoMap{
+		storage: make(map[int64]*categories.Category),
+		nextID:  1,
+		m:       sync.RWMutex{},
+	}
+}
+
+func (r *RepoMap) CategoryByID(_ context.Context, ID int64) (*categories.Category, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	c, ok := r.storage[ID]
+
+	if !ok {
+		return nil, ErrNoCategory
+	}
+
+	cp := *c
+	return &cp, nil
+}
+
+func (r *RepoMap) Categories(_ context.Context) ([]*categories.Category, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return copies(r.storage), nil
+}
+
+func (r *RepoMap) AddCategory(_ context.Context, c *categories.Category) (int64, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	_, ok := r.storage[c.ID]
+	if ok {
+		return -1, ErrCategoryAlreadyExists
+	}
+
+	c.ID = r.nextID
+	c.Version = 1
+	cp := *c
+	r.storage[c.ID] = &cp
+	r.nextID++
+
+	return c.ID, nil
+}
+
+// UpdateCategory заменяет категорию, если её версия в хранилище совпадает с c.Version,
+// и увеличивает версию (в том числе у переданной c)
+func (r *RepoMap) UpdateCategory(_ context.Context, c *categories.Category) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	old, ok := r.storage[c.ID]
+	if !ok {
+		return ErrNoCategory
+	}
+	if old.Version != c.Version {
+		return ErrCategoryVersionConflict
+	}
+
+	c.Version++
+	cp := *c
+	r.storage[c.ID] = &cp
+
+	return nil
+}
+
+func (r *RepoMap) DeleteCategory(_ context.Context, ID int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	_, ok := r.storage[ID]
+	if !ok {
+		return ErrNoCategory
+	}
+
+	delete(r.storage, ID)
+
+	return nil
+}
+
+func copies(storage map[int64]*categories.Category) []*categories.Category {
+	res := make([]*categories.Category, 0, len(storage))
+	for _, c := range storage {
+		cp := *c
+		res = append(res, &cp)
+	}
+
+	return res
+}
@@ -0,0 +1,156 @@
+package catrepo
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"homework10/internal/categories"
+)
+
+type RepoTestSuite struct {
+	suite.Suite
+	repo    categories.Repository
+	newRepo func() categories.Repository
+}
+
+// SetupTest заполняет репозиторий деревом: 1 Электроника > 2 Телефоны, 3 Ноутбуки; 4 Одежда
+func (s *RepoTestSuite) SetupTest() {
+	s.repo = s.newRepo()
+	for _, c := range []categories.Category{
+		{Name: "Электроника"},
+		{Name: "Телефоны", ParentID: 1},
+		{Name: "Ноутбуки", ParentID: 1},
+		{Name: "Одежда"},
+	} {
+		c := c
+		_, _ = s.repo.AddCategory(context.Background(), &c)
+	}
+}
+
+func (s *RepoTestSuite) TestAddCategory() {
+	tests := []struct {
+		name    string
+		c       *categories.Category
+		want    int64
+		wantErr bool
+	}{
+		{
+			name: "ok add 5th category",
+			c:    &categories.Category{Name: "Планшеты", ParentID: 1},
+			want: 5,
+		},
+		{
+			name: "ok add 6th category",
+			c:    &categories.Category{Name: "Обувь", ParentID: 4},
+			want: 6,
+		},
+		{
+			name:    "wrong add category with existing ID=2",
+			c:       &categories.Category{ID: 2, Name: "Смартфоны"},
+			want:    -1,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			ID, err := s.repo.AddCategory(context.Background(), tt.c)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrCategoryAlreadyExists)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, int64(1), tt.c.Version)
+			}
+			assert.Equal(t, tt.want, ID)
+		})
+	}
+}
+
+func (s *RepoTestSuite) TestCategoryByID() {
+	c, err := s.repo.CategoryByID(context.Background(), 2)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), &categories.Category{ID: 2, ParentID: 1, Name: "Телефоны", Version: 1}, c)
+
+	// наружу отдаётся копия
+	c.Name = "Смартфоны"
+	c, err = s.repo.CategoryByID(context.Background(), 2)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "Телефоны", c.Name)
+
+	_, err = s.repo.CategoryByID(context.Background(), 0)
+	assert.ErrorIs(s.T(), err, ErrNoCategory)
+}
+
+func (s *RepoTestSuite) TestCategories() {
+	list, err := s.repo.Categories(context.Background())
+	assert.NoError(s.T(), err)
+
+	var names []string
+	for _, c := range list {
+		names = append(names, c.Name)
+	}
+	sort.Strings(names)
+	assert.Equal(s.T(), []string{"Ноутбуки", "Одежда", "Телефоны", "Электроника"}, names)
+}
+
+func (s *RepoTestSuite) TestUpdateCategory() {
+	tests := []struct {
+		name        string
+		c           *categories.Category
+		wantVersion int64
+		err         error
+	}{
+		{
+			name:        "ok move category with ID=3",
+			c:           &categories.Category{ID: 3, ParentID: 4, Name: "Ноутбуки", Version: 1},
+			wantVersion: 2,
+		},
+		{
+			name: "wrong update category with ID=3 (stale version)",
+			c:    &categories.Category{ID: 3, Name: "Компьютеры", Version: 1},
+			err:  ErrCategoryVersionConflict,
+		},
+		{
+			name: "wrong update category with ID=100",
+			c:    &categories.Category{ID: 100, Name: "Книги", Version: 1},
+			err:  ErrNoCategory,
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			err := s.repo.UpdateCategory(context.Background(), tt.c)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantVersion, tt.c.Version)
+			c, err := s.repo.CategoryByID(context.Background(), tt.c.ID)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.c, c)
+		})
+	}
+}
+
+func (s *RepoTestSuite) TestDeleteCategory() {
+	assert.NoError(s.T(), s.repo.DeleteCategory(context.Background(), 4))
+	_, err := s.repo.CategoryByID(context.Background(), 4)
+	assert.ErrorIs(s.T(), err, ErrNoCategory)
+
+	assert.ErrorIs(s.T(), s.repo.DeleteCategory(context.Background(), 4), ErrNoCategory)
+
+	// ID удалённых категорий не переиспользуются
+	ID, err := s.repo.AddCategory(context.Background(), &categories.Category{Name: "Книги"})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(5), ID)
+}
+
+func TestRepoTestSuite(t *testing.T) {
+	suite.Run(t, &RepoTestSuite{newRepo: New})
+}
@@ -0,0 +1,220 @@
+package favrepo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"homework10/internal/adapters/wal"
+	"homework10/internal/favorites"
+)
+
+const (
+	opAdd          = "add"
+	opDelete       = "delete"
+	opDeleteByAd   = "delete_by_ad"
+	opDeleteByUser = "delete_by_user"
+)
+
+// RepoFile - репозиторий избранного, переживающий перезапуск сервиса:
+// состояние хранится в памяти, каждое изменение пишется в WAL, периодически делается снимок
+type RepoFile struct {
+	set    set
+	log    *wal.Log
+	logger *slog.Logger
+	m      sync.RWMutex
+}
+
+type fileState struct {
+	Favorites []*favorites.Favorite `json:"favorites"`
+}
+
+// favoriteKey - запись журнала об удалении одного объявления из избранного
+type favoriteKey struct {
+	UserID int64 `json:"user_id"`
+	AdID   int64 `json:"ad_id"`
+}
+
+func NewFile(dir string, snapshotEvery int, logger *slog.Logger) (*RepoFile, error) {
+	l, err := wal.Open(dir, snapshotEvery)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &RepoFile{
+		set:    newSet(),
+		log:    l,
+		logger: logger,
+		m:      sync.RWMutex{},
+	}
+
+	if err = l.Recover(r.restore, r.apply); err != nil {
+		_ = l.Close()
+		return nil, fmt.Errorf("recover favorite repo: %w", err)
+	}
+
+	return r, nil
+}
+
+func (r *RepoFile) AddFavorite(ctx context.Context, f *favorites.Favorite) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.set.has(f.UserID, f.AdID) {
+		return ErrFavoriteAlreadyExists
+	}
+
+	if err := r.log.Append(opAdd, f); err != nil {
+		return err
+	}
+
+	r.set.add(f)
+	r.snapshotIfNeeded(ctx)
+
+	return nil
+}
+
+func (r *RepoFile) DeleteFavorite(ctx context.Context, userID, adID int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if !r.set.has(userID, adID) {
+		return ErrNoFavorite
+	}
+
+	if err := r.log.Append(opDelete, favoriteKey{UserID: userID, AdID: adID}); err != nil {
+		return err
+	}
+
+	r.set.delete(userID, adID)
+	r.snapshotIfNeeded(ctx)
+
+	return nil
+}
+
+func (r *RepoFile) Favorites(_ context.Context, userID int64) ([]*favorites.Favorite, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.set.list(userID), nil
+}
+
+func (r *RepoFile) CountByAds(_ context.Context, adIDs []int64) (map[int64]int, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.set.counts(adIDs), nil
+}
+
+func (r *RepoFile) DeleteByAd(ctx context.Context, adID int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.set.count[adID] == 0 {
+		return nil
+	}
+
+	if err := r.log.Append(opDeleteByAd, adID); err != nil {
+		return err
+	}
+
+	r.set.deleteAd(adID)
+	r.snapshotIfNeeded(ctx)
+
+	return nil
+}
+
+func (r *RepoFile) DeleteByUser(ctx context.Context, userID int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if len(r.set.byUser[userID]) == 0 {
+		return nil
+	}
+
+	if err := r.log.Append(opDeleteByUser, userID); err != nil {
+		return err
+	}
+
+	r.set.deleteUser(userID)
+	r.snapshotIfNeeded(ctx)
+
+	return nil
+}
+
+// Close сохраняет итоговый снимок состояния и закрывает журнал
+func (r *RepoFile) Close() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if err := r.log.Snapshot(r.state()); err != nil {
+		_ = r.log.Close()
+		return err
+	}
+
+	return r.log.Close()
+}
+
+// snapshotIfNeeded не возвращает ошибку: операция уже записана в журнал,
+// а неудавшийся снимок будет повторён при следующем изменении
+func (r *RepoFile) snapshotIfNeeded(ctx context.Context) {
+	if !r.log.NeedSnapshot() {
+		return
+	}
+
+	if err := r.log.Snapshot(r.state()); err != nil {
+		r.logger.ErrorContext(ctx, "can't snapshot favorite repo", "err", err)
+	}
+}
+
+func (r *RepoFile) state() fileState {
+	return fileState{Favorites: r.set.all()}
+}
+
+func (r *RepoFile) restore(data []byte) error {
+	var s fileState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	for _, f := range s.Favorites {
+		r.set.add(f)
+	}
+
+	return nil
+}
+
+func (r *RepoFile) apply(rec wal.Record) error {
+	switch rec.Op {
+	case opAdd:
+		var f favorites.Favorite
+		if err := json.Unmarshal(rec.Data, &f); err != nil {
+			return err
+		}
+		r.set.add(&f)
+	case opDelete:
+		var k favoriteKey
+		if err := json.Unmarshal(rec.Data, &k); err != nil {
+			return err
+		}
+		r.set.delete(k.UserID, k.AdID)
+	case opDeleteByAd:
+		var adID int64
+		if err := json.Unmarshal(rec.Data, &adID); err != nil {
+			return err
+		}
+		r.set.deleteAd(adID)
+	case opDeleteByUser:
+		var userID int64
+		if err := json.Unmarshal(rec.Data, &userID); err != nil {
+			return err
+		}
+		r.set.deleteUser(userID)
+	default:
+		return fmt.Errorf("%w: unknown op %q", wal.ErrCorrupted, rec.Op)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package favrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"homework10/internal/favorites"
+	"homework10/internal/logging"
+)
+
+func TestRepoFileTestSuite(t *testing.T) {
+	suite.Run(t, &RepoTestSuite{newRepo: func() favorites.Repository {
+		r, err := NewFile(t.TempDir(), 3, logging.Discard())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			_ = r.Close()
+		})
+		return r
+	}})
+}
+
+func TestRepoFile_Reopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	r, err := NewFile(dir, 2, logging.Discard())
+	assert.NoError(t, err)
+	for i, f := range []favorites.Favorite{
+		{UserID: 1, AdID: 10},
+		{UserID: 1, AdID: 11},
+		{UserID: 2, AdID: 10},
+		{UserID: 2, AdID: 12},
+		{UserID: 3, AdID: 12},
+	} {
+		f := f
+		f.Added = added.Add(time.Duration(i) * time.Minute)
+		assert.NoError(t, r.AddFavorite(ctx, &f))
+	}
+	assert.NoError(t, r.DeleteFavorite(ctx, 1, 11))
+	assert.NoError(t, r.DeleteByAd(ctx, 10))
+	assert.NoError(t, r.DeleteByUser(ctx, 3))
+
+	r2, err := NewFile(dir, 2, logging.Discard())
+	assert.NoError(t, err)
+
+	list, err := r2.Favorites(ctx, 1)
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+
+	list, err = r2.Favorites(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []*favorites.Favorite{{UserID: 2, AdID: 12, Added: added.Add(3 * time.Minute)}}, list)
+
+	counts, err := r2.CountByAds(ctx, []int64{10, 11, 12})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]int{12: 1}, counts)
+	assert.NoError(t, r2.Close())
+}
@@ -0,0 +1,178 @@
+package favrepo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"homework10/internal/favorites"
+)
+
+var (
+	ErrNoFavorite            = fmt.Errorf("favorite does not exist")
+	ErrFavoriteAlreadyExists = fmt.Errorf("favorite already exists")
+)
+
+// RepoMap хранит избранное в памяти и отдаёт наружу копии
+type RepoMap struct {
+	set set
+	m   sync.RWMutex
+}
+
+func New() favorites.Repository {
+	return &RepoMap{
+		set: newSet(),
+		m:   sync.RWMutex{},
+	}
+}
+
+func (r *RepoMap) AddFavorite(_ context.Context, f *favorites.Favorite) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if r.set.has(f.UserID, f.AdID) {
+		return ErrFavoriteAlreadyExists
+	}
+
+	r.set.add(f)
+	return nil
+}
+
+func (r *RepoMap) DeleteFavorite(_ context.Context, userID, adID int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if !r.set.has(userID, adID) {
+		return ErrNoFavorite
+	}
+
+	r.set.delete(userID, adID)
+	return nil
+}
+
+func (r *RepoMap) Favorites(_ context.Context, userID int64) ([]*favorites.Favorite, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.set.list(userID), nil
+}
+
+func (r *RepoMap) CountByAds(_ context.Context, adIDs []int64) (map[int64]int, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.set.counts(adIDs), nil
+}
+
+func (r *RepoMap) DeleteByAd(_ context.Context, adID int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.set.deleteAd(adID)
+	return nil
+}
+
+func (r *RepoMap) DeleteByUser(_ context.Context, userID int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.set.deleteUser(userID)
+	return nil
+}
+
+// set - избранное по пользователям вместе с количеством добавлений каждого объявления
+type set struct {
+	byUser map[int64]map[int64]favorites.Favorite
+	count  map[int64]int
+}
+
+func newSet() set {
+	return set{
+		byUser: make(map[int64]map[int64]favorites.Favorite),
+		count:  make(map[int64]int),
+	}
+}
+
+func (s set) has(userID, adID int64) bool {
+	_, ok := s.byUser[userID][adID]
+	return ok
+}
+
+func (s set) add(f *favorites.Favorite) {
+	user, ok := s.byUser[f.UserID]
+	if !ok {
+		user = make(map[int64]favorites.Favorite)
+		s.byUser[f.UserID] = user
+	}
+	if _, ok = user[f.AdID]; !ok {
+		s.count[f.AdID]++
+	}
+	user[f.AdID] = *f
+}
+
+func (s set) delete(userID, adID int64) {
+	user := s.byUser[userID]
+	if _, ok := user[adID]; !ok {
+		return
+	}
+
+	delete(user, adID)
+	if len(user) == 0 {
+		delete(s.byUser, userID)
+	}
+	if s.count[adID]--; s.count[adID] == 0 {
+		delete(s.count, adID)
+	}
+}
+
+func (s set) deleteAd(adID int64) {
+	if s.count[adID] == 0 {
+		return
+	}
+	for userID := range s.byUser {
+		s.delete(userID, adID)
+	}
+}
+
+func (s set) deleteUser(userID int64) {
+	for adID := range s.byUser[userID] {
+		s.delete(userID, adID)
+	}
+}
+
+func (s set) list(userID int64) []*favorites.Favorite {
+	res := make([]*favorites.Favorite, 0, len(s.byUser[userID]))
+	for _, f := range s.byUser[userID] {
+		f := f
+		res = append(res, &f)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].Added.Equal(res[j].Added) {
+			return res[i].Added.After(res[j].Added)
+		}
+		return res[i].AdID > res[j].AdID
+	})
+
+	return res
+}
+
+func (s set) counts(adIDs []int64) map[int64]int {
+	res := make(map[int64]int)
+	for _, ID := range adIDs {
+		if n := s.count[ID]; n > 0 {
+			res[ID] = n
+		}
+	}
+
+	return res
+}
+
+func (s set) all() []*favorites.Favorite {
+	var res []*favorites.Favorite
+	for userID := range s.byUser {
+		res = append(res, s.list(userID)...)
+	}
+
+	return res
+}
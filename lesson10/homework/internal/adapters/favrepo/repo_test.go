@@ -0,0 +1,140 @@
+package favrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"homework10/internal/favorites"
+)
+
+var added = time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+
+type RepoTestSuite struct {
+	suite.Suite
+	repo    favorites.Repository
+	newRepo func() favorites.Repository
+}
+
+// SetupTest заполняет репозиторий: у пользователя 1 в избранном объявления 10, 11, 12 (добавлены по порядку), у пользователя 2 - 11
+func (s *RepoTestSuite) SetupTest() {
+	s.repo = s.newRepo()
+	for i, f := range []favorites.Favorite{
+		{UserID: 1, AdID: 10},
+		{UserID: 1, AdID: 11},
+		{UserID: 1, AdID: 12},
+		{UserID: 2, AdID: 11},
+	} {
+		f := f
+		f.Added = added.Add(time.Duration(i) * time.Minute)
+		_ = s.repo.AddFavorite(context.Background(), &f)
+	}
+}
+
+func (s *RepoTestSuite) adIDs(userID int64) []int64 {
+	list, err := s.repo.Favorites(context.Background(), userID)
+	assert.NoError(s.T(), err)
+
+	res := make([]int64, 0, len(list))
+	for _, f := range list {
+		res = append(res, f.AdID)
+	}
+	return res
+}
+
+func (s *RepoTestSuite) TestAddFavorite() {
+	tests := []struct {
+		name string
+		f    *favorites.Favorite
+		err  error
+	}{
+		{
+			name: "ok add ad 12 for user 2",
+			f:    &favorites.Favorite{UserID: 2, AdID: 12, Added: added.Add(time.Hour)},
+		},
+		{
+			name: "ok add ad 10 for user 3",
+			f:    &favorites.Favorite{UserID: 3, AdID: 10, Added: added.Add(time.Hour)},
+		},
+		{
+			name: "wrong add ad 11 for user 1 twice",
+			f:    &favorites.Favorite{UserID: 1, AdID: 11, Added: added.Add(time.Hour)},
+			err:  ErrFavoriteAlreadyExists,
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			err := s.repo.AddFavorite(context.Background(), tt.f)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			assert.NoError(t, err)
+			list, err := s.repo.Favorites(context.Background(), tt.f.UserID)
+			assert.NoError(t, err)
+			if assert.NotEmpty(t, list) {
+				assert.Equal(t, tt.f, list[0])
+			}
+		})
+	}
+}
+
+func (s *RepoTestSuite) TestFavorites() {
+	assert.Equal(s.T(), []int64{12, 11, 10}, s.adIDs(1))
+	assert.Equal(s.T(), []int64{11}, s.adIDs(2))
+	assert.Empty(s.T(), s.adIDs(3))
+
+	// наружу отдаются копии
+	list, err := s.repo.Favorites(context.Background(), 2)
+	assert.NoError(s.T(), err)
+	list[0].AdID = 100
+	assert.Equal(s.T(), []int64{11}, s.adIDs(2))
+}
+
+func (s *RepoTestSuite) TestCountByAds() {
+	counts, err := s.repo.CountByAds(context.Background(), []int64{10, 11, 13})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), map[int64]int{10: 1, 11: 2}, counts)
+}
+
+func (s *RepoTestSuite) TestDeleteFavorite() {
+	assert.NoError(s.T(), s.repo.DeleteFavorite(context.Background(), 1, 11))
+	assert.ErrorIs(s.T(), s.repo.DeleteFavorite(context.Background(), 1, 11), ErrNoFavorite)
+	assert.ErrorIs(s.T(), s.repo.DeleteFavorite(context.Background(), 3, 10), ErrNoFavorite)
+
+	assert.Equal(s.T(), []int64{12, 10}, s.adIDs(1))
+	counts, err := s.repo.CountByAds(context.Background(), []int64{11})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), map[int64]int{11: 1}, counts)
+}
+
+func (s *RepoTestSuite) TestDeleteByAd() {
+	assert.NoError(s.T(), s.repo.DeleteByAd(context.Background(), 11))
+	assert.NoError(s.T(), s.repo.DeleteByAd(context.Background(), 100))
+
+	assert.Equal(s.T(), []int64{12, 10}, s.adIDs(1))
+	assert.Empty(s.T(), s.adIDs(2))
+	counts, err := s.repo.CountByAds(context.Background(), []int64{10, 11, 12})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), map[int64]int{10: 1, 12: 1}, counts)
+}
+
+func (s *RepoTestSuite) TestDeleteByUser() {
+	assert.NoError(s.T(), s.repo.DeleteByUser(context.Background(), 1))
+	assert.NoError(s.T(), s.repo.DeleteByUser(context.Background(), 100))
+
+	assert.Empty(s.T(), s.adIDs(1))
+	assert.Equal(s.T(), []int64{11}, s.adIDs(2))
+	counts, err := s.repo.CountByAds(context.Background(), []int64{10, 11, 12})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), map[int64]int{11: 1}, counts)
+}
+
+func TestRepoTestSuite(t *testing.T) {
+	suite.Run(t, &RepoTestSuite{newRepo: New})
+}
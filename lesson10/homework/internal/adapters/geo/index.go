@@ -0,0 +1,139 @@
+package geo
+
+import (
+	"math"
+
+	"homework10/internal/ads"
+)
+
+// CellSize - сторона ячейки сетки в градусах (около 55 км по широте)
+const CellSize = 0.5
+
+var (
+	rows = int(math.Ceil(180 / CellSize))
+	cols = int(math.Ceil(360 / CellSize))
+)
+
+type cell struct {
+	row, col int
+}
+
+func cellOf(p ads.Point) cell {
+	return cell{row: rowOf(p.Lat), col: colOf(p.Lon)}
+}
+
+func rowOf(lat float64) int {
+	r := int(math.Floor((lat + 90) / CellSize))
+	if r >= rows {
+		r = rows - 1
+	}
+	if r < 0 {
+		r = 0
+	}
+	return r
+}
+
+// colOf возвращает столбец долготы; долготы за пределами [-180, 180) заворачиваются
+func colOf(lon float64) int {
+	c := int(math.Floor((lon + 180) / CellSize))
+	return (c%cols + cols) % cols
+}
+
+// Index - пространственный индекс точек на регулярной сетке по широте и долготе: поиск по радиусу
+// просматривает только ячейки, пересекающие описанный вокруг круга прямоугольник.
+// Index не потокобезопасен, синхронизацию обеспечивает владелец (репозиторий).
+type Index struct {
+	cells  map[cell]map[int64]ads.Point
+	points map[int64]ads.Point
+}
+
+func NewIndex() *Index {
+	return &Index{
+		cells:  make(map[cell]map[int64]ads.Point),
+		points: make(map[int64]ads.Point),
+	}
+}
+
+// Add помещает точку с идентификатором ID в индекс; если точка с таким ID уже была, она заменяется
+func (idx *Index) Add(ID int64, p ads.Point) {
+	idx.Remove(ID)
+
+	c := cellOf(p)
+	points, ok := idx.cells[c]
+	if !ok {
+		points = make(map[int64]ads.Point)
+		idx.cells[c] = points
+	}
+	points[ID] = p
+	idx.points[ID] = p
+}
+
+// Remove убирает точку из индекса
+func (idx *Index) Remove(ID int64) {
+	p, ok := idx.points[ID]
+	if !ok {
+		return
+	}
+
+	c := cellOf(p)
+	points := idx.cells[c]
+	delete(points, ID)
+	if len(points) == 0 {
+		delete(idx.cells, c)
+	}
+	delete(idx.points, ID)
+}
+
+// Within возвращает точки, лежащие в области, с их расстояниями до центра области в километрах
+func (idx *Index) Within(area ads.Circle) map[int64]float64 {
+	res := make(map[int64]float64)
+	visit := func(points map[int64]ads.Point) {
+		for ID, p := range points {
+			if d := ads.Distance(area.Center, p); d <= area.Radius {
+				res[ID] = d
+			}
+		}
+	}
+
+	minRow, maxRow, minCol, maxCol := bounds(area)
+	spanCols := maxCol - minCol + 1
+	if spanCols > cols {
+		spanCols = cols
+	}
+
+	// если ячеек вокруг круга больше, чем занятых, дешевле просмотреть занятые
+	if (maxRow-minRow+1)*spanCols > len(idx.cells) {
+		for c, points := range idx.cells {
+			if c.row >= minRow && c.row <= maxRow {
+				visit(points)
+			}
+		}
+		return res
+	}
+
+	for r := minRow; r <= maxRow; r++ {
+		for i := 0; i < spanCols; i++ {
+			visit(idx.cells[cell{row: r, col: ((minCol+i)%cols + cols) % cols}])
+		}
+	}
+	return res
+}
+
+// bounds возвращает диапазоны строк и столбцов сетки, покрывающие область. Столбцы не заворачиваются:
+// minCol может быть отрицательным, а maxCol - не меньше cols, если область пересекает 180-й меридиан
+func bounds(area ads.Circle) (minRow, maxRow, minCol, maxCol int) {
+	angle := area.Radius / ads.EarthRadius
+	dLat := angle * 180 / math.Pi
+	minLat, maxLat := area.Center.Lat-dLat, area.Center.Lat+dLat
+	minRow, maxRow = rowOf(minLat), rowOf(maxLat)
+
+	// у полюса область захватывает все долготы
+	if minLat <= -90 || maxLat >= 90 {
+		return minRow, maxRow, 0, cols - 1
+	}
+
+	dLon := math.Asin(math.Sin(angle)/math.Cos(area.Center.Lat*math.Pi/180)) * 180 / math.Pi
+	minCol = int(math.Floor((area.Center.Lon - dLon + 180) / CellSize))
+	maxCol = int(math.Floor((area.Center.Lon + dLon + 180) / CellSize))
+	return minRow, maxRow, minCol, maxCol
+}
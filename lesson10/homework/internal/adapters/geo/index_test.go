@@ -0,0 +1,112 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/ads"
+)
+
+var (
+	moscow      = ads.Point{Lat: 55.7558, Lon: 37.6173}
+	khimki      = ads.Point{Lat: 55.8970, Lon: 37.4297}
+	tver        = ads.Point{Lat: 56.8587, Lon: 35.9176}
+	petersburg  = ads.Point{Lat: 59.9343, Lon: 30.3351}
+	anadyr      = ads.Point{Lat: 64.7337, Lon: 177.5089}
+	beringWest  = ads.Point{Lat: 65.0, Lon: 179.9}
+	beringEast  = ads.Point{Lat: 65.0, Lon: -179.9}
+	northPole   = ads.Point{Lat: 89.99, Lon: 0}
+	nearPoleFar = ads.Point{Lat: 89.95, Lon: 180}
+)
+
+func TestDistance(t *testing.T) {
+	assert.InDelta(t, 634, ads.Distance(moscow, petersburg), 5)
+	assert.InDelta(t, 0, ads.Distance(moscow, moscow), 1e-9)
+	assert.InDelta(t, ads.Distance(beringWest, beringEast), ads.Distance(beringEast, beringWest), 1e-9)
+	assert.Less(t, ads.Distance(beringWest, beringEast), 10.0)
+}
+
+func TestIndex_Within(t *testing.T) {
+	idx := NewIndex()
+	fill(idx)
+	idx.Add(1, moscow)
+	idx.Add(2, khimki)
+	idx.Add(3, tver)
+	idx.Add(4, petersburg)
+
+	found := idx.Within(ads.Circle{Center: moscow, Radius: 30})
+	assert.Len(t, found, 2)
+	assert.InDelta(t, 0, found[1], 1e-9)
+	assert.InDelta(t, 19.5, found[2], 1)
+
+	found = idx.Within(ads.Circle{Center: moscow, Radius: 200})
+	assert.Len(t, found, 3)
+	assert.NotContains(t, found, int64(4))
+
+	idx.Remove(3)
+	idx.Add(2, petersburg)
+	found = idx.Within(ads.Circle{Center: moscow, Radius: 200})
+	assert.Equal(t, []int64{1}, keys(found))
+}
+
+func TestIndex_Within_Wraps(t *testing.T) {
+	idx := NewIndex()
+	fill(idx)
+	idx.Add(1, beringWest)
+	idx.Add(2, beringEast)
+	idx.Add(3, anadyr)
+	idx.Add(4, northPole)
+	idx.Add(5, nearPoleFar)
+
+	// область пересекает 180-й меридиан
+	found := idx.Within(ads.Circle{Center: beringEast, Radius: 50})
+	assert.ElementsMatch(t, []int64{1, 2}, keys(found))
+
+	// и захватывает полюс
+	found = idx.Within(ads.Circle{Center: northPole, Radius: 10})
+	assert.ElementsMatch(t, []int64{4, 5}, keys(found))
+}
+
+func TestIndex_Within_MatchesScan(t *testing.T) {
+	idx := NewIndex()
+	var points []ads.Point
+	for lat := -89.0; lat <= 89; lat += 7.3 {
+		for lon := -179.0; lon < 180; lon += 11.7 {
+			p := ads.Point{Lat: lat, Lon: lon}
+			idx.Add(int64(len(points)), p)
+			points = append(points, p)
+		}
+	}
+
+	for _, area := range []ads.Circle{
+		{Center: ads.Point{Lat: 10, Lon: 10}, Radius: 500},
+		{Center: ads.Point{Lat: -60, Lon: 179}, Radius: 500},
+		{Center: ads.Point{Lat: 85, Lon: -100}, Radius: 500},
+		{Center: ads.Point{Lat: 0, Lon: 0}, Radius: 1},
+	} {
+		want := make(map[int64]float64)
+		for ID, p := range points {
+			if d := ads.Distance(area.Center, p); d <= area.Radius {
+				want[int64(ID)] = d
+			}
+		}
+		assert.Equal(t, want, idx.Within(area), area)
+	}
+}
+
+// fill занимает много далёких от остальных точек ячеек, чтобы поиск шёл по ячейкам вокруг области,
+// а не по всем занятым
+func fill(idx *Index) {
+	for i := 0; i < cols; i++ {
+		idx.Add(int64(1000+i), ads.Point{Lat: -30, Lon: -180 + float64(i)*CellSize})
+	}
+}
+
+func keys(m map[int64]float64) []int64 {
+	var res []int64
+	for k := range m {
+		res = append(res, k)
+	}
+	return res
+}
@@ -0,0 +1,263 @@
+package hookrepo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"homework10/internal/adapters/wal"
+	"homework10/internal/webhooks"
+)
+
+const (
+	opAddSubscription    = "add_subscription"
+	opDeleteSubscription = "delete_subscription"
+	opAddDelivery        = "add_delivery"
+	opUpdateDelivery     = "update_delivery"
+)
+
+// RepoFile - репозиторий подписок и доставок, переживающий перезапуск сервиса:
+// состояние хранится в памяти, каждое изменение пишется в WAL, периодически делается снимок
+type RepoFile struct {
+	store  store
+	log    *wal.Log
+	logger *slog.Logger
+	m      sync.RWMutex
+}
+
+type fileState struct {
+	NextSubscriptionID int64                    `json:"next_subscription_id"`
+	NextDeliveryID     int64                    `json:"next_delivery_id"`
+	Subscriptions      []*webhooks.Subscription `json:"subscriptions"`
+	Deliveries         []*webhooks.Delivery     `json:"deliveries"`
+}
+
+// deleteMark - запись журнала об удалении подписки
+type deleteMark struct {
+	ID int64 `json:"id"`
+}
+
+func NewFile(dir string, snapshotEvery int, logger *slog.Logger) (*RepoFile, error) {
+	l, err := wal.Open(dir, snapshotEvery)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &RepoFile{
+		store:  newStore(),
+		log:    l,
+		logger: logger,
+		m:      sync.RWMutex{},
+	}
+
+	if err = l.Recover(r.restore, r.apply); err != nil {
+		_ = l.Close()
+		return nil, fmt.Errorf("recover webhook repo: %w", err)
+	}
+
+	return r, nil
+}
+
+func (r *RepoFile) AddSubscription(ctx context.Context, s *webhooks.Subscription) (int64, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	cp := s.Copy()
+	cp.ID = r.store.nextSubscriptionID
+	if err := r.log.Append(opAddSubscription, cp); err != nil {
+		return -1, err
+	}
+
+	s.ID = cp.ID
+	r.store.addSubscription(cp)
+	r.snapshotIfNeeded(ctx)
+
+	return s.ID, nil
+}
+
+func (r *RepoFile) SubscriptionByID(_ context.Context, ID int64) (*webhooks.Subscription, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.subscription(ID)
+}
+
+func (r *RepoFile) Subscriptions(_ context.Context) ([]*webhooks.Subscription, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.subscriptionList(), nil
+}
+
+func (r *RepoFile) DeleteSubscription(ctx context.Context, ID int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if _, ok := r.store.subscriptions[ID]; !ok {
+		return ErrNoSubscription
+	}
+	if err := r.log.Append(opDeleteSubscription, deleteMark{ID: ID}); err != nil {
+		return err
+	}
+
+	r.store.deleteSubscription(ID)
+	r.snapshotIfNeeded(ctx)
+
+	return nil
+}
+
+func (r *RepoFile) AddDelivery(ctx context.Context, d *webhooks.Delivery) (int64, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if _, ok := r.store.subscriptions[d.SubscriptionID]; !ok {
+		return -1, ErrNoSubscription
+	}
+
+	cp := d.Copy()
+	cp.ID = r.store.nextDeliveryID
+	if err := r.log.Append(opAddDelivery, cp); err != nil {
+		return -1, err
+	}
+
+	d.ID = cp.ID
+	r.store.addDelivery(cp)
+	r.snapshotIfNeeded(ctx)
+
+	return d.ID, nil
+}
+
+func (r *RepoFile) UpdateDelivery(ctx context.Context, d *webhooks.Delivery) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if _, ok := r.store.deliveries[d.ID]; !ok {
+		return ErrNoDelivery
+	}
+	if err := r.log.Append(opUpdateDelivery, d); err != nil {
+		return err
+	}
+
+	r.store.updateDelivery(d)
+	r.snapshotIfNeeded(ctx)
+
+	return nil
+}
+
+func (r *RepoFile) DueDeliveries(_ context.Context, now time.Time, limit int) ([]*webhooks.Delivery, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.due(now, limit), nil
+}
+
+func (r *RepoFile) Deliveries(_ context.Context, subscriptionID int64, limit int) ([]*webhooks.Delivery, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.deliveriesOf(subscriptionID, limit)
+}
+
+// Close сохраняет итоговый снимок состояния и закрывает журнал
+func (r *RepoFile) Close() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if err := r.log.Snapshot(r.state()); err != nil {
+		_ = r.log.Close()
+		return err
+	}
+
+	return r.log.Close()
+}
+
+// snapshotIfNeeded не возвращает ошибку: операция уже записана в журнал,
+// а неудавшийся снимок будет повторён при следующем изменении
+func (r *RepoFile) snapshotIfNeeded(ctx context.Context) {
+	if !r.log.NeedSnapshot() {
+		return
+	}
+
+	if err := r.log.Snapshot(r.state()); err != nil {
+		r.logger.ErrorContext(ctx, "can't snapshot webhook repo", "err", err)
+	}
+}
+
+func (r *RepoFile) state() fileState {
+	s := fileState{
+		NextSubscriptionID: r.store.nextSubscriptionID,
+		NextDeliveryID:     r.store.nextDeliveryID,
+	}
+	for _, sub := range r.store.subscriptions {
+		s.Subscriptions = append(s.Subscriptions, sub)
+	}
+	for _, d := range r.store.deliveries {
+		s.Deliveries = append(s.Deliveries, d)
+	}
+	sort.Slice(s.Subscriptions, func(i, j int) bool {
+		return s.Subscriptions[i].ID < s.Subscriptions[j].ID
+	})
+	// доставки восстанавливаются по порядку, чтобы сохранить порядок журнала подписки
+	sort.Slice(s.Deliveries, func(i, j int) bool {
+		return s.Deliveries[i].ID < s.Deliveries[j].ID
+	})
+
+	return s
+}
+
+func (r *RepoFile) restore(data []byte) error {
+	var s fileState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	r.store.nextSubscriptionID = s.NextSubscriptionID
+	r.store.nextDeliveryID = s.NextDeliveryID
+	for _, sub := range s.Subscriptions {
+		r.store.addSubscription(sub)
+	}
+	for _, d := range s.Deliveries {
+		r.store.addDelivery(d)
+	}
+
+	return nil
+}
+
+func (r *RepoFile) apply(rec wal.Record) error {
+	switch rec.Op {
+	case opAddSubscription:
+		var sub webhooks.Subscription
+		if err := json.Unmarshal(rec.Data, &sub); err != nil {
+			return err
+		}
+		r.store.addSubscription(&sub)
+	case opDeleteSubscription:
+		var mark deleteMark
+		if err := json.Unmarshal(rec.Data, &mark); err != nil {
+			return err
+		}
+		r.store.deleteSubscription(mark.ID)
+	case opAddDelivery:
+		var d webhooks.Delivery
+		if err := json.Unmarshal(rec.Data, &d); err != nil {
+			return err
+		}
+		r.store.addDelivery(&d)
+	case opUpdateDelivery:
+		var d webhooks.Delivery
+		if err := json.Unmarshal(rec.Data, &d); err != nil {
+			return err
+		}
+		if _, ok := r.store.deliveries[d.ID]; ok {
+			r.store.updateDelivery(&d)
+		}
+	default:
+		return fmt.Errorf("%w: unknown op %q", wal.ErrCorrupted, rec.Op)
+	}
+
+	return nil
+}
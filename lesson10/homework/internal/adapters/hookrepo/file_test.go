@@ -0,0 +1,83 @@
+package hookrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"homework10/internal/events"
+	"homework10/internal/logging"
+	"homework10/internal/webhooks"
+)
+
+func TestRepoFileTestSuite(t *testing.T) {
+	suite.Run(t, &RepoTestSuite{newRepo: func() webhooks.Repository {
+		r, err := NewFile(t.TempDir(), 3, logging.Discard())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			_ = r.Close()
+		})
+		return r
+	}})
+}
+
+func TestRepoFile_Reopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	r, err := NewFile(dir, 2, logging.Discard())
+	assert.NoError(t, err)
+	for _, url := range []string{"http://a.example", "http://b.example"} {
+		_, err = r.AddSubscription(ctx, &webhooks.Subscription{URL: url, Secret: "secret", Created: started})
+		assert.NoError(t, err)
+	}
+	for _, subID := range []int64{1, 2, 2} {
+		_, err = r.AddDelivery(ctx, &webhooks.Delivery{
+			SubscriptionID: subID,
+			Event:          events.Event{ID: 1, Type: events.AdCreated, At: started},
+			Status:         webhooks.DeliveryPending,
+			NextAttempt:    started,
+			Created:        started,
+		})
+		assert.NoError(t, err)
+	}
+	delivered := &webhooks.Delivery{
+		ID:             1,
+		SubscriptionID: 1,
+		Event:          events.Event{ID: 1, Type: events.AdCreated, At: started},
+		Status:         webhooks.DeliveryDelivered,
+		Attempts:       []webhooks.Attempt{{At: started, StatusCode: 204, Duration: time.Millisecond}},
+		NextAttempt:    started,
+		Created:        started,
+	}
+	assert.NoError(t, r.UpdateDelivery(ctx, delivered))
+	assert.NoError(t, r.DeleteSubscription(ctx, 2))
+
+	r2, err := NewFile(dir, 2, logging.Discard())
+	assert.NoError(t, err)
+
+	list, err := r2.Subscriptions(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, int64(1), list[0].ID)
+	}
+	ds, err := r2.Deliveries(ctx, 1, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []*webhooks.Delivery{delivered}, ds)
+	due, err := r2.DueDeliveries(ctx, started, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, due)
+
+	ID, err := r2.AddSubscription(ctx, &webhooks.Subscription{URL: "http://c.example"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), ID)
+	ID, err = r2.AddDelivery(ctx, &webhooks.Delivery{SubscriptionID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), ID)
+	assert.NoError(t, r2.Close())
+}
@@ -0,0 +1,211 @@
+package hookrepo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"homework10/internal/webhooks"
+)
+
+var (
+	ErrNoSubscription = fmt.Errorf("subscription does not exist")
+	ErrNoDelivery     = fmt.Errorf("delivery does not exist")
+)
+
+// RepoMap хранит подписки и доставки в памяти и отдаёт наружу копии
+type RepoMap struct {
+	store store
+	m     sync.RWMutex
+}
+
+func New() webhooks.Repository {
+	return &RepoMap{
+		store: newStore(),
+		m:     sync.RWMutex{},
+	}
+}
+
+func (r *RepoMap) AddSubscription(_ context.Context, s *webhooks.Subscription) (int64, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	s.ID = r.store.nextSubscriptionID
+	r.store.addSubscription(s)
+
+	return s.ID, nil
+}
+
+func (r *RepoMap) SubscriptionByID(_ context.Context, ID int64) (*webhooks.Subscription, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.subscription(ID)
+}
+
+func (r *RepoMap) Subscriptions(_ context.Context) ([]*webhooks.Subscription, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.subscriptionList(), nil
+}
+
+func (r *RepoMap) DeleteSubscription(_ context.Context, ID int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if _, ok := r.store.subscriptions[ID]; !ok {
+		return ErrNoSubscription
+	}
+
+	r.store.deleteSubscription(ID)
+	return nil
+}
+
+func (r *RepoMap) AddDelivery(_ context.Context, d *webhooks.Delivery) (int64, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if _, ok := r.store.subscriptions[d.SubscriptionID]; !ok {
+		return -1, ErrNoSubscription
+	}
+
+	d.ID = r.store.nextDeliveryID
+	r.store.addDelivery(d)
+
+	return d.ID, nil
+}
+
+func (r *RepoMap) UpdateDelivery(_ context.Context, d *webhooks.Delivery) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if _, ok := r.store.deliveries[d.ID]; !ok {
+		return ErrNoDelivery
+	}
+
+	r.store.updateDelivery(d)
+	return nil
+}
+
+func (r *RepoMap) DueDeliveries(_ context.Context, now time.Time, limit int) ([]*webhooks.Delivery, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.due(now, limit), nil
+}
+
+func (r *RepoMap) Deliveries(_ context.Context, subscriptionID int64, limit int) ([]*webhooks.Delivery, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.deliveriesOf(subscriptionID, limit)
+}
+
+// store - подписки и доставки; ID не переиспользуются, доставки подписки хранятся в порядке добавления
+type store struct {
+	subscriptions      map[int64]*webhooks.Subscription
+	deliveries         map[int64]*webhooks.Delivery
+	bySubscription     map[int64][]int64
+	nextSubscriptionID int64
+	nextDeliveryID     int64
+}
+
+func newStore() store {
+	return store{
+		subscriptions:      make(map[int64]*webhooks.Subscription),
+		deliveries:         make(map[int64]*webhooks.Delivery),
+		bySubscription:     make(map[int64][]int64),
+		nextSubscriptionID: 1,
+		nextDeliveryID:     1,
+	}
+}
+
+func (s *store) subscription(ID int64) (*webhooks.Subscription, error) {
+	sub, ok := s.subscriptions[ID]
+	if !ok {
+		return nil, ErrNoSubscription
+	}
+	return sub.Copy(), nil
+}
+
+// addSubscription сохраняет копию подписки с уже назначенным ID
+func (s *store) addSubscription(sub *webhooks.Subscription) {
+	s.subscriptions[sub.ID] = sub.Copy()
+	if sub.ID >= s.nextSubscriptionID {
+		s.nextSubscriptionID = sub.ID + 1
+	}
+}
+
+func (s *store) subscriptionList() []*webhooks.Subscription {
+	res := make([]*webhooks.Subscription, 0, len(s.subscriptions))
+	for _, sub := range s.subscriptions {
+		res = append(res, sub.Copy())
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
+
+func (s *store) deleteSubscription(ID int64) {
+	for _, dID := range s.bySubscription[ID] {
+		delete(s.deliveries, dID)
+	}
+	delete(s.bySubscription, ID)
+	delete(s.subscriptions, ID)
+}
+
+// addDelivery сохраняет копию доставки с уже назначенным ID
+func (s *store) addDelivery(d *webhooks.Delivery) {
+	s.deliveries[d.ID] = d.Copy()
+	s.bySubscription[d.SubscriptionID] = append(s.bySubscription[d.SubscriptionID], d.ID)
+	if d.ID >= s.nextDeliveryID {
+		s.nextDeliveryID = d.ID + 1
+	}
+}
+
+func (s *store) updateDelivery(d *webhooks.Delivery) {
+	s.deliveries[d.ID] = d.Copy()
+}
+
+func (s *store) due(now time.Time, limit int) []*webhooks.Delivery {
+	var res []*webhooks.Delivery
+	for _, d := range s.deliveries {
+		if d.Status == webhooks.DeliveryPending && !d.NextAttempt.After(now) {
+			res = append(res, d)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].NextAttempt.Equal(res[j].NextAttempt) {
+			return res[i].NextAttempt.Before(res[j].NextAttempt)
+		}
+		return res[i].ID < res[j].ID
+	})
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+
+	for i, d := range res {
+		res[i] = d.Copy()
+	}
+	return res
+}
+
+func (s *store) deliveriesOf(subscriptionID int64, limit int) ([]*webhooks.Delivery, error) {
+	if _, ok := s.subscriptions[subscriptionID]; !ok {
+		return nil, ErrNoSubscription
+	}
+
+	IDs := s.bySubscription[subscriptionID]
+	res := make([]*webhooks.Delivery, 0, len(IDs))
+	for i := len(IDs) - 1; i >= 0; i-- {
+		if limit > 0 && len(res) == limit {
+			break
+		}
+		res = append(res, s.deliveries[IDs[i]].Copy())
+	}
+	return res, nil
+}
@@ -0,0 +1,195 @@
+package hookrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"homework10/internal/events"
+	"homework10/internal/webhooks"
+)
+
+var started = time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+
+type RepoTestSuite struct {
+	suite.Suite
+	repo    webhooks.Repository
+	newRepo func() webhooks.Repository
+}
+
+// SetupTest заполняет репозиторий: подписка 1 на все события с доставками 1 (доставлена), 2 и 3 (ждут попытки
+// через минуту и сразу), подписка 2 на создание объявлений с доставкой 4 (не доставлена)
+func (s *RepoTestSuite) SetupTest() {
+	ctx := context.Background()
+	s.repo = s.newRepo()
+	_, _ = s.repo.AddSubscription(ctx, &webhooks.Subscription{URL: "http://a.example", Secret: "a", Created: started})
+	_, _ = s.repo.AddSubscription(ctx, &webhooks.Subscription{URL: "http://b.example", Secret: "b", Types: []events.Type{events.AdCreated}, Created: started})
+	for _, d := range []webhooks.Delivery{
+		{SubscriptionID: 1, Event: events.Event{ID: 1, Type: events.AdCreated}, Status: webhooks.DeliveryDelivered},
+		{SubscriptionID: 1, Event: events.Event{ID: 2, Type: events.AdUpdated}, Status: webhooks.DeliveryPending, NextAttempt: started.Add(time.Minute)},
+		{SubscriptionID: 1, Event: events.Event{ID: 3, Type: events.AdDeleted}, Status: webhooks.DeliveryPending, NextAttempt: started},
+		{SubscriptionID: 2, Event: events.Event{ID: 1, Type: events.AdCreated}, Status: webhooks.DeliveryFailed},
+	} {
+		d := d
+		d.Created = started
+		_, _ = s.repo.AddDelivery(ctx, &d)
+	}
+}
+
+func (s *RepoTestSuite) TestAddSubscription() {
+	sub := &webhooks.Subscription{URL: "http://c.example", Types: []events.Type{events.UserCreated}}
+	ID, err := s.repo.AddSubscription(context.Background(), sub)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(3), ID)
+
+	got, err := s.repo.SubscriptionByID(context.Background(), ID)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), sub, got)
+}
+
+func (s *RepoTestSuite) TestSubscriptionByID() {
+	sub, err := s.repo.SubscriptionByID(context.Background(), 2)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), &webhooks.Subscription{ID: 2, URL: "http://b.example", Secret: "b", Types: []events.Type{events.AdCreated}, Created: started}, sub)
+
+	_, err = s.repo.SubscriptionByID(context.Background(), 3)
+	assert.ErrorIs(s.T(), err, ErrNoSubscription)
+}
+
+func (s *RepoTestSuite) TestSubscriptions() {
+	list, err := s.repo.Subscriptions(context.Background())
+	assert.NoError(s.T(), err)
+	if assert.Len(s.T(), list, 2) {
+		assert.Equal(s.T(), int64(1), list[0].ID)
+		assert.Equal(s.T(), int64(2), list[1].ID)
+	}
+
+	// наружу отдаются копии
+	list[1].Types[0] = events.AdDeleted
+	sub, _ := s.repo.SubscriptionByID(context.Background(), 2)
+	assert.Equal(s.T(), []events.Type{events.AdCreated}, sub.Types)
+}
+
+func (s *RepoTestSuite) TestDeleteSubscription() {
+	ctx := context.Background()
+	assert.NoError(s.T(), s.repo.DeleteSubscription(ctx, 1))
+	assert.ErrorIs(s.T(), s.repo.DeleteSubscription(ctx, 1), ErrNoSubscription)
+
+	_, err := s.repo.SubscriptionByID(ctx, 1)
+	assert.ErrorIs(s.T(), err, ErrNoSubscription)
+	_, err = s.repo.Deliveries(ctx, 1, 0)
+	assert.ErrorIs(s.T(), err, ErrNoSubscription)
+
+	// доставки удалённой подписки больше не отправляются
+	list, err := s.repo.DueDeliveries(ctx, started.Add(time.Hour), 0)
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), list)
+	assert.ErrorIs(s.T(), s.repo.UpdateDelivery(ctx, &webhooks.Delivery{ID: 2, SubscriptionID: 1}), ErrNoDelivery)
+}
+
+func (s *RepoTestSuite) TestAddDelivery() {
+	tests := []struct {
+		name string
+		d    *webhooks.Delivery
+		want int64
+		err  error
+	}{
+		{
+			name: "ok add delivery for subscription 2",
+			d:    &webhooks.Delivery{SubscriptionID: 2, Status: webhooks.DeliveryPending},
+			want: 5,
+		},
+		{
+			name: "wrong add delivery for unknown subscription",
+			d:    &webhooks.Delivery{SubscriptionID: 3, Status: webhooks.DeliveryPending},
+			want: -1,
+			err:  ErrNoSubscription,
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			ID, err := s.repo.AddDelivery(context.Background(), tt.d)
+			assert.ErrorIs(s.T(), err, tt.err)
+			assert.Equal(s.T(), tt.want, ID)
+		})
+	}
+}
+
+func (s *RepoTestSuite) TestDueDeliveries() {
+	tests := []struct {
+		name  string
+		now   time.Time
+		limit int
+		want  []int64
+	}{
+		{name: "nothing is due before the first attempt", now: started.Add(-time.Second), want: nil},
+		{name: "delivery 3 is due at start", now: started, want: []int64{3}},
+		{name: "oldest attempts first", now: started.Add(time.Hour), want: []int64{3, 2}},
+		{name: "limited", now: started.Add(time.Hour), limit: 1, want: []int64{3}},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			list, err := s.repo.DueDeliveries(context.Background(), tt.now, tt.limit)
+			assert.NoError(s.T(), err)
+			var IDs []int64
+			for _, d := range list {
+				IDs = append(IDs, d.ID)
+			}
+			assert.Equal(s.T(), tt.want, IDs)
+		})
+	}
+}
+
+func (s *RepoTestSuite) TestUpdateDelivery() {
+	ctx := context.Background()
+	list, _ := s.repo.DueDeliveries(ctx, started, 0)
+	d := list[0]
+	d.Status = webhooks.DeliveryDelivered
+	d.Attempts = append(d.Attempts, webhooks.Attempt{At: started, StatusCode: 200, Duration: time.Millisecond})
+	assert.NoError(s.T(), s.repo.UpdateDelivery(ctx, d))
+	assert.ErrorIs(s.T(), s.repo.UpdateDelivery(ctx, &webhooks.Delivery{ID: 10}), ErrNoDelivery)
+
+	list, err := s.repo.DueDeliveries(ctx, started, 0)
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), list)
+
+	list, err = s.repo.Deliveries(ctx, 1, 1)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []*webhooks.Delivery{d}, list)
+}
+
+func (s *RepoTestSuite) TestDeliveries() {
+	tests := []struct {
+		name           string
+		subscriptionID int64
+		limit          int
+		want           []int64
+		err            error
+	}{
+		{name: "newest first", subscriptionID: 1, want: []int64{3, 2, 1}},
+		{name: "limited", subscriptionID: 1, limit: 2, want: []int64{3, 2}},
+		{name: "other subscription", subscriptionID: 2, want: []int64{4}},
+		{name: "unknown subscription", subscriptionID: 3, err: ErrNoSubscription},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			list, err := s.repo.Deliveries(context.Background(), tt.subscriptionID, tt.limit)
+			assert.ErrorIs(s.T(), err, tt.err)
+			var IDs []int64
+			for _, d := range list {
+				IDs = append(IDs, d.ID)
+			}
+			assert.Equal(s.T(), tt.want, IDs)
+		})
+	}
+}
+
+func TestRepoTestSuite(t *testing.T) {
+	suite.Run(t, &RepoTestSuite{newRepo: New})
+}
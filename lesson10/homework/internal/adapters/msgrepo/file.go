@@ -0,0 +1,250 @@
+package msgrepo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+
+	"homework10/internal/adapters/wal"
+	"homework10/internal/messages"
+)
+
+const (
+	opAddThread  = "add_thread"
+	opAddMessage = "add_message"
+	opMarkRead   = "mark_read"
+)
+
+// RepoFile - репозиторий переписок, переживающий перезапуск сервиса:
+// состояние хранится в памяти, каждое изменение пишется в WAL, периодически делается снимок
+type RepoFile struct {
+	store  store
+	log    *wal.Log
+	logger *slog.Logger
+	m      sync.RWMutex
+}
+
+// fileState хранит сообщения без учёта в переписках: счётчики непрочитанных уже сохранены в самих переписках
+type fileState struct {
+	NextThreadID  int64               `json:"next_thread_id"`
+	NextMessageID int64               `json:"next_message_id"`
+	Threads       []*messages.Thread  `json:"threads"`
+	Messages      []*messages.Message `json:"messages"`
+}
+
+// readMark - запись журнала о прочтении переписки
+type readMark struct {
+	ThreadID int64 `json:"thread_id"`
+	UserID   int64 `json:"user_id"`
+}
+
+func NewFile(dir string, snapshotEvery int, logger *slog.Logger) (*RepoFile, error) {
+	l, err := wal.Open(dir, snapshotEvery)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &RepoFile{
+		store:  newStore(),
+		log:    l,
+		logger: logger,
+		m:      sync.RWMutex{},
+	}
+
+	if err = l.Recover(r.restore, r.apply); err != nil {
+		_ = l.Close()
+		return nil, fmt.Errorf("recover message repo: %w", err)
+	}
+
+	return r, nil
+}
+
+func (r *RepoFile) ThreadByID(_ context.Context, ID int64) (*messages.Thread, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.thread(ID)
+}
+
+func (r *RepoFile) ThreadByAd(_ context.Context, adID, buyerID int64) (*messages.Thread, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.thread(r.store.byAd[threadKey{AdID: adID, BuyerID: buyerID}])
+}
+
+func (r *RepoFile) AddThread(ctx context.Context, t *messages.Thread) (int64, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if err := r.store.canAddThread(t); err != nil {
+		return -1, err
+	}
+
+	cp := *t
+	cp.ID = r.store.nextThreadID
+	if err := r.log.Append(opAddThread, &cp); err != nil {
+		return -1, err
+	}
+
+	t.ID = cp.ID
+	r.store.addThread(&cp)
+	r.snapshotIfNeeded(ctx)
+
+	return t.ID, nil
+}
+
+func (r *RepoFile) Threads(_ context.Context, userID int64) ([]*messages.Thread, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.threadsOf(userID), nil
+}
+
+func (r *RepoFile) AddMessage(ctx context.Context, m *messages.Message) (int64, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if _, ok := r.store.threads[m.ThreadID]; !ok {
+		return -1, ErrNoThread
+	}
+
+	cp := *m
+	cp.ID = r.store.nextMessageID
+	if err := r.log.Append(opAddMessage, &cp); err != nil {
+		return -1, err
+	}
+
+	m.ID = cp.ID
+	r.store.addMessage(&cp)
+	r.snapshotIfNeeded(ctx)
+
+	return m.ID, nil
+}
+
+func (r *RepoFile) Messages(_ context.Context, threadID int64, page messages.Page) ([]*messages.Message, string, error) {
+	r.m.RLock()
+	list, err := r.store.messagesOf(threadID)
+	r.m.RUnlock()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return page.Cut(list)
+}
+
+func (r *RepoFile) MarkRead(ctx context.Context, threadID, userID int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	t, ok := r.store.threads[threadID]
+	if !ok {
+		return ErrNoThread
+	}
+	// прочитанная переписка не засоряет журнал
+	if t.UnreadFor(userID) == 0 {
+		return nil
+	}
+
+	if err := r.log.Append(opMarkRead, readMark{ThreadID: threadID, UserID: userID}); err != nil {
+		return err
+	}
+
+	t.Read(userID)
+	r.snapshotIfNeeded(ctx)
+
+	return nil
+}
+
+// Close сохраняет итоговый снимок состояния и закрывает журнал
+func (r *RepoFile) Close() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if err := r.log.Snapshot(r.state()); err != nil {
+		_ = r.log.Close()
+		return err
+	}
+
+	return r.log.Close()
+}
+
+// snapshotIfNeeded не возвращает ошибку: операция уже записана в журнал,
+// а неудавшийся снимок будет повторён при следующем изменении
+func (r *RepoFile) snapshotIfNeeded(ctx context.Context) {
+	if !r.log.NeedSnapshot() {
+		return
+	}
+
+	if err := r.log.Snapshot(r.state()); err != nil {
+		r.logger.ErrorContext(ctx, "can't snapshot message repo", "err", err)
+	}
+}
+
+func (r *RepoFile) state() fileState {
+	s := fileState{
+		NextThreadID:  r.store.nextThreadID,
+		NextMessageID: r.store.nextMessageID,
+	}
+	for _, t := range r.store.threads {
+		s.Threads = append(s.Threads, t)
+	}
+	for _, list := range r.store.messages {
+		s.Messages = append(s.Messages, list...)
+	}
+	sort.Slice(s.Messages, func(i, j int) bool {
+		return s.Messages[i].ID < s.Messages[j].ID
+	})
+
+	return s
+}
+
+func (r *RepoFile) restore(data []byte) error {
+	var s fileState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	r.store.nextThreadID = s.NextThreadID
+	r.store.nextMessageID = s.NextMessageID
+	for _, t := range s.Threads {
+		r.store.addThread(t)
+	}
+	for _, m := range s.Messages {
+		r.store.messages[m.ThreadID] = append(r.store.messages[m.ThreadID], m)
+	}
+
+	return nil
+}
+
+func (r *RepoFile) apply(rec wal.Record) error {
+	switch rec.Op {
+	case opAddThread:
+		var t messages.Thread
+		if err := json.Unmarshal(rec.Data, &t); err != nil {
+			return err
+		}
+		r.store.addThread(&t)
+	case opAddMessage:
+		var m messages.Message
+		if err := json.Unmarshal(rec.Data, &m); err != nil {
+			return err
+		}
+		r.store.addMessage(&m)
+	case opMarkRead:
+		var mark readMark
+		if err := json.Unmarshal(rec.Data, &mark); err != nil {
+			return err
+		}
+		if t, ok := r.store.threads[mark.ThreadID]; ok {
+			t.Read(mark.UserID)
+		}
+	default:
+		return fmt.Errorf("%w: unknown op %q", wal.ErrCorrupted, rec.Op)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package msgrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"homework10/internal/logging"
+	"homework10/internal/messages"
+)
+
+func TestRepoFileTestSuite(t *testing.T) {
+	suite.Run(t, &RepoTestSuite{newRepo: func() messages.Repository {
+		r, err := NewFile(t.TempDir(), 3, logging.Discard())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			_ = r.Close()
+		})
+		return r
+	}})
+}
+
+func TestRepoFile_Reopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	r, err := NewFile(dir, 2, logging.Discard())
+	assert.NoError(t, err)
+	_, err = r.AddThread(ctx, &messages.Thread{AdID: 10, BuyerID: 2, SellerID: 1, Created: started})
+	assert.NoError(t, err)
+	for i, sender := range []int64{2, 2, 1} {
+		_, err = r.AddMessage(ctx, &messages.Message{ThreadID: 1, SenderID: sender, Text: "text", Sent: started.Add(time.Duration(i) * time.Minute)})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, r.MarkRead(ctx, 1, 1))
+
+	r2, err := NewFile(dir, 2, logging.Discard())
+	assert.NoError(t, err)
+
+	th, err := r2.ThreadByAd(ctx, 10, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, &messages.Thread{
+		ID:          1,
+		AdID:        10,
+		BuyerID:     2,
+		SellerID:    1,
+		Created:     started,
+		Updated:     started.Add(2 * time.Minute),
+		BuyerUnread: 1,
+	}, th)
+
+	list, _, err := r2.Messages(ctx, 1, messages.DefaultPage())
+	assert.NoError(t, err)
+	assert.Len(t, list, 3)
+
+	ID, err := r2.AddMessage(ctx, &messages.Message{ThreadID: 1, SenderID: 2, Text: "text"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), ID)
+	ID, err = r2.AddThread(ctx, &messages.Thread{AdID: 11, BuyerID: 2, SellerID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), ID)
+	assert.NoError(t, r2.Close())
+}
@@ -0,0 +1,200 @@
+package msgrepo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"homework10/internal/messages"
+)
+
+var (
+	ErrNoThread            = fmt.Errorf("thread does not exist")
+	ErrThreadAlreadyExists = fmt.Errorf("thread already exists")
+)
+
+// RepoMap хранит переписки и сообщения в памяти и отдаёт наружу копии
+type RepoMap struct {
+	store store
+	m     sync.RWMutex
+}
+
+func New() messages.Repository {
+	return &RepoMap{
+		store: newStore(),
+		m:     sync.RWMutex{},
+	}
+}
+
+func (r *RepoMap) ThreadByID(_ context.Context, ID int64) (*messages.Thread, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.thread(ID)
+}
+
+func (r *RepoMap) ThreadByAd(_ context.Context, adID, buyerID int64) (*messages.Thread, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.thread(r.store.byAd[threadKey{AdID: adID, BuyerID: buyerID}])
+}
+
+func (r *RepoMap) AddThread(_ context.Context, t *messages.Thread) (int64, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if err := r.store.canAddThread(t); err != nil {
+		return -1, err
+	}
+
+	t.ID = r.store.nextThreadID
+	r.store.addThread(t)
+
+	return t.ID, nil
+}
+
+func (r *RepoMap) Threads(_ context.Context, userID int64) ([]*messages.Thread, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.threadsOf(userID), nil
+}
+
+func (r *RepoMap) AddMessage(_ context.Context, m *messages.Message) (int64, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if _, ok := r.store.threads[m.ThreadID]; !ok {
+		return -1, ErrNoThread
+	}
+
+	m.ID = r.store.nextMessageID
+	r.store.addMessage(m)
+
+	return m.ID, nil
+}
+
+func (r *RepoMap) Messages(_ context.Context, threadID int64, page messages.Page) ([]*messages.Message, string, error) {
+	r.m.RLock()
+	list, err := r.store.messagesOf(threadID)
+	r.m.RUnlock()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return page.Cut(list)
+}
+
+func (r *RepoMap) MarkRead(_ context.Context, threadID, userID int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	t, ok := r.store.threads[threadID]
+	if !ok {
+		return ErrNoThread
+	}
+
+	t.Read(userID)
+	return nil
+}
+
+// threadKey - по одной переписке на объявление и покупателя
+type threadKey struct {
+	AdID    int64
+	BuyerID int64
+}
+
+// store - переписки с их сообщениями; ID переписок и сообщений не переиспользуются
+type store struct {
+	threads       map[int64]*messages.Thread
+	byAd          map[threadKey]int64
+	messages      map[int64][]*messages.Message
+	nextThreadID  int64
+	nextMessageID int64
+}
+
+func newStore() store {
+	return store{
+		threads:       make(map[int64]*messages.Thread),
+		byAd:          make(map[threadKey]int64),
+		messages:      make(map[int64][]*messages.Message),
+		nextThreadID:  1,
+		nextMessageID: 1,
+	}
+}
+
+func (s *store) thread(ID int64) (*messages.Thread, error) {
+	t, ok := s.threads[ID]
+	if !ok {
+		return nil, ErrNoThread
+	}
+
+	cp := *t
+	return &cp, nil
+}
+
+func (s *store) canAddThread(t *messages.Thread) error {
+	if _, ok := s.threads[t.ID]; ok {
+		return ErrThreadAlreadyExists
+	}
+	if _, ok := s.byAd[threadKey{AdID: t.AdID, BuyerID: t.BuyerID}]; ok {
+		return ErrThreadAlreadyExists
+	}
+	return nil
+}
+
+// addThread сохраняет копию переписки с уже назначенным ID
+func (s *store) addThread(t *messages.Thread) {
+	cp := *t
+	s.threads[cp.ID] = &cp
+	s.byAd[threadKey{AdID: cp.AdID, BuyerID: cp.BuyerID}] = cp.ID
+	if cp.ID >= s.nextThreadID {
+		s.nextThreadID = cp.ID + 1
+	}
+}
+
+// addMessage сохраняет копию сообщения с уже назначенным ID и учитывает его в переписке
+func (s *store) addMessage(m *messages.Message) {
+	cp := *m
+	s.messages[cp.ThreadID] = append(s.messages[cp.ThreadID], &cp)
+	if t, ok := s.threads[cp.ThreadID]; ok {
+		t.Received(cp.SenderID, cp.Sent)
+	}
+	if cp.ID >= s.nextMessageID {
+		s.nextMessageID = cp.ID + 1
+	}
+}
+
+func (s *store) threadsOf(userID int64) []*messages.Thread {
+	var res []*messages.Thread
+	for _, t := range s.threads {
+		if t.Has(userID) {
+			cp := *t
+			res = append(res, &cp)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].Updated.Equal(res[j].Updated) {
+			return res[i].Updated.After(res[j].Updated)
+		}
+		return res[i].ID > res[j].ID
+	})
+
+	return res
+}
+
+func (s *store) messagesOf(threadID int64) ([]*messages.Message, error) {
+	if _, ok := s.threads[threadID]; !ok {
+		return nil, ErrNoThread
+	}
+
+	res := make([]*messages.Message, 0, len(s.messages[threadID]))
+	for _, m := range s.messages[threadID] {
+		cp := *m
+		res = append(res, &cp)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,153 @@
+package msgrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"homework10/internal/messages"
+)
+
+var started = time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+
+type RepoTestSuite struct {
+	suite.Suite
+	repo    messages.Repository
+	newRepo func() messages.Repository
+}
+
+// SetupTest заполняет репозиторий: переписка 1 покупателя 2 с продавцом 1 по объявлению 10
+// (сообщения 1 и 2 от покупателя, 3 - ответ продавца) и переписка 2 покупателя 3 по тому же объявлению без сообщений
+func (s *RepoTestSuite) SetupTest() {
+	ctx := context.Background()
+	s.repo = s.newRepo()
+	_, _ = s.repo.AddThread(ctx, &messages.Thread{AdID: 10, BuyerID: 2, SellerID: 1, Created: started, Updated: started})
+	_, _ = s.repo.AddThread(ctx, &messages.Thread{AdID: 10, BuyerID: 3, SellerID: 1, Created: started, Updated: started})
+	for i, m := range []messages.Message{
+		{ThreadID: 1, SenderID: 2, Text: "Здравствуйте"},
+		{ThreadID: 1, SenderID: 2, Text: "Ещё продаёте?"},
+		{ThreadID: 1, SenderID: 1, Text: "Да"},
+	} {
+		m := m
+		m.Sent = started.Add(time.Duration(i+1) * time.Minute)
+		_, _ = s.repo.AddMessage(ctx, &m)
+	}
+}
+
+func (s *RepoTestSuite) TestAddThread() {
+	tests := []struct {
+		name string
+		t    *messages.Thread
+		want int64
+		err  error
+	}{
+		{
+			name: "ok add thread of buyer 2 for ad 11",
+			t:    &messages.Thread{AdID: 11, BuyerID: 2, SellerID: 1},
+			want: 3,
+		},
+		{
+			name: "wrong add second thread of buyer 2 for ad 10",
+			t:    &messages.Thread{AdID: 10, BuyerID: 2, SellerID: 1},
+			want: -1,
+			err:  ErrThreadAlreadyExists,
+		},
+		{
+			name: "wrong add thread with existing ID=1",
+			t:    &messages.Thread{ID: 1, AdID: 12, BuyerID: 2, SellerID: 1},
+			want: -1,
+			err:  ErrThreadAlreadyExists,
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			ID, err := s.repo.AddThread(context.Background(), tt.t)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, ID)
+		})
+	}
+}
+
+func (s *RepoTestSuite) TestThreadByAd() {
+	t, err := s.repo.ThreadByAd(context.Background(), 10, 3)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(2), t.ID)
+
+	_, err = s.repo.ThreadByAd(context.Background(), 10, 4)
+	assert.ErrorIs(s.T(), err, ErrNoThread)
+	_, err = s.repo.ThreadByID(context.Background(), 0)
+	assert.ErrorIs(s.T(), err, ErrNoThread)
+}
+
+func (s *RepoTestSuite) TestAddMessage() {
+	t, err := s.repo.ThreadByID(context.Background(), 1)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, t.BuyerUnread)
+	assert.Equal(s.T(), 2, t.SellerUnread)
+	assert.Equal(s.T(), started.Add(3*time.Minute), t.Updated)
+
+	_, err = s.repo.AddMessage(context.Background(), &messages.Message{ThreadID: 100, SenderID: 1, Text: "Привет"})
+	assert.ErrorIs(s.T(), err, ErrNoThread)
+
+	ID, err := s.repo.AddMessage(context.Background(), &messages.Message{ThreadID: 2, SenderID: 3, Text: "Привет", Sent: started.Add(time.Hour)})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(4), ID)
+
+	// переписка со свежим сообщением поднимается наверх
+	threads, err := s.repo.Threads(context.Background(), 1)
+	assert.NoError(s.T(), err)
+	if assert.Len(s.T(), threads, 2) {
+		assert.Equal(s.T(), int64(2), threads[0].ID)
+		assert.Equal(s.T(), 1, threads[0].SellerUnread)
+		assert.Equal(s.T(), int64(1), threads[1].ID)
+	}
+
+	threads, err = s.repo.Threads(context.Background(), 3)
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), threads, 1)
+}
+
+func (s *RepoTestSuite) TestMessages() {
+	list, next, err := s.repo.Messages(context.Background(), 1, messages.Page{Limit: 2})
+	assert.NoError(s.T(), err)
+	assert.NotEmpty(s.T(), next)
+	if assert.Len(s.T(), list, 2) {
+		assert.Equal(s.T(), "Да", list[0].Text)
+		assert.Equal(s.T(), "Ещё продаёте?", list[1].Text)
+	}
+
+	list, next, err = s.repo.Messages(context.Background(), 1, messages.Page{Limit: 2, Cursor: next})
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), next)
+	if assert.Len(s.T(), list, 1) {
+		assert.Equal(s.T(), "Здравствуйте", list[0].Text)
+	}
+
+	_, _, err = s.repo.Messages(context.Background(), 1, messages.Page{Cursor: "!"})
+	assert.ErrorIs(s.T(), err, messages.ErrBadPage)
+	_, _, err = s.repo.Messages(context.Background(), 100, messages.DefaultPage())
+	assert.ErrorIs(s.T(), err, ErrNoThread)
+}
+
+func (s *RepoTestSuite) TestMarkRead() {
+	assert.NoError(s.T(), s.repo.MarkRead(context.Background(), 1, 1))
+	assert.NoError(s.T(), s.repo.MarkRead(context.Background(), 1, 1))
+	assert.ErrorIs(s.T(), s.repo.MarkRead(context.Background(), 100, 1), ErrNoThread)
+
+	t, err := s.repo.ThreadByID(context.Background(), 1)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, t.BuyerUnread)
+	assert.Equal(s.T(), 0, t.SellerUnread)
+}
+
+func TestRepoTestSuite(t *testing.T) {
+	suite.Run(t, &RepoTestSuite{newRepo: New})
+}
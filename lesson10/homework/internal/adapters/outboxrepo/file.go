@@ -0,0 +1,162 @@
+package outboxrepo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"homework10/internal/adapters/wal"
+	"homework10/internal/events"
+)
+
+const (
+	opAdd  = "add"
+	opDone = "done"
+)
+
+// RepoFile - исходящие события, переживающие перезапуск сервиса:
+// события хранятся в памяти, каждое изменение пишется в WAL, периодически делается снимок
+type RepoFile struct {
+	store  store
+	log    *wal.Log
+	logger *slog.Logger
+	m      sync.RWMutex
+}
+
+type fileState struct {
+	NextID int64           `json:"next_id"`
+	Events []*events.Event `json:"events"`
+}
+
+// doneMark - запись журнала о разосланном событии
+type doneMark struct {
+	ID int64 `json:"id"`
+}
+
+func NewFile(dir string, snapshotEvery int, logger *slog.Logger) (*RepoFile, error) {
+	l, err := wal.Open(dir, snapshotEvery)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &RepoFile{
+		store:  newStore(),
+		log:    l,
+		logger: logger,
+		m:      sync.RWMutex{},
+	}
+
+	if err = l.Recover(r.restore, r.apply); err != nil {
+		_ = l.Close()
+		return nil, fmt.Errorf("recover outbox: %w", err)
+	}
+
+	return r, nil
+}
+
+func (r *RepoFile) Add(ctx context.Context, e *events.Event) (int64, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	cp := e.Copy()
+	cp.ID = r.store.nextID
+	if err := r.log.Append(opAdd, cp); err != nil {
+		return -1, err
+	}
+
+	e.ID = cp.ID
+	r.store.add(cp)
+	r.snapshotIfNeeded(ctx)
+
+	return e.ID, nil
+}
+
+func (r *RepoFile) Pending(_ context.Context, limit int) ([]*events.Event, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.pending(limit), nil
+}
+
+func (r *RepoFile) Done(ctx context.Context, ID int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if !r.store.has(ID) {
+		return nil
+	}
+	if err := r.log.Append(opDone, doneMark{ID: ID}); err != nil {
+		return err
+	}
+
+	r.store.done(ID)
+	r.snapshotIfNeeded(ctx)
+
+	return nil
+}
+
+// Close сохраняет итоговый снимок состояния и закрывает журнал
+func (r *RepoFile) Close() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if err := r.log.Snapshot(r.state()); err != nil {
+		_ = r.log.Close()
+		return err
+	}
+
+	return r.log.Close()
+}
+
+// snapshotIfNeeded не возвращает ошибку: операция уже записана в журнал,
+// а неудавшийся снимок будет повторён при следующем изменении
+func (r *RepoFile) snapshotIfNeeded(ctx context.Context) {
+	if !r.log.NeedSnapshot() {
+		return
+	}
+
+	if err := r.log.Snapshot(r.state()); err != nil {
+		r.logger.ErrorContext(ctx, "can't snapshot outbox", "err", err)
+	}
+}
+
+func (r *RepoFile) state() fileState {
+	return fileState{NextID: r.store.nextID, Events: r.store.events}
+}
+
+func (r *RepoFile) restore(data []byte) error {
+	var s fileState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	r.store.nextID = s.NextID
+	for _, e := range s.Events {
+		r.store.add(e)
+	}
+
+	return nil
+}
+
+func (r *RepoFile) apply(rec wal.Record) error {
+	switch rec.Op {
+	case opAdd:
+		var e events.Event
+		if err := json.Unmarshal(rec.Data, &e); err != nil {
+			return err
+		}
+		r.store.add(&e)
+	case opDone:
+		var mark doneMark
+		if err := json.Unmarshal(rec.Data, &mark); err != nil {
+			return err
+		}
+		r.store.done(mark.ID)
+	default:
+		return fmt.Errorf("%w: unknown op %q", wal.ErrCorrupted, rec.Op)
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package outboxrepo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"homework10/internal/ads"
+	"homework10/internal/events"
+	"homework10/internal/logging"
+)
+
+func TestRepoFileTestSuite(t *testing.T) {
+	suite.Run(t, &RepoTestSuite{newRepo: func() events.Outbox {
+		r, err := NewFile(t.TempDir(), 3, logging.Discard())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			_ = r.Close()
+		})
+		return r
+	}})
+}
+
+func TestRepoFile_Reopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	r, err := NewFile(dir, 2, logging.Discard())
+	assert.NoError(t, err)
+	for _, tp := range []events.Type{events.AdCreated, events.AdUpdated, events.AdDeleted} {
+		_, err = r.Add(ctx, &events.Event{Type: tp, ObjectID: 1, ActorID: 1, At: started, Ad: &ads.Ad{ID: 1, Status: ads.StatusDraft}})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, r.Done(ctx, 1))
+
+	r2, err := NewFile(dir, 2, logging.Discard())
+	assert.NoError(t, err)
+
+	list, err := r2.Pending(ctx, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, &events.Event{ID: 2, Type: events.AdUpdated, ObjectID: 1, ActorID: 1, At: started, Ad: &ads.Ad{ID: 1, Status: ads.StatusDraft}}, list[0])
+		assert.Equal(t, int64(3), list[1].ID)
+	}
+
+	ID, err := r2.Add(ctx, &events.Event{Type: events.UserCreated, ObjectID: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), ID)
+	assert.NoError(t, r2.Close())
+}
@@ -0,0 +1,97 @@
+package outboxrepo
+
+import (
+	"context"
+	"sync"
+
+	"homework10/internal/events"
+)
+
+// RepoMap хранит исходящие события в памяти и отдаёт наружу копии
+type RepoMap struct {
+	store store
+	m     sync.RWMutex
+}
+
+func New() events.Outbox {
+	return &RepoMap{
+		store: newStore(),
+		m:     sync.RWMutex{},
+	}
+}
+
+func (r *RepoMap) Add(_ context.Context, e *events.Event) (int64, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	e.ID = r.store.nextID
+	r.store.add(e)
+
+	return e.ID, nil
+}
+
+func (r *RepoMap) Pending(_ context.Context, limit int) ([]*events.Event, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.pending(limit), nil
+}
+
+func (r *RepoMap) Done(_ context.Context, ID int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.store.done(ID)
+	return nil
+}
+
+// store - неразосланные события в порядке добавления; ID событий не переиспользуются
+type store struct {
+	events []*events.Event
+	nextID int64
+}
+
+func newStore() store {
+	return store{
+		nextID: 1,
+	}
+}
+
+// add сохраняет копию события с уже назначенным ID
+func (s *store) add(e *events.Event) {
+	s.events = append(s.events, e.Copy())
+	if e.ID >= s.nextID {
+		s.nextID = e.ID + 1
+	}
+}
+
+func (s *store) has(ID int64) bool {
+	for _, e := range s.events {
+		if e.ID == ID {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *store) done(ID int64) {
+	for i, e := range s.events {
+		if e.ID == ID {
+			s.events = append(s.events[:i], s.events[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *store) pending(limit int) []*events.Event {
+	n := len(s.events)
+	if limit > 0 && limit < n {
+		n = limit
+	}
+
+	res := make([]*events.Event, 0, n)
+	for _, e := range s.events[:n] {
+		res = append(res, e.Copy())
+	}
+	return res
+}
@@ -0,0 +1,97 @@
+package outboxrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"homework10/internal/ads"
+	"homework10/internal/events"
+)
+
+var started = time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+
+type RepoTestSuite struct {
+	suite.Suite
+	repo    events.Outbox
+	newRepo func() events.Outbox
+}
+
+// SetupTest заполняет outbox тремя событиями об объявлении 1: создание, публикация и удаление
+func (s *RepoTestSuite) SetupTest() {
+	ctx := context.Background()
+	s.repo = s.newRepo()
+	for _, t := range []events.Type{events.AdCreated, events.AdPublished, events.AdDeleted} {
+		_, _ = s.repo.Add(ctx, &events.Event{Type: t, ObjectID: 1, ActorID: 1, At: started, Ad: &ads.Ad{ID: 1, Title: "title"}})
+	}
+}
+
+func (s *RepoTestSuite) TestAdd() {
+	e := &events.Event{Type: events.UserCreated, ObjectID: 2, At: started}
+	ID, err := s.repo.Add(context.Background(), e)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(4), ID)
+	assert.Equal(s.T(), int64(4), e.ID)
+}
+
+func (s *RepoTestSuite) TestPending() {
+	tests := []struct {
+		name  string
+		limit int
+		want  []int64
+	}{
+		{name: "all events without limit", limit: 0, want: []int64{1, 2, 3}},
+		{name: "first events with limit", limit: 2, want: []int64{1, 2}},
+		{name: "all events with large limit", limit: 10, want: []int64{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			list, err := s.repo.Pending(context.Background(), tt.limit)
+			assert.NoError(s.T(), err)
+			var IDs []int64
+			for _, e := range list {
+				IDs = append(IDs, e.ID)
+			}
+			assert.Equal(s.T(), tt.want, IDs)
+		})
+	}
+}
+
+func (s *RepoTestSuite) TestDone() {
+	ctx := context.Background()
+	assert.NoError(s.T(), s.repo.Done(ctx, 2))
+	// повторная отметка и отметка неизвестного события - не ошибка
+	assert.NoError(s.T(), s.repo.Done(ctx, 2))
+	assert.NoError(s.T(), s.repo.Done(ctx, 10))
+
+	list, err := s.repo.Pending(ctx, 0)
+	assert.NoError(s.T(), err)
+	if assert.Len(s.T(), list, 2) {
+		assert.Equal(s.T(), int64(1), list[0].ID)
+		assert.Equal(s.T(), int64(3), list[1].ID)
+	}
+
+	// ID разосланных событий не переиспользуются
+	ID, err := s.repo.Add(ctx, &events.Event{Type: events.AdCreated, ObjectID: 2})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(4), ID)
+}
+
+func (s *RepoTestSuite) TestPending_ReturnsCopies() {
+	ctx := context.Background()
+	list, err := s.repo.Pending(ctx, 1)
+	assert.NoError(s.T(), err)
+	list[0].Ad.Title = "changed"
+
+	list, err = s.repo.Pending(ctx, 1)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "title", list[0].Ad.Title)
+}
+
+func TestRepoTestSuite(t *testing.T) {
+	suite.Run(t, &RepoTestSuite{newRepo: New})
+}
@@ -0,0 +1,109 @@
+package search
+
+import "math"
+
+// Параметры ранжирования BM25
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Index - инвертированный индекс: для каждой основы слова хранит документы, в которых она встречается,
+// и число её вхождений. Index не потокобезопасен, синхронизацию обеспечивает владелец (репозиторий).
+type Index struct {
+	postings map[string]map[int64]int
+	docTerms map[int64][]string
+	docLen   map[int64]int
+	totalLen int
+}
+
+func NewIndex() *Index {
+	return &Index{
+		postings: make(map[string]map[int64]int),
+		docTerms: make(map[int64][]string),
+		docLen:   make(map[int64]int),
+	}
+}
+
+// Add индексирует документ с идентификатором ID, состоящий из текстов texts;
+// если документ уже был в индексе, он переиндексируется
+func (idx *Index) Add(ID int64, texts ...string) {
+	idx.Remove(ID)
+
+	tf := make(map[string]int)
+	n := 0
+	for _, t := range texts {
+		for _, term := range Tokenize(t) {
+			tf[term]++
+			n++
+		}
+	}
+
+	terms := make([]string, 0, len(tf))
+	for term, cnt := range tf {
+		docs, ok := idx.postings[term]
+		if !ok {
+			docs = make(map[int64]int)
+			idx.postings[term] = docs
+		}
+		docs[ID] = cnt
+		terms = append(terms, term)
+	}
+
+	idx.docTerms[ID] = terms
+	idx.docLen[ID] = n
+	idx.totalLen += n
+}
+
+// Remove убирает документ из индекса
+func (idx *Index) Remove(ID int64) {
+	terms, ok := idx.docTerms[ID]
+	if !ok {
+		return
+	}
+
+	for _, term := range terms {
+		docs := idx.postings[term]
+		delete(docs, ID)
+		if len(docs) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+
+	idx.totalLen -= idx.docLen[ID]
+	delete(idx.docTerms, ID)
+	delete(idx.docLen, ID)
+}
+
+// Search возвращает документы, содержащие хотя бы одно слово запроса, с их оценкой релевантности по BM25
+func (idx *Index) Search(query string) map[int64]float64 {
+	scores := make(map[int64]float64)
+	if len(idx.docLen) == 0 {
+		return scores
+	}
+
+	N := float64(len(idx.docLen))
+	avgLen := float64(idx.totalLen) / N
+	if avgLen == 0 {
+		avgLen = 1
+	}
+
+	seen := make(map[string]struct{})
+	for _, term := range Tokenize(query) {
+		if _, ok := seen[term]; ok {
+			continue
+		}
+		seen[term] = struct{}{}
+
+		docs := idx.postings[term]
+		df := float64(len(docs))
+		idf := math.Log(1 + (N-df+0.5)/(df+0.5))
+		for ID, cnt := range docs {
+			tf := float64(cnt)
+			norm := k1 * (1 - b + b*float64(idx.docLen[ID])/avgLen)
+			scores[ID] += idf * tf * (k1 + 1) / (tf + norm)
+		}
+	}
+
+	return scores
+}
@@ -0,0 +1,111 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "russian word forms share a stem",
+			text: "велосипед велосипеды велосипеда велосипедом",
+			want: []string{"велосипед", "велосипед", "велосипед", "велосипед"},
+		},
+		{
+			name: "russian adjectives",
+			text: "Горный горные ГОРНОГО",
+			want: []string{"горн", "горн", "горн"},
+		},
+		{
+			name: "english word forms share a stem",
+			text: "bike bikes running runs",
+			want: []string{"bike", "bike", "run", "run"},
+		},
+		{
+			name: "punctuation and stop words are dropped",
+			text: "Продам горный велосипед, the mountain bike - и недорого!",
+			want: []string{"прод", "горн", "велосипед", "mountain", "bike", "недор"},
+		},
+		{
+			name: "yo is folded to ye",
+			text: "ёлка елка",
+			want: []string{"елк", "елк"},
+		},
+		{
+			name: "numbers are kept as is",
+			text: "iphone 12",
+			want: []string{"iphon", "12"},
+		},
+		{
+			name: "empty text",
+			text: "  ,.! ",
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Tokenize(tt.text))
+		})
+	}
+}
+
+func TestStemEnglish(t *testing.T) {
+	words := map[string]string{
+		"caresses":       "caress",
+		"ponies":         "poni",
+		"hopping":        "hop",
+		"relational":     "relat",
+		"generalization": "gener",
+		"hopeful":        "hope",
+		"sky":            "sky",
+	}
+
+	for w, want := range words {
+		assert.Equal(t, want, stemEnglish(w), w)
+	}
+}
+
+func TestIndex_Search(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(0, "Продам горный велосипед", "Почти новый, катался одно лето")
+	idx.Add(1, "Велосипед детский", "Велосипед для ребёнка, велосипед в хорошем состоянии")
+	idx.Add(2, "Продам диван", "Диван раскладной")
+
+	scores := idx.Search("велосипеды")
+	assert.Len(t, scores, 2)
+	assert.Greater(t, scores[1], scores[0])
+
+	scores = idx.Search("продам горный велосипед")
+	assert.Len(t, scores, 3)
+	assert.Greater(t, scores[0], scores[1])
+	assert.Greater(t, scores[0], scores[2])
+
+	assert.Empty(t, idx.Search("самокат"))
+	assert.Empty(t, idx.Search("и в на"))
+}
+
+func TestIndex_Update(t *testing.T) {
+	idx := NewIndex()
+	idx.Add(0, "bike", "mountain bike")
+	idx.Add(1, "sofa", "")
+
+	idx.Add(0, "car", "fast car")
+	assert.Empty(t, idx.Search("bike"))
+	assert.Contains(t, idx.Search("cars"), int64(0))
+
+	idx.Remove(0)
+	idx.Remove(42)
+	assert.Empty(t, idx.Search("car"))
+	assert.Len(t, idx.postings, 1)
+	assert.Equal(t, 1, idx.totalLen)
+
+	idx.Remove(1)
+	assert.Empty(t, idx.Search("sofa"))
+}
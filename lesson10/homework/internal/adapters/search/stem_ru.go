@@ -0,0 +1,137 @@
+package search
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Окончания русского стеммера Snowball (Портера).
+// Окончания из групп "...After" отбрасываются, только если перед ними стоит "а" или "я".
+var (
+	ruPerfectiveGerundAfter = []string{"в", "вши", "вшись"}
+	ruPerfectiveGerund      = []string{"ив", "ивши", "ившись", "ыв", "ывши", "ывшись"}
+	ruReflexive             = []string{"ся", "сь"}
+	ruAdjective             = []string{
+		"ее", "ие", "ые", "ое", "ими", "ыми", "ей", "ий", "ый", "ой", "ем", "им", "ым", "ом",
+		"его", "ого", "ему", "ому", "их", "ых", "ую", "юю", "ая", "яя", "ою", "ею",
+	}
+	ruParticipleAfter = []string{"ем", "нн", "вш", "ющ", "щ"}
+	ruParticiple      = []string{"ивш", "ывш", "ующ"}
+	ruVerbAfter       = []string{
+		"ла", "на", "ете", "йте", "ли", "й", "л", "ем", "н", "ло", "но", "ет", "ют", "ны", "ть", "ешь", "нно",
+	}
+	ruVerb = []string{
+		"ила", "ыла", "ена", "ейте", "уйте", "ите", "или", "ыли", "ей", "уй", "ил", "ыл", "им", "ым", "ен",
+		"ило", "ыло", "ено", "ят", "ует", "уют", "ит", "ыт", "ены", "ить", "ыть", "ишь", "ую", "ю",
+	}
+	ruNoun = []string{
+		"а", "ев", "ов", "ие", "ье", "е", "иями", "ями", "ами", "еи", "ии", "и", "ией", "ей", "ой", "ий", "й",
+		"иям", "ям", "ием", "ем", "ам", "ом", "о", "у", "ах", "иях", "ях", "ы", "ь", "ию", "ью", "ю", "ия", "ья", "я",
+	}
+	ruSuperlative  = []string{"ейш", "ейше"}
+	ruDerivational = []string{"ост", "ость"}
+	ruI            = []string{"и"}
+	ruSoftSign     = []string{"ь"}
+	ruDoubleN      = []string{"нн"}
+)
+
+const ruVowels = "аеиоуыэюя"
+
+func isRuVowel(r rune) bool {
+	return strings.ContainsRune(ruVowels, r)
+}
+
+// stemRussian отбрасывает у слова окончание и словообразовательные суффиксы по алгоритму Snowball
+func stemRussian(word string) string {
+	w := []rune(word)
+	rv, r2 := ruRegions(w)
+	if rv >= len(w) {
+		return word
+	}
+
+	if n := ruEnding(w, rv, ruPerfectiveGerund, ruPerfectiveGerundAfter); n > 0 {
+		w = w[:len(w)-n]
+	} else {
+		if n = ruEnding(w, rv, ruReflexive, nil); n > 0 {
+			w = w[:len(w)-n]
+		}
+		if n = ruEnding(w, rv, ruAdjective, nil); n > 0 {
+			w = w[:len(w)-n]
+			if n = ruEnding(w, rv, ruParticiple, ruParticipleAfter); n > 0 {
+				w = w[:len(w)-n]
+			}
+		} else if n = ruEnding(w, rv, ruVerb, ruVerbAfter); n > 0 {
+			w = w[:len(w)-n]
+		} else if n = ruEnding(w, rv, ruNoun, nil); n > 0 {
+			w = w[:len(w)-n]
+		}
+	}
+
+	if n := ruEnding(w, rv, ruI, nil); n > 0 {
+		w = w[:len(w)-n]
+	}
+
+	if n := ruEnding(w, r2, ruDerivational, nil); n > 0 {
+		w = w[:len(w)-n]
+	}
+
+	if n := ruEnding(w, rv, ruSuperlative, nil); n > 0 {
+		w = w[:len(w)-n]
+	}
+	if ruEnding(w, rv, ruDoubleN, nil) > 0 {
+		w = w[:len(w)-1]
+	} else if ruEnding(w, rv, ruSoftSign, nil) > 0 {
+		w = w[:len(w)-1]
+	}
+
+	return string(w)
+}
+
+// ruRegions возвращает начало области RV (после первой гласной) и области R2
+func ruRegions(w []rune) (int, int) {
+	rv := len(w)
+	for i, r := range w {
+		if isRuVowel(r) {
+			rv = i + 1
+			break
+		}
+	}
+
+	r1 := ruRegion(w, 0)
+	return rv, ruRegion(w, r1)
+}
+
+// ruRegion возвращает позицию после первой согласной, следующей за гласной, начиная со start
+func ruRegion(w []rune, start int) int {
+	for i := start + 1; i < len(w); i++ {
+		if !isRuVowel(w[i]) && isRuVowel(w[i-1]) {
+			return i + 1
+		}
+	}
+	return len(w)
+}
+
+// ruEnding ищет самое длинное из окончаний, целиком лежащее в w[limit:], и возвращает его длину или 0.
+// Если самое длинное окончание взято из after, оно засчитывается, только если перед ним стоит "а" или "я".
+func ruEnding(w []rune, limit int, plain, after []string) int {
+	best, fromAfter := 0, false
+	find := func(endings []string, isAfter bool) {
+		for _, e := range endings {
+			n := utf8.RuneCountInString(e)
+			if n > best && n <= len(w)-limit && string(w[len(w)-n:]) == e {
+				best, fromAfter = n, isAfter
+			}
+		}
+	}
+	find(after, true)
+	find(plain, false)
+
+	if best > 0 && fromAfter {
+		i := len(w) - best - 1
+		if i < limit || (w[i] != 'а' && w[i] != 'я') {
+			return 0
+		}
+	}
+
+	return best
+}
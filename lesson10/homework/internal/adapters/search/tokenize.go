@@ -0,0 +1,60 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+var stopWords = map[string]struct{}{
+	// русские
+	"и": {}, "в": {}, "во": {}, "не": {}, "на": {}, "с": {}, "со": {}, "а": {}, "но": {}, "или": {},
+	"к": {}, "ко": {}, "у": {}, "о": {}, "об": {}, "от": {}, "по": {}, "за": {}, "из": {}, "для": {},
+	"до": {}, "же": {}, "ли": {}, "бы": {}, "то": {}, "это": {}, "как": {}, "что": {},
+	// английские
+	"a": {}, "an": {}, "the": {}, "and": {}, "or": {}, "of": {}, "to": {}, "in": {}, "on": {},
+	"for": {}, "with": {}, "at": {}, "by": {}, "from": {}, "is": {}, "are": {}, "it": {}, "this": {},
+}
+
+// Tokenize разбивает текст на слова, приводит их к нижнему регистру, отбрасывает стоп-слова
+// и приводит каждое слово к основе: кириллические - русским стеммером, латинские - английским
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make([]string, 0, len(words))
+	for _, w := range words {
+		w = strings.ReplaceAll(w, "ё", "е")
+		if _, ok := stopWords[w]; ok {
+			continue
+		}
+
+		switch {
+		case isCyrillic(w):
+			w = stemRussian(w)
+		case isLatin(w):
+			w = stemEnglish(w)
+		}
+		tokens = append(tokens, w)
+	}
+
+	return tokens
+}
+
+func isCyrillic(w string) bool {
+	for _, r := range w {
+		if !unicode.Is(unicode.Cyrillic, r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isLatin(w string) bool {
+	for _, r := range w {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
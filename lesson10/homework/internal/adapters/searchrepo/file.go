@@ -0,0 +1,320 @@
+package searchrepo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+
+	"homework10/internal/adapters/wal"
+	"homework10/internal/ads"
+	"homework10/internal/searches"
+)
+
+const (
+	opAddSearch       = "add_search"
+	opUpdateSearch    = "update_search"
+	opDeleteSearch    = "delete_search"
+	opAddNotification = "add_notification"
+	opMarkRead        = "mark_read"
+	opDeleteByUser    = "delete_by_user"
+)
+
+// RepoFile - репозиторий сохранённых поисков, переживающий перезапуск сервиса:
+// состояние хранится в памяти, каждое изменение пишется в WAL, периодически делается снимок
+type RepoFile struct {
+	store  store
+	log    *wal.Log
+	logger *slog.Logger
+	m      sync.RWMutex
+}
+
+// fileState хранит поиски и уведомления без индекса: он строится заново при восстановлении
+type fileState struct {
+	NextSearchID       int64                    `json:"next_search_id"`
+	NextNotificationID int64                    `json:"next_notification_id"`
+	Searches           []*searches.Search       `json:"searches"`
+	Notifications      []*searches.Notification `json:"notifications"`
+}
+
+// readMark - запись журнала о прочтении уведомлений
+type readMark struct {
+	UserID int64 `json:"user_id"`
+	UpTo   int64 `json:"up_to"`
+}
+
+func NewFile(dir string, snapshotEvery int, logger *slog.Logger) (*RepoFile, error) {
+	l, err := wal.Open(dir, snapshotEvery)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &RepoFile{
+		store:  newStore(),
+		log:    l,
+		logger: logger,
+		m:      sync.RWMutex{},
+	}
+
+	if err = l.Recover(r.restore, r.apply); err != nil {
+		_ = l.Close()
+		return nil, fmt.Errorf("recover search repo: %w", err)
+	}
+
+	return r, nil
+}
+
+func (r *RepoFile) AddSearch(ctx context.Context, s *searches.Search) (int64, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if _, err := s.Query.Pattern(); err != nil {
+		return -1, err
+	}
+
+	cp := s.Copy()
+	cp.ID = r.store.nextSearchID
+	if err := r.log.Append(opAddSearch, cp); err != nil {
+		return -1, err
+	}
+
+	s.ID = cp.ID
+	r.store.addSearch(cp)
+	r.snapshotIfNeeded(ctx)
+
+	return s.ID, nil
+}
+
+func (r *RepoFile) SearchByID(_ context.Context, ID int64) (*searches.Search, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.search(ID)
+}
+
+func (r *RepoFile) UpdateSearch(ctx context.Context, s *searches.Search) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if err := r.store.canUpdateSearch(s); err != nil {
+		return err
+	}
+
+	if err := r.log.Append(opUpdateSearch, s); err != nil {
+		return err
+	}
+
+	r.store.addSearch(s)
+	r.snapshotIfNeeded(ctx)
+
+	return nil
+}
+
+func (r *RepoFile) DeleteSearch(ctx context.Context, ID int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if _, ok := r.store.searches[ID]; !ok {
+		return ErrNoSearch
+	}
+
+	if err := r.log.Append(opDeleteSearch, ID); err != nil {
+		return err
+	}
+
+	r.store.deleteSearch(ID)
+	r.snapshotIfNeeded(ctx)
+
+	return nil
+}
+
+func (r *RepoFile) Searches(_ context.Context, userID int64) ([]*searches.Search, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.searchesOf(userID), nil
+}
+
+func (r *RepoFile) Matching(_ context.Context, ad *ads.Ad, within func(category int64) bool) ([]*searches.Search, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.matching(ad, within), nil
+}
+
+func (r *RepoFile) AddNotification(ctx context.Context, n *searches.Notification) (int64, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if _, ok := r.store.notified[notifiedKey{SearchID: n.SearchID, AdID: n.AdID}]; ok {
+		return -1, ErrAlreadyNotified
+	}
+
+	cp := *n
+	cp.ID = r.store.nextNotificationID
+	if err := r.log.Append(opAddNotification, &cp); err != nil {
+		return -1, err
+	}
+
+	n.ID = cp.ID
+	r.store.addNotification(&cp)
+	r.snapshotIfNeeded(ctx)
+
+	return n.ID, nil
+}
+
+func (r *RepoFile) Notifications(_ context.Context, userID int64, limit int) ([]*searches.Notification, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.notificationsOf(userID, limit), nil
+}
+
+func (r *RepoFile) Unread(_ context.Context, userID int64) (int, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.unread(userID), nil
+}
+
+func (r *RepoFile) MarkRead(ctx context.Context, userID, upTo int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	// прочитанные уведомления не засоряют журнал
+	if !r.store.hasUnread(userID, upTo) {
+		return nil
+	}
+
+	if err := r.log.Append(opMarkRead, readMark{UserID: userID, UpTo: upTo}); err != nil {
+		return err
+	}
+
+	r.store.markRead(userID, upTo)
+	r.snapshotIfNeeded(ctx)
+
+	return nil
+}
+
+func (r *RepoFile) DeleteByUser(ctx context.Context, userID int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if len(r.store.searchesOf(userID)) == 0 && len(r.store.notifications[userID]) == 0 {
+		return nil
+	}
+
+	if err := r.log.Append(opDeleteByUser, userID); err != nil {
+		return err
+	}
+
+	r.store.deleteByUser(userID)
+	r.snapshotIfNeeded(ctx)
+
+	return nil
+}
+
+// Close сохраняет итоговый снимок состояния и закрывает журнал
+func (r *RepoFile) Close() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if err := r.log.Snapshot(r.state()); err != nil {
+		_ = r.log.Close()
+		return err
+	}
+
+	return r.log.Close()
+}
+
+// snapshotIfNeeded не возвращает ошибку: операция уже записана в журнал,
+// а неудавшийся снимок будет повторён при следующем изменении
+func (r *RepoFile) snapshotIfNeeded(ctx context.Context) {
+	if !r.log.NeedSnapshot() {
+		return
+	}
+
+	if err := r.log.Snapshot(r.state()); err != nil {
+		r.logger.ErrorContext(ctx, "can't snapshot search repo", "err", err)
+	}
+}
+
+func (r *RepoFile) state() fileState {
+	s := fileState{
+		NextSearchID:       r.store.nextSearchID,
+		NextNotificationID: r.store.nextNotificationID,
+	}
+	for _, found := range r.store.searches {
+		s.Searches = append(s.Searches, found)
+	}
+	sort.Slice(s.Searches, func(i, j int) bool {
+		return s.Searches[i].ID < s.Searches[j].ID
+	})
+	for _, list := range r.store.notifications {
+		s.Notifications = append(s.Notifications, list...)
+	}
+	sort.Slice(s.Notifications, func(i, j int) bool {
+		return s.Notifications[i].ID < s.Notifications[j].ID
+	})
+
+	return s
+}
+
+func (r *RepoFile) restore(data []byte) error {
+	var s fileState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	r.store.nextSearchID = s.NextSearchID
+	r.store.nextNotificationID = s.NextNotificationID
+	for _, found := range s.Searches {
+		r.store.addSearch(found)
+	}
+	for _, n := range s.Notifications {
+		r.store.addNotification(n)
+	}
+
+	return nil
+}
+
+func (r *RepoFile) apply(rec wal.Record) error {
+	switch rec.Op {
+	case opAddSearch, opUpdateSearch:
+		var s searches.Search
+		if err := json.Unmarshal(rec.Data, &s); err != nil {
+			return err
+		}
+		r.store.addSearch(&s)
+	case opDeleteSearch:
+		var ID int64
+		if err := json.Unmarshal(rec.Data, &ID); err != nil {
+			return err
+		}
+		r.store.deleteSearch(ID)
+	case opAddNotification:
+		var n searches.Notification
+		if err := json.Unmarshal(rec.Data, &n); err != nil {
+			return err
+		}
+		r.store.addNotification(&n)
+	case opMarkRead:
+		var mark readMark
+		if err := json.Unmarshal(rec.Data, &mark); err != nil {
+			return err
+		}
+		r.store.markRead(mark.UserID, mark.UpTo)
+	case opDeleteByUser:
+		var userID int64
+		if err := json.Unmarshal(rec.Data, &userID); err != nil {
+			return err
+		}
+		r.store.deleteByUser(userID)
+	default:
+		return fmt.Errorf("%w: unknown op %q", wal.ErrCorrupted, rec.Op)
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package searchrepo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"homework10/internal/ads"
+	"homework10/internal/logging"
+	"homework10/internal/searches"
+)
+
+func TestRepoFileTestSuite(t *testing.T) {
+	suite.Run(t, &RepoTestSuite{newRepo: func() searches.Repository {
+		r, err := NewFile(t.TempDir(), 3, logging.Discard())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			_ = r.Close()
+		})
+		return r
+	}})
+}
+
+func TestRepoFile_Reopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	r, err := NewFile(dir, 2, logging.Discard())
+	assert.NoError(t, err)
+	for _, found := range []*searches.Search{
+		{UserID: 1, Name: "Велосипеды", Query: ads.Query{Query: "велосипед"}, Created: started},
+		{UserID: 1, Name: "Самокаты", Query: ads.Query{Query: "самокат"}, Created: started},
+		{UserID: 2, Name: "Одежда", Query: ads.Query{Category: 4}, Created: started},
+	} {
+		_, err = r.AddSearch(ctx, found)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, r.UpdateSearch(ctx, &searches.Search{ID: 1, UserID: 1, Name: "Горные", Query: ads.Query{Query: "горный"}, Created: started}))
+	assert.NoError(t, r.DeleteSearch(ctx, 2))
+	for _, adID := range []int64{10, 11} {
+		_, err = r.AddNotification(ctx, &searches.Notification{UserID: 1, SearchID: 1, AdID: adID, Title: "Горный велосипед"})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, r.MarkRead(ctx, 1, 1))
+	assert.NoError(t, r.DeleteByUser(ctx, 2))
+
+	r2, err := NewFile(dir, 2, logging.Discard())
+	assert.NoError(t, err)
+
+	list, err := r2.Searches(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []*searches.Search{
+		{ID: 1, UserID: 1, Name: "Горные", Query: ads.Query{Query: "горный"}, Created: started},
+	}, list)
+	list, err = r2.Searches(ctx, 2)
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+
+	// индекс восстановлен вместе с поисками
+	list, err = r2.Matching(ctx, &ads.Ad{Title: "Горный велосипед", Text: "новый"}, within())
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	n, err := r2.Unread(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	_, err = r2.AddNotification(ctx, &searches.Notification{UserID: 1, SearchID: 1, AdID: 11})
+	assert.ErrorIs(t, err, ErrAlreadyNotified)
+
+	ID, err := r2.AddNotification(ctx, &searches.Notification{UserID: 1, SearchID: 1, AdID: 12})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), ID)
+	ID, err = r2.AddSearch(ctx, &searches.Search{UserID: 1, Name: "Ещё", Query: ads.Query{}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), ID)
+	assert.NoError(t, r2.Close())
+}
@@ -0,0 +1,388 @@
+package searchrepo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"homework10/internal/adapters/search"
+	"homework10/internal/ads"
+	"homework10/internal/searches"
+)
+
+var (
+	ErrNoSearch        = fmt.Errorf("saved search does not exist")
+	ErrAlreadyNotified = fmt.Errorf("user was already notified about the ad by the search")
+)
+
+// RepoMap хранит сохранённые поиски и уведомления в памяти и отдаёт наружу копии
+type RepoMap struct {
+	store store
+	m     sync.RWMutex
+}
+
+func New() searches.Repository {
+	return &RepoMap{
+		store: newStore(),
+		m:     sync.RWMutex{},
+	}
+}
+
+func (r *RepoMap) AddSearch(_ context.Context, s *searches.Search) (int64, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if _, err := s.Query.Pattern(); err != nil {
+		return -1, err
+	}
+
+	s.ID = r.store.nextSearchID
+	r.store.addSearch(s)
+
+	return s.ID, nil
+}
+
+func (r *RepoMap) SearchByID(_ context.Context, ID int64) (*searches.Search, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.search(ID)
+}
+
+func (r *RepoMap) UpdateSearch(_ context.Context, s *searches.Search) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if err := r.store.canUpdateSearch(s); err != nil {
+		return err
+	}
+
+	r.store.addSearch(s)
+	return nil
+}
+
+func (r *RepoMap) DeleteSearch(_ context.Context, ID int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if _, ok := r.store.searches[ID]; !ok {
+		return ErrNoSearch
+	}
+
+	r.store.deleteSearch(ID)
+	return nil
+}
+
+func (r *RepoMap) Searches(_ context.Context, userID int64) ([]*searches.Search, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.searchesOf(userID), nil
+}
+
+func (r *RepoMap) Matching(_ context.Context, ad *ads.Ad, within func(category int64) bool) ([]*searches.Search, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.matching(ad, within), nil
+}
+
+func (r *RepoMap) AddNotification(_ context.Context, n *searches.Notification) (int64, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if _, ok := r.store.notified[notifiedKey{SearchID: n.SearchID, AdID: n.AdID}]; ok {
+		return -1, ErrAlreadyNotified
+	}
+
+	n.ID = r.store.nextNotificationID
+	r.store.addNotification(n)
+
+	return n.ID, nil
+}
+
+func (r *RepoMap) Notifications(_ context.Context, userID int64, limit int) ([]*searches.Notification, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.notificationsOf(userID, limit), nil
+}
+
+func (r *RepoMap) Unread(_ context.Context, userID int64) (int, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return r.store.unread(userID), nil
+}
+
+func (r *RepoMap) MarkRead(_ context.Context, userID, upTo int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.store.markRead(userID, upTo)
+	return nil
+}
+
+func (r *RepoMap) DeleteByUser(_ context.Context, userID int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.store.deleteByUser(userID)
+	return nil
+}
+
+// notifiedKey - по каждому поиску об объявлении уведомляют один раз
+type notifiedKey struct {
+	SearchID int64
+	AdID     int64
+}
+
+// matcher - поиск, подготовленный к проверке объявлений: категория проверяется приложением (см. Matching),
+// слова запроса - по индексу, остальные условия - шаблоном
+type matcher struct {
+	pattern  *ads.Pattern
+	category int64
+	terms    []string
+}
+
+// store - поиски с индексом для подбора их под объявления и уведомления пользователей.
+// Поиск с полнотекстовым запросом лежит в индексе под каждым словом запроса, остальные - под своей категорией
+// (0 - любая), так что объявление проверяется только против поисков, которые могут ему подойти
+type store struct {
+	searches           map[int64]*searches.Search
+	matchers           map[int64]*matcher
+	byTerm             map[string]map[int64]struct{}
+	byCategory         map[int64]map[int64]struct{}
+	notifications      map[int64][]*searches.Notification
+	notified           map[notifiedKey]struct{}
+	nextSearchID       int64
+	nextNotificationID int64
+}
+
+func newStore() store {
+	return store{
+		searches:           make(map[int64]*searches.Search),
+		matchers:           make(map[int64]*matcher),
+		byTerm:             make(map[string]map[int64]struct{}),
+		byCategory:         make(map[int64]map[int64]struct{}),
+		notifications:      make(map[int64][]*searches.Notification),
+		notified:           make(map[notifiedKey]struct{}),
+		nextSearchID:       1,
+		nextNotificationID: 1,
+	}
+}
+
+func (s *store) search(ID int64) (*searches.Search, error) {
+	found, ok := s.searches[ID]
+	if !ok {
+		return nil, ErrNoSearch
+	}
+	return found.Copy(), nil
+}
+
+func (s *store) canUpdateSearch(found *searches.Search) error {
+	if _, ok := s.searches[found.ID]; !ok {
+		return ErrNoSearch
+	}
+	_, err := found.Query.Pattern()
+	return err
+}
+
+// addSearch сохраняет копию поиска с уже назначенным ID, заменяя прежнюю версию
+func (s *store) addSearch(found *searches.Search) {
+	cp := found.Copy()
+	s.unindex(cp.ID)
+	s.searches[cp.ID] = cp
+	s.index(cp)
+	if cp.ID >= s.nextSearchID {
+		s.nextSearchID = cp.ID + 1
+	}
+}
+
+func (s *store) deleteSearch(ID int64) {
+	s.unindex(ID)
+	delete(s.searches, ID)
+}
+
+// index добавляет поиск в индекс; поиск с непригодными условиями ни под какое объявление не подходит
+func (s *store) index(found *searches.Search) {
+	p, err := found.Query.Pattern()
+	if err != nil {
+		return
+	}
+
+	m := &matcher{
+		pattern:  p.SetQuery("").SetCategory(0),
+		category: p.Category,
+		terms:    unique(search.Tokenize(p.Query)),
+	}
+	s.matchers[found.ID] = m
+
+	if len(m.terms) == 0 {
+		add(s.byCategory, m.category, found.ID)
+		return
+	}
+	for _, term := range m.terms {
+		add(s.byTerm, term, found.ID)
+	}
+}
+
+func (s *store) unindex(ID int64) {
+	m, ok := s.matchers[ID]
+	if !ok {
+		return
+	}
+
+	delete(s.matchers, ID)
+	if len(m.terms) == 0 {
+		remove(s.byCategory, m.category, ID)
+		return
+	}
+	for _, term := range m.terms {
+		remove(s.byTerm, term, ID)
+	}
+}
+
+func (s *store) searchesOf(userID int64) []*searches.Search {
+	var res []*searches.Search
+	for _, found := range s.searches {
+		if found.UserID == userID {
+			res = append(res, found.Copy())
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+
+	return res
+}
+
+func (s *store) matching(ad *ads.Ad, within func(category int64) bool) []*searches.Search {
+	candidates := make(map[int64]struct{})
+	for _, text := range []string{ad.Title, ad.Text} {
+		for _, term := range search.Tokenize(text) {
+			for ID := range s.byTerm[term] {
+				candidates[ID] = struct{}{}
+			}
+		}
+	}
+	for category, IDs := range s.byCategory {
+		if category != 0 && !within(category) {
+			continue
+		}
+		for ID := range IDs {
+			candidates[ID] = struct{}{}
+		}
+	}
+
+	var res []*searches.Search
+	for ID := range candidates {
+		m := s.matchers[ID]
+		if m.category != 0 && !within(m.category) {
+			continue
+		}
+		if !m.pattern.Fits(ad) {
+			continue
+		}
+		res = append(res, s.searches[ID].Copy())
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+
+	return res
+}
+
+// addNotification сохраняет копию уведомления с уже назначенным ID
+func (s *store) addNotification(n *searches.Notification) {
+	cp := *n
+	s.notifications[cp.UserID] = append(s.notifications[cp.UserID], &cp)
+	s.notified[notifiedKey{SearchID: cp.SearchID, AdID: cp.AdID}] = struct{}{}
+	if cp.ID >= s.nextNotificationID {
+		s.nextNotificationID = cp.ID + 1
+	}
+}
+
+func (s *store) notificationsOf(userID int64, limit int) []*searches.Notification {
+	list := s.notifications[userID]
+
+	var res []*searches.Notification
+	for i := len(list) - 1; i >= 0 && (limit <= 0 || len(res) < limit); i-- {
+		cp := *list[i]
+		res = append(res, &cp)
+	}
+
+	return res
+}
+
+func (s *store) unread(userID int64) int {
+	n := 0
+	for _, notification := range s.notifications[userID] {
+		if !notification.Read {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *store) hasUnread(userID, upTo int64) bool {
+	for _, notification := range s.notifications[userID] {
+		if notification.ID > upTo {
+			break
+		}
+		if !notification.Read {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *store) markRead(userID, upTo int64) {
+	for _, notification := range s.notifications[userID] {
+		if notification.ID > upTo {
+			break
+		}
+		notification.Read = true
+	}
+}
+
+func (s *store) deleteByUser(userID int64) {
+	for ID, found := range s.searches {
+		if found.UserID == userID {
+			s.deleteSearch(ID)
+		}
+	}
+	for _, n := range s.notifications[userID] {
+		delete(s.notified, notifiedKey{SearchID: n.SearchID, AdID: n.AdID})
+	}
+	delete(s.notifications, userID)
+}
+
+func add[K comparable](index map[K]map[int64]struct{}, key K, ID int64) {
+	IDs, ok := index[key]
+	if !ok {
+		IDs = make(map[int64]struct{})
+		index[key] = IDs
+	}
+	IDs[ID] = struct{}{}
+}
+
+func remove[K comparable](index map[K]map[int64]struct{}, key K, ID int64) {
+	delete(index[key], ID)
+	if len(index[key]) == 0 {
+		delete(index, key)
+	}
+}
+
+func unique(terms []string) []string {
+	seen := make(map[string]struct{}, len(terms))
+	var res []string
+	for _, term := range terms {
+		if _, ok := seen[term]; !ok {
+			seen[term] = struct{}{}
+			res = append(res, term)
+		}
+	}
+	return res
+}
@@ -0,0 +1,289 @@
+package searchrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"homework10/internal/ads"
+	"homework10/internal/searches"
+)
+
+var started = time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// within - проверка категорий для Matching: категория объявления лежит в поддеревьях categories
+func within(categories ...int64) func(int64) bool {
+	return func(category int64) bool {
+		for _, c := range categories {
+			if c == category {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+type RepoTestSuite struct {
+	suite.Suite
+	repo    searches.Repository
+	newRepo func() searches.Repository
+}
+
+// SetupTest заполняет репозиторий поисками: 1 и 2 пользователя 1 - по словам и по категории 1 (Электроника),
+// 3 и 4 пользователя 2 - дешевле 1000 рублей в любой категории и опубликованные в категории 5 (Смартфоны)
+func (s *RepoTestSuite) SetupTest() {
+	ctx := context.Background()
+	s.repo = s.newRepo()
+	for _, found := range []searches.Search{
+		{UserID: 1, Name: "Велосипеды", Query: ads.Query{Query: "горный велосипед"}},
+		{UserID: 1, Name: "Электроника", Query: ads.Query{Category: 1}},
+		{UserID: 2, Name: "Дешёвое", Query: ads.Query{PriceMax: ptr(int64(1000)), Currency: "RUB"}},
+		{UserID: 2, Name: "Смартфоны", Query: ads.Query{Category: 5, Published: ptr(true)}},
+	} {
+		found := found
+		found.Created = started
+		found.Updated = started
+		_, _ = s.repo.AddSearch(ctx, &found)
+	}
+}
+
+func (s *RepoTestSuite) TestAddSearch() {
+	tests := []struct {
+		name string
+		s    *searches.Search
+		want int64
+		err  error
+	}{
+		{
+			name: "ok add search by filter",
+			s:    &searches.Search{UserID: 1, Name: "Самокаты", Query: ads.Query{Filter: `title = "самокат"`}},
+			want: 5,
+		},
+		{
+			name: "wrong add search with bad filter",
+			s:    &searches.Search{UserID: 1, Name: "Плохой", Query: ads.Query{Filter: "title"}},
+			want: -1,
+			err:  ads.ErrBadFilter,
+		},
+		{
+			name: "wrong add search with unknown currency",
+			s:    &searches.Search{UserID: 1, Name: "Плохой", Query: ads.Query{Currency: "XXY"}},
+			want: -1,
+			err:  ads.ErrBadFilter,
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			ID, err := s.repo.AddSearch(context.Background(), tt.s)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.want, ID)
+		})
+	}
+}
+
+func (s *RepoTestSuite) TestSearchByID() {
+	found, err := s.repo.SearchByID(context.Background(), 3)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), &searches.Search{
+		ID:      3,
+		UserID:  2,
+		Name:    "Дешёвое",
+		Query:   ads.Query{PriceMax: ptr(int64(1000)), Currency: "RUB"},
+		Created: started,
+		Updated: started,
+	}, found)
+
+	// изменение полученного поиска не меняет сохранённый
+	*found.Query.PriceMax = 5000
+	found, err = s.repo.SearchByID(context.Background(), 3)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(1000), *found.Query.PriceMax)
+
+	_, err = s.repo.SearchByID(context.Background(), 10)
+	assert.ErrorIs(s.T(), err, ErrNoSearch)
+}
+
+func (s *RepoTestSuite) TestUpdateSearch() {
+	ctx := context.Background()
+	found, err := s.repo.SearchByID(ctx, 1)
+	assert.NoError(s.T(), err)
+
+	found.Name = "Самокаты"
+	found.Query = ads.Query{Query: "самокат"}
+	assert.NoError(s.T(), s.repo.UpdateSearch(ctx, found))
+
+	// поиск переиндексирован по новым словам
+	list, err := s.repo.Matching(ctx, &ads.Ad{Title: "Велосипед", Text: "горный"}, within())
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), list)
+	list, err = s.repo.Matching(ctx, &ads.Ad{Title: "Самокат", Text: "детский"}, within())
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []*searches.Search{found}, list)
+
+	err = s.repo.UpdateSearch(ctx, &searches.Search{ID: 1, UserID: 1, Name: "Плохой", Query: ads.Query{Filter: "title"}})
+	assert.ErrorIs(s.T(), err, ads.ErrBadFilter)
+	err = s.repo.UpdateSearch(ctx, &searches.Search{ID: 10, UserID: 1, Name: "Нет"})
+	assert.ErrorIs(s.T(), err, ErrNoSearch)
+}
+
+func (s *RepoTestSuite) TestDeleteSearch() {
+	ctx := context.Background()
+	assert.NoError(s.T(), s.repo.DeleteSearch(ctx, 2))
+	assert.ErrorIs(s.T(), s.repo.DeleteSearch(ctx, 2), ErrNoSearch)
+
+	_, err := s.repo.SearchByID(ctx, 2)
+	assert.ErrorIs(s.T(), err, ErrNoSearch)
+	list, err := s.repo.Matching(ctx, &ads.Ad{Title: "Ноутбук", Text: "мощный", CategoryID: 3}, within(1, 3))
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), list)
+}
+
+func (s *RepoTestSuite) TestSearches() {
+	list, err := s.repo.Searches(context.Background(), 2)
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), list, 2)
+	assert.Equal(s.T(), int64(3), list[0].ID)
+	assert.Equal(s.T(), int64(4), list[1].ID)
+
+	list, err = s.repo.Searches(context.Background(), 3)
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), list)
+}
+
+func (s *RepoTestSuite) TestMatching() {
+	tests := []struct {
+		name string
+		ad   *ads.Ad
+		// categories - категория объявления и её предки
+		categories []int64
+		want       []int64
+	}{
+		{
+			name:       "by words of title and text",
+			ad:         &ads.Ad{Title: "Велосипеды", Text: "для города"},
+			categories: nil,
+			want:       []int64{1},
+		},
+		{
+			name:       "by ancestor category",
+			ad:         &ads.Ad{Title: "Телефон", Text: "новый", CategoryID: 5, Status: ads.StatusDraft},
+			categories: []int64{1, 2, 5},
+			want:       []int64{2},
+		},
+		{
+			name:       "by category and published status",
+			ad:         &ads.Ad{Title: "Телефон", Text: "новый", CategoryID: 5, Status: ads.StatusPublished},
+			categories: []int64{1, 2, 5},
+			want:       []int64{2, 4},
+		},
+		{
+			name:       "by price in any category",
+			ad:         &ads.Ad{Title: "Велосипед", Text: "детский", CategoryID: 4, Price: ads.Price{Amount: 500, Currency: "RUB"}},
+			categories: []int64{4},
+			want:       []int64{1, 3},
+		},
+		{
+			name:       "price in other currency does not match",
+			ad:         &ads.Ad{Title: "Шапка", Text: "тёплая", CategoryID: 4, Price: ads.Price{Amount: 500, Currency: "USD"}},
+			categories: []int64{4},
+			want:       nil,
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			list, err := s.repo.Matching(context.Background(), tt.ad, within(tt.categories...))
+			assert.NoError(t, err)
+
+			var IDs []int64
+			for _, found := range list {
+				IDs = append(IDs, found.ID)
+			}
+			assert.Equal(t, tt.want, IDs)
+		})
+	}
+}
+
+func (s *RepoTestSuite) TestNotifications() {
+	ctx := context.Background()
+	for i, adID := range []int64{10, 11, 12} {
+		ID, err := s.repo.AddNotification(ctx, &searches.Notification{
+			UserID:   1,
+			SearchID: 1,
+			AdID:     adID,
+			Title:    "Велосипед",
+			Created:  started.Add(time.Duration(i) * time.Minute),
+		})
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), int64(i+1), ID)
+	}
+
+	// по тому же поиску об объявлении не уведомляют повторно, по другому поиску - уведомляют
+	ID, err := s.repo.AddNotification(ctx, &searches.Notification{UserID: 1, SearchID: 1, AdID: 10})
+	assert.ErrorIs(s.T(), err, ErrAlreadyNotified)
+	assert.Equal(s.T(), int64(-1), ID)
+	ID, err = s.repo.AddNotification(ctx, &searches.Notification{UserID: 1, SearchID: 2, AdID: 10})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(4), ID)
+
+	list, err := s.repo.Notifications(ctx, 1, 2)
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), list, 2)
+	assert.Equal(s.T(), int64(4), list[0].ID)
+	assert.Equal(s.T(), int64(3), list[1].ID)
+
+	assert.NoError(s.T(), s.repo.MarkRead(ctx, 1, 2))
+	n, err := s.repo.Unread(ctx, 1)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), 2, n)
+
+	list, err = s.repo.Notifications(ctx, 1, 0)
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), list, 4)
+	assert.False(s.T(), list[1].Read)
+	assert.True(s.T(), list[2].Read)
+	assert.True(s.T(), list[3].Read)
+
+	n, err = s.repo.Unread(ctx, 2)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), 0, n)
+}
+
+func (s *RepoTestSuite) TestDeleteByUser() {
+	ctx := context.Background()
+	_, err := s.repo.AddNotification(ctx, &searches.Notification{UserID: 2, SearchID: 3, AdID: 10})
+	assert.NoError(s.T(), err)
+
+	assert.NoError(s.T(), s.repo.DeleteByUser(ctx, 2))
+
+	list, err := s.repo.Searches(ctx, 2)
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), list)
+	notifications, err := s.repo.Notifications(ctx, 2, 0)
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), notifications)
+	matching, err := s.repo.Matching(ctx, &ads.Ad{Title: "Шапка", Text: "тёплая", Price: ads.Price{Amount: 500, Currency: "RUB"}}, within())
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), matching)
+
+	// поиски остальных пользователей на месте
+	list, err = s.repo.Searches(ctx, 1)
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), list, 2)
+}
+
+func TestRepoTestSuite(t *testing.T) {
+	suite.Run(t, &RepoTestSuite{newRepo: New})
+}
@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/auditrepo"
+	"homework10/internal/adapters/blobstore"
+	"homework10/internal/adapters/catrepo"
+	"homework10/internal/adapters/favrepo"
+	"homework10/internal/adapters/hookrepo"
+	"homework10/internal/adapters/msgrepo"
+	"homework10/internal/adapters/outboxrepo"
+	"homework10/internal/adapters/searchrepo"
+	"homework10/internal/adapters/userrepo"
+	"homework10/internal/adapters/wal"
+	"homework10/internal/ads"
+	"homework10/internal/audit"
+	"homework10/internal/categories"
+	"homework10/internal/events"
+	"homework10/internal/favorites"
+	"homework10/internal/images"
+	"homework10/internal/messages"
+	"homework10/internal/searches"
+	"homework10/internal/users"
+	"homework10/internal/webhooks"
+)
+
+// Виды хранилища: в памяти (данные теряются при остановке) и в файлах каталога данных
+const (
+	Memory = "memory"
+	File   = "file"
+)
+
+// Repos - хранилища сервиса; открытые в файлах закрываются через Close
+type Repos struct {
+	Ads        ads.Repository
+	Users      users.Repository
+	Categories categories.Repository
+	Favorites  favorites.Repository
+	Messages   messages.Repository
+	Audit      audit.Repository
+	Outbox     events.Outbox
+	Webhooks   webhooks.Repository
+	Searches   searches.Repository
+	Images     images.Store
+
+	closers []closer
+	logger  *slog.Logger
+}
+
+// closer - открытый репозиторий и его название для лога
+type closer struct {
+	name string
+	c    io.Closer
+}
+
+// Open создаёт репозитории и хранилище фотографий вида kind; файловые хранятся в подкаталогах dir.
+// Если какой-то репозиторий открыть не удалось, уже открытые закрываются
+func Open(kind, dir string, logger *slog.Logger) (*Repos, error) {
+	switch kind {
+	case Memory:
+		return &Repos{
+			Ads:        adrepo.New(),
+			Users:      userrepo.New(),
+			Categories: catrepo.New(),
+			Favorites:  favrepo.New(),
+			Messages:   msgrepo.New(),
+			Audit:      auditrepo.New(),
+			Outbox:     outboxrepo.New(),
+			Webhooks:   hookrepo.New(),
+			Searches:   searchrepo.New(),
+			Images:     blobstore.New(),
+			logger:     logger,
+		}, nil
+	case File:
+		r, err := openFiles(dir, logger)
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		return r, nil
+	default:
+		return nil, fmt.Errorf("unknown storage %q", kind)
+	}
+}
+
+// openFiles открывает файловые репозитории по одному; при ошибке возвращает и уже открытые, чтобы их закрыть
+func openFiles(dir string, logger *slog.Logger) (*Repos, error) {
+	r := &Repos{logger: logger}
+	path := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+
+	var err error
+	if r.Images, err = blobstore.NewFile(path("images")); err != nil {
+		return r, err
+	}
+
+	adRepo, err := adrepo.NewFile(path("ads"), wal.DefaultSnapshotEvery, logger)
+	if err != nil {
+		return r, err
+	}
+	r.Ads = adRepo
+	r.closers = append(r.closers, closer{"ad repo", adRepo})
+
+	userRepo, err := userrepo.NewFile(path("users"), wal.DefaultSnapshotEvery, logger)
+	if err != nil {
+		return r, err
+	}
+	r.Users = userRepo
+	r.closers = append(r.closers, closer{"user repo", userRepo})
+
+	catRepo, err := catrepo.NewFile(path("categories"), wal.DefaultSnapshotEvery, logger)
+	if err != nil {
+		return r, err
+	}
+	r.Categories = catRepo
+	r.closers = append(r.closers, closer{"category repo", catRepo})
+
+	favRepo, err := favrepo.NewFile(path("favorites"), wal.DefaultSnapshotEvery, logger)
+	if err != nil {
+		return r, err
+	}
+	r.Favorites = favRepo
+	r.closers = append(r.closers, closer{"favorite repo", favRepo})
+
+	msgRepo, err := msgrepo.NewFile(path("messages"), wal.DefaultSnapshotEvery, logger)
+	if err != nil {
+		return r, err
+	}
+	r.Messages = msgRepo
+	r.closers = append(r.closers, closer{"message repo", msgRepo})
+
+	auditRepo, err := auditrepo.NewFile(path("audit"), wal.DefaultSnapshotEvery, logger)
+	if err != nil {
+		return r, err
+	}
+	r.Audit = auditRepo
+	r.closers = append(r.closers, closer{"audit repo", auditRepo})
+
+	outbox, err := outboxrepo.NewFile(path("outbox"), wal.DefaultSnapshotEvery, logger)
+	if err != nil {
+		return r, err
+	}
+	r.Outbox = outbox
+	r.closers = append(r.closers, closer{"outbox", outbox})
+
+	hookRepo, err := hookrepo.NewFile(path("webhooks"), wal.DefaultSnapshotEvery, logger)
+	if err != nil {
+		return r, err
+	}
+	r.Webhooks = hookRepo
+	r.closers = append(r.closers, closer{"webhook repo", hookRepo})
+
+	searchRepo, err := searchrepo.NewFile(path("searches"), wal.DefaultSnapshotEvery, logger)
+	if err != nil {
+		return r, err
+	}
+	r.Searches = searchRepo
+	r.closers = append(r.closers, closer{"saved search repo", searchRepo})
+
+	return r, nil
+}
+
+// Close закрывает открытые репозитории в порядке, обратном открытию; ошибки пишутся в лог, а не прерывают закрытие
+func (r *Repos) Close() {
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		if err := r.closers[i].c.Close(); err != nil {
+			r.logger.Error("can't close "+r.closers[i].name, "err", err)
+		}
+	}
+	r.closers = nil
+}
@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/logging"
+	"homework10/internal/users"
+)
+
+type closeFunc func() error
+
+func (f closeFunc) Close() error {
+	return f()
+}
+
+func TestOpen_Memory(t *testing.T) {
+	r, err := Open(Memory, "", logging.Discard())
+	assert.NoError(t, err)
+	assert.NotNil(t, r.Ads)
+	assert.NotNil(t, r.Images)
+	r.Close()
+}
+
+func TestOpen_Unknown(t *testing.T) {
+	_, err := Open("cloud", "", logging.Discard())
+	assert.Error(t, err)
+}
+
+func TestOpen_File(t *testing.T) {
+	dir := t.TempDir()
+
+	r, err := Open(File, dir, logging.Discard())
+	assert.NoError(t, err)
+	_, err = r.Users.AddUser(context.Background(), &users.User{ID: -1, Nickname: "jenny"})
+	assert.NoError(t, err)
+	r.Close()
+
+	r, err = Open(File, dir, logging.Discard())
+	assert.NoError(t, err)
+	defer r.Close()
+	u, err := r.Users.UserByID(context.Background(), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "jenny", u.Nickname)
+}
+
+func TestOpen_FileError(t *testing.T) {
+	dir := t.TempDir()
+	// последний репозиторий не откроется: на месте его каталога файл
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "searches"), nil, 0o644))
+
+	_, err := Open(File, dir, logging.Discard())
+	assert.Error(t, err)
+}
+
+func TestRepos_Close(t *testing.T) {
+	var closed []string
+	closing := func(name string, err error) closer {
+		return closer{name: name, c: closeFunc(func() error {
+			closed = append(closed, name)
+			return err
+		})}
+	}
+	r := &Repos{
+		closers: []closer{closing("first", nil), closing("second", fmt.Errorf("unknown error")), closing("third", nil)},
+		logger:  logging.Discard(),
+	}
+
+	// ошибка одного репозитория не мешает закрыть остальные, а закрываются они в обратном порядке
+	r.Close()
+	assert.Equal(t, []string{"third", "second", "first"}, closed)
+
+	r.Close()
+	assert.Len(t, closed, 3)
+}
@@ -0,0 +1,221 @@
+package userrepo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"homework10/internal/adapters/wal"
+	"homework10/internal/users"
+)
+
+const (
+	opAdd    = "add"
+	opUpdate = "update"
+	opDelete = "delete"
+)
+
+// RepoFile - репозиторий пользователей, переживающий перезапуск сервиса (как и RepoMap, работает с копиями):
+// состояние хранится в памяти, каждое изменение пишется в WAL, периодически делается снимок
+type RepoFile struct {
+	storage map[int64]*users.User
+	nextID  int64
+	log     *wal.Log
+	logger  *slog.Logger
+	m       sync.RWMutex
+}
+
+type fileState struct {
+	NextID int64         `json:"next_id"`
+	Users  []*users.User `json:"users"`
+}
+
+func NewFile(dir string, snapshotEvery int, logger *slog.Logger) (*RepoFile, error) {
+	l, err := wal.Open(dir, snapshotEvery)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &RepoFile{
+		storage: make(map[int64]*users.User),
+		log:     l,
+		logger:  logger,
+		m:       sync.RWMutex{},
+	}
+
+	if err = l.Recover(r.restore, r.apply); err != nil {
+		_ = l.Close()
+		return nil, fmt.Errorf("recover user repo: %w", err)
+	}
+
+	return r, nil
+}
+
+func (r *RepoFile) UserByID(_ context.Context, ID int64) (*users.User, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	u, ok := r.storage[ID]
+
+	if !ok {
+		return nil, ErrNoUser
+	}
+
+	cp := *u
+	return &cp, nil
+}
+
+func (r *RepoFile) AddUser(ctx context.Context, u *users.User) (int64, error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	_, ok := r.storage[u.ID]
+	if ok {
+		return -1, ErrUserAlreadyExists
+	}
+
+	cp := *u
+	cp.ID = r.nextID
+	cp.Version = 1
+	if err := r.log.Append(opAdd, &cp); err != nil {
+		return -1, err
+	}
+
+	u.ID = cp.ID
+	u.Version = cp.Version
+	r.storage[cp.ID] = &cp
+	r.nextID++
+	r.snapshotIfNeeded(ctx)
+
+	return u.ID, nil
+}
+
+func (r *RepoFile) UpdateUser(ctx context.Context, u *users.User) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	old, ok := r.storage[u.ID]
+	if !ok {
+		return ErrNoUser
+	}
+	if old.Version != u.Version {
+		return ErrUserVersionConflict
+	}
+
+	cp := *u
+	cp.Version++
+	if err := r.log.Append(opUpdate, &cp); err != nil {
+		return err
+	}
+
+	u.Version = cp.Version
+	r.storage[cp.ID] = &cp
+	r.snapshotIfNeeded(ctx)
+
+	return nil
+}
+
+func (r *RepoFile) DeleteUser(ctx context.Context, ID int64) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	_, ok := r.storage[ID]
+	if !ok {
+		return ErrNoUser
+	}
+
+	if err := r.log.Append(opDelete, ID); err != nil {
+		return err
+	}
+
+	delete(r.storage, ID)
+	r.snapshotIfNeeded(ctx)
+
+	return nil
+}
+
+func (r *RepoFile) CountUsers(_ context.Context) (int, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return len(r.storage), nil
+}
+
+// Close сохраняет итоговый снимок состояния и закрывает журнал
+func (r *RepoFile) Close() error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if err := r.log.Snapshot(r.state()); err != nil {
+		_ = r.log.Close()
+		return err
+	}
+
+	return r.log.Close()
+}
+
+// snapshotIfNeeded не возвращает ошибку: операция уже записана в журнал,
+// а неудавшийся снимок будет повторён при следующем изменении
+func (r *RepoFile) snapshotIfNeeded(ctx context.Context) {
+	if !r.log.NeedSnapshot() {
+		return
+	}
+
+	if err := r.log.Snapshot(r.state()); err != nil {
+		r.logger.ErrorContext(ctx, "can't snapshot user repo", "err", err)
+	}
+}
+
+func (r *RepoFile) state() fileState {
+	s := fileState{NextID: r.nextID, Users: make([]*users.User, 0, len(r.storage))}
+	for _, u := range r.storage {
+		s.Users = append(s.Users, u)
+	}
+
+	return s
+}
+
+func (r *RepoFile) restore(data []byte) error {
+	var s fileState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	r.nextID = s.NextID
+	for _, u := range s.Users {
+		r.storage[u.ID] = u
+	}
+
+	return nil
+}
+
+func (r *RepoFile) apply(rec wal.Record) error {
+	switch rec.Op {
+	case opAdd:
+		var u users.User
+		if err := json.Unmarshal(rec.Data, &u); err != nil {
+			return err
+		}
+		r.storage[u.ID] = &u
+		if u.ID >= r.nextID {
+			r.nextID = u.ID + 1
+		}
+	case opUpdate:
+		var u users.User
+		if err := json.Unmarshal(rec.Data, &u); err != nil {
+			return err
+		}
+		r.storage[u.ID] = &u
+	case opDelete:
+		var ID int64
+		if err := json.Unmarshal(rec.Data, &ID); err != nil {
+			return err
+		}
+		delete(r.storage, ID)
+	default:
+		return fmt.Errorf("%w: unknown op %q", wal.ErrCorrupted, rec.Op)
+	}
+
+	return nil
+}
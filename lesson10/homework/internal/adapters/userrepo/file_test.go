@@ -0,0 +1,54 @@
+package userrepo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+
+	"homework10/internal/logging"
+	"homework10/internal/users"
+)
+
+func TestRepoFileTestSuite(t *testing.T) {
+	suite.Run(t, &RepoTestSuite{newRepo: func() users.Repository {
+		r, err := NewFile(t.TempDir(), 3, logging.Discard())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			_ = r.Close()
+		})
+		return r
+	}})
+}
+
+func TestRepoFile_Reopen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	r, err := NewFile(dir, 2, logging.Discard())
+	assert.NoError(t, err)
+	for _, nick := range []string{"jenny", "polly", "molly"} {
+		_, err = r.AddUser(ctx, &users.User{ID: -1, Nickname: nick, Email: nick + "@gmail.com"})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, r.DeleteUser(ctx, 0))
+
+	r2, err := NewFile(dir, 2, logging.Discard())
+	assert.NoError(t, err)
+
+	_, err = r2.UserByID(ctx, 0)
+	assert.ErrorIs(t, err, ErrNoUser)
+
+	u, err := r2.UserByID(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, "molly", u.Nickname)
+	assert.Equal(t, "molly@gmail.com", u.Email)
+
+	id, err := r2.AddUser(ctx, &users.User{ID: -1, Nickname: "dolly", Email: "dolly@gmail.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), id)
+	assert.NoError(t, r2.Close())
+}
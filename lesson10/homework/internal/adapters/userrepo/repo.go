@@ -9,12 +9,17 @@ import (
 )
 
 var (
-	ErrNoUser            = fmt.Errorf("user does not exist")
-	ErrUserAlreadyExists = fmt.Errorf("user already exists")
+	ErrNoUser              = fmt.Errorf("user does not exist")
+	ErrUserAlreadyExists   = fmt.Errorf("user already exists")
+	ErrUserVersionConflict = fmt.Errorf("user version conflict")
 )
 
+// RepoMap хранит копии пользователей и отдаёт наружу тоже копии,
+// поэтому любое изменение пользователя должно проходить через UpdateUser.
+// ID не переиспользуются: иначе новый пользователь получил бы токены и данные удалённого
 type RepoMap struct {
 	storage map[int64]*users.User
+	nextID  int64
 	m       sync.RWMutex
 }
 
@@ -34,7 +39,8 @@ func (r *RepoMap) UserByID(_ context.Context, ID int64) (*users.User, error) {
 		return nil, ErrNoUser
 	}
 
-	return u, nil
+	cp := *u
+	return &cp, nil
 }
 
 func (r *RepoMap) AddUser(_ context.Context, u *users.User) (int64, error) {
@@ -46,12 +52,36 @@ func (r *RepoMap) AddUser(_ context.Context, u *users.User) (int64, error) {
 		return -1, ErrUserAlreadyExists
 	}
 
-	u.ID = int64(len(r.storage))
-	r.storage[u.ID] = u
+	u.ID = r.nextID
+	u.Version = 1
+	cp := *u
+	r.storage[u.ID] = &cp
+	r.nextID++
 
 	return u.ID, nil
 }
 
+// UpdateUser заменяет пользователя, если его версия в хранилище совпадает с u.Version,
+// и увеличивает версию (в том числе у переданного u)
+func (r *RepoMap) UpdateUser(_ context.Context, u *users.User) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	old, ok := r.storage[u.ID]
+	if !ok {
+		return ErrNoUser
+	}
+	if old.Version != u.Version {
+		return ErrUserVersionConflict
+	}
+
+	u.Version++
+	cp := *u
+	r.storage[u.ID] = &cp
+
+	return nil
+}
+
 func (r *RepoMap) DeleteUser(_ context.Context, ID int64) error {
 	r.m.Lock()
 	defer r.m.Unlock()
@@ -65,3 +95,10 @@ func (r *RepoMap) DeleteUser(_ context.Context, ID int64) error {
 
 	return nil
 }
+
+func (r *RepoMap) CountUsers(_ context.Context) (int, error) {
+	r.m.RLock()
+	defer r.m.RUnlock()
+
+	return len(r.storage), nil
+}
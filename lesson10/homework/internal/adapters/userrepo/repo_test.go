@@ -13,11 +13,12 @@ import (
 
 type RepoTestSuite struct {
 	suite.Suite
-	repo users.Repository
+	repo    users.Repository
+	newRepo func() users.Repository
 }
 
 func (s *RepoTestSuite) SetupTest() {
-	s.repo = New()
+	s.repo = s.newRepo()
 	for i := 0; i < 5; i++ {
 		_, _ = s.repo.AddUser(context.Background(), &users.User{
 			ID:       -1,
@@ -174,6 +175,64 @@ func (s *RepoTestSuite) TestUserByID() {
 	}
 }
 
+func (s *RepoTestSuite) TestUpdateUser() {
+	type args struct {
+		ctx context.Context
+		u   *users.User
+	}
+	tests := []struct {
+		name        string
+		args        args
+		wantVersion int64
+		wantErr     bool
+		err         error
+	}{
+		{
+			name: "ok update user with ID=1",
+			args: args{
+				ctx: context.Background(),
+				u:   &users.User{ID: 1, Nickname: "new.user1", Email: "new.user1@gmail.com", Version: 1},
+			},
+			wantVersion: 2,
+			wantErr:     false,
+		},
+		{
+			name: "wrong update user with ID=1 (stale version)",
+			args: args{
+				ctx: context.Background(),
+				u:   &users.User{ID: 1, Nickname: "other", Email: "other@gmail.com", Version: 1},
+			},
+			wantErr: true,
+			err:     ErrUserVersionConflict,
+		},
+		{
+			name: "wrong update user with ID=100",
+			args: args{
+				ctx: context.Background(),
+				u:   &users.User{ID: 100, Version: 1},
+			},
+			wantErr: true,
+			err:     ErrNoUser,
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			err := s.repo.UpdateUser(tt.args.ctx, tt.args.u)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantVersion, tt.args.u.Version)
+
+				u, err := s.repo.UserByID(tt.args.ctx, tt.args.u.ID)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.args.u, u)
+			}
+		})
+	}
+}
+
 func (s *RepoTestSuite) TestDeleteUser() {
 	type args struct {
 		ctx context.Context
@@ -235,6 +294,35 @@ func (s *RepoTestSuite) TestDeleteUser() {
 	}
 }
 
+func (s *RepoTestSuite) TestCountUsers() {
+	n, err := s.repo.CountUsers(context.Background())
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), 5, n)
+
+	assert.NoError(s.T(), s.repo.DeleteUser(context.Background(), 2))
+	n, err = s.repo.CountUsers(context.Background())
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), 4, n)
+}
+
+func (s *RepoTestSuite) TestAddUser_AfterDelete() {
+	ctx := context.Background()
+	assert.NoError(s.T(), s.repo.DeleteUser(ctx, 0))
+
+	// ID удалённого пользователя не достаётся новому, и живые пользователи не перезаписываются
+	id, err := s.repo.AddUser(ctx, &users.User{ID: -1, Nickname: "jenny", Email: "jenny@gmail.com"})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(5), id)
+
+	for i := int64(1); i < 5; i++ {
+		u, err := s.repo.UserByID(ctx, i)
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), fmt.Sprintf("user%d", i), u.Nickname)
+	}
+	_, err = s.repo.UserByID(ctx, 0)
+	assert.ErrorIs(s.T(), err, ErrNoUser)
+}
+
 func TestRepoTestSuite(t *testing.T) {
-	suite.Run(t, new(RepoTestSuite))
+	suite.Run(t, &RepoTestSuite{newRepo: New})
 }
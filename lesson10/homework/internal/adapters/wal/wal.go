@@ -0,0 +1,269 @@
+package wal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+const (
+	logFile      = "wal.log"
+	snapshotFile = "snapshot.json"
+
+	DefaultSnapshotEvery = 1000
+)
+
+var ErrCorrupted = fmt.Errorf("wal is corrupted")
+
+// Record - одна запись журнала: порядковый номер, операция и её данные
+type Record struct {
+	Seq  uint64          `json:"seq"`
+	Op   string          `json:"op"`
+	Data json.RawMessage `json:"data"`
+}
+
+type snapshot struct {
+	Seq   uint64          `json:"seq"`
+	State json.RawMessage `json:"state"`
+}
+
+// Log - журнал упреждающей записи (WAL) с периодическими снимками состояния.
+// Каждая запись - отдельная строка вида "<crc32> <json>\n", после записи делается fsync.
+// Снимок пишется во временный файл и атомарно переименовывается, после чего журнал обрезается.
+type Log struct {
+	dir           string
+	f             *os.File
+	seq           uint64
+	records       int
+	snapshotEvery int
+	m             sync.Mutex
+}
+
+func Open(dir string, snapshotEvery int) (*Log, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, logFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if snapshotEvery <= 0 {
+		snapshotEvery = DefaultSnapshotEvery
+	}
+
+	return &Log{
+		dir:           dir,
+		f:             f,
+		snapshotEvery: snapshotEvery,
+	}, nil
+}
+
+// Recover восстанавливает состояние после (в том числе аварийного) перезапуска:
+// сначала передаёт в restore последний снимок (если он есть), затем в apply - записи журнала,
+// сделанные после снимка. Недописанная последняя запись отбрасывается, журнал обрезается по ней.
+func (l *Log) Recover(restore func(state []byte) error, apply func(r Record) error) error {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	data, err := os.ReadFile(filepath.Join(l.dir, snapshotFile))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil {
+		var s snapshot
+		if err = json.Unmarshal(data, &s); err != nil {
+			return fmt.Errorf("%w: bad snapshot: %s", ErrCorrupted, err.Error())
+		}
+		if err = restore(s.State); err != nil {
+			return err
+		}
+		l.seq = s.Seq
+	}
+
+	if _, err = l.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	var offset int64
+	rd := bufio.NewReader(l.f)
+	for {
+		line, err := rd.ReadBytes('\n')
+		if err == io.EOF {
+			// хвост без перевода строки - запись не успела долететь до диска
+			break
+		} else if err != nil {
+			return err
+		}
+
+		r, ok := decode(line)
+		if !ok {
+			rest, _ := io.ReadAll(rd)
+			if len(bytes.TrimSpace(rest)) != 0 {
+				return fmt.Errorf("%w: bad record at offset %d", ErrCorrupted, offset)
+			}
+			break
+		}
+		offset += int64(len(line))
+
+		if r.Seq <= l.seq {
+			// запись уже учтена в снимке
+			continue
+		}
+		if err = apply(r); err != nil {
+			return err
+		}
+		l.seq = r.Seq
+		l.records++
+	}
+
+	if err = l.f.Truncate(offset); err != nil {
+		return err
+	}
+	_, err = l.f.Seek(offset, io.SeekStart)
+
+	return err
+}
+
+// Append записывает операцию в журнал и дожидается её сброса на диск
+func (l *Log) Append(op string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	r := Record{Seq: l.seq + 1, Op: op, Data: data}
+	if err = l.write(r); err != nil {
+		return err
+	}
+	l.seq = r.Seq
+	l.records++
+
+	return nil
+}
+
+// NeedSnapshot сообщает, что с последнего снимка накопилось достаточно записей
+func (l *Log) NeedSnapshot() bool {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	return l.records >= l.snapshotEvery
+}
+
+// Snapshot сохраняет состояние state, покрывающее все записанные операции, и очищает журнал
+func (l *Log) Snapshot(state any) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	data, err = json.Marshal(snapshot{Seq: l.seq, State: data})
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(l.dir, snapshotFile+".tmp")
+	if err = writeFileSync(tmp, data); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, filepath.Join(l.dir, snapshotFile)); err != nil {
+		return err
+	}
+	if err = syncDir(l.dir); err != nil {
+		return err
+	}
+
+	// если упадём здесь, то при восстановлении записи с seq <= seq снимка будут пропущены
+	if err = l.f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err = l.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	l.records = 0
+
+	return l.f.Sync()
+}
+
+func (l *Log) Close() error {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	return l.f.Close()
+}
+
+func (l *Log) write(r Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	line := make([]byte, 0, len(data)+10)
+	line = strconv.AppendUint(line, uint64(crc32.ChecksumIEEE(data)), 16)
+	line = append(line, ' ')
+	line = append(line, data...)
+	line = append(line, '\n')
+
+	if _, err = l.f.Write(line); err != nil {
+		return err
+	}
+
+	return l.f.Sync()
+}
+
+func decode(line []byte) (Record, bool) {
+	var r Record
+
+	sum, data, ok := bytes.Cut(bytes.TrimSuffix(line, []byte("\n")), []byte(" "))
+	if !ok {
+		return r, false
+	}
+	crc, err := strconv.ParseUint(string(sum), 16, 32)
+	if err != nil || uint32(crc) != crc32.ChecksumIEEE(data) {
+		return r, false
+	}
+	if err = json.Unmarshal(data, &r); err != nil {
+		return r, false
+	}
+
+	return r, true
+}
+
+func writeFileSync(name string, data []byte) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
@@ -0,0 +1,176 @@
+package wal
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type counter struct {
+	Sum int `json:"sum"`
+}
+
+func replay(t *testing.T, l *Log) (counter, []Record) {
+	var (
+		c    counter
+		recs []Record
+	)
+	err := l.Recover(func(state []byte) error {
+		return json.Unmarshal(state, &c)
+	}, func(r Record) error {
+		var v int
+		if err := json.Unmarshal(r.Data, &v); err != nil {
+			return err
+		}
+		c.Sum += v
+		recs = append(recs, r)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	return c, recs
+}
+
+func TestLog_AppendRecover(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := Open(dir, 100)
+	assert.NoError(t, err)
+	_, _ = replay(t, l)
+	for i := 1; i <= 3; i++ {
+		assert.NoError(t, l.Append("add", i))
+	}
+	assert.NoError(t, l.Close())
+
+	l, err = Open(dir, 100)
+	assert.NoError(t, err)
+	c, recs := replay(t, l)
+	assert.Equal(t, 6, c.Sum)
+	assert.Len(t, recs, 3)
+	assert.Equal(t, uint64(3), recs[2].Seq)
+
+	assert.NoError(t, l.Append("add", 4))
+	assert.NoError(t, l.Close())
+
+	l, err = Open(dir, 100)
+	assert.NoError(t, err)
+	c, _ = replay(t, l)
+	assert.Equal(t, 10, c.Sum)
+	assert.NoError(t, l.Close())
+}
+
+func TestLog_Snapshot(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := Open(dir, 2)
+	assert.NoError(t, err)
+	_, _ = replay(t, l)
+
+	assert.NoError(t, l.Append("add", 1))
+	assert.False(t, l.NeedSnapshot())
+	assert.NoError(t, l.Append("add", 2))
+	assert.True(t, l.NeedSnapshot())
+	assert.NoError(t, l.Snapshot(counter{Sum: 3}))
+	assert.False(t, l.NeedSnapshot())
+	assert.NoError(t, l.Append("add", 5))
+	assert.NoError(t, l.Close())
+
+	l, err = Open(dir, 2)
+	assert.NoError(t, err)
+	c, recs := replay(t, l)
+	assert.Equal(t, 8, c.Sum)
+	assert.Len(t, recs, 1)
+	assert.NoError(t, l.Close())
+}
+
+func TestLog_RecoverSkipsRecordsInSnapshot(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := Open(dir, 100)
+	assert.NoError(t, err)
+	_, _ = replay(t, l)
+	assert.NoError(t, l.Append("add", 1))
+	assert.NoError(t, l.Append("add", 2))
+	assert.NoError(t, l.Close())
+
+	// имитируем падение между записью снимка и обрезкой журнала
+	data, err := json.Marshal(snapshot{Seq: 2, State: json.RawMessage(`{"sum":3}`)})
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, snapshotFile), data, 0o644))
+
+	l, err = Open(dir, 100)
+	assert.NoError(t, err)
+	c, recs := replay(t, l)
+	assert.Equal(t, 3, c.Sum)
+	assert.Empty(t, recs)
+	assert.NoError(t, l.Close())
+}
+
+func TestLog_RecoverTornTail(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := Open(dir, 100)
+	assert.NoError(t, err)
+	_, _ = replay(t, l)
+	assert.NoError(t, l.Append("add", 1))
+	assert.NoError(t, l.Append("add", 2))
+	assert.NoError(t, l.Close())
+
+	name := filepath.Join(dir, logFile)
+	data, err := os.ReadFile(name)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name string
+		tail string
+	}{
+		{name: "no newline", tail: `1a2b3c {"seq":3,"op":"add","da`},
+		{name: "bad checksum", tail: "0 {\"seq\":3,\"op\":\"add\",\"data\":3}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NoError(t, os.WriteFile(name, append(append([]byte{}, data...), tt.tail...), 0o644))
+
+			l, err := Open(dir, 100)
+			assert.NoError(t, err)
+			c, recs := replay(t, l)
+			assert.Equal(t, 3, c.Sum)
+			assert.Len(t, recs, 2)
+
+			// после восстановления журнал продолжается с места обрыва
+			assert.NoError(t, l.Append("add", 10))
+			assert.NoError(t, l.Close())
+
+			l, err = Open(dir, 100)
+			assert.NoError(t, err)
+			c, _ = replay(t, l)
+			assert.Equal(t, 13, c.Sum)
+			assert.NoError(t, l.Close())
+		})
+	}
+}
+
+func TestLog_RecoverCorruptedMiddle(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := Open(dir, 100)
+	assert.NoError(t, err)
+	_, _ = replay(t, l)
+	assert.NoError(t, l.Append("add", 1))
+	assert.NoError(t, l.Close())
+
+	name := filepath.Join(dir, logFile)
+	data, err := os.ReadFile(name)
+	assert.NoError(t, err)
+	data = append([]byte("garbage\n"), data...)
+	assert.NoError(t, os.WriteFile(name, data, 0o644))
+
+	l, err = Open(dir, 100)
+	assert.NoError(t, err)
+	err = l.Recover(func([]byte) error { return nil }, func(Record) error { return nil })
+	assert.ErrorIs(t, err, ErrCorrupted)
+	assert.NoError(t, l.Close())
+}
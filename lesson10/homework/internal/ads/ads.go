@@ -3,11 +3,46 @@ package ads
 import "time"
 
 type Ad struct {
-	ID        int64
-	Title     string `validate:"min:1;max:99"`
-	Text      string `validate:"min:1;max:499"`
-	UserID    int64
-	Published bool
-	Created   time.Time
-	Updated   time.Time
+	ID     int64
+	Title  string `validate:"min:1;max:99"`
+	Text   string `validate:"min:1;max:499"`
+	UserID int64
+	// CategoryID - категория объявления, 0 - без категории (у объявлений, созданных до появления категорий)
+	CategoryID int64
+	// Price - цена; валидируется отдельно (см. Price.Valid), нулевая - не указана
+	Price Price
+	// Location - где находится объявление, nil - не указано. Не меняется на месте, а заменяется целиком
+	Location *Location
+	Status   Status
+	Created  time.Time
+	Updated  time.Time
+	Version  int64
+	// Expires - когда объявление истекает и уходит в архив, нулевое - бессрочное
+	Expires time.Time
+	// Transitions - история смены статусов, в порядке их применения
+	Transitions []Transition
+	// Images - фотографии объявления в порядке загрузки
+	Images []Image
+	// Favorites - у скольких пользователей объявление в избранном. Хранится не в объявлении,
+	// а в репозитории избранного: его заполняет приложение при чтении объявлений
+	Favorites int `json:"-"`
+	// Distance - расстояние в километрах до центра поиска по радиусу (см. Pattern.Near). Не хранится:
+	// его заполняет репозиторий в объявлениях, выбранных по шаблону с Near, в остальных случаях nil
+	Distance *float64 `json:"-"`
+}
+
+// Image - фотография объявления; сами файлы изображения и миниатюры лежат в images.Store
+type Image struct {
+	// ID - ключ файла изображения
+	ID string
+	// Thumb - ключ файла миниатюры
+	Thumb       string
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// Expired сообщает, истёк ли к моменту now срок объявления
+func (ad *Ad) Expired(now time.Time) bool {
+	return !ad.Expires.IsZero() && !now.Before(ad.Expires)
 }
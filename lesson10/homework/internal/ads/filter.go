@@ -0,0 +1,360 @@
+package ads
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expr - узел дерева фильтра объявлений. Дерево можно напечатать в строковый синтаксис (String)
+// и разобрать обратно (ParseFilter), поэтому фильтр можно логировать, кэшировать и передавать по сети.
+type Expr interface {
+	Eval(ad *Ad) bool
+	String() string
+}
+
+// Field - поле объявления, по которому можно фильтровать
+type Field string
+
+const (
+	FieldID        Field = "id"
+	FieldTitle     Field = "title"
+	FieldText      Field = "text"
+	FieldUserID    Field = "user_id"
+	FieldCategory  Field = "category_id"
+	FieldPrice     Field = "price"
+	FieldCurrency  Field = "currency"
+	FieldPublished Field = "published"
+	FieldStatus    Field = "status"
+	FieldCreated   Field = "created"
+	FieldUpdated   Field = "updated"
+	FieldExpires   Field = "expires"
+)
+
+type Kind int
+
+const (
+	KindString Kind = iota
+	KindInt
+	KindBool
+	KindTime
+)
+
+var fieldKinds = map[Field]Kind{
+	FieldID:        KindInt,
+	FieldTitle:     KindString,
+	FieldText:      KindString,
+	FieldUserID:    KindInt,
+	FieldCategory:  KindInt,
+	FieldPrice:     KindInt,
+	FieldCurrency:  KindString,
+	FieldPublished: KindBool,
+	FieldStatus:    KindString,
+	FieldCreated:   KindTime,
+	FieldUpdated:   KindTime,
+	FieldExpires:   KindTime,
+}
+
+func (k Kind) String() string {
+	switch k {
+	case KindString:
+		return "string"
+	case KindInt:
+		return "int"
+	case KindBool:
+		return "bool"
+	default:
+		return "time"
+	}
+}
+
+// Value - значение в условии фильтра
+type Value struct {
+	Kind Kind
+	Str  string
+	Int  int64
+	Bool bool
+	Time time.Time
+	// Date - время задано датой без часов: поле сравнивается с ним с точностью до дня (UTC)
+	Date bool
+}
+
+func StringValue(s string) Value {
+	return Value{Kind: KindString, Str: s}
+}
+
+func IntValue(i int64) Value {
+	return Value{Kind: KindInt, Int: i}
+}
+
+func BoolValue(b bool) Value {
+	return Value{Kind: KindBool, Bool: b}
+}
+
+func TimeValue(t time.Time) Value {
+	return Value{Kind: KindTime, Time: t}
+}
+
+// DateValue - день, в который попадает t (UTC)
+func DateValue(t time.Time) Value {
+	y, m, d := t.UTC().Date()
+	return Value{Kind: KindTime, Time: time.Date(y, m, d, 0, 0, 0, 0, time.UTC), Date: true}
+}
+
+func (v Value) String() string {
+	switch v.Kind {
+	case KindString:
+		return strconv.Quote(v.Str)
+	case KindInt:
+		return strconv.FormatInt(v.Int, 10)
+	case KindBool:
+		return strconv.FormatBool(v.Bool)
+	default:
+		if v.Date {
+			return v.Time.Format(dateLayout)
+		}
+		return v.Time.Format(time.RFC3339Nano)
+	}
+}
+
+// compare сравнивает значение поля объявления v со значением из условия lit
+func (v Value) compare(lit Value) int {
+	switch v.Kind {
+	case KindString:
+		return strings.Compare(v.Str, lit.Str)
+	case KindInt:
+		switch {
+		case v.Int < lit.Int:
+			return -1
+		case v.Int > lit.Int:
+			return 1
+		}
+		return 0
+	case KindBool:
+		switch {
+		case v.Bool == lit.Bool:
+			return 0
+		case lit.Bool:
+			return -1
+		}
+		return 1
+	default:
+		t := v.Time
+		if lit.Date {
+			t = DateValue(t).Time
+		}
+		return t.Compare(lit.Time)
+	}
+}
+
+func (ad *Ad) field(f Field) Value {
+	switch f {
+	case FieldID:
+		return IntValue(ad.ID)
+	case FieldTitle:
+		return StringValue(ad.Title)
+	case FieldText:
+		return StringValue(ad.Text)
+	case FieldUserID:
+		return IntValue(ad.UserID)
+	case FieldCategory:
+		return IntValue(ad.CategoryID)
+	case FieldPrice:
+		return IntValue(ad.Price.Amount)
+	case FieldCurrency:
+		return StringValue(ad.Price.Currency)
+	case FieldPublished:
+		return BoolValue(ad.Published())
+	case FieldStatus:
+		return StringValue(string(ad.Status))
+	case FieldCreated:
+		return TimeValue(ad.Created)
+	case FieldExpires:
+		return TimeValue(ad.Expires)
+	default:
+		return TimeValue(ad.Updated)
+	}
+}
+
+// And - выполнены все условия
+type And struct {
+	Args []Expr
+}
+
+func (e *And) Eval(ad *Ad) bool {
+	for _, a := range e.Args {
+		if !a.Eval(ad) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *And) String() string {
+	parts := make([]string, len(e.Args))
+	for i, a := range e.Args {
+		parts[i] = a.String()
+		if _, ok := a.(*Or); ok {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+	return strings.Join(parts, " and ")
+}
+
+// Or - выполнено хотя бы одно условие
+type Or struct {
+	Args []Expr
+}
+
+func (e *Or) Eval(ad *Ad) bool {
+	for _, a := range e.Args {
+		if a.Eval(ad) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Or) String() string {
+	parts := make([]string, len(e.Args))
+	for i, a := range e.Args {
+		parts[i] = a.String()
+	}
+	return strings.Join(parts, " or ")
+}
+
+// Not - условие не выполнено
+type Not struct {
+	Arg Expr
+}
+
+func (e *Not) Eval(ad *Ad) bool {
+	return !e.Arg.Eval(ad)
+}
+
+func (e *Not) String() string {
+	return "not (" + e.Arg.String() + ")"
+}
+
+// Eq - поле равно значению
+type Eq struct {
+	Field Field
+	Value Value
+}
+
+func (e *Eq) Eval(ad *Ad) bool {
+	return ad.field(e.Field).compare(e.Value) == 0
+}
+
+func (e *Eq) String() string {
+	return string(e.Field) + " = " + e.Value.String()
+}
+
+// Contains - строковое поле содержит подстроку (без учёта регистра)
+type Contains struct {
+	Field Field
+	Value string
+}
+
+func (e *Contains) Eval(ad *Ad) bool {
+	return strings.Contains(strings.ToLower(ad.field(e.Field).Str), strings.ToLower(e.Value))
+}
+
+func (e *Contains) String() string {
+	return string(e.Field) + " contains " + strconv.Quote(e.Value)
+}
+
+// Range - поле лежит в диапазоне; отсутствующая граница диапазон не ограничивает,
+// но хотя бы одна граница должна быть задана
+type Range struct {
+	Field       Field
+	From, To    *Value
+	IncludeFrom bool
+	IncludeTo   bool
+}
+
+func (e *Range) Eval(ad *Ad) bool {
+	v := ad.field(e.Field)
+	if e.From != nil {
+		c := v.compare(*e.From)
+		if c < 0 || c == 0 && !e.IncludeFrom {
+			return false
+		}
+	}
+	if e.To != nil {
+		c := v.compare(*e.To)
+		if c > 0 || c == 0 && !e.IncludeTo {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Range) String() string {
+	if e.From != nil && e.To != nil && e.IncludeFrom && e.IncludeTo {
+		return string(e.Field) + " between " + e.From.String() + " and " + e.To.String()
+	}
+
+	var parts []string
+	if e.From != nil {
+		op := " > "
+		if e.IncludeFrom {
+			op = " >= "
+		}
+		parts = append(parts, string(e.Field)+op+e.From.String())
+	}
+	if e.To != nil {
+		op := " < "
+		if e.IncludeTo {
+			op = " <= "
+		}
+		parts = append(parts, string(e.Field)+op+e.To.String())
+	}
+	return strings.Join(parts, " and ")
+}
+
+// In - поле равно одному из значений
+type In struct {
+	Field  Field
+	Values []Value
+}
+
+func (e *In) Eval(ad *Ad) bool {
+	v := ad.field(e.Field)
+	for _, lit := range e.Values {
+		if v.compare(lit) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *In) String() string {
+	parts := make([]string, len(e.Values))
+	for i, v := range e.Values {
+		parts[i] = v.String()
+	}
+	return string(e.Field) + " in (" + strings.Join(parts, ", ") + ")"
+}
+
+// AndExpr объединяет условия через "и", пропуская пустые (nil)
+func AndExpr(exprs ...Expr) Expr {
+	var args []Expr
+	for _, e := range exprs {
+		switch e := e.(type) {
+		case nil:
+		case *And:
+			args = append(args, e.Args...)
+		default:
+			args = append(args, e)
+		}
+	}
+
+	switch len(args) {
+	case 0:
+		return nil
+	case 1:
+		return args[0]
+	}
+	return &And{Args: args}
+}
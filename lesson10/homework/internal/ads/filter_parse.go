@@ -0,0 +1,415 @@
+package ads
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const dateLayout = "2006-01-02"
+
+const (
+	// MaxFilterLength - наибольшая длина фильтра в байтах
+	MaxFilterLength = 4 << 10
+	// MaxFilterDepth - наибольшая вложенность скобок и not в фильтре: разбор рекурсивный,
+	// и без ограничения глубокая вложенность переполнила бы стек
+	MaxFilterDepth = 64
+)
+
+var ErrBadFilter = fmt.Errorf("bad filter")
+
+// FilterError - ошибка разбора фильтра; Pos - номер символа (с единицы), на котором разбор остановился
+type FilterError struct {
+	Pos int
+	Msg string
+}
+
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("bad filter at position %d: %s", e.Pos, e.Msg)
+}
+
+func (e *FilterError) Unwrap() error {
+	return ErrBadFilter
+}
+
+type tokenKind int
+
+const (
+	tkEOF tokenKind = iota
+	tkIdent
+	tkString
+	tkLiteral
+	tkOp
+	tkLParen
+	tkRParen
+	tkComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tkEOF {
+		return "end of filter"
+	}
+	return strconv.Quote(t.text)
+}
+
+// is проверяет, что токен - ключевое слово kw (без учёта регистра)
+func (t token) is(kw string) bool {
+	return t.kind == tkIdent && strings.EqualFold(t.text, kw)
+}
+
+// ParseFilter разбирает фильтр вида `published = true and created >= 2023-04-01`.
+//
+// Грамматика (ключевые слова - без учёта регистра, not связывает сильнее and, and - сильнее or):
+//
+//	expr  := and ("or" and)*
+//	and   := unary ("and" unary)*
+//	unary := "not" unary | "(" expr ")" | cond
+//	cond  := field ("=" | "!=" | "<" | "<=" | ">" | ">=") value
+//	       | field "contains" string
+//	       | field "in" "(" value ("," value)* ")"
+//	       | field "between" value "and" value
+//	value := "строка" | число | true | false | 2023-04-01 | 2023-04-01T10:00:00Z
+//
+// Пустая строка - фильтр без условий (nil). Фильтр длиннее MaxFilterLength или с вложенностью больше
+// MaxFilterDepth не разбирается.
+func ParseFilter(s string) (Expr, error) {
+	if len(s) > MaxFilterLength {
+		return nil, &FilterError{Pos: 1, Msg: fmt.Sprintf("filter is longer than %d bytes", MaxFilterLength)}
+	}
+
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tkEOF {
+		return nil, nil
+	}
+
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tkEOF {
+		return nil, p.errorf(t, "unexpected %s", t)
+	}
+
+	return e, nil
+}
+
+func lex(s string) ([]token, error) {
+	var tokens []token
+	rs := []rune(s)
+
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '(':
+			tokens = append(tokens, token{kind: tkLParen, text: "(", pos: start + 1})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tkRParen, text: ")", pos: start + 1})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tkComma, text: ",", pos: start + 1})
+			i++
+		case r == '=' || r == '<' || r == '>' || r == '!':
+			i++
+			if i < len(rs) && rs[i] == '=' {
+				i++
+			}
+			op := string(rs[start:i])
+			if op == "!" {
+				return nil, &FilterError{Pos: start + 1, Msg: `unexpected "!", did you mean "!="?`}
+			}
+			tokens = append(tokens, token{kind: tkOp, text: op, pos: start + 1})
+		case r == '"':
+			i++
+			for i < len(rs) && rs[i] != '"' {
+				if rs[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(rs) {
+				return nil, &FilterError{Pos: start + 1, Msg: "unterminated string"}
+			}
+			i++
+			str, err := strconv.Unquote(string(rs[start:i]))
+			if err != nil {
+				return nil, &FilterError{Pos: start + 1, Msg: "bad string " + string(rs[start:i])}
+			}
+			tokens = append(tokens, token{kind: tkString, text: str, pos: start + 1})
+		case unicode.IsLetter(r) || r == '_':
+			for i < len(rs) && (unicode.IsLetter(rs[i]) || unicode.IsDigit(rs[i]) || rs[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tkIdent, text: string(rs[start:i]), pos: start + 1})
+		case unicode.IsDigit(r) || r == '-' || r == '+':
+			// числа, даты и время вида 2023-04-01T10:00:00+03:00
+			i++
+			for i < len(rs) && (unicode.IsLetter(rs[i]) || unicode.IsDigit(rs[i]) || strings.ContainsRune(":.+-", rs[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tkLiteral, text: string(rs[start:i]), pos: start + 1})
+		default:
+			return nil, &FilterError{Pos: start + 1, Msg: fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+
+	return append(tokens, token{kind: tkEOF, pos: len(rs) + 1}), nil
+}
+
+type parser struct {
+	tokens []token
+	i      int
+	// depth - текущая вложенность скобок и not
+	depth int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tkEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return &FilterError{Pos: t.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	e, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	args := []Expr{e}
+	for p.peek().is("or") {
+		p.next()
+		if e, err = p.parseAnd(); err != nil {
+			return nil, err
+		}
+		args = append(args, e)
+	}
+
+	if len(args) == 1 {
+		return args[0], nil
+	}
+	return &Or{Args: args}, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	e, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	args := []Expr{e}
+	for p.peek().is("and") {
+		p.next()
+		if e, err = p.parseUnary(); err != nil {
+			return nil, err
+		}
+		args = append(args, e)
+	}
+
+	return AndExpr(args...), nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	t := p.peek()
+	if t.is("not") || t.kind == tkLParen {
+		if p.depth == MaxFilterDepth {
+			return nil, p.errorf(t, "filter is nested deeper than %d levels", MaxFilterDepth)
+		}
+		p.depth++
+		defer func() { p.depth-- }()
+	}
+
+	switch {
+	case t.is("not"):
+		p.next()
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Arg: e}, nil
+	case t.kind == tkLParen:
+		p.next()
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t = p.next(); t.kind != tkRParen {
+			return nil, p.errorf(t, `expected ")", got %s`, t)
+		}
+		return e, nil
+	}
+
+	return p.parseCond()
+}
+
+func (p *parser) parseCond() (Expr, error) {
+	t := p.next()
+	if t.kind != tkIdent {
+		return nil, p.errorf(t, "expected field name, got %s", t)
+	}
+	f := Field(strings.ToLower(t.text))
+	kind, ok := fieldKinds[f]
+	if !ok {
+		return nil, p.errorf(t, "unknown field %s", t)
+	}
+
+	op := p.next()
+	switch {
+	case op.kind == tkOp:
+		v, err := p.parseValue(f, kind)
+		if err != nil {
+			return nil, err
+		}
+		return p.comparison(op, f, kind, v)
+	case op.is("contains"):
+		if kind != KindString {
+			return nil, p.errorf(op, "contains is not supported for %s field %q", kind, f)
+		}
+		v, err := p.parseValue(f, kind)
+		if err != nil {
+			return nil, err
+		}
+		return &Contains{Field: f, Value: v.Str}, nil
+	case op.is("in"):
+		return p.parseIn(f, kind)
+	case op.is("between"):
+		if kind == KindBool {
+			return nil, p.errorf(op, "between is not supported for %s field %q", kind, f)
+		}
+		from, err := p.parseValue(f, kind)
+		if err != nil {
+			return nil, err
+		}
+		if t = p.next(); !t.is("and") {
+			return nil, p.errorf(t, `expected "and", got %s`, t)
+		}
+		to, err := p.parseValue(f, kind)
+		if err != nil {
+			return nil, err
+		}
+		return &Range{Field: f, From: &from, To: &to, IncludeFrom: true, IncludeTo: true}, nil
+	}
+
+	return nil, p.errorf(op, "expected operator after %q, got %s", f, op)
+}
+
+func (p *parser) comparison(op token, f Field, kind Kind, v Value) (Expr, error) {
+	switch op.text {
+	case "=":
+		return &Eq{Field: f, Value: v}, nil
+	case "!=":
+		return &Not{Arg: &Eq{Field: f, Value: v}}, nil
+	}
+
+	if kind == KindBool {
+		return nil, p.errorf(op, "%s is not supported for %s field %q", op.text, kind, f)
+	}
+	switch op.text {
+	case ">":
+		return &Range{Field: f, From: &v}, nil
+	case ">=":
+		return &Range{Field: f, From: &v, IncludeFrom: true}, nil
+	case "<":
+		return &Range{Field: f, To: &v}, nil
+	case "<=":
+		return &Range{Field: f, To: &v, IncludeTo: true}, nil
+	}
+
+	return nil, p.errorf(op, "unknown operator %s", op)
+}
+
+func (p *parser) parseIn(f Field, kind Kind) (Expr, error) {
+	if t := p.next(); t.kind != tkLParen {
+		return nil, p.errorf(t, `expected "(", got %s`, t)
+	}
+
+	var values []Value
+	for {
+		v, err := p.parseValue(f, kind)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+
+		t := p.next()
+		if t.kind == tkRParen {
+			break
+		}
+		if t.kind != tkComma {
+			return nil, p.errorf(t, `expected "," or ")", got %s`, t)
+		}
+	}
+
+	return &In{Field: f, Values: values}, nil
+}
+
+// parseValue разбирает значение, которое должно иметь тип поля f
+func (p *parser) parseValue(f Field, kind Kind) (Value, error) {
+	t := p.next()
+	bad := func() (Value, error) {
+		return Value{}, p.errorf(t, "expected %s value for %q, got %s", kind, f, t)
+	}
+
+	switch kind {
+	case KindString:
+		if t.kind != tkString {
+			return bad()
+		}
+		return StringValue(t.text), nil
+	case KindInt:
+		if t.kind != tkLiteral {
+			return bad()
+		}
+		i, err := strconv.ParseInt(t.text, 10, 64)
+		if err != nil {
+			return bad()
+		}
+		return IntValue(i), nil
+	case KindBool:
+		switch {
+		case t.is("true"):
+			return BoolValue(true), nil
+		case t.is("false"):
+			return BoolValue(false), nil
+		}
+		return bad()
+	default:
+		if t.kind != tkLiteral {
+			return bad()
+		}
+		if d, err := time.Parse(dateLayout, t.text); err == nil {
+			return DateValue(d), nil
+		}
+		tm, err := time.Parse(time.RFC3339Nano, t.text)
+		if err != nil {
+			return bad()
+		}
+		return TimeValue(tm), nil
+	}
+}
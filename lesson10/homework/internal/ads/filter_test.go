@@ -0,0 +1,139 @@
+package ads
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFilter_Eval(t *testing.T) {
+	created := time.Date(2023, 4, 1, 15, 30, 0, 0, time.UTC)
+	ad := &Ad{
+		ID:         3,
+		Title:      "Продам Велосипед",
+		Text:       "почти новый",
+		UserID:     7,
+		CategoryID: 3,
+		Price:      Price{Amount: 1500000, Currency: "RUB"},
+		Status:     StatusPublished,
+		Created:    created,
+		Updated:    created.Add(48 * time.Hour),
+	}
+
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{filter: `published = true`, want: true},
+		{filter: `published != true`, want: false},
+		{filter: `status = "published"`, want: true},
+		{filter: `status in ("draft", "pending")`, want: false},
+		{filter: `PUBLISHED = TRUE AND user_id = 7`, want: true},
+		{filter: `title = "Продам Велосипед"`, want: true},
+		{filter: `title contains "велосипед"`, want: true},
+		{filter: `text contains "б/у"`, want: false},
+		{filter: `user_id in (1, 2, 7)`, want: true},
+		{filter: `category_id in (2, 3)`, want: true},
+		{filter: `id in (1)`, want: false},
+		{filter: `price between 1000000 and 2000000 and currency = "RUB"`, want: true},
+		{filter: `price > 1500000`, want: false},
+		{filter: `created = 2023-04-01`, want: true},
+		{filter: `created >= 2023-04-01 and created < 2023-04-02`, want: true},
+		{filter: `created > 2023-04-01`, want: false},
+		{filter: `created <= 2023-04-01`, want: true},
+		{filter: `created > 2023-04-01T15:00:00Z`, want: true},
+		{filter: `created < 2023-04-01T18:00:00+03:00`, want: false},
+		{filter: `updated between 2023-04-02 and 2023-04-03`, want: true},
+		{filter: `user_id between 8 and 10`, want: false},
+		{filter: `not published = true or user_id = 7`, want: true},
+		{filter: `not (published = true or user_id = 1)`, want: false},
+		{filter: `published = false or user_id > 5 and title contains "продам"`, want: true},
+		{filter: `(published = false or user_id > 5) and id = 4`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			e, err := ParseFilter(tt.filter)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, e.Eval(ad))
+
+			// напечатанный фильтр разбирается в эквивалентный
+			again, err := ParseFilter(e.String())
+			assert.NoError(t, err)
+			assert.Equal(t, e.String(), again.String())
+			assert.Equal(t, tt.want, again.Eval(ad))
+		})
+	}
+}
+
+func TestParseFilter_Empty(t *testing.T) {
+	e, err := ParseFilter("  ")
+	assert.NoError(t, err)
+	assert.Nil(t, e)
+	assert.True(t, DefaultPattern().Where(e).Fits(&Ad{}))
+}
+
+func TestParseFilter_Errors(t *testing.T) {
+	tests := []struct {
+		filter string
+		pos    int
+		msg    string
+	}{
+		{filter: `color = "red"`, pos: 1, msg: `unknown field "color"`},
+		{filter: `published = yes`, pos: 13, msg: `expected bool value for "published", got "yes"`},
+		{filter: `user_id = "7"`, pos: 11, msg: `expected int value for "user_id", got "7"`},
+		{filter: `created >= 2023-13-01`, pos: 12, msg: `expected time value for "created", got "2023-13-01"`},
+		{filter: `published > true`, pos: 11, msg: `> is not supported for bool field "published"`},
+		{filter: `user_id contains "1"`, pos: 9, msg: `contains is not supported for int field "user_id"`},
+		{filter: `title`, pos: 6, msg: `expected operator after "title", got end of filter`},
+		{filter: `title = "a" and`, pos: 16, msg: `expected field name, got end of filter`},
+		{filter: `title = "a" user_id = 1`, pos: 13, msg: `unexpected "user_id"`},
+		{filter: `(title = "a"`, pos: 13, msg: `expected ")", got end of filter`},
+		{filter: `user_id in (1 2)`, pos: 15, msg: `expected "," or ")", got "2"`},
+		{filter: `title = "abc`, pos: 9, msg: `unterminated string`},
+		{filter: `title # "a"`, pos: 7, msg: `unexpected character '#'`},
+		{filter: `id between 1 or 2`, pos: 14, msg: `expected "and", got "or"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			_, err := ParseFilter(tt.filter)
+			assert.ErrorIs(t, err, ErrBadFilter)
+
+			var fe *FilterError
+			if assert.True(t, errors.As(err, &fe)) {
+				assert.Equal(t, tt.pos, fe.Pos)
+				assert.Equal(t, tt.msg, fe.Msg)
+			}
+		})
+	}
+}
+
+func TestParseFilter_Limits(t *testing.T) {
+	nested := func(depth int) string {
+		return strings.Repeat("(", depth) + `title = "a"` + strings.Repeat(")", depth)
+	}
+
+	_, err := ParseFilter(nested(MaxFilterDepth))
+	assert.NoError(t, err)
+
+	// глубокая вложенность не переполняет стек, а отклоняется
+	for _, filter := range []string{
+		nested(MaxFilterDepth + 1),
+		strings.Repeat("not ", MaxFilterDepth+1) + `title = "a"`,
+		strings.Repeat("(", MaxFilterLength),
+		strings.Repeat("(", 1100000),
+	} {
+		_, err := ParseFilter(filter)
+		assert.ErrorIs(t, err, ErrBadFilter)
+	}
+
+	var fe *FilterError
+	_, err = ParseFilter(nested(MaxFilterDepth + 1))
+	if assert.True(t, errors.As(err, &fe)) {
+		assert.Equal(t, MaxFilterDepth+1, fe.Pos)
+	}
+}
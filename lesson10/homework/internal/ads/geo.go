@@ -0,0 +1,67 @@
+package ads
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// EarthRadius - средний радиус Земли в километрах
+	EarthRadius = 6371.0
+	// MaxRadius - наибольший радиус поиска объявлений вокруг точки, в километрах
+	MaxRadius = 500.0
+)
+
+// Point - точка на поверхности Земли: широта и долгота в градусах
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+// Valid сообщает, лежат ли координаты в допустимых пределах
+func (p Point) Valid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lon >= -180 && p.Lon <= 180
+}
+
+// Distance возвращает расстояние между точками по поверхности Земли в километрах (формула гаверсинусов)
+func Distance(a, b Point) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Location - где находится объявление: точка на карте и название населённого пункта для показа
+type Location struct {
+	Point
+	City string `validate:"min:1;max:99"`
+}
+
+// Circle - область поиска: все точки не дальше Radius километров от центра
+type Circle struct {
+	Center Point
+	Radius float64
+}
+
+// NewCircle проверяет параметры поиска по радиусу (в километрах) и возвращает область поиска
+func NewCircle(lat, lon, radius float64) (*Circle, error) {
+	c := &Circle{Center: Point{Lat: lat, Lon: lon}, Radius: radius}
+	if !c.Center.Valid() {
+		return nil, fmt.Errorf("%w: coordinates are out of range", ErrBadFilter)
+	}
+	if !(radius > 0 && radius <= MaxRadius) {
+		return nil, fmt.Errorf("%w: radius must be in (0, %g] km", ErrBadFilter, MaxRadius)
+	}
+	return c, nil
+}
+
+// Near возвращает расстояние от объявления до центра области, если объявление в неё попадает
+func (c *Circle) Near(ad *Ad) (float64, bool) {
+	if ad.Location == nil {
+		return 0, false
+	}
+	d := Distance(c.Center, ad.Location.Point)
+	return d, d <= c.Radius
+}
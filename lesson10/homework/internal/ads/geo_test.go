@@ -0,0 +1,63 @@
+package ads
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCircle(t *testing.T) {
+	tests := []struct {
+		lat, lon, radius float64
+		wantErr          bool
+	}{
+		{lat: 55.75, lon: 37.62, radius: 10},
+		{lat: -90, lon: 180, radius: MaxRadius},
+		{lat: 91, lon: 37.62, radius: 10, wantErr: true},
+		{lat: 55.75, lon: -181, radius: 10, wantErr: true},
+		{lat: 55.75, lon: 37.62, radius: 0, wantErr: true},
+		{lat: 55.75, lon: 37.62, radius: MaxRadius + 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		_, err := NewCircle(tt.lat, tt.lon, tt.radius)
+		if tt.wantErr {
+			assert.ErrorIs(t, err, ErrBadFilter, tt)
+		} else {
+			assert.NoError(t, err, tt)
+		}
+	}
+}
+
+func TestPattern_Near(t *testing.T) {
+	c, err := NewCircle(55.7539, 37.6208, 30)
+	assert.NoError(t, err)
+	p := DefaultPattern().SetNear(c)
+
+	khimki := &Ad{Location: &Location{Point: Point{Lat: 55.8970, Lon: 37.4297}, City: "Химки"}}
+	tver := &Ad{Location: &Location{Point: Point{Lat: 56.8587, Lon: 35.9176}, City: "Тверь"}}
+	assert.True(t, p.Fits(khimki))
+	assert.False(t, p.Fits(tver))
+	assert.False(t, p.Fits(&Ad{}))
+	assert.True(t, DefaultPattern().Fits(&Ad{}))
+
+	d, ok := c.Near(khimki)
+	assert.True(t, ok)
+	assert.InDelta(t, 19.7, d, 0.5)
+}
+
+func TestPage_SortByDistance(t *testing.T) {
+	near, far := 1.5, 20.0
+	list := []*Ad{{ID: 0}, {ID: 1, Distance: &far}, {ID: 2, Distance: &near}}
+
+	page := Page{Limit: 2, Sort: Sort{Field: SortByDistance}}
+	res, next, err := page.Cut(list)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Ad{list[2], list[1]}, res)
+
+	// объявления без расстояния идут последними
+	page.Cursor = next
+	res, _, err = page.Cut(list)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Ad{list[0]}, res)
+}
@@ -64,20 +64,53 @@ func (_m *Repository) AddAd(ctx context.Context, ad *ads.Ad) (int64, error) {
 	return r0, r1
 }
 
-// AdsByPattern provides a mock function with given fields: ctx, p
-func (_m *Repository) AdsByPattern(ctx context.Context, p *ads.Pattern) ([]*ads.Ad, error) {
-	ret := _m.Called(ctx, p)
+// AdsByPattern provides a mock function with given fields: ctx, p, page
+func (_m *Repository) AdsByPattern(ctx context.Context, p *ads.Pattern, page ads.Page) ([]*ads.Ad, string, error) {
+	ret := _m.Called(ctx, p, page)
 
 	var r0 []*ads.Ad
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, *ads.Pattern, ads.Page) ([]*ads.Ad, string, error)); ok {
+		return rf(ctx, p, page)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *ads.Pattern, ads.Page) []*ads.Ad); ok {
+		r0 = rf(ctx, p, page)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*ads.Ad)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *ads.Pattern, ads.Page) string); ok {
+		r1 = rf(ctx, p, page)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, *ads.Pattern, ads.Page) error); ok {
+		r2 = rf(ctx, p, page)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// CountByCategory provides a mock function with given fields: ctx, p
+func (_m *Repository) CountByCategory(ctx context.Context, p *ads.Pattern) (map[int64]int, error) {
+	ret := _m.Called(ctx, p)
+
+	var r0 map[int64]int
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, *ads.Pattern) ([]*ads.Ad, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *ads.Pattern) (map[int64]int, error)); ok {
 		return rf(ctx, p)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, *ads.Pattern) []*ads.Ad); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *ads.Pattern) map[int64]int); ok {
 		r0 = rf(ctx, p)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*ads.Ad)
+			r0 = ret.Get(0).(map[int64]int)
 		}
 	}
 
@@ -104,6 +137,20 @@ func (_m *Repository) DeleteAd(ctx context.Context, ID int64) error {
 	return r0
 }
 
+// UpdateAd provides a mock function with given fields: ctx, ad
+func (_m *Repository) UpdateAd(ctx context.Context, ad *ads.Ad) error {
+	ret := _m.Called(ctx, ad)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *ads.Ad) error); ok {
+		r0 = rf(ctx, ad)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 type mockConstructorTestingTNewRepository interface {
 	mock.TestingT
 	Cleanup(func())
@@ -0,0 +1,277 @@
+package ads
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 100
+)
+
+var ErrBadPage = fmt.Errorf("bad page parameters")
+
+type SortField string
+
+const (
+	SortByCreated SortField = "created"
+	SortByUpdated SortField = "updated"
+	SortByTitle   SortField = "title"
+	// SortByPrice - по сумме цены; сравнивать цены в разных валютах смысла нет, так что сортировку по цене
+	// стоит сочетать с фильтром по валюте. Объявления без цены считаются бесплатными
+	SortByPrice SortField = "price"
+	// SortByDistance - по расстоянию до центра поиска по радиусу, без поиска по радиусу не применима
+	SortByDistance SortField = "distance"
+	// SortByRelevance - по оценке релевантности полнотекстовому запросу, по умолчанию сначала самые релевантные
+	SortByRelevance SortField = "relevance"
+)
+
+type Sort struct {
+	Field SortField
+	Desc  bool
+}
+
+func DefaultSort() Sort {
+	return Sort{Field: SortByCreated}
+}
+
+// ParseSort разбирает строку вида "поле" или "поле:asc|desc". Пустая строка - сортировка по умолчанию:
+// по релевантности, если задан полнотекстовый запрос, иначе по дате создания
+func ParseSort(s string) (Sort, error) {
+	if s == "" {
+		return Sort{}, nil
+	}
+
+	field, dir, _ := strings.Cut(s, ":")
+	res := Sort{Field: SortField(field)}
+	switch res.Field {
+	case SortByCreated, SortByUpdated, SortByTitle, SortByPrice, SortByDistance, SortByRelevance:
+	default:
+		return Sort{}, fmt.Errorf("%w: unknown sort field %q", ErrBadPage, field)
+	}
+	switch dir {
+	case "":
+		res.Desc = res.Field == SortByRelevance
+	case "asc":
+	case "desc":
+		res.Desc = true
+	default:
+		return Sort{}, fmt.Errorf("%w: unknown sort direction %q", ErrBadPage, dir)
+	}
+
+	return res, nil
+}
+
+func (s Sort) String() string {
+	if s.Desc {
+		return string(s.Field) + ":desc"
+	}
+	return string(s.Field) + ":asc"
+}
+
+// Page - параметры постраничной выборки: размер страницы, курсор, полученный с предыдущей страницей, и сортировка
+type Page struct {
+	Limit  int
+	Cursor string
+	Sort   Sort
+}
+
+func DefaultPage() Page {
+	return Page{Limit: DefaultLimit, Sort: DefaultSort()}
+}
+
+// курсор указывает на последнее объявление предыдущей страницы
+type cursor struct {
+	Sort string `json:"sort"`
+	ID   int64  `json:"id"`
+	Key  string `json:"key"`
+}
+
+// Cut упорядочивает объявления (при равенстве ключа сортировки - по ID) и вырезает из них страницу.
+// Вторым значением возвращает курсор следующей страницы или пустую строку, если страница последняя.
+func (p Page) Cut(adverts []*Ad) ([]*Ad, string, error) {
+	return p.CutRanked(adverts, nil)
+}
+
+// CutRanked работает как Cut, но для объявлений, найденных полнотекстовым поиском:
+// scores - оценки релевантности объявлений по их ID
+func (p Page) CutRanked(adverts []*Ad, scores map[int64]float64) ([]*Ad, string, error) {
+	limit := p.Limit
+	switch {
+	case limit < 0:
+		return nil, "", fmt.Errorf("%w: negative limit", ErrBadPage)
+	case limit == 0:
+		limit = DefaultLimit
+	case limit > MaxLimit:
+		limit = MaxLimit
+	}
+
+	if p.Sort.Field == "" {
+		p.Sort = DefaultSort()
+		if scores != nil {
+			p.Sort = Sort{Field: SortByRelevance, Desc: true}
+		}
+	}
+
+	items := make([]rankedAd, len(adverts))
+	for i, ad := range adverts {
+		items[i] = rankedAd{ad: ad, score: scores[ad.ID]}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return p.Sort.compare(items[i], items[j]) < 0
+	})
+
+	start := 0
+	if p.Cursor != "" {
+		after, err := p.decodeCursor()
+		if err != nil {
+			return nil, "", err
+		}
+		start = sort.Search(len(items), func(i int) bool {
+			return p.Sort.compare(items[i], after) > 0
+		})
+	}
+
+	end := start + limit
+	next := ""
+	if end < len(items) {
+		next = p.encodeCursor(items[end-1])
+	} else {
+		end = len(items)
+	}
+
+	res := make([]*Ad, 0, end-start)
+	for _, it := range items[start:end] {
+		res = append(res, it.ad)
+	}
+
+	return res, next, nil
+}
+
+// rankedAd - объявление вместе с его оценкой релевантности
+type rankedAd struct {
+	ad    *Ad
+	score float64
+}
+
+func (s Sort) compare(a, b rankedAd) int {
+	var c int
+	switch s.Field {
+	case SortByUpdated:
+		c = a.ad.Updated.Compare(b.ad.Updated)
+	case SortByTitle:
+		c = strings.Compare(a.ad.Title, b.ad.Title)
+	case SortByPrice:
+		switch {
+		case a.ad.Price.Amount < b.ad.Price.Amount:
+			c = -1
+		case a.ad.Price.Amount > b.ad.Price.Amount:
+			c = 1
+		}
+	case SortByDistance:
+		da, db := a.ad.distance(), b.ad.distance()
+		switch {
+		case da < db:
+			c = -1
+		case da > db:
+			c = 1
+		}
+	case SortByRelevance:
+		switch {
+		case a.score < b.score:
+			c = -1
+		case a.score > b.score:
+			c = 1
+		}
+	default:
+		c = a.ad.Created.Compare(b.ad.Created)
+	}
+	if c == 0 {
+		switch {
+		case a.ad.ID < b.ad.ID:
+			c = -1
+		case a.ad.ID > b.ad.ID:
+			c = 1
+		}
+	}
+
+	if s.Desc {
+		return -c
+	}
+	return c
+}
+
+// distance - расстояние до центра поиска; объявления, для которых оно неизвестно, считаются самыми дальними
+func (ad *Ad) distance() float64 {
+	if ad.Distance == nil {
+		return math.Inf(1)
+	}
+	return *ad.Distance
+}
+
+func (p Page) encodeCursor(it rankedAd) string {
+	ad := it.ad
+	c := cursor{Sort: p.Sort.String(), ID: ad.ID}
+	switch p.Sort.Field {
+	case SortByUpdated:
+		c.Key = ad.Updated.Format(time.RFC3339Nano)
+	case SortByTitle:
+		c.Key = ad.Title
+	case SortByPrice:
+		c.Key = strconv.FormatInt(ad.Price.Amount, 10)
+	case SortByDistance:
+		c.Key = strconv.FormatFloat(ad.distance(), 'g', -1, 64)
+	case SortByRelevance:
+		c.Key = strconv.FormatFloat(it.score, 'g', -1, 64)
+	default:
+		c.Key = ad.Created.Format(time.RFC3339Nano)
+	}
+
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (p Page) decodeCursor() (rankedAd, error) {
+	data, err := base64.RawURLEncoding.DecodeString(p.Cursor)
+	if err != nil {
+		return rankedAd{}, fmt.Errorf("%w: bad cursor", ErrBadPage)
+	}
+
+	var c cursor
+	if err = json.Unmarshal(data, &c); err != nil {
+		return rankedAd{}, fmt.Errorf("%w: bad cursor", ErrBadPage)
+	}
+	if c.Sort != p.Sort.String() {
+		return rankedAd{}, fmt.Errorf("%w: cursor was issued for another sort", ErrBadPage)
+	}
+
+	it := rankedAd{ad: &Ad{ID: c.ID}}
+	switch p.Sort.Field {
+	case SortByUpdated:
+		it.ad.Updated, err = time.Parse(time.RFC3339Nano, c.Key)
+	case SortByTitle:
+		it.ad.Title = c.Key
+	case SortByPrice:
+		it.ad.Price.Amount, err = strconv.ParseInt(c.Key, 10, 64)
+	case SortByDistance:
+		var d float64
+		d, err = strconv.ParseFloat(c.Key, 64)
+		it.ad.Distance = &d
+	case SortByRelevance:
+		it.score, err = strconv.ParseFloat(c.Key, 64)
+	default:
+		it.ad.Created, err = time.Parse(time.RFC3339Nano, c.Key)
+	}
+	if err != nil {
+		return rankedAd{}, fmt.Errorf("%w: bad cursor", ErrBadPage)
+	}
+
+	return it, nil
+}
@@ -1,133 +1,62 @@
 package ads
 
-import "time"
-
+// Pattern - условия выборки объявлений
 type Pattern struct {
-	TitleFits     func(title string) bool
-	TextFits      func(text string) bool
-	UserIDFits    func(userID int64) bool
-	PublishedFits func(published bool) bool
-	CreatedFits   func(created time.Time) bool
-	UpdatedFits   func(updated time.Time) bool
+	// Query - полнотекстовый запрос по заголовку и тексту, пустой - без поиска
+	Query string
+	// Filter - фильтр по полям объявления, nil - без фильтра
+	Filter Expr
+	// Category - категория вместе со всеми подкатегориями, 0 - любая. Дерево категорий знает только приложение:
+	// оно заменяет Category условием на category_id, а без такой замены подходят лишь объявления самой категории
+	Category int64
+	// Near - область поиска по радиусу, nil - где угодно. Объявления без местоположения в область не попадают
+	Near *Circle
 }
 
 func DefaultPattern() *Pattern {
-	return &Pattern{
-		TitleFits: func(string) bool {
-			return true
-		},
-		TextFits: func(string) bool {
-			return true
-		},
-		UserIDFits: func(int64) bool {
-			return true
-		},
-		PublishedFits: func(bool) bool {
-			return true
-		},
-		CreatedFits: func(time.Time) bool {
-			return true
-		},
-		UpdatedFits: func(time.Time) bool {
-			return true
-		},
-	}
+	return &Pattern{}
 }
 
 func (p *Pattern) Fits(ad *Ad) bool {
-	if !p.TitleFits(ad.Title) {
-		return false
-	}
-	if !p.TextFits(ad.Text) {
-		return false
-	}
-	if !p.UserIDFits(ad.UserID) {
+	if p.Category != 0 && ad.CategoryID != p.Category {
 		return false
 	}
-	if !p.PublishedFits(ad.Published) {
-		return false
+	if p.Near != nil {
+		if _, ok := p.Near.Near(ad); !ok {
+			return false
+		}
 	}
-	if !p.CreatedFits(ad.Created) {
-		return false
-	}
-	if !p.UpdatedFits(ad.Updated) {
-		return false
-	}
-	return true
-}
-
-func (p *Pattern) SetTitleFits(f func(string) bool) *Pattern {
-	pat := *p
-	pat.TitleFits = f
-	return &pat
+	return p.Filter == nil || p.Filter.Eval(ad)
 }
 
-func (p *Pattern) SetTextFits(f func(string) bool) *Pattern {
+func (p *Pattern) SetQuery(q string) *Pattern {
 	pat := *p
-	pat.TextFits = f
+	pat.Query = q
 	return &pat
 }
 
-func (p *Pattern) SetUserIDFits(f func(int64) bool) *Pattern {
+func (p *Pattern) SetCategory(ID int64) *Pattern {
 	pat := *p
-	pat.UserIDFits = f
+	pat.Category = ID
 	return &pat
 }
 
-func (p *Pattern) SetPublishedFits(f func(bool) bool) *Pattern {
+func (p *Pattern) SetNear(c *Circle) *Pattern {
 	pat := *p
-	pat.PublishedFits = f
+	pat.Near = c
 	return &pat
 }
 
-func (p *Pattern) SetCreatedFits(f func(time.Time) bool) *Pattern {
+// Where добавляет к фильтру шаблона условие e через "и"
+func (p *Pattern) Where(e Expr) *Pattern {
 	pat := *p
-	pat.CreatedFits = f
+	pat.Filter = AndExpr(p.Filter, e)
 	return &pat
 }
 
-func (p *Pattern) SetUpdatedFits(f func(time.Time) bool) *Pattern {
-	pat := *p
-	pat.UpdatedFits = f
-	return &pat
+func (p *Pattern) String() string {
+	if p.Filter == nil {
+		return ""
+	}
+	return p.Filter.String()
 }
-
-//type Pattern Ad
-//
-//func NewPattern() *Pattern {
-//	return &Pattern{
-//		UserID:    -1,
-//		Published: true,
-//	}
-//}
-//
-//func (p *Pattern) Match(ad *Ad) bool {
-//	if p.Title != "" && p.Title != ad.Title {
-//		return false
-//	}
-//	if p.Text != "" && p.Text != ad.Text {
-//		return false
-//	}
-//	if p.UserID != -1 && p.UserID != ad.UserID {
-//		return false
-//	}
-//	if p.Published != ad.Published {
-//		return false
-//	}
-//	t := time.Time{}
-//	if p.Created != t {
-//		pY, pM, pD := p.Created.UTC().Date()
-//		y, m, d := ad.Created.UTC().Date()
-//		if y != pY || m != pM || d != pD {
-//			return false
-//		}
-//	}
-//	if p.Updated != t {
-//		pY, pM, pD := p.Created.UTC().Date()
-//		y, m, d := ad.Created.UTC().Date()
-//		if y != pY || m != pM || d != pD {
-//			return false
-//		}
-//	}
-//	return true
-//}
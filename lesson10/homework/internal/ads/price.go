@@ -0,0 +1,88 @@
+package ads
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Price - цена объявления: сумма в минимальных единицах валюты (копейках, центах) и код валюты по ISO 4217.
+// Нулевая цена без валюты - цена не указана, например у объявлений, созданных до появления цен
+type Price struct {
+	Amount   int64  `validate:"min:0"`
+	Currency string `validate:"max:3"`
+}
+
+// Valid сообщает, корректна ли цена: валюта - известный код ISO 4217, а без валюты сумма нулевая
+func (p Price) Valid() bool {
+	if p.Currency == "" {
+		return p.Amount == 0
+	}
+	return ValidCurrency(p.Currency)
+}
+
+// Set сообщает, указана ли цена
+func (p Price) Set() bool {
+	return p.Currency != ""
+}
+
+// ValidCurrency сообщает, является ли code действующим кодом валюты по ISO 4217 (в верхнем регистре)
+func ValidCurrency(code string) bool {
+	return currencies[code]
+}
+
+// ParseCurrency разбирает код валюты из параметров выборки; регистр не важен
+func ParseCurrency(s string) (string, error) {
+	code := strings.ToUpper(strings.TrimSpace(s))
+	if !ValidCurrency(code) {
+		return "", fmt.Errorf("%w: unknown currency %q", ErrBadFilter, s)
+	}
+	return code, nil
+}
+
+// CurrencyIs - условие "цена объявления указана в валюте code"
+func CurrencyIs(code string) Expr {
+	return &Eq{Field: FieldCurrency, Value: StringValue(code)}
+}
+
+// PriceBetween - условие "цена указана и лежит в диапазоне [from, to]"; nil-граница диапазон не ограничивает.
+// Если не задана ни одна граница, условия нет (nil)
+func PriceBetween(from, to *int64) Expr {
+	if from == nil && to == nil {
+		return nil
+	}
+
+	r := &Range{Field: FieldPrice, IncludeFrom: true, IncludeTo: true}
+	if from != nil {
+		v := IntValue(*from)
+		r.From = &v
+	}
+	if to != nil {
+		v := IntValue(*to)
+		r.To = &v
+	}
+	return AndExpr(&Not{Arg: CurrencyIs("")}, r)
+}
+
+// currencies - действующие коды валют ISO 4217
+var currencies = map[string]bool{
+	"AED": true, "AFN": true, "ALL": true, "AMD": true, "ANG": true, "AOA": true, "ARS": true, "AUD": true,
+	"AWG": true, "AZN": true, "BAM": true, "BBD": true, "BDT": true, "BGN": true, "BHD": true, "BIF": true,
+	"BMD": true, "BND": true, "BOB": true, "BRL": true, "BSD": true, "BTN": true, "BWP": true, "BYN": true,
+	"BZD": true, "CAD": true, "CDF": true, "CHF": true, "CLP": true, "CNY": true, "COP": true, "CRC": true,
+	"CUP": true, "CVE": true, "CZK": true, "DJF": true, "DKK": true, "DOP": true, "DZD": true, "EGP": true,
+	"ERN": true, "ETB": true, "EUR": true, "FJD": true, "FKP": true, "GBP": true, "GEL": true, "GHS": true,
+	"GIP": true, "GMD": true, "GNF": true, "GTQ": true, "GYD": true, "HKD": true, "HNL": true, "HTG": true,
+	"HUF": true, "IDR": true, "ILS": true, "INR": true, "IQD": true, "IRR": true, "ISK": true, "JMD": true,
+	"JOD": true, "JPY": true, "KES": true, "KGS": true, "KHR": true, "KMF": true, "KPW": true, "KRW": true,
+	"KWD": true, "KYD": true, "KZT": true, "LAK": true, "LBP": true, "LKR": true, "LRD": true, "LSL": true,
+	"LYD": true, "MAD": true, "MDL": true, "MGA": true, "MKD": true, "MMK": true, "MNT": true, "MOP": true,
+	"MRU": true, "MUR": true, "MVR": true, "MWK": true, "MXN": true, "MYR": true, "MZN": true, "NAD": true,
+	"NGN": true, "NIO": true, "NOK": true, "NPR": true, "NZD": true, "OMR": true, "PAB": true, "PEN": true,
+	"PGK": true, "PHP": true, "PKR": true, "PLN": true, "PYG": true, "QAR": true, "RON": true, "RSD": true,
+	"RUB": true, "RWF": true, "SAR": true, "SBD": true, "SCR": true, "SDG": true, "SEK": true, "SGD": true,
+	"SHP": true, "SLE": true, "SOS": true, "SRD": true, "SSP": true, "STN": true, "SVC": true, "SYP": true,
+	"SZL": true, "THB": true, "TJS": true, "TMT": true, "TND": true, "TOP": true, "TRY": true, "TTD": true,
+	"TWD": true, "TZS": true, "UAH": true, "UGX": true, "USD": true, "UYU": true, "UZS": true, "VES": true,
+	"VND": true, "VUV": true, "WST": true, "XAF": true, "XCD": true, "XOF": true, "XPF": true, "YER": true,
+	"ZAR": true, "ZMW": true, "ZWL": true,
+}
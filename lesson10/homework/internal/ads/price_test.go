@@ -0,0 +1,87 @@
+package ads
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrice_Valid(t *testing.T) {
+	tests := []struct {
+		price Price
+		want  bool
+	}{
+		{price: Price{}, want: true},
+		{price: Price{Amount: 0, Currency: "RUB"}, want: true},
+		{price: Price{Amount: 1500000, Currency: "USD"}, want: true},
+		{price: Price{Amount: 100}, want: false},
+		{price: Price{Amount: 100, Currency: "usd"}, want: false},
+		{price: Price{Amount: 100, Currency: "XYZ"}, want: false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, tt.price.Valid(), tt.price)
+	}
+}
+
+func TestParseCurrency(t *testing.T) {
+	code, err := ParseCurrency(" eur")
+	assert.NoError(t, err)
+	assert.Equal(t, "EUR", code)
+
+	_, err = ParseCurrency("euro")
+	assert.ErrorIs(t, err, ErrBadFilter)
+}
+
+func TestPriceBetween(t *testing.T) {
+	from, to := int64(1000), int64(5000)
+	free := &Ad{Price: Price{Currency: "RUB"}}
+	cheap := &Ad{Price: Price{Amount: 1000, Currency: "RUB"}}
+	dear := &Ad{Price: Price{Amount: 5001, Currency: "RUB"}}
+	unpriced := &Ad{}
+
+	assert.Nil(t, PriceBetween(nil, nil))
+
+	e := PriceBetween(&from, &to)
+	assert.True(t, e.Eval(cheap))
+	assert.False(t, e.Eval(dear))
+
+	// объявление без цены не подходит ни под какой диапазон, даже начинающийся с нуля
+	zero := int64(0)
+	e = PriceBetween(nil, &to)
+	assert.True(t, e.Eval(free))
+	assert.False(t, e.Eval(unpriced))
+	e = PriceBetween(&zero, nil)
+	assert.False(t, e.Eval(unpriced))
+
+	again, err := ParseFilter(PriceBetween(&from, &to).String())
+	assert.NoError(t, err)
+	assert.Equal(t, PriceBetween(&from, &to).String(), again.String())
+}
+
+func TestPage_SortByPrice(t *testing.T) {
+	adverts := []*Ad{
+		{ID: 1, Price: Price{Amount: 300, Currency: "RUB"}},
+		{ID: 2, Price: Price{Amount: 100, Currency: "RUB"}},
+		{ID: 3, Price: Price{Amount: 200, Currency: "RUB"}},
+		{ID: 4, Price: Price{Amount: 100, Currency: "RUB"}},
+	}
+
+	sort, err := ParseSort("price:desc")
+	assert.NoError(t, err)
+
+	page, next, err := Page{Limit: 2, Sort: sort}.Cut(adverts)
+	assert.NoError(t, err)
+	if assert.Len(t, page, 2) {
+		assert.Equal(t, int64(1), page[0].ID)
+		assert.Equal(t, int64(3), page[1].ID)
+	}
+
+	page, next, err = Page{Limit: 2, Sort: sort, Cursor: next}.Cut(adverts)
+	assert.NoError(t, err)
+	assert.Empty(t, next)
+	if assert.Len(t, page, 2) {
+		assert.Equal(t, int64(4), page[0].ID)
+		assert.Equal(t, int64(2), page[1].ID)
+	}
+}
@@ -0,0 +1,116 @@
+package ads
+
+import (
+	"fmt"
+	"time"
+)
+
+// Query - условия выборки объявлений в том виде, в каком их задаёт клиент. В отличие от Pattern
+// условия можно сохранить, например в сохранённом поиске, и затем снова собрать из них шаблон
+type Query struct {
+	// Query - полнотекстовый запрос, Filter - фильтр в синтаксисе ParseFilter
+	Query  string `json:",omitempty"`
+	Filter string `json:",omitempty"`
+
+	Title     *string    `json:",omitempty"`
+	Created   *time.Time `json:",omitempty"`
+	UserID    *int64     `json:",omitempty"`
+	Published *bool      `json:",omitempty"`
+	// Statuses - статусы объявления, пустой - любой
+	Statuses []Status `json:",omitempty"`
+	// Category - категория вместе с подкатегориями, 0 - любая
+	Category int64  `json:",omitempty"`
+	PriceMin *int64 `json:",omitempty"`
+	PriceMax *int64 `json:",omitempty"`
+	// Currency - код валюты, пустой - любая
+	Currency string  `json:",omitempty"`
+	Near     *Circle `json:",omitempty"`
+}
+
+// Pattern проверяет условия и собирает из них шаблон выборки
+func (q *Query) Pattern() (*Pattern, error) {
+	filter, err := ParseFilter(q.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	f := DefaultPattern().SetQuery(q.Query).Where(filter)
+
+	if q.Title != nil {
+		f = f.Where(&Eq{Field: FieldTitle, Value: StringValue(*q.Title)})
+	}
+	if q.Created != nil {
+		f = f.Where(&Eq{Field: FieldCreated, Value: DateValue(*q.Created)})
+	}
+	if q.UserID != nil {
+		f = f.Where(&Eq{Field: FieldUserID, Value: IntValue(*q.UserID)})
+	}
+	if q.Published != nil {
+		f = f.Where(&Eq{Field: FieldPublished, Value: BoolValue(*q.Published)})
+	}
+	if q.Category != 0 {
+		f = f.SetCategory(q.Category)
+	}
+	if len(q.Statuses) > 0 {
+		for _, st := range q.Statuses {
+			if !st.Valid() {
+				return nil, fmt.Errorf("%w: unknown status %q", ErrBadFilter, st)
+			}
+		}
+		f = f.Where(StatusIn(q.Statuses...))
+	}
+	f = f.Where(PriceBetween(q.PriceMin, q.PriceMax))
+	if q.Currency != "" {
+		currency, err := ParseCurrency(q.Currency)
+		if err != nil {
+			return nil, err
+		}
+		f = f.Where(CurrencyIs(currency))
+	}
+	if q.Near != nil {
+		near, err := NewCircle(q.Near.Center.Lat, q.Near.Center.Lon, q.Near.Radius)
+		if err != nil {
+			return nil, err
+		}
+		f = f.SetNear(near)
+	}
+
+	return f, nil
+}
+
+// Copy возвращает копию условий, не разделяющую с ними значения
+func (q Query) Copy() Query {
+	cp := q
+	if q.Title != nil {
+		title := *q.Title
+		cp.Title = &title
+	}
+	if q.Created != nil {
+		created := *q.Created
+		cp.Created = &created
+	}
+	if q.UserID != nil {
+		userID := *q.UserID
+		cp.UserID = &userID
+	}
+	if q.Published != nil {
+		published := *q.Published
+		cp.Published = &published
+	}
+	if q.Statuses != nil {
+		cp.Statuses = append([]Status(nil), q.Statuses...)
+	}
+	if q.PriceMin != nil {
+		min := *q.PriceMin
+		cp.PriceMin = &min
+	}
+	if q.PriceMax != nil {
+		max := *q.PriceMax
+		cp.PriceMax = &max
+	}
+	if q.Near != nil {
+		near := *q.Near
+		cp.Near = &near
+	}
+	return cp
+}
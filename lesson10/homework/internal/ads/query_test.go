@@ -0,0 +1,78 @@
+package ads
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuery_Pattern(t *testing.T) {
+	title, published, min := "bike", true, int64(1000)
+	created := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+	q := Query{
+		Query:     "велосипед",
+		Filter:    "user_id = 2",
+		Title:     &title,
+		Created:   &created,
+		Published: &published,
+		Statuses:  []Status{StatusPublished},
+		Category:  3,
+		PriceMin:  &min,
+		Currency:  "rub",
+		Near:      &Circle{Center: Point{Lat: 55.75, Lon: 37.62}, Radius: 5},
+	}
+
+	p, err := q.Pattern()
+	assert.NoError(t, err)
+	assert.Equal(t, "велосипед", p.Query)
+	assert.Equal(t, int64(3), p.Category)
+	assert.Equal(t, &Circle{Center: Point{Lat: 55.75, Lon: 37.62}, Radius: 5}, p.Near)
+	assert.Equal(t, `user_id = 2 and title = "bike" and created = 2023-04-01 and published = true and status in ("published") and not (currency = "") and price >= 1000 and currency = "RUB"`, p.String())
+
+	// условия переживают сохранение
+	data, err := json.Marshal(q)
+	assert.NoError(t, err)
+	var restored Query
+	assert.NoError(t, json.Unmarshal(data, &restored))
+	p2, err := restored.Pattern()
+	assert.NoError(t, err)
+	assert.Equal(t, p.String(), p2.String())
+
+	p, err = (&Query{}).Pattern()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultPattern(), p)
+}
+
+func TestQuery_Pattern_Invalid(t *testing.T) {
+	tests := []Query{
+		{Filter: "title"},
+		{Statuses: []Status{"sold"}},
+		{Currency: "euro"},
+		{Near: &Circle{Center: Point{Lat: 91}, Radius: 5}},
+		{Near: &Circle{Radius: 1000}},
+	}
+
+	for _, q := range tests {
+		_, err := q.Pattern()
+		assert.ErrorIs(t, err, ErrBadFilter, q)
+	}
+}
+
+func TestQuery_Copy(t *testing.T) {
+	title, min := "bike", int64(1000)
+	q := Query{Title: &title, PriceMin: &min, Statuses: []Status{StatusDraft}, Near: &Circle{Radius: 5}}
+
+	cp := q.Copy()
+	assert.Equal(t, q, cp)
+
+	*cp.Title = "car"
+	*cp.PriceMin = 1
+	cp.Statuses[0] = StatusPublished
+	cp.Near.Radius = 1
+	assert.Equal(t, "bike", *q.Title)
+	assert.Equal(t, int64(1000), *q.PriceMin)
+	assert.Equal(t, StatusDraft, q.Statuses[0])
+	assert.Equal(t, 5.0, q.Near.Radius)
+}
@@ -6,6 +6,9 @@ import "context"
 type Repository interface {
 	AdByID(ctx context.Context, ID int64) (*Ad, error)
 	AddAd(ctx context.Context, ad *Ad) (int64, error)
-	AdsByPattern(ctx context.Context, p *Pattern) ([]*Ad, error)
+	AdsByPattern(ctx context.Context, p *Pattern, page Page) ([]*Ad, string, error)
+	// CountByCategory считает подходящие под шаблон объявления по их категориям (без учёта подкатегорий)
+	CountByCategory(ctx context.Context, p *Pattern) (map[int64]int, error)
+	UpdateAd(ctx context.Context, ad *Ad) error
 	DeleteAd(ctx context.Context, ID int64) error
 }
@@ -0,0 +1,184 @@
+package ads
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Status - стадия жизненного цикла объявления
+type Status string
+
+const (
+	StatusDraft     Status = "draft"
+	StatusPending   Status = "pending"
+	StatusPublished Status = "published"
+	StatusArchived  Status = "archived"
+	StatusRejected  Status = "rejected"
+)
+
+func (s Status) Valid() bool {
+	switch s {
+	case StatusDraft, StatusPending, StatusPublished, StatusArchived, StatusRejected:
+		return true
+	}
+	return false
+}
+
+// ParseStatuses разбирает список статусов, перечисленных через запятую
+func ParseStatuses(s string) ([]Status, error) {
+	var res []Status
+	for _, part := range strings.Split(s, ",") {
+		st := Status(strings.TrimSpace(part))
+		if !st.Valid() {
+			return nil, fmt.Errorf("%w: unknown status %q", ErrBadFilter, st)
+		}
+		res = append(res, st)
+	}
+	return res, nil
+}
+
+// StatusIn - условие "статус объявления - один из statuses"
+func StatusIn(statuses ...Status) Expr {
+	values := make([]Value, len(statuses))
+	for i, st := range statuses {
+		values[i] = StringValue(string(st))
+	}
+	return &In{Field: FieldStatus, Values: values}
+}
+
+// Event - действие, переводящее объявление из одного статуса в другой
+type Event string
+
+const (
+	// автор отправляет черновик (или отклонённое объявление) на модерацию
+	EventSubmit Event = "submit"
+	// автор отзывает объявление с модерации обратно в черновики
+	EventWithdraw Event = "withdraw"
+	// модератор одобряет объявление
+	EventApprove Event = "approve"
+	// модератор отклоняет объявление, указывая причину
+	EventReject Event = "reject"
+	// модератор публикует черновик сразу, минуя очередь на модерацию; прежнее ChangeAdStatus(published = true)
+	// делает то же от имени автора
+	EventPublish Event = "publish"
+	// объявление снимается с публикации в черновики (прежнее ChangeAdStatus(published = false))
+	EventUnpublish Event = "unpublish"
+	// объявление убирается в архив
+	EventArchive Event = "archive"
+	// архивное объявление возвращается в черновики
+	EventRestore Event = "restore"
+	// у объявления истёк срок, его убирает в архив сервис (см. Ad.Expires)
+	EventExpire Event = "expire"
+)
+
+// NoActor - ActorID перехода, который выполнил сам сервис, а не пользователь
+const NoActor int64 = -1
+
+type edge struct {
+	from []Status
+	to   Status
+}
+
+var lifecycle = map[Event]edge{
+	EventSubmit:    {from: []Status{StatusDraft, StatusRejected}, to: StatusPending},
+	EventWithdraw:  {from: []Status{StatusPending}, to: StatusDraft},
+	EventApprove:   {from: []Status{StatusPending}, to: StatusPublished},
+	EventReject:    {from: []Status{StatusPending, StatusPublished}, to: StatusRejected},
+	EventPublish:   {from: []Status{StatusDraft}, to: StatusPublished},
+	EventUnpublish: {from: []Status{StatusPublished}, to: StatusDraft},
+	EventArchive:   {from: []Status{StatusDraft, StatusPublished, StatusRejected}, to: StatusArchived},
+	EventRestore:   {from: []Status{StatusArchived}, to: StatusDraft},
+	EventExpire:    {from: []Status{StatusDraft, StatusPending, StatusPublished, StatusRejected}, to: StatusArchived},
+}
+
+func (e Event) Valid() bool {
+	_, ok := lifecycle[e]
+	return ok
+}
+
+var (
+	ErrBadTransition = fmt.Errorf("transition is not allowed")
+	ErrNoReason      = fmt.Errorf("reason is required")
+)
+
+// Transition - запись о смене статуса объявления
+type Transition struct {
+	Event   Event
+	From    Status
+	To      Status
+	At      time.Time
+	ActorID int64
+	Reason  string
+}
+
+// Published сообщает, опубликовано ли объявление
+func (ad *Ad) Published() bool {
+	return ad.Status == StatusPublished
+}
+
+// LastTransition возвращает последнюю смену статуса или nil, если статус не менялся с момента создания
+func (ad *Ad) LastTransition() *Transition {
+	if len(ad.Transitions) == 0 {
+		return nil
+	}
+	return &ad.Transitions[len(ad.Transitions)-1]
+}
+
+// Apply переводит объявление по событию e и записывает переход в историю
+func (ad *Ad) Apply(e Event, actorID int64, reason string, at time.Time) error {
+	ed, ok := lifecycle[e]
+	if !ok {
+		return fmt.Errorf("%w: unknown event %q", ErrBadTransition, e)
+	}
+	if e == EventReject && strings.TrimSpace(reason) == "" {
+		return ErrNoReason
+	}
+
+	from := ad.Status
+	if from == "" {
+		from = StatusDraft
+	}
+	allowed := false
+	for _, s := range ed.from {
+		allowed = allowed || s == from
+	}
+	if !allowed {
+		return fmt.Errorf("%w: can't %s %s ad", ErrBadTransition, e, from)
+	}
+
+	ad.Status = ed.to
+	// полное выражение среза, чтобы не писать в массив, общий с копиями объявления
+	ad.Transitions = append(ad.Transitions[:len(ad.Transitions):len(ad.Transitions)], Transition{
+		Event:   e,
+		From:    from,
+		To:      ed.to,
+		At:      at,
+		ActorID: actorID,
+		Reason:  reason,
+	})
+	ad.Updated = at
+	return nil
+}
+
+// UnmarshalJSON читает и объявления, сохранённые до появления статусов, когда был только флаг Published
+func (ad *Ad) UnmarshalJSON(data []byte) error {
+	type plain Ad
+	var v struct {
+		plain
+		Published *bool
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*ad = Ad(v.plain)
+	if ad.Status == "" {
+		ad.Status = StatusDraft
+		if v.Published != nil && *v.Published {
+			ad.Status = StatusPublished
+		}
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package ads
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAd_Apply(t *testing.T) {
+	at := time.Date(2023, 4, 1, 15, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		from   Status
+		event  Event
+		reason string
+		to     Status
+		err    error
+	}{
+		{name: "submit draft", from: StatusDraft, event: EventSubmit, to: StatusPending},
+		{name: "resubmit rejected", from: StatusRejected, event: EventSubmit, to: StatusPending},
+		{name: "withdraw pending", from: StatusPending, event: EventWithdraw, to: StatusDraft},
+		{name: "approve pending", from: StatusPending, event: EventApprove, to: StatusPublished},
+		{name: "reject pending", from: StatusPending, event: EventReject, reason: "spam", to: StatusRejected},
+		{name: "reject published", from: StatusPublished, event: EventReject, reason: "spam", to: StatusRejected},
+		{name: "publish draft", from: StatusDraft, event: EventPublish, to: StatusPublished},
+		{name: "unpublish published", from: StatusPublished, event: EventUnpublish, to: StatusDraft},
+		{name: "archive published", from: StatusPublished, event: EventArchive, to: StatusArchived},
+		{name: "restore archived", from: StatusArchived, event: EventRestore, to: StatusDraft},
+		{name: "expire published", from: StatusPublished, event: EventExpire, to: StatusArchived},
+		{name: "expire pending", from: StatusPending, event: EventExpire, to: StatusArchived},
+
+		{name: "approve draft", from: StatusDraft, event: EventApprove, err: ErrBadTransition},
+		{name: "publish pending", from: StatusPending, event: EventPublish, err: ErrBadTransition},
+		{name: "archive pending", from: StatusPending, event: EventArchive, err: ErrBadTransition},
+		{name: "submit archived", from: StatusArchived, event: EventSubmit, err: ErrBadTransition},
+		{name: "expire archived", from: StatusArchived, event: EventExpire, err: ErrBadTransition},
+		{name: "unknown event", from: StatusDraft, event: "burn", err: ErrBadTransition},
+		{name: "reject without reason", from: StatusPending, event: EventReject, reason: " ", err: ErrNoReason},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ad := &Ad{Status: tc.from}
+
+			err := ad.Apply(tc.event, 5, tc.reason, at)
+
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				assert.Equal(t, tc.from, ad.Status)
+				assert.Empty(t, ad.Transitions)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.to, ad.Status)
+			assert.Equal(t, at, ad.Updated)
+			assert.Equal(t, &Transition{Event: tc.event, From: tc.from, To: tc.to, At: at, ActorID: 5, Reason: tc.reason}, ad.LastTransition())
+		})
+	}
+}
+
+func TestAd_Expired(t *testing.T) {
+	now := time.Date(2023, 4, 1, 15, 30, 0, 0, time.UTC)
+
+	assert.False(t, (&Ad{}).Expired(now))
+	assert.False(t, (&Ad{Expires: now.Add(time.Second)}).Expired(now))
+	assert.True(t, (&Ad{Expires: now}).Expired(now))
+	assert.True(t, (&Ad{Expires: now.Add(-time.Hour)}).Expired(now))
+}
+
+func TestAd_ApplyDoesNotShareHistory(t *testing.T) {
+	ad := &Ad{Status: StatusDraft, Transitions: make([]Transition, 0, 4)}
+	cp := *ad
+
+	assert.NoError(t, ad.Apply(EventSubmit, 1, "", time.Now()))
+	assert.NoError(t, cp.Apply(EventPublish, 1, "", time.Now()))
+
+	assert.Equal(t, EventSubmit, ad.Transitions[0].Event)
+	assert.Equal(t, EventPublish, cp.Transitions[0].Event)
+}
+
+func TestAd_UnmarshalLegacy(t *testing.T) {
+	tests := []struct {
+		data string
+		want Status
+	}{
+		{data: `{"ID":1,"Published":true}`, want: StatusPublished},
+		{data: `{"ID":1,"Published":false}`, want: StatusDraft},
+		{data: `{"ID":1}`, want: StatusDraft},
+		{data: `{"ID":1,"Status":"archived"}`, want: StatusArchived},
+	}
+
+	for _, tc := range tests {
+		var ad Ad
+		assert.NoError(t, json.Unmarshal([]byte(tc.data), &ad))
+		assert.Equal(t, int64(1), ad.ID)
+		assert.Equal(t, tc.want, ad.Status, tc.data)
+	}
+}
+
+func TestParseStatuses(t *testing.T) {
+	got, err := ParseStatuses("draft, pending")
+	assert.NoError(t, err)
+	assert.Equal(t, []Status{StatusDraft, StatusPending}, got)
+
+	_, err = ParseStatuses("draft,deleted")
+	assert.ErrorIs(t, err, ErrBadFilter)
+}
@@ -4,69 +4,343 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/blobstore"
+	"homework10/internal/adapters/catrepo"
 	"homework10/internal/adapters/userrepo"
 	"homework10/internal/ads"
+	"homework10/internal/audit"
+	"homework10/internal/auth"
+	"homework10/internal/categories"
+	"homework10/internal/events"
+	"homework10/internal/favorites"
+	"homework10/internal/feed"
+	"homework10/internal/images"
+	"homework10/internal/messages"
+	"homework10/internal/policy"
+	"homework10/internal/searches"
 	"homework10/internal/users"
+	"homework10/internal/webhooks"
 
 	"github.com/newRational/vld"
 )
 
+// Методы, изменяющие данные, выполняются от имени пользователя, аутентифицированного в ctx (см. auth.WithUserID)
+//
 //go:generate mockery --name App
 type App interface {
-	CreateAd(ctx context.Context, title, text string, userID int64) (*ads.Ad, error)
+	CreateAd(ctx context.Context, title, text string, categoryID int64, price ads.Price, location *ads.Location, ttl time.Duration) (*ads.Ad, error)
 	AdByID(ctx context.Context, ID int64) (*ads.Ad, error)
-	AdsByPattern(ctx context.Context, p *ads.Pattern) ([]*ads.Ad, error)
-	UpdateAd(ctx context.Context, ID, userID int64, title, text string) (*ads.Ad, error)
-	ChangeAdStatus(ctx context.Context, ID, userID int64, published bool) (*ads.Ad, error)
-	DeleteAd(ctx context.Context, ID, userID int64) (*ads.Ad, error)
+	AdsByPattern(ctx context.Context, p *ads.Pattern, page ads.Page) ([]*ads.Ad, string, error)
+	WatchAds(ctx context.Context, p *ads.Pattern) (*feed.Subscription, error)
+	CategoryCounts(ctx context.Context, p *ads.Pattern) ([]categories.Count, error)
+	UpdateAd(ctx context.Context, ID, version int64, title, text string, price *ads.Price, location *ads.Location, clearLocation bool) (*ads.Ad, error)
+	ChangeAdStatus(ctx context.Context, ID, version int64, published bool) (*ads.Ad, error)
+	TransitionAd(ctx context.Context, ID, version int64, event ads.Event, reason string) (*ads.Ad, error)
+	RenewAd(ctx context.Context, ID, version int64, ttl time.Duration) (*ads.Ad, error)
+	AddAdImage(ctx context.Context, ID, version int64, data []byte) (*ads.Ad, error)
+	DeleteAdImage(ctx context.Context, ID, version int64, imageID string) (*ads.Ad, error)
+	Image(ctx context.Context, key string) ([]byte, error)
+	ExpireAds(ctx context.Context, now time.Time) (int, error)
+	DeleteAd(ctx context.Context, ID int64) (*ads.Ad, error)
+	AdHistory(ctx context.Context, ID int64) ([]*audit.Entry, error)
+	RevertAd(ctx context.Context, ID, version, revision int64) (*ads.Ad, error)
 
-	CreateUser(ctx context.Context, nick, email string) (*users.User, error)
+	Categories(ctx context.Context) (*categories.Tree, error)
+	CreateCategory(ctx context.Context, name string, parentID int64) (*categories.Category, error)
+	UpdateCategory(ctx context.Context, ID, version int64, name string, parentID int64) (*categories.Category, error)
+	DeleteCategory(ctx context.Context, ID int64) (*categories.Category, error)
+
+	Favorites(ctx context.Context, userID int64) ([]*ads.Ad, error)
+	AddFavorite(ctx context.Context, userID, adID int64) (*ads.Ad, error)
+	DeleteFavorite(ctx context.Context, userID, adID int64) (*ads.Ad, error)
+
+	CreateWebhook(ctx context.Context, url string, types []events.Type) (*webhooks.Subscription, error)
+	Webhooks(ctx context.Context) ([]*webhooks.Subscription, error)
+	DeleteWebhook(ctx context.Context, ID int64) (*webhooks.Subscription, error)
+	WebhookDeliveries(ctx context.Context, ID int64) ([]*webhooks.Delivery, error)
+
+	ContactSeller(ctx context.Context, adID int64, text string) (*messages.Message, error)
+	SendMessage(ctx context.Context, threadID int64, text string) (*messages.Message, error)
+	Threads(ctx context.Context, userID int64) ([]*messages.Thread, error)
+	Messages(ctx context.Context, threadID int64, page messages.Page) ([]*messages.Message, string, error)
+
+	SavedSearches(ctx context.Context, userID int64) ([]*searches.Search, error)
+	CreateSavedSearch(ctx context.Context, userID int64, name string, q ads.Query) (*searches.Search, error)
+	UpdateSavedSearch(ctx context.Context, userID, ID int64, name string, q ads.Query) (*searches.Search, error)
+	DeleteSavedSearch(ctx context.Context, userID, ID int64) (*searches.Search, error)
+	Notifications(ctx context.Context, userID int64) ([]*searches.Notification, int, error)
+	ReadNotifications(ctx context.Context, userID, upTo int64) error
+
+	CreateUser(ctx context.Context, nick, email, password string) (*users.User, error)
 	UserByID(ctx context.Context, ID int64) (*users.User, error)
-	UpdateUser(ctx context.Context, ID int64, nick, email string) (*users.User, error)
+	UpdateUser(ctx context.Context, ID, version int64, nick, email string) (*users.User, error)
 	DeleteUser(ctx context.Context, ID int64) (*users.User, error)
+	ChangeUserRole(ctx context.Context, ID, version int64, role users.Role) (*users.User, error)
+
+	Login(ctx context.Context, userID int64, password string) (auth.Tokens, error)
+	Refresh(ctx context.Context, refreshToken string) (auth.Tokens, error)
+	Authenticate(ctx context.Context, accessToken string) (int64, error)
 }
 
 type AdApp struct {
-	adRepo   ads.Repository
-	userRepo users.Repository
+	adRepo     ads.Repository
+	userRepo   users.Repository
+	catRepo    categories.Repository
+	favRepo    favorites.Repository
+	msgRepo    messages.Repository
+	auditRepo  audit.Repository
+	outbox     events.Outbox
+	hookRepo   webhooks.Repository
+	searchRepo searches.Repository
+	feed       *feed.Hub
+	images     images.Store
+	issuer     *auth.Issuer
+	adTTL      time.Duration
+	logger     *slog.Logger
 }
 
+const (
+	MinPasswordLen = 8
+
+	// DefaultAdTTL - срок жизни объявления, если при создании он не задан
+	DefaultAdTTL = 30 * 24 * time.Hour
+	// MaxAdTTL - наибольший срок, на который можно создать или продлить объявление
+	MaxAdTTL = 365 * 24 * time.Hour
+
+	// MaxAdImages - сколько фотографий можно прикрепить к одному объявлению
+	MaxAdImages = 10
+
+	// MaxFavorites - сколько объявлений пользователь может держать в избранном
+	MaxFavorites = 500
+
+	// MaxSearches - сколько поисков может сохранить пользователь
+	MaxSearches = 50
+	// MaxNotifications - сколько последних уведомлений отдаётся пользователю
+	MaxNotifications = 100
+)
+
 var (
-	ErrBadRequest            = fmt.Errorf("bad request")
-	ErrForbidden             = fmt.Errorf("forbidden")
-	ErrInternalAdRepoError   = fmt.Errorf("internal ad repo error")
-	ErrInternalUserRepoError = fmt.Errorf("internal user repo error")
+	ErrBadRequest              = fmt.Errorf("bad request")
+	ErrUnauthorized            = fmt.Errorf("unauthorized")
+	ErrForbidden               = fmt.Errorf("forbidden")
+	ErrConflict                = fmt.Errorf("version conflict")
+	ErrTransition              = fmt.Errorf("status transition is not allowed")
+	ErrTooLarge                = fmt.Errorf("payload is too large")
+	ErrUnavailable             = fmt.Errorf("service is shutting down")
+	ErrInternalAdRepoError     = fmt.Errorf("internal ad repo error")
+	ErrInternalUserRepoError   = fmt.Errorf("internal user repo error")
+	ErrInternalCatRepoError    = fmt.Errorf("internal category repo error")
+	ErrInternalFavRepoError    = fmt.Errorf("internal favorite repo error")
+	ErrInternalMsgRepoError    = fmt.Errorf("internal message repo error")
+	ErrInternalAuditRepoError  = fmt.Errorf("internal audit repo error")
+	ErrInternalHookRepoError   = fmt.Errorf("internal webhook repo error")
+	ErrInternalSearchRepoError = fmt.Errorf("internal saved search repo error")
+	ErrInternalImageError      = fmt.Errorf("internal image store error")
+	ErrInternalOutboxError     = fmt.Errorf("internal outbox error")
 )
 
-func NewApp(adRepo ads.Repository, userRepo users.Repository) App {
+// ErrorKind возвращает вид ошибки приложения для метрик и логов: bad_request, unauthorized, forbidden, conflict,
+// transition, too_large, unavailable или internal - для ошибок хранилищ и всех прочих
+func ErrorKind(err error) string {
+	switch {
+	case errors.Is(err, ErrTransition):
+		return "transition"
+	case errors.Is(err, ErrBadRequest):
+		return "bad_request"
+	case errors.Is(err, ErrUnauthorized):
+		return "unauthorized"
+	case errors.Is(err, ErrForbidden):
+		return "forbidden"
+	case errors.Is(err, ErrConflict):
+		return "conflict"
+	case errors.Is(err, ErrTooLarge):
+		return "too_large"
+	case errors.Is(err, ErrUnavailable):
+		return "unavailable"
+	default:
+		return "internal"
+	}
+}
+
+// NewApp создаёт приложение; adTTL - срок жизни объявлений по умолчанию, 0 - DefaultAdTTL.
+// Все опубликованные события рассылаются также подписчикам hub (см. WatchAds), ошибки, не прерывающие
+// запрос, пишутся в logger
+func NewApp(adRepo ads.Repository, userRepo users.Repository, catRepo categories.Repository, favRepo favorites.Repository,
+	msgRepo messages.Repository, auditRepo audit.Repository, outbox events.Outbox, hookRepo webhooks.Repository,
+	searchRepo searches.Repository, hub *feed.Hub, imageStore images.Store, issuer *auth.Issuer, adTTL time.Duration,
+	logger *slog.Logger) App {
+	if adTTL <= 0 {
+		adTTL = DefaultAdTTL
+	}
+
 	return &AdApp{
-		adRepo:   adRepo,
-		userRepo: userRepo,
+		adRepo:     adRepo,
+		userRepo:   userRepo,
+		catRepo:    catRepo,
+		favRepo:    favRepo,
+		msgRepo:    msgRepo,
+		auditRepo:  auditRepo,
+		outbox:     outbox,
+		hookRepo:   hookRepo,
+		searchRepo: searchRepo,
+		feed:       hub,
+		images:     imageStore,
+		issuer:     issuer,
+		adTTL:      adTTL,
+		logger:     logger,
+	}
+}
+
+// TTLFromSeconds переводит срок жизни объявления из секунд в time.Duration. Срок проверяется до умножения:
+// иначе большое число секунд переполнило бы int64 и превратилось в короткий или отрицательный срок
+func TTLFromSeconds(seconds int64) (time.Duration, error) {
+	if seconds < 0 || seconds > int64(MaxAdTTL/time.Second) {
+		return 0, ErrBadRequest
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// ttlOrDefault проверяет срок жизни объявления, нулевой заменяя сроком по умолчанию
+func (a *AdApp) ttlOrDefault(ttl time.Duration) (time.Duration, error) {
+	if ttl == 0 {
+		return a.adTTL, nil
+	}
+	if ttl < 0 || ttl > MaxAdTTL {
+		return 0, ErrBadRequest
 	}
+	return ttl, nil
 }
 
-func (a *AdApp) CreateAd(ctx context.Context, title, text string, userID int64) (*ads.Ad, error) {
-	_, err := a.userRepo.UserByID(ctx, userID)
+// checkPrice проверяет цену объявления: сумма неотрицательна, а валюта - код ISO 4217
+func checkPrice(price ads.Price) error {
+	if err := vld.Validate(price); err != nil {
+		return ErrBadRequest
+	}
+	if !price.Valid() {
+		return fmt.Errorf("%w: bad price or unknown currency %q", ErrBadRequest, price.Currency)
+	}
+	return nil
+}
+
+// checkLocation проверяет местоположение объявления (nil - не указано): координаты в допустимых пределах
+// и непустое название населённого пункта
+func checkLocation(location *ads.Location) error {
+	if location == nil {
+		return nil
+	}
+	if err := vld.Validate(*location); err != nil {
+		return ErrBadRequest
+	}
+	if !location.Valid() {
+		return fmt.Errorf("%w: coordinates are out of range", ErrBadRequest)
+	}
+	return nil
+}
+
+// actingUser возвращает пользователя, от имени которого выполняется запрос
+func (a *AdApp) actingUser(ctx context.Context) (*users.User, error) {
+	userID, ok := auth.UserID(ctx)
+	if !ok {
+		return nil, ErrUnauthorized
+	}
+
+	u, err := a.userRepo.UserByID(ctx, userID)
 	if errors.Is(err, userrepo.ErrNoUser) {
-		return nil, ErrBadRequest
+		return nil, ErrUnauthorized
 	} else if err != nil {
 		return nil, ErrInternalUserRepoError
 	}
 
+	return u, nil
+}
+
+// authorize проверяет по политике доступа, что actor может выполнить action над объектом ownerID
+func authorize(actor *users.User, action policy.Action, ownerID int64) error {
+	if err := policy.Check(actor, action, ownerID); err != nil {
+		return fmt.Errorf("%w: %s", ErrForbidden, err.Error())
+	}
+
+	return nil
+}
+
+// actorOf возвращает ID пользователя, аутентифицированного в ctx, а для запросов без аутентификации - ads.NoActor
+func actorOf(ctx context.Context) int64 {
+	if ID, ok := auth.UserID(ctx); ok {
+		return ID
+	}
+	return ads.NoActor
+}
+
+// record дописывает изменение в журнал аудита, публикует соответствующее ему событие в outbox и в ленту и уведомляет
+// о нём владельцев сохранённых поисков. Ошибка журнала только пишется в лог, а ошибка outbox проваливает запрос
+// с ErrInternalOutboxError: изменение к этому моменту уже сохранено, но без события его молча не увидели бы
+// подписчики вебхуков, так что о сбое должен узнать хотя бы клиент
+func (a *AdApp) record(ctx context.Context, e *audit.Entry) error {
+	e.At = time.Now().UTC()
+	if _, err := a.auditRepo.Append(ctx, e); err != nil {
+		a.logger.ErrorContext(ctx, "can't record change in audit log", "op", e.Op, "kind", e.Kind, "object_id", e.ObjectID, "err", err)
+	}
+
+	ev := eventOf(e)
+	if ev == nil {
+		return nil
+	}
+	if _, err := a.outbox.Add(ctx, ev); err != nil {
+		a.logger.ErrorContext(ctx, "can't publish event", "event", ev.Type, "object_id", ev.ObjectID, "err", err)
+		return ErrInternalOutboxError
+	}
+	a.feed.Publish(ev)
+	a.notifySearches(ctx, ev)
+	return nil
+}
+
+// CreateAd создаёт черновик объявления с ценой price в категории categoryID и местоположением location (nil - не указано),
+// который истечёт через ttl (0 - срок по умолчанию)
+func (a *AdApp) CreateAd(ctx context.Context, title, text string, categoryID int64, price ads.Price, location *ads.Location, ttl time.Duration) (*ads.Ad, error) {
+	actor, err := a.actingUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl, err = a.ttlOrDefault(ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
 	ad := &ads.Ad{
-		ID:      -1,
-		Title:   title,
-		Text:    text,
-		UserID:  userID,
-		Created: time.Now().UTC(),
-		Updated: time.Now().UTC(),
+		ID:         -1,
+		Title:      title,
+		Text:       text,
+		UserID:     actor.ID,
+		CategoryID: categoryID,
+		Price:      price,
+		Location:   location,
+		Status:     ads.StatusDraft,
+		Created:    now,
+		Updated:    now,
+		Expires:    now.Add(ttl),
 	}
 	if err = vld.Validate(*ad); err != nil {
 		return nil, ErrBadRequest
 	}
+	if err = checkPrice(price); err != nil {
+		return nil, err
+	}
+	if err = checkLocation(location); err != nil {
+		return nil, err
+	}
+
+	_, err = a.catRepo.CategoryByID(ctx, categoryID)
+	if errors.Is(err, catrepo.ErrNoCategory) {
+		return nil, ErrBadRequest
+	} else if err != nil {
+		return nil, ErrInternalCatRepoError
+	}
 
 	id, err := a.adRepo.AddAd(ctx, ad)
 	if errors.Is(err, adrepo.ErrAdAlreadyExists) {
@@ -76,15 +350,18 @@ func (a *AdApp) CreateAd(ctx context.Context, title, text string, userID int64)
 	}
 
 	ad.ID = id
+	if err = a.record(ctx, &audit.Entry{Kind: audit.KindAd, ObjectID: id, ActorID: actor.ID, Op: audit.OpCreate, After: audit.AdSnapshot(ad)}); err != nil {
+		return nil, err
+	}
 	return ad, nil
 }
 
-func (a *AdApp) UpdateAd(ctx context.Context, ID, userID int64, title, text string) (*ads.Ad, error) {
-	_, err := a.userRepo.UserByID(ctx, userID)
-	if errors.Is(err, userrepo.ErrNoUser) {
-		return nil, ErrBadRequest
-	} else if err != nil {
-		return nil, ErrInternalUserRepoError
+// UpdateAd обновляет объявление: цена и местоположение заменяются, только если заданы (nil - не менять),
+// clearLocation убирает местоположение; если version != 0, то объявление должно иметь именно эту версию
+func (a *AdApp) UpdateAd(ctx context.Context, ID, version int64, title, text string, price *ads.Price, location *ads.Location, clearLocation bool) (*ads.Ad, error) {
+	actor, err := a.actingUser(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	ad, err := a.adRepo.AdByID(ctx, ID)
@@ -94,27 +371,118 @@ func (a *AdApp) UpdateAd(ctx context.Context, ID, userID int64, title, text stri
 		return nil, ErrInternalAdRepoError
 	}
 
-	if ad.UserID != userID {
-		return nil, ErrForbidden
+	if err = authorize(actor, policy.UpdateAd, ad.UserID); err != nil {
+		return nil, err
+	}
+
+	if version != 0 && ad.Version != version {
+		return nil, ErrConflict
 	}
 
 	if err = vld.Validate(ads.Ad{Title: title, Text: text}); err != nil {
 		return nil, ErrBadRequest
 	}
+	if price != nil {
+		if err = checkPrice(*price); err != nil {
+			return nil, err
+		}
+	}
+	if location != nil && clearLocation {
+		return nil, ErrBadRequest
+	}
+	if err = checkLocation(location); err != nil {
+		return nil, err
+	}
 
+	before := audit.AdSnapshot(ad)
 	ad.Text = text
 	ad.Title = title
+	if price != nil {
+		ad.Price = *price
+	}
+	if location != nil || clearLocation {
+		ad.Location = location
+	}
 	ad.Updated = time.Now().UTC()
 
+	if err = a.updateAd(ctx, ad); err != nil {
+		return nil, err
+	}
+	if err = a.record(ctx, &audit.Entry{Kind: audit.KindAd, ObjectID: ID, ActorID: actor.ID, Op: audit.OpUpdate, Before: before, After: audit.AdSnapshot(ad)}); err != nil {
+		return nil, err
+	}
+
 	return ad, nil
 }
 
-func (a *AdApp) ChangeAdStatus(ctx context.Context, ID, userID int64, published bool) (*ads.Ad, error) {
-	_, err := a.userRepo.UserByID(ctx, userID)
-	if errors.Is(err, userrepo.ErrNoUser) {
+// ChangeAdStatus публикует черновик (published = true) или снимает объявление с публикации (published = false);
+// если объявление уже в нужном состоянии, статус не меняется. Если version != 0, то объявление должно иметь именно эту версию.
+// Автор по-прежнему публикует здесь черновик сам, без модерации; в TransitionAd событие publish доступно только модераторам
+func (a *AdApp) ChangeAdStatus(ctx context.Context, ID, version int64, published bool) (*ads.Ad, error) {
+	actor, err := a.actingUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ad, err := a.adRepo.AdByID(ctx, ID)
+	if errors.Is(err, adrepo.ErrNoAd) {
 		return nil, ErrBadRequest
 	} else if err != nil {
-		return nil, ErrInternalUserRepoError
+		return nil, ErrInternalAdRepoError
+	}
+
+	event, action := ads.EventUnpublish, policy.UnpublishAd
+	if published {
+		event, action = ads.EventPublish, policy.PublishAd
+	}
+	if err = authorize(actor, action, ad.UserID); err != nil {
+		return nil, err
+	}
+
+	if version != 0 && ad.Version != version {
+		return nil, ErrConflict
+	}
+
+	before := audit.AdSnapshot(ad)
+	if ad.Published() == published {
+		ad.Updated = time.Now().UTC()
+	} else if err = ad.Apply(event, actor.ID, "", time.Now().UTC()); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrTransition, err.Error())
+	}
+
+	if err = a.updateAd(ctx, ad); err != nil {
+		return nil, err
+	}
+	if err = a.record(ctx, &audit.Entry{Kind: audit.KindAd, ObjectID: ID, ActorID: actor.ID, Op: audit.OpChangeStatus, Before: before, After: audit.AdSnapshot(ad)}); err != nil {
+		return nil, err
+	}
+
+	return ad, nil
+}
+
+// eventActions - какое право по политике доступа нужно для каждого перехода
+var eventActions = map[ads.Event]policy.Action{
+	ads.EventSubmit:    policy.SubmitAd,
+	ads.EventWithdraw:  policy.SubmitAd,
+	ads.EventApprove:   policy.ModerateAd,
+	ads.EventReject:    policy.ModerateAd,
+	ads.EventPublish:   policy.ModerateAd,
+	ads.EventUnpublish: policy.UnpublishAd,
+	ads.EventArchive:   policy.ArchiveAd,
+	ads.EventRestore:   policy.RestoreAd,
+}
+
+// TransitionAd переводит объявление по жизненному циклу (см. ads.Event); причина обязательна при отклонении.
+// Если version != 0, то объявление должно иметь именно эту версию
+func (a *AdApp) TransitionAd(ctx context.Context, ID, version int64, event ads.Event, reason string) (*ads.Ad, error) {
+	actor, err := a.actingUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	action, ok := eventActions[event]
+	if !ok {
+		return nil, ErrBadRequest
 	}
 
 	ad, err := a.adRepo.AdByID(ctx, ID)
@@ -124,22 +492,140 @@ func (a *AdApp) ChangeAdStatus(ctx context.Context, ID, userID int64, published
 		return nil, ErrInternalAdRepoError
 	}
 
-	if ad.UserID != userID {
-		return nil, ErrForbidden
+	if err = authorize(actor, action, ad.UserID); err != nil {
+		return nil, err
 	}
 
-	ad.Published = published
-	ad.Updated = time.Now().UTC()
+	if version != 0 && ad.Version != version {
+		return nil, ErrConflict
+	}
+
+	before := audit.AdSnapshot(ad)
+	err = ad.Apply(event, actor.ID, reason, time.Now().UTC())
+	if errors.Is(err, ads.ErrNoReason) {
+		return nil, ErrBadRequest
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrTransition, err.Error())
+	}
+
+	if err = a.updateAd(ctx, ad); err != nil {
+		return nil, err
+	}
+	if err = a.record(ctx, &audit.Entry{Kind: audit.KindAd, ObjectID: ID, ActorID: actor.ID, Op: audit.OpTransition, Before: before, After: audit.AdSnapshot(ad)}); err != nil {
+		return nil, err
+	}
 
 	return ad, nil
 }
 
-func (a *AdApp) DeleteAd(ctx context.Context, ID, userID int64) (*ads.Ad, error) {
-	_, err := a.userRepo.UserByID(ctx, userID)
-	if errors.Is(err, userrepo.ErrNoUser) {
+// RenewAd продлевает объявление автора на ttl (0 - срок по умолчанию), считая от текущего момента.
+// Если version != 0, то объявление должно иметь именно эту версию
+func (a *AdApp) RenewAd(ctx context.Context, ID, version int64, ttl time.Duration) (*ads.Ad, error) {
+	actor, err := a.actingUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl, err = a.ttlOrDefault(ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	ad, err := a.adRepo.AdByID(ctx, ID)
+	if errors.Is(err, adrepo.ErrNoAd) {
 		return nil, ErrBadRequest
 	} else if err != nil {
-		return nil, ErrInternalUserRepoError
+		return nil, ErrInternalAdRepoError
+	}
+
+	if err = authorize(actor, policy.RenewAd, ad.UserID); err != nil {
+		return nil, err
+	}
+
+	if version != 0 && ad.Version != version {
+		return nil, ErrConflict
+	}
+
+	before := audit.AdSnapshot(ad)
+	now := time.Now().UTC()
+	ad.Expires = now.Add(ttl)
+	ad.Updated = now
+
+	if err = a.updateAd(ctx, ad); err != nil {
+		return nil, err
+	}
+	if err = a.record(ctx, &audit.Entry{Kind: audit.KindAd, ObjectID: ID, ActorID: actor.ID, Op: audit.OpRenew, Before: before, After: audit.AdSnapshot(ad)}); err != nil {
+		return nil, err
+	}
+
+	return ad, nil
+}
+
+// ExpireAds убирает в архив объявления, срок которых истёк к моменту now, и возвращает их количество.
+// Вызывается фоновым процессом (см. пакет janitor), а не от имени пользователя. Объявления, изменённые
+// одновременно с ним, пропускаются - они будут убраны при следующем вызове
+func (a *AdApp) ExpireAds(ctx context.Context, now time.Time) (int, error) {
+	p := ads.DefaultPattern().Where(&ads.Range{
+		Field: ads.FieldExpires,
+		// бессрочные объявления (с нулевым Expires) не истекают
+		From:      &ads.Value{Kind: ads.KindTime},
+		To:        &ads.Value{Kind: ads.KindTime, Time: now},
+		IncludeTo: true,
+	}).Where(&ads.Not{Arg: ads.StatusIn(ads.StatusArchived)})
+
+	var expired []*ads.Ad
+	page := ads.Page{Limit: ads.MaxLimit}
+	for {
+		adverts, next, err := a.adRepo.AdsByPattern(ctx, p, page)
+		if err != nil {
+			return 0, ErrInternalAdRepoError
+		}
+		expired = append(expired, adverts...)
+		if next == "" {
+			break
+		}
+		page.Cursor = next
+	}
+
+	n := 0
+	for _, ad := range expired {
+		before := audit.AdSnapshot(ad)
+		if err := ad.Apply(ads.EventExpire, ads.NoActor, "", now); err != nil {
+			continue
+		}
+		err := a.updateAd(ctx, ad)
+		if errors.Is(err, ErrConflict) || errors.Is(err, ErrBadRequest) {
+			continue
+		} else if err != nil {
+			return n, err
+		}
+		n++
+		if err = a.record(ctx, &audit.Entry{Kind: audit.KindAd, ObjectID: ad.ID, ActorID: ads.NoActor, Op: audit.OpExpire, Before: before, After: audit.AdSnapshot(ad)}); err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+func (a *AdApp) updateAd(ctx context.Context, ad *ads.Ad) error {
+	err := a.adRepo.UpdateAd(ctx, ad)
+	if errors.Is(err, adrepo.ErrAdVersionConflict) {
+		return ErrConflict
+	} else if errors.Is(err, adrepo.ErrNoAd) {
+		return ErrBadRequest
+	} else if err != nil {
+		return ErrInternalAdRepoError
+	}
+
+	return nil
+}
+
+// DeleteAd удаляет объявление вместе с его фотографиями и убирает его из избранного всех пользователей
+func (a *AdApp) DeleteAd(ctx context.Context, ID int64) (*ads.Ad, error) {
+	actor, err := a.actingUser(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	ad, err := a.adRepo.AdByID(ctx, ID)
@@ -149,18 +635,141 @@ func (a *AdApp) DeleteAd(ctx context.Context, ID, userID int64) (*ads.Ad, error)
 		return nil, ErrInternalAdRepoError
 	}
 
-	if ad.UserID != userID {
-		return nil, ErrForbidden
+	if err = authorize(actor, policy.DeleteAd, ad.UserID); err != nil {
+		return nil, err
+	}
+
+	// избранное чистится до удаления объявления: при ошибке запрос можно просто повторить
+	if err = a.favRepo.DeleteByAd(ctx, ID); err != nil {
+		return nil, ErrInternalFavRepoError
 	}
 
 	if err = a.adRepo.DeleteAd(ctx, ID); err != nil {
 		return nil, ErrInternalAdRepoError
 	}
+	err = a.record(ctx, &audit.Entry{Kind: audit.KindAd, ObjectID: ID, ActorID: actor.ID, Op: audit.OpDelete, Before: audit.AdSnapshot(ad)})
+
+	// объявление уже удалено, поэтому файлы освобождаются и при ошибке outbox, а ошибка освобождения оставит
+	// лишь неиспользуемые файлы
+	for _, img := range ad.Images {
+		a.releaseImage(ctx, img)
+	}
+	if err != nil {
+		return nil, err
+	}
 
 	return ad, nil
 }
 
-func (a *AdApp) AdByID(ctx context.Context, ID int64) (*ads.Ad, error) {
+// AddAdImage прикрепляет к объявлению фотографию в формате JPEG или PNG и строит её миниатюру;
+// повторная загрузка той же фотографии ничего не меняет. Если version != 0, то объявление должно иметь именно эту версию
+func (a *AdApp) AddAdImage(ctx context.Context, ID, version int64, data []byte) (*ads.Ad, error) {
+	ad, err := a.editableAd(ctx, ID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	key := images.Key(data)
+	for _, img := range ad.Images {
+		if img.ID == key {
+			return ad, nil
+		}
+	}
+	if len(ad.Images) >= MaxAdImages {
+		return nil, ErrBadRequest
+	}
+
+	p, err := images.Process(data)
+	if errors.Is(err, images.ErrTooLarge) {
+		return nil, ErrTooLarge
+	} else if err != nil {
+		return nil, ErrBadRequest
+	}
+
+	before := audit.AdSnapshot(ad)
+	img := ads.Image{ContentType: p.ContentType, Width: p.Width, Height: p.Height}
+	if img.ID, err = a.images.Put(ctx, data); err != nil {
+		return nil, ErrInternalImageError
+	}
+	if img.Thumb, err = a.images.Put(ctx, p.Thumb); err != nil {
+		_ = a.images.Release(ctx, img.ID)
+		return nil, ErrInternalImageError
+	}
+
+	ad.Images = append(ad.Images[:len(ad.Images):len(ad.Images)], img)
+	ad.Updated = time.Now().UTC()
+
+	if err = a.updateAd(ctx, ad); err != nil {
+		a.releaseImage(ctx, img)
+		return nil, err
+	}
+	if err = a.record(ctx, &audit.Entry{Kind: audit.KindAd, ObjectID: ID, ActorID: actorOf(ctx), Op: audit.OpAddImage, Before: before, After: audit.AdSnapshot(ad)}); err != nil {
+		return nil, err
+	}
+
+	return ad, nil
+}
+
+// DeleteAdImage открепляет фотографию от объявления и освобождает её файлы.
+// Если version != 0, то объявление должно иметь именно эту версию
+func (a *AdApp) DeleteAdImage(ctx context.Context, ID, version int64, imageID string) (*ads.Ad, error) {
+	ad, err := a.editableAd(ctx, ID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	i := 0
+	for i < len(ad.Images) && ad.Images[i].ID != imageID {
+		i++
+	}
+	if i == len(ad.Images) {
+		return nil, ErrBadRequest
+	}
+
+	before := audit.AdSnapshot(ad)
+	img := ad.Images[i]
+	rest := make([]ads.Image, 0, len(ad.Images)-1)
+	ad.Images = append(append(rest, ad.Images[:i]...), ad.Images[i+1:]...)
+	ad.Updated = time.Now().UTC()
+
+	if err = a.updateAd(ctx, ad); err != nil {
+		return nil, err
+	}
+	err = a.record(ctx, &audit.Entry{Kind: audit.KindAd, ObjectID: ID, ActorID: actorOf(ctx), Op: audit.OpDeleteImage, Before: before, After: audit.AdSnapshot(ad)})
+
+	a.releaseImage(ctx, img)
+	if err != nil {
+		return nil, err
+	}
+	return ad, nil
+}
+
+// Image возвращает файл изображения или миниатюры по его ключу
+func (a *AdApp) Image(ctx context.Context, key string) ([]byte, error) {
+	data, err := a.images.Get(ctx, key)
+	if errors.Is(err, blobstore.ErrNoBlob) {
+		return nil, ErrBadRequest
+	} else if err != nil {
+		return nil, ErrInternalImageError
+	}
+
+	return data, nil
+}
+
+// releaseImage освобождает файлы фотографии; ошибка оставляет неиспользуемый файл и не мешает работе
+func (a *AdApp) releaseImage(ctx context.Context, img ads.Image) {
+	_ = a.images.Release(ctx, img.ID)
+	_ = a.images.Release(ctx, img.Thumb)
+}
+
+// editableAd возвращает объявление ID, проверив, что отправитель запроса может его изменять,
+// и что (если version != 0) у объявления именно эта версия
+func (a *AdApp) editableAd(ctx context.Context, ID, version int64) (*ads.Ad, error) {
+	actor, err := a.actingUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	ad, err := a.adRepo.AdByID(ctx, ID)
 	if errors.Is(err, adrepo.ErrNoAd) {
 		return nil, ErrBadRequest
@@ -168,30 +777,79 @@ func (a *AdApp) AdByID(ctx context.Context, ID int64) (*ads.Ad, error) {
 		return nil, ErrInternalAdRepoError
 	}
 
+	if err = authorize(actor, policy.UpdateAd, ad.UserID); err != nil {
+		return nil, err
+	}
+
+	if version != 0 && ad.Version != version {
+		return nil, ErrConflict
+	}
+
 	return ad, nil
 }
 
-func (a *AdApp) AdsByPattern(ctx context.Context, p *ads.Pattern) ([]*ads.Ad, error) {
-	adverts, err := a.adRepo.AdsByPattern(ctx, p)
+func (a *AdApp) AdByID(ctx context.Context, ID int64) (*ads.Ad, error) {
+	ad, err := a.adRepo.AdByID(ctx, ID)
 	if errors.Is(err, adrepo.ErrNoAd) {
 		return nil, ErrBadRequest
 	} else if err != nil {
 		return nil, ErrInternalAdRepoError
 	}
 
-	return adverts, nil
+	if err = a.countFavorites(ctx, ad); err != nil {
+		return nil, err
+	}
+
+	return ad, nil
+}
+
+// AdsByPattern возвращает страницу подходящих под шаблон объявлений; категория шаблона отбирает
+// объявления как самой категории, так и всех её подкатегорий
+func (a *AdApp) AdsByPattern(ctx context.Context, p *ads.Pattern, page ads.Page) ([]*ads.Ad, string, error) {
+	if page.Sort.Field == ads.SortByDistance && (p == nil || p.Near == nil) {
+		return nil, "", fmt.Errorf("%w: sorting by distance requires a search area", ErrBadRequest)
+	}
+
+	p, err := a.expandCategory(ctx, p)
+	if err != nil {
+		return nil, "", err
+	}
+
+	adverts, next, err := a.adRepo.AdsByPattern(ctx, p, page)
+	if errors.Is(err, adrepo.ErrNoAd) || errors.Is(err, ads.ErrBadPage) {
+		return nil, "", ErrBadRequest
+	} else if err != nil {
+		return nil, "", ErrInternalAdRepoError
+	}
+
+	if err = a.countFavorites(ctx, adverts...); err != nil {
+		return nil, "", err
+	}
+
+	return adverts, next, nil
 }
 
-func (a *AdApp) CreateUser(ctx context.Context, nick, email string) (*users.User, error) {
+// CreateUser регистрирует пользователя с паролем, по которому он затем сможет войти (см. Login)
+func (a *AdApp) CreateUser(ctx context.Context, nick, email, password string) (*users.User, error) {
 	u := &users.User{
 		ID:       -1,
 		Nickname: nick,
 		Email:    email,
+		Role:     users.RoleUser,
 	}
 
 	if err := vld.Validate(*u); err != nil {
 		return nil, ErrBadRequest
 	}
+	if len(password) < MinPasswordLen {
+		return nil, ErrBadRequest
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return nil, ErrBadRequest
+	}
+	u.PasswordHash = hash
 
 	id, err := a.userRepo.AddUser(ctx, u)
 	if errors.Is(err, userrepo.ErrUserAlreadyExists) {
@@ -201,27 +859,84 @@ func (a *AdApp) CreateUser(ctx context.Context, nick, email string) (*users.User
 	}
 
 	u.ID = id
+	// при регистрации запрос выполняется без аутентификации, так что пользователь создаёт себя сам
+	if err = a.record(ctx, &audit.Entry{Kind: audit.KindUser, ObjectID: id, ActorID: id, Op: audit.OpCreate, After: audit.UserSnapshot(u)}); err != nil {
+		return nil, err
+	}
 	return u, nil
 }
 
-func (a *AdApp) UpdateUser(ctx context.Context, ID int64, nick, email string) (*users.User, error) {
-	u, err := a.userRepo.UserByID(ctx, ID)
-	if errors.Is(err, userrepo.ErrNoUser) {
-		return nil, ErrBadRequest
-	} else if err != nil {
-		return nil, ErrInternalUserRepoError
+// UpdateUser обновляет пользователя; если version != 0, то пользователь должен иметь именно эту версию.
+// Пользователь может изменить себя, администратор - любого пользователя.
+func (a *AdApp) UpdateUser(ctx context.Context, ID, version int64, nick, email string) (*users.User, error) {
+	u, err := a.managedUser(ctx, ID, policy.UpdateUser)
+	if err != nil {
+		return nil, err
+	}
+
+	if version != 0 && u.Version != version {
+		return nil, ErrConflict
 	}
 
 	if err = vld.Validate(users.User{Nickname: nick, Email: email}); err != nil {
 		return nil, ErrBadRequest
 	}
 
+	before := audit.UserSnapshot(u)
 	u.Nickname = nick
 	u.Email = email
 
+	if err = a.updateUser(ctx, u); err != nil {
+		return nil, err
+	}
+	if err = a.record(ctx, &audit.Entry{Kind: audit.KindUser, ObjectID: ID, ActorID: actorOf(ctx), Op: audit.OpUpdate, Before: before, After: audit.UserSnapshot(u)}); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// ChangeUserRole назначает пользователю роль; это может делать только администратор
+func (a *AdApp) ChangeUserRole(ctx context.Context, ID, version int64, role users.Role) (*users.User, error) {
+	u, err := a.managedUser(ctx, ID, policy.ChangeUserRole)
+	if err != nil {
+		return nil, err
+	}
+
+	if !role.Valid() {
+		return nil, ErrBadRequest
+	}
+
+	if version != 0 && u.Version != version {
+		return nil, ErrConflict
+	}
+
+	before := audit.UserSnapshot(u)
+	u.Role = role
+
+	if err = a.updateUser(ctx, u); err != nil {
+		return nil, err
+	}
+	if err = a.record(ctx, &audit.Entry{Kind: audit.KindUser, ObjectID: ID, ActorID: actorOf(ctx), Op: audit.OpChangeRole, Before: before, After: audit.UserSnapshot(u)}); err != nil {
+		return nil, err
+	}
+
 	return u, nil
 }
 
+func (a *AdApp) updateUser(ctx context.Context, u *users.User) error {
+	err := a.userRepo.UpdateUser(ctx, u)
+	if errors.Is(err, userrepo.ErrUserVersionConflict) {
+		return ErrConflict
+	} else if errors.Is(err, userrepo.ErrNoUser) {
+		return ErrBadRequest
+	} else if err != nil {
+		return ErrInternalUserRepoError
+	}
+
+	return nil
+}
+
 func (a *AdApp) UserByID(ctx context.Context, ID int64) (*users.User, error) {
 	u, err := a.userRepo.UserByID(ctx, ID)
 	if errors.Is(err, userrepo.ErrNoUser) {
@@ -233,7 +948,44 @@ func (a *AdApp) UserByID(ctx context.Context, ID int64) (*users.User, error) {
 	return u, nil
 }
 
+// DeleteUser удаляет пользователя вместе с его избранным и сохранёнными поисками; пользователь может удалить себя, администратор - любого пользователя
 func (a *AdApp) DeleteUser(ctx context.Context, ID int64) (*users.User, error) {
+	u, err := a.managedUser(ctx, ID, policy.DeleteUser)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = a.favRepo.DeleteByUser(ctx, ID); err != nil {
+		return nil, ErrInternalFavRepoError
+	}
+	if err = a.searchRepo.DeleteByUser(ctx, ID); err != nil {
+		return nil, ErrInternalSearchRepoError
+	}
+
+	if err = a.userRepo.DeleteUser(ctx, ID); err != nil {
+		return nil, ErrInternalUserRepoError
+	}
+	if err = a.record(ctx, &audit.Entry{Kind: audit.KindUser, ObjectID: ID, ActorID: actorOf(ctx), Op: audit.OpDelete, Before: audit.UserSnapshot(u)}); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// managedUser возвращает пользователя ID, проверив, что отправитель запроса может выполнить над ним action
+func (a *AdApp) managedUser(ctx context.Context, ID int64, action policy.Action) (*users.User, error) {
+	actor, err := a.actingUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = authorize(actor, action, ID); err != nil {
+		return nil, err
+	}
+	if actor.ID == ID {
+		return actor, nil
+	}
+
 	u, err := a.userRepo.UserByID(ctx, ID)
 	if errors.Is(err, userrepo.ErrNoUser) {
 		return nil, ErrBadRequest
@@ -241,9 +993,62 @@ func (a *AdApp) DeleteUser(ctx context.Context, ID int64) (*users.User, error) {
 		return nil, ErrInternalUserRepoError
 	}
 
-	if err = a.userRepo.DeleteUser(ctx, ID); err != nil {
-		return nil, ErrInternalUserRepoError
+	return u, nil
+}
+
+// Login проверяет пароль пользователя и выдаёт ему пару токенов
+func (a *AdApp) Login(ctx context.Context, userID int64, password string) (auth.Tokens, error) {
+	u, err := a.userRepo.UserByID(ctx, userID)
+	if errors.Is(err, userrepo.ErrNoUser) {
+		return auth.Tokens{}, ErrUnauthorized
+	} else if err != nil {
+		return auth.Tokens{}, ErrInternalUserRepoError
 	}
 
-	return u, nil
+	ok, err := auth.CheckPassword(u.PasswordHash, password)
+	if err != nil || !ok {
+		return auth.Tokens{}, ErrUnauthorized
+	}
+
+	return a.issue(u.ID)
+}
+
+// Refresh выдаёт новую пару токенов по действующему refresh-токену
+func (a *AdApp) Refresh(ctx context.Context, refreshToken string) (auth.Tokens, error) {
+	userID, err := a.verify(ctx, refreshToken, auth.Refresh)
+	if err != nil {
+		return auth.Tokens{}, err
+	}
+
+	return a.issue(userID)
+}
+
+// Authenticate проверяет access-токен и возвращает ID его владельца
+func (a *AdApp) Authenticate(ctx context.Context, accessToken string) (int64, error) {
+	return a.verify(ctx, accessToken, auth.Access)
+}
+
+func (a *AdApp) verify(ctx context.Context, token string, kind auth.Kind) (int64, error) {
+	userID, err := a.issuer.Verify(token, kind)
+	if err != nil {
+		return 0, ErrUnauthorized
+	}
+
+	_, err = a.userRepo.UserByID(ctx, userID)
+	if errors.Is(err, userrepo.ErrNoUser) {
+		return 0, ErrUnauthorized
+	} else if err != nil {
+		return 0, ErrInternalUserRepoError
+	}
+
+	return userID, nil
+}
+
+func (a *AdApp) issue(userID int64) (auth.Tokens, error) {
+	t, err := a.issuer.Issue(userID)
+	if err != nil {
+		return auth.Tokens{}, fmt.Errorf("issue tokens: %w", err)
+	}
+
+	return t, nil
 }
@@ -1,42 +1,105 @@
 package app
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"image"
+	"image/png"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/bcrypt"
 
 	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/auditrepo"
+	"homework10/internal/adapters/blobstore"
+	"homework10/internal/adapters/catrepo"
+	"homework10/internal/adapters/favrepo"
+	"homework10/internal/adapters/hookrepo"
+	"homework10/internal/adapters/msgrepo"
+	"homework10/internal/adapters/outboxrepo"
+	"homework10/internal/adapters/searchrepo"
 	"homework10/internal/adapters/userrepo"
 	"homework10/internal/ads"
 	adrepoMock "homework10/internal/ads/mocks"
+	"homework10/internal/audit"
+	auditrepoMock "homework10/internal/audit/mocks"
+	"homework10/internal/auth"
+	"homework10/internal/categories"
+	catrepoMock "homework10/internal/categories/mocks"
+	"homework10/internal/events"
+	outboxMock "homework10/internal/events/mocks"
+	"homework10/internal/favorites"
+	favrepoMock "homework10/internal/favorites/mocks"
+	"homework10/internal/feed"
+	"homework10/internal/images"
+	imagesMock "homework10/internal/images/mocks"
+	"homework10/internal/logging"
+	"homework10/internal/messages"
+	msgrepoMock "homework10/internal/messages/mocks"
+	"homework10/internal/searches"
+	searchrepoMock "homework10/internal/searches/mocks"
 	"homework10/internal/users"
 	userrepoMock "homework10/internal/users/mocks"
+	"homework10/internal/webhooks"
+	hookrepoMock "homework10/internal/webhooks/mocks"
 )
 
 type AppTestSuite struct {
 	suite.Suite
-	adRepo   *adrepoMock.Repository
-	userRepo *userrepoMock.Repository
-	app      App
+	adRepo     *adrepoMock.Repository
+	userRepo   *userrepoMock.Repository
+	catRepo    *catrepoMock.Repository
+	favRepo    *favrepoMock.Repository
+	msgRepo    *msgrepoMock.Repository
+	auditRepo  *auditrepoMock.Repository
+	outbox     *outboxMock.Outbox
+	hookRepo   *hookrepoMock.Repository
+	searchRepo *searchrepoMock.Repository
+	feed       *feed.Hub
+	images     *imagesMock.Store
+	issuer     *auth.Issuer
+	app        App
 }
 
 func (s *AppTestSuite) SetupSuite() {
 	s.adRepo = adrepoMock.NewRepository(s.T())
 	s.userRepo = userrepoMock.NewRepository(s.T())
-	s.app = NewApp(s.adRepo, s.userRepo)
+	s.catRepo = catrepoMock.NewRepository(s.T())
+	s.favRepo = favrepoMock.NewRepository(s.T())
+	s.msgRepo = msgrepoMock.NewRepository(s.T())
+	s.auditRepo = auditrepoMock.NewRepository(s.T())
+	// журнал пишется после каждого изменения; что именно в него попадает, проверяют тесты с настоящим журналом
+	s.auditRepo.On("Append", mock.Anything, mock.Anything).Return(int64(1), nil).Maybe()
+	s.outbox = outboxMock.NewOutbox(s.T())
+	// как и журнал, события публикуются после каждого изменения: их проверяют тесты с настоящим outbox
+	s.outbox.On("Add", mock.Anything, mock.Anything).Return(int64(1), nil).Maybe()
+	s.hookRepo = hookrepoMock.NewRepository(s.T())
+	s.searchRepo = searchrepoMock.NewRepository(s.T())
+	// новые объявления проверяются по сохранённым поискам; уведомления проверяют тесты с настоящим репозиторием поисков
+	s.searchRepo.On("Matching", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil).Maybe()
+	s.feed = feed.NewHub(0)
+	s.images = imagesMock.NewStore(s.T())
+	s.issuer = auth.NewIssuer([]byte("secret"), time.Minute, time.Hour)
+	s.app = NewApp(s.adRepo, s.userRepo, s.catRepo, s.favRepo, s.msgRepo, s.auditRepo, s.outbox, s.hookRepo, s.searchRepo, s.feed, s.images, s.issuer, 0, logging.Discard())
+
+	auth.PasswordCost = bcrypt.MinCost
 }
 
 func (s *AppTestSuite) TestAdApp_CreateAd() {
 	type args struct {
-		ctx    context.Context
-		title  string
-		text   string
-		userID int64
+		ctx      context.Context
+		title    string
+		text     string
+		ttl      time.Duration
+		userID   int64
+		category int64
+		price    ads.Price
+		location *ads.Location
 	}
 	tests := []struct {
 		name    string
@@ -58,7 +121,7 @@ func (s *AppTestSuite) TestAdApp_CreateAd() {
 					Once()
 			},
 			wantErr: true,
-			err:     ErrBadRequest,
+			err:     ErrUnauthorized,
 		},
 		{
 			name: "unknown error from userRepo.UserByID func",
@@ -82,7 +145,7 @@ func (s *AppTestSuite) TestAdApp_CreateAd() {
 			setMock: func() {
 				s.userRepo.
 					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, nil).
+					Return(&users.User{}, nil).
 					Once()
 			},
 			wantErr: true,
@@ -91,14 +154,20 @@ func (s *AppTestSuite) TestAdApp_CreateAd() {
 		{
 			name: "err ad already exists",
 			args: args{
-				ctx:   context.Background(),
-				title: "title",
-				text:  "text",
+				ctx:      context.Background(),
+				title:    "title",
+				text:     "text",
+				category: 1,
 			},
 			setMock: func() {
 				s.userRepo.
 					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, nil).
+					Return(&users.User{}, nil).
+					Once()
+
+				s.catRepo.
+					On("CategoryByID", mock.Anything, int64(1)).
+					Return(&categories.Category{ID: 1, Name: "Разное"}, nil).
 					Once()
 
 				s.adRepo.
@@ -112,14 +181,20 @@ func (s *AppTestSuite) TestAdApp_CreateAd() {
 		{
 			name: "unknown error from adRepo.AddAd func",
 			args: args{
-				ctx:   context.Background(),
-				title: "title",
-				text:  "text",
+				ctx:      context.Background(),
+				title:    "title",
+				text:     "text",
+				category: 1,
 			},
 			setMock: func() {
 				s.userRepo.
 					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, nil).
+					Return(&users.User{}, nil).
+					Once()
+
+				s.catRepo.
+					On("CategoryByID", mock.Anything, int64(1)).
+					Return(&categories.Category{ID: 1, Name: "Разное"}, nil).
 					Once()
 
 				s.adRepo.
@@ -130,17 +205,45 @@ func (s *AppTestSuite) TestAdApp_CreateAd() {
 			wantErr: true,
 			err:     ErrInternalAdRepoError,
 		},
+		{
+			name: "unknown category",
+			args: args{
+				ctx:      context.Background(),
+				title:    "title",
+				text:     "text",
+				category: 7,
+			},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{}, nil).
+					Once()
+
+				s.catRepo.
+					On("CategoryByID", mock.Anything, int64(7)).
+					Return(nil, catrepo.ErrNoCategory).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrBadRequest,
+		},
 		{
 			name: "ok",
 			args: args{
-				ctx:   context.Background(),
-				title: "title",
-				text:  "text",
+				ctx:      context.Background(),
+				title:    "title",
+				text:     "text",
+				category: 1,
 			},
 			setMock: func() {
 				s.userRepo.
 					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, nil).
+					Return(&users.User{}, nil).
+					Once()
+
+				s.catRepo.
+					On("CategoryByID", mock.Anything, int64(1)).
+					Return(&categories.Category{ID: 1, Name: "Разное"}, nil).
 					Once()
 
 				s.adRepo.
@@ -149,179 +252,233 @@ func (s *AppTestSuite) TestAdApp_CreateAd() {
 					Once()
 			},
 			want: &ads.Ad{
-				ID:      0,
-				Title:   "title",
-				Text:    "text",
-				UserID:  0,
-				Created: time.Now().UTC(),
-				Updated: time.Now().UTC(),
+				ID:         0,
+				Title:      "title",
+				Text:       "text",
+				UserID:     0,
+				CategoryID: 1,
+				Created:    time.Now().UTC(),
+				Updated:    time.Now().UTC(),
+				Expires:    time.Now().UTC().Add(DefaultAdTTL),
 			},
 			wantErr: false,
 		},
-	}
+		{
+			name: "too long ttl",
+			args: args{
+				ctx:      context.Background(),
+				title:    "title",
+				text:     "text",
+				category: 1,
+				ttl:      MaxAdTTL + time.Hour,
+			},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{}, nil).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrBadRequest,
+		},
+		{
+			name: "ok with ttl",
+			args: args{
+				ctx:      context.Background(),
+				title:    "title",
+				text:     "text",
+				category: 1,
+				ttl:      time.Hour,
+			},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{}, nil).
+					Once()
 
-	for _, tt := range tests {
-		s.T().Run(tt.name, func(t *testing.T) {
-			tt.setMock()
-			ad, err := s.app.CreateAd(tt.args.ctx, tt.args.title, tt.args.text, tt.args.userID)
-			if tt.wantErr {
-				assert.ErrorIs(t, err, tt.err)
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, tt.want.ID, ad.ID)
-				assert.Equal(t, tt.want.Title, ad.Title)
-				assert.Equal(t, tt.want.Text, ad.Text)
-				assert.Equal(t, tt.want.UserID, ad.UserID)
-				assert.InDelta(t, tt.want.Created.Unix(), ad.Created.Unix(), 1)
-				assert.InDelta(t, tt.want.Updated.Unix(), ad.Updated.Unix(), 1)
-			}
-		})
-	}
-}
+				s.catRepo.
+					On("CategoryByID", mock.Anything, int64(1)).
+					Return(&categories.Category{ID: 1, Name: "Разное"}, nil).
+					Once()
 
-func (s *AppTestSuite) TestAdApp_UpdateAd() {
-	type args struct {
-		ctx    context.Context
-		adId   int64
-		title  string
-		text   string
-		userID int64
-	}
-	tests := []struct {
-		name    string
-		args    args
-		setMock func()
-		want    *ads.Ad
-		wantErr bool
-		err     error
-	}{
+				s.adRepo.
+					On("AddAd", mock.Anything, mock.Anything).
+					Return(int64(0), nil).
+					Once()
+			},
+			want: &ads.Ad{
+				Title:      "title",
+				Text:       "text",
+				CategoryID: 1,
+				Created:    time.Now().UTC(),
+				Updated:    time.Now().UTC(),
+				Expires:    time.Now().UTC().Add(time.Hour),
+			},
+			wantErr: false,
+		},
 		{
-			name: "wrong userID",
+			name: "unknown currency",
 			args: args{
-				ctx: context.Background(),
+				ctx:      context.Background(),
+				title:    "title",
+				text:     "text",
+				category: 1,
+				price:    ads.Price{Amount: 100, Currency: "XYZ"},
 			},
 			setMock: func() {
 				s.userRepo.
 					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, userrepo.ErrNoUser).
+					Return(&users.User{}, nil).
 					Once()
 			},
 			wantErr: true,
 			err:     ErrBadRequest,
 		},
 		{
-			name: "unknown error from userRepo.UserByID func",
+			name: "price without currency",
 			args: args{
-				ctx: context.Background(),
+				ctx:      context.Background(),
+				title:    "title",
+				text:     "text",
+				category: 1,
+				price:    ads.Price{Amount: 100},
 			},
 			setMock: func() {
 				s.userRepo.
 					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, fmt.Errorf("unknown error from userRepo.UserByID func")).
+					Return(&users.User{}, nil).
 					Once()
 			},
 			wantErr: true,
-			err:     ErrInternalUserRepoError,
+			err:     ErrBadRequest,
 		},
 		{
-			name: "err no ad",
+			name: "negative price",
 			args: args{
-				ctx: context.Background(),
+				ctx:      context.Background(),
+				title:    "title",
+				text:     "text",
+				category: 1,
+				price:    ads.Price{Amount: -1, Currency: "RUB"},
 			},
 			setMock: func() {
 				s.userRepo.
 					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, nil).
-					Once()
-
-				s.adRepo.
-					On("AdByID", mock.Anything, mock.Anything).
-					Return(nil, adrepo.ErrNoAd).
+					Return(&users.User{}, nil).
 					Once()
 			},
 			wantErr: true,
 			err:     ErrBadRequest,
 		},
 		{
-			name: "unknown error from adRepo.AdByID func",
+			name: "ok with price",
 			args: args{
-				ctx: context.Background(),
+				ctx:      context.Background(),
+				title:    "title",
+				text:     "text",
+				category: 1,
+				price:    ads.Price{Amount: 1500000, Currency: "RUB"},
 			},
 			setMock: func() {
 				s.userRepo.
 					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, nil).
+					Return(&users.User{}, nil).
+					Once()
+
+				s.catRepo.
+					On("CategoryByID", mock.Anything, int64(1)).
+					Return(&categories.Category{ID: 1, Name: "Разное"}, nil).
 					Once()
 
 				s.adRepo.
-					On("AdByID", mock.Anything, mock.Anything).
-					Return(nil, fmt.Errorf("unknown error from userRepo.UserByID func")).
+					On("AddAd", mock.Anything, mock.MatchedBy(func(ad *ads.Ad) bool {
+						return ad.Price == ads.Price{Amount: 1500000, Currency: "RUB"}
+					})).
+					Return(int64(0), nil).
 					Once()
 			},
-			wantErr: true,
-			err:     ErrInternalAdRepoError,
+			want: &ads.Ad{
+				Title:      "title",
+				Text:       "text",
+				CategoryID: 1,
+				Price:      ads.Price{Amount: 1500000, Currency: "RUB"},
+				Created:    time.Now().UTC(),
+				Updated:    time.Now().UTC(),
+				Expires:    time.Now().UTC().Add(DefaultAdTTL),
+			},
+			wantErr: false,
 		},
 		{
-			name: "forbidden request from user",
+			name: "location out of range",
 			args: args{
-				ctx:    context.Background(),
-				userID: 1,
+				ctx:      context.Background(),
+				title:    "title",
+				text:     "text",
+				category: 1,
+				location: &ads.Location{Point: ads.Point{Lat: 95, Lon: 37.6}, City: "Москва"},
 			},
 			setMock: func() {
 				s.userRepo.
 					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, nil).
-					Once()
-
-				s.adRepo.
-					On("AdByID", mock.Anything, mock.Anything).
-					Return(&ads.Ad{}, nil).
+					Return(&users.User{}, nil).
 					Once()
 			},
 			wantErr: true,
-			err:     ErrForbidden,
+			err:     ErrBadRequest,
 		},
 		{
-			name: "validate error",
+			name: "location without city",
 			args: args{
-				ctx: context.Background(),
+				ctx:      context.Background(),
+				title:    "title",
+				text:     "text",
+				category: 1,
+				location: &ads.Location{Point: ads.Point{Lat: 55.7, Lon: 37.6}},
 			},
 			setMock: func() {
 				s.userRepo.
 					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, nil).
-					Once()
-
-				s.adRepo.
-					On("AdByID", mock.Anything, mock.Anything).
-					Return(&ads.Ad{}, nil).
+					Return(&users.User{}, nil).
 					Once()
 			},
 			wantErr: true,
 			err:     ErrBadRequest,
 		},
 		{
-			name: "ok",
+			name: "ok with location",
 			args: args{
-				ctx:   context.Background(),
-				title: "new title",
-				text:  "new text",
+				ctx:      context.Background(),
+				title:    "title",
+				text:     "text",
+				category: 1,
+				location: &ads.Location{Point: ads.Point{Lat: 55.7558, Lon: 37.6173}, City: "Москва"},
 			},
 			setMock: func() {
 				s.userRepo.
 					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, nil).
+					Return(&users.User{}, nil).
+					Once()
+
+				s.catRepo.
+					On("CategoryByID", mock.Anything, int64(1)).
+					Return(&categories.Category{ID: 1, Name: "Разное"}, nil).
 					Once()
 
 				s.adRepo.
-					On("AdByID", mock.Anything, mock.Anything).
-					Return(&ads.Ad{}, nil).
+					On("AddAd", mock.Anything, mock.MatchedBy(func(ad *ads.Ad) bool {
+						return ad.Location != nil && ad.Location.City == "Москва"
+					})).
+					Return(int64(0), nil).
 					Once()
 			},
 			want: &ads.Ad{
-				Title:   "new title",
-				Text:    "new text",
-				Updated: time.Now().UTC(),
+				Title:      "title",
+				Text:       "text",
+				CategoryID: 1,
+				Location:   &ads.Location{Point: ads.Point{Lat: 55.7558, Lon: 37.6173}, City: "Москва"},
+				Created:    time.Now().UTC(),
+				Updated:    time.Now().UTC(),
+				Expires:    time.Now().UTC().Add(DefaultAdTTL),
 			},
 			wantErr: false,
 		},
@@ -330,7 +487,7 @@ func (s *AppTestSuite) TestAdApp_UpdateAd() {
 	for _, tt := range tests {
 		s.T().Run(tt.name, func(t *testing.T) {
 			tt.setMock()
-			ad, err := s.app.UpdateAd(tt.args.ctx, tt.args.adId, tt.args.userID, tt.args.title, tt.args.text)
+			ad, err := s.app.CreateAd(auth.WithUserID(tt.args.ctx, tt.args.userID), tt.args.title, tt.args.text, tt.args.category, tt.args.price, tt.args.location, tt.args.ttl)
 			if tt.wantErr {
 				assert.ErrorIs(t, err, tt.err)
 			} else {
@@ -339,18 +496,28 @@ func (s *AppTestSuite) TestAdApp_UpdateAd() {
 				assert.Equal(t, tt.want.Title, ad.Title)
 				assert.Equal(t, tt.want.Text, ad.Text)
 				assert.Equal(t, tt.want.UserID, ad.UserID)
+				assert.Equal(t, tt.want.CategoryID, ad.CategoryID)
+				assert.Equal(t, tt.want.Price, ad.Price)
+				assert.Equal(t, tt.want.Location, ad.Location)
+				assert.InDelta(t, tt.want.Created.Unix(), ad.Created.Unix(), 1)
 				assert.InDelta(t, tt.want.Updated.Unix(), ad.Updated.Unix(), 1)
+				assert.InDelta(t, tt.want.Expires.Unix(), ad.Expires.Unix(), 1)
 			}
 		})
 	}
 }
 
-func (s *AppTestSuite) TestAdApp_ChangeAdStatus() {
+func (s *AppTestSuite) TestAdApp_UpdateAd() {
 	type args struct {
-		ctx       context.Context
-		adId      int64
-		published bool
-		userID    int64
+		ctx           context.Context
+		adId          int64
+		title         string
+		text          string
+		userID        int64
+		version       int64
+		price         *ads.Price
+		location      *ads.Location
+		clearLocation bool
 	}
 	tests := []struct {
 		name    string
@@ -372,7 +539,7 @@ func (s *AppTestSuite) TestAdApp_ChangeAdStatus() {
 					Once()
 			},
 			wantErr: true,
-			err:     ErrBadRequest,
+			err:     ErrUnauthorized,
 		},
 		{
 			name: "unknown error from userRepo.UserByID func",
@@ -396,7 +563,7 @@ func (s *AppTestSuite) TestAdApp_ChangeAdStatus() {
 			setMock: func() {
 				s.userRepo.
 					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, nil).
+					Return(&users.User{}, nil).
 					Once()
 
 				s.adRepo.
@@ -415,7 +582,7 @@ func (s *AppTestSuite) TestAdApp_ChangeAdStatus() {
 			setMock: func() {
 				s.userRepo.
 					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, nil).
+					Return(&users.User{}, nil).
 					Once()
 
 				s.adRepo.
@@ -435,7 +602,7 @@ func (s *AppTestSuite) TestAdApp_ChangeAdStatus() {
 			setMock: func() {
 				s.userRepo.
 					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, nil).
+					Return(&users.User{ID: 1}, nil).
 					Once()
 
 				s.adRepo.
@@ -447,14 +614,14 @@ func (s *AppTestSuite) TestAdApp_ChangeAdStatus() {
 			err:     ErrForbidden,
 		},
 		{
-			name: "ok",
+			name: "validate error",
 			args: args{
 				ctx: context.Background(),
 			},
 			setMock: func() {
 				s.userRepo.
 					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, nil).
+					Return(&users.User{}, nil).
 					Once()
 
 				s.adRepo.
@@ -462,122 +629,123 @@ func (s *AppTestSuite) TestAdApp_ChangeAdStatus() {
 					Return(&ads.Ad{}, nil).
 					Once()
 			},
-			want: &ads.Ad{
-				Published: true,
-				Updated:   time.Now().UTC(),
-			},
-			wantErr: false,
+			wantErr: true,
+			err:     ErrBadRequest,
 		},
-	}
-
-	for _, tt := range tests {
-		s.T().Run(tt.name, func(t *testing.T) {
-			tt.setMock()
-			ad, err := s.app.ChangeAdStatus(tt.args.ctx, tt.args.adId, tt.args.userID, tt.args.published)
-			if tt.wantErr {
-				assert.ErrorIs(t, err, tt.err)
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, tt.want.ID, ad.ID)
-				assert.Equal(t, tt.want.Title, ad.Title)
-				assert.Equal(t, tt.want.Text, ad.Text)
-				assert.Equal(t, tt.want.UserID, ad.UserID)
-				assert.InDelta(t, tt.want.Updated.Unix(), ad.Updated.Unix(), 1)
-			}
-		})
-	}
-}
-
-func (s *AppTestSuite) TestAdApp_DeleteAd() {
-	type args struct {
-		ctx    context.Context
-		adId   int64
-		userID int64
-	}
-	tests := []struct {
-		name    string
-		args    args
-		setMock func()
-		want    *ads.Ad
-		wantErr bool
-		err     error
-	}{
 		{
-			name: "wrong userID",
+			name: "version mismatch",
 			args: args{
-				ctx: context.Background(),
+				ctx:     context.Background(),
+				version: 2,
 			},
 			setMock: func() {
 				s.userRepo.
 					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, userrepo.ErrNoUser).
+					Return(&users.User{}, nil).
+					Once()
+
+				s.adRepo.
+					On("AdByID", mock.Anything, mock.Anything).
+					Return(&ads.Ad{Version: 1}, nil).
 					Once()
 			},
 			wantErr: true,
-			err:     ErrBadRequest,
+			err:     ErrConflict,
 		},
 		{
-			name: "unknown error from userRepo.UserByID func",
+			name: "version conflict from adRepo.UpdateAd func",
 			args: args{
-				ctx: context.Background(),
+				ctx:   context.Background(),
+				title: "new title",
+				text:  "new text",
 			},
 			setMock: func() {
 				s.userRepo.
 					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, fmt.Errorf("unknown error from userRepo.UserByID func")).
+					Return(&users.User{}, nil).
+					Once()
+
+				s.adRepo.
+					On("AdByID", mock.Anything, mock.Anything).
+					Return(&ads.Ad{}, nil).
+					Once()
+
+				s.adRepo.
+					On("UpdateAd", mock.Anything, mock.Anything).
+					Return(adrepo.ErrAdVersionConflict).
 					Once()
 			},
 			wantErr: true,
-			err:     ErrInternalUserRepoError,
+			err:     ErrConflict,
 		},
 		{
-			name: "err no ad",
+			name: "unknown error from adRepo.UpdateAd func",
 			args: args{
-				ctx: context.Background(),
+				ctx:   context.Background(),
+				title: "new title",
+				text:  "new text",
 			},
 			setMock: func() {
 				s.userRepo.
 					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, nil).
+					Return(&users.User{}, nil).
 					Once()
 
 				s.adRepo.
 					On("AdByID", mock.Anything, mock.Anything).
-					Return(nil, adrepo.ErrNoAd).
+					Return(&ads.Ad{}, nil).
+					Once()
+
+				s.adRepo.
+					On("UpdateAd", mock.Anything, mock.Anything).
+					Return(fmt.Errorf("unknown error from adRepo.UpdateAd func")).
 					Once()
 			},
 			wantErr: true,
-			err:     ErrBadRequest,
+			err:     ErrInternalAdRepoError,
 		},
 		{
-			name: "unknown error from adRepo.AdByID func",
+			name: "ok",
 			args: args{
-				ctx: context.Background(),
+				ctx:   context.Background(),
+				title: "new title",
+				text:  "new text",
 			},
 			setMock: func() {
 				s.userRepo.
 					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, nil).
+					Return(&users.User{}, nil).
 					Once()
 
 				s.adRepo.
 					On("AdByID", mock.Anything, mock.Anything).
-					Return(nil, fmt.Errorf("unknown error from userRepo.UserByID func")).
+					Return(&ads.Ad{}, nil).
 					Once()
-			},
-			wantErr: true,
-			err:     ErrInternalAdRepoError,
+
+				s.adRepo.
+					On("UpdateAd", mock.Anything, mock.Anything).
+					Return(nil).
+					Once()
+			},
+			want: &ads.Ad{
+				Title:   "new title",
+				Text:    "new text",
+				Updated: time.Now().UTC(),
+			},
+			wantErr: false,
 		},
 		{
-			name: "forbidden request from user",
+			name: "unknown currency",
 			args: args{
-				ctx:    context.Background(),
-				userID: 1,
+				ctx:   context.Background(),
+				title: "new title",
+				text:  "new text",
+				price: &ads.Price{Amount: 100, Currency: "rub"},
 			},
 			setMock: func() {
 				s.userRepo.
 					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, nil).
+					Return(&users.User{}, nil).
 					Once()
 
 				s.adRepo.
@@ -586,137 +754,164 @@ func (s *AppTestSuite) TestAdApp_DeleteAd() {
 					Once()
 			},
 			wantErr: true,
-			err:     ErrForbidden,
+			err:     ErrBadRequest,
 		},
 		{
-			name: "unknown error from adRepo.AdByID func",
+			name: "ok with price",
 			args: args{
-				ctx: context.Background(),
+				ctx:   context.Background(),
+				title: "new title",
+				text:  "new text",
+				price: &ads.Price{Amount: 99900, Currency: "USD"},
 			},
 			setMock: func() {
 				s.userRepo.
 					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, nil).
+					Return(&users.User{}, nil).
 					Once()
 
 				s.adRepo.
 					On("AdByID", mock.Anything, mock.Anything).
-					Return(&ads.Ad{}, nil).
+					Return(&ads.Ad{Price: ads.Price{Amount: 100000, Currency: "USD"}}, nil).
 					Once()
 
 				s.adRepo.
-					On("DeleteAd", mock.Anything, mock.Anything).
-					Return(fmt.Errorf("unknown error from adRepo.AdByID func")).
+					On("UpdateAd", mock.Anything, mock.Anything).
+					Return(nil).
 					Once()
 			},
-			wantErr: true,
-			err:     ErrInternalAdRepoError,
+			want: &ads.Ad{
+				Title:   "new title",
+				Text:    "new text",
+				Price:   ads.Price{Amount: 99900, Currency: "USD"},
+				Updated: time.Now().UTC(),
+			},
+			wantErr: false,
 		},
 		{
-			name: "ok",
+			name: "ok without price keeps price",
 			args: args{
-				ctx: context.Background(),
+				ctx:   context.Background(),
+				title: "new title",
+				text:  "new text",
 			},
 			setMock: func() {
 				s.userRepo.
 					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, nil).
+					Return(&users.User{}, nil).
 					Once()
 
 				s.adRepo.
 					On("AdByID", mock.Anything, mock.Anything).
-					Return(&ads.Ad{}, nil).
+					Return(&ads.Ad{Price: ads.Price{Amount: 100000, Currency: "USD"}}, nil).
 					Once()
 
 				s.adRepo.
-					On("DeleteAd", mock.Anything, mock.Anything).
+					On("UpdateAd", mock.Anything, mock.Anything).
 					Return(nil).
 					Once()
 			},
-			want:    &ads.Ad{},
+			want: &ads.Ad{
+				Title:   "new title",
+				Text:    "new text",
+				Price:   ads.Price{Amount: 100000, Currency: "USD"},
+				Updated: time.Now().UTC(),
+			},
 			wantErr: false,
 		},
-	}
-
-	for _, tt := range tests {
-		s.T().Run(tt.name, func(t *testing.T) {
-			tt.setMock()
-			ad, err := s.app.DeleteAd(tt.args.ctx, tt.args.adId, tt.args.userID)
-			if tt.wantErr {
-				assert.ErrorIs(t, err, tt.err)
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, tt.want.ID, ad.ID)
-				assert.Equal(t, tt.want.Title, ad.Title)
-				assert.Equal(t, tt.want.Text, ad.Text)
-				assert.Equal(t, tt.want.UserID, ad.UserID)
-				assert.InDelta(t, tt.want.Updated.Unix(), ad.Updated.Unix(), 1)
-			}
-		})
-	}
-}
-
-func (s *AppTestSuite) TestAdApp_AdByID() {
-	type args struct {
-		ctx  context.Context
-		adId int64
-	}
-	tests := []struct {
-		name    string
-		args    args
-		setMock func()
-		want    *ads.Ad
-		wantErr bool
-		err     error
-	}{
 		{
-			name: "err no ad",
+			name: "ok clear location",
 			args: args{
-				ctx: context.Background(),
+				ctx:           context.Background(),
+				title:         "new title",
+				text:          "new text",
+				clearLocation: true,
 			},
 			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{}, nil).
+					Once()
+
 				s.adRepo.
 					On("AdByID", mock.Anything, mock.Anything).
-					Return(nil, adrepo.ErrNoAd).
+					Return(&ads.Ad{Location: &ads.Location{Point: ads.Point{Lat: 55.7, Lon: 37.6}, City: "Москва"}}, nil).
+					Once()
+
+				s.adRepo.
+					On("UpdateAd", mock.Anything, mock.MatchedBy(func(ad *ads.Ad) bool {
+						return ad.Location == nil
+					})).
+					Return(nil).
 					Once()
 			},
-			wantErr: true,
-			err:     ErrBadRequest,
+			want: &ads.Ad{
+				Title:   "new title",
+				Text:    "new text",
+				Updated: time.Now().UTC(),
+			},
+			wantErr: false,
 		},
 		{
-			name: "unknown error from adRepo.AdByID func",
+			name: "ok without location keeps location",
 			args: args{
-				ctx: context.Background(),
+				ctx:   context.Background(),
+				title: "new title",
+				text:  "new text",
 			},
 			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{}, nil).
+					Once()
+
 				s.adRepo.
 					On("AdByID", mock.Anything, mock.Anything).
-					Return(nil, fmt.Errorf("unknown error from userRepo.UserByID func")).
+					Return(&ads.Ad{Location: &ads.Location{Point: ads.Point{Lat: 55.7, Lon: 37.6}, City: "Москва"}}, nil).
+					Once()
+
+				s.adRepo.
+					On("UpdateAd", mock.Anything, mock.Anything).
+					Return(nil).
 					Once()
 			},
-			wantErr: true,
-			err:     ErrInternalAdRepoError,
+			want: &ads.Ad{
+				Title:    "new title",
+				Text:     "new text",
+				Location: &ads.Location{Point: ads.Point{Lat: 55.7, Lon: 37.6}, City: "Москва"},
+				Updated:  time.Now().UTC(),
+			},
+			wantErr: false,
 		},
 		{
-			name: "ok",
+			name: "location and clear location",
 			args: args{
-				ctx: context.Background(),
+				ctx:           context.Background(),
+				title:         "new title",
+				text:          "new text",
+				location:      &ads.Location{Point: ads.Point{Lat: 55.7, Lon: 37.6}, City: "Москва"},
+				clearLocation: true,
 			},
 			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{}, nil).
+					Once()
+
 				s.adRepo.
 					On("AdByID", mock.Anything, mock.Anything).
 					Return(&ads.Ad{}, nil).
 					Once()
 			},
-			want:    &ads.Ad{},
-			wantErr: false,
+			wantErr: true,
+			err:     ErrBadRequest,
 		},
 	}
 
 	for _, tt := range tests {
 		s.T().Run(tt.name, func(t *testing.T) {
 			tt.setMock()
-			ad, err := s.app.AdByID(tt.args.ctx, tt.args.adId)
+			ad, err := s.app.UpdateAd(auth.WithUserID(tt.args.ctx, tt.args.userID), tt.args.adId, tt.args.version, tt.args.title, tt.args.text, tt.args.price, tt.args.location, tt.args.clearLocation)
 			if tt.wantErr {
 				assert.ErrorIs(t, err, tt.err)
 			} else {
@@ -725,223 +920,158 @@ func (s *AppTestSuite) TestAdApp_AdByID() {
 				assert.Equal(t, tt.want.Title, ad.Title)
 				assert.Equal(t, tt.want.Text, ad.Text)
 				assert.Equal(t, tt.want.UserID, ad.UserID)
+				assert.Equal(t, tt.want.Price, ad.Price)
+				assert.Equal(t, tt.want.Location, ad.Location)
 				assert.InDelta(t, tt.want.Updated.Unix(), ad.Updated.Unix(), 1)
 			}
 		})
 	}
 }
 
-func (s *AppTestSuite) TestAdApp_AdsByPattern() {
+func (s *AppTestSuite) TestAdApp_ChangeAdStatus() {
 	type args struct {
-		ctx context.Context
-		p   *ads.Pattern
+		ctx       context.Context
+		adId      int64
+		published bool
+		userID    int64
+		version   int64
 	}
 	tests := []struct {
 		name    string
 		args    args
 		setMock func()
-		want    []*ads.Ad
+		want    *ads.Ad
 		wantErr bool
 		err     error
 	}{
 		{
-			name: "err no ad",
+			name: "wrong userID",
 			args: args{
 				ctx: context.Background(),
 			},
 			setMock: func() {
-				s.adRepo.
-					On("AdsByPattern", mock.Anything, mock.Anything).
-					Return(nil, adrepo.ErrNoAd).
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(nil, userrepo.ErrNoUser).
 					Once()
 			},
 			wantErr: true,
-			err:     ErrBadRequest,
+			err:     ErrUnauthorized,
 		},
 		{
-			name: "unknown error from adRepo.AdByID func",
+			name: "unknown error from userRepo.UserByID func",
 			args: args{
 				ctx: context.Background(),
 			},
 			setMock: func() {
-				s.adRepo.
-					On("AdsByPattern", mock.Anything, mock.Anything).
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
 					Return(nil, fmt.Errorf("unknown error from userRepo.UserByID func")).
 					Once()
 			},
 			wantErr: true,
-			err:     ErrInternalAdRepoError,
+			err:     ErrInternalUserRepoError,
 		},
 		{
-			name: "ok",
+			name: "err no ad",
 			args: args{
 				ctx: context.Background(),
-				p:   ads.DefaultPattern(),
 			},
 			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{}, nil).
+					Once()
+
 				s.adRepo.
-					On("AdsByPattern", mock.Anything, mock.Anything).
-					Return([]*ads.Ad{{ID: 0}, {ID: 1}, {ID: 2}}, nil).
+					On("AdByID", mock.Anything, mock.Anything).
+					Return(nil, adrepo.ErrNoAd).
 					Once()
 			},
-			want:    []*ads.Ad{{ID: 0}, {ID: 1}, {ID: 2}},
-			wantErr: false,
+			wantErr: true,
+			err:     ErrBadRequest,
 		},
-	}
-
-	for _, tt := range tests {
-		s.T().Run(tt.name, func(t *testing.T) {
-			tt.setMock()
-			adverts, err := s.app.AdsByPattern(tt.args.ctx, tt.args.p)
-			if tt.wantErr {
-				assert.ErrorIs(t, err, tt.err)
-			} else {
-				assert.NoError(t, err)
-				for i := range adverts {
-					assert.Contains(t, adverts, tt.want[i])
-				}
-			}
-		})
-	}
-}
-
-func (s *AppTestSuite) TestAdApp_CreateUser() {
-	type args struct {
-		ctx   context.Context
-		nick  string
-		email string
-	}
-	tests := []struct {
-		name    string
-		args    args
-		setMock func()
-		want    *users.User
-		wantErr bool
-		err     error
-	}{
 		{
-			name: "validate error",
+			name: "unknown error from adRepo.AdByID func",
 			args: args{
 				ctx: context.Background(),
 			},
-			setMock: func() {},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{}, nil).
+					Once()
+
+				s.adRepo.
+					On("AdByID", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("unknown error from userRepo.UserByID func")).
+					Once()
+			},
 			wantErr: true,
-			err:     ErrBadRequest,
+			err:     ErrInternalAdRepoError,
 		},
 		{
-			name: "err user already exists",
+			name: "forbidden request from user",
 			args: args{
-				ctx:   context.Background(),
-				nick:  "user",
-				email: "user@gmail.com",
+				ctx:    context.Background(),
+				userID: 1,
 			},
 			setMock: func() {
 				s.userRepo.
-					On("AddUser", mock.Anything, mock.Anything).
-					Return(int64(-1), userrepo.ErrUserAlreadyExists).
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{ID: 1}, nil).
+					Once()
+
+				s.adRepo.
+					On("AdByID", mock.Anything, mock.Anything).
+					Return(&ads.Ad{}, nil).
 					Once()
 			},
 			wantErr: true,
-			err:     ErrBadRequest,
+			err:     ErrForbidden,
 		},
 		{
-			name: "unknown error from adRepo.AddUser func",
+			name: "version mismatch",
 			args: args{
-				ctx:   context.Background(),
-				nick:  "user",
-				email: "user@gmail.com",
+				ctx:     context.Background(),
+				version: 2,
 			},
 			setMock: func() {
 				s.userRepo.
-					On("AddUser", mock.Anything, mock.Anything).
-					Return(int64(-1), fmt.Errorf("unknown error from adRepo.AddUser func")).
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{}, nil).
+					Once()
+
+				s.adRepo.
+					On("AdByID", mock.Anything, mock.Anything).
+					Return(&ads.Ad{Version: 1}, nil).
 					Once()
 			},
 			wantErr: true,
-			err:     ErrInternalUserRepoError,
+			err:     ErrConflict,
 		},
 		{
-			name: "ok",
+			name: "publish ad pending moderation",
 			args: args{
-				ctx:   context.Background(),
-				nick:  "user",
-				email: "user@gmail.com",
+				ctx:       context.Background(),
+				published: true,
 			},
 			setMock: func() {
 				s.userRepo.
-					On("AddUser", mock.Anything, mock.Anything).
-					Return(int64(0), nil).
-					Once()
-			},
-			want: &users.User{
-				Nickname: "user",
-				Email:    "user@gmail.com",
-			},
-			wantErr: false,
-		},
-	}
-
-	for _, tt := range tests {
-		s.T().Run(tt.name, func(t *testing.T) {
-			tt.setMock()
-			u, err := s.app.CreateUser(tt.args.ctx, tt.args.nick, tt.args.email)
-			if tt.wantErr {
-				assert.ErrorIs(t, err, tt.err)
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, tt.want.ID, u.ID)
-				assert.Equal(t, tt.want.Nickname, u.Nickname)
-				assert.Equal(t, tt.want.Email, u.Email)
-			}
-		})
-	}
-}
-
-func (s *AppTestSuite) TestAdApp_UpdateUser() {
-	type args struct {
-		ctx   context.Context
-		id    int64
-		nick  string
-		email string
-	}
-	tests := []struct {
-		name    string
-		args    args
-		setMock func()
-		want    *users.User
-		wantErr bool
-		err     error
-	}{
-		{
-			name: "wrong userID",
-			args: args{
-				ctx: context.Background(),
-			},
-			setMock: func() {
-				s.userRepo.
-					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, userrepo.ErrNoUser).
-					Once()
-			},
-			wantErr: true,
-			err:     ErrBadRequest,
-		},
-		{
-			name: "unknown error from userRepo.UserByID func",
-			args: args{
-				ctx: context.Background(),
-			},
-			setMock: func() {
-				s.userRepo.
-					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, fmt.Errorf("unknown error from userRepo.UserByID func")).
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{}, nil).
+					Once()
+
+				s.adRepo.
+					On("AdByID", mock.Anything, mock.Anything).
+					Return(&ads.Ad{Status: ads.StatusPending}, nil).
 					Once()
 			},
 			wantErr: true,
-			err:     ErrInternalUserRepoError,
+			err:     ErrTransition,
 		},
 		{
-			name: "validate error",
+			name: "version conflict from adRepo.UpdateAd func",
 			args: args{
 				ctx: context.Background(),
 			},
@@ -950,26 +1080,45 @@ func (s *AppTestSuite) TestAdApp_UpdateUser() {
 					On("UserByID", mock.Anything, mock.Anything).
 					Return(&users.User{}, nil).
 					Once()
+
+				s.adRepo.
+					On("AdByID", mock.Anything, mock.Anything).
+					Return(&ads.Ad{}, nil).
+					Once()
+
+				s.adRepo.
+					On("UpdateAd", mock.Anything, mock.Anything).
+					Return(adrepo.ErrAdVersionConflict).
+					Once()
 			},
 			wantErr: true,
-			err:     ErrBadRequest,
+			err:     ErrConflict,
 		},
 		{
 			name: "ok",
 			args: args{
-				ctx:   context.Background(),
-				nick:  "new.user",
-				email: "new.user@gmail.com",
+				ctx:       context.Background(),
+				published: true,
 			},
 			setMock: func() {
 				s.userRepo.
 					On("UserByID", mock.Anything, mock.Anything).
 					Return(&users.User{}, nil).
 					Once()
+
+				s.adRepo.
+					On("AdByID", mock.Anything, mock.Anything).
+					Return(&ads.Ad{}, nil).
+					Once()
+
+				s.adRepo.
+					On("UpdateAd", mock.Anything, mock.Anything).
+					Return(nil).
+					Once()
 			},
-			want: &users.User{
-				Nickname: "new.user",
-				Email:    "new.user@gmail.com",
+			want: &ads.Ad{
+				Status:  ads.StatusPublished,
+				Updated: time.Now().UTC(),
 			},
 			wantErr: false,
 		},
@@ -978,102 +1127,361 @@ func (s *AppTestSuite) TestAdApp_UpdateUser() {
 	for _, tt := range tests {
 		s.T().Run(tt.name, func(t *testing.T) {
 			tt.setMock()
-			u, err := s.app.UpdateUser(tt.args.ctx, tt.args.id, tt.args.nick, tt.args.email)
+			ad, err := s.app.ChangeAdStatus(auth.WithUserID(tt.args.ctx, tt.args.userID), tt.args.adId, tt.args.version, tt.args.published)
 			if tt.wantErr {
 				assert.ErrorIs(t, err, tt.err)
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, tt.want.ID, u.ID)
-				assert.Equal(t, tt.want.Nickname, u.Nickname)
-				assert.Equal(t, tt.want.Email, u.Email)
+				assert.Equal(t, tt.want.ID, ad.ID)
+				assert.Equal(t, tt.want.Title, ad.Title)
+				assert.Equal(t, tt.want.Text, ad.Text)
+				assert.Equal(t, tt.want.UserID, ad.UserID)
+				assert.Equal(t, tt.want.Status, ad.Status)
+				assert.InDelta(t, tt.want.Updated.Unix(), ad.Updated.Unix(), 1)
 			}
 		})
 	}
 }
 
-func (s *AppTestSuite) TestAdApp_UserByID() {
-	type args struct {
-		ctx context.Context
-		id  int64
+func (s *AppTestSuite) TestAdApp_TransitionAd() {
+	author := &users.User{ID: 1}
+	moderator := &users.User{ID: 2, Role: users.RoleModerator}
+
+	tests := []struct {
+		name    string
+		actor   *users.User
+		ad      *ads.Ad
+		event   ads.Event
+		reason  string
+		version int64
+		update  bool
+		want    ads.Status
+		err     error
+	}{
+		{name: "author submits draft", actor: author, ad: &ads.Ad{UserID: 1, Status: ads.StatusDraft}, event: ads.EventSubmit, update: true, want: ads.StatusPending},
+		{name: "moderator approves", actor: moderator, ad: &ads.Ad{UserID: 1, Status: ads.StatusPending}, event: ads.EventApprove, update: true, want: ads.StatusPublished},
+		{name: "moderator rejects with reason", actor: moderator, ad: &ads.Ad{UserID: 1, Status: ads.StatusPublished}, event: ads.EventReject, reason: "spam", update: true, want: ads.StatusRejected},
+		{name: "moderator archives foreign ad", actor: moderator, ad: &ads.Ad{UserID: 1, Status: ads.StatusRejected}, event: ads.EventArchive, update: true, want: ads.StatusArchived},
+		{name: "moderator publishes draft", actor: moderator, ad: &ads.Ad{UserID: 1, Status: ads.StatusDraft}, event: ads.EventPublish, update: true, want: ads.StatusPublished},
+		{name: "author approves own ad", actor: author, ad: &ads.Ad{UserID: 1, Status: ads.StatusPending}, event: ads.EventApprove, err: ErrForbidden},
+		{name: "author publishes own draft", actor: author, ad: &ads.Ad{UserID: 1, Status: ads.StatusDraft}, event: ads.EventPublish, err: ErrForbidden},
+		{name: "moderator submits foreign ad", actor: moderator, ad: &ads.Ad{UserID: 1, Status: ads.StatusDraft}, event: ads.EventSubmit, err: ErrForbidden},
+		{name: "approve draft", actor: moderator, ad: &ads.Ad{UserID: 1, Status: ads.StatusDraft}, event: ads.EventApprove, err: ErrTransition},
+		{name: "reject without reason", actor: moderator, ad: &ads.Ad{UserID: 1, Status: ads.StatusPending}, event: ads.EventReject, err: ErrBadRequest},
+		{name: "version mismatch", actor: author, ad: &ads.Ad{UserID: 1, Status: ads.StatusDraft, Version: 1}, event: ads.EventSubmit, version: 2, err: ErrConflict},
+		{name: "unknown event", actor: author, event: "burn", err: ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			s.userRepo.
+				On("UserByID", mock.Anything, tt.actor.ID).
+				Return(tt.actor, nil).
+				Once()
+			if tt.ad != nil {
+				s.adRepo.
+					On("AdByID", mock.Anything, int64(7)).
+					Return(tt.ad, nil).
+					Once()
+			}
+			if tt.update {
+				s.adRepo.
+					On("UpdateAd", mock.Anything, mock.Anything).
+					Return(nil).
+					Once()
+			}
+
+			ad, err := s.app.TransitionAd(auth.WithUserID(context.Background(), tt.actor.ID), 7, tt.version, tt.event, tt.reason)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, ad.Status)
+			assert.Equal(t, tt.event, ad.LastTransition().Event)
+			assert.Equal(t, tt.actor.ID, ad.LastTransition().ActorID)
+			assert.Equal(t, tt.reason, ad.LastTransition().Reason)
+		})
 	}
+}
+
+func (s *AppTestSuite) TestAdApp_PublishWithoutModeration() {
+	adRepo, userRepo := adrepo.New(), userrepo.New()
+	a := NewApp(adRepo, userRepo, s.catRepo, s.favRepo, s.msgRepo, s.auditRepo, s.outbox, s.hookRepo, s.searchRepo, s.feed, s.images, s.issuer, 0, logging.Discard())
+
+	authorID, err := userRepo.AddUser(context.Background(), &users.User{ID: -1, Nickname: "author"})
+	assert.NoError(s.T(), err)
+	moderatorID, err := userRepo.AddUser(context.Background(), &users.User{ID: -1, Nickname: "moderator", Role: users.RoleModerator})
+	assert.NoError(s.T(), err)
+	adID, err := adRepo.AddAd(context.Background(), &ads.Ad{ID: -1, Title: "title", Text: "text", UserID: authorID, Status: ads.StatusDraft})
+	assert.NoError(s.T(), err)
+	author, moderator := auth.WithUserID(context.Background(), authorID), auth.WithUserID(context.Background(), moderatorID)
+
+	// в жизненном цикле черновик без модерации публикует только модератор
+	_, err = a.TransitionAd(author, adID, 0, ads.EventPublish, "")
+	assert.ErrorIs(s.T(), err, ErrForbidden)
+	ad, err := a.TransitionAd(moderator, adID, 0, ads.EventPublish, "")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), ads.StatusPublished, ad.Status)
+
+	// прежний ChangeAdStatus оставляет автору право публиковать своё объявление, но не чужому модератору
+	ad, err = a.ChangeAdStatus(author, adID, 0, false)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), ads.StatusDraft, ad.Status)
+	_, err = a.ChangeAdStatus(moderator, adID, 0, true)
+	assert.ErrorIs(s.T(), err, ErrForbidden)
+	ad, err = a.ChangeAdStatus(author, adID, 0, true)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), ads.StatusPublished, ad.Status)
+}
+
+func (s *AppTestSuite) TestAdApp_RenewAd() {
+	author := &users.User{ID: 1}
+
 	tests := []struct {
 		name    string
-		args    args
+		actor   *users.User
+		ad      *ads.Ad
+		ttl     time.Duration
+		version int64
+		update  bool
+		want    time.Duration
+		err     error
+	}{
+		{name: "renew for default ttl", actor: author, ad: &ads.Ad{UserID: 1}, update: true, want: DefaultAdTTL},
+		{name: "renew for an hour", actor: author, ad: &ads.Ad{UserID: 1, Expires: time.Now().Add(-time.Hour)}, ttl: time.Hour, update: true, want: time.Hour},
+		{name: "negative ttl", actor: author, ttl: -time.Hour, err: ErrBadRequest},
+		{name: "foreign ad", actor: &users.User{ID: 2, Role: users.RoleAdmin}, ad: &ads.Ad{UserID: 1}, err: ErrForbidden},
+		{name: "version mismatch", actor: author, ad: &ads.Ad{UserID: 1, Version: 1}, version: 2, err: ErrConflict},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			s.userRepo.
+				On("UserByID", mock.Anything, tt.actor.ID).
+				Return(tt.actor, nil).
+				Once()
+			if tt.ad != nil {
+				s.adRepo.
+					On("AdByID", mock.Anything, int64(7)).
+					Return(tt.ad, nil).
+					Once()
+			}
+			if tt.update {
+				s.adRepo.
+					On("UpdateAd", mock.Anything, mock.Anything).
+					Return(nil).
+					Once()
+			}
+
+			ad, err := s.app.RenewAd(auth.WithUserID(context.Background(), tt.actor.ID), 7, tt.version, tt.ttl)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.InDelta(t, time.Now().Add(tt.want).Unix(), ad.Expires.Unix(), 1)
+		})
+	}
+}
+
+func (s *AppTestSuite) TestAdApp_ExpireAds() {
+	now := time.Now().UTC()
+	expired := []*ads.Ad{
+		{ID: 1, Status: ads.StatusPublished, Expires: now.Add(-time.Hour)},
+		{ID: 2, Status: ads.StatusPending, Expires: now},
+		{ID: 3, Status: ads.StatusDraft, Expires: now.Add(-time.Minute)},
+	}
+
+	var pattern *ads.Pattern
+	s.adRepo.
+		On("AdsByPattern", mock.Anything, mock.Anything, ads.Page{Limit: ads.MaxLimit}).
+		Return(expired[:2], "next", nil).
+		Run(func(args mock.Arguments) { pattern = args.Get(1).(*ads.Pattern) }).
+		Once()
+	s.adRepo.
+		On("AdsByPattern", mock.Anything, mock.Anything, ads.Page{Limit: ads.MaxLimit, Cursor: "next"}).
+		Return(expired[2:], "", nil).
+		Once()
+	s.adRepo.
+		On("UpdateAd", mock.Anything, expired[0]).
+		Return(nil).
+		Once()
+	// объявление изменили одновременно с janitor - оно будет убрано в следующий раз
+	s.adRepo.
+		On("UpdateAd", mock.Anything, expired[1]).
+		Return(adrepo.ErrAdVersionConflict).
+		Once()
+	s.adRepo.
+		On("UpdateAd", mock.Anything, expired[2]).
+		Return(nil).
+		Once()
+
+	n, err := s.app.ExpireAds(context.Background(), now)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), 2, n)
+	assert.Equal(s.T(), ads.StatusArchived, expired[0].Status)
+	assert.Equal(s.T(), ads.EventExpire, expired[0].LastTransition().Event)
+	assert.Equal(s.T(), ads.NoActor, expired[0].LastTransition().ActorID)
+
+	// под шаблон не попадают бессрочные, ещё не истёкшие и уже архивные объявления
+	assert.True(s.T(), pattern.Fits(&ads.Ad{Status: ads.StatusPublished, Expires: now}))
+	assert.False(s.T(), pattern.Fits(&ads.Ad{Status: ads.StatusPublished}))
+	assert.False(s.T(), pattern.Fits(&ads.Ad{Status: ads.StatusPublished, Expires: now.Add(time.Second)}))
+	assert.False(s.T(), pattern.Fits(&ads.Ad{Status: ads.StatusArchived, Expires: now.Add(-time.Hour)}))
+
+	s.adRepo.
+		On("AdsByPattern", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, "", fmt.Errorf("repo is down")).
+		Once()
+	_, err = s.app.ExpireAds(context.Background(), now)
+	assert.ErrorIs(s.T(), err, ErrInternalAdRepoError)
+}
+
+func testPNG(w, h int) []byte {
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h)))
+	return buf.Bytes()
+}
+
+func (s *AppTestSuite) TestAdApp_AddAdImage() {
+	author := &users.User{ID: 1}
+	photo := testPNG(640, 480)
+	key := images.Key(photo)
+
+	full := &ads.Ad{UserID: 1}
+	for i := 0; i < MaxAdImages; i++ {
+		full.Images = append(full.Images, ads.Image{ID: fmt.Sprint(i)})
+	}
+
+	tests := []struct {
+		name    string
+		actor   *users.User
+		ad      *ads.Ad
+		data    []byte
 		setMock func()
-		want    *users.User
-		wantErr bool
+		want    int
 		err     error
 	}{
 		{
-			name: "wrong userID",
-			args: args{
-				ctx: context.Background(),
-			},
+			name:  "ok",
+			actor: author,
+			ad:    &ads.Ad{UserID: 1},
+			data:  photo,
 			setMock: func() {
-				s.userRepo.
-					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, userrepo.ErrNoUser).
-					Once()
+				s.images.On("Put", mock.Anything, photo).Return(key, nil).Once()
+				s.images.On("Put", mock.Anything, mock.Anything).Return("thumb", nil).Once()
+				s.adRepo.On("UpdateAd", mock.Anything, mock.Anything).Return(nil).Once()
 			},
-			wantErr: true,
-			err:     ErrBadRequest,
+			want: 1,
 		},
 		{
-			name: "unknown error from userRepo.UserByID func",
-			args: args{
-				ctx: context.Background(),
-			},
+			name:  "same photo again",
+			actor: author,
+			ad:    &ads.Ad{UserID: 1, Images: []ads.Image{{ID: key, Thumb: "thumb", ContentType: "image/png", Width: 640, Height: 480}}},
+			data:  photo,
+			want:  1,
+		},
+		{
+			name:  "version conflict releases files",
+			actor: author,
+			ad:    &ads.Ad{UserID: 1},
+			data:  photo,
 			setMock: func() {
-				s.userRepo.
-					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, fmt.Errorf("unknown error from userRepo.UserByID func")).
-					Once()
+				s.images.On("Put", mock.Anything, photo).Return(key, nil).Once()
+				s.images.On("Put", mock.Anything, mock.Anything).Return("thumb", nil).Once()
+				s.adRepo.On("UpdateAd", mock.Anything, mock.Anything).Return(adrepo.ErrAdVersionConflict).Once()
+				s.images.On("Release", mock.Anything, key).Return(nil).Once()
+				s.images.On("Release", mock.Anything, "thumb").Return(nil).Once()
 			},
-			wantErr: true,
-			err:     ErrInternalUserRepoError,
+			err: ErrConflict,
 		},
 		{
-			name: "ok",
-			args: args{
-				ctx: context.Background(),
-			},
+			name:  "store error",
+			actor: author,
+			ad:    &ads.Ad{UserID: 1},
+			data:  photo,
 			setMock: func() {
-				s.userRepo.
-					On("UserByID", mock.Anything, mock.Anything).
-					Return(&users.User{}, nil).
-					Once()
+				s.images.On("Put", mock.Anything, photo).Return("", fmt.Errorf("disk is full")).Once()
 			},
-			want:    &users.User{},
-			wantErr: false,
+			err: ErrInternalImageError,
 		},
+		{name: "foreign ad", actor: &users.User{ID: 2, Role: users.RoleModerator}, ad: &ads.Ad{UserID: 1}, data: photo, err: ErrForbidden},
+		{name: "too many images", actor: author, ad: full, data: photo, err: ErrBadRequest},
+		{name: "not an image", actor: author, ad: &ads.Ad{UserID: 1}, data: []byte("hello"), err: ErrBadRequest},
+		{name: "too large image", actor: author, ad: &ads.Ad{UserID: 1}, data: make([]byte, images.MaxSize+1), err: ErrTooLarge},
 	}
 
 	for _, tt := range tests {
 		s.T().Run(tt.name, func(t *testing.T) {
-			tt.setMock()
-			u, err := s.app.UserByID(tt.args.ctx, tt.args.id)
-			if tt.wantErr {
+			s.userRepo.On("UserByID", mock.Anything, tt.actor.ID).Return(tt.actor, nil).Once()
+			s.adRepo.On("AdByID", mock.Anything, int64(7)).Return(tt.ad, nil).Once()
+			if tt.setMock != nil {
+				tt.setMock()
+			}
+
+			ad, err := s.app.AddAdImage(auth.WithUserID(context.Background(), tt.actor.ID), 7, 0, tt.data)
+			if tt.err != nil {
 				assert.ErrorIs(t, err, tt.err)
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, tt.want.ID, u.ID)
-				assert.Equal(t, tt.want.Nickname, u.Nickname)
-				assert.Equal(t, tt.want.Email, u.Email)
+				return
+			}
+			assert.NoError(t, err)
+			if assert.Len(t, ad.Images, tt.want) {
+				assert.Equal(t, ads.Image{ID: key, Thumb: "thumb", ContentType: "image/png", Width: 640, Height: 480}, ad.Images[0])
 			}
 		})
 	}
 }
 
-func (s *AppTestSuite) TestAdApp_DeleteUser() {
+func (s *AppTestSuite) TestAdApp_DeleteAdImage() {
+	author := &users.User{ID: 1}
+	ad := func() *ads.Ad {
+		return &ads.Ad{UserID: 1, Images: []ads.Image{{ID: "a", Thumb: "ta"}, {ID: "b", Thumb: "tb"}, {ID: "c", Thumb: "tc"}}}
+	}
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(author, nil).Once()
+	s.adRepo.On("AdByID", mock.Anything, int64(7)).Return(ad(), nil).Once()
+	_, err := s.app.DeleteAdImage(auth.WithUserID(context.Background(), 1), 7, 0, "x")
+	assert.ErrorIs(s.T(), err, ErrBadRequest)
+
+	stored := ad()
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(author, nil).Once()
+	s.adRepo.On("AdByID", mock.Anything, int64(7)).Return(stored, nil).Once()
+	s.adRepo.On("UpdateAd", mock.Anything, mock.Anything).Return(nil).Once()
+	s.images.On("Release", mock.Anything, "b").Return(nil).Once()
+	s.images.On("Release", mock.Anything, "tb").Return(nil).Once()
+
+	res, err := s.app.DeleteAdImage(auth.WithUserID(context.Background(), 1), 7, 0, "b")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []ads.Image{{ID: "a", Thumb: "ta"}, {ID: "c", Thumb: "tc"}}, res.Images)
+}
+
+func (s *AppTestSuite) TestAdApp_Image() {
+	s.images.On("Get", mock.Anything, "a").Return([]byte("photo"), nil).Once()
+	data, err := s.app.Image(context.Background(), "a")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []byte("photo"), data)
+
+	s.images.On("Get", mock.Anything, "b").Return(nil, blobstore.ErrNoBlob).Once()
+	_, err = s.app.Image(context.Background(), "b")
+	assert.ErrorIs(s.T(), err, ErrBadRequest)
+
+	s.images.On("Get", mock.Anything, "c").Return(nil, fmt.Errorf("disk is broken")).Once()
+	_, err = s.app.Image(context.Background(), "c")
+	assert.ErrorIs(s.T(), err, ErrInternalImageError)
+}
+
+func (s *AppTestSuite) TestAdApp_DeleteAd() {
 	type args struct {
-		ctx context.Context
-		id  int64
+		ctx    context.Context
+		adId   int64
+		userID int64
 	}
 	tests := []struct {
 		name    string
 		args    args
 		setMock func()
-		want    *users.User
+		want    *ads.Ad
 		wantErr bool
 		err     error
 	}{
@@ -1089,7 +1497,7 @@ func (s *AppTestSuite) TestAdApp_DeleteUser() {
 					Once()
 			},
 			wantErr: true,
-			err:     ErrBadRequest,
+			err:     ErrUnauthorized,
 		},
 		{
 			name: "unknown error from userRepo.UserByID func",
@@ -1106,7 +1514,7 @@ func (s *AppTestSuite) TestAdApp_DeleteUser() {
 			err:     ErrInternalUserRepoError,
 		},
 		{
-			name: "unknown error from userRepo.DeleteUser func",
+			name: "err no ad",
 			args: args{
 				ctx: context.Background(),
 			},
@@ -1116,16 +1524,16 @@ func (s *AppTestSuite) TestAdApp_DeleteUser() {
 					Return(&users.User{}, nil).
 					Once()
 
-				s.userRepo.
-					On("DeleteUser", mock.Anything, mock.Anything).
-					Return(fmt.Errorf("unknown error from userRepo.DeleteUser func")).
+				s.adRepo.
+					On("AdByID", mock.Anything, mock.Anything).
+					Return(nil, adrepo.ErrNoAd).
 					Once()
 			},
 			wantErr: true,
-			err:     ErrInternalUserRepoError,
+			err:     ErrBadRequest,
 		},
 		{
-			name: "ok",
+			name: "unknown error from adRepo.AdByID func",
 			args: args{
 				ctx: context.Background(),
 			},
@@ -1135,32 +1543,2555 @@ func (s *AppTestSuite) TestAdApp_DeleteUser() {
 					Return(&users.User{}, nil).
 					Once()
 
-				s.userRepo.
-					On("DeleteUser", mock.Anything, mock.Anything).
-					Return(nil).
+				s.adRepo.
+					On("AdByID", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("unknown error from userRepo.UserByID func")).
 					Once()
 			},
-			want:    &users.User{},
-			wantErr: false,
+			wantErr: true,
+			err:     ErrInternalAdRepoError,
 		},
-	}
-
-	for _, tt := range tests {
-		s.T().Run(tt.name, func(t *testing.T) {
-			tt.setMock()
-			u, err := s.app.DeleteUser(tt.args.ctx, tt.args.id)
-			if tt.wantErr {
-				assert.ErrorIs(t, err, tt.err)
+		{
+			name: "forbidden request from user",
+			args: args{
+				ctx:    context.Background(),
+				userID: 1,
+			},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{ID: 1}, nil).
+					Once()
+
+				s.adRepo.
+					On("AdByID", mock.Anything, mock.Anything).
+					Return(&ads.Ad{}, nil).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrForbidden,
+		},
+		{
+			name: "unknown error from adRepo.AdByID func",
+			args: args{
+				ctx: context.Background(),
+			},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{}, nil).
+					Once()
+
+				s.adRepo.
+					On("AdByID", mock.Anything, mock.Anything).
+					Return(&ads.Ad{}, nil).
+					Once()
+
+				s.favRepo.
+					On("DeleteByAd", mock.Anything, mock.Anything).
+					Return(nil).
+					Once()
+
+				s.adRepo.
+					On("DeleteAd", mock.Anything, mock.Anything).
+					Return(fmt.Errorf("unknown error from adRepo.AdByID func")).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrInternalAdRepoError,
+		},
+		{
+			name: "unknown error from favRepo.DeleteByAd func",
+			args: args{
+				ctx: context.Background(),
+			},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{}, nil).
+					Once()
+
+				s.adRepo.
+					On("AdByID", mock.Anything, mock.Anything).
+					Return(&ads.Ad{}, nil).
+					Once()
+
+				s.favRepo.
+					On("DeleteByAd", mock.Anything, mock.Anything).
+					Return(fmt.Errorf("unknown error from favRepo.DeleteByAd func")).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrInternalFavRepoError,
+		},
+		{
+			name: "ok",
+			args: args{
+				ctx: context.Background(),
+			},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{}, nil).
+					Once()
+
+				s.adRepo.
+					On("AdByID", mock.Anything, mock.Anything).
+					Return(&ads.Ad{}, nil).
+					Once()
+
+				s.favRepo.
+					On("DeleteByAd", mock.Anything, mock.Anything).
+					Return(nil).
+					Once()
+
+				s.adRepo.
+					On("DeleteAd", mock.Anything, mock.Anything).
+					Return(nil).
+					Once()
+			},
+			want:    &ads.Ad{},
+			wantErr: false,
+		},
+		{
+			name: "ok with images",
+			args: args{
+				ctx: context.Background(),
+			},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{}, nil).
+					Once()
+
+				s.adRepo.
+					On("AdByID", mock.Anything, mock.Anything).
+					Return(&ads.Ad{Images: []ads.Image{{ID: "photo", Thumb: "thumb"}}}, nil).
+					Once()
+
+				s.favRepo.
+					On("DeleteByAd", mock.Anything, mock.Anything).
+					Return(nil).
+					Once()
+
+				s.adRepo.
+					On("DeleteAd", mock.Anything, mock.Anything).
+					Return(nil).
+					Once()
+
+				s.images.
+					On("Release", mock.Anything, "photo").
+					Return(nil).
+					Once()
+
+				// ошибка освобождения файла не отменяет удаление объявления
+				s.images.
+					On("Release", mock.Anything, "thumb").
+					Return(fmt.Errorf("disk is broken")).
+					Once()
+			},
+			want:    &ads.Ad{},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.setMock()
+			ad, err := s.app.DeleteAd(auth.WithUserID(tt.args.ctx, tt.args.userID), tt.args.adId)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want.ID, ad.ID)
+				assert.Equal(t, tt.want.Title, ad.Title)
+				assert.Equal(t, tt.want.Text, ad.Text)
+				assert.Equal(t, tt.want.UserID, ad.UserID)
+				assert.InDelta(t, tt.want.Updated.Unix(), ad.Updated.Unix(), 1)
+			}
+		})
+	}
+}
+
+func (s *AppTestSuite) TestAdApp_AdByID() {
+	type args struct {
+		ctx  context.Context
+		adId int64
+	}
+	tests := []struct {
+		name    string
+		args    args
+		setMock func()
+		want    *ads.Ad
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "err no ad",
+			args: args{
+				ctx: context.Background(),
+			},
+			setMock: func() {
+				s.adRepo.
+					On("AdByID", mock.Anything, mock.Anything).
+					Return(nil, adrepo.ErrNoAd).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrBadRequest,
+		},
+		{
+			name: "unknown error from adRepo.AdByID func",
+			args: args{
+				ctx: context.Background(),
+			},
+			setMock: func() {
+				s.adRepo.
+					On("AdByID", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("unknown error from userRepo.UserByID func")).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrInternalAdRepoError,
+		},
+		{
+			name: "ok",
+			args: args{
+				ctx: context.Background(),
+			},
+			setMock: func() {
+				s.adRepo.
+					On("AdByID", mock.Anything, mock.Anything).
+					Return(&ads.Ad{}, nil).
+					Once()
+				s.favRepo.
+					On("CountByAds", mock.Anything, []int64{0}).
+					Return(map[int64]int{0: 3}, nil).
+					Once()
+			},
+			want:    &ads.Ad{Favorites: 3},
+			wantErr: false,
+		},
+		{
+			name: "unknown error from favRepo.CountByAds func",
+			args: args{
+				ctx: context.Background(),
+			},
+			setMock: func() {
+				s.adRepo.
+					On("AdByID", mock.Anything, mock.Anything).
+					Return(&ads.Ad{}, nil).
+					Once()
+				s.favRepo.
+					On("CountByAds", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("unknown error from favRepo.CountByAds func")).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrInternalFavRepoError,
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.setMock()
+			ad, err := s.app.AdByID(tt.args.ctx, tt.args.adId)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want.ID, ad.ID)
+				assert.Equal(t, tt.want.Favorites, ad.Favorites)
+				assert.Equal(t, tt.want.Title, ad.Title)
+				assert.Equal(t, tt.want.Text, ad.Text)
+				assert.Equal(t, tt.want.UserID, ad.UserID)
+				assert.InDelta(t, tt.want.Updated.Unix(), ad.Updated.Unix(), 1)
+			}
+		})
+	}
+}
+
+func (s *AppTestSuite) TestAdApp_AdsByPattern() {
+	type args struct {
+		ctx context.Context
+		p   *ads.Pattern
+	}
+	tests := []struct {
+		name    string
+		args    args
+		setMock func()
+		want    []*ads.Ad
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "err no ad",
+			args: args{
+				ctx: context.Background(),
+			},
+			setMock: func() {
+				s.adRepo.
+					On("AdsByPattern", mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, "", adrepo.ErrNoAd).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrBadRequest,
+		},
+		{
+			name: "unknown error from adRepo.AdByID func",
+			args: args{
+				ctx: context.Background(),
+			},
+			setMock: func() {
+				s.adRepo.
+					On("AdsByPattern", mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, "", fmt.Errorf("unknown error from userRepo.UserByID func")).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrInternalAdRepoError,
+		},
+		{
+			name: "ok",
+			args: args{
+				ctx: context.Background(),
+				p:   ads.DefaultPattern(),
+			},
+			setMock: func() {
+				s.adRepo.
+					On("AdsByPattern", mock.Anything, mock.Anything, mock.Anything).
+					Return([]*ads.Ad{{ID: 0}, {ID: 1}, {ID: 2}}, "", nil).
+					Once()
+				s.favRepo.
+					On("CountByAds", mock.Anything, []int64{0, 1, 2}).
+					Return(map[int64]int{1: 2}, nil).
+					Once()
+			},
+			want:    []*ads.Ad{{ID: 0}, {ID: 1, Favorites: 2}, {ID: 2}},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.setMock()
+			adverts, _, err := s.app.AdsByPattern(tt.args.ctx, tt.args.p, ads.DefaultPage())
+			if tt.wantErr {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+				for i := range adverts {
+					assert.Contains(t, adverts, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// testCategories: Электроника > {Телефоны > Смартфоны, Ноутбуки}; Одежда
+func testCategories() []*categories.Category {
+	return []*categories.Category{
+		{ID: 1, Name: "Электроника", Version: 1},
+		{ID: 2, ParentID: 1, Name: "Телефоны", Version: 1},
+		{ID: 3, ParentID: 1, Name: "Ноутбуки", Version: 1},
+		{ID: 4, Name: "Одежда", Version: 1},
+		{ID: 5, ParentID: 2, Name: "Смартфоны", Version: 1},
+	}
+}
+
+func (s *AppTestSuite) TestAdApp_AdsByPattern_Category() {
+	s.catRepo.On("Categories", mock.Anything).Return(testCategories(), nil).Once()
+	s.adRepo.
+		On("AdsByPattern", mock.Anything, mock.MatchedBy(func(p *ads.Pattern) bool {
+			return p.Category == 0 && p.String() == "category_id in (2, 5)"
+		}), mock.Anything).
+		Return([]*ads.Ad{{ID: 1, CategoryID: 5}}, "", nil).
+		Once()
+	s.favRepo.On("CountByAds", mock.Anything, []int64{1}).Return(map[int64]int{}, nil).Once()
+
+	adverts, _, err := s.app.AdsByPattern(context.Background(), ads.DefaultPattern().SetCategory(2), ads.DefaultPage())
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), adverts, 1)
+
+	s.catRepo.On("Categories", mock.Anything).Return(testCategories(), nil).Once()
+	_, _, err = s.app.AdsByPattern(context.Background(), ads.DefaultPattern().SetCategory(100), ads.DefaultPage())
+	assert.ErrorIs(s.T(), err, ErrBadRequest)
+}
+
+func (s *AppTestSuite) TestAdApp_WatchAds() {
+	ctx := auth.WithUserID(context.Background(), 1)
+	owner := &users.User{ID: 1}
+
+	s.catRepo.On("Categories", mock.Anything).Return(testCategories(), nil).Once()
+	sub, err := s.app.WatchAds(context.Background(), ads.DefaultPattern().SetQuery("смартфоны").SetCategory(2))
+	assert.NoError(s.T(), err)
+	defer sub.Close()
+
+	// черновики в ленту не попадают, даже подходящие под шаблон
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(owner, nil).Once()
+	s.catRepo.On("CategoryByID", mock.Anything, int64(5)).Return(&categories.Category{ID: 5}, nil).Once()
+	s.adRepo.On("AddAd", mock.Anything, mock.Anything).Return(int64(7), nil).Once()
+	_, err = s.app.CreateAd(ctx, "Смартфон", "text", 5, ads.Price{}, nil, 0)
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), sub.Events())
+
+	changeStatus := func(title string, category int64, status ads.Status, published bool) {
+		s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(owner, nil).Once()
+		s.adRepo.On("AdByID", mock.Anything, int64(7)).Return(&ads.Ad{ID: 7, Title: title, UserID: 1, CategoryID: category, Status: status}, nil).Once()
+		s.adRepo.On("UpdateAd", mock.Anything, mock.Anything).Return(nil).Once()
+		_, err := s.app.ChangeAdStatus(ctx, 7, 0, published)
+		assert.NoError(s.T(), err)
+	}
+	// не подходит по запросу, по категории и, наконец, подходит: подкатегории тоже отслеживаются
+	changeStatus("Ноутбук", 5, ads.StatusDraft, true)
+	changeStatus("Смартфон", 3, ads.StatusDraft, true)
+	changeStatus("Смартфон", 5, ads.StatusDraft, true)
+
+	select {
+	case e := <-sub.Events():
+		assert.Equal(s.T(), events.AdPublished, e.Type)
+		assert.Equal(s.T(), int64(5), e.Ad.CategoryID)
+		assert.Equal(s.T(), "Смартфон", e.Ad.Title)
+	default:
+		s.T().Error("no event for a matching ad")
+	}
+	assert.Empty(s.T(), sub.Events())
+
+	// снятие с публикации в ленту попадает: объявление было опубликовано до события
+	changeStatus("Смартфон", 5, ads.StatusPublished, false)
+	select {
+	case e := <-sub.Events():
+		assert.Equal(s.T(), events.AdStatusChanged, e.Type)
+		assert.Equal(s.T(), ads.StatusDraft, e.Ad.Status)
+	default:
+		s.T().Error("no event for an unpublished ad")
+	}
+	assert.Empty(s.T(), sub.Events())
+
+	s.catRepo.On("Categories", mock.Anything).Return(testCategories(), nil).Once()
+	_, err = s.app.WatchAds(context.Background(), ads.DefaultPattern().SetCategory(100))
+	assert.ErrorIs(s.T(), err, ErrBadRequest)
+}
+
+func (s *AppTestSuite) TestAdApp_WatchAds_Closed() {
+	hub := feed.NewHub(0)
+	a := NewApp(s.adRepo, s.userRepo, s.catRepo, s.favRepo, s.msgRepo, s.auditRepo, s.outbox, s.hookRepo, s.searchRepo, hub, s.images, s.issuer, 0, logging.Discard())
+	hub.Close()
+
+	_, err := a.WatchAds(context.Background(), nil)
+	assert.ErrorIs(s.T(), err, ErrUnavailable)
+}
+
+func (s *AppTestSuite) TestAdApp_AdsByPattern_Distance() {
+	page := ads.Page{Sort: ads.Sort{Field: ads.SortByDistance}}
+
+	// без области поиска расстояние не определено
+	_, _, err := s.app.AdsByPattern(context.Background(), ads.DefaultPattern(), page)
+	assert.ErrorIs(s.T(), err, ErrBadRequest)
+
+	near, err := ads.NewCircle(55.75, 37.62, 10)
+	assert.NoError(s.T(), err)
+	s.adRepo.
+		On("AdsByPattern", mock.Anything, mock.MatchedBy(func(p *ads.Pattern) bool {
+			return p.Near == near
+		}), page).
+		Return([]*ads.Ad{{ID: 3}}, "", nil).
+		Once()
+	s.favRepo.On("CountByAds", mock.Anything, []int64{3}).Return(map[int64]int{}, nil).Once()
+
+	adverts, _, err := s.app.AdsByPattern(context.Background(), ads.DefaultPattern().SetNear(near), page)
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), adverts, 1)
+}
+
+func (s *AppTestSuite) TestAdApp_CategoryCounts() {
+	s.catRepo.On("Categories", mock.Anything).Return(testCategories(), nil).Once()
+	s.adRepo.
+		On("CountByCategory", mock.Anything, mock.MatchedBy(func(p *ads.Pattern) bool {
+			return p.String() == "category_id in (1, 3, 2, 5)"
+		})).
+		Return(map[int64]int{2: 1, 3: 2, 5: 4}, nil).
+		Once()
+
+	counts, err := s.app.CategoryCounts(context.Background(), ads.DefaultPattern().SetCategory(1))
+	assert.NoError(s.T(), err)
+	if assert.Len(s.T(), counts, 4) {
+		assert.Equal(s.T(), []string{"Электроника"}, counts[0].Path)
+		assert.Equal(s.T(), 7, counts[0].Ads)
+		assert.Equal(s.T(), []string{"Электроника", "Ноутбуки"}, counts[1].Path)
+		assert.Equal(s.T(), 2, counts[1].Ads)
+		assert.Equal(s.T(), []string{"Электроника", "Телефоны"}, counts[2].Path)
+		assert.Equal(s.T(), 5, counts[2].Ads)
+		assert.Equal(s.T(), []string{"Электроника", "Телефоны", "Смартфоны"}, counts[3].Path)
+		assert.Equal(s.T(), 4, counts[3].Ads)
+	}
+}
+
+func (s *AppTestSuite) TestAdApp_CreateCategory() {
+	admin := &users.User{ID: 1, Role: users.RoleAdmin}
+
+	tests := []struct {
+		name     string
+		category string
+		parentID int64
+		setMock  func()
+		want     *categories.Category
+		err      error
+	}{
+		{
+			name:     "not an admin",
+			category: "Планшеты",
+			parentID: 1,
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+			},
+			err: ErrForbidden,
+		},
+		{
+			name:     "unknown parent",
+			category: "Планшеты",
+			parentID: 100,
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(admin, nil).Once()
+				s.catRepo.On("Categories", mock.Anything).Return(testCategories(), nil).Once()
+			},
+			err: ErrBadRequest,
+		},
+		{
+			name:     "same name in parent",
+			category: "ноутбуки",
+			parentID: 1,
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(admin, nil).Once()
+				s.catRepo.On("Categories", mock.Anything).Return(testCategories(), nil).Once()
+			},
+			err: ErrBadRequest,
+		},
+		{
+			name:     "empty name",
+			category: "",
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(admin, nil).Once()
+				s.catRepo.On("Categories", mock.Anything).Return(testCategories(), nil).Once()
+			},
+			err: ErrBadRequest,
+		},
+		{
+			name:     "ok",
+			category: "Планшеты",
+			parentID: 1,
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(admin, nil).Once()
+				s.catRepo.On("Categories", mock.Anything).Return(testCategories(), nil).Once()
+				s.catRepo.On("AddCategory", mock.Anything, mock.Anything).Return(int64(6), nil).Once()
+			},
+			want: &categories.Category{ID: 6, ParentID: 1, Name: "Планшеты"},
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.setMock()
+			c, err := s.app.CreateCategory(auth.WithUserID(context.Background(), 1), tt.category, tt.parentID)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, c)
+			}
+		})
+	}
+}
+
+func (s *AppTestSuite) TestAdApp_UpdateCategory() {
+	admin := &users.User{ID: 1, Role: users.RoleAdmin}
+
+	tests := []struct {
+		name     string
+		ID       int64
+		version  int64
+		category string
+		parentID int64
+		setMock  func()
+		want     *categories.Category
+		err      error
+	}{
+		{
+			name:     "move into own subcategory",
+			ID:       1,
+			category: "Электроника",
+			parentID: 5,
+			setMock: func() {
+				s.catRepo.On("Categories", mock.Anything).Return(testCategories(), nil).Once()
+			},
+			err: ErrBadRequest,
+		},
+		{
+			name:     "move into itself",
+			ID:       2,
+			category: "Телефоны",
+			parentID: 2,
+			setMock: func() {
+				s.catRepo.On("Categories", mock.Anything).Return(testCategories(), nil).Once()
+			},
+			err: ErrBadRequest,
+		},
+		{
+			name:     "unknown category",
+			ID:       100,
+			category: "Книги",
+			setMock: func() {
+				s.catRepo.On("Categories", mock.Anything).Return(testCategories(), nil).Once()
+			},
+			err: ErrBadRequest,
+		},
+		{
+			name:     "stale version",
+			ID:       3,
+			version:  2,
+			category: "Компьютеры",
+			parentID: 1,
+			setMock: func() {
+				s.catRepo.On("Categories", mock.Anything).Return(testCategories(), nil).Once()
+			},
+			err: ErrConflict,
+		},
+		{
+			name:     "ok move to top level",
+			ID:       2,
+			version:  1,
+			category: "Телефоны",
+			setMock: func() {
+				s.catRepo.On("Categories", mock.Anything).Return(testCategories(), nil).Once()
+				s.catRepo.
+					On("UpdateCategory", mock.Anything, &categories.Category{ID: 2, Name: "Телефоны", Version: 1}).
+					Return(nil).
+					Once()
+			},
+			want: &categories.Category{ID: 2, Name: "Телефоны", Version: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(admin, nil).Once()
+			tt.setMock()
+			c, err := s.app.UpdateCategory(auth.WithUserID(context.Background(), 1), tt.ID, tt.version, tt.category, tt.parentID)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, c)
+			}
+		})
+	}
+}
+
+func (s *AppTestSuite) TestAdApp_DeleteCategory() {
+	admin := &users.User{ID: 1, Role: users.RoleAdmin}
+	ctx := auth.WithUserID(context.Background(), 1)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(admin, nil).Times(3)
+	s.catRepo.On("Categories", mock.Anything).Return(testCategories(), nil).Times(3)
+
+	_, err := s.app.DeleteCategory(ctx, 2)
+	assert.ErrorIs(s.T(), err, ErrBadRequest, "category with subcategories")
+
+	s.adRepo.On("AdsByPattern", mock.Anything, mock.Anything, mock.Anything).Return([]*ads.Ad{{ID: 7, CategoryID: 3}}, "", nil).Once()
+	_, err = s.app.DeleteCategory(ctx, 3)
+	assert.ErrorIs(s.T(), err, ErrBadRequest, "category with ads")
+
+	s.adRepo.On("AdsByPattern", mock.Anything, mock.Anything, mock.Anything).Return(nil, "", nil).Once()
+	s.catRepo.On("DeleteCategory", mock.Anything, int64(4)).Return(nil).Once()
+	c, err := s.app.DeleteCategory(ctx, 4)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "Одежда", c.Name)
+}
+
+func (s *AppTestSuite) TestAdApp_Favorites() {
+	ctx := auth.WithUserID(context.Background(), 1)
+	added := time.Now().UTC()
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+	_, err := s.app.Favorites(ctx, 2)
+	assert.ErrorIs(s.T(), err, ErrForbidden, "foreign favorites")
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+	s.favRepo.
+		On("Favorites", mock.Anything, int64(1)).
+		Return([]*favorites.Favorite{
+			{UserID: 1, AdID: 5, Added: added},
+			{UserID: 1, AdID: 6, Added: added.Add(-time.Minute)},
+			{UserID: 1, AdID: 3, Added: added.Add(-time.Hour)},
+		}, nil).
+		Once()
+	s.adRepo.On("AdByID", mock.Anything, int64(5)).Return(&ads.Ad{ID: 5}, nil).Once()
+	// удалённое одновременно с запросом объявление пропускается
+	s.adRepo.On("AdByID", mock.Anything, int64(6)).Return(nil, adrepo.ErrNoAd).Once()
+	s.adRepo.On("AdByID", mock.Anything, int64(3)).Return(&ads.Ad{ID: 3}, nil).Once()
+	s.favRepo.On("CountByAds", mock.Anything, []int64{5, 3}).Return(map[int64]int{5: 1, 3: 4}, nil).Once()
+
+	adverts, err := s.app.Favorites(ctx, 1)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), []*ads.Ad{{ID: 5, Favorites: 1}, {ID: 3, Favorites: 4}}, adverts)
+}
+
+func (s *AppTestSuite) TestAdApp_AddFavorite() {
+	tests := []struct {
+		name    string
+		userID  int64
+		adID    int64
+		setMock func()
+		want    *ads.Ad
+		err     error
+	}{
+		{
+			name:   "foreign favorites",
+			userID: 2,
+			adID:   5,
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1, Role: users.RoleAdmin}, nil).Once()
+			},
+			err: ErrForbidden,
+		},
+		{
+			name:   "unknown ad",
+			userID: 1,
+			adID:   5,
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+				s.adRepo.On("AdByID", mock.Anything, int64(5)).Return(nil, adrepo.ErrNoAd).Once()
+			},
+			err: ErrBadRequest,
+		},
+		{
+			name:   "too many favorites",
+			userID: 1,
+			adID:   5,
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+				s.adRepo.On("AdByID", mock.Anything, int64(5)).Return(&ads.Ad{ID: 5}, nil).Once()
+				s.favRepo.On("Favorites", mock.Anything, int64(1)).Return(make([]*favorites.Favorite, MaxFavorites), nil).Once()
+			},
+			err: ErrBadRequest,
+		},
+		{
+			name:   "unknown error from favRepo.AddFavorite func",
+			userID: 1,
+			adID:   5,
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+				s.adRepo.On("AdByID", mock.Anything, int64(5)).Return(&ads.Ad{ID: 5}, nil).Once()
+				s.favRepo.On("Favorites", mock.Anything, int64(1)).Return(nil, nil).Once()
+				s.favRepo.On("AddFavorite", mock.Anything, mock.Anything).Return(fmt.Errorf("disk is broken")).Once()
+			},
+			err: ErrInternalFavRepoError,
+		},
+		{
+			name:   "ok",
+			userID: 1,
+			adID:   5,
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+				s.adRepo.On("AdByID", mock.Anything, int64(5)).Return(&ads.Ad{ID: 5}, nil).Once()
+				s.favRepo.On("Favorites", mock.Anything, int64(1)).Return(nil, nil).Once()
+				s.favRepo.
+					On("AddFavorite", mock.Anything, mock.MatchedBy(func(f *favorites.Favorite) bool {
+						return f.UserID == 1 && f.AdID == 5 && !f.Added.IsZero()
+					})).
+					Return(nil).
+					Once()
+				s.favRepo.On("CountByAds", mock.Anything, []int64{5}).Return(map[int64]int{5: 1}, nil).Once()
+			},
+			want: &ads.Ad{ID: 5, Favorites: 1},
+		},
+		{
+			name:   "ok already in favorites",
+			userID: 1,
+			adID:   5,
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+				s.adRepo.On("AdByID", mock.Anything, int64(5)).Return(&ads.Ad{ID: 5}, nil).Once()
+				s.favRepo.On("Favorites", mock.Anything, int64(1)).Return([]*favorites.Favorite{{UserID: 1, AdID: 5}}, nil).Once()
+				s.favRepo.On("AddFavorite", mock.Anything, mock.Anything).Return(favrepo.ErrFavoriteAlreadyExists).Once()
+				s.favRepo.On("CountByAds", mock.Anything, []int64{5}).Return(map[int64]int{5: 1}, nil).Once()
+			},
+			want: &ads.Ad{ID: 5, Favorites: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.setMock()
+			ad, err := s.app.AddFavorite(auth.WithUserID(context.Background(), 1), tt.userID, tt.adID)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, ad)
+			}
+		})
+	}
+}
+
+func (s *AppTestSuite) TestAdApp_DeleteFavorite() {
+	ctx := auth.WithUserID(context.Background(), 1)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+	s.adRepo.On("AdByID", mock.Anything, int64(5)).Return(&ads.Ad{ID: 5}, nil).Once()
+	s.favRepo.On("DeleteFavorite", mock.Anything, int64(1), int64(5)).Return(favrepo.ErrNoFavorite).Once()
+	_, err := s.app.DeleteFavorite(ctx, 1, 5)
+	assert.ErrorIs(s.T(), err, ErrBadRequest)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+	s.adRepo.On("AdByID", mock.Anything, int64(5)).Return(&ads.Ad{ID: 5}, nil).Once()
+	s.favRepo.On("DeleteFavorite", mock.Anything, int64(1), int64(5)).Return(nil).Once()
+	s.favRepo.On("CountByAds", mock.Anything, []int64{5}).Return(map[int64]int{}, nil).Once()
+	ad, err := s.app.DeleteFavorite(ctx, 1, 5)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), &ads.Ad{ID: 5}, ad)
+}
+
+func (s *AppTestSuite) TestAdApp_ContactSeller() {
+	buyer := &users.User{ID: 2}
+	published := &ads.Ad{ID: 10, UserID: 1, Status: ads.StatusPublished}
+	thread := &messages.Thread{ID: 3, AdID: 10, BuyerID: 2, SellerID: 1}
+
+	tests := []struct {
+		name    string
+		text    string
+		setMock func()
+		err     error
+	}{
+		{
+			name: "unknown ad",
+			text: "Здравствуйте",
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(2)).Return(buyer, nil).Once()
+				s.adRepo.On("AdByID", mock.Anything, int64(10)).Return(nil, adrepo.ErrNoAd).Once()
+			},
+			err: ErrBadRequest,
+		},
+		{
+			name: "own ad",
+			text: "Здравствуйте",
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(2)).Return(buyer, nil).Once()
+				s.adRepo.On("AdByID", mock.Anything, int64(10)).Return(&ads.Ad{ID: 10, UserID: 2, Status: ads.StatusPublished}, nil).Once()
+			},
+			err: ErrBadRequest,
+		},
+		{
+			name: "new thread for draft",
+			text: "Здравствуйте",
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(2)).Return(buyer, nil).Once()
+				s.adRepo.On("AdByID", mock.Anything, int64(10)).Return(&ads.Ad{ID: 10, UserID: 1, Status: ads.StatusDraft}, nil).Once()
+				s.msgRepo.On("ThreadByAd", mock.Anything, int64(10), int64(2)).Return(nil, msgrepo.ErrNoThread).Once()
+			},
+			err: ErrBadRequest,
+		},
+		{
+			name: "empty text",
+			text: "",
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(2)).Return(buyer, nil).Once()
+				s.adRepo.On("AdByID", mock.Anything, int64(10)).Return(published, nil).Once()
+				s.msgRepo.On("ThreadByAd", mock.Anything, int64(10), int64(2)).Return(thread, nil).Once()
+			},
+			err: ErrBadRequest,
+		},
+		{
+			name: "ok new thread",
+			text: "Здравствуйте",
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(2)).Return(buyer, nil).Once()
+				s.adRepo.On("AdByID", mock.Anything, int64(10)).Return(published, nil).Once()
+				s.msgRepo.On("ThreadByAd", mock.Anything, int64(10), int64(2)).Return(nil, msgrepo.ErrNoThread).Once()
+				s.msgRepo.
+					On("AddThread", mock.Anything, mock.MatchedBy(func(t *messages.Thread) bool {
+						return t.AdID == 10 && t.BuyerID == 2 && t.SellerID == 1
+					})).
+					Return(int64(3), nil).
+					Once()
+				s.msgRepo.
+					On("AddMessage", mock.Anything, mock.MatchedBy(func(m *messages.Message) bool {
+						return m.ThreadID == 3 && m.SenderID == 2
+					})).
+					Return(int64(7), nil).
+					Once()
+			},
+		},
+		{
+			name: "ok thread started concurrently",
+			text: "Здравствуйте",
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(2)).Return(buyer, nil).Once()
+				s.adRepo.On("AdByID", mock.Anything, int64(10)).Return(published, nil).Once()
+				s.msgRepo.On("ThreadByAd", mock.Anything, int64(10), int64(2)).Return(nil, msgrepo.ErrNoThread).Once()
+				s.msgRepo.On("AddThread", mock.Anything, mock.Anything).Return(int64(-1), msgrepo.ErrThreadAlreadyExists).Once()
+				s.msgRepo.On("ThreadByAd", mock.Anything, int64(10), int64(2)).Return(thread, nil).Once()
+				s.msgRepo.On("AddMessage", mock.Anything, mock.Anything).Return(int64(7), nil).Once()
+			},
+		},
+		{
+			name: "ok existing thread",
+			text: "Ещё продаёте?",
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(2)).Return(buyer, nil).Once()
+				s.adRepo.On("AdByID", mock.Anything, int64(10)).Return(published, nil).Once()
+				s.msgRepo.On("ThreadByAd", mock.Anything, int64(10), int64(2)).Return(thread, nil).Once()
+				s.msgRepo.On("AddMessage", mock.Anything, mock.Anything).Return(int64(7), nil).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.setMock()
+			m, err := s.app.ContactSeller(auth.WithUserID(context.Background(), 2), 10, tt.text)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, int64(7), m.ID)
+				assert.Equal(t, int64(3), m.ThreadID)
+				assert.Equal(t, tt.text, m.Text)
+			}
+		})
+	}
+}
+
+func (s *AppTestSuite) TestAdApp_SendMessage() {
+	thread := &messages.Thread{ID: 3, AdID: 10, BuyerID: 2, SellerID: 1}
+
+	s.userRepo.On("UserByID", mock.Anything, int64(4)).Return(&users.User{ID: 4, Role: users.RoleAdmin}, nil).Once()
+	s.msgRepo.On("ThreadByID", mock.Anything, int64(3)).Return(thread, nil).Once()
+	_, err := s.app.SendMessage(auth.WithUserID(context.Background(), 4), 3, "Привет")
+	assert.ErrorIs(s.T(), err, ErrForbidden, "not a participant")
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+	s.msgRepo.On("ThreadByID", mock.Anything, int64(100)).Return(nil, msgrepo.ErrNoThread).Once()
+	_, err = s.app.SendMessage(auth.WithUserID(context.Background(), 1), 100, "Привет")
+	assert.ErrorIs(s.T(), err, ErrBadRequest, "unknown thread")
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+	s.msgRepo.On("ThreadByID", mock.Anything, int64(3)).Return(thread, nil).Once()
+	s.msgRepo.
+		On("AddMessage", mock.Anything, mock.MatchedBy(func(m *messages.Message) bool {
+			return m.ThreadID == 3 && m.SenderID == 1 && m.Text == "Да"
+		})).
+		Return(int64(8), nil).
+		Once()
+	m, err := s.app.SendMessage(auth.WithUserID(context.Background(), 1), 3, "Да")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(8), m.ID)
+}
+
+func (s *AppTestSuite) TestAdApp_Threads() {
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1, Role: users.RoleAdmin}, nil).Once()
+	_, err := s.app.Threads(auth.WithUserID(context.Background(), 1), 2)
+	assert.ErrorIs(s.T(), err, ErrForbidden)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+	s.msgRepo.On("Threads", mock.Anything, int64(1)).Return([]*messages.Thread{{ID: 3}, {ID: 2}}, nil).Once()
+	threads, err := s.app.Threads(auth.WithUserID(context.Background(), 1), 1)
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), threads, 2)
+}
+
+func (s *AppTestSuite) TestAdApp_Messages() {
+	thread := &messages.Thread{ID: 3, AdID: 10, BuyerID: 2, SellerID: 1, SellerUnread: 2}
+	ctx := auth.WithUserID(context.Background(), 1)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+	s.msgRepo.On("ThreadByID", mock.Anything, int64(3)).Return(thread, nil).Once()
+	s.msgRepo.On("Messages", mock.Anything, int64(3), mock.Anything).Return(nil, "", messages.ErrBadPage).Once()
+	_, _, err := s.app.Messages(ctx, 3, messages.Page{Cursor: "bad"})
+	assert.ErrorIs(s.T(), err, ErrBadRequest)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+	s.msgRepo.On("ThreadByID", mock.Anything, int64(3)).Return(thread, nil).Once()
+	s.msgRepo.
+		On("Messages", mock.Anything, int64(3), messages.DefaultPage()).
+		Return([]*messages.Message{{ID: 2, ThreadID: 3}, {ID: 1, ThreadID: 3}}, "next", nil).
+		Once()
+	s.msgRepo.On("MarkRead", mock.Anything, int64(3), int64(1)).Return(nil).Once()
+	list, next, err := s.app.Messages(ctx, 3, messages.DefaultPage())
+	assert.NoError(s.T(), err)
+	assert.Len(s.T(), list, 2)
+	assert.Equal(s.T(), "next", next)
+}
+
+func (s *AppTestSuite) TestAdApp_CreateUser() {
+	type args struct {
+		ctx      context.Context
+		nick     string
+		email    string
+		password string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		setMock func()
+		want    *users.User
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "validate error",
+			args: args{
+				ctx: context.Background(),
+			},
+			setMock: func() {},
+			wantErr: true,
+			err:     ErrBadRequest,
+		},
+		{
+			name: "short password",
+			args: args{
+				ctx:      context.Background(),
+				nick:     "user",
+				email:    "user@gmail.com",
+				password: "short",
+			},
+			setMock: func() {},
+			wantErr: true,
+			err:     ErrBadRequest,
+		},
+		{
+			name: "err user already exists",
+			args: args{
+				ctx:      context.Background(),
+				nick:     "user",
+				email:    "user@gmail.com",
+				password: "password",
+			},
+			setMock: func() {
+				s.userRepo.
+					On("AddUser", mock.Anything, mock.Anything).
+					Return(int64(-1), userrepo.ErrUserAlreadyExists).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrBadRequest,
+		},
+		{
+			name: "unknown error from adRepo.AddUser func",
+			args: args{
+				ctx:      context.Background(),
+				nick:     "user",
+				email:    "user@gmail.com",
+				password: "password",
+			},
+			setMock: func() {
+				s.userRepo.
+					On("AddUser", mock.Anything, mock.Anything).
+					Return(int64(-1), fmt.Errorf("unknown error from adRepo.AddUser func")).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrInternalUserRepoError,
+		},
+		{
+			name: "ok",
+			args: args{
+				ctx:      context.Background(),
+				nick:     "user",
+				email:    "user@gmail.com",
+				password: "password",
+			},
+			setMock: func() {
+				s.userRepo.
+					On("AddUser", mock.Anything, mock.Anything).
+					Return(int64(0), nil).
+					Once()
+			},
+			want: &users.User{
+				Nickname: "user",
+				Email:    "user@gmail.com",
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.setMock()
+			u, err := s.app.CreateUser(tt.args.ctx, tt.args.nick, tt.args.email, tt.args.password)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want.ID, u.ID)
+				assert.Equal(t, tt.want.Nickname, u.Nickname)
+				assert.Equal(t, tt.want.Email, u.Email)
+				assert.NotEqual(t, tt.args.password, u.PasswordHash)
+
+				ok, err := auth.CheckPassword(u.PasswordHash, tt.args.password)
+				assert.NoError(t, err)
+				assert.True(t, ok)
+			}
+		})
+	}
+}
+
+func (s *AppTestSuite) TestAdApp_UpdateUser() {
+	type args struct {
+		ctx     context.Context
+		id      int64
+		version int64
+		nick    string
+		email   string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		setMock func()
+		want    *users.User
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "wrong userID",
+			args: args{
+				ctx: context.Background(),
+			},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(nil, userrepo.ErrNoUser).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrUnauthorized,
+		},
+		{
+			name: "unknown error from userRepo.UserByID func",
+			args: args{
+				ctx: context.Background(),
+			},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("unknown error from userRepo.UserByID func")).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrInternalUserRepoError,
+		},
+		{
+			name: "validate error",
+			args: args{
+				ctx: context.Background(),
+			},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{}, nil).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrBadRequest,
+		},
+		{
+			name: "version mismatch",
+			args: args{
+				ctx:     context.Background(),
+				version: 2,
+			},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{Version: 1}, nil).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrConflict,
+		},
+		{
+			name: "version conflict from userRepo.UpdateUser func",
+			args: args{
+				ctx:   context.Background(),
+				nick:  "new.user",
+				email: "new.user@gmail.com",
+			},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{}, nil).
+					Once()
+
+				s.userRepo.
+					On("UpdateUser", mock.Anything, mock.Anything).
+					Return(userrepo.ErrUserVersionConflict).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrConflict,
+		},
+		{
+			name: "unknown error from userRepo.UpdateUser func",
+			args: args{
+				ctx:   context.Background(),
+				nick:  "new.user",
+				email: "new.user@gmail.com",
+			},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{}, nil).
+					Once()
+
+				s.userRepo.
+					On("UpdateUser", mock.Anything, mock.Anything).
+					Return(fmt.Errorf("unknown error from userRepo.UpdateUser func")).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrInternalUserRepoError,
+		},
+		{
+			name: "ok",
+			args: args{
+				ctx:   context.Background(),
+				nick:  "new.user",
+				email: "new.user@gmail.com",
+			},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{}, nil).
+					Once()
+
+				s.userRepo.
+					On("UpdateUser", mock.Anything, mock.Anything).
+					Return(nil).
+					Once()
+			},
+			want: &users.User{
+				Nickname: "new.user",
+				Email:    "new.user@gmail.com",
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.setMock()
+			u, err := s.app.UpdateUser(auth.WithUserID(tt.args.ctx, tt.args.id), tt.args.id, tt.args.version, tt.args.nick, tt.args.email)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want.ID, u.ID)
+				assert.Equal(t, tt.want.Nickname, u.Nickname)
+				assert.Equal(t, tt.want.Email, u.Email)
+			}
+		})
+	}
+}
+
+func (s *AppTestSuite) TestAdApp_UserByID() {
+	type args struct {
+		ctx context.Context
+		id  int64
+	}
+	tests := []struct {
+		name    string
+		args    args
+		setMock func()
+		want    *users.User
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "wrong userID",
+			args: args{
+				ctx: context.Background(),
+			},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(nil, userrepo.ErrNoUser).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrBadRequest,
+		},
+		{
+			name: "unknown error from userRepo.UserByID func",
+			args: args{
+				ctx: context.Background(),
+			},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("unknown error from userRepo.UserByID func")).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrInternalUserRepoError,
+		},
+		{
+			name: "ok",
+			args: args{
+				ctx: context.Background(),
+			},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{}, nil).
+					Once()
+			},
+			want:    &users.User{},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.setMock()
+			u, err := s.app.UserByID(tt.args.ctx, tt.args.id)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want.ID, u.ID)
+				assert.Equal(t, tt.want.Nickname, u.Nickname)
+				assert.Equal(t, tt.want.Email, u.Email)
+			}
+		})
+	}
+}
+
+func (s *AppTestSuite) TestAdApp_DeleteUser() {
+	type args struct {
+		ctx context.Context
+		id  int64
+	}
+	tests := []struct {
+		name    string
+		args    args
+		setMock func()
+		want    *users.User
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "wrong userID",
+			args: args{
+				ctx: context.Background(),
+			},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(nil, userrepo.ErrNoUser).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrUnauthorized,
+		},
+		{
+			name: "unknown error from userRepo.UserByID func",
+			args: args{
+				ctx: context.Background(),
+			},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("unknown error from userRepo.UserByID func")).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrInternalUserRepoError,
+		},
+		{
+			name: "unknown error from favRepo.DeleteByUser func",
+			args: args{
+				ctx: context.Background(),
+			},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{}, nil).
+					Once()
+
+				s.favRepo.
+					On("DeleteByUser", mock.Anything, mock.Anything).
+					Return(fmt.Errorf("unknown error from favRepo.DeleteByUser func")).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrInternalFavRepoError,
+		},
+		{
+			name: "unknown error from searchRepo.DeleteByUser func",
+			args: args{
+				ctx: context.Background(),
+			},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{}, nil).
+					Once()
+
+				s.favRepo.
+					On("DeleteByUser", mock.Anything, mock.Anything).
+					Return(nil).
+					Once()
+
+				s.searchRepo.
+					On("DeleteByUser", mock.Anything, mock.Anything).
+					Return(fmt.Errorf("unknown error from searchRepo.DeleteByUser func")).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrInternalSearchRepoError,
+		},
+		{
+			name: "unknown error from userRepo.DeleteUser func",
+			args: args{
+				ctx: context.Background(),
+			},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{}, nil).
+					Once()
+
+				s.favRepo.
+					On("DeleteByUser", mock.Anything, mock.Anything).
+					Return(nil).
+					Once()
+
+				s.searchRepo.
+					On("DeleteByUser", mock.Anything, mock.Anything).
+					Return(nil).
+					Once()
+
+				s.userRepo.
+					On("DeleteUser", mock.Anything, mock.Anything).
+					Return(fmt.Errorf("unknown error from userRepo.DeleteUser func")).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrInternalUserRepoError,
+		},
+		{
+			name: "ok",
+			args: args{
+				ctx: context.Background(),
+			},
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{}, nil).
+					Once()
+
+				s.favRepo.
+					On("DeleteByUser", mock.Anything, mock.Anything).
+					Return(nil).
+					Once()
+
+				s.searchRepo.
+					On("DeleteByUser", mock.Anything, mock.Anything).
+					Return(nil).
+					Once()
+
+				s.userRepo.
+					On("DeleteUser", mock.Anything, mock.Anything).
+					Return(nil).
+					Once()
+			},
+			want:    &users.User{},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.setMock()
+			u, err := s.app.DeleteUser(auth.WithUserID(tt.args.ctx, tt.args.id), tt.args.id)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want.ID, u.ID)
+				assert.Equal(t, tt.want.Nickname, u.Nickname)
+				assert.Equal(t, tt.want.Email, u.Email)
+			}
+		})
+	}
+}
+
+func (s *AppTestSuite) TestAdApp_Anonymous() {
+	ctx := context.Background()
+
+	_, err := s.app.CreateAd(ctx, "title", "text", 1, ads.Price{}, nil, 0)
+	assert.ErrorIs(s.T(), err, ErrUnauthorized)
+
+	_, err = s.app.CreateCategory(ctx, "Телефоны", 0)
+	assert.ErrorIs(s.T(), err, ErrUnauthorized)
+
+	_, err = s.app.UpdateAd(ctx, 0, 0, "title", "text", nil, nil, false)
+	assert.ErrorIs(s.T(), err, ErrUnauthorized)
+
+	_, err = s.app.ChangeAdStatus(ctx, 0, 0, true)
+	assert.ErrorIs(s.T(), err, ErrUnauthorized)
+
+	_, err = s.app.DeleteAd(ctx, 0)
+	assert.ErrorIs(s.T(), err, ErrUnauthorized)
+
+	_, err = s.app.UpdateUser(ctx, 0, 0, "user", "user@gmail.com")
+	assert.ErrorIs(s.T(), err, ErrUnauthorized)
+
+	_, err = s.app.DeleteUser(ctx, 0)
+	assert.ErrorIs(s.T(), err, ErrUnauthorized)
+}
+
+func (s *AppTestSuite) TestAdApp_Policy() {
+	moderator := &users.User{ID: 2, Role: users.RoleModerator}
+	admin := &users.User{ID: 3, Role: users.RoleAdmin}
+
+	tests := []struct {
+		name    string
+		actor   *users.User
+		setMock func()
+		call    func(ctx context.Context) error
+		err     error
+	}{
+		{
+			name:  "user updates other user",
+			actor: &users.User{ID: 1},
+			call: func(ctx context.Context) error {
+				_, err := s.app.UpdateUser(ctx, 0, 0, "user", "user@gmail.com")
+				return err
+			},
+			err: ErrForbidden,
+		},
+		{
+			name:  "user deletes other user",
+			actor: &users.User{ID: 1, Role: users.RoleUser},
+			call: func(ctx context.Context) error {
+				_, err := s.app.DeleteUser(ctx, 0)
+				return err
+			},
+			err: ErrForbidden,
+		},
+		{
+			name:  "moderator updates foreign ad",
+			actor: moderator,
+			setMock: func() {
+				s.adRepo.
+					On("AdByID", mock.Anything, int64(7)).
+					Return(&ads.Ad{ID: 7, UserID: 1}, nil).
+					Once()
+			},
+			call: func(ctx context.Context) error {
+				_, err := s.app.UpdateAd(ctx, 7, 0, "title", "text", nil, nil, false)
+				return err
+			},
+			err: ErrForbidden,
+		},
+		{
+			name:  "moderator publishes foreign ad",
+			actor: moderator,
+			setMock: func() {
+				s.adRepo.
+					On("AdByID", mock.Anything, int64(7)).
+					Return(&ads.Ad{ID: 7, UserID: 1}, nil).
+					Once()
+			},
+			call: func(ctx context.Context) error {
+				_, err := s.app.ChangeAdStatus(ctx, 7, 0, true)
+				return err
+			},
+			err: ErrForbidden,
+		},
+		{
+			name:  "moderator unpublishes foreign ad",
+			actor: moderator,
+			setMock: func() {
+				s.adRepo.
+					On("AdByID", mock.Anything, int64(7)).
+					Return(&ads.Ad{ID: 7, UserID: 1, Status: ads.StatusPublished}, nil).
+					Once()
+				s.adRepo.
+					On("UpdateAd", mock.Anything, mock.Anything).
+					Return(nil).
+					Once()
+			},
+			call: func(ctx context.Context) error {
+				ad, err := s.app.ChangeAdStatus(ctx, 7, 0, false)
+				if err == nil {
+					assert.False(s.T(), ad.Published())
+				}
+				return err
+			},
+		},
+		{
+			name:  "moderator deletes foreign ad",
+			actor: moderator,
+			setMock: func() {
+				s.adRepo.
+					On("AdByID", mock.Anything, int64(7)).
+					Return(&ads.Ad{ID: 7, UserID: 1}, nil).
+					Once()
+				s.favRepo.
+					On("DeleteByAd", mock.Anything, int64(7)).
+					Return(nil).
+					Once()
+
+				s.adRepo.
+					On("DeleteAd", mock.Anything, int64(7)).
+					Return(nil).
+					Once()
+			},
+			call: func(ctx context.Context) error {
+				_, err := s.app.DeleteAd(ctx, 7)
+				return err
+			},
+		},
+		{
+			name:  "moderator changes role",
+			actor: moderator,
+			call: func(ctx context.Context) error {
+				_, err := s.app.ChangeUserRole(ctx, 2, 0, users.RoleAdmin)
+				return err
+			},
+			err: ErrForbidden,
+		},
+		{
+			name:  "admin changes role",
+			actor: admin,
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, int64(1)).
+					Return(&users.User{ID: 1, Role: users.RoleUser}, nil).
+					Once()
+				s.userRepo.
+					On("UpdateUser", mock.Anything, mock.Anything).
+					Return(nil).
+					Once()
+			},
+			call: func(ctx context.Context) error {
+				u, err := s.app.ChangeUserRole(ctx, 1, 0, users.RoleModerator)
+				if err == nil {
+					assert.Equal(s.T(), users.RoleModerator, u.Role)
+				}
+				return err
+			},
+		},
+		{
+			name:  "admin sets unknown role",
+			actor: admin,
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, int64(1)).
+					Return(&users.User{ID: 1}, nil).
+					Once()
+			},
+			call: func(ctx context.Context) error {
+				_, err := s.app.ChangeUserRole(ctx, 1, 0, "king")
+				return err
+			},
+			err: ErrBadRequest,
+		},
+		{
+			name:  "admin changes role of unknown user",
+			actor: admin,
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, int64(9)).
+					Return(nil, userrepo.ErrNoUser).
+					Once()
+			},
+			call: func(ctx context.Context) error {
+				_, err := s.app.ChangeUserRole(ctx, 9, 0, users.RoleModerator)
+				return err
+			},
+			err: ErrBadRequest,
+		},
+		{
+			name:  "admin deletes other user",
+			actor: admin,
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, int64(1)).
+					Return(&users.User{ID: 1}, nil).
+					Once()
+				s.favRepo.
+					On("DeleteByUser", mock.Anything, int64(1)).
+					Return(nil).
+					Once()
+				s.searchRepo.
+					On("DeleteByUser", mock.Anything, int64(1)).
+					Return(nil).
+					Once()
+
+				s.userRepo.
+					On("DeleteUser", mock.Anything, int64(1)).
+					Return(nil).
+					Once()
+			},
+			call: func(ctx context.Context) error {
+				_, err := s.app.DeleteUser(ctx, 1)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			s.userRepo.
+				On("UserByID", mock.Anything, tt.actor.ID).
+				Return(tt.actor, nil).
+				Once()
+			if tt.setMock != nil {
+				tt.setMock()
+			}
+
+			err := tt.call(auth.WithUserID(context.Background(), tt.actor.ID))
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func (s *AppTestSuite) TestAdApp_Login() {
+	hash, err := auth.HashPassword("password")
+	assert.NoError(s.T(), err)
+
+	tests := []struct {
+		name     string
+		userID   int64
+		password string
+		setMock  func()
+		wantErr  bool
+		err      error
+	}{
+		{
+			name:   "wrong userID",
+			userID: 1,
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, int64(1)).
+					Return(nil, userrepo.ErrNoUser).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrUnauthorized,
+		},
+		{
+			name:   "unknown error from userRepo.UserByID func",
+			userID: 1,
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, int64(1)).
+					Return(nil, fmt.Errorf("unknown error from userRepo.UserByID func")).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrInternalUserRepoError,
+		},
+		{
+			name:     "wrong password",
+			userID:   1,
+			password: "wrong password",
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, int64(1)).
+					Return(&users.User{ID: 1, PasswordHash: hash}, nil).
+					Once()
+			},
+			wantErr: true,
+			err:     ErrUnauthorized,
+		},
+		{
+			name:     "ok",
+			userID:   1,
+			password: "password",
+			setMock: func() {
+				s.userRepo.
+					On("UserByID", mock.Anything, int64(1)).
+					Return(&users.User{ID: 1, PasswordHash: hash}, nil).
+					Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.setMock()
+			tokens, err := s.app.Login(context.Background(), tt.userID, tt.password)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, tt.err)
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, tt.want.ID, u.ID)
-				assert.Equal(t, tt.want.Nickname, u.Nickname)
-				assert.Equal(t, tt.want.Email, u.Email)
+				assert.Equal(t, time.Minute, tokens.ExpiresIn)
+
+				userID, err := s.issuer.Verify(tokens.Access, auth.Access)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.userID, userID)
+
+				userID, err = s.issuer.Verify(tokens.Refresh, auth.Refresh)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.userID, userID)
+			}
+		})
+	}
+}
+
+func (s *AppTestSuite) TestAdApp_RefreshAndAuthenticate() {
+	tokens, err := s.issuer.Issue(1)
+	assert.NoError(s.T(), err)
+
+	s.userRepo.
+		On("UserByID", mock.Anything, int64(1)).
+		Return(&users.User{ID: 1}, nil).
+		Twice()
+
+	userID, err := s.app.Authenticate(context.Background(), tokens.Access)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(1), userID)
+
+	refreshed, err := s.app.Refresh(context.Background(), tokens.Refresh)
+	assert.NoError(s.T(), err)
+	assert.NotEmpty(s.T(), refreshed.Access)
+
+	// токены разного назначения не взаимозаменяемы
+	_, err = s.app.Authenticate(context.Background(), tokens.Refresh)
+	assert.ErrorIs(s.T(), err, ErrUnauthorized)
+
+	_, err = s.app.Refresh(context.Background(), tokens.Access)
+	assert.ErrorIs(s.T(), err, ErrUnauthorized)
+
+	_, err = s.app.Authenticate(context.Background(), "garbage")
+	assert.ErrorIs(s.T(), err, ErrUnauthorized)
+
+	// токен удалённого пользователя больше не действует
+	s.userRepo.
+		On("UserByID", mock.Anything, int64(1)).
+		Return(nil, userrepo.ErrNoUser).
+		Once()
+
+	_, err = s.app.Authenticate(context.Background(), tokens.Access)
+	assert.ErrorIs(s.T(), err, ErrUnauthorized)
+}
+
+func (s *AppTestSuite) TestAdApp_AdHistory() {
+	journal := auditrepo.New()
+	a := NewApp(s.adRepo, s.userRepo, s.catRepo, s.favRepo, s.msgRepo, journal, s.outbox, s.hookRepo, s.searchRepo, s.feed, s.images, s.issuer, 0, logging.Discard())
+	ctx := auth.WithUserID(context.Background(), 1)
+	owner := &users.User{ID: 1}
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(owner, nil).Once()
+	s.catRepo.On("CategoryByID", mock.Anything, int64(1)).Return(&categories.Category{ID: 1}, nil).Once()
+	s.adRepo.On("AddAd", mock.Anything, mock.Anything).Return(int64(5), nil).Once()
+	_, err := a.CreateAd(ctx, "title", "text", 1, ads.Price{}, nil, 0)
+	assert.NoError(s.T(), err)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(owner, nil).Once()
+	s.adRepo.On("AdByID", mock.Anything, int64(5)).Return(&ads.Ad{ID: 5, Title: "title", Text: "text", UserID: 1}, nil).Once()
+	s.adRepo.On("UpdateAd", mock.Anything, mock.Anything).Return(nil).Once()
+	_, err = a.UpdateAd(ctx, 5, 0, "new title", "new text", &ads.Price{Amount: 100, Currency: "RUB"}, nil, false)
+	assert.NoError(s.T(), err)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(owner, nil).Once()
+	list, err := a.AdHistory(ctx, 5)
+	assert.NoError(s.T(), err)
+	if assert.Len(s.T(), list, 2) {
+		assert.Equal(s.T(), audit.OpCreate, list[0].Op)
+		assert.Equal(s.T(), int64(1), list[0].ActorID)
+		assert.True(s.T(), list[0].Before.Empty())
+		assert.Equal(s.T(), audit.OpUpdate, list[1].Op)
+		assert.Equal(s.T(), int64(2), list[1].Revision)
+		assert.Equal(s.T(), "title", list[1].Before.Ad.Title)
+		assert.Equal(s.T(), "new title", list[1].After.Ad.Title)
+		assert.Equal(s.T(), ads.Price{Amount: 100, Currency: "RUB"}, list[1].After.Ad.Price)
+	}
+
+	// чужую историю обычный пользователь не видит
+	s.userRepo.On("UserByID", mock.Anything, int64(2)).Return(&users.User{ID: 2}, nil).Once()
+	_, err = a.AdHistory(auth.WithUserID(context.Background(), 2), 5)
+	assert.ErrorIs(s.T(), err, ErrForbidden)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(owner, nil).Once()
+	s.favRepo.On("DeleteByAd", mock.Anything, int64(5)).Return(nil).Once()
+	s.adRepo.On("AdByID", mock.Anything, int64(5)).Return(&ads.Ad{ID: 5, Title: "new title", Text: "new text", UserID: 1}, nil).Once()
+	s.adRepo.On("DeleteAd", mock.Anything, int64(5)).Return(nil).Once()
+	_, err = a.DeleteAd(ctx, 5)
+	assert.NoError(s.T(), err)
+
+	// история удалённого объявления остаётся
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(owner, nil).Once()
+	list, err = a.AdHistory(ctx, 5)
+	assert.NoError(s.T(), err)
+	if assert.Len(s.T(), list, 3) {
+		assert.Equal(s.T(), audit.OpDelete, list[2].Op)
+		assert.True(s.T(), list[2].After.Empty())
+	}
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(owner, nil).Once()
+	s.adRepo.On("AdByID", mock.Anything, int64(6)).Return(nil, adrepo.ErrNoAd).Once()
+	_, err = a.AdHistory(ctx, 6)
+	assert.ErrorIs(s.T(), err, ErrBadRequest)
+}
+
+func (s *AppTestSuite) TestAdApp_AdHistory_Error() {
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+	s.auditRepo.On("History", mock.Anything, audit.KindAd, int64(5)).Return(nil, fmt.Errorf("unknown error")).Once()
+
+	_, err := s.app.AdHistory(auth.WithUserID(context.Background(), 1), 5)
+	assert.ErrorIs(s.T(), err, ErrInternalAuditRepoError)
+}
+
+func (s *AppTestSuite) TestAdApp_RevertAd() {
+	journal := auditrepo.New()
+	a := NewApp(s.adRepo, s.userRepo, s.catRepo, s.favRepo, s.msgRepo, journal, s.outbox, s.hookRepo, s.searchRepo, s.feed, s.images, s.issuer, 0, logging.Discard())
+	ctx := auth.WithUserID(context.Background(), 1)
+	owner := &users.User{ID: 1}
+	location := &ads.Location{Point: ads.Point{Lat: 55.75, Lon: 37.62}, City: "Москва"}
+	first := &ads.Ad{ID: 5, Title: "title", Text: "text", UserID: 1, Location: location, Status: ads.StatusPublished}
+	current := &ads.Ad{ID: 5, Title: "new title", Text: "new text", UserID: 1, Status: ads.StatusArchived, Version: 3}
+	_, _ = journal.Append(context.Background(), &audit.Entry{Kind: audit.KindAd, ObjectID: 5, ActorID: 1, Op: audit.OpCreate, After: audit.AdSnapshot(first)})
+	_, _ = journal.Append(context.Background(), &audit.Entry{Kind: audit.KindAd, ObjectID: 5, ActorID: 1, Op: audit.OpDelete, Before: audit.AdSnapshot(first)})
+
+	tests := []struct {
+		name     string
+		actor    *users.User
+		version  int64
+		revision int64
+		err      error
+	}{
+		{name: "foreign ad", actor: &users.User{ID: 2, Role: users.RoleAdmin}, revision: 1, err: ErrForbidden},
+		{name: "stale version", actor: owner, version: 2, revision: 1, err: ErrConflict},
+		{name: "unknown revision", actor: owner, revision: 10, err: ErrBadRequest},
+		{name: "revision without state", actor: owner, revision: 2, err: ErrBadRequest},
+	}
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			s.userRepo.On("UserByID", mock.Anything, tt.actor.ID).Return(tt.actor, nil).Once()
+			cp := *current
+			s.adRepo.On("AdByID", mock.Anything, int64(5)).Return(&cp, nil).Once()
+			_, err := a.RevertAd(auth.WithUserID(context.Background(), tt.actor.ID), 5, tt.version, tt.revision)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(owner, nil).Once()
+	cp := *current
+	s.adRepo.On("AdByID", mock.Anything, int64(5)).Return(&cp, nil).Once()
+	s.adRepo.
+		On("UpdateAd", mock.Anything, mock.MatchedBy(func(ad *ads.Ad) bool {
+			return ad.Title == "title" && ad.Location == location
+		})).
+		Return(nil).
+		Once()
+	ad, err := a.RevertAd(ctx, 5, 3, 1)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "text", ad.Text)
+	// статус при откате не меняется
+	assert.Equal(s.T(), ads.StatusArchived, ad.Status)
+
+	list, err := journal.History(context.Background(), audit.KindAd, 5)
+	assert.NoError(s.T(), err)
+	if assert.Len(s.T(), list, 3) {
+		assert.Equal(s.T(), audit.OpRevert, list[2].Op)
+		assert.Equal(s.T(), "new title", list[2].Before.Ad.Title)
+		assert.Equal(s.T(), "title", list[2].After.Ad.Title)
+	}
+}
+
+func (s *AppTestSuite) TestAdApp_History_ReusedID() {
+	journal := auditrepo.New()
+	a := NewApp(s.adRepo, s.userRepo, s.catRepo, s.favRepo, s.msgRepo, journal, s.outbox, s.hookRepo, s.searchRepo, s.feed, s.images, s.issuer, 0, logging.Discard())
+	ctx := auth.WithUserID(context.Background(), 1)
+	owner := &users.User{ID: 1}
+
+	// до исправления репозиториев ID удалённого объявления другого автора мог достаться новому
+	foreign := &ads.Ad{ID: 6, Title: "чужое", Text: "чужой текст", UserID: 4}
+	current := &ads.Ad{ID: 6, Title: "своё", Text: "свой текст", UserID: 1}
+	_, _ = journal.Append(context.Background(), &audit.Entry{Kind: audit.KindAd, ObjectID: 6, ActorID: 4, Op: audit.OpCreate, After: audit.AdSnapshot(foreign)})
+	_, _ = journal.Append(context.Background(), &audit.Entry{Kind: audit.KindAd, ObjectID: 6, ActorID: 4, Op: audit.OpDelete, Before: audit.AdSnapshot(foreign)})
+	_, _ = journal.Append(context.Background(), &audit.Entry{Kind: audit.KindAd, ObjectID: 6, ActorID: 1, Op: audit.OpCreate, After: audit.AdSnapshot(current)})
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(owner, nil).Once()
+	list, err := a.AdHistory(ctx, 6)
+	assert.NoError(s.T(), err)
+	if assert.Len(s.T(), list, 1) {
+		assert.Equal(s.T(), int64(3), list[0].Revision)
+		assert.Equal(s.T(), "своё", list[0].After.Ad.Title)
+	}
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(owner, nil).Once()
+	cp := *current
+	s.adRepo.On("AdByID", mock.Anything, int64(6)).Return(&cp, nil).Once()
+	_, err = a.RevertAd(ctx, 6, 0, 1)
+	assert.ErrorIs(s.T(), err, ErrBadRequest)
+}
+
+func (s *AppTestSuite) TestAdApp_Audit_Users() {
+	journal := auditrepo.New()
+	a := NewApp(s.adRepo, s.userRepo, s.catRepo, s.favRepo, s.msgRepo, journal, s.outbox, s.hookRepo, s.searchRepo, s.feed, s.images, s.issuer, 0, logging.Discard())
+
+	s.userRepo.On("AddUser", mock.Anything, mock.Anything).Return(int64(3), nil).Once()
+	_, err := a.CreateUser(context.Background(), "jenny", "jenny@gmail.com", "password")
+	assert.NoError(s.T(), err)
+
+	admin := &users.User{ID: 1, Role: users.RoleAdmin}
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(admin, nil).Once()
+	s.userRepo.On("UserByID", mock.Anything, int64(3)).Return(&users.User{ID: 3, Nickname: "jenny", PasswordHash: "hash"}, nil).Once()
+	s.userRepo.On("UpdateUser", mock.Anything, mock.Anything).Return(nil).Once()
+	_, err = a.ChangeUserRole(auth.WithUserID(context.Background(), 1), 3, 0, users.RoleModerator)
+	assert.NoError(s.T(), err)
+
+	list, err := journal.History(context.Background(), audit.KindUser, 3)
+	assert.NoError(s.T(), err)
+	if assert.Len(s.T(), list, 2) {
+		// при регистрации пользователь создаёт себя сам
+		assert.Equal(s.T(), int64(3), list[0].ActorID)
+		assert.Equal(s.T(), audit.OpChangeRole, list[1].Op)
+		assert.Equal(s.T(), int64(1), list[1].ActorID)
+		assert.Equal(s.T(), users.RoleModerator, list[1].After.User.Role)
+		// хэш пароля в журнал не попадает
+		assert.Empty(s.T(), list[0].After.User.PasswordHash)
+		assert.Empty(s.T(), list[1].Before.User.PasswordHash)
+	}
+}
+
+func (s *AppTestSuite) TestAdApp_Events() {
+	outbox := outboxrepo.New()
+	a := NewApp(s.adRepo, s.userRepo, s.catRepo, s.favRepo, s.msgRepo, auditrepo.New(), outbox, s.hookRepo, s.searchRepo, s.feed, s.images, s.issuer, 0, logging.Discard())
+	ctx := auth.WithUserID(context.Background(), 1)
+	owner := &users.User{ID: 1}
+
+	s.userRepo.On("AddUser", mock.Anything, mock.Anything).Return(int64(1), nil).Once()
+	_, err := a.CreateUser(context.Background(), "nick", "nick@mail.com", "password")
+	assert.NoError(s.T(), err)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(owner, nil).Once()
+	s.catRepo.On("CategoryByID", mock.Anything, int64(1)).Return(&categories.Category{ID: 1}, nil).Once()
+	s.adRepo.On("AddAd", mock.Anything, mock.Anything).Return(int64(5), nil).Once()
+	_, err = a.CreateAd(ctx, "title", "text", 1, ads.Price{}, nil, 0)
+	assert.NoError(s.T(), err)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(owner, nil).Once()
+	s.adRepo.On("AdByID", mock.Anything, int64(5)).Return(&ads.Ad{ID: 5, Title: "title", Text: "text", UserID: 1, Status: ads.StatusDraft}, nil).Once()
+	s.adRepo.On("UpdateAd", mock.Anything, mock.Anything).Return(nil).Once()
+	_, err = a.ChangeAdStatus(ctx, 5, 0, true)
+	assert.NoError(s.T(), err)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(owner, nil).Once()
+	s.adRepo.On("AdByID", mock.Anything, int64(5)).Return(&ads.Ad{ID: 5, Title: "title", Text: "text", UserID: 1, Status: ads.StatusPublished}, nil).Once()
+	s.adRepo.On("UpdateAd", mock.Anything, mock.Anything).Return(nil).Once()
+	_, err = a.UpdateAd(ctx, 5, 0, "new title", "text", nil, nil, false)
+	assert.NoError(s.T(), err)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(owner, nil).Once()
+	s.adRepo.On("AdByID", mock.Anything, int64(5)).Return(&ads.Ad{ID: 5, Title: "new title", Text: "text", UserID: 1, Status: ads.StatusPublished}, nil).Once()
+	s.adRepo.On("UpdateAd", mock.Anything, mock.Anything).Return(nil).Once()
+	_, err = a.ChangeAdStatus(ctx, 5, 0, false)
+	assert.NoError(s.T(), err)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(owner, nil).Once()
+	s.favRepo.On("DeleteByAd", mock.Anything, int64(5)).Return(nil).Once()
+	s.adRepo.On("AdByID", mock.Anything, int64(5)).Return(&ads.Ad{ID: 5, Title: "new title", Text: "text", UserID: 1}, nil).Once()
+	s.adRepo.On("DeleteAd", mock.Anything, int64(5)).Return(nil).Once()
+	_, err = a.DeleteAd(ctx, 5)
+	assert.NoError(s.T(), err)
+
+	// изменения категорий событий не порождают
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1, Role: users.RoleAdmin}, nil).Once()
+	s.catRepo.On("Categories", mock.Anything).Return([]*categories.Category{}, nil).Once()
+	s.catRepo.On("AddCategory", mock.Anything, mock.Anything).Return(int64(2), nil).Once()
+	_, err = a.CreateCategory(ctx, "category", 0)
+	assert.NoError(s.T(), err)
+
+	list, err := outbox.Pending(context.Background(), 0)
+	assert.NoError(s.T(), err)
+	var types []events.Type
+	for _, e := range list {
+		types = append(types, e.Type)
+	}
+	assert.Equal(s.T(), []events.Type{events.UserCreated, events.AdCreated, events.AdPublished, events.AdUpdated, events.AdStatusChanged, events.AdDeleted}, types)
+	if assert.Len(s.T(), list, 6) {
+		assert.Equal(s.T(), int64(1), list[0].ActorID)
+		assert.Empty(s.T(), list[0].User.PasswordHash)
+		assert.Equal(s.T(), int64(5), list[1].ObjectID)
+		assert.Equal(s.T(), "title", list[1].Ad.Title)
+		assert.Equal(s.T(), "new title", list[3].Ad.Title)
+		// удалённое объявление приходит в событии в последнем состоянии
+		assert.Equal(s.T(), "new title", list[5].Ad.Title)
+	}
+}
+
+func (s *AppTestSuite) TestAdApp_Events_OutboxError() {
+	outbox := outboxMock.NewOutbox(s.T())
+	a := NewApp(s.adRepo, s.userRepo, s.catRepo, s.favRepo, s.msgRepo, s.auditRepo, outbox, s.hookRepo, s.searchRepo, s.feed, s.images, s.issuer, 0, logging.Discard())
+
+	// без события изменение не увидят подписчики, поэтому ошибка outbox проваливает запрос
+	outbox.On("Add", mock.Anything, mock.Anything).Return(int64(-1), fmt.Errorf("unknown error")).Once()
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+	s.catRepo.On("CategoryByID", mock.Anything, int64(1)).Return(&categories.Category{ID: 1}, nil).Once()
+	s.adRepo.On("AddAd", mock.Anything, mock.Anything).Return(int64(5), nil).Once()
+
+	_, err := a.CreateAd(auth.WithUserID(context.Background(), 1), "title", "text", 1, ads.Price{}, nil, 0)
+	assert.ErrorIs(s.T(), err, ErrInternalOutboxError)
+
+	outbox.On("Add", mock.Anything, mock.Anything).Return(int64(-1), fmt.Errorf("unknown error")).Once()
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+	s.adRepo.On("AdByID", mock.Anything, int64(5)).Return(&ads.Ad{ID: 5, UserID: 1, Status: ads.StatusPublished}, nil).Once()
+	s.adRepo.On("UpdateAd", mock.Anything, mock.Anything).Return(nil).Once()
+
+	_, err = a.ChangeAdStatus(auth.WithUserID(context.Background(), 1), 5, 0, false)
+	assert.ErrorIs(s.T(), err, ErrInternalOutboxError)
+	assert.Equal(s.T(), "internal", ErrorKind(err))
+}
+
+func (s *AppTestSuite) TestAdApp_CreateWebhook() {
+	admin := &users.User{ID: 1, Role: users.RoleAdmin}
+	ctx := auth.WithUserID(context.Background(), 1)
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		url     string
+		types   []events.Type
+		setMock func()
+		wantErr error
+	}{
+		{
+			name:    "unauthorized error",
+			ctx:     context.Background(),
+			url:     "http://example.com",
+			setMock: func() {},
+			wantErr: ErrUnauthorized,
+		},
+		{
+			name: "forbidden error for moderator",
+			ctx:  auth.WithUserID(context.Background(), 2),
+			url:  "http://example.com",
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(2)).Return(&users.User{ID: 2, Role: users.RoleModerator}, nil).Once()
+			},
+			wantErr: ErrForbidden,
+		},
+		{
+			name: "bad request error for relative url",
+			ctx:  ctx,
+			url:  "/hooks",
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(admin, nil).Once()
+			},
+			wantErr: ErrBadRequest,
+		},
+		{
+			name:  "bad request error for unknown event",
+			ctx:   ctx,
+			url:   "http://example.com",
+			types: []events.Type{"ad.viewed"},
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(admin, nil).Once()
+			},
+			wantErr: ErrBadRequest,
+		},
+		{
+			name: "internal error",
+			ctx:  ctx,
+			url:  "http://example.com",
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(admin, nil).Once()
+				s.hookRepo.On("AddSubscription", mock.Anything, mock.Anything).Return(int64(-1), fmt.Errorf("unknown error")).Once()
+			},
+			wantErr: ErrInternalHookRepoError,
+		},
+		{
+			name:  "ok",
+			ctx:   ctx,
+			url:   "https://example.com/hooks",
+			types: []events.Type{events.AdCreated, events.AdPublished},
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(admin, nil).Once()
+				s.hookRepo.On("AddSubscription", mock.Anything, mock.Anything).Return(int64(3), nil).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			tt.setMock()
+			sub, err := s.app.CreateWebhook(tt.ctx, tt.url, tt.types)
+			assert.ErrorIs(s.T(), err, tt.wantErr)
+			if tt.wantErr != nil {
+				return
+			}
+			assert.Equal(s.T(), int64(3), sub.ID)
+			assert.Equal(s.T(), tt.url, sub.URL)
+			assert.Equal(s.T(), tt.types, sub.Types)
+			assert.Equal(s.T(), int64(1), sub.OwnerID)
+			assert.Len(s.T(), sub.Secret, 64)
+		})
+	}
+}
+
+func (s *AppTestSuite) TestAdApp_Webhooks() {
+	admin := &users.User{ID: 1, Role: users.RoleAdmin}
+	ctx := auth.WithUserID(context.Background(), 1)
+	list := []*webhooks.Subscription{{ID: 1, URL: "http://a.example"}, {ID: 2, URL: "http://b.example"}}
+
+	s.userRepo.On("UserByID", mock.Anything, int64(2)).Return(&users.User{ID: 2}, nil).Once()
+	_, err := s.app.Webhooks(auth.WithUserID(context.Background(), 2))
+	assert.ErrorIs(s.T(), err, ErrForbidden)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(admin, nil).Once()
+	s.hookRepo.On("Subscriptions", mock.Anything).Return(nil, fmt.Errorf("unknown error")).Once()
+	_, err = s.app.Webhooks(ctx)
+	assert.ErrorIs(s.T(), err, ErrInternalHookRepoError)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(admin, nil).Once()
+	s.hookRepo.On("Subscriptions", mock.Anything).Return(list, nil).Once()
+	got, err := s.app.Webhooks(ctx)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), list, got)
+}
+
+func (s *AppTestSuite) TestAdApp_DeleteWebhook() {
+	admin := &users.User{ID: 1, Role: users.RoleAdmin}
+	ctx := auth.WithUserID(context.Background(), 1)
+	sub := &webhooks.Subscription{ID: 1, URL: "http://a.example"}
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(admin, nil).Once()
+	s.hookRepo.On("SubscriptionByID", mock.Anything, int64(2)).Return(nil, hookrepo.ErrNoSubscription).Once()
+	_, err := s.app.DeleteWebhook(ctx, 2)
+	assert.ErrorIs(s.T(), err, ErrBadRequest)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(admin, nil).Once()
+	s.hookRepo.On("SubscriptionByID", mock.Anything, int64(1)).Return(sub, nil).Once()
+	s.hookRepo.On("DeleteSubscription", mock.Anything, int64(1)).Return(fmt.Errorf("unknown error")).Once()
+	_, err = s.app.DeleteWebhook(ctx, 1)
+	assert.ErrorIs(s.T(), err, ErrInternalHookRepoError)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(admin, nil).Once()
+	s.hookRepo.On("SubscriptionByID", mock.Anything, int64(1)).Return(sub, nil).Once()
+	s.hookRepo.On("DeleteSubscription", mock.Anything, int64(1)).Return(nil).Once()
+	got, err := s.app.DeleteWebhook(ctx, 1)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), sub, got)
+}
+
+func (s *AppTestSuite) TestAdApp_WebhookDeliveries() {
+	admin := &users.User{ID: 1, Role: users.RoleAdmin}
+	ctx := auth.WithUserID(context.Background(), 1)
+	list := []*webhooks.Delivery{{ID: 2, SubscriptionID: 1, Status: webhooks.DeliveryPending}, {ID: 1, SubscriptionID: 1, Status: webhooks.DeliveryDelivered}}
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(admin, nil).Once()
+	s.hookRepo.On("Deliveries", mock.Anything, int64(2), MaxWebhookDeliveries).Return(nil, hookrepo.ErrNoSubscription).Once()
+	_, err := s.app.WebhookDeliveries(ctx, 2)
+	assert.ErrorIs(s.T(), err, ErrBadRequest)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(admin, nil).Once()
+	s.hookRepo.On("Deliveries", mock.Anything, int64(1), MaxWebhookDeliveries).Return(list, nil).Once()
+	got, err := s.app.WebhookDeliveries(ctx, 1)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), list, got)
+}
+
+func (s *AppTestSuite) TestAdApp_CreateSavedSearch() {
+	tests := []struct {
+		name    string
+		userID  int64
+		search  string
+		q       ads.Query
+		setMock func()
+		err     error
+	}{
+		{
+			name:   "foreign searches",
+			userID: 2,
+			search: "Телефоны",
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1, Role: users.RoleAdmin}, nil).Once()
+			},
+			err: ErrForbidden,
+		},
+		{
+			name:   "empty name",
+			userID: 1,
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+			},
+			err: ErrBadRequest,
+		},
+		{
+			name:   "bad filter",
+			userID: 1,
+			search: "Телефоны",
+			q:      ads.Query{Filter: "title"},
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+			},
+			err: ErrBadRequest,
+		},
+		{
+			name:   "unknown category",
+			userID: 1,
+			search: "Телефоны",
+			q:      ads.Query{Category: 9},
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+				s.catRepo.On("Categories", mock.Anything).Return(testCategories(), nil).Once()
+			},
+			err: ErrBadRequest,
+		},
+		{
+			name:   "too many searches",
+			userID: 1,
+			search: "Телефоны",
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+				s.searchRepo.On("Searches", mock.Anything, int64(1)).Return(make([]*searches.Search, MaxSearches), nil).Once()
+			},
+			err: ErrBadRequest,
+		},
+		{
+			name:   "unknown error from searchRepo.AddSearch func",
+			userID: 1,
+			search: "Телефоны",
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+				s.searchRepo.On("Searches", mock.Anything, int64(1)).Return(nil, nil).Once()
+				s.searchRepo.On("AddSearch", mock.Anything, mock.Anything).Return(int64(-1), fmt.Errorf("disk is broken")).Once()
+			},
+			err: ErrInternalSearchRepoError,
+		},
+		{
+			name:   "ok",
+			userID: 1,
+			search: "Телефоны",
+			q:      ads.Query{Query: "iphone", Category: 2},
+			setMock: func() {
+				s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+				s.catRepo.On("Categories", mock.Anything).Return(testCategories(), nil).Once()
+				s.searchRepo.On("Searches", mock.Anything, int64(1)).Return(nil, nil).Once()
+				s.searchRepo.
+					On("AddSearch", mock.Anything, mock.MatchedBy(func(found *searches.Search) bool {
+						return found.UserID == 1 && found.Name == "Телефоны" && found.Query.Category == 2 && !found.Created.IsZero()
+					})).
+					Return(int64(1), nil).
+					Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		s.T().Run(tt.name, func(t *testing.T) {
+			tt.setMock()
+			found, err := s.app.CreateSavedSearch(auth.WithUserID(context.Background(), 1), tt.userID, tt.search, tt.q)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.q, found.Query)
 			}
 		})
 	}
 }
 
+func (s *AppTestSuite) TestAdApp_UpdateSavedSearch() {
+	ctx := auth.WithUserID(context.Background(), 1)
+	q := ads.Query{Query: "iphone"}
+
+	// чужой поиск не отличается от несуществующего
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+	s.searchRepo.On("SearchByID", mock.Anything, int64(3)).Return(&searches.Search{ID: 3, UserID: 2, Name: "Телефоны"}, nil).Once()
+	_, err := s.app.UpdateSavedSearch(ctx, 1, 3, "Айфоны", q)
+	assert.ErrorIs(s.T(), err, ErrBadRequest)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+	s.searchRepo.On("SearchByID", mock.Anything, int64(4)).Return(nil, searchrepo.ErrNoSearch).Once()
+	_, err = s.app.UpdateSavedSearch(ctx, 1, 4, "Айфоны", q)
+	assert.ErrorIs(s.T(), err, ErrBadRequest)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+	s.searchRepo.On("SearchByID", mock.Anything, int64(1)).Return(&searches.Search{ID: 1, UserID: 1, Name: "Телефоны"}, nil).Once()
+	s.searchRepo.
+		On("UpdateSearch", mock.Anything, mock.MatchedBy(func(found *searches.Search) bool {
+			return found.ID == 1 && found.Name == "Айфоны" && found.Query.Query == "iphone"
+		})).
+		Return(nil).
+		Once()
+	found, err := s.app.UpdateSavedSearch(ctx, 1, 1, "Айфоны", q)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "Айфоны", found.Name)
+	assert.False(s.T(), found.Updated.IsZero())
+}
+
+func (s *AppTestSuite) TestAdApp_DeleteSavedSearch() {
+	ctx := auth.WithUserID(context.Background(), 1)
+	found := &searches.Search{ID: 1, UserID: 1, Name: "Телефоны"}
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+	s.searchRepo.On("SearchByID", mock.Anything, int64(1)).Return(found, nil).Once()
+	s.searchRepo.On("DeleteSearch", mock.Anything, int64(1)).Return(fmt.Errorf("unknown error")).Once()
+	_, err := s.app.DeleteSavedSearch(ctx, 1, 1)
+	assert.ErrorIs(s.T(), err, ErrInternalSearchRepoError)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+	s.searchRepo.On("SearchByID", mock.Anything, int64(1)).Return(found, nil).Once()
+	s.searchRepo.On("DeleteSearch", mock.Anything, int64(1)).Return(nil).Once()
+	got, err := s.app.DeleteSavedSearch(ctx, 1, 1)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), found, got)
+}
+
+func (s *AppTestSuite) TestAdApp_Notifications() {
+	ctx := auth.WithUserID(context.Background(), 1)
+	list := []*searches.Notification{{ID: 2, UserID: 1, SearchID: 1, AdID: 6}, {ID: 1, UserID: 1, SearchID: 1, AdID: 5, Read: true}}
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1, Role: users.RoleAdmin}, nil).Once()
+	_, _, err := s.app.Notifications(ctx, 2)
+	assert.ErrorIs(s.T(), err, ErrForbidden)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+	s.searchRepo.On("Notifications", mock.Anything, int64(1), MaxNotifications).Return(list, nil).Once()
+	s.searchRepo.On("Unread", mock.Anything, int64(1)).Return(1, nil).Once()
+	got, unread, err := s.app.Notifications(ctx, 1)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), list, got)
+	assert.Equal(s.T(), 1, unread)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+	assert.ErrorIs(s.T(), s.app.ReadNotifications(ctx, 1, 0), ErrBadRequest)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(&users.User{ID: 1}, nil).Once()
+	s.searchRepo.On("MarkRead", mock.Anything, int64(1), int64(2)).Return(nil).Once()
+	assert.NoError(s.T(), s.app.ReadNotifications(ctx, 1, 2))
+}
+
+func (s *AppTestSuite) TestAdApp_NotifySearches() {
+	searchRepo := searchrepo.New()
+	a := NewApp(s.adRepo, s.userRepo, s.catRepo, s.favRepo, s.msgRepo, s.auditRepo, s.outbox, s.hookRepo, searchRepo, s.feed, s.images, s.issuer, 0, logging.Discard())
+	ctx := auth.WithUserID(context.Background(), 1)
+	owner := &users.User{ID: 1}
+
+	published := true
+	for _, found := range []*searches.Search{
+		{UserID: 2, Name: "Электроника", Query: ads.Query{Category: 1}},
+		{UserID: 3, Name: "Опубликованные телефоны", Query: ads.Query{Query: "телефон", Published: &published}},
+		{UserID: 3, Name: "Одежда", Query: ads.Query{Category: 4}},
+		// о своих объявлениях автор не уведомляется
+		{UserID: 1, Name: "Свои", Query: ads.Query{}},
+	} {
+		_, err := searchRepo.AddSearch(context.Background(), found)
+		assert.NoError(s.T(), err)
+	}
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(owner, nil).Once()
+	s.catRepo.On("CategoryByID", mock.Anything, int64(5)).Return(&categories.Category{ID: 5}, nil).Once()
+	s.adRepo.On("AddAd", mock.Anything, mock.Anything).Return(int64(7), nil).Once()
+	// дерево категорий загружается один раз на объявление
+	s.catRepo.On("Categories", mock.Anything).Return(testCategories(), nil).Once()
+	_, err := a.CreateAd(ctx, "Телефон", "новый", 5, ads.Price{}, nil, 0)
+	assert.NoError(s.T(), err)
+
+	s.userRepo.On("UserByID", mock.Anything, int64(1)).Return(owner, nil).Once()
+	s.adRepo.On("AdByID", mock.Anything, int64(7)).Return(&ads.Ad{ID: 7, Title: "Телефон", Text: "новый", UserID: 1, CategoryID: 5, Status: ads.StatusDraft}, nil).Once()
+	s.adRepo.On("UpdateAd", mock.Anything, mock.Anything).Return(nil).Once()
+	s.catRepo.On("Categories", mock.Anything).Return(testCategories(), nil).Once()
+	_, err = a.ChangeAdStatus(ctx, 7, 0, true)
+	assert.NoError(s.T(), err)
+
+	for userID, want := range map[int64][]int64{1: nil, 2: {1}, 3: {2}} {
+		list, err := searchRepo.Notifications(context.Background(), userID, 0)
+		assert.NoError(s.T(), err)
+
+		var searchIDs []int64
+		for _, n := range list {
+			assert.Equal(s.T(), int64(7), n.AdID)
+			assert.Equal(s.T(), "Телефон", n.Title)
+			searchIDs = append(searchIDs, n.SearchID)
+		}
+		assert.Equal(s.T(), want, searchIDs, userID)
+	}
+}
+
+func (s *AppTestSuite) TestObserve() {
+	type call struct {
+		method string
+		err    error
+	}
+	var calls []call
+	a := Observe(s.app, func(ctx context.Context, method string) (context.Context, func(error)) {
+		return ctx, func(err error) {
+			calls = append(calls, call{method: method, err: err})
+		}
+	})
+
+	s.adRepo.
+		On("AdByID", mock.Anything, int64(1)).
+		Return(nil, adrepo.ErrNoAd).
+		Once()
+	_, err := a.AdByID(context.Background(), 1)
+	assert.ErrorIs(s.T(), err, ErrBadRequest)
+
+	// результат вызова отдаётся как есть, а наблюдатель узнаёт о каждом вызове
+	_, err = a.CreateAd(context.Background(), "title", "text", 0, ads.Price{}, nil, 0)
+	assert.ErrorIs(s.T(), err, ErrUnauthorized)
+
+	assert.Equal(s.T(), []call{{method: "AdByID", err: ErrBadRequest}, {method: "CreateAd", err: ErrUnauthorized}}, calls)
+}
+
+func TestErrorKind(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: ErrBadRequest, want: "bad_request"},
+		{err: fmt.Errorf("%w: title is empty", ErrBadRequest), want: "bad_request"},
+		{err: ErrUnauthorized, want: "unauthorized"},
+		{err: fmt.Errorf("%w: not an owner", ErrForbidden), want: "forbidden"},
+		{err: ErrConflict, want: "conflict"},
+		{err: ErrTransition, want: "transition"},
+		{err: ErrTooLarge, want: "too_large"},
+		{err: ErrUnavailable, want: "unavailable"},
+		{err: ErrInternalAdRepoError, want: "internal"},
+		{err: fmt.Errorf("unexpected"), want: "internal"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ErrorKind(tt.err), tt.err.Error())
+	}
+}
+
+func TestTTLFromSeconds(t *testing.T) {
+	tests := []struct {
+		seconds int64
+		want    time.Duration
+		err     error
+	}{
+		{seconds: 0, want: 0},
+		{seconds: 3600, want: time.Hour},
+		{seconds: int64(MaxAdTTL / time.Second), want: MaxAdTTL},
+		{seconds: int64(MaxAdTTL/time.Second) + 1, err: ErrBadRequest},
+		{seconds: -1, err: ErrBadRequest},
+		// 18446744074 * time.Second переполняет int64 и даёт около 0.29 секунды
+		{seconds: 18446744074, err: ErrBadRequest},
+	}
+
+	for _, tt := range tests {
+		ttl, err := TTLFromSeconds(tt.seconds)
+		assert.ErrorIs(t, err, tt.err, tt.seconds)
+		assert.Equal(t, tt.want, ttl, tt.seconds)
+	}
+}
+
 func TestAppTestSuite(t *testing.T) {
 	suite.Run(t, new(AppTestSuite))
 }
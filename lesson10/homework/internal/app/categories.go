@@ -0,0 +1,232 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"homework10/internal/adapters/catrepo"
+	"homework10/internal/ads"
+	"homework10/internal/audit"
+	"homework10/internal/categories"
+	"homework10/internal/policy"
+
+	"github.com/newRational/vld"
+)
+
+// Categories возвращает дерево категорий
+func (a *AdApp) Categories(ctx context.Context) (*categories.Tree, error) {
+	list, err := a.catRepo.Categories(ctx)
+	if err != nil {
+		return nil, ErrInternalCatRepoError
+	}
+
+	return categories.NewTree(list), nil
+}
+
+// CategoryCounts считает подходящие под шаблон объявления по категориям; в количество
+// для категории входят и объявления её подкатегорий
+func (a *AdApp) CategoryCounts(ctx context.Context, p *ads.Pattern) ([]categories.Count, error) {
+	tree, err := a.Categories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err = expandCategory(tree, p)
+	if err != nil {
+		return nil, err
+	}
+
+	own, err := a.adRepo.CountByCategory(ctx, p)
+	if err != nil {
+		return nil, ErrInternalAdRepoError
+	}
+
+	return tree.Counts(own), nil
+}
+
+// CreateCategory создаёт категорию внутри parentID (0 - на верхнем уровне); это может делать только администратор
+func (a *AdApp) CreateCategory(ctx context.Context, name string, parentID int64) (*categories.Category, error) {
+	if err := a.authorizeCategories(ctx); err != nil {
+		return nil, err
+	}
+
+	tree, err := a.Categories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &categories.Category{
+		ID:       -1,
+		ParentID: parentID,
+		Name:     name,
+	}
+	if err = checkPlacement(tree, c); err != nil {
+		return nil, err
+	}
+
+	id, err := a.catRepo.AddCategory(ctx, c)
+	if errors.Is(err, catrepo.ErrCategoryAlreadyExists) {
+		return nil, ErrBadRequest
+	} else if err != nil {
+		return nil, ErrInternalCatRepoError
+	}
+
+	c.ID = id
+	if err = a.record(ctx, &audit.Entry{Kind: audit.KindCategory, ObjectID: id, ActorID: actorOf(ctx), Op: audit.OpCreate, After: audit.CategorySnapshot(c)}); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// UpdateCategory переименовывает категорию и (или) переносит её вместе с подкатегориями в parentID.
+// Если version != 0, то категория должна иметь именно эту версию
+func (a *AdApp) UpdateCategory(ctx context.Context, ID, version int64, name string, parentID int64) (*categories.Category, error) {
+	if err := a.authorizeCategories(ctx); err != nil {
+		return nil, err
+	}
+
+	tree, err := a.Categories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	old, ok := tree.Category(ID)
+	if !ok {
+		return nil, ErrBadRequest
+	}
+
+	if version != 0 && old.Version != version {
+		return nil, ErrConflict
+	}
+
+	c := *old
+	c.Name = name
+	c.ParentID = parentID
+	if err = checkPlacement(tree, &c); err != nil {
+		return nil, err
+	}
+
+	err = a.catRepo.UpdateCategory(ctx, &c)
+	if errors.Is(err, catrepo.ErrCategoryVersionConflict) {
+		return nil, ErrConflict
+	} else if errors.Is(err, catrepo.ErrNoCategory) {
+		return nil, ErrBadRequest
+	} else if err != nil {
+		return nil, ErrInternalCatRepoError
+	}
+	if err = a.record(ctx, &audit.Entry{Kind: audit.KindCategory, ObjectID: ID, ActorID: actorOf(ctx), Op: audit.OpUpdate, Before: audit.CategorySnapshot(old), After: audit.CategorySnapshot(&c)}); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// DeleteCategory удаляет категорию, в которой нет ни подкатегорий, ни объявлений
+func (a *AdApp) DeleteCategory(ctx context.Context, ID int64) (*categories.Category, error) {
+	if err := a.authorizeCategories(ctx); err != nil {
+		return nil, err
+	}
+
+	tree, err := a.Categories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c, ok := tree.Category(ID)
+	if !ok {
+		return nil, ErrBadRequest
+	}
+	if len(tree.Children(ID)) > 0 {
+		return nil, fmt.Errorf("%w: category has subcategories", ErrBadRequest)
+	}
+
+	p := ads.DefaultPattern().Where(&ads.Eq{Field: ads.FieldCategory, Value: ads.IntValue(ID)})
+	adverts, _, err := a.adRepo.AdsByPattern(ctx, p, ads.Page{Limit: 1})
+	if err != nil {
+		return nil, ErrInternalAdRepoError
+	}
+	if len(adverts) > 0 {
+		return nil, fmt.Errorf("%w: category has ads", ErrBadRequest)
+	}
+
+	err = a.catRepo.DeleteCategory(ctx, ID)
+	if errors.Is(err, catrepo.ErrNoCategory) {
+		return nil, ErrBadRequest
+	} else if err != nil {
+		return nil, ErrInternalCatRepoError
+	}
+	if err = a.record(ctx, &audit.Entry{Kind: audit.KindCategory, ObjectID: ID, ActorID: actorOf(ctx), Op: audit.OpDelete, Before: audit.CategorySnapshot(c)}); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// authorizeCategories проверяет, что отправитель запроса может менять дерево категорий
+func (a *AdApp) authorizeCategories(ctx context.Context) error {
+	actor, err := a.actingUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	return authorize(actor, policy.ManageCategories, 0)
+}
+
+// checkPlacement проверяет имя категории и её место в дереве: родитель существует и не лежит внутри самой категории,
+// а среди соседей нет категории с тем же именем
+func checkPlacement(tree *categories.Tree, c *categories.Category) error {
+	if err := vld.Validate(*c); err != nil {
+		return ErrBadRequest
+	}
+
+	if c.ParentID != 0 {
+		if _, ok := tree.Category(c.ParentID); !ok {
+			return ErrBadRequest
+		}
+		if tree.Within(c.ParentID, c.ID) {
+			return fmt.Errorf("%w: category can't be moved into itself", ErrBadRequest)
+		}
+	}
+
+	for _, sibling := range tree.Children(c.ParentID) {
+		if sibling.ID != c.ID && strings.EqualFold(sibling.Name, c.Name) {
+			return fmt.Errorf("%w: category %q already exists", ErrBadRequest, c.Name)
+		}
+	}
+
+	return nil
+}
+
+// expandCategory заменяет категорию шаблона условием на категорию и все её подкатегории
+func (a *AdApp) expandCategory(ctx context.Context, p *ads.Pattern) (*ads.Pattern, error) {
+	if p == nil || p.Category == 0 {
+		return p, nil
+	}
+
+	tree, err := a.Categories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return expandCategory(tree, p)
+}
+
+func expandCategory(tree *categories.Tree, p *ads.Pattern) (*ads.Pattern, error) {
+	if p == nil || p.Category == 0 {
+		return p, nil
+	}
+
+	subtree := tree.Subtree(p.Category)
+	if subtree == nil {
+		return nil, ErrBadRequest
+	}
+
+	values := make([]ads.Value, len(subtree))
+	for i, ID := range subtree {
+		values[i] = ads.IntValue(ID)
+	}
+
+	return p.SetCategory(0).Where(&ads.In{Field: ads.FieldCategory, Values: values}), nil
+}
@@ -0,0 +1,137 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/favrepo"
+	"homework10/internal/ads"
+	"homework10/internal/favorites"
+	"homework10/internal/policy"
+)
+
+// Favorites возвращает объявления из избранного пользователя: сначала добавленные последними.
+// Избранное видит только сам пользователь
+func (a *AdApp) Favorites(ctx context.Context, userID int64) ([]*ads.Ad, error) {
+	if err := a.authorizeFavorites(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	list, err := a.favRepo.Favorites(ctx, userID)
+	if err != nil {
+		return nil, ErrInternalFavRepoError
+	}
+
+	adverts := make([]*ads.Ad, 0, len(list))
+	for _, f := range list {
+		ad, err := a.adRepo.AdByID(ctx, f.AdID)
+		// объявление могло быть удалено одновременно с запросом
+		if errors.Is(err, adrepo.ErrNoAd) {
+			continue
+		} else if err != nil {
+			return nil, ErrInternalAdRepoError
+		}
+		adverts = append(adverts, ad)
+	}
+
+	if err = a.countFavorites(ctx, adverts...); err != nil {
+		return nil, err
+	}
+
+	return adverts, nil
+}
+
+// AddFavorite добавляет объявление в избранное пользователя; повторное добавление ничего не меняет
+func (a *AdApp) AddFavorite(ctx context.Context, userID, adID int64) (*ads.Ad, error) {
+	if err := a.authorizeFavorites(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	ad, err := a.adRepo.AdByID(ctx, adID)
+	if errors.Is(err, adrepo.ErrNoAd) {
+		return nil, ErrBadRequest
+	} else if err != nil {
+		return nil, ErrInternalAdRepoError
+	}
+
+	list, err := a.favRepo.Favorites(ctx, userID)
+	if err != nil {
+		return nil, ErrInternalFavRepoError
+	}
+	if len(list) >= MaxFavorites {
+		return nil, ErrBadRequest
+	}
+
+	err = a.favRepo.AddFavorite(ctx, &favorites.Favorite{UserID: userID, AdID: adID, Added: time.Now().UTC()})
+	if err != nil && !errors.Is(err, favrepo.ErrFavoriteAlreadyExists) {
+		return nil, ErrInternalFavRepoError
+	}
+
+	if err = a.countFavorites(ctx, ad); err != nil {
+		return nil, err
+	}
+
+	return ad, nil
+}
+
+// DeleteFavorite убирает объявление из избранного пользователя
+func (a *AdApp) DeleteFavorite(ctx context.Context, userID, adID int64) (*ads.Ad, error) {
+	if err := a.authorizeFavorites(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	ad, err := a.adRepo.AdByID(ctx, adID)
+	if errors.Is(err, adrepo.ErrNoAd) {
+		return nil, ErrBadRequest
+	} else if err != nil {
+		return nil, ErrInternalAdRepoError
+	}
+
+	err = a.favRepo.DeleteFavorite(ctx, userID, adID)
+	if errors.Is(err, favrepo.ErrNoFavorite) {
+		return nil, ErrBadRequest
+	} else if err != nil {
+		return nil, ErrInternalFavRepoError
+	}
+
+	if err = a.countFavorites(ctx, ad); err != nil {
+		return nil, err
+	}
+
+	return ad, nil
+}
+
+// authorizeFavorites проверяет, что отправитель запроса может работать с избранным пользователя userID
+func (a *AdApp) authorizeFavorites(ctx context.Context, userID int64) error {
+	actor, err := a.actingUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	return authorize(actor, policy.ManageFavorites, userID)
+}
+
+// countFavorites заполняет у объявлений количество добавлений в избранное
+func (a *AdApp) countFavorites(ctx context.Context, adverts ...*ads.Ad) error {
+	if len(adverts) == 0 {
+		return nil
+	}
+
+	IDs := make([]int64, len(adverts))
+	for i, ad := range adverts {
+		IDs[i] = ad.ID
+	}
+
+	counts, err := a.favRepo.CountByAds(ctx, IDs)
+	if err != nil {
+		return ErrInternalFavRepoError
+	}
+
+	for _, ad := range adverts {
+		ad.Favorites = counts[ad.ID]
+	}
+
+	return nil
+}
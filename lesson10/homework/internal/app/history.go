@@ -0,0 +1,122 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/ads"
+	"homework10/internal/audit"
+	"homework10/internal/policy"
+)
+
+// AdHistory возвращает журнал изменений объявления в порядке их выполнения, в том числе удалённого объявления;
+// его видят автор и модераторы. У объявлений, созданных до появления журнала, история начинается не с создания.
+// Записи о другом авторе (ID, переиспользованный до того, как репозитории перестали это делать) не возвращаются
+func (a *AdApp) AdHistory(ctx context.Context, ID int64) ([]*audit.Entry, error) {
+	actor, err := a.actingUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := a.auditRepo.History(ctx, audit.KindAd, ID)
+	if err != nil {
+		return nil, ErrInternalAuditRepoError
+	}
+
+	var ownerID int64
+	if len(list) > 0 {
+		// автор объявления не меняется, так что его можно взять из последней записи
+		ownerID = entryOwner(list[len(list)-1])
+		own := make([]*audit.Entry, 0, len(list))
+		for _, e := range list {
+			if entryOwner(e) == ownerID {
+				own = append(own, e)
+			}
+		}
+		list = own
+	} else {
+		ad, err := a.adRepo.AdByID(ctx, ID)
+		if errors.Is(err, adrepo.ErrNoAd) {
+			return nil, ErrBadRequest
+		} else if err != nil {
+			return nil, ErrInternalAdRepoError
+		}
+		ownerID = ad.UserID
+	}
+
+	if err = authorize(actor, policy.ReadAdHistory, ownerID); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// entryOwner возвращает автора объявления из записи журнала о нём
+func entryOwner(e *audit.Entry) int64 {
+	if e.After.Ad != nil {
+		return e.After.Ad.UserID
+	}
+	if e.Before.Ad != nil {
+		return e.Before.Ad.UserID
+	}
+	return 0
+}
+
+// RevertAd возвращает содержимое объявления (заголовок, текст, цену и местоположение) к состоянию после изменения
+// с номером revision; статус, фотографии и срок жизни не меняются. Откат сам попадает в журнал как новое изменение.
+// Если version != 0, то объявление должно иметь именно эту версию
+func (a *AdApp) RevertAd(ctx context.Context, ID, version, revision int64) (*ads.Ad, error) {
+	actor, err := a.actingUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ad, err := a.adRepo.AdByID(ctx, ID)
+	if errors.Is(err, adrepo.ErrNoAd) {
+		return nil, ErrBadRequest
+	} else if err != nil {
+		return nil, ErrInternalAdRepoError
+	}
+
+	if err = authorize(actor, policy.RevertAd, ad.UserID); err != nil {
+		return nil, err
+	}
+
+	if version != 0 && ad.Version != version {
+		return nil, ErrConflict
+	}
+
+	list, err := a.auditRepo.History(ctx, audit.KindAd, ID)
+	if err != nil {
+		return nil, ErrInternalAuditRepoError
+	}
+
+	var target *ads.Ad
+	for _, e := range list {
+		if e.Revision == revision {
+			target = e.After.Ad
+		}
+	}
+	// запись о другом авторе осталась от прежнего объявления с тем же ID: его содержимое не переносится
+	if target == nil || target.UserID != ad.UserID {
+		return nil, ErrBadRequest
+	}
+
+	before := audit.AdSnapshot(ad)
+	ad.Title = target.Title
+	ad.Text = target.Text
+	ad.Price = target.Price
+	ad.Location = target.Location
+	ad.Updated = time.Now().UTC()
+
+	if err = a.updateAd(ctx, ad); err != nil {
+		return nil, err
+	}
+	if err = a.record(ctx, &audit.Entry{Kind: audit.KindAd, ObjectID: ID, ActorID: actor.ID, Op: audit.OpRevert, Before: before, After: audit.AdSnapshot(ad)}); err != nil {
+		return nil, err
+	}
+
+	return ad, nil
+}
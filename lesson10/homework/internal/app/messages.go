@@ -0,0 +1,167 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/msgrepo"
+	"homework10/internal/messages"
+	"homework10/internal/policy"
+	"homework10/internal/users"
+
+	"github.com/newRational/vld"
+)
+
+// ContactSeller отправляет автору опубликованного объявления сообщение от покупателя;
+// первое сообщение начинает переписку, следующие попадают в неё же
+func (a *AdApp) ContactSeller(ctx context.Context, adID int64, text string) (*messages.Message, error) {
+	actor, err := a.actingUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ad, err := a.adRepo.AdByID(ctx, adID)
+	if errors.Is(err, adrepo.ErrNoAd) {
+		return nil, ErrBadRequest
+	} else if err != nil {
+		return nil, ErrInternalAdRepoError
+	}
+
+	if ad.UserID == actor.ID {
+		return nil, fmt.Errorf("%w: author can't contact himself", ErrBadRequest)
+	}
+
+	t, err := a.msgRepo.ThreadByAd(ctx, adID, actor.ID)
+	if errors.Is(err, msgrepo.ErrNoThread) {
+		if !ad.Published() {
+			return nil, fmt.Errorf("%w: ad is not published", ErrBadRequest)
+		}
+		t, err = a.startThread(ctx, adID, actor.ID, ad.UserID)
+	}
+	if err != nil {
+		return nil, ErrInternalMsgRepoError
+	}
+
+	return a.send(ctx, actor, t, text)
+}
+
+// startThread начинает переписку; если её одновременно начал другой запрос того же покупателя, возвращает её
+func (a *AdApp) startThread(ctx context.Context, adID, buyerID, sellerID int64) (*messages.Thread, error) {
+	now := time.Now().UTC()
+	t := &messages.Thread{
+		ID:       -1,
+		AdID:     adID,
+		BuyerID:  buyerID,
+		SellerID: sellerID,
+		Created:  now,
+		Updated:  now,
+	}
+
+	id, err := a.msgRepo.AddThread(ctx, t)
+	if errors.Is(err, msgrepo.ErrThreadAlreadyExists) {
+		return a.msgRepo.ThreadByAd(ctx, adID, buyerID)
+	} else if err != nil {
+		return nil, err
+	}
+
+	t.ID = id
+	return t, nil
+}
+
+// SendMessage отправляет сообщение в переписку; писать в неё могут только её участники
+func (a *AdApp) SendMessage(ctx context.Context, threadID int64, text string) (*messages.Message, error) {
+	actor, t, err := a.participantThread(ctx, threadID)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.send(ctx, actor, t, text)
+}
+
+func (a *AdApp) send(ctx context.Context, actor *users.User, t *messages.Thread, text string) (*messages.Message, error) {
+	m := &messages.Message{
+		ID:       -1,
+		ThreadID: t.ID,
+		SenderID: actor.ID,
+		Text:     text,
+		Sent:     time.Now().UTC(),
+	}
+	if err := vld.Validate(*m); err != nil {
+		return nil, ErrBadRequest
+	}
+
+	id, err := a.msgRepo.AddMessage(ctx, m)
+	if errors.Is(err, msgrepo.ErrNoThread) {
+		return nil, ErrBadRequest
+	} else if err != nil {
+		return nil, ErrInternalMsgRepoError
+	}
+
+	m.ID = id
+	return m, nil
+}
+
+// Threads возвращает переписки пользователя, сначала с самыми свежими сообщениями; их видит только сам пользователь
+func (a *AdApp) Threads(ctx context.Context, userID int64) ([]*messages.Thread, error) {
+	actor, err := a.actingUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = authorize(actor, policy.ReadMessages, userID); err != nil {
+		return nil, err
+	}
+
+	threads, err := a.msgRepo.Threads(ctx, userID)
+	if err != nil {
+		return nil, ErrInternalMsgRepoError
+	}
+
+	return threads, nil
+}
+
+// Messages возвращает страницу сообщений переписки, от новых к старым, и отмечает переписку прочитанной.
+// Читать переписку могут только её участники
+func (a *AdApp) Messages(ctx context.Context, threadID int64, page messages.Page) ([]*messages.Message, string, error) {
+	actor, _, err := a.participantThread(ctx, threadID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	list, next, err := a.msgRepo.Messages(ctx, threadID, page)
+	if errors.Is(err, msgrepo.ErrNoThread) || errors.Is(err, messages.ErrBadPage) {
+		return nil, "", ErrBadRequest
+	} else if err != nil {
+		return nil, "", ErrInternalMsgRepoError
+	}
+
+	if err = a.msgRepo.MarkRead(ctx, threadID, actor.ID); err != nil {
+		return nil, "", ErrInternalMsgRepoError
+	}
+
+	return list, next, nil
+}
+
+// participantThread возвращает отправителя запроса и переписку, проверив, что он в ней участвует
+func (a *AdApp) participantThread(ctx context.Context, threadID int64) (*users.User, *messages.Thread, error) {
+	actor, err := a.actingUser(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	t, err := a.msgRepo.ThreadByID(ctx, threadID)
+	if errors.Is(err, msgrepo.ErrNoThread) {
+		return nil, nil, ErrBadRequest
+	} else if err != nil {
+		return nil, nil, ErrInternalMsgRepoError
+	}
+
+	if !t.Has(actor.ID) {
+		return nil, nil, fmt.Errorf("%w: only participants can access the thread", ErrForbidden)
+	}
+
+	return actor, t, nil
+}
@@ -5,11 +5,29 @@ package mocks
 import (
 	ads "homework10/internal/ads"
 
+	audit "homework10/internal/audit"
+
+	auth "homework10/internal/auth"
+
+	categories "homework10/internal/categories"
+
 	context "context"
 
+	events "homework10/internal/events"
+
+	feed "homework10/internal/feed"
+
+	messages "homework10/internal/messages"
+
 	mock "github.com/stretchr/testify/mock"
 
+	searches "homework10/internal/searches"
+
+	time "time"
+
 	users "homework10/internal/users"
+
+	webhooks "homework10/internal/webhooks"
 )
 
 // App is an autogenerated mock type for the App type
@@ -43,20 +61,181 @@ func (_m *App) AdByID(ctx context.Context, ID int64) (*ads.Ad, error) {
 	return r0, r1
 }
 
-// AdsByPattern provides a mock function with given fields: ctx, p
-func (_m *App) AdsByPattern(ctx context.Context, p *ads.Pattern) ([]*ads.Ad, error) {
-	ret := _m.Called(ctx, p)
+// AdHistory provides a mock function with given fields: ctx, ID
+func (_m *App) AdHistory(ctx context.Context, ID int64) ([]*audit.Entry, error) {
+	ret := _m.Called(ctx, ID)
+
+	var r0 []*audit.Entry
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]*audit.Entry, error)); ok {
+		return rf(ctx, ID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []*audit.Entry); ok {
+		r0 = rf(ctx, ID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*audit.Entry)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, ID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AddAdImage provides a mock function with given fields: ctx, ID, version, data
+func (_m *App) AddAdImage(ctx context.Context, ID int64, version int64, data []byte) (*ads.Ad, error) {
+	ret := _m.Called(ctx, ID, version, data)
+
+	var r0 *ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, []byte) (*ads.Ad, error)); ok {
+		return rf(ctx, ID, version, data)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, []byte) *ads.Ad); ok {
+		r0 = rf(ctx, ID, version, data)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ads.Ad)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, []byte) error); ok {
+		r1 = rf(ctx, ID, version, data)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AddFavorite provides a mock function with given fields: ctx, userID, adID
+func (_m *App) AddFavorite(ctx context.Context, userID int64, adID int64) (*ads.Ad, error) {
+	ret := _m.Called(ctx, userID, adID)
+
+	var r0 *ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (*ads.Ad, error)); ok {
+		return rf(ctx, userID, adID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) *ads.Ad); ok {
+		r0 = rf(ctx, userID, adID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ads.Ad)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, userID, adID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// AdsByPattern provides a mock function with given fields: ctx, p, page
+func (_m *App) AdsByPattern(ctx context.Context, p *ads.Pattern, page ads.Page) ([]*ads.Ad, string, error) {
+	ret := _m.Called(ctx, p, page)
 
 	var r0 []*ads.Ad
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, *ads.Pattern, ads.Page) ([]*ads.Ad, string, error)); ok {
+		return rf(ctx, p, page)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *ads.Pattern, ads.Page) []*ads.Ad); ok {
+		r0 = rf(ctx, p, page)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*ads.Ad)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *ads.Pattern, ads.Page) string); ok {
+		r1 = rf(ctx, p, page)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, *ads.Pattern, ads.Page) error); ok {
+		r2 = rf(ctx, p, page)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Authenticate provides a mock function with given fields: ctx, accessToken
+func (_m *App) Authenticate(ctx context.Context, accessToken string) (int64, error) {
+	ret := _m.Called(ctx, accessToken)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int64, error)); ok {
+		return rf(ctx, accessToken)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int64); ok {
+		r0 = rf(ctx, accessToken)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, accessToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Categories provides a mock function with given fields: ctx
+func (_m *App) Categories(ctx context.Context) (*categories.Tree, error) {
+	ret := _m.Called(ctx)
+
+	var r0 *categories.Tree
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, *ads.Pattern) ([]*ads.Ad, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context) (*categories.Tree, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) *categories.Tree); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*categories.Tree)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CategoryCounts provides a mock function with given fields: ctx, p
+func (_m *App) CategoryCounts(ctx context.Context, p *ads.Pattern) ([]categories.Count, error) {
+	ret := _m.Called(ctx, p)
+
+	var r0 []categories.Count
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *ads.Pattern) ([]categories.Count, error)); ok {
 		return rf(ctx, p)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, *ads.Pattern) []*ads.Ad); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *ads.Pattern) []categories.Count); ok {
 		r0 = rf(ctx, p)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]*ads.Ad)
+			r0 = ret.Get(0).([]categories.Count)
 		}
 	}
 
@@ -69,17 +248,17 @@ func (_m *App) AdsByPattern(ctx context.Context, p *ads.Pattern) ([]*ads.Ad, err
 	return r0, r1
 }
 
-// ChangeAdStatus provides a mock function with given fields: ctx, ID, userID, published
-func (_m *App) ChangeAdStatus(ctx context.Context, ID int64, userID int64, published bool) (*ads.Ad, error) {
-	ret := _m.Called(ctx, ID, userID, published)
+// ChangeAdStatus provides a mock function with given fields: ctx, ID, version, published
+func (_m *App) ChangeAdStatus(ctx context.Context, ID int64, version int64, published bool) (*ads.Ad, error) {
+	ret := _m.Called(ctx, ID, version, published)
 
 	var r0 *ads.Ad
 	var r1 error
 	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, bool) (*ads.Ad, error)); ok {
-		return rf(ctx, ID, userID, published)
+		return rf(ctx, ID, version, published)
 	}
 	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, bool) *ads.Ad); ok {
-		r0 = rf(ctx, ID, userID, published)
+		r0 = rf(ctx, ID, version, published)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*ads.Ad)
@@ -87,7 +266,59 @@ func (_m *App) ChangeAdStatus(ctx context.Context, ID int64, userID int64, publi
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, bool) error); ok {
-		r1 = rf(ctx, ID, userID, published)
+		r1 = rf(ctx, ID, version, published)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChangeUserRole provides a mock function with given fields: ctx, ID, version, role
+func (_m *App) ChangeUserRole(ctx context.Context, ID int64, version int64, role users.Role) (*users.User, error) {
+	ret := _m.Called(ctx, ID, version, role)
+
+	var r0 *users.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, users.Role) (*users.User, error)); ok {
+		return rf(ctx, ID, version, role)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, users.Role) *users.User); ok {
+		r0 = rf(ctx, ID, version, role)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*users.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, users.Role) error); ok {
+		r1 = rf(ctx, ID, version, role)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ContactSeller provides a mock function with given fields: ctx, adID, text
+func (_m *App) ContactSeller(ctx context.Context, adID int64, text string) (*messages.Message, error) {
+	ret := _m.Called(ctx, adID, text)
+
+	var r0 *messages.Message
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) (*messages.Message, error)); ok {
+		return rf(ctx, adID, text)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) *messages.Message); ok {
+		r0 = rf(ctx, adID, text)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*messages.Message)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(ctx, adID, text)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -95,25 +326,51 @@ func (_m *App) ChangeAdStatus(ctx context.Context, ID int64, userID int64, publi
 	return r0, r1
 }
 
-// CreateAd provides a mock function with given fields: ctx, title, text, userID
-func (_m *App) CreateAd(ctx context.Context, title string, text string, userID int64) (*ads.Ad, error) {
-	ret := _m.Called(ctx, title, text, userID)
+// CreateAd provides a mock function with given fields: ctx, title, text, categoryID, price, location, ttl
+func (_m *App) CreateAd(ctx context.Context, title string, text string, categoryID int64, price ads.Price, location *ads.Location, ttl time.Duration) (*ads.Ad, error) {
+	ret := _m.Called(ctx, title, text, categoryID, price, location, ttl)
 
 	var r0 *ads.Ad
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64) (*ads.Ad, error)); ok {
-		return rf(ctx, title, text, userID)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64, ads.Price, *ads.Location, time.Duration) (*ads.Ad, error)); ok {
+		return rf(ctx, title, text, categoryID, price, location, ttl)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64) *ads.Ad); ok {
-		r0 = rf(ctx, title, text, userID)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64, ads.Price, *ads.Location, time.Duration) *ads.Ad); ok {
+		r0 = rf(ctx, title, text, categoryID, price, location, ttl)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*ads.Ad)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, int64) error); ok {
-		r1 = rf(ctx, title, text, userID)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int64, ads.Price, *ads.Location, time.Duration) error); ok {
+		r1 = rf(ctx, title, text, categoryID, price, location, ttl)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateCategory provides a mock function with given fields: ctx, name, parentID
+func (_m *App) CreateCategory(ctx context.Context, name string, parentID int64) (*categories.Category, error) {
+	ret := _m.Called(ctx, name, parentID)
+
+	var r0 *categories.Category
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) (*categories.Category, error)); ok {
+		return rf(ctx, name, parentID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) *categories.Category); ok {
+		r0 = rf(ctx, name, parentID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*categories.Category)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, int64) error); ok {
+		r1 = rf(ctx, name, parentID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -121,25 +378,77 @@ func (_m *App) CreateAd(ctx context.Context, title string, text string, userID i
 	return r0, r1
 }
 
-// CreateUser provides a mock function with given fields: ctx, nick, email
-func (_m *App) CreateUser(ctx context.Context, nick string, email string) (*users.User, error) {
-	ret := _m.Called(ctx, nick, email)
+// CreateSavedSearch provides a mock function with given fields: ctx, userID, name, q
+func (_m *App) CreateSavedSearch(ctx context.Context, userID int64, name string, q ads.Query) (*searches.Search, error) {
+	ret := _m.Called(ctx, userID, name, q)
+
+	var r0 *searches.Search
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, ads.Query) (*searches.Search, error)); ok {
+		return rf(ctx, userID, name, q)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, ads.Query) *searches.Search); ok {
+		r0 = rf(ctx, userID, name, q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*searches.Search)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string, ads.Query) error); ok {
+		r1 = rf(ctx, userID, name, q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateUser provides a mock function with given fields: ctx, nick, email, password
+func (_m *App) CreateUser(ctx context.Context, nick string, email string, password string) (*users.User, error) {
+	ret := _m.Called(ctx, nick, email, password)
 
 	var r0 *users.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*users.User, error)); ok {
-		return rf(ctx, nick, email)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (*users.User, error)); ok {
+		return rf(ctx, nick, email, password)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string, string) *users.User); ok {
-		r0 = rf(ctx, nick, email)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) *users.User); ok {
+		r0 = rf(ctx, nick, email, password)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*users.User)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
-		r1 = rf(ctx, nick, email)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, nick, email, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateWebhook provides a mock function with given fields: ctx, url, types
+func (_m *App) CreateWebhook(ctx context.Context, url string, types []events.Type) (*webhooks.Subscription, error) {
+	ret := _m.Called(ctx, url, types)
+
+	var r0 *webhooks.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []events.Type) (*webhooks.Subscription, error)); ok {
+		return rf(ctx, url, types)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, []events.Type) *webhooks.Subscription); ok {
+		r0 = rf(ctx, url, types)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*webhooks.Subscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, []events.Type) error); ok {
+		r1 = rf(ctx, url, types)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -147,25 +456,25 @@ func (_m *App) CreateUser(ctx context.Context, nick string, email string) (*user
 	return r0, r1
 }
 
-// DeleteAd provides a mock function with given fields: ctx, ID, userID
-func (_m *App) DeleteAd(ctx context.Context, ID int64, userID int64) (*ads.Ad, error) {
-	ret := _m.Called(ctx, ID, userID)
+// DeleteAd provides a mock function with given fields: ctx, ID
+func (_m *App) DeleteAd(ctx context.Context, ID int64) (*ads.Ad, error) {
+	ret := _m.Called(ctx, ID)
 
 	var r0 *ads.Ad
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (*ads.Ad, error)); ok {
-		return rf(ctx, ID, userID)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*ads.Ad, error)); ok {
+		return rf(ctx, ID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) *ads.Ad); ok {
-		r0 = rf(ctx, ID, userID)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *ads.Ad); ok {
+		r0 = rf(ctx, ID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*ads.Ad)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
-		r1 = rf(ctx, ID, userID)
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, ID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -173,20 +482,46 @@ func (_m *App) DeleteAd(ctx context.Context, ID int64, userID int64) (*ads.Ad, e
 	return r0, r1
 }
 
-// DeleteUser provides a mock function with given fields: ctx, ID
-func (_m *App) DeleteUser(ctx context.Context, ID int64) (*users.User, error) {
+// DeleteAdImage provides a mock function with given fields: ctx, ID, version, imageID
+func (_m *App) DeleteAdImage(ctx context.Context, ID int64, version int64, imageID string) (*ads.Ad, error) {
+	ret := _m.Called(ctx, ID, version, imageID)
+
+	var r0 *ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string) (*ads.Ad, error)); ok {
+		return rf(ctx, ID, version, imageID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string) *ads.Ad); ok {
+		r0 = rf(ctx, ID, version, imageID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ads.Ad)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, string) error); ok {
+		r1 = rf(ctx, ID, version, imageID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteCategory provides a mock function with given fields: ctx, ID
+func (_m *App) DeleteCategory(ctx context.Context, ID int64) (*categories.Category, error) {
 	ret := _m.Called(ctx, ID)
 
-	var r0 *users.User
+	var r0 *categories.Category
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64) (*users.User, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*categories.Category, error)); ok {
 		return rf(ctx, ID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64) *users.User); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *categories.Category); ok {
 		r0 = rf(ctx, ID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*users.User)
+			r0 = ret.Get(0).(*categories.Category)
 		}
 	}
 
@@ -199,25 +534,25 @@ func (_m *App) DeleteUser(ctx context.Context, ID int64) (*users.User, error) {
 	return r0, r1
 }
 
-// UpdateAd provides a mock function with given fields: ctx, ID, userID, title, text
-func (_m *App) UpdateAd(ctx context.Context, ID int64, userID int64, title string, text string) (*ads.Ad, error) {
-	ret := _m.Called(ctx, ID, userID, title, text)
+// DeleteFavorite provides a mock function with given fields: ctx, userID, adID
+func (_m *App) DeleteFavorite(ctx context.Context, userID int64, adID int64) (*ads.Ad, error) {
+	ret := _m.Called(ctx, userID, adID)
 
 	var r0 *ads.Ad
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, string) (*ads.Ad, error)); ok {
-		return rf(ctx, ID, userID, title, text)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (*ads.Ad, error)); ok {
+		return rf(ctx, userID, adID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, string) *ads.Ad); ok {
-		r0 = rf(ctx, ID, userID, title, text)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) *ads.Ad); ok {
+		r0 = rf(ctx, userID, adID)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*ads.Ad)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, string, string) error); ok {
-		r1 = rf(ctx, ID, userID, title, text)
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, userID, adID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -225,25 +560,25 @@ func (_m *App) UpdateAd(ctx context.Context, ID int64, userID int64, title strin
 	return r0, r1
 }
 
-// UpdateUser provides a mock function with given fields: ctx, ID, nick, email
-func (_m *App) UpdateUser(ctx context.Context, ID int64, nick string, email string) (*users.User, error) {
-	ret := _m.Called(ctx, ID, nick, email)
+// DeleteSavedSearch provides a mock function with given fields: ctx, userID, ID
+func (_m *App) DeleteSavedSearch(ctx context.Context, userID int64, ID int64) (*searches.Search, error) {
+	ret := _m.Called(ctx, userID, ID)
 
-	var r0 *users.User
+	var r0 *searches.Search
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string) (*users.User, error)); ok {
-		return rf(ctx, ID, nick, email)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (*searches.Search, error)); ok {
+		return rf(ctx, userID, ID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string) *users.User); ok {
-		r0 = rf(ctx, ID, nick, email)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) *searches.Search); ok {
+		r0 = rf(ctx, userID, ID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*users.User)
+			r0 = ret.Get(0).(*searches.Search)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, int64, string, string) error); ok {
-		r1 = rf(ctx, ID, nick, email)
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, userID, ID)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -251,8 +586,8 @@ func (_m *App) UpdateUser(ctx context.Context, ID int64, nick string, email stri
 	return r0, r1
 }
 
-// UserByID provides a mock function with given fields: ctx, ID
-func (_m *App) UserByID(ctx context.Context, ID int64) (*users.User, error) {
+// DeleteUser provides a mock function with given fields: ctx, ID
+func (_m *App) DeleteUser(ctx context.Context, ID int64) (*users.User, error) {
 	ret := _m.Called(ctx, ID)
 
 	var r0 *users.User
@@ -277,6 +612,600 @@ func (_m *App) UserByID(ctx context.Context, ID int64) (*users.User, error) {
 	return r0, r1
 }
 
+// DeleteWebhook provides a mock function with given fields: ctx, ID
+func (_m *App) DeleteWebhook(ctx context.Context, ID int64) (*webhooks.Subscription, error) {
+	ret := _m.Called(ctx, ID)
+
+	var r0 *webhooks.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*webhooks.Subscription, error)); ok {
+		return rf(ctx, ID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *webhooks.Subscription); ok {
+		r0 = rf(ctx, ID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*webhooks.Subscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, ID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExpireAds provides a mock function with given fields: ctx, now
+func (_m *App) ExpireAds(ctx context.Context, now time.Time) (int, error) {
+	ret := _m.Called(ctx, now)
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int, error)); ok {
+		return rf(ctx, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int); ok {
+		r0 = rf(ctx, now)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Favorites provides a mock function with given fields: ctx, userID
+func (_m *App) Favorites(ctx context.Context, userID int64) ([]*ads.Ad, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []*ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]*ads.Ad, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []*ads.Ad); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*ads.Ad)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Image provides a mock function with given fields: ctx, key
+func (_m *App) Image(ctx context.Context, key string) ([]byte, error) {
+	ret := _m.Called(ctx, key)
+
+	var r0 []byte
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]byte, error)); ok {
+		return rf(ctx, key)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []byte); ok {
+		r0 = rf(ctx, key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]byte)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, key)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Login provides a mock function with given fields: ctx, userID, password
+func (_m *App) Login(ctx context.Context, userID int64, password string) (auth.Tokens, error) {
+	ret := _m.Called(ctx, userID, password)
+
+	var r0 auth.Tokens
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) (auth.Tokens, error)); ok {
+		return rf(ctx, userID, password)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) auth.Tokens); ok {
+		r0 = rf(ctx, userID, password)
+	} else {
+		r0 = ret.Get(0).(auth.Tokens)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(ctx, userID, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Messages provides a mock function with given fields: ctx, threadID, page
+func (_m *App) Messages(ctx context.Context, threadID int64, page messages.Page) ([]*messages.Message, string, error) {
+	ret := _m.Called(ctx, threadID, page)
+
+	var r0 []*messages.Message
+	var r1 string
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, messages.Page) ([]*messages.Message, string, error)); ok {
+		return rf(ctx, threadID, page)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, messages.Page) []*messages.Message); ok {
+		r0 = rf(ctx, threadID, page)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*messages.Message)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, messages.Page) string); ok {
+		r1 = rf(ctx, threadID, page)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, int64, messages.Page) error); ok {
+		r2 = rf(ctx, threadID, page)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// Notifications provides a mock function with given fields: ctx, userID
+func (_m *App) Notifications(ctx context.Context, userID int64) ([]*searches.Notification, int, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []*searches.Notification
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]*searches.Notification, int, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []*searches.Notification); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*searches.Notification)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) int); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, int64) error); ok {
+		r2 = rf(ctx, userID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// ReadNotifications provides a mock function with given fields: ctx, userID, upTo
+func (_m *App) ReadNotifications(ctx context.Context, userID int64, upTo int64) error {
+	ret := _m.Called(ctx, userID, upTo)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, userID, upTo)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Refresh provides a mock function with given fields: ctx, refreshToken
+func (_m *App) Refresh(ctx context.Context, refreshToken string) (auth.Tokens, error) {
+	ret := _m.Called(ctx, refreshToken)
+
+	var r0 auth.Tokens
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (auth.Tokens, error)); ok {
+		return rf(ctx, refreshToken)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) auth.Tokens); ok {
+		r0 = rf(ctx, refreshToken)
+	} else {
+		r0 = ret.Get(0).(auth.Tokens)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, refreshToken)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RenewAd provides a mock function with given fields: ctx, ID, version, ttl
+func (_m *App) RenewAd(ctx context.Context, ID int64, version int64, ttl time.Duration) (*ads.Ad, error) {
+	ret := _m.Called(ctx, ID, version, ttl)
+
+	var r0 *ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, time.Duration) (*ads.Ad, error)); ok {
+		return rf(ctx, ID, version, ttl)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, time.Duration) *ads.Ad); ok {
+		r0 = rf(ctx, ID, version, ttl)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ads.Ad)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, time.Duration) error); ok {
+		r1 = rf(ctx, ID, version, ttl)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RevertAd provides a mock function with given fields: ctx, ID, version, revision
+func (_m *App) RevertAd(ctx context.Context, ID int64, version int64, revision int64) (*ads.Ad, error) {
+	ret := _m.Called(ctx, ID, version, revision)
+
+	var r0 *ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) (*ads.Ad, error)); ok {
+		return rf(ctx, ID, version, revision)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) *ads.Ad); ok {
+		r0 = rf(ctx, ID, version, revision)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ads.Ad)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, int64) error); ok {
+		r1 = rf(ctx, ID, version, revision)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SavedSearches provides a mock function with given fields: ctx, userID
+func (_m *App) SavedSearches(ctx context.Context, userID int64) ([]*searches.Search, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []*searches.Search
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]*searches.Search, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []*searches.Search); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*searches.Search)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SendMessage provides a mock function with given fields: ctx, threadID, text
+func (_m *App) SendMessage(ctx context.Context, threadID int64, text string) (*messages.Message, error) {
+	ret := _m.Called(ctx, threadID, text)
+
+	var r0 *messages.Message
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) (*messages.Message, error)); ok {
+		return rf(ctx, threadID, text)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) *messages.Message); ok {
+		r0 = rf(ctx, threadID, text)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*messages.Message)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string) error); ok {
+		r1 = rf(ctx, threadID, text)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Threads provides a mock function with given fields: ctx, userID
+func (_m *App) Threads(ctx context.Context, userID int64) ([]*messages.Thread, error) {
+	ret := _m.Called(ctx, userID)
+
+	var r0 []*messages.Thread
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]*messages.Thread, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []*messages.Thread); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*messages.Thread)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TransitionAd provides a mock function with given fields: ctx, ID, version, event, reason
+func (_m *App) TransitionAd(ctx context.Context, ID int64, version int64, event ads.Event, reason string) (*ads.Ad, error) {
+	ret := _m.Called(ctx, ID, version, event, reason)
+
+	var r0 *ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, ads.Event, string) (*ads.Ad, error)); ok {
+		return rf(ctx, ID, version, event, reason)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, ads.Event, string) *ads.Ad); ok {
+		r0 = rf(ctx, ID, version, event, reason)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ads.Ad)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, ads.Event, string) error); ok {
+		r1 = rf(ctx, ID, version, event, reason)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateAd provides a mock function with given fields: ctx, ID, version, title, text, price, location, clearLocation
+func (_m *App) UpdateAd(ctx context.Context, ID int64, version int64, title string, text string, price *ads.Price, location *ads.Location, clearLocation bool) (*ads.Ad, error) {
+	ret := _m.Called(ctx, ID, version, title, text, price, location, clearLocation)
+
+	var r0 *ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, string, *ads.Price, *ads.Location, bool) (*ads.Ad, error)); ok {
+		return rf(ctx, ID, version, title, text, price, location, clearLocation)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, string, *ads.Price, *ads.Location, bool) *ads.Ad); ok {
+		r0 = rf(ctx, ID, version, title, text, price, location, clearLocation)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ads.Ad)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, string, string, *ads.Price, *ads.Location, bool) error); ok {
+		r1 = rf(ctx, ID, version, title, text, price, location, clearLocation)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateCategory provides a mock function with given fields: ctx, ID, version, name, parentID
+func (_m *App) UpdateCategory(ctx context.Context, ID int64, version int64, name string, parentID int64) (*categories.Category, error) {
+	ret := _m.Called(ctx, ID, version, name, parentID)
+
+	var r0 *categories.Category
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, int64) (*categories.Category, error)); ok {
+		return rf(ctx, ID, version, name, parentID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, int64) *categories.Category); ok {
+		r0 = rf(ctx, ID, version, name, parentID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*categories.Category)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, string, int64) error); ok {
+		r1 = rf(ctx, ID, version, name, parentID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateSavedSearch provides a mock function with given fields: ctx, userID, ID, name, q
+func (_m *App) UpdateSavedSearch(ctx context.Context, userID int64, ID int64, name string, q ads.Query) (*searches.Search, error) {
+	ret := _m.Called(ctx, userID, ID, name, q)
+
+	var r0 *searches.Search
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, ads.Query) (*searches.Search, error)); ok {
+		return rf(ctx, userID, ID, name, q)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, ads.Query) *searches.Search); ok {
+		r0 = rf(ctx, userID, ID, name, q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*searches.Search)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, string, ads.Query) error); ok {
+		r1 = rf(ctx, userID, ID, name, q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateUser provides a mock function with given fields: ctx, ID, version, nick, email
+func (_m *App) UpdateUser(ctx context.Context, ID int64, version int64, nick string, email string) (*users.User, error) {
+	ret := _m.Called(ctx, ID, version, nick, email)
+
+	var r0 *users.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, string) (*users.User, error)); ok {
+		return rf(ctx, ID, version, nick, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, string) *users.User); ok {
+		r0 = rf(ctx, ID, version, nick, email)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*users.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, string, string) error); ok {
+		r1 = rf(ctx, ID, version, nick, email)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UserByID provides a mock function with given fields: ctx, ID
+func (_m *App) UserByID(ctx context.Context, ID int64) (*users.User, error) {
+	ret := _m.Called(ctx, ID)
+
+	var r0 *users.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*users.User, error)); ok {
+		return rf(ctx, ID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *users.User); ok {
+		r0 = rf(ctx, ID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*users.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, ID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WatchAds provides a mock function with given fields: ctx, p
+func (_m *App) WatchAds(ctx context.Context, p *ads.Pattern) (*feed.Subscription, error) {
+	ret := _m.Called(ctx, p)
+
+	var r0 *feed.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *ads.Pattern) (*feed.Subscription, error)); ok {
+		return rf(ctx, p)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *ads.Pattern) *feed.Subscription); ok {
+		r0 = rf(ctx, p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*feed.Subscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *ads.Pattern) error); ok {
+		r1 = rf(ctx, p)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WebhookDeliveries provides a mock function with given fields: ctx, ID
+func (_m *App) WebhookDeliveries(ctx context.Context, ID int64) ([]*webhooks.Delivery, error) {
+	ret := _m.Called(ctx, ID)
+
+	var r0 []*webhooks.Delivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]*webhooks.Delivery, error)); ok {
+		return rf(ctx, ID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []*webhooks.Delivery); ok {
+		r0 = rf(ctx, ID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*webhooks.Delivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, ID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Webhooks provides a mock function with given fields: ctx
+func (_m *App) Webhooks(ctx context.Context) ([]*webhooks.Subscription, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []*webhooks.Subscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]*webhooks.Subscription, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []*webhooks.Subscription); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*webhooks.Subscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 type mockConstructorTestingTNewApp interface {
 	mock.TestingT
 	Cleanup(func())
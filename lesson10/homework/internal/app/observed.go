@@ -0,0 +1,347 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"homework10/internal/ads"
+	"homework10/internal/audit"
+	"homework10/internal/auth"
+	"homework10/internal/categories"
+	"homework10/internal/events"
+	"homework10/internal/feed"
+	"homework10/internal/messages"
+	"homework10/internal/searches"
+	"homework10/internal/users"
+	"homework10/internal/webhooks"
+)
+
+// Observer вызывается в начале каждого вызова метода method приложения. Он возвращает контекст, с которым
+// выполняется вызов, и функцию, которую вызывают с результатом вызова после его завершения
+type Observer func(ctx context.Context, method string) (context.Context, func(err error))
+
+// observed - приложение, о каждом вызове которого сообщается наблюдателю
+type observed struct {
+	app     App
+	observe Observer
+}
+
+// Observe возвращает приложение, которое выполняет вызовы a, сообщая о каждом observe, - например, чтобы считать ошибки
+func Observe(a App, observe Observer) App {
+	return &observed{app: a, observe: observe}
+}
+
+func (o *observed) CreateAd(ctx context.Context, title, text string, categoryID int64, price ads.Price, location *ads.Location, ttl time.Duration) (*ads.Ad, error) {
+	ctx, done := o.observe(ctx, "CreateAd")
+	res, err := o.app.CreateAd(ctx, title, text, categoryID, price, location, ttl)
+	done(err)
+	return res, err
+}
+
+func (o *observed) AdByID(ctx context.Context, ID int64) (*ads.Ad, error) {
+	ctx, done := o.observe(ctx, "AdByID")
+	res, err := o.app.AdByID(ctx, ID)
+	done(err)
+	return res, err
+}
+
+func (o *observed) AdsByPattern(ctx context.Context, p *ads.Pattern, page ads.Page) ([]*ads.Ad, string, error) {
+	ctx, done := o.observe(ctx, "AdsByPattern")
+	res1, res2, err := o.app.AdsByPattern(ctx, p, page)
+	done(err)
+	return res1, res2, err
+}
+
+func (o *observed) WatchAds(ctx context.Context, p *ads.Pattern) (*feed.Subscription, error) {
+	ctx, done := o.observe(ctx, "WatchAds")
+	res, err := o.app.WatchAds(ctx, p)
+	done(err)
+	return res, err
+}
+
+func (o *observed) CategoryCounts(ctx context.Context, p *ads.Pattern) ([]categories.Count, error) {
+	ctx, done := o.observe(ctx, "CategoryCounts")
+	res, err := o.app.CategoryCounts(ctx, p)
+	done(err)
+	return res, err
+}
+
+func (o *observed) UpdateAd(ctx context.Context, ID, version int64, title, text string, price *ads.Price, location *ads.Location, clearLocation bool) (*ads.Ad, error) {
+	ctx, done := o.observe(ctx, "UpdateAd")
+	res, err := o.app.UpdateAd(ctx, ID, version, title, text, price, location, clearLocation)
+	done(err)
+	return res, err
+}
+
+func (o *observed) ChangeAdStatus(ctx context.Context, ID, version int64, published bool) (*ads.Ad, error) {
+	ctx, done := o.observe(ctx, "ChangeAdStatus")
+	res, err := o.app.ChangeAdStatus(ctx, ID, version, published)
+	done(err)
+	return res, err
+}
+
+func (o *observed) TransitionAd(ctx context.Context, ID, version int64, event ads.Event, reason string) (*ads.Ad, error) {
+	ctx, done := o.observe(ctx, "TransitionAd")
+	res, err := o.app.TransitionAd(ctx, ID, version, event, reason)
+	done(err)
+	return res, err
+}
+
+func (o *observed) RenewAd(ctx context.Context, ID, version int64, ttl time.Duration) (*ads.Ad, error) {
+	ctx, done := o.observe(ctx, "RenewAd")
+	res, err := o.app.RenewAd(ctx, ID, version, ttl)
+	done(err)
+	return res, err
+}
+
+func (o *observed) AddAdImage(ctx context.Context, ID, version int64, data []byte) (*ads.Ad, error) {
+	ctx, done := o.observe(ctx, "AddAdImage")
+	res, err := o.app.AddAdImage(ctx, ID, version, data)
+	done(err)
+	return res, err
+}
+
+func (o *observed) DeleteAdImage(ctx context.Context, ID, version int64, imageID string) (*ads.Ad, error) {
+	ctx, done := o.observe(ctx, "DeleteAdImage")
+	res, err := o.app.DeleteAdImage(ctx, ID, version, imageID)
+	done(err)
+	return res, err
+}
+
+func (o *observed) Image(ctx context.Context, key string) ([]byte, error) {
+	ctx, done := o.observe(ctx, "Image")
+	res, err := o.app.Image(ctx, key)
+	done(err)
+	return res, err
+}
+
+func (o *observed) ExpireAds(ctx context.Context, now time.Time) (int, error) {
+	ctx, done := o.observe(ctx, "ExpireAds")
+	res, err := o.app.ExpireAds(ctx, now)
+	done(err)
+	return res, err
+}
+
+func (o *observed) DeleteAd(ctx context.Context, ID int64) (*ads.Ad, error) {
+	ctx, done := o.observe(ctx, "DeleteAd")
+	res, err := o.app.DeleteAd(ctx, ID)
+	done(err)
+	return res, err
+}
+
+func (o *observed) AdHistory(ctx context.Context, ID int64) ([]*audit.Entry, error) {
+	ctx, done := o.observe(ctx, "AdHistory")
+	res, err := o.app.AdHistory(ctx, ID)
+	done(err)
+	return res, err
+}
+
+func (o *observed) RevertAd(ctx context.Context, ID, version, revision int64) (*ads.Ad, error) {
+	ctx, done := o.observe(ctx, "RevertAd")
+	res, err := o.app.RevertAd(ctx, ID, version, revision)
+	done(err)
+	return res, err
+}
+
+func (o *observed) Categories(ctx context.Context) (*categories.Tree, error) {
+	ctx, done := o.observe(ctx, "Categories")
+	res, err := o.app.Categories(ctx)
+	done(err)
+	return res, err
+}
+
+func (o *observed) CreateCategory(ctx context.Context, name string, parentID int64) (*categories.Category, error) {
+	ctx, done := o.observe(ctx, "CreateCategory")
+	res, err := o.app.CreateCategory(ctx, name, parentID)
+	done(err)
+	return res, err
+}
+
+func (o *observed) UpdateCategory(ctx context.Context, ID, version int64, name string, parentID int64) (*categories.Category, error) {
+	ctx, done := o.observe(ctx, "UpdateCategory")
+	res, err := o.app.UpdateCategory(ctx, ID, version, name, parentID)
+	done(err)
+	return res, err
+}
+
+func (o *observed) DeleteCategory(ctx context.Context, ID int64) (*categories.Category, error) {
+	ctx, done := o.observe(ctx, "DeleteCategory")
+	res, err := o.app.DeleteCategory(ctx, ID)
+	done(err)
+	return res, err
+}
+
+func (o *observed) Favorites(ctx context.Context, userID int64) ([]*ads.Ad, error) {
+	ctx, done := o.observe(ctx, "Favorites")
+	res, err := o.app.Favorites(ctx, userID)
+	done(err)
+	return res, err
+}
+
+func (o *observed) AddFavorite(ctx context.Context, userID, adID int64) (*ads.Ad, error) {
+	ctx, done := o.observe(ctx, "AddFavorite")
+	res, err := o.app.AddFavorite(ctx, userID, adID)
+	done(err)
+	return res, err
+}
+
+func (o *observed) DeleteFavorite(ctx context.Context, userID, adID int64) (*ads.Ad, error) {
+	ctx, done := o.observe(ctx, "DeleteFavorite")
+	res, err := o.app.DeleteFavorite(ctx, userID, adID)
+	done(err)
+	return res, err
+}
+
+func (o *observed) CreateWebhook(ctx context.Context, url string, types []events.Type) (*webhooks.Subscription, error) {
+	ctx, done := o.observe(ctx, "CreateWebhook")
+	res, err := o.app.CreateWebhook(ctx, url, types)
+	done(err)
+	return res, err
+}
+
+func (o *observed) Webhooks(ctx context.Context) ([]*webhooks.Subscription, error) {
+	ctx, done := o.observe(ctx, "Webhooks")
+	res, err := o.app.Webhooks(ctx)
+	done(err)
+	return res, err
+}
+
+func (o *observed) DeleteWebhook(ctx context.Context, ID int64) (*webhooks.Subscription, error) {
+	ctx, done := o.observe(ctx, "DeleteWebhook")
+	res, err := o.app.DeleteWebhook(ctx, ID)
+	done(err)
+	return res, err
+}
+
+func (o *observed) WebhookDeliveries(ctx context.Context, ID int64) ([]*webhooks.Delivery, error) {
+	ctx, done := o.observe(ctx, "WebhookDeliveries")
+	res, err := o.app.WebhookDeliveries(ctx, ID)
+	done(err)
+	return res, err
+}
+
+func (o *observed) ContactSeller(ctx context.Context, adID int64, text string) (*messages.Message, error) {
+	ctx, done := o.observe(ctx, "ContactSeller")
+	res, err := o.app.ContactSeller(ctx, adID, text)
+	done(err)
+	return res, err
+}
+
+func (o *observed) SendMessage(ctx context.Context, threadID int64, text string) (*messages.Message, error) {
+	ctx, done := o.observe(ctx, "SendMessage")
+	res, err := o.app.SendMessage(ctx, threadID, text)
+	done(err)
+	return res, err
+}
+
+func (o *observed) Threads(ctx context.Context, userID int64) ([]*messages.Thread, error) {
+	ctx, done := o.observe(ctx, "Threads")
+	res, err := o.app.Threads(ctx, userID)
+	done(err)
+	return res, err
+}
+
+func (o *observed) Messages(ctx context.Context, threadID int64, page messages.Page) ([]*messages.Message, string, error) {
+	ctx, done := o.observe(ctx, "Messages")
+	res1, res2, err := o.app.Messages(ctx, threadID, page)
+	done(err)
+	return res1, res2, err
+}
+
+func (o *observed) SavedSearches(ctx context.Context, userID int64) ([]*searches.Search, error) {
+	ctx, done := o.observe(ctx, "SavedSearches")
+	res, err := o.app.SavedSearches(ctx, userID)
+	done(err)
+	return res, err
+}
+
+func (o *observed) CreateSavedSearch(ctx context.Context, userID int64, name string, q ads.Query) (*searches.Search, error) {
+	ctx, done := o.observe(ctx, "CreateSavedSearch")
+	res, err := o.app.CreateSavedSearch(ctx, userID, name, q)
+	done(err)
+	return res, err
+}
+
+func (o *observed) UpdateSavedSearch(ctx context.Context, userID, ID int64, name string, q ads.Query) (*searches.Search, error) {
+	ctx, done := o.observe(ctx, "UpdateSavedSearch")
+	res, err := o.app.UpdateSavedSearch(ctx, userID, ID, name, q)
+	done(err)
+	return res, err
+}
+
+func (o *observed) DeleteSavedSearch(ctx context.Context, userID, ID int64) (*searches.Search, error) {
+	ctx, done := o.observe(ctx, "DeleteSavedSearch")
+	res, err := o.app.DeleteSavedSearch(ctx, userID, ID)
+	done(err)
+	return res, err
+}
+
+func (o *observed) Notifications(ctx context.Context, userID int64) ([]*searches.Notification, int, error) {
+	ctx, done := o.observe(ctx, "Notifications")
+	res1, res2, err := o.app.Notifications(ctx, userID)
+	done(err)
+	return res1, res2, err
+}
+
+func (o *observed) ReadNotifications(ctx context.Context, userID, upTo int64) error {
+	ctx, done := o.observe(ctx, "ReadNotifications")
+	err := o.app.ReadNotifications(ctx, userID, upTo)
+	done(err)
+	return err
+}
+
+func (o *observed) CreateUser(ctx context.Context, nick, email, password string) (*users.User, error) {
+	ctx, done := o.observe(ctx, "CreateUser")
+	res, err := o.app.CreateUser(ctx, nick, email, password)
+	done(err)
+	return res, err
+}
+
+func (o *observed) UserByID(ctx context.Context, ID int64) (*users.User, error) {
+	ctx, done := o.observe(ctx, "UserByID")
+	res, err := o.app.UserByID(ctx, ID)
+	done(err)
+	return res, err
+}
+
+func (o *observed) UpdateUser(ctx context.Context, ID, version int64, nick, email string) (*users.User, error) {
+	ctx, done := o.observe(ctx, "UpdateUser")
+	res, err := o.app.UpdateUser(ctx, ID, version, nick, email)
+	done(err)
+	return res, err
+}
+
+func (o *observed) DeleteUser(ctx context.Context, ID int64) (*users.User, error) {
+	ctx, done := o.observe(ctx, "DeleteUser")
+	res, err := o.app.DeleteUser(ctx, ID)
+	done(err)
+	return res, err
+}
+
+func (o *observed) ChangeUserRole(ctx context.Context, ID, version int64, role users.Role) (*users.User, error) {
+	ctx, done := o.observe(ctx, "ChangeUserRole")
+	res, err := o.app.ChangeUserRole(ctx, ID, version, role)
+	done(err)
+	return res, err
+}
+
+func (o *observed) Login(ctx context.Context, userID int64, password string) (auth.Tokens, error) {
+	ctx, done := o.observe(ctx, "Login")
+	res, err := o.app.Login(ctx, userID, password)
+	done(err)
+	return res, err
+}
+
+func (o *observed) Refresh(ctx context.Context, refreshToken string) (auth.Tokens, error) {
+	ctx, done := o.observe(ctx, "Refresh")
+	res, err := o.app.Refresh(ctx, refreshToken)
+	done(err)
+	return res, err
+}
+
+func (o *observed) Authenticate(ctx context.Context, accessToken string) (int64, error) {
+	ctx, done := o.observe(ctx, "Authenticate")
+	res, err := o.app.Authenticate(ctx, accessToken)
+	done(err)
+	return res, err
+}
@@ -0,0 +1,232 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"homework10/internal/adapters/searchrepo"
+	"homework10/internal/ads"
+	"homework10/internal/categories"
+	"homework10/internal/events"
+	"homework10/internal/policy"
+	"homework10/internal/searches"
+
+	"github.com/newRational/vld"
+)
+
+// SavedSearches возвращает сохранённые поиски пользователя в порядке создания
+func (a *AdApp) SavedSearches(ctx context.Context, userID int64) ([]*searches.Search, error) {
+	if err := a.authorizeSearches(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	list, err := a.searchRepo.Searches(ctx, userID)
+	if err != nil {
+		return nil, ErrInternalSearchRepoError
+	}
+
+	return list, nil
+}
+
+// CreateSavedSearch сохраняет поиск пользователя по условиям q: о новых и только что опубликованных
+// подходящих под них объявлениях других пользователей он будет получать уведомления
+func (a *AdApp) CreateSavedSearch(ctx context.Context, userID int64, name string, q ads.Query) (*searches.Search, error) {
+	if err := a.authorizeSearches(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	s := &searches.Search{
+		ID:      -1,
+		UserID:  userID,
+		Name:    name,
+		Query:   q.Copy(),
+		Created: now,
+		Updated: now,
+	}
+	if err := a.checkSearch(ctx, s); err != nil {
+		return nil, err
+	}
+
+	list, err := a.searchRepo.Searches(ctx, userID)
+	if err != nil {
+		return nil, ErrInternalSearchRepoError
+	}
+	if len(list) >= MaxSearches {
+		return nil, fmt.Errorf("%w: too many saved searches", ErrBadRequest)
+	}
+
+	if _, err = a.searchRepo.AddSearch(ctx, s); err != nil {
+		return nil, ErrInternalSearchRepoError
+	}
+
+	return s, nil
+}
+
+// UpdateSavedSearch заменяет название и условия сохранённого поиска; уже полученные уведомления остаются
+func (a *AdApp) UpdateSavedSearch(ctx context.Context, userID, ID int64, name string, q ads.Query) (*searches.Search, error) {
+	s, err := a.savedSearch(ctx, userID, ID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Name = name
+	s.Query = q.Copy()
+	s.Updated = time.Now().UTC()
+	if err = a.checkSearch(ctx, s); err != nil {
+		return nil, err
+	}
+
+	err = a.searchRepo.UpdateSearch(ctx, s)
+	if errors.Is(err, searchrepo.ErrNoSearch) {
+		return nil, ErrBadRequest
+	} else if err != nil {
+		return nil, ErrInternalSearchRepoError
+	}
+
+	return s, nil
+}
+
+// DeleteSavedSearch удаляет сохранённый поиск; уже полученные по нему уведомления остаются
+func (a *AdApp) DeleteSavedSearch(ctx context.Context, userID, ID int64) (*searches.Search, error) {
+	s, err := a.savedSearch(ctx, userID, ID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.searchRepo.DeleteSearch(ctx, ID)
+	if errors.Is(err, searchrepo.ErrNoSearch) {
+		return nil, ErrBadRequest
+	} else if err != nil {
+		return nil, ErrInternalSearchRepoError
+	}
+
+	return s, nil
+}
+
+// Notifications возвращает последние MaxNotifications уведомлений пользователя, начиная с самых новых,
+// и сколько всего у него непрочитанных уведомлений
+func (a *AdApp) Notifications(ctx context.Context, userID int64) ([]*searches.Notification, int, error) {
+	if err := a.authorizeSearches(ctx, userID); err != nil {
+		return nil, 0, err
+	}
+
+	list, err := a.searchRepo.Notifications(ctx, userID, MaxNotifications)
+	if err != nil {
+		return nil, 0, ErrInternalSearchRepoError
+	}
+
+	unread, err := a.searchRepo.Unread(ctx, userID)
+	if err != nil {
+		return nil, 0, ErrInternalSearchRepoError
+	}
+
+	return list, unread, nil
+}
+
+// ReadNotifications отмечает прочитанными уведомления пользователя с ID не больше upTo
+func (a *AdApp) ReadNotifications(ctx context.Context, userID, upTo int64) error {
+	if err := a.authorizeSearches(ctx, userID); err != nil {
+		return err
+	}
+
+	if upTo <= 0 {
+		return ErrBadRequest
+	}
+
+	if err := a.searchRepo.MarkRead(ctx, userID, upTo); err != nil {
+		return ErrInternalSearchRepoError
+	}
+
+	return nil
+}
+
+// authorizeSearches проверяет, что отправитель запроса может работать с сохранёнными поисками пользователя userID
+func (a *AdApp) authorizeSearches(ctx context.Context, userID int64) error {
+	actor, err := a.actingUser(ctx)
+	if err != nil {
+		return err
+	}
+
+	return authorize(actor, policy.ManageSearches, userID)
+}
+
+// savedSearch возвращает сохранённый поиск ID пользователя userID; чужой поиск не отличается от несуществующего
+func (a *AdApp) savedSearch(ctx context.Context, userID, ID int64) (*searches.Search, error) {
+	if err := a.authorizeSearches(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	s, err := a.searchRepo.SearchByID(ctx, ID)
+	if errors.Is(err, searchrepo.ErrNoSearch) {
+		return nil, ErrBadRequest
+	} else if err != nil {
+		return nil, ErrInternalSearchRepoError
+	}
+	if s.UserID != userID {
+		return nil, ErrBadRequest
+	}
+
+	return s, nil
+}
+
+// checkSearch проверяет название поиска и его условия так же, как при выборке объявлений
+func (a *AdApp) checkSearch(ctx context.Context, s *searches.Search) error {
+	if err := vld.Validate(*s); err != nil {
+		return ErrBadRequest
+	}
+
+	p, err := s.Query.Pattern()
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBadRequest, err.Error())
+	}
+
+	_, err = a.expandCategory(ctx, p)
+	return err
+}
+
+// notifySearches уведомляет владельцев подходящих сохранённых поисков о созданном или опубликованном объявлении;
+// автор о своём объявлении не уведомляется. Как и в record, ошибки только пишутся в лог
+func (a *AdApp) notifySearches(ctx context.Context, ev *events.Event) {
+	if ev.Type != events.AdCreated && ev.Type != events.AdPublished {
+		return
+	}
+
+	// дерево категорий нужно, только если объявлению подходят поиски в какой-то категории
+	var tree *categories.Tree
+	var treeErr error
+	within := func(category int64) bool {
+		if tree == nil && treeErr == nil {
+			tree, treeErr = a.Categories(ctx)
+		}
+		return treeErr == nil && tree.Within(ev.Ad.CategoryID, category)
+	}
+
+	list, err := a.searchRepo.Matching(ctx, ev.Ad, within)
+	if err != nil {
+		a.logger.ErrorContext(ctx, "can't match ad against saved searches", "ad_id", ev.Ad.ID, "err", err)
+		return
+	}
+	if treeErr != nil {
+		a.logger.ErrorContext(ctx, "can't match ad against saved searches in categories", "ad_id", ev.Ad.ID, "err", treeErr)
+	}
+
+	for _, s := range list {
+		if s.UserID == ev.Ad.UserID {
+			continue
+		}
+
+		_, err = a.searchRepo.AddNotification(ctx, &searches.Notification{
+			UserID:   s.UserID,
+			SearchID: s.ID,
+			AdID:     ev.Ad.ID,
+			Title:    ev.Ad.Title,
+			Created:  ev.At,
+		})
+		if err != nil && !errors.Is(err, searchrepo.ErrAlreadyNotified) {
+			a.logger.ErrorContext(ctx, "can't notify user about ad", "notified_user_id", s.UserID, "ad_id", ev.Ad.ID, "err", err)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"context"
+	"errors"
+
+	"homework10/internal/adapters/search"
+	"homework10/internal/ads"
+	"homework10/internal/events"
+	"homework10/internal/feed"
+)
+
+// WatchAds подписывает на изменения подходящих под шаблон опубликованных объявлений: создание, правки, смены статуса
+// и удаление (удалённое объявление проверяется в состоянии до удаления). Лента доступна без аутентификации,
+// поэтому в неё попадают только события объявлений, опубликованных до или после события (см. publicEvent).
+// Подписка получает только события, случившиеся после её создания; отстающий подписчик отключается (см. feed.ErrSlowConsumer).
+// Подкатегории шаблона определяются в момент подписки, а количество добавлений в избранное в событиях не заполняется
+func (a *AdApp) WatchAds(ctx context.Context, p *ads.Pattern) (*feed.Subscription, error) {
+	if p == nil {
+		p = ads.DefaultPattern()
+	}
+
+	p, err := a.expandCategory(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	terms := make(map[string]struct{})
+	for _, term := range search.Tokenize(p.Query) {
+		terms[term] = struct{}{}
+	}
+
+	sub, err := a.feed.Subscribe(func(e *events.Event) bool {
+		return e.Ad != nil && publicEvent(e) && p.Fits(e.Ad) && matchesQuery(terms, e.Ad)
+	})
+	if errors.Is(err, feed.ErrClosed) {
+		return nil, ErrUnavailable
+	} else if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// publicEvent проверяет, что событие касается опубликованного объявления: оно опубликовано после события
+// или было опубликовано до него (снятие с публикации, отклонение, архивирование). Черновики, объявления
+// на модерации и отклонённые видят только автор и модераторы
+func publicEvent(e *events.Event) bool {
+	if e.Ad.Published() {
+		return true
+	}
+	if e.Type != events.AdStatusChanged {
+		return false
+	}
+	// статус меняется только переходом, поэтому последний переход и есть это событие
+	t := e.Ad.LastTransition()
+	return t != nil && t.From == ads.StatusPublished
+}
+
+// matchesQuery проверяет, что в объявлении есть хотя бы одно слово запроса, как и при поиске в репозитории;
+// пустой запрос подходит под любое объявление
+func matchesQuery(terms map[string]struct{}, ad *ads.Ad) bool {
+	if len(terms) == 0 {
+		return true
+	}
+
+	for _, text := range []string{ad.Title, ad.Text} {
+		for _, term := range search.Tokenize(text) {
+			if _, ok := terms[term]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
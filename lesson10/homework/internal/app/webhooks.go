@@ -0,0 +1,161 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"homework10/internal/adapters/hookrepo"
+	"homework10/internal/audit"
+	"homework10/internal/events"
+	"homework10/internal/policy"
+	"homework10/internal/webhooks"
+
+	"github.com/newRational/vld"
+)
+
+// MaxWebhookDeliveries - сколько последних доставок подписки показывает журнал
+const MaxWebhookDeliveries = 100
+
+// eventOf возвращает событие, о котором сообщает изменение из журнала аудита; nil - изменение не публикуется
+func eventOf(e *audit.Entry) *events.Event {
+	ev := &events.Event{ObjectID: e.ObjectID, ActorID: e.ActorID, At: e.At}
+	switch e.Kind {
+	case audit.KindAd:
+		ev.Ad = e.After.Copy().Ad
+		switch {
+		case e.Op == audit.OpCreate:
+			ev.Type = events.AdCreated
+		case e.Op == audit.OpDelete:
+			ev.Type = events.AdDeleted
+			ev.Ad = e.Before.Copy().Ad
+		case e.Before.Ad != nil && e.After.Ad != nil && e.Before.Ad.Status != e.After.Ad.Status:
+			ev.Type = events.AdStatusChanged
+			if e.After.Ad.Published() {
+				ev.Type = events.AdPublished
+			}
+		default:
+			ev.Type = events.AdUpdated
+		}
+	case audit.KindUser:
+		ev.User = e.After.Copy().User
+		switch e.Op {
+		case audit.OpCreate:
+			ev.Type = events.UserCreated
+		case audit.OpDelete:
+			ev.Type = events.UserDeleted
+			ev.User = e.Before.Copy().User
+		default:
+			ev.Type = events.UserUpdated
+		}
+	default:
+		return nil
+	}
+
+	return ev
+}
+
+// CreateWebhook подписывает url на события types (пустой - на все); это может делать только администратор.
+// Секрет для проверки подписи запросов создаётся сервисом и возвращается вместе с подпиской
+func (a *AdApp) CreateWebhook(ctx context.Context, url string, types []events.Type) (*webhooks.Subscription, error) {
+	actor, err := a.authorizeWebhooks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range types {
+		if !t.Valid() {
+			return nil, ErrBadRequest
+		}
+	}
+
+	s := &webhooks.Subscription{
+		ID:      -1,
+		URL:     url,
+		Types:   types,
+		OwnerID: actor,
+		Created: time.Now().UTC(),
+	}
+	if err = vld.Validate(*s); err != nil || !webhooks.ValidURL(url) {
+		return nil, ErrBadRequest
+	}
+
+	s.Secret, err = webhooks.NewSecret()
+	if err != nil {
+		return nil, ErrInternalHookRepoError
+	}
+
+	ID, err := a.hookRepo.AddSubscription(ctx, s)
+	if err != nil {
+		return nil, ErrInternalHookRepoError
+	}
+
+	s.ID = ID
+	return s, nil
+}
+
+// Webhooks возвращает все подписки на события
+func (a *AdApp) Webhooks(ctx context.Context) ([]*webhooks.Subscription, error) {
+	if _, err := a.authorizeWebhooks(ctx); err != nil {
+		return nil, err
+	}
+
+	list, err := a.hookRepo.Subscriptions(ctx)
+	if err != nil {
+		return nil, ErrInternalHookRepoError
+	}
+
+	return list, nil
+}
+
+// DeleteWebhook удаляет подписку вместе с журналом её доставок; недоставленные события ей больше не отправляются
+func (a *AdApp) DeleteWebhook(ctx context.Context, ID int64) (*webhooks.Subscription, error) {
+	if _, err := a.authorizeWebhooks(ctx); err != nil {
+		return nil, err
+	}
+
+	s, err := a.hookRepo.SubscriptionByID(ctx, ID)
+	if errors.Is(err, hookrepo.ErrNoSubscription) {
+		return nil, ErrBadRequest
+	} else if err != nil {
+		return nil, ErrInternalHookRepoError
+	}
+
+	err = a.hookRepo.DeleteSubscription(ctx, ID)
+	if errors.Is(err, hookrepo.ErrNoSubscription) {
+		return nil, ErrBadRequest
+	} else if err != nil {
+		return nil, ErrInternalHookRepoError
+	}
+
+	return s, nil
+}
+
+// WebhookDeliveries возвращает журнал MaxWebhookDeliveries последних доставок подписки, начиная с самых новых
+func (a *AdApp) WebhookDeliveries(ctx context.Context, ID int64) ([]*webhooks.Delivery, error) {
+	if _, err := a.authorizeWebhooks(ctx); err != nil {
+		return nil, err
+	}
+
+	list, err := a.hookRepo.Deliveries(ctx, ID, MaxWebhookDeliveries)
+	if errors.Is(err, hookrepo.ErrNoSubscription) {
+		return nil, ErrBadRequest
+	} else if err != nil {
+		return nil, ErrInternalHookRepoError
+	}
+
+	return list, nil
+}
+
+// authorizeWebhooks проверяет, что отправитель запроса может управлять подписками, и возвращает его ID
+func (a *AdApp) authorizeWebhooks(ctx context.Context) (int64, error) {
+	actor, err := a.actingUser(ctx)
+	if err != nil {
+		return -1, err
+	}
+
+	if err = authorize(actor, policy.ManageWebhooks, 0); err != nil {
+		return -1, err
+	}
+	return actor.ID, nil
+}
@@ -0,0 +1,112 @@
+package audit
+
+import (
+	"time"
+
+	"homework10/internal/ads"
+	"homework10/internal/categories"
+	"homework10/internal/users"
+)
+
+// Kind - вид объекта, изменения которого попадают в журнал
+type Kind string
+
+const (
+	KindAd       Kind = "ad"
+	KindUser     Kind = "user"
+	KindCategory Kind = "category"
+)
+
+// Op - операция, изменившая объект
+type Op string
+
+const (
+	OpCreate       Op = "create"
+	OpUpdate       Op = "update"
+	OpChangeStatus Op = "change_status"
+	OpTransition   Op = "transition"
+	OpRenew        Op = "renew"
+	OpExpire       Op = "expire"
+	OpAddImage     Op = "add_image"
+	OpDeleteImage  Op = "delete_image"
+	OpRevert       Op = "revert"
+	OpChangeRole   Op = "change_role"
+	OpDelete       Op = "delete"
+)
+
+// Entry - запись журнала аудита. Журнал только пополняется: записи не меняются и не удаляются,
+// в том числе вместе с самим объектом
+type Entry struct {
+	ID       int64
+	Kind     Kind
+	ObjectID int64
+	// Revision - порядковый номер изменения объекта, начиная с 1
+	Revision int64
+	// ActorID - кто изменил объект, ads.NoActor - сам сервис (например, при истечении срока объявления)
+	ActorID int64
+	Op      Op
+	At      time.Time
+	// Before и After - объект до и после изменения: у создания пуст Before, у удаления - After
+	Before Snapshot
+	After  Snapshot
+}
+
+// Snapshot - состояние объекта; заполнено поле его вида, у отсутствующего объекта все поля nil
+type Snapshot struct {
+	Ad       *ads.Ad              `json:",omitempty"`
+	User     *users.User          `json:",omitempty"`
+	Category *categories.Category `json:",omitempty"`
+}
+
+// AdSnapshot сохраняет копию объявления; nil - объявления нет
+func AdSnapshot(ad *ads.Ad) Snapshot {
+	if ad == nil {
+		return Snapshot{}
+	}
+	cp := *ad
+	cp.Favorites = 0
+	cp.Distance = nil
+	return Snapshot{Ad: &cp}
+}
+
+// UserSnapshot сохраняет копию пользователя без хэша пароля; nil - пользователя нет
+func UserSnapshot(u *users.User) Snapshot {
+	if u == nil {
+		return Snapshot{}
+	}
+	cp := *u
+	cp.PasswordHash = ""
+	return Snapshot{User: &cp}
+}
+
+// CategorySnapshot сохраняет копию категории; nil - категории нет
+func CategorySnapshot(c *categories.Category) Snapshot {
+	if c == nil {
+		return Snapshot{}
+	}
+	cp := *c
+	return Snapshot{Category: &cp}
+}
+
+// Copy возвращает снимок с копиями объекта, который можно отдать наружу
+func (s Snapshot) Copy() Snapshot {
+	var res Snapshot
+	if s.Ad != nil {
+		ad := *s.Ad
+		res.Ad = &ad
+	}
+	if s.User != nil {
+		u := *s.User
+		res.User = &u
+	}
+	if s.Category != nil {
+		c := *s.Category
+		res.Category = &c
+	}
+	return res
+}
+
+// Empty сообщает, что объекта в снимке нет
+func (s Snapshot) Empty() bool {
+	return s.Ad == nil && s.User == nil && s.Category == nil
+}
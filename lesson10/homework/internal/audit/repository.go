@@ -0,0 +1,11 @@
+package audit
+
+import "context"
+
+//go:generate mockery --name Repository
+type Repository interface {
+	// Append добавляет запись в журнал, назначая ей ID и очередной номер изменения объекта (Revision)
+	Append(ctx context.Context, e *Entry) (int64, error)
+	// History возвращает записи об объекте в порядке изменений, для объекта без записей - пустой список
+	History(ctx context.Context, kind Kind, objectID int64) ([]*Entry, error)
+}
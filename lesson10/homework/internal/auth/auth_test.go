@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPassword(t *testing.T) {
+	PasswordCost = bcrypt.MinCost
+
+	hash, err := HashPassword("password")
+	assert.NoError(t, err)
+	assert.NotEqual(t, "password", hash)
+
+	ok, err := CheckPassword(hash, "password")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = CheckPassword(hash, "Password")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = CheckPassword("not a hash", "password")
+	assert.Error(t, err)
+}
+
+func TestIssuer(t *testing.T) {
+	now := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+	i := NewIssuer([]byte("secret"), time.Minute, time.Hour)
+	i.now = func() time.Time { return now }
+
+	tokens, err := i.Issue(7)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, tokens.ExpiresIn)
+
+	userID, err := i.Verify(tokens.Access, Access)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), userID)
+
+	userID, err = i.Verify(tokens.Refresh, Refresh)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), userID)
+
+	tests := []struct {
+		name  string
+		token string
+		kind  Kind
+		err   error
+	}{
+		{name: "wrong kind", token: tokens.Refresh, kind: Access, err: ErrBadToken},
+		{name: "garbage", token: "garbage", kind: Access, err: ErrBadToken},
+		{name: "tampered payload", token: "x" + tokens.Access, kind: Access, err: ErrBadToken},
+		{name: "tampered signature", token: strings.TrimSuffix(tokens.Access, tokens.Access[len(tokens.Access)-2:]), kind: Access, err: ErrBadToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := i.Verify(tt.token, tt.kind)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+
+	t.Run("other secret", func(t *testing.T) {
+		_, err := NewIssuer([]byte("other"), time.Minute, time.Hour).Verify(tokens.Access, Access)
+		assert.ErrorIs(t, err, ErrBadToken)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		_, err := i.Verify(tokens.Access, Access)
+		assert.ErrorIs(t, err, ErrTokenExpired)
+
+		_, err = i.Verify(tokens.Refresh, Refresh)
+		assert.NoError(t, err)
+	})
+}
+
+func TestUserID(t *testing.T) {
+	_, ok := UserID(context.Background())
+	assert.False(t, ok)
+
+	userID, ok := UserID(WithUserID(context.Background(), 0))
+	assert.True(t, ok)
+	assert.Zero(t, userID)
+}
@@ -0,0 +1,16 @@
+package auth
+
+import "context"
+
+type userIDKey struct{}
+
+// WithUserID возвращает контекст запроса, выполняемого от имени аутентифицированного пользователя
+func WithUserID(ctx context.Context, userID int64) context.Context {
+	return context.WithValue(ctx, userIDKey{}, userID)
+}
+
+// UserID возвращает ID аутентифицированного пользователя; ok = false, если запрос анонимный
+func UserID(ctx context.Context) (int64, bool) {
+	id, ok := ctx.Value(userIDKey{}).(int64)
+	return id, ok
+}
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordCost - стоимость хэширования паролей bcrypt; в тестах её можно снизить до bcrypt.MinCost
+var PasswordCost = bcrypt.DefaultCost
+
+// HashPassword возвращает соленый хэш пароля, который можно хранить вместо самого пароля
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), PasswordCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// CheckPassword сверяет пароль с хэшем, полученным из HashPassword
+func CheckPassword(hash, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
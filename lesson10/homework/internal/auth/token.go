@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultAccessTTL  = 15 * time.Minute
+	DefaultRefreshTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrBadToken     = fmt.Errorf("bad token")
+	ErrTokenExpired = fmt.Errorf("token expired")
+)
+
+// Kind - назначение токена: access-токен предъявляется с каждым запросом,
+// refresh-токен - только для получения новой пары токенов
+type Kind string
+
+const (
+	Access  Kind = "access"
+	Refresh Kind = "refresh"
+)
+
+// Tokens - пара токенов, выдаваемая пользователю при входе
+type Tokens struct {
+	Access    string
+	Refresh   string
+	ExpiresIn time.Duration // время жизни access-токена
+}
+
+type claims struct {
+	UserID  int64 `json:"sub"`
+	Kind    Kind  `json:"typ"`
+	Expires int64 `json:"exp"`
+}
+
+// Issuer выдаёт и проверяет токены вида "<base64(claims)>.<base64(HMAC-SHA256)>"
+type Issuer struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	now        func() time.Time
+}
+
+func NewIssuer(secret []byte, accessTTL, refreshTTL time.Duration) *Issuer {
+	return &Issuer{
+		secret:     secret,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+		now:        time.Now,
+	}
+}
+
+// Issue выдаёт пользователю новую пару токенов
+func (i *Issuer) Issue(userID int64) (Tokens, error) {
+	now := i.now()
+
+	access, err := i.sign(claims{UserID: userID, Kind: Access, Expires: now.Add(i.accessTTL).Unix()})
+	if err != nil {
+		return Tokens{}, err
+	}
+	refresh, err := i.sign(claims{UserID: userID, Kind: Refresh, Expires: now.Add(i.refreshTTL).Unix()})
+	if err != nil {
+		return Tokens{}, err
+	}
+
+	return Tokens{Access: access, Refresh: refresh, ExpiresIn: i.accessTTL}, nil
+}
+
+// Verify проверяет подпись, назначение и срок действия токена и возвращает ID его владельца
+func (i *Issuer) Verify(token string, kind Kind) (int64, error) {
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, ErrBadToken
+	}
+
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, i.mac(payload)) {
+		return 0, ErrBadToken
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return 0, ErrBadToken
+	}
+	var c claims
+	if err = json.Unmarshal(data, &c); err != nil || c.Kind != kind {
+		return 0, ErrBadToken
+	}
+	if i.now().Unix() >= c.Expires {
+		return 0, ErrTokenExpired
+	}
+
+	return c.UserID, nil
+}
+
+func (i *Issuer) sign(c claims) (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(i.mac(payload)), nil
+}
+
+func (i *Issuer) mac(payload string) []byte {
+	h := hmac.New(sha256.New, i.secret)
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
@@ -0,0 +1,11 @@
+package categories
+
+// Category - раздел каталога объявлений; категории образуют дерево через ParentID.
+// ID категорий начинаются с 1, поэтому нулевой ID означает отсутствие категории
+type Category struct {
+	ID int64
+	// ParentID - родительская категория, 0 - категория верхнего уровня
+	ParentID int64
+	Name     string `validate:"min:1;max:50"`
+	Version  int64
+}
@@ -0,0 +1,13 @@
+package categories
+
+import "context"
+
+//go:generate mockery --name Repository
+type Repository interface {
+	CategoryByID(ctx context.Context, ID int64) (*Category, error)
+	// Categories возвращает все категории: их немного, и дерево удобнее строить целиком
+	Categories(ctx context.Context) ([]*Category, error)
+	AddCategory(ctx context.Context, c *Category) (int64, error)
+	UpdateCategory(ctx context.Context, c *Category) error
+	DeleteCategory(ctx context.Context, ID int64) error
+}
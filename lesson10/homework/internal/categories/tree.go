@@ -0,0 +1,151 @@
+package categories
+
+import (
+	"sort"
+	"strings"
+)
+
+// Tree - дерево категорий, построенное по их списку; дети каждой категории упорядочены по имени.
+// Категории, чей родитель отсутствует в списке, считаются категориями верхнего уровня
+type Tree struct {
+	byID     map[int64]*Category
+	children map[int64][]*Category
+}
+
+// Count - сколько объявлений в категории вместе со всеми её подкатегориями
+type Count struct {
+	Category *Category
+	// Path - имена категорий от верхнего уровня до этой категории включительно
+	Path []string
+	Ads  int
+}
+
+func NewTree(list []*Category) *Tree {
+	t := &Tree{
+		byID:     make(map[int64]*Category, len(list)),
+		children: make(map[int64][]*Category),
+	}
+	for _, c := range list {
+		t.byID[c.ID] = c
+	}
+	for _, c := range list {
+		parent := c.ParentID
+		if _, ok := t.byID[parent]; !ok {
+			parent = 0
+		}
+		t.children[parent] = append(t.children[parent], c)
+	}
+	for _, cs := range t.children {
+		sort.Slice(cs, func(i, j int) bool {
+			if c := strings.Compare(cs[i].Name, cs[j].Name); c != 0 {
+				return c < 0
+			}
+			return cs[i].ID < cs[j].ID
+		})
+	}
+
+	return t
+}
+
+func (t *Tree) Category(ID int64) (*Category, bool) {
+	c, ok := t.byID[ID]
+	return c, ok
+}
+
+// Children возвращает непосредственных потомков категории ID, для 0 - категории верхнего уровня
+func (t *Tree) Children(ID int64) []*Category {
+	return t.children[ID]
+}
+
+// All возвращает все категории в порядке обхода в глубину: каждая категория идёт сразу после родителя
+func (t *Tree) All() []*Category {
+	res := make([]*Category, 0, len(t.byID))
+	t.walk(0, func(c *Category) {
+		res = append(res, c)
+	})
+	return res
+}
+
+// Path возвращает имена категорий от верхнего уровня до ID включительно, для неизвестной категории - nil
+func (t *Tree) Path(ID int64) []string {
+	var path []string
+	for _, c := range t.ancestors(ID) {
+		path = append(path, c.Name)
+	}
+	return path
+}
+
+// Subtree возвращает ID категории и всех её потомков, для неизвестной категории - nil
+func (t *Tree) Subtree(ID int64) []int64 {
+	if _, ok := t.byID[ID]; !ok {
+		return nil
+	}
+
+	res := []int64{ID}
+	t.walk(ID, func(c *Category) {
+		res = append(res, c.ID)
+	})
+	return res
+}
+
+// Within сообщает, лежит ли категория ID в поддереве root (в том числе совпадает с ним)
+func (t *Tree) Within(ID, root int64) bool {
+	for _, c := range t.ancestors(ID) {
+		if c.ID == root {
+			return true
+		}
+	}
+	return false
+}
+
+// Counts по количествам объявлений в самих категориях (own) считает количества вместе с подкатегориями.
+// Результат идёт в порядке All, категории без объявлений и неизвестные категории пропускаются
+func (t *Tree) Counts(own map[int64]int) []Count {
+	total := make(map[int64]int)
+	for ID, n := range own {
+		for _, c := range t.ancestors(ID) {
+			total[c.ID] += n
+		}
+	}
+
+	var res []Count
+	for _, c := range t.All() {
+		if n := total[c.ID]; n > 0 {
+			res = append(res, Count{Category: c, Path: t.Path(c.ID), Ads: n})
+		}
+	}
+	return res
+}
+
+// ancestors возвращает цепочку категорий от верхнего уровня до ID включительно
+func (t *Tree) ancestors(ID int64) []*Category {
+	var chain []*Category
+	c, ok := t.byID[ID]
+	// цепочка длиной в число категорий либо уже дошла до верхнего уровня, либо зациклилась - дальше идти незачем
+	for ok && len(chain) < len(t.byID) {
+		chain = append(chain, c)
+		c, ok = t.byID[c.ParentID]
+	}
+
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
+
+// walk обходит потомков ID в глубину; каждая категория посещается не больше одного раза, даже если в родителях есть цикл
+func (t *Tree) walk(ID int64, visit func(c *Category)) {
+	seen := map[int64]bool{ID: true}
+	var rec func(ID int64)
+	rec = func(ID int64) {
+		for _, c := range t.children[ID] {
+			if seen[c.ID] {
+				continue
+			}
+			seen[c.ID] = true
+			visit(c)
+			rec(c.ID)
+		}
+	}
+	rec(ID)
+}
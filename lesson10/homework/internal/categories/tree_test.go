@@ -0,0 +1,76 @@
+package categories
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testTree: Электроника > {Телефоны > Смартфоны, Ноутбуки}; Одежда
+func testTree() *Tree {
+	return NewTree([]*Category{
+		{ID: 1, Name: "Электроника"},
+		{ID: 2, ParentID: 1, Name: "Телефоны"},
+		{ID: 3, ParentID: 1, Name: "Ноутбуки"},
+		{ID: 4, Name: "Одежда"},
+		{ID: 5, ParentID: 2, Name: "Смартфоны"},
+	})
+}
+
+func ids(cs []*Category) []int64 {
+	var res []int64
+	for _, c := range cs {
+		res = append(res, c.ID)
+	}
+	return res
+}
+
+func TestTree_All(t *testing.T) {
+	assert.Equal(t, []int64{4, 1, 3, 2, 5}, ids(testTree().All()))
+	assert.Equal(t, []int64{3, 2}, ids(testTree().Children(1)))
+	assert.Empty(t, NewTree(nil).All())
+}
+
+func TestTree_Path(t *testing.T) {
+	tree := testTree()
+	assert.Equal(t, []string{"Электроника", "Телефоны", "Смартфоны"}, tree.Path(5))
+	assert.Equal(t, []string{"Одежда"}, tree.Path(4))
+	assert.Nil(t, tree.Path(100))
+}
+
+func TestTree_Subtree(t *testing.T) {
+	tree := testTree()
+	assert.Equal(t, []int64{1, 3, 2, 5}, tree.Subtree(1))
+	assert.Equal(t, []int64{5}, tree.Subtree(5))
+	assert.Nil(t, tree.Subtree(100))
+}
+
+func TestTree_Within(t *testing.T) {
+	tree := testTree()
+	assert.True(t, tree.Within(5, 1))
+	assert.True(t, tree.Within(2, 2))
+	assert.False(t, tree.Within(1, 2))
+	assert.False(t, tree.Within(4, 1))
+	assert.False(t, tree.Within(100, 1))
+}
+
+func TestTree_Counts(t *testing.T) {
+	counts := testTree().Counts(map[int64]int{5: 2, 2: 1, 4: 3, 100: 7})
+	assert.Equal(t, []Count{
+		{Category: &Category{ID: 4, Name: "Одежда"}, Path: []string{"Одежда"}, Ads: 3},
+		{Category: &Category{ID: 1, Name: "Электроника"}, Path: []string{"Электроника"}, Ads: 3},
+		{Category: &Category{ID: 2, ParentID: 1, Name: "Телефоны"}, Path: []string{"Электроника", "Телефоны"}, Ads: 3},
+		{Category: &Category{ID: 5, ParentID: 2, Name: "Смартфоны"}, Path: []string{"Электроника", "Телефоны", "Смартфоны"}, Ads: 2},
+	}, counts)
+}
+
+func TestTree_Cycle(t *testing.T) {
+	// испорченные данные не должны зацикливать обход
+	tree := NewTree([]*Category{
+		{ID: 1, ParentID: 2, Name: "a"},
+		{ID: 2, ParentID: 1, Name: "b"},
+	})
+	assert.Equal(t, []int64{1, 2}, tree.Subtree(1))
+	assert.Len(t, tree.Path(1), 2)
+	assert.Empty(t, tree.All())
+}
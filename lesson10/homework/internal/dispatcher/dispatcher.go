@@ -0,0 +1,282 @@
+package dispatcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"homework10/internal/adapters/hookrepo"
+	"homework10/internal/events"
+	"homework10/internal/webhooks"
+)
+
+const (
+	// DefaultInterval - как часто по умолчанию проверяются новые события и недоставленные запросы
+	DefaultInterval = time.Second
+	// DefaultMaxAttempts - сколько раз по умолчанию событие отправляется подписчику, прежде чем доставка считается неудавшейся
+	DefaultMaxAttempts = 8
+	// DefaultBaseBackoff - пауза после первой неудачной попытки, после каждой следующей она удваивается до DefaultMaxBackoff
+	DefaultBaseBackoff = 10 * time.Second
+	DefaultMaxBackoff  = time.Hour
+	// DefaultTimeout - сколько по умолчанию ждать ответа подписчика на одну попытку
+	DefaultTimeout = 10 * time.Second
+	// DefaultWorkers - скольким подписчикам по умолчанию события отправляются одновременно
+	DefaultWorkers = 8
+
+	// batch - сколько событий и доставок обрабатывается за один проход
+	batch = 100
+	// maxResponseBody - сколько байт ответа подписчика дочитывается, чтобы переиспользовать соединение
+	maxResponseBody = 64 << 10
+)
+
+// Config - настройки рассылки; нулевые поля заменяются значениями по умолчанию
+type Config struct {
+	Interval    time.Duration
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	Timeout     time.Duration
+	Workers     int
+}
+
+func (c Config) withDefaults() Config {
+	if c.Interval <= 0 {
+		c.Interval = DefaultInterval
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = DefaultMaxAttempts
+	}
+	if c.BaseBackoff <= 0 {
+		c.BaseBackoff = DefaultBaseBackoff
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = DefaultMaxBackoff
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTimeout
+	}
+	if c.Workers <= 0 {
+		c.Workers = DefaultWorkers
+	}
+	return c
+}
+
+// Dispatcher разбирает outbox на доставки подписчикам и отправляет их, повторяя неудавшиеся попытки.
+// Событие убирается из outbox только после того, как сохранены все его доставки, поэтому при сбое
+// подписчик может получить событие повторно: для этого в каждом запросе есть ID события (HeaderEventID)
+type Dispatcher struct {
+	outbox events.Outbox
+	repo   webhooks.Repository
+	client *http.Client
+	cfg    Config
+	logger *slog.Logger
+}
+
+func New(outbox events.Outbox, repo webhooks.Repository, cfg Config, logger *slog.Logger) *Dispatcher {
+	cfg = cfg.withDefaults()
+
+	return &Dispatcher{
+		outbox: outbox,
+		repo:   repo,
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			// перенаправление - не доставка: подписчик должен указать адрес, который сам принимает события
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// Run рассылает события сразу и затем каждые Config.Interval, пока не отменён ctx
+func (d *Dispatcher) Run(ctx context.Context) error {
+	d.logger.Info("starting webhook dispatcher", "interval", d.cfg.Interval)
+	defer d.logger.Info("webhook dispatcher was stopped")
+
+	t := time.NewTicker(d.cfg.Interval)
+	defer t.Stop()
+
+	d.tick(ctx, time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-t.C:
+			d.tick(ctx, now)
+		}
+	}
+}
+
+func (d *Dispatcher) tick(ctx context.Context, now time.Time) {
+	now = now.UTC()
+	d.fanOut(ctx, now)
+	d.deliverDue(ctx, now)
+}
+
+// fanOut создаёт по доставке каждого события из outbox каждой подходящей подписке
+func (d *Dispatcher) fanOut(ctx context.Context, now time.Time) {
+	list, err := d.outbox.Pending(ctx, batch)
+	if err != nil {
+		d.logger.ErrorContext(ctx, "dispatcher can't read outbox", "err", err)
+		return
+	}
+	if len(list) == 0 {
+		return
+	}
+
+	subs, err := d.repo.Subscriptions(ctx)
+	if err != nil {
+		d.logger.ErrorContext(ctx, "dispatcher can't read subscriptions", "err", err)
+		return
+	}
+
+	for _, e := range list {
+		for _, s := range subs {
+			if !s.Matches(e.Type) {
+				continue
+			}
+			_, err = d.repo.AddDelivery(ctx, &webhooks.Delivery{
+				SubscriptionID: s.ID,
+				Event:          *e,
+				Status:         webhooks.DeliveryPending,
+				NextAttempt:    now,
+				Created:        now,
+			})
+			// подписку могли удалить после чтения списка - тогда доставлять некому
+			if err != nil && !errors.Is(err, hookrepo.ErrNoSubscription) {
+				d.logger.ErrorContext(ctx, "dispatcher can't add delivery", "event_id", e.ID, "err", err)
+				return
+			}
+		}
+
+		if err = d.outbox.Done(ctx, e.ID); err != nil {
+			d.logger.ErrorContext(ctx, "dispatcher can't mark event done", "event_id", e.ID, "err", err)
+			return
+		}
+	}
+}
+
+// deliverDue отправляет доставки, следующая попытка которых уже наступила. Подписчикам они отправляются
+// одновременно, не больше чем Config.Workers сразу, чтобы медленный подписчик не задерживал остальных;
+// одному подписчику - по очереди, в порядке событий
+func (d *Dispatcher) deliverDue(ctx context.Context, now time.Time) {
+	list, err := d.repo.DueDeliveries(ctx, now, batch)
+	if err != nil {
+		d.logger.ErrorContext(ctx, "dispatcher can't read deliveries", "err", err)
+		return
+	}
+
+	var order []int64
+	bySubscription := make(map[int64][]*webhooks.Delivery)
+	for _, dl := range list {
+		if _, ok := bySubscription[dl.SubscriptionID]; !ok {
+			order = append(order, dl.SubscriptionID)
+		}
+		bySubscription[dl.SubscriptionID] = append(bySubscription[dl.SubscriptionID], dl)
+	}
+
+	queue := make(chan int64, len(order))
+	for _, id := range order {
+		queue <- id
+	}
+	close(queue)
+
+	var wg sync.WaitGroup
+	for i := 0; i < min(d.cfg.Workers, len(order)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range queue {
+				d.deliverTo(ctx, id, bySubscription[id], now)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// deliverTo отправляет доставки одной подписки по очереди
+func (d *Dispatcher) deliverTo(ctx context.Context, subscriptionID int64, list []*webhooks.Delivery, now time.Time) {
+	s, err := d.repo.SubscriptionByID(ctx, subscriptionID)
+	if errors.Is(err, hookrepo.ErrNoSubscription) {
+		return
+	} else if err != nil {
+		d.logger.ErrorContext(ctx, "dispatcher can't read subscription", "subscription_id", subscriptionID, "err", err)
+		return
+	}
+
+	for _, dl := range list {
+		if ctx.Err() != nil {
+			return
+		}
+
+		d.attempt(ctx, s, dl, now)
+		err = d.repo.UpdateDelivery(ctx, dl)
+		if err != nil && !errors.Is(err, hookrepo.ErrNoDelivery) {
+			d.logger.ErrorContext(ctx, "dispatcher can't save delivery", "delivery_id", dl.ID, "err", err)
+		}
+	}
+}
+
+// attempt отправляет событие подписчику и дописывает попытку в доставку: удачная завершает её,
+// неудачная откладывает следующую попытку, а последняя разрешённая - завершает доставку неудачей
+func (d *Dispatcher) attempt(ctx context.Context, s *webhooks.Subscription, dl *webhooks.Delivery, now time.Time) {
+	start := time.Now()
+	code, err := d.send(ctx, s, dl, now)
+	a := webhooks.Attempt{At: now, StatusCode: code, Duration: time.Since(start)}
+	if err == nil && (code < 200 || code > 299) {
+		err = fmt.Errorf("unexpected status %d", code)
+	}
+	if err != nil {
+		a.Error = err.Error()
+	}
+	dl.Attempts = append(dl.Attempts, a)
+
+	switch {
+	case err == nil:
+		dl.Status = webhooks.DeliveryDelivered
+	case len(dl.Attempts) >= d.cfg.MaxAttempts:
+		dl.Status = webhooks.DeliveryFailed
+		d.logger.WarnContext(ctx, "dispatcher gave up delivering event", "event_id", dl.Event.ID, "url", s.URL, "err", a.Error)
+	default:
+		dl.NextAttempt = now.Add(webhooks.Backoff(len(dl.Attempts), d.cfg.BaseBackoff, d.cfg.MaxBackoff))
+	}
+}
+
+// send отправляет подписанный запрос с событием и возвращает код ответа
+func (d *Dispatcher) send(ctx context.Context, s *webhooks.Subscription, dl *webhooks.Delivery, now time.Time) (int, error) {
+	body, err := json.Marshal(newPayload(&dl.Event))
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	ts := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhooks.HeaderEvent, string(dl.Event.Type))
+	req.Header.Set(webhooks.HeaderEventID, strconv.FormatInt(dl.Event.ID, 10))
+	req.Header.Set(webhooks.HeaderDelivery, strconv.FormatInt(dl.ID, 10))
+	req.Header.Set(webhooks.HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(webhooks.HeaderSignature, webhooks.Sign(s.Secret, ts, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+
+	return resp.StatusCode, nil
+}
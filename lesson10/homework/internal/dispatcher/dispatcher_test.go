@@ -0,0 +1,264 @@
+package dispatcher
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/adapters/hookrepo"
+	"homework10/internal/adapters/outboxrepo"
+	"homework10/internal/ads"
+	"homework10/internal/events"
+	"homework10/internal/logging"
+	"homework10/internal/webhooks"
+)
+
+var started = time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// receiver - подписчик, который отвечает кодами из codes по очереди (последним - на все остальные запросы)
+// и запоминает полученные запросы; если задан block, ответ ждёт, пока его закроют
+type receiver struct {
+	m      sync.Mutex
+	codes  []int
+	bodies [][]byte
+	heads  []http.Header
+	block  chan struct{}
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if rc.block != nil {
+		<-rc.block
+	}
+	rc.m.Lock()
+	defer rc.m.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	rc.bodies = append(rc.bodies, body)
+	rc.heads = append(rc.heads, r.Header.Clone())
+
+	code := rc.codes[0]
+	if len(rc.codes) > 1 {
+		rc.codes = rc.codes[1:]
+	}
+	w.WriteHeader(code)
+}
+
+func (rc *receiver) received() int {
+	rc.m.Lock()
+	defer rc.m.Unlock()
+	return len(rc.bodies)
+}
+
+// setup создаёт рассылку с подпиской на receiver и событием о создании объявления в outbox
+func setup(t *testing.T, rc *receiver, cfg Config) (*Dispatcher, webhooks.Repository, *webhooks.Subscription) {
+	srv := httptest.NewServer(rc)
+	t.Cleanup(srv.Close)
+
+	ctx := context.Background()
+	outbox, repo := outboxrepo.New(), hookrepo.New()
+	s := &webhooks.Subscription{URL: srv.URL, Secret: "secret", Types: []events.Type{events.AdCreated}}
+	_, _ = repo.AddSubscription(ctx, s)
+	_, _ = outbox.Add(ctx, &events.Event{
+		Type:     events.AdCreated,
+		ObjectID: 1,
+		ActorID:  2,
+		At:       started,
+		Ad:       &ads.Ad{ID: 1, Title: "title", Text: "text", UserID: 2, Status: ads.StatusDraft, Created: started},
+	})
+
+	return New(outbox, repo, cfg, logging.Discard()), repo, s
+}
+
+func TestDispatcher_Deliver(t *testing.T) {
+	rc := &receiver{codes: []int{http.StatusNoContent}}
+	d, repo, s := setup(t, rc, Config{})
+	ctx := context.Background()
+
+	// подписка не на создание объявлений доставок не получает
+	_, _ = repo.AddSubscription(ctx, &webhooks.Subscription{URL: s.URL, Types: []events.Type{events.UserCreated}})
+
+	d.tick(ctx, started)
+	assert.Equal(t, 1, rc.received())
+
+	pending, _ := d.outbox.Pending(ctx, 0)
+	assert.Empty(t, pending)
+	list, err := repo.Deliveries(ctx, s.ID, 0)
+	assert.NoError(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, webhooks.DeliveryDelivered, list[0].Status)
+		if assert.Len(t, list[0].Attempts, 1) {
+			assert.Equal(t, http.StatusNoContent, list[0].Attempts[0].StatusCode)
+			assert.Empty(t, list[0].Attempts[0].Error)
+		}
+	}
+	list, _ = repo.Deliveries(ctx, 2, 0)
+	assert.Empty(t, list)
+
+	h, body := rc.heads[0], rc.bodies[0]
+	assert.Equal(t, "ad.created", h.Get(webhooks.HeaderEvent))
+	assert.Equal(t, "1", h.Get(webhooks.HeaderEventID))
+	assert.Equal(t, "1", h.Get(webhooks.HeaderDelivery))
+	ts, err := strconv.ParseInt(h.Get(webhooks.HeaderTimestamp), 10, 64)
+	assert.NoError(t, err)
+	assert.Equal(t, started.Unix(), ts)
+	assert.True(t, webhooks.Verify("secret", ts, body, h.Get(webhooks.HeaderSignature)))
+
+	var p Payload
+	assert.NoError(t, json.Unmarshal(body, &p))
+	assert.Equal(t, Payload{
+		ID:       1,
+		Type:     "ad.created",
+		At:       started,
+		ActorID:  2,
+		ObjectID: 1,
+		Ad:       &AdData{ID: 1, Title: "title", Text: "text", AuthorID: 2, Status: "draft", Created: started},
+	}, p)
+
+	// доставленное событие больше не отправляется
+	d.tick(ctx, started.Add(time.Hour))
+	assert.Equal(t, 1, rc.received())
+}
+
+func TestDispatcher_Retry(t *testing.T) {
+	rc := &receiver{codes: []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK}}
+	d, repo, s := setup(t, rc, Config{BaseBackoff: time.Minute})
+	ctx := context.Background()
+
+	d.tick(ctx, started)
+	list, _ := repo.Deliveries(ctx, s.ID, 0)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, webhooks.DeliveryPending, list[0].Status)
+		assert.Equal(t, started.Add(time.Minute), list[0].NextAttempt)
+		assert.Equal(t, "unexpected status 500", list[0].Attempts[0].Error)
+	}
+
+	// до следующей попытки запрос не повторяется, а паузы между попытками растут
+	d.tick(ctx, started.Add(30*time.Second))
+	assert.Equal(t, 1, rc.received())
+	d.tick(ctx, started.Add(time.Minute))
+	assert.Equal(t, 2, rc.received())
+	list, _ = repo.Deliveries(ctx, s.ID, 0)
+	assert.Equal(t, started.Add(3*time.Minute), list[0].NextAttempt)
+
+	d.tick(ctx, started.Add(3*time.Minute))
+	assert.Equal(t, 3, rc.received())
+	list, _ = repo.Deliveries(ctx, s.ID, 0)
+	assert.Equal(t, webhooks.DeliveryDelivered, list[0].Status)
+	assert.Len(t, list[0].Attempts, 3)
+
+	// повторные запросы несут то же событие
+	assert.Equal(t, rc.bodies[0], rc.bodies[2])
+	assert.Equal(t, rc.heads[0].Get(webhooks.HeaderEventID), rc.heads[2].Get(webhooks.HeaderEventID))
+}
+
+func TestDispatcher_GiveUp(t *testing.T) {
+	rc := &receiver{codes: []int{http.StatusMovedPermanently}}
+	d, repo, s := setup(t, rc, Config{MaxAttempts: 3, BaseBackoff: time.Minute, MaxBackoff: time.Minute})
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		d.tick(ctx, started.Add(time.Duration(i)*time.Minute))
+	}
+
+	assert.Equal(t, 3, rc.received())
+	list, _ := repo.Deliveries(ctx, s.ID, 0)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, webhooks.DeliveryFailed, list[0].Status)
+		assert.Len(t, list[0].Attempts, 3)
+	}
+}
+
+func TestDispatcher_Unreachable(t *testing.T) {
+	rc := &receiver{codes: []int{http.StatusOK}}
+	d, repo, s := setup(t, rc, Config{})
+	ctx := context.Background()
+
+	s.URL = "http://127.0.0.1:1"
+	_ = repo.DeleteSubscription(ctx, s.ID)
+	_, _ = repo.AddSubscription(ctx, s)
+
+	d.tick(ctx, started)
+	list, _ := repo.Deliveries(ctx, s.ID, 0)
+	if assert.Len(t, list, 1) && assert.Len(t, list[0].Attempts, 1) {
+		assert.Equal(t, webhooks.DeliveryPending, list[0].Status)
+		assert.Equal(t, 0, list[0].Attempts[0].StatusCode)
+		assert.NotEmpty(t, list[0].Attempts[0].Error)
+		assert.Equal(t, started.Add(DefaultBaseBackoff), list[0].NextAttempt)
+	}
+}
+
+func TestDispatcher_SlowSubscriber(t *testing.T) {
+	slow := &receiver{codes: []int{http.StatusOK}, block: make(chan struct{})}
+	d, repo, s := setup(t, slow, Config{})
+	ctx := context.Background()
+
+	// доставка медленному подписчику в очереди первой, но не задерживает доставку другому
+	fast := &receiver{codes: []int{http.StatusOK}}
+	srv := httptest.NewServer(fast)
+	t.Cleanup(srv.Close)
+	other := &webhooks.Subscription{URL: srv.URL, Types: []events.Type{events.AdCreated}}
+	_, _ = repo.AddSubscription(ctx, other)
+
+	done := make(chan struct{})
+	go func() {
+		d.tick(ctx, started)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool { return fast.received() == 1 }, time.Second, time.Millisecond)
+	select {
+	case <-done:
+		assert.Fail(t, "tick finished before the slow subscriber answered")
+	default:
+	}
+
+	close(slow.block)
+	<-done
+	for _, id := range []int64{s.ID, other.ID} {
+		list, _ := repo.Deliveries(ctx, id, 0)
+		if assert.Len(t, list, 1) {
+			assert.Equal(t, webhooks.DeliveryDelivered, list[0].Status)
+		}
+	}
+}
+
+func TestDispatcher_Run(t *testing.T) {
+	rc := &receiver{codes: []int{http.StatusOK}}
+	d, _, _ := setup(t, rc, Config{Interval: time.Millisecond})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error)
+	go func() {
+		done <- d.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool { return rc.received() == 1 }, time.Second, time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "dispatcher wasn't stopped")
+	}
+}
+
+func TestNew_Defaults(t *testing.T) {
+	d := New(outboxrepo.New(), hookrepo.New(), Config{}, logging.Discard())
+	assert.Equal(t, Config{
+		Interval:    DefaultInterval,
+		MaxAttempts: DefaultMaxAttempts,
+		BaseBackoff: DefaultBaseBackoff,
+		MaxBackoff:  DefaultMaxBackoff,
+		Timeout:     DefaultTimeout,
+		Workers:     DefaultWorkers,
+	}, d.cfg)
+}
@@ -0,0 +1,82 @@
+package dispatcher
+
+import (
+	"time"
+
+	"homework10/internal/events"
+)
+
+// Payload - тело запроса с событием; заполнено поле вида объекта
+type Payload struct {
+	ID       int64     `json:"id"`
+	Type     string    `json:"type"`
+	At       time.Time `json:"at"`
+	ActorID  int64     `json:"actor_id"` // -1 - событие вызвал сам сервис
+	ObjectID int64     `json:"object_id"`
+	Ad       *AdData   `json:"ad,omitempty"`
+	User     *UserData `json:"user,omitempty"`
+}
+
+type AdData struct {
+	ID         int64      `json:"id"`
+	Title      string     `json:"title"`
+	Text       string     `json:"text"`
+	AuthorID   int64      `json:"author_id"`
+	CategoryID int64      `json:"category_id"`
+	Status     string     `json:"status"`
+	Published  bool       `json:"published"`
+	Price      int64      `json:"price"`
+	Currency   string     `json:"currency"`
+	Created    time.Time  `json:"created"`
+	Updated    time.Time  `json:"updated"`
+	Expires    *time.Time `json:"expires"` // null - бессрочное
+	Version    int64      `json:"version"`
+}
+
+type UserData struct {
+	ID       int64  `json:"id"`
+	Nickname string `json:"nickname"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+	Version  int64  `json:"version"`
+}
+
+func newPayload(e *events.Event) Payload {
+	p := Payload{
+		ID:       e.ID,
+		Type:     string(e.Type),
+		At:       e.At,
+		ActorID:  e.ActorID,
+		ObjectID: e.ObjectID,
+	}
+	if ad := e.Ad; ad != nil {
+		p.Ad = &AdData{
+			ID:         ad.ID,
+			Title:      ad.Title,
+			Text:       ad.Text,
+			AuthorID:   ad.UserID,
+			CategoryID: ad.CategoryID,
+			Status:     string(ad.Status),
+			Published:  ad.Published(),
+			Price:      ad.Price.Amount,
+			Currency:   ad.Price.Currency,
+			Created:    ad.Created,
+			Updated:    ad.Updated,
+			Version:    ad.Version,
+		}
+		if !ad.Expires.IsZero() {
+			expires := ad.Expires
+			p.Ad.Expires = &expires
+		}
+	}
+	if u := e.User; u != nil {
+		p.User = &UserData{
+			ID:       u.ID,
+			Nickname: u.Nickname,
+			Email:    u.Email,
+			Role:     string(u.RoleOf()),
+			Version:  u.Version,
+		}
+	}
+	return p
+}
@@ -0,0 +1,65 @@
+package events
+
+import (
+	"time"
+
+	"homework10/internal/ads"
+	"homework10/internal/users"
+)
+
+// Type - вид доменного события, на который можно подписаться
+type Type string
+
+const (
+	AdCreated Type = "ad.created"
+	// AdUpdated - изменилось содержимое объявления: текст, цена, местоположение или фотографии
+	AdUpdated Type = "ad.updated"
+	// AdPublished - объявление стало опубликованным, AdStatusChanged - любая другая смена статуса
+	AdPublished     Type = "ad.published"
+	AdStatusChanged Type = "ad.status_changed"
+	AdDeleted       Type = "ad.deleted"
+
+	UserCreated Type = "user.created"
+	// UserUpdated - изменились данные или роль пользователя
+	UserUpdated Type = "user.updated"
+	UserDeleted Type = "user.deleted"
+)
+
+// Types - все виды событий
+var Types = []Type{AdCreated, AdUpdated, AdPublished, AdStatusChanged, AdDeleted, UserCreated, UserUpdated, UserDeleted}
+
+func (t Type) Valid() bool {
+	for _, known := range Types {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Event - доменное событие: что произошло с объявлением или пользователем, кто и когда это сделал
+type Event struct {
+	ID       int64
+	Type     Type
+	ObjectID int64
+	// ActorID - кто вызвал событие, ads.NoActor - сам сервис (например, при истечении срока объявления)
+	ActorID int64
+	At      time.Time
+	// Ad и User - объект после события, у удаления - до него; заполнено поле вида объекта
+	Ad   *ads.Ad     `json:",omitempty"`
+	User *users.User `json:",omitempty"`
+}
+
+// Copy возвращает копию события, которую можно отдать наружу
+func (e *Event) Copy() *Event {
+	cp := *e
+	if e.Ad != nil {
+		ad := *e.Ad
+		cp.Ad = &ad
+	}
+	if e.User != nil {
+		u := *e.User
+		cp.User = &u
+	}
+	return &cp
+}
@@ -0,0 +1,25 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/ads"
+)
+
+func TestType_Valid(t *testing.T) {
+	for _, tt := range Types {
+		assert.True(t, tt.Valid(), tt)
+	}
+	assert.False(t, Type("ad.favorited").Valid())
+	assert.False(t, Type("").Valid())
+}
+
+func TestEvent_Copy(t *testing.T) {
+	e := &Event{ID: 1, Type: AdCreated, ObjectID: 2, Ad: &ads.Ad{ID: 2, Title: "title"}}
+	cp := e.Copy()
+	cp.Ad.Title = "changed"
+	assert.Equal(t, "title", e.Ad.Title)
+	assert.Nil(t, cp.User)
+}
@@ -0,0 +1,16 @@
+package events
+
+import "context"
+
+// Outbox - исходящие события, ещё не разосланные подписчикам. Событие сохраняется в нём до ответа
+// на изменивший объект запрос, поэтому переживает перезапуск сервиса и рассылается уже после него
+//
+//go:generate mockery --name Outbox
+type Outbox interface {
+	// Add сохраняет событие, назначая ему ID
+	Add(ctx context.Context, e *Event) (int64, error)
+	// Pending возвращает до limit ещё не разосланных событий в порядке их добавления
+	Pending(ctx context.Context, limit int) ([]*Event, error)
+	// Done убирает разосланное событие; событие, которого уже нет, - не ошибка
+	Done(ctx context.Context, ID int64) error
+}
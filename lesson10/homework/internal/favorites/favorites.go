@@ -0,0 +1,11 @@
+package favorites
+
+import "time"
+
+// Favorite - объявление AdID в избранном у пользователя UserID
+type Favorite struct {
+	UserID int64
+	AdID   int64
+	// Added - когда объявление добавлено в избранное
+	Added time.Time
+}
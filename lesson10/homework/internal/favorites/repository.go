@@ -0,0 +1,18 @@
+package favorites
+
+import "context"
+
+//go:generate mockery --name Repository
+type Repository interface {
+	AddFavorite(ctx context.Context, f *Favorite) error
+	DeleteFavorite(ctx context.Context, userID, adID int64) error
+	// Favorites возвращает избранное пользователя: сначала добавленные последними
+	Favorites(ctx context.Context, userID int64) ([]*Favorite, error)
+	// CountByAds считает, у скольких пользователей каждое из объявлений adIDs в избранном;
+	// объявлений, которых нет ни у кого в избранном, в результате нет
+	CountByAds(ctx context.Context, adIDs []int64) (map[int64]int, error)
+	// DeleteByAd убирает объявление из избранного всех пользователей
+	DeleteByAd(ctx context.Context, adID int64) error
+	// DeleteByUser очищает избранное пользователя
+	DeleteByUser(ctx context.Context, userID int64) error
+}
@@ -0,0 +1,142 @@
+package feed
+
+import (
+	"fmt"
+	"sync"
+
+	"homework10/internal/events"
+)
+
+// DefaultBuffer - сколько событий по умолчанию может ждать отправки одному подписчику
+const DefaultBuffer = 64
+
+var (
+	ErrSlowConsumer = fmt.Errorf("subscriber can't keep up with the feed")
+	ErrClosed       = fmt.Errorf("feed is closed")
+)
+
+// Hub рассылает доменные события подписчикам, пока они подключены. Публикация никогда не ждёт подписчиков:
+// у каждого есть буфер на buffer событий, и подписчик, который не успевает его разбирать, отключается
+// с ошибкой ErrSlowConsumer. События не сохраняются, подписчик получает только опубликованные после подписки.
+type Hub struct {
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	buffer int
+	closed bool
+}
+
+// NewHub создаёт рассыльщик; buffer <= 0 - DefaultBuffer
+func NewHub(buffer int) *Hub {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+
+	return &Hub{
+		subs:   make(map[*Subscription]struct{}),
+		buffer: buffer,
+	}
+}
+
+// Subscription - подписка на события, для которых match вернул true. Канал Events закрывается,
+// когда подписка закрыта самим подписчиком, отключена как отстающая или закрыт весь Hub; причину возвращает Err
+type Subscription struct {
+	hub   *Hub
+	match func(*events.Event) bool
+	c     chan *events.Event
+	// err защищён hub.mu
+	err error
+}
+
+// Subscribe подписывает на события, подходящие под match; match вызывается при каждой публикации
+// и должен быть быстрым. Подписку нужно закрыть, когда она больше не нужна
+func (h *Hub) Subscribe(match func(*events.Event) bool) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrClosed
+	}
+
+	s := &Subscription{
+		hub:   h,
+		match: match,
+		c:     make(chan *events.Event, h.buffer),
+	}
+	h.subs[s] = struct{}{}
+
+	return s, nil
+}
+
+// Publish рассылает событие подходящим подписчикам. Подписчики получают одну общую копию события
+// и не должны её изменять
+func (h *Hub) Publish(e *events.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var cp *events.Event
+	for s := range h.subs {
+		if !s.match(e) {
+			continue
+		}
+		if cp == nil {
+			cp = e.Copy()
+		}
+
+		select {
+		case s.c <- cp:
+		default:
+			h.drop(s, ErrSlowConsumer)
+		}
+	}
+}
+
+// Close отключает всех подписчиков с ошибкой ErrClosed; новые подписки после этого не принимаются
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for s := range h.subs {
+		h.drop(s, ErrClosed)
+	}
+}
+
+// Len возвращает число подключённых подписчиков
+func (h *Hub) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return len(h.subs)
+}
+
+func (h *Hub) drop(s *Subscription, err error) {
+	if _, ok := h.subs[s]; !ok {
+		return
+	}
+
+	delete(h.subs, s)
+	s.err = err
+	close(s.c)
+}
+
+// Events возвращает канал событий подписки
+func (s *Subscription) Events() <-chan *events.Event {
+	return s.c
+}
+
+// Err возвращает причину, по которой закрыт канал событий: ErrSlowConsumer, ErrClosed
+// или nil, если подписка открыта или закрыта самим подписчиком
+func (s *Subscription) Err() error {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	return s.err
+}
+
+// Close отписывает от событий; повторный вызов ничего не делает
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	s.hub.drop(s, nil)
+}
@@ -0,0 +1,101 @@
+package feed
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/events"
+)
+
+func everything(*events.Event) bool { return true }
+
+func TestHub_Publish(t *testing.T) {
+	h := NewHub(4)
+	ads, err := h.Subscribe(func(e *events.Event) bool { return e.Type == events.AdCreated })
+	assert.NoError(t, err)
+	all, err := h.Subscribe(everything)
+	assert.NoError(t, err)
+
+	h.Publish(&events.Event{ID: 1, Type: events.UserCreated})
+	h.Publish(&events.Event{ID: 2, Type: events.AdCreated})
+
+	assert.Equal(t, int64(2), (<-ads.Events()).ID)
+	assert.Empty(t, ads.Events())
+	assert.Equal(t, int64(1), (<-all.Events()).ID)
+	assert.Equal(t, int64(2), (<-all.Events()).ID)
+	assert.Equal(t, 2, h.Len())
+}
+
+func TestHub_Publish_Copy(t *testing.T) {
+	h := NewHub(1)
+	s, err := h.Subscribe(everything)
+	assert.NoError(t, err)
+
+	e := &events.Event{ID: 1, Type: events.AdCreated}
+	h.Publish(e)
+	e.Type = events.AdDeleted
+
+	assert.Equal(t, events.AdCreated, (<-s.Events()).Type)
+}
+
+func TestHub_SlowConsumer(t *testing.T) {
+	h := NewHub(2)
+	slow, err := h.Subscribe(everything)
+	assert.NoError(t, err)
+	fast, err := h.Subscribe(everything)
+	assert.NoError(t, err)
+
+	for i := int64(1); i <= 3; i++ {
+		h.Publish(&events.Event{ID: i})
+		if i < 3 {
+			<-fast.Events()
+		}
+	}
+
+	// отстающий получает то, что успело попасть в буфер, и затем закрытый канал
+	var got []int64
+	for e := range slow.Events() {
+		got = append(got, e.ID)
+	}
+	assert.Equal(t, []int64{1, 2}, got)
+	assert.ErrorIs(t, slow.Err(), ErrSlowConsumer)
+
+	assert.Equal(t, int64(3), (<-fast.Events()).ID)
+	assert.NoError(t, fast.Err())
+	assert.Equal(t, 1, h.Len())
+}
+
+func TestSubscription_Close(t *testing.T) {
+	h := NewHub(1)
+	s, err := h.Subscribe(everything)
+	assert.NoError(t, err)
+
+	s.Close()
+	s.Close()
+	h.Publish(&events.Event{ID: 1})
+
+	_, ok := <-s.Events()
+	assert.False(t, ok)
+	assert.NoError(t, s.Err())
+	assert.Equal(t, 0, h.Len())
+}
+
+func TestHub_Close(t *testing.T) {
+	h := NewHub(0)
+	s, err := h.Subscribe(everything)
+	assert.NoError(t, err)
+
+	h.Close()
+
+	_, ok := <-s.Events()
+	assert.False(t, ok)
+	assert.ErrorIs(t, s.Err(), ErrClosed)
+
+	_, err = h.Subscribe(everything)
+	assert.ErrorIs(t, err, ErrClosed)
+
+	// закрытие после отключения ничего не ломает
+	s.Close()
+	h.Close()
+}
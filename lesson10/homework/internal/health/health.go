@@ -0,0 +1,74 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"homework10/internal/ads"
+	"homework10/internal/users"
+)
+
+// DefaultInterval - как часто по умолчанию перепроверяется готовность там, где её нужно сообщать заранее
+const DefaultInterval = 5 * time.Second
+
+// ErrShuttingDown - сервис начал останавливаться: начатые запросы он дорабатывает, но новых не ждёт
+var ErrShuttingDown = fmt.Errorf("service is shutting down")
+
+// Check проверяет, что зависимость сервиса доступна
+type Check func(ctx context.Context) error
+
+// Probe определяет, готов ли сервис принимать запросы: готов, пока не начал останавливаться
+// и все его проверки проходят
+type Probe struct {
+	names  []string
+	checks []Check
+	down   atomic.Bool
+}
+
+func New() *Probe {
+	return &Probe{}
+}
+
+// Add добавляет проверку зависимости name; проверки добавляются до того, как сервис начнёт принимать запросы
+func (p *Probe) Add(name string, check Check) {
+	p.names = append(p.names, name)
+	p.checks = append(p.checks, check)
+}
+
+// Shutdown переводит сервис в неготовые насовсем; вызывается в начале остановки сервиса,
+// чтобы балансировщик перестал присылать новые запросы, пока дорабатываются начатые
+func (p *Probe) Shutdown() {
+	p.down.Store(true)
+}
+
+// Ready возвращает nil, если сервис готов принимать запросы, иначе - причину, по которой не готов
+func (p *Probe) Ready(ctx context.Context) error {
+	if p.down.Load() {
+		return ErrShuttingDown
+	}
+
+	for i, check := range p.checks {
+		if err := check(ctx); err != nil {
+			return fmt.Errorf("%s: %w", p.names[i], err)
+		}
+	}
+	return nil
+}
+
+// Ads проверяет, что хранилище объявлений отвечает на запросы
+func Ads(r ads.Repository) Check {
+	return func(ctx context.Context) error {
+		_, err := r.CountByCategory(ctx, ads.DefaultPattern())
+		return err
+	}
+}
+
+// Users проверяет, что хранилище пользователей отвечает на запросы
+func Users(r users.Repository) Check {
+	return func(ctx context.Context) error {
+		_, err := r.CountUsers(ctx)
+		return err
+	}
+}
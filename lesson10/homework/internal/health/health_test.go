@@ -0,0 +1,39 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/userrepo"
+	usersMock "homework10/internal/users/mocks"
+)
+
+func TestProbe(t *testing.T) {
+	ctx := context.Background()
+	p := New()
+	p.Add("ads", Ads(adrepo.New()))
+	p.Add("users", Users(userrepo.New()))
+	assert.NoError(t, p.Ready(ctx))
+
+	p.Shutdown()
+	assert.ErrorIs(t, p.Ready(ctx), ErrShuttingDown)
+}
+
+func TestProbe_Unavailable(t *testing.T) {
+	ctx := context.Background()
+	down := fmt.Errorf("repo is down")
+	broken := usersMock.NewRepository(t)
+	broken.On("CountUsers", mock.Anything).Return(0, down)
+
+	p := New()
+	p.Add("ads", Ads(adrepo.New()))
+	p.Add("users", Users(broken))
+	err := p.Ready(ctx)
+	assert.ErrorIs(t, err, down)
+	assert.EqualError(t, err, "users: repo is down")
+}
@@ -0,0 +1,163 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"homework10/internal/auth"
+)
+
+const (
+	// DefaultWindow - сколько по умолчанию хранится ответ на запрос с ключом идемпотентности
+	DefaultWindow = 24 * time.Hour
+	// MaxKeyLength - максимальная длина ключа идемпотентности
+	MaxKeyLength = 255
+)
+
+var (
+	ErrBadKey     = fmt.Errorf("idempotency key must be from 1 to %d characters long", MaxKeyLength)
+	ErrInProgress = fmt.Errorf("request with this idempotency key is still in progress")
+	ErrMismatch   = fmt.Errorf("idempotency key was already used with a different request")
+)
+
+// CheckKey проверяет ключ идемпотентности, присланный клиентом
+func CheckKey(key string) error {
+	if len(key) == 0 || len(key) > MaxKeyLength {
+		return ErrBadKey
+	}
+	return nil
+}
+
+// Key - ключ в хранилище: клиентский ключ действует только для своего маршрута и своего пользователя,
+// а у анонимных запросов - для IP-адреса ip, поэтому чужой ключ не даст получить чужой ответ
+func Key(ctx context.Context, route, ip, key string) string {
+	principal := "ip:" + ip
+	if userID, ok := auth.UserID(ctx); ok {
+		principal = "user:" + strconv.FormatInt(userID, 10)
+	}
+	return route + "\n" + principal + "\n" + key
+}
+
+// Fingerprint - отпечаток запроса из его частей, например строки запроса и тела
+func Fingerprint(parts ...[]byte) string {
+	h := sha256.New()
+	for _, p := range parts {
+		// длина перед каждой частью, чтобы ("ab", "c") и ("a", "bc") различались
+		_ = binary.Write(h, binary.BigEndian, uint64(len(p)))
+		h.Write(p)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type record[T any] struct {
+	fingerprint string
+	done        bool
+	response    T
+	expires     time.Time
+}
+
+// Store хранит ответы на запросы с ключами идемпотентности в течение window после ответа, чтобы повтор
+// запроса с тем же ключом получил прежний ответ, а не выполнил запрос ещё раз. Ответы хранятся в памяти
+// и не переживают перезапуск сервиса; T - ответ в том виде, в каком его повторяет транспорт
+type Store[T any] struct {
+	mu      sync.Mutex
+	window  time.Duration
+	records map[string]*record[T]
+	swept   time.Time
+	now     func() time.Time
+}
+
+// New создаёт хранилище; window <= 0 - DefaultWindow
+func New[T any](window time.Duration) *Store[T] {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+
+	return &Store[T]{
+		window:  window,
+		records: make(map[string]*record[T]),
+		now:     time.Now,
+	}
+}
+
+// Begin начинает запрос с ключом key и отпечатком fingerprint. Если на запрос с этим ключом уже есть ответ,
+// Begin возвращает его и true; если запрос с этим ключом ещё выполняется - ErrInProgress, если ключ
+// использован для другого запроса - ErrMismatch. Иначе ключ занимается до вызова Finish или Abort
+func (s *Store[T]) Begin(key, fingerprint string) (T, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var empty T
+	now := s.now()
+	s.sweep(now)
+
+	r, ok := s.records[key]
+	if ok && r.done && !now.Before(r.expires) {
+		ok = false
+	}
+	if !ok {
+		s.records[key] = &record[T]{fingerprint: fingerprint}
+		return empty, false, nil
+	}
+
+	if r.fingerprint != fingerprint {
+		return empty, false, ErrMismatch
+	}
+	if !r.done {
+		return empty, false, ErrInProgress
+	}
+	return r.response, true, nil
+}
+
+// Finish сохраняет ответ на запрос, начатый Begin
+func (s *Store[T]) Finish(key string, response T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.records[key]
+	if !ok {
+		return
+	}
+	r.done = true
+	r.response = response
+	r.expires = s.now().Add(s.window)
+}
+
+// Abort освобождает ключ запроса, начатого Begin, не сохраняя ответ: так делают, когда запрос
+// не выполнился по временной причине, и повтор с тем же ключом должен выполнить его заново
+func (s *Store[T]) Abort(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r, ok := s.records[key]; ok && !r.done {
+		delete(s.records, key)
+	}
+}
+
+// sweep не чаще раза в window удаляет ответы, срок хранения которых истёк
+func (s *Store[T]) sweep(now time.Time) {
+	if now.Sub(s.swept) < s.window {
+		return
+	}
+	s.swept = now
+
+	for key, r := range s.records {
+		if r.done && !now.Before(r.expires) {
+			delete(s.records, key)
+		}
+	}
+}
+
+// size - сколько ключей сейчас хранится
+func (s *Store[T]) size() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.records)
+}
@@ -0,0 +1,117 @@
+package idempotency
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/auth"
+)
+
+func newTestStore(window time.Duration) (*Store[string], *time.Time) {
+	now := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	s := New[string](window)
+	s.now = func() time.Time {
+		return now
+	}
+	return s, &now
+}
+
+func TestStore(t *testing.T) {
+	s, _ := newTestStore(time.Hour)
+	fp := Fingerprint([]byte("body"))
+
+	_, done, err := s.Begin("key", fp)
+	assert.NoError(t, err)
+	assert.False(t, done)
+
+	// пока запрос выполняется, повтор отклоняется, как и другой запрос с тем же ключом
+	_, _, err = s.Begin("key", fp)
+	assert.ErrorIs(t, err, ErrInProgress)
+	_, _, err = s.Begin("key", Fingerprint([]byte("other")))
+	assert.ErrorIs(t, err, ErrMismatch)
+
+	s.Finish("key", "created")
+	resp, done, err := s.Begin("key", fp)
+	assert.NoError(t, err)
+	assert.True(t, done)
+	assert.Equal(t, "created", resp)
+
+	_, _, err = s.Begin("key", Fingerprint([]byte("other")))
+	assert.ErrorIs(t, err, ErrMismatch)
+}
+
+func TestStore_Abort(t *testing.T) {
+	s, _ := newTestStore(time.Hour)
+	fp := Fingerprint([]byte("body"))
+
+	_, _, err := s.Begin("key", fp)
+	assert.NoError(t, err)
+	s.Abort("key")
+
+	// после неудачи запрос с тем же ключом выполняется заново, в том числе с другим телом
+	_, done, err := s.Begin("key", Fingerprint([]byte("other")))
+	assert.NoError(t, err)
+	assert.False(t, done)
+
+	// сохранённый ответ Abort не удаляет
+	s.Finish("key", "created")
+	s.Abort("key")
+	_, done, err = s.Begin("key", Fingerprint([]byte("other")))
+	assert.NoError(t, err)
+	assert.True(t, done)
+}
+
+func TestStore_Expire(t *testing.T) {
+	s, now := newTestStore(time.Hour)
+	fp := Fingerprint([]byte("body"))
+
+	_, _, _ = s.Begin("old", fp)
+	s.Finish("old", "old")
+	*now = now.Add(30 * time.Minute)
+	_, _, _ = s.Begin("new", fp)
+	s.Finish("new", "new")
+	_, _, _ = s.Begin("running", fp)
+
+	// по истечении window ключ можно использовать заново, и старые ответы удаляются
+	*now = now.Add(30 * time.Minute)
+	_, done, err := s.Begin("old", Fingerprint([]byte("other")))
+	assert.NoError(t, err)
+	assert.False(t, done)
+	assert.Equal(t, 3, s.size())
+
+	resp, done, err := s.Begin("new", fp)
+	assert.NoError(t, err)
+	assert.True(t, done)
+	assert.Equal(t, "new", resp)
+}
+
+func TestKey(t *testing.T) {
+	anonymous := Key(context.Background(), "POST /api/v1/ads", "10.0.0.1", "abc")
+	neighbour := Key(context.Background(), "POST /api/v1/ads", "10.0.0.2", "abc")
+	user := Key(auth.WithUserID(context.Background(), 5), "POST /api/v1/ads", "10.0.0.1", "abc")
+	moved := Key(auth.WithUserID(context.Background(), 5), "POST /api/v1/ads", "10.0.0.2", "abc")
+	other := Key(auth.WithUserID(context.Background(), 6), "POST /api/v1/ads", "10.0.0.1", "abc")
+	route := Key(auth.WithUserID(context.Background(), 5), "POST /api/v1/users", "10.0.0.1", "abc")
+
+	// анонимные клиенты с разных адресов не получают ответы друг друга, а ключ пользователя от адреса не зависит
+	assert.NotEqual(t, anonymous, neighbour)
+	assert.NotEqual(t, anonymous, user)
+	assert.Equal(t, user, moved)
+	assert.NotEqual(t, user, other)
+	assert.NotEqual(t, user, route)
+}
+
+func TestFingerprint(t *testing.T) {
+	assert.Equal(t, Fingerprint([]byte("a"), []byte("b")), Fingerprint([]byte("a"), []byte("b")))
+	assert.NotEqual(t, Fingerprint([]byte("ab"), []byte("c")), Fingerprint([]byte("a"), []byte("bc")))
+}
+
+func TestCheckKey(t *testing.T) {
+	assert.NoError(t, CheckKey("0b8e4d3a-7c2f-4f38-9d0e-2f6a1c5b9e71"))
+	assert.ErrorIs(t, CheckKey(""), ErrBadKey)
+	assert.ErrorIs(t, CheckKey(strings.Repeat("k", MaxKeyLength+1)), ErrBadKey)
+}
@@ -0,0 +1,104 @@
+package images
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encode(t *testing.T, format string, w, h int) []byte {
+	t.Helper()
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	default:
+		err = gif.Encode(&buf, img, nil)
+	}
+	assert.NoError(t, err)
+	return buf.Bytes()
+}
+
+func TestProcess(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   []byte
+		ct     string
+		thumbW int
+		thumbH int
+		err    error
+	}{
+		{name: "landscape png", data: encode(t, "png", 800, 400), ct: "image/png", thumbW: 320, thumbH: 160},
+		{name: "portrait jpeg", data: encode(t, "jpeg", 300, 900), ct: "image/jpeg", thumbW: 106, thumbH: 320},
+		{name: "small image is not enlarged", data: encode(t, "png", 40, 30), ct: "image/png", thumbW: 40, thumbH: 30},
+		{name: "gif", data: encode(t, "gif", 10, 10), err: ErrBadFormat},
+		{name: "not an image", data: []byte("hello"), err: ErrBadFormat},
+		{name: "broken png", data: encode(t, "png", 10, 10)[:40], err: ErrBadFormat},
+		{name: "too large file", data: append(encode(t, "png", 10, 10), make([]byte, MaxSize)...), err: ErrTooLarge},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := Process(tc.data)
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.ct, p.ContentType)
+
+			cfg, format, err := image.DecodeConfig(bytes.NewReader(p.Thumb))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.ct, "image/"+format)
+			assert.Equal(t, tc.thumbW, cfg.Width)
+			assert.Equal(t, tc.thumbH, cfg.Height)
+		})
+	}
+}
+
+func TestProcess_TooManyPixels(t *testing.T) {
+	// заголовок PNG, объявляющий картинку 10000x10000 - распаковывать её не нужно, чтобы отказать
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 10000, 10000))))
+
+	_, err := Process(buf.Bytes())
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
+
+func TestThumbnail_Averages(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 2, 1))
+	img.SetGray(1, 0, color.Gray{Y: 255})
+
+	thumb := Thumbnail(img, 1)
+
+	assert.Equal(t, image.Rect(0, 0, 1, 1), thumb.Bounds())
+	r, _, _, _ := thumb.At(0, 0).RGBA()
+	assert.InDelta(t, 0xffff/2, r, 1)
+}
+
+func TestKey(t *testing.T) {
+	key := Key([]byte("hello"))
+
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", key)
+	assert.True(t, ValidKey(key))
+	assert.False(t, ValidKey("../../etc/passwd"))
+	assert.False(t, ValidKey(key[:10]))
+	assert.Equal(t, "/api/v1/images/"+key, URL(key))
+}
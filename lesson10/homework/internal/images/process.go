@@ -0,0 +1,121 @@
+package images
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+const (
+	// MaxSize - наибольший размер загружаемого изображения в байтах
+	MaxSize = 5 << 20
+	// MaxPixels - наибольшее число пикселей: защищает от маленьких файлов, которые распаковываются в гигабайты
+	MaxPixels = 40_000_000
+	// ThumbSize - наибольшая ширина и высота миниатюры
+	ThumbSize = 320
+)
+
+var (
+	ErrTooLarge  = fmt.Errorf("image is too large")
+	ErrBadFormat = fmt.Errorf("image must be JPEG or PNG")
+)
+
+// Processed - проверенное изображение и его миниатюра в том же формате
+type Processed struct {
+	ContentType string
+	Width       int
+	Height      int
+	Thumb       []byte
+}
+
+// Process проверяет размер и формат изображения и строит его миниатюру
+func Process(data []byte) (Processed, error) {
+	if len(data) > MaxSize {
+		return Processed{}, ErrTooLarge
+	}
+
+	ct := http.DetectContentType(data)
+	if ct != "image/jpeg" && ct != "image/png" {
+		return Processed{}, ErrBadFormat
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Processed{}, fmt.Errorf("%w: %s", ErrBadFormat, err.Error())
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return Processed{}, ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Processed{}, fmt.Errorf("%w: %s", ErrBadFormat, err.Error())
+	}
+
+	var buf bytes.Buffer
+	thumb := Thumbnail(img, ThumbSize)
+	if ct == "image/png" {
+		err = png.Encode(&buf, thumb)
+	} else {
+		err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 80})
+	}
+	if err != nil {
+		return Processed{}, err
+	}
+
+	return Processed{
+		ContentType: ct,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+		Thumb:       buf.Bytes(),
+	}, nil
+}
+
+// Thumbnail уменьшает изображение, сохраняя пропорции, чтобы обе стороны были не больше size;
+// каждый пиксель миниатюры - среднее покрываемых им пикселей исходника. Маленькие изображения не увеличиваются
+func Thumbnail(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	tw, th := w, h
+	if w > size || h > size {
+		if w >= h {
+			tw, th = size, max(1, h*size/w)
+		} else {
+			tw, th = max(1, w*size/h), size
+		}
+	}
+
+	dst := image.NewNRGBA64(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := b.Min.Y+y*h/th, b.Min.Y+(y+1)*h/th
+		for x := 0; x < tw; x++ {
+			x0, x1 := b.Min.X+x*w/tw, b.Min.X+(x+1)*w/tw
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBA64Model.Convert(img.At(sx, sy)).(color.NRGBA64)
+					r += uint64(c.R)
+					g += uint64(c.G)
+					bl += uint64(c.B)
+					a += uint64(c.A)
+					n++
+				}
+			}
+			dst.SetNRGBA64(x, y, color.NRGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n)})
+		}
+	}
+
+	return dst
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
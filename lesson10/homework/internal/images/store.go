@@ -0,0 +1,46 @@
+package images
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Store - хранилище файлов, адресуемых по содержимому: одинаковые файлы хранятся один раз,
+// а удаляются, когда их освободят столько же раз, сколько добавили
+//
+//go:generate mockery --name Store
+type Store interface {
+	// Put сохраняет файл (или увеличивает число ссылок на уже сохранённый) и возвращает его ключ
+	Put(ctx context.Context, data []byte) (string, error)
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Release освобождает одну ссылку на файл, последняя освобождённая ссылка удаляет его
+	Release(ctx context.Context, key string) error
+}
+
+// Key - ключ файла в Store: SHA-256 содержимого в hex
+func Key(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidKey проверяет, что key имеет вид ключа Store (и потому безопасен как имя файла)
+func ValidKey(key string) bool {
+	if len(key) != sha256.Size*2 {
+		return false
+	}
+	for _, c := range key {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// URLPrefix - путь HTTP API, по которому отдаются файлы Store
+const URLPrefix = "/api/v1/images/"
+
+// URL - адрес файла с ключом key в HTTP API
+func URL(key string) string {
+	return URLPrefix + key
+}
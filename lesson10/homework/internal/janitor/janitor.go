@@ -0,0 +1,61 @@
+package janitor
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"homework10/internal/app"
+)
+
+// DefaultInterval - как часто по умолчанию проверяются сроки объявлений
+const DefaultInterval = time.Minute
+
+// Janitor периодически убирает в архив объявления с истёкшим сроком
+type Janitor struct {
+	app      app.App
+	interval time.Duration
+	logger   *slog.Logger
+}
+
+func New(a app.App, interval time.Duration, logger *slog.Logger) *Janitor {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	return &Janitor{
+		app:      a,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Run проверяет объявления сразу и затем каждые interval, пока не отменён ctx
+func (j *Janitor) Run(ctx context.Context) error {
+	j.logger.Info("starting janitor", "interval", j.interval)
+	defer j.logger.Info("janitor was stopped")
+
+	t := time.NewTicker(j.interval)
+	defer t.Stop()
+
+	j.sweep(ctx, time.Now())
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-t.C:
+			j.sweep(ctx, now)
+		}
+	}
+}
+
+func (j *Janitor) sweep(ctx context.Context, now time.Time) {
+	n, err := j.app.ExpireAds(ctx, now.UTC())
+	if err != nil {
+		j.logger.ErrorContext(ctx, "janitor can't expire ads", "err", err)
+		return
+	}
+	if n > 0 {
+		j.logger.InfoContext(ctx, "janitor archived expired ads", "count", n)
+	}
+}
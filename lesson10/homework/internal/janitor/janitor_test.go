@@ -0,0 +1,50 @@
+package janitor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"homework10/internal/app/mocks"
+	"homework10/internal/logging"
+)
+
+func TestJanitor_Run(t *testing.T) {
+	a := mocks.NewApp(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// первая проверка - сразу при запуске, ошибка не останавливает janitor
+	a.
+		On("ExpireAds", mock.Anything, mock.Anything).
+		Return(0, fmt.Errorf("repo is down")).
+		Once()
+	a.
+		On("ExpireAds", mock.Anything, mock.Anything).
+		Return(2, nil).
+		Run(func(mock.Arguments) { cancel() })
+
+	var out bytes.Buffer
+	done := make(chan error)
+	go func() {
+		done <- New(a, time.Millisecond, logging.New(&out, slog.LevelInfo)).Run(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+		assert.Contains(t, out.String(), `"msg":"janitor can't expire ads","err":"repo is down"`)
+		assert.Contains(t, out.String(), `"msg":"janitor archived expired ads","count":2`)
+	case <-time.After(time.Second):
+		assert.Fail(t, "janitor wasn't stopped")
+	}
+}
+
+func TestNew_DefaultInterval(t *testing.T) {
+	assert.Equal(t, DefaultInterval, New(mocks.NewApp(t), 0, logging.Discard()).interval)
+}
@@ -0,0 +1,104 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"homework10/internal/auth"
+)
+
+// MaxRequestIDLength - максимальная длина ID запроса, присланного клиентом
+const MaxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// WithRequestID возвращает контекст запроса с ID id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID возвращает ID запроса; ok = false, если контекст не относится к запросу
+func RequestID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok
+}
+
+// NewRequestID генерирует случайный ID запроса
+func NewRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ValidRequestID проверяет ID запроса, присланный клиентом: он попадает в логи и заголовки ответа,
+// поэтому допускаются только буквы, цифры и символы - _ . : не длиннее MaxRequestIDLength
+func ValidRequestID(id string) bool {
+	if len(id) == 0 || len(id) > MaxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// RequestIDOrNew возвращает ID запроса, присланный клиентом, или новый, если клиент его не прислал
+// или прислал недопустимый
+func RequestIDOrNew(id string) string {
+	if ValidRequestID(id) {
+		return id
+	}
+	return NewRequestID()
+}
+
+// contextHandler дописывает к записям, сделанным с контекстом запроса (InfoContext и т.п.),
+// ID запроса, ID пользователя, от имени которого он выполняется, и ID трассировки запроса
+type contextHandler struct {
+	slog.Handler
+}
+
+// NewHandler оборачивает h так, чтобы записи с контекстом запроса содержали request_id, user_id и trace_id
+func NewHandler(h slog.Handler) slog.Handler {
+	return contextHandler{Handler: h}
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id, ok := RequestID(ctx); ok {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if userID, ok := auth.UserID(ctx); ok {
+		r.AddAttrs(slog.Int64("user_id", userID))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// New создаёт логгер, пишущий в w записи не ниже level по одной JSON-строке на запись
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(NewHandler(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})))
+}
+
+// Discard создаёт логгер, который ничего не пишет, - для тестов и там, где логи не нужны
+func Discard() *slog.Logger {
+	return New(io.Discard, slog.LevelError+1)
+}
@@ -0,0 +1,56 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+
+	"homework10/internal/auth"
+)
+
+func TestRequestIDOrNew(t *testing.T) {
+	assert.Equal(t, "0b8e4d3a-7c2f-4f38", RequestIDOrNew("0b8e4d3a-7c2f-4f38"))
+
+	for _, id := range []string{"", "with space", "line\nbreak", strings.Repeat("a", MaxRequestIDLength+1)} {
+		generated := RequestIDOrNew(id)
+		assert.NotEqual(t, id, generated)
+		assert.True(t, ValidRequestID(generated))
+	}
+	assert.NotEqual(t, NewRequestID(), NewRequestID())
+}
+
+func TestNew(t *testing.T) {
+	var out bytes.Buffer
+	l := New(&out, slog.LevelInfo)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := auth.WithUserID(WithRequestID(context.Background(), "req-1"), 7)
+	ctx = trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+	l.With("component", "test").InfoContext(ctx, "hello", "n", 1)
+	l.DebugContext(ctx, "hidden")
+	l.Info("no context")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 2)
+
+	var record map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "hello", record["msg"])
+	assert.Equal(t, "test", record["component"])
+	assert.Equal(t, "req-1", record["request_id"])
+	assert.Equal(t, float64(7), record["user_id"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record["trace_id"])
+
+	record = nil
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.NotContains(t, record, "request_id")
+	assert.NotContains(t, record, "user_id")
+	assert.NotContains(t, record, "trace_id")
+}
@@ -0,0 +1,64 @@
+package messages
+
+import "time"
+
+// Thread - переписка покупателя с автором объявления; у каждого покупателя по объявлению одна переписка.
+// ID переписок начинаются с 1
+type Thread struct {
+	ID      int64
+	AdID    int64
+	BuyerID int64
+	// SellerID - автор объявления на момент начала переписки
+	SellerID int64
+	Created  time.Time
+	// Updated - время последнего сообщения
+	Updated time.Time
+	// BuyerUnread и SellerUnread - сколько сообщений собеседника участник ещё не прочитал
+	BuyerUnread  int
+	SellerUnread int
+}
+
+type Message struct {
+	ID       int64
+	ThreadID int64
+	SenderID int64
+	Text     string `validate:"min:1;max:999"`
+	Sent     time.Time
+}
+
+// Has сообщает, участвует ли пользователь в переписке
+func (t *Thread) Has(userID int64) bool {
+	return userID == t.BuyerID || userID == t.SellerID
+}
+
+// UnreadFor возвращает, сколько непрочитанных сообщений в переписке у пользователя
+func (t *Thread) UnreadFor(userID int64) int {
+	switch userID {
+	case t.BuyerID:
+		return t.BuyerUnread
+	case t.SellerID:
+		return t.SellerUnread
+	}
+	return 0
+}
+
+// Received учитывает новое сообщение от sender: оно непрочитано у собеседника
+func (t *Thread) Received(sender int64, at time.Time) {
+	switch sender {
+	case t.BuyerID:
+		t.SellerUnread++
+	case t.SellerID:
+		t.BuyerUnread++
+	}
+	t.Updated = at
+}
+
+// Read отмечает все сообщения переписки прочитанными пользователем
+func (t *Thread) Read(userID int64) {
+	switch userID {
+	case t.BuyerID:
+		t.BuyerUnread = 0
+	case t.SellerID:
+		t.SellerUnread = 0
+	}
+}
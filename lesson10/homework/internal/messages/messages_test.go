@@ -0,0 +1,62 @@
+package messages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThread_Unread(t *testing.T) {
+	th := &Thread{ID: 1, AdID: 10, BuyerID: 2, SellerID: 1}
+	at := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	th.Received(2, at)
+	th.Received(2, at.Add(time.Minute))
+	th.Received(1, at.Add(2*time.Minute))
+	assert.Equal(t, 2, th.UnreadFor(1))
+	assert.Equal(t, 1, th.UnreadFor(2))
+	assert.Equal(t, 0, th.UnreadFor(3))
+	assert.Equal(t, at.Add(2*time.Minute), th.Updated)
+
+	th.Read(1)
+	th.Read(3)
+	assert.Equal(t, 0, th.UnreadFor(1))
+	assert.Equal(t, 1, th.UnreadFor(2))
+
+	assert.True(t, th.Has(2))
+	assert.False(t, th.Has(3))
+}
+
+func TestPage_Cut(t *testing.T) {
+	var list []*Message
+	for ID := int64(1); ID <= 5; ID++ {
+		list = append(list, &Message{ID: ID})
+	}
+
+	IDs := func(list []*Message) []int64 {
+		var res []int64
+		for _, m := range list {
+			res = append(res, m.ID)
+		}
+		return res
+	}
+
+	page, next, err := Page{Limit: 2}.Cut(list)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{5, 4}, IDs(page))
+
+	page, next, err = Page{Limit: 2, Cursor: next}.Cut(list)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3, 2}, IDs(page))
+
+	page, next, err = Page{Limit: 2, Cursor: next}.Cut(list)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1}, IDs(page))
+	assert.Empty(t, next)
+
+	_, _, err = Page{Limit: -1}.Cut(list)
+	assert.ErrorIs(t, err, ErrBadPage)
+	_, _, err = Page{Cursor: "bm90IGFuIGlk"}.Cut(list)
+	assert.ErrorIs(t, err, ErrBadPage)
+}
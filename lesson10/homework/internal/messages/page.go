@@ -0,0 +1,84 @@
+package messages
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+const (
+	DefaultLimit = 50
+	MaxLimit     = 100
+)
+
+var ErrBadPage = fmt.Errorf("bad page parameters")
+
+// Page - параметры постраничной выборки сообщений: размер страницы и курсор, полученный с предыдущей страницей.
+// Сообщения идут от новых к старым, так что следующая страница - более ранние сообщения
+type Page struct {
+	Limit  int
+	Cursor string
+}
+
+func DefaultPage() Page {
+	return Page{Limit: DefaultLimit}
+}
+
+// Cut упорядочивает сообщения от новых к старым и вырезает из них страницу.
+// Вторым значением возвращает курсор следующей страницы или пустую строку, если страница последняя
+func (p Page) Cut(list []*Message) ([]*Message, string, error) {
+	limit := p.Limit
+	switch {
+	case limit < 0:
+		return nil, "", fmt.Errorf("%w: negative limit", ErrBadPage)
+	case limit == 0:
+		limit = DefaultLimit
+	case limit > MaxLimit:
+		limit = MaxLimit
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ID > list[j].ID
+	})
+
+	start := 0
+	if p.Cursor != "" {
+		before, err := p.decodeCursor()
+		if err != nil {
+			return nil, "", err
+		}
+		start = sort.Search(len(list), func(i int) bool {
+			return list[i].ID < before
+		})
+	}
+
+	end := start + limit
+	next := ""
+	if end < len(list) {
+		next = encodeCursor(list[end-1].ID)
+	} else {
+		end = len(list)
+	}
+
+	return list[start:end], next, nil
+}
+
+// курсор - ID последнего сообщения предыдущей страницы
+func encodeCursor(ID int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(ID, 10)))
+}
+
+func (p Page) decodeCursor() (int64, error) {
+	data, err := base64.RawURLEncoding.DecodeString(p.Cursor)
+	if err != nil {
+		return 0, fmt.Errorf("%w: bad cursor", ErrBadPage)
+	}
+
+	ID, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: bad cursor", ErrBadPage)
+	}
+
+	return ID, nil
+}
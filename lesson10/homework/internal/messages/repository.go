@@ -0,0 +1,19 @@
+package messages
+
+import "context"
+
+//go:generate mockery --name Repository
+type Repository interface {
+	ThreadByID(ctx context.Context, ID int64) (*Thread, error)
+	// ThreadByAd возвращает переписку покупателя buyerID по объявлению adID
+	ThreadByAd(ctx context.Context, adID, buyerID int64) (*Thread, error)
+	AddThread(ctx context.Context, t *Thread) (int64, error)
+	// Threads возвращает переписки, в которых участвует пользователь: сначала с самыми свежими сообщениями
+	Threads(ctx context.Context, userID int64) ([]*Thread, error)
+	// AddMessage добавляет сообщение в переписку и учитывает его в переписке (см. Thread.Received)
+	AddMessage(ctx context.Context, m *Message) (int64, error)
+	// Messages возвращает страницу сообщений переписки и курсор следующей страницы
+	Messages(ctx context.Context, threadID int64, page Page) ([]*Message, string, error)
+	// MarkRead отмечает переписку прочитанной пользователем (см. Thread.Read)
+	MarkRead(ctx context.Context, threadID, userID int64) error
+}
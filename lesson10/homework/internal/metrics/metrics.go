@@ -0,0 +1,140 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc/codes"
+
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/users"
+)
+
+// namespace - общий префикс имён метрик сервиса
+const namespace = "adapp"
+
+// Metrics - метрики сервиса в формате Prometheus. У каждого Metrics свой реестр, поэтому в одном процессе
+// (например, в тестах) их может быть сколько угодно
+type Metrics struct {
+	registry     *prometheus.Registry
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	grpcRequests *prometheus.CounterVec
+	grpcDuration *prometheus.HistogramVec
+	appErrors    *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by route and status code.",
+		}, []string{"method", "route", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of HTTP requests by route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		grpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "grpc_requests_total",
+			Help:      "Number of gRPC calls by method and status code.",
+		}, []string{"method", "code"}),
+		grpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "grpc_request_duration_seconds",
+			Help:      "Latency of gRPC calls by method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"}),
+		appErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "app_errors_total",
+			Help:      "Number of errors returned by the application by method and kind.",
+		}, []string{"method", "kind"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests, m.httpDuration, m.grpcRequests, m.grpcDuration, m.appErrors,
+	)
+	return m
+}
+
+// Handler отдаёт метрики в текстовом формате Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveHTTP учитывает HTTP-запрос к маршруту route, на который ответили кодом code за d
+func (m *Metrics) ObserveHTTP(method, route string, code int, d time.Duration) {
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(code)).Inc()
+	m.httpDuration.WithLabelValues(method, route).Observe(d.Seconds())
+}
+
+// ObserveGRPC учитывает вызов method, завершившийся с кодом code за d
+func (m *Metrics) ObserveGRPC(method string, code codes.Code, d time.Duration) {
+	m.grpcRequests.WithLabelValues(method, code.String()).Inc()
+	m.grpcDuration.WithLabelValues(method).Observe(d.Seconds())
+}
+
+// AppObserver считает ошибки вызовов приложения по их виду (см. app.ErrorKind); подключается через app.Observe
+func (m *Metrics) AppObserver() app.Observer {
+	return func(ctx context.Context, method string) (context.Context, func(error)) {
+		return ctx, func(err error) {
+			if err != nil {
+				m.appErrors.WithLabelValues(method, app.ErrorKind(err)).Inc()
+			}
+		}
+	}
+}
+
+// CountRepos добавляет метрики с числом объявлений и пользователей: они считаются по хранилищам при каждом
+// сборе метрик. Если хранилище не ответило, его метрика в этот сбор не попадает
+func (m *Metrics) CountRepos(adRepo ads.Repository, userRepo users.Repository) {
+	m.registry.MustRegister(&repoCollector{
+		ads:   adRepo,
+		users: userRepo,
+		adsDesc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "ads"),
+			"Number of ads in the repository.", nil, nil),
+		usersDesc: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "users"),
+			"Number of users in the repository.", nil, nil),
+	})
+}
+
+type repoCollector struct {
+	ads       ads.Repository
+	users     users.Repository
+	adsDesc   *prometheus.Desc
+	usersDesc *prometheus.Desc
+}
+
+func (c *repoCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.adsDesc
+	ch <- c.usersDesc
+}
+
+func (c *repoCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx := context.Background()
+
+	if counts, err := c.ads.CountByCategory(ctx, ads.DefaultPattern()); err == nil {
+		total := 0
+		for _, n := range counts {
+			total += n
+		}
+		ch <- prometheus.MustNewConstMetric(c.adsDesc, prometheus.GaugeValue, float64(total))
+	}
+
+	if n, err := c.users.CountUsers(ctx); err == nil {
+		ch <- prometheus.MustNewConstMetric(c.usersDesc, prometheus.GaugeValue, float64(n))
+	}
+}
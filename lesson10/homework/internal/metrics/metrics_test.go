@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/userrepo"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/users"
+	usersMock "homework10/internal/users/mocks"
+)
+
+// scrape возвращает метрики так, как их получит Prometheus
+func scrape(t *testing.T, m *Metrics) string {
+	r := httptest.NewRecorder()
+	m.Handler().ServeHTTP(r, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, r.Code)
+	return r.Body.String()
+}
+
+func TestMetrics_Requests(t *testing.T) {
+	m := New()
+	m.ObserveHTTP(http.MethodPost, "/api/v1/ads", http.StatusOK, 20*time.Millisecond)
+	m.ObserveHTTP(http.MethodPost, "/api/v1/ads", http.StatusOK, 30*time.Millisecond)
+	m.ObserveHTTP(http.MethodGet, "/api/v1/ads/:ad_id", http.StatusNotFound, time.Millisecond)
+	m.ObserveGRPC("/ad.AdService/CreateAd", codes.InvalidArgument, time.Millisecond)
+
+	out := scrape(t, m)
+	assert.Contains(t, out, `adapp_http_requests_total{code="200",method="POST",route="/api/v1/ads"} 2`)
+	assert.Contains(t, out, `adapp_http_requests_total{code="404",method="GET",route="/api/v1/ads/:ad_id"} 1`)
+	assert.Contains(t, out, `adapp_http_request_duration_seconds_count{method="POST",route="/api/v1/ads"} 2`)
+	assert.Contains(t, out, `adapp_http_request_duration_seconds_bucket{method="POST",route="/api/v1/ads",le="0.025"} 1`)
+	assert.Contains(t, out, `adapp_grpc_requests_total{code="InvalidArgument",method="/ad.AdService/CreateAd"} 1`)
+	assert.Contains(t, out, `adapp_grpc_request_duration_seconds_count{method="/ad.AdService/CreateAd"} 1`)
+	assert.Contains(t, out, "go_goroutines")
+}
+
+func TestMetrics_AppObserver(t *testing.T) {
+	m := New()
+	observe := m.AppObserver()
+
+	for _, err := range []error{
+		nil,
+		app.ErrBadRequest,
+		fmt.Errorf("%w: title is empty", app.ErrBadRequest),
+		app.ErrForbidden,
+		app.ErrInternalAdRepoError,
+	} {
+		_, done := observe(context.Background(), "CreateAd")
+		done(err)
+	}
+
+	out := scrape(t, m)
+	assert.Contains(t, out, `adapp_app_errors_total{kind="bad_request",method="CreateAd"} 2`)
+	assert.Contains(t, out, `adapp_app_errors_total{kind="forbidden",method="CreateAd"} 1`)
+	assert.Contains(t, out, `adapp_app_errors_total{kind="internal",method="CreateAd"} 1`)
+}
+
+func TestMetrics_CountRepos(t *testing.T) {
+	ctx := context.Background()
+	adRepo, userRepo := adrepo.New(), userrepo.New()
+	for i := 0; i < 3; i++ {
+		_, _ = adRepo.AddAd(ctx, &ads.Ad{ID: -1, Title: "title", Text: "text"})
+	}
+	_, _ = userRepo.AddUser(ctx, &users.User{ID: -1, Nickname: "jenny", Email: "jenny@gmail.com"})
+
+	m := New()
+	m.CountRepos(adRepo, userRepo)
+	out := scrape(t, m)
+	assert.Contains(t, out, "adapp_ads 3\n")
+	assert.Contains(t, out, "adapp_users 1\n")
+
+	// не ответившее хранилище пропускается, остальные метрики отдаются как обычно
+	broken := usersMock.NewRepository(t)
+	broken.On("CountUsers", mock.Anything).Return(0, fmt.Errorf("repo is down"))
+	m = New()
+	m.CountRepos(adRepo, broken)
+	out = scrape(t, m)
+	assert.Contains(t, out, "adapp_ads 3\n")
+	assert.NotContains(t, out, "adapp_users ")
+}
@@ -0,0 +1,103 @@
+package policy
+
+import (
+	"fmt"
+
+	"homework10/internal/users"
+)
+
+var ErrDenied = fmt.Errorf("access denied")
+
+// Action - действие над объявлением, пользователем, категорией или подписками на события, право на которое проверяет политика
+type Action string
+
+const (
+	UpdateAd    Action = "ad.update"
+	PublishAd   Action = "ad.publish"
+	UnpublishAd Action = "ad.unpublish"
+	DeleteAd    Action = "ad.delete"
+	SubmitAd    Action = "ad.submit"
+	ModerateAd  Action = "ad.moderate"
+	ArchiveAd   Action = "ad.archive"
+	RestoreAd   Action = "ad.restore"
+	RenewAd     Action = "ad.renew"
+	// ReadAdHistory - просмотр журнала изменений объявления, RevertAd - откат объявления к прежнему состоянию
+	ReadAdHistory Action = "ad.history"
+	RevertAd      Action = "ad.revert"
+
+	UpdateUser     Action = "user.update"
+	DeleteUser     Action = "user.delete"
+	ChangeUserRole Action = "user.change_role"
+	// ManageFavorites - просмотр и изменение избранного пользователя
+	ManageFavorites Action = "user.favorites"
+	// ReadMessages - просмотр списка переписок пользователя
+	ReadMessages Action = "user.messages"
+	// ManageSearches - сохранённые поиски пользователя и уведомления по ним
+	ManageSearches Action = "user.searches"
+
+	ManageCategories Action = "category.manage"
+
+	// ManageWebhooks - подписки на события сервиса и журнал их доставок
+	ManageWebhooks Action = "webhook.manage"
+)
+
+// rule - кому разрешено действие: владельцу объекта и/или пользователям с перечисленными ролями
+type rule struct {
+	owner bool
+	roles []users.Role
+}
+
+var rules = map[Action]rule{
+	// менять содержимое объявления и публиковать его прежним ChangeAdStatus может только автор
+	UpdateAd:  {owner: true},
+	PublishAd: {owner: true},
+	// отправить на модерацию (или отозвать с неё) и вернуть из архива может только автор
+	SubmitAd:  {owner: true},
+	RestoreAd: {owner: true},
+	RenewAd:   {owner: true},
+	RevertAd:  {owner: true},
+	// одобряют, отклоняют и публикуют через жизненный цикл (событие publish) только модераторы, даже свои:
+	// там автор может лишь отправить объявление на модерацию
+	ModerateAd: {roles: []users.Role{users.RoleModerator, users.RoleAdmin}},
+	ArchiveAd:  {owner: true, roles: []users.Role{users.RoleModerator, users.RoleAdmin}},
+	// снять с публикации или удалить любое объявление могут модераторы
+	UnpublishAd: {owner: true, roles: []users.Role{users.RoleModerator, users.RoleAdmin}},
+	DeleteAd:    {owner: true, roles: []users.Role{users.RoleModerator, users.RoleAdmin}},
+	// историю видят те, кто может удалить объявление: прежние версии не должны быть доступнее нынешней
+	ReadAdHistory: {owner: true, roles: []users.Role{users.RoleModerator, users.RoleAdmin}},
+
+	// пользователями управляют администраторы
+	UpdateUser:     {owner: true, roles: []users.Role{users.RoleAdmin}},
+	DeleteUser:     {owner: true, roles: []users.Role{users.RoleAdmin}},
+	ChangeUserRole: {roles: []users.Role{users.RoleAdmin}},
+	// избранное, переписки и сохранённые поиски личные: их не видят и не меняют даже администраторы
+	ManageFavorites: {owner: true},
+	ReadMessages:    {owner: true},
+	ManageSearches:  {owner: true},
+
+	// дерево категорий общее для всех, его ведут администраторы
+	ManageCategories: {roles: []users.Role{users.RoleAdmin}},
+	// события несут черновики и данные пользователей, поэтому подписываться на них могут только администраторы
+	ManageWebhooks: {roles: []users.Role{users.RoleAdmin}},
+}
+
+// Check проверяет, что actor может выполнить action над объектом, принадлежащим ownerID
+// (для действий над пользователями и их избранным ownerID - ID самого пользователя, у категорий владельца нет - ownerID не важен)
+func Check(actor *users.User, action Action, ownerID int64) error {
+	r, ok := rules[action]
+	if !ok {
+		return fmt.Errorf("%w: unknown action %q", ErrDenied, action)
+	}
+
+	if r.owner && actor.ID == ownerID {
+		return nil
+	}
+	role := actor.RoleOf()
+	for _, allowed := range r.roles {
+		if role == allowed {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s can't %s", ErrDenied, role, action)
+}
@@ -0,0 +1,75 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/users"
+)
+
+func TestCheck(t *testing.T) {
+	user := &users.User{ID: 1}
+	moderator := &users.User{ID: 2, Role: users.RoleModerator}
+	admin := &users.User{ID: 3, Role: users.RoleAdmin}
+
+	tests := []struct {
+		name    string
+		actor   *users.User
+		action  Action
+		ownerID int64
+		allowed bool
+	}{
+		{name: "owner updates ad", actor: user, action: UpdateAd, ownerID: 1, allowed: true},
+		{name: "user updates foreign ad", actor: user, action: UpdateAd, ownerID: 5},
+		{name: "moderator updates foreign ad", actor: moderator, action: UpdateAd, ownerID: 5},
+		{name: "moderator publishes foreign ad", actor: moderator, action: PublishAd, ownerID: 5},
+		{name: "user unpublishes foreign ad", actor: user, action: UnpublishAd, ownerID: 5},
+		{name: "moderator unpublishes foreign ad", actor: moderator, action: UnpublishAd, ownerID: 5, allowed: true},
+		{name: "admin unpublishes foreign ad", actor: admin, action: UnpublishAd, ownerID: 5, allowed: true},
+		{name: "user deletes foreign ad", actor: user, action: DeleteAd, ownerID: 5},
+		{name: "moderator deletes foreign ad", actor: moderator, action: DeleteAd, ownerID: 5, allowed: true},
+		{name: "owner submits ad", actor: user, action: SubmitAd, ownerID: 1, allowed: true},
+		{name: "moderator submits foreign ad", actor: moderator, action: SubmitAd, ownerID: 5},
+		{name: "owner approves own ad", actor: user, action: ModerateAd, ownerID: 1},
+		{name: "moderator approves foreign ad", actor: moderator, action: ModerateAd, ownerID: 5, allowed: true},
+		{name: "moderator archives foreign ad", actor: moderator, action: ArchiveAd, ownerID: 5, allowed: true},
+		{name: "moderator restores foreign ad", actor: moderator, action: RestoreAd, ownerID: 5},
+		{name: "owner renews ad", actor: user, action: RenewAd, ownerID: 1, allowed: true},
+		{name: "admin renews foreign ad", actor: admin, action: RenewAd, ownerID: 5},
+		{name: "owner reads ad history", actor: user, action: ReadAdHistory, ownerID: 1, allowed: true},
+		{name: "user reads foreign ad history", actor: user, action: ReadAdHistory, ownerID: 5},
+		{name: "moderator reads foreign ad history", actor: moderator, action: ReadAdHistory, ownerID: 5, allowed: true},
+		{name: "owner reverts ad", actor: user, action: RevertAd, ownerID: 1, allowed: true},
+		{name: "admin reverts foreign ad", actor: admin, action: RevertAd, ownerID: 5},
+		{name: "user updates himself", actor: user, action: UpdateUser, ownerID: 1, allowed: true},
+		{name: "moderator updates other user", actor: moderator, action: UpdateUser, ownerID: 5},
+		{name: "admin updates other user", actor: admin, action: UpdateUser, ownerID: 5, allowed: true},
+		{name: "admin deletes other user", actor: admin, action: DeleteUser, ownerID: 5, allowed: true},
+		{name: "user changes own role", actor: user, action: ChangeUserRole, ownerID: 1},
+		{name: "admin changes role", actor: admin, action: ChangeUserRole, ownerID: 5, allowed: true},
+		{name: "moderator manages categories", actor: moderator, action: ManageCategories, ownerID: 2},
+		{name: "admin manages categories", actor: admin, action: ManageCategories, ownerID: 3, allowed: true},
+		{name: "user manages own favorites", actor: user, action: ManageFavorites, ownerID: 1, allowed: true},
+		{name: "admin manages foreign favorites", actor: admin, action: ManageFavorites, ownerID: 5},
+		{name: "user reads own messages", actor: user, action: ReadMessages, ownerID: 1, allowed: true},
+		{name: "moderator reads foreign messages", actor: moderator, action: ReadMessages, ownerID: 5},
+		{name: "user manages own searches", actor: user, action: ManageSearches, ownerID: 1, allowed: true},
+		{name: "admin manages foreign searches", actor: admin, action: ManageSearches, ownerID: 5},
+		{name: "user manages own webhooks", actor: user, action: ManageWebhooks, ownerID: 1},
+		{name: "moderator manages webhooks", actor: moderator, action: ManageWebhooks, ownerID: 2},
+		{name: "admin manages webhooks", actor: admin, action: ManageWebhooks, ownerID: 3, allowed: true},
+		{name: "unknown action", actor: admin, action: "ad.steal", ownerID: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Check(tt.actor, tt.action, tt.ownerID)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrDenied)
+			}
+		})
+	}
+}
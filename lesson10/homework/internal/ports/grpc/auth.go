@@ -0,0 +1,66 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"homework10/internal/app"
+	"homework10/internal/auth"
+)
+
+// UnaryAuthInterceptor по метаданным "authorization: Bearer <access-токен>" определяет пользователя
+// и кладёт его ID в контекст вызова. Вызовы без метаданных выполняются анонимно,
+// а методы, которым нужен пользователь, сами вернут Unauthenticated.
+func UnaryAuthInterceptor(a app.App) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(ctx, a)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamAuthInterceptor - то же, что UnaryAuthInterceptor, для потоковых вызовов
+func StreamAuthInterceptor(a app.App) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(ss.Context(), a)
+		if err != nil {
+			return err
+		}
+
+		wrapped := middleware.WrapServerStream(ss)
+		wrapped.WrappedContext = ctx
+		return handler(srv, wrapped)
+	}
+}
+
+func authenticate(ctx context.Context, a app.App) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return ctx, nil
+	}
+
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	}
+
+	userID, err := a.Authenticate(ctx, token)
+	if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return auth.WithUserID(ctx, userID), nil
+}
@@ -0,0 +1,33 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"homework10/internal/health"
+)
+
+// WatchHealth раз в interval переносит готовность p в статус s - общий статус сервера (пустое имя сервиса)
+// и статус AdService, - пока ctx не отменён. После s.Shutdown статус больше не меняется
+func WatchHealth(ctx context.Context, s *grpchealth.Server, p *health.Probe, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		status := healthpb.HealthCheckResponse_SERVING
+		if err := p.Ready(ctx); err != nil {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		s.SetServingStatus("", status)
+		s.SetServingStatus(AdService_ServiceDesc.ServiceName, status)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
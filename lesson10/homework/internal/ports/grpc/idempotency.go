@@ -0,0 +1,101 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"homework10/internal/idempotency"
+)
+
+// idempotentMethods - создающие методы, которые принимают ключ идемпотентности
+var idempotentMethods = map[string]bool{
+	"/ad.AdService/CreateAd":          true,
+	"/ad.AdService/CreateUser":        true,
+	"/ad.AdService/CreateCategory":    true,
+	"/ad.AdService/CreateWebhook":     true,
+	"/ad.AdService/CreateSavedSearch": true,
+	"/ad.AdService/ContactSeller":     true,
+	"/ad.AdService/SendMessage":       true,
+}
+
+// reply - сохранённый ответ на вызов с ключом идемпотентности
+type reply struct {
+	resp any
+	err  error
+}
+
+// UnaryIdempotencyInterceptor делает создающие вызовы с метаданными "idempotency-key" идемпотентными: ответ на первый
+// вызов хранится window, и повтор с тем же ключом и тем же запросом получает его с заголовком idempotent-replayed,
+// не создавая ничего заново. Тот же ключ с другим запросом, как и повтор ещё не завершённого вызова, - Aborted.
+// Ответы с временными ошибками не сохраняются: повтор после них выполняет вызов заново
+func UnaryIdempotencyInterceptor(window time.Duration) grpc.UnaryServerInterceptor {
+	keys := idempotency.New[reply](window)
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get("idempotency-key")
+		if !idempotentMethods[info.FullMethod] || len(values) == 0 {
+			return handler(ctx, req)
+		}
+		if err := idempotency.CheckKey(values[0]); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		msg, ok := req.(proto.Message)
+		if !ok {
+			return handler(ctx, req)
+		}
+		data, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+		if err != nil {
+			return nil, status.Error(codes.Internal, "Internal server error")
+		}
+
+		k := idempotency.Key(ctx, info.FullMethod, peerIP(ctx), values[0])
+		stored, done, err := keys.Begin(k, idempotency.Fingerprint(data))
+		if errors.Is(err, idempotency.ErrMismatch) || errors.Is(err, idempotency.ErrInProgress) {
+			return nil, status.Error(codes.Aborted, err.Error())
+		} else if err != nil {
+			return nil, status.Error(codes.Internal, "Internal server error")
+		}
+		if done {
+			_ = grpc.SetHeader(ctx, metadata.Pairs("idempotent-replayed", "true"))
+			return stored.resp, stored.err
+		}
+
+		// если обработчик упадёт, ключ освобождается, и повтор выполнит вызов заново
+		finished := false
+		defer func() {
+			if !finished {
+				keys.Abort(k)
+			}
+		}()
+
+		resp, err := handler(ctx, req)
+		if temporary(status.Code(err)) {
+			keys.Abort(k)
+		} else {
+			keys.Finish(k, reply{resp: resp, err: err})
+		}
+		finished = true
+
+		return resp, err
+	}
+}
+
+// temporary сообщает, что вызов не выполнился по временной причине и его стоит повторить
+func temporary(code codes.Code) bool {
+	switch code {
+	case codes.Canceled, codes.Unknown, codes.DeadlineExceeded, codes.ResourceExhausted,
+		codes.Aborted, codes.Internal, codes.Unavailable, codes.Unauthenticated:
+		return true
+	default:
+		return false
+	}
+}
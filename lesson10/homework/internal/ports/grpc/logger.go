@@ -2,40 +2,110 @@ package grpc
 
 import (
 	"context"
-	"log"
+	"log/slog"
+	"runtime/debug"
 	"time"
 
+	middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
-)
 
-const (
-	green   = "\033[97;42m"
-	red     = "\033[97;41m"
-	magenta = "\033[97;45m"
-	cyan    = "\033[97;46m"
-	reset   = "\033[0m"
+	"homework10/internal/logging"
 )
 
-func UnaryLogInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
-	start := time.Now()
+// MetadataRequestID - ключ метаданных с ID вызова: клиент может прислать свой, иначе он генерируется;
+// в заголовке ответа ID есть всегда
+const MetadataRequestID = "x-request-id"
+
+// UnaryLogInterceptor присваивает вызову ID, кладёт его в контекст и после завершения пишет вызов в l:
+// внутренние ошибки - с уровнем ERROR, остальные - WARN, успешные вызовы - INFO. Должен стоять в цепочке первым
+// после UnaryTraceInterceptor
+func UnaryLogInterceptor(l *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		ctx = withRequestID(ctx)
+		_ = grpc.SetHeader(ctx, metadata.Pairs(MetadataRequestID, requestID(ctx)))
+
+		resp, err := handler(ctx, req)
+
+		logCall(ctx, l, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamLogInterceptor - то же, что UnaryLogInterceptor, для потоковых вызовов: пишет вызов после закрытия потока
+func StreamLogInterceptor(l *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx := withRequestID(ss.Context())
+		_ = ss.SetHeader(metadata.Pairs(MetadataRequestID, requestID(ctx)))
+
+		wrapped := middleware.WrapServerStream(ss)
+		wrapped.WrappedContext = ctx
+		err := handler(srv, wrapped)
+
+		logCall(ctx, l, info.FullMethod, start, err)
+		return err
+	}
+}
 
-	h, err := handler(ctx, req)
+// RecoveryHandler отвечает Internal на вызов, обработчик которого запаниковал, и пишет панику в l вместе со стеком
+func RecoveryHandler(l *slog.Logger) recovery.RecoveryHandlerFuncContext {
+	return func(ctx context.Context, p any) error {
+		l.ErrorContext(ctx, "panic", slog.Any("panic", p), slog.String("stack", string(debug.Stack())))
+		return status.Error(codes.Internal, "Internal server error")
+	}
+}
 
-	st, _ := status.FromError(err)
-	log.SetPrefix("[ADAPP] (req) - ")
-	log.Printf("- <"+errCodeColor(err)+"%d"+reset+"> - meth:"+cyan+"%s"+reset+" - dur:"+magenta+"%s"+reset,
-		st.Code(),
-		info.FullMethod,
-		time.Since(start),
-	)
+// withRequestID кладёт в контекст ID вызова из метаданных или новый
+func withRequestID(ctx context.Context) context.Context {
+	md, _ := metadata.FromIncomingContext(ctx)
+	id := ""
+	if values := md.Get(MetadataRequestID); len(values) > 0 {
+		id = values[0]
+	}
+	return logging.WithRequestID(ctx, logging.RequestIDOrNew(id))
+}
 
-	return h, err
+func requestID(ctx context.Context) string {
+	id, _ := logging.RequestID(ctx)
+	return id
 }
 
-func errCodeColor(err error) string {
+func logCall(ctx context.Context, l *slog.Logger, method string, start time.Time, err error) {
+	code := status.Code(err)
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("code", code.String()),
+		slog.Duration("duration", time.Since(start)),
+		slog.String("ip", peerIP(ctx)),
+	}
 	if err != nil {
-		return red
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+	l.LogAttrs(ctx, codeLevel(code), "call", attrs...)
+}
+
+func codeLevel(code codes.Code) slog.Level {
+	switch {
+	case code == codes.OK:
+		return slog.LevelInfo
+	case serverError(code):
+		return slog.LevelError
+	default:
+		return slog.LevelWarn
+	}
+}
+
+// serverError проверяет, что вызов завершился по вине сервера, а не клиента
+func serverError(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.Internal, codes.DataLoss, codes.Unimplemented:
+		return true
+	default:
+		return false
 	}
-	return green
 }
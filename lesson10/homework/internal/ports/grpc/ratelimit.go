@@ -0,0 +1,69 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"homework10/internal/ratelimit"
+)
+
+// UnaryRateLimitInterceptor ограничивает вызовы клиента к каждому методу; клиент - пользователь, определённый
+// UnaryAuthInterceptor (он должен стоять в цепочке раньше), или IP-адрес. Превысивший лимит получает
+// ResourceExhausted и в заголовке retry-after - через сколько секунд повторить вызов
+func UnaryRateLimitInterceptor(l *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := allow(ctx, l, info.FullMethod); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamRateLimitInterceptor - то же, что UnaryRateLimitInterceptor, для потоковых вызовов: считается открытие потока
+func StreamRateLimitInterceptor(l *ratelimit.Limiter) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := allow(ss.Context(), l, info.FullMethod); err != nil {
+			return err
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+func allow(ctx context.Context, l *ratelimit.Limiter, method string) error {
+	// проверки оркестратора не ограничиваются: упёршись в лимит, они сочли бы исправный сервис неготовым
+	if strings.HasPrefix(method, "/"+healthpb.Health_ServiceDesc.ServiceName+"/") {
+		return nil
+	}
+
+	ok, wait := l.Allow(method, ratelimit.Principal(ctx, peerIP(ctx)))
+	if ok {
+		return nil
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", ratelimit.RetryAfter(wait)))
+	return status.Error(codes.ResourceExhausted, "Rate limit exceeded")
+}
+
+// peerIP - IP-адрес клиента без порта
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
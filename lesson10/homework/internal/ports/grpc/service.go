@@ -3,13 +3,24 @@ package grpc
 import (
 	"context"
 	"errors"
-	"time"
+	"strings"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"homework10/internal/ads"
 	"homework10/internal/app"
+	"homework10/internal/audit"
+	"homework10/internal/auth"
+	"homework10/internal/categories"
+	"homework10/internal/events"
+	"homework10/internal/feed"
+	"homework10/internal/images"
+	"homework10/internal/messages"
+	"homework10/internal/searches"
+	"homework10/internal/users"
+	"homework10/internal/webhooks"
 )
 
 type Server struct {
@@ -24,20 +35,22 @@ func NewService(a app.App) *Server {
 }
 
 func (s *Server) CreateAd(ctx context.Context, req *CreateAdRequest) (*AdResponse, error) {
-	ad, err := s.app.CreateAd(ctx, req.Title, req.Text, req.UserId)
+	ttl, err := app.TTLFromSeconds(req.Ttl)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	}
+
+	price := ads.Price{Amount: req.Price, Currency: req.Currency}
+	ad, err := s.app.CreateAd(ctx, req.Title, req.Text, req.CategoryId, price, location(req.Location), ttl)
 	if errors.Is(err, app.ErrBadRequest) {
 		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
 	} else if err != nil {
 		return nil, status.Error(codes.Internal, "Internal server error")
 	}
 
-	return &AdResponse{
-		Id:        ad.ID,
-		Title:     ad.Title,
-		Text:      ad.Text,
-		UserId:    ad.UserID,
-		Published: ad.Published,
-	}, nil
+	return adResponse(ad), nil
 }
 
 func (s *Server) GetAd(ctx context.Context, req *GetAdRequest) (*AdResponse, error) {
@@ -48,17 +61,32 @@ func (s *Server) GetAd(ctx context.Context, req *GetAdRequest) (*AdResponse, err
 		return nil, status.Error(codes.Internal, "Internal server error")
 	}
 
-	return &AdResponse{
-		Id:        ad.ID,
-		Title:     ad.Title,
-		Text:      ad.Text,
-		UserId:    ad.UserID,
-		Published: ad.Published,
-	}, nil
+	return adResponse(ad), nil
 }
 
 func (s *Server) ListAds(ctx context.Context, req *ListAdsRequest) (*ListAdResponse, error) {
-	adverts, err := s.app.AdsByPattern(ctx, createAdPattern(req))
+	sort, err := ads.ParseSort(req.Sort)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	}
+
+	p, err := createAdPattern(req)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	adverts, next, err := s.app.AdsByPattern(ctx, p, ads.Page{
+		Limit:  int(req.Limit),
+		Cursor: req.Cursor,
+		Sort:   sort,
+	})
+	if errors.Is(err, app.ErrBadRequest) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	counts, err := s.app.CategoryCounts(ctx, p)
 	if errors.Is(err, app.ErrBadRequest) {
 		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
 	} else if err != nil {
@@ -67,79 +95,201 @@ func (s *Server) ListAds(ctx context.Context, req *ListAdsRequest) (*ListAdRespo
 
 	var list []*AdResponse
 	for i := range adverts {
-		list = append(list, &AdResponse{
-			Id:        adverts[i].ID,
-			Title:     adverts[i].Title,
-			Text:      adverts[i].Text,
-			UserId:    adverts[i].UserID,
-			Published: adverts[i].Published,
+		list = append(list, adResponse(adverts[i]))
+	}
+
+	var countList []*CategoryCount
+	for _, cnt := range counts {
+		countList = append(countList, &CategoryCount{
+			CategoryId: cnt.Category.ID,
+			Name:       cnt.Category.Name,
+			Path:       cnt.Path,
+			Count:      int64(cnt.Ads),
 		})
 	}
 
 	return &ListAdResponse{
-		List: list,
+		List:           list,
+		NextCursor:     next,
+		CategoryCounts: countList,
 	}, nil
 }
 
+// WatchAds отправляет клиенту изменения подходящих под фильтр опубликованных объявлений, пока он не отключится
+func (s *Server) WatchAds(req *WatchAdsRequest, stream AdService_WatchAdsServer) error {
+	filter := req.GetFilter()
+	if filter == nil {
+		filter = &ListAdsRequest{}
+	}
+
+	p, err := createAdPattern(filter)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	ctx := stream.Context()
+	sub, err := s.app.WatchAds(ctx, p)
+	if errors.Is(err, app.ErrBadRequest) {
+		return status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if errors.Is(err, app.ErrUnavailable) {
+		return status.Error(codes.Unavailable, "Unavailable")
+	} else if err != nil {
+		return status.Error(codes.Internal, "Internal server error")
+	}
+	defer sub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case e, ok := <-sub.Events():
+			if !ok {
+				if errors.Is(sub.Err(), feed.ErrSlowConsumer) {
+					return status.Error(codes.ResourceExhausted, "Slow consumer")
+				}
+				return status.Error(codes.Unavailable, "Unavailable")
+			}
+			if err = stream.Send(adEvent(e)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 func (s *Server) UpdateAd(ctx context.Context, req *UpdateAdRequest) (*AdResponse, error) {
-	ad, err := s.app.UpdateAd(ctx, req.AdId, req.UserId, req.Title, req.Text)
+	var price *ads.Price
+	if req.Price != nil || req.Currency != nil {
+		price = &ads.Price{Amount: req.GetPrice(), Currency: req.GetCurrency()}
+	}
+	ad, err := s.app.UpdateAd(ctx, req.AdId, req.Version, req.Title, req.Text, price, location(req.Location), req.ClearLocation)
 	if errors.Is(err, app.ErrBadRequest) {
 		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
 	} else if errors.Is(err, app.ErrForbidden) {
 		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if errors.Is(err, app.ErrConflict) {
+		return nil, status.Error(codes.Aborted, "Version conflict")
 	} else if err != nil {
 		return nil, status.Error(codes.Internal, "Internal server error")
 	}
 
-	return &AdResponse{
-		Id:        ad.ID,
-		Title:     ad.Title,
-		Text:      ad.Text,
-		UserId:    ad.UserID,
-		Published: ad.Published,
-	}, nil
+	return adResponse(ad), nil
 }
 
 func (s *Server) ChangeAdStatus(ctx context.Context, req *ChangeAdStatusRequest) (*AdResponse, error) {
-	ad, err := s.app.ChangeAdStatus(ctx, req.AdId, req.UserId, req.Published)
+	ad, err := s.app.ChangeAdStatus(ctx, req.AdId, req.Version, req.Published)
 	if errors.Is(err, app.ErrBadRequest) {
 		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
 	} else if errors.Is(err, app.ErrForbidden) {
 		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if errors.Is(err, app.ErrConflict) {
+		return nil, status.Error(codes.Aborted, "Version conflict")
+	} else if errors.Is(err, app.ErrTransition) {
+		return nil, status.Error(codes.FailedPrecondition, "Transition is not allowed")
 	} else if err != nil {
 		return nil, status.Error(codes.Internal, "Internal server error")
 	}
 
-	return &AdResponse{
-		Id:        ad.ID,
-		Title:     ad.Title,
-		Text:      ad.Text,
-		UserId:    ad.UserID,
-		Published: ad.Published,
-	}, nil
+	return adResponse(ad), nil
+}
+
+func (s *Server) TransitionAd(ctx context.Context, req *TransitionAdRequest) (*AdResponse, error) {
+	ad, err := s.app.TransitionAd(ctx, req.AdId, req.Version, ads.Event(req.Event), req.Reason)
+	if errors.Is(err, app.ErrBadRequest) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if errors.Is(err, app.ErrConflict) {
+		return nil, status.Error(codes.Aborted, "Version conflict")
+	} else if errors.Is(err, app.ErrTransition) {
+		return nil, status.Error(codes.FailedPrecondition, "Transition is not allowed")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return adResponse(ad), nil
+}
+
+func (s *Server) RenewAd(ctx context.Context, req *RenewAdRequest) (*AdResponse, error) {
+	ttl, err := app.TTLFromSeconds(req.Ttl)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	}
+
+	ad, err := s.app.RenewAd(ctx, req.AdId, req.Version, ttl)
+	if errors.Is(err, app.ErrBadRequest) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if errors.Is(err, app.ErrConflict) {
+		return nil, status.Error(codes.Aborted, "Version conflict")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return adResponse(ad), nil
 }
 
 func (s *Server) DeleteAd(ctx context.Context, req *DeleteAdRequest) (*AdResponse, error) {
-	ad, err := s.app.DeleteAd(ctx, req.AdId, req.UserId)
+	ad, err := s.app.DeleteAd(ctx, req.AdId)
 	if errors.Is(err, app.ErrBadRequest) {
 		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
 	} else if errors.Is(err, app.ErrForbidden) {
 		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
 	} else if err != nil {
 		return nil, status.Error(codes.Internal, "Internal server error")
 	}
 
-	return &AdResponse{
-		Id:        ad.ID,
-		Title:     ad.Title,
-		Text:      ad.Text,
-		UserId:    ad.UserID,
-		Published: ad.Published,
-	}, nil
+	return adResponse(ad), nil
+}
+
+func (s *Server) GetAdHistory(ctx context.Context, req *GetAdHistoryRequest) (*AdHistoryResponse, error) {
+	list, err := s.app.AdHistory(ctx, req.AdId)
+	if errors.Is(err, app.ErrBadRequest) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	res := &AdHistoryResponse{}
+	for _, e := range list {
+		res.Entries = append(res.Entries, historyEntry(e))
+	}
+	return res, nil
+}
+
+func (s *Server) RevertAd(ctx context.Context, req *RevertAdRequest) (*AdResponse, error) {
+	ad, err := s.app.RevertAd(ctx, req.AdId, req.Version, req.Revision)
+	if errors.Is(err, app.ErrBadRequest) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if errors.Is(err, app.ErrConflict) {
+		return nil, status.Error(codes.Aborted, "Version conflict")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return adResponse(ad), nil
 }
 
 func (s *Server) CreateUser(ctx context.Context, req *CreateUserRequest) (*UserResponse, error) {
-	u, err := s.app.CreateUser(ctx, req.Nickname, req.Email)
+	u, err := s.app.CreateUser(ctx, req.Nickname, req.Email, req.Password)
 	if errors.Is(err, app.ErrBadRequest) {
 		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
 	} else if err != nil {
@@ -150,6 +300,8 @@ func (s *Server) CreateUser(ctx context.Context, req *CreateUserRequest) (*UserR
 		Id:       u.ID,
 		Nickname: u.Nickname,
 		Email:    u.Email,
+		Version:  u.Version,
+		Role:     string(u.RoleOf()),
 	}, nil
 }
 
@@ -165,13 +317,44 @@ func (s *Server) GetUser(ctx context.Context, req *GetUserRequest) (*UserRespons
 		Id:       u.ID,
 		Nickname: u.Nickname,
 		Email:    u.Email,
+		Version:  u.Version,
+		Role:     string(u.RoleOf()),
 	}, nil
 }
 
 func (s *Server) UpdateUser(ctx context.Context, req *UpdateUserRequest) (*UserResponse, error) {
-	u, err := s.app.UpdateUser(ctx, req.Id, req.Nickname, req.Email)
+	u, err := s.app.UpdateUser(ctx, req.Id, req.Version, req.Nickname, req.Email)
 	if errors.Is(err, app.ErrBadRequest) {
 		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if errors.Is(err, app.ErrConflict) {
+		return nil, status.Error(codes.Aborted, "Version conflict")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return &UserResponse{
+		Id:       u.ID,
+		Nickname: u.Nickname,
+		Email:    u.Email,
+		Version:  u.Version,
+		Role:     string(u.RoleOf()),
+	}, nil
+}
+
+func (s *Server) ChangeUserRole(ctx context.Context, req *ChangeUserRoleRequest) (*UserResponse, error) {
+	u, err := s.app.ChangeUserRole(ctx, req.Id, req.Version, users.Role(req.Role))
+	if errors.Is(err, app.ErrBadRequest) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if errors.Is(err, app.ErrConflict) {
+		return nil, status.Error(codes.Aborted, "Version conflict")
 	} else if err != nil {
 		return nil, status.Error(codes.Internal, "Internal server error")
 	}
@@ -180,6 +363,8 @@ func (s *Server) UpdateUser(ctx context.Context, req *UpdateUserRequest) (*UserR
 		Id:       u.ID,
 		Nickname: u.Nickname,
 		Email:    u.Email,
+		Version:  u.Version,
+		Role:     string(u.RoleOf()),
 	}, nil
 }
 
@@ -187,6 +372,10 @@ func (s *Server) DeleteUser(ctx context.Context, req *DeleteUserRequest) (*UserR
 	u, err := s.app.DeleteUser(ctx, req.Id)
 	if errors.Is(err, app.ErrBadRequest) {
 		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
 	} else if err != nil {
 		return nil, status.Error(codes.Internal, "Internal server error")
 	}
@@ -195,35 +384,625 @@ func (s *Server) DeleteUser(ctx context.Context, req *DeleteUserRequest) (*UserR
 		Id:       u.ID,
 		Nickname: u.Nickname,
 		Email:    u.Email,
+		Version:  u.Version,
+		Role:     string(u.RoleOf()),
 	}, nil
 }
 
+func (s *Server) ListFavorites(ctx context.Context, req *ListFavoritesRequest) (*ListAdResponse, error) {
+	adverts, err := s.app.Favorites(ctx, req.UserId)
+	if errors.Is(err, app.ErrBadRequest) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	var list []*AdResponse
+	for i := range adverts {
+		list = append(list, adResponse(adverts[i]))
+	}
+
+	return &ListAdResponse{List: list}, nil
+}
+
+func (s *Server) AddFavorite(ctx context.Context, req *FavoriteRequest) (*AdResponse, error) {
+	return s.changeFavorite(ctx, req, s.app.AddFavorite)
+}
+
+func (s *Server) DeleteFavorite(ctx context.Context, req *FavoriteRequest) (*AdResponse, error) {
+	return s.changeFavorite(ctx, req, s.app.DeleteFavorite)
+}
+
+// changeFavorite - общая часть AddFavorite и DeleteFavorite: change - соответствующий метод приложения
+func (s *Server) changeFavorite(ctx context.Context, req *FavoriteRequest,
+	change func(ctx context.Context, userID, adID int64) (*ads.Ad, error)) (*AdResponse, error) {
+	ad, err := change(ctx, req.UserId, req.AdId)
+	if errors.Is(err, app.ErrBadRequest) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return adResponse(ad), nil
+}
+
+func (s *Server) ListSavedSearches(ctx context.Context, req *ListSavedSearchesRequest) (*ListSavedSearchesResponse, error) {
+	list, err := s.app.SavedSearches(ctx, req.UserId)
+	if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	res := &ListSavedSearchesResponse{}
+	for _, found := range list {
+		res.List = append(res.List, savedSearchResponse(found))
+	}
+
+	return res, nil
+}
+
+func (s *Server) CreateSavedSearch(ctx context.Context, req *CreateSavedSearchRequest) (*SavedSearchResponse, error) {
+	q, err := createSearchQuery(req.Filter)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	found, err := s.app.CreateSavedSearch(ctx, req.UserId, req.Name, q)
+	if errors.Is(err, app.ErrBadRequest) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return savedSearchResponse(found), nil
+}
+
+func (s *Server) UpdateSavedSearch(ctx context.Context, req *UpdateSavedSearchRequest) (*SavedSearchResponse, error) {
+	q, err := createSearchQuery(req.Filter)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	found, err := s.app.UpdateSavedSearch(ctx, req.UserId, req.Id, req.Name, q)
+	if errors.Is(err, app.ErrBadRequest) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return savedSearchResponse(found), nil
+}
+
+func (s *Server) DeleteSavedSearch(ctx context.Context, req *DeleteSavedSearchRequest) (*SavedSearchResponse, error) {
+	found, err := s.app.DeleteSavedSearch(ctx, req.UserId, req.Id)
+	if errors.Is(err, app.ErrBadRequest) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return savedSearchResponse(found), nil
+}
+
+func (s *Server) ListNotifications(ctx context.Context, req *ListNotificationsRequest) (*ListNotificationsResponse, error) {
+	return s.notifications(ctx, req.UserId)
+}
+
+func (s *Server) ReadNotifications(ctx context.Context, req *ReadNotificationsRequest) (*ListNotificationsResponse, error) {
+	err := s.app.ReadNotifications(ctx, req.UserId, req.UpTo)
+	if errors.Is(err, app.ErrBadRequest) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return s.notifications(ctx, req.UserId)
+}
+
+// notifications - общая часть ListNotifications и ReadNotifications
+func (s *Server) notifications(ctx context.Context, userID int64) (*ListNotificationsResponse, error) {
+	list, unread, err := s.app.Notifications(ctx, userID)
+	if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	res := &ListNotificationsResponse{UnreadTotal: int64(unread)}
+	for _, n := range list {
+		res.List = append(res.List, &NotificationResponse{
+			Id:       n.ID,
+			SearchId: n.SearchID,
+			AdId:     n.AdID,
+			Title:    n.Title,
+			Created:  timestamppb.New(n.Created),
+			Read:     n.Read,
+		})
+	}
+
+	return res, nil
+}
+
+func (s *Server) ContactSeller(ctx context.Context, req *ContactSellerRequest) (*MessageResponse, error) {
+	m, err := s.app.ContactSeller(ctx, req.AdId, req.Text)
+	if errors.Is(err, app.ErrBadRequest) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return messageResponse(m), nil
+}
+
+func (s *Server) SendMessage(ctx context.Context, req *SendMessageRequest) (*MessageResponse, error) {
+	m, err := s.app.SendMessage(ctx, req.ThreadId, req.Text)
+	if errors.Is(err, app.ErrBadRequest) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return messageResponse(m), nil
+}
+
+func (s *Server) ListThreads(ctx context.Context, req *ListThreadsRequest) (*ListThreadsResponse, error) {
+	threads, err := s.app.Threads(ctx, req.UserId)
+	if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	res := &ListThreadsResponse{}
+	for _, t := range threads {
+		unread := int64(t.UnreadFor(req.UserId))
+		res.UnreadTotal += unread
+		res.List = append(res.List, &ThreadResponse{
+			Id:       t.ID,
+			AdId:     t.AdID,
+			BuyerId:  t.BuyerID,
+			SellerId: t.SellerID,
+			Updated:  timestamppb.New(t.Updated),
+			Unread:   unread,
+		})
+	}
+
+	return res, nil
+}
+
+func (s *Server) ListMessages(ctx context.Context, req *ListMessagesRequest) (*ListMessagesResponse, error) {
+	page := messages.Page{Limit: int(req.Limit), Cursor: req.Cursor}
+	list, next, err := s.app.Messages(ctx, req.ThreadId, page)
+	if errors.Is(err, app.ErrBadRequest) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	res := &ListMessagesResponse{NextCursor: next}
+	for _, m := range list {
+		res.List = append(res.List, messageResponse(m))
+	}
+
+	return res, nil
+}
+
+func (s *Server) ListCategories(ctx context.Context, _ *ListCategoriesRequest) (*ListCategoriesResponse, error) {
+	tree, err := s.app.Categories(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	var list []*CategoryResponse
+	for _, c := range tree.All() {
+		res := categoryResponse(c)
+		res.Path = tree.Path(c.ID)
+		list = append(list, res)
+	}
+
+	return &ListCategoriesResponse{List: list}, nil
+}
+
+func (s *Server) CreateCategory(ctx context.Context, req *CreateCategoryRequest) (*CategoryResponse, error) {
+	c, err := s.app.CreateCategory(ctx, req.Name, req.ParentId)
+	if errors.Is(err, app.ErrBadRequest) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return categoryResponse(c), nil
+}
+
+func (s *Server) UpdateCategory(ctx context.Context, req *UpdateCategoryRequest) (*CategoryResponse, error) {
+	c, err := s.app.UpdateCategory(ctx, req.Id, req.Version, req.Name, req.ParentId)
+	if errors.Is(err, app.ErrBadRequest) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if errors.Is(err, app.ErrConflict) {
+		return nil, status.Error(codes.Aborted, "Version conflict")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return categoryResponse(c), nil
+}
+
+func (s *Server) DeleteCategory(ctx context.Context, req *DeleteCategoryRequest) (*CategoryResponse, error) {
+	c, err := s.app.DeleteCategory(ctx, req.Id)
+	if errors.Is(err, app.ErrBadRequest) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return categoryResponse(c), nil
+}
+
+func (s *Server) CreateWebhook(ctx context.Context, req *CreateWebhookRequest) (*WebhookResponse, error) {
+	var types []events.Type
+	for _, e := range req.Events {
+		types = append(types, events.Type(e))
+	}
+
+	sub, err := s.app.CreateWebhook(ctx, req.Url, types)
+	if errors.Is(err, app.ErrBadRequest) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	res := webhookResponse(sub)
+	// секрет показывается только при создании подписки
+	res.Secret = sub.Secret
+	return res, nil
+}
+
+func (s *Server) ListWebhooks(ctx context.Context, _ *ListWebhooksRequest) (*ListWebhooksResponse, error) {
+	list, err := s.app.Webhooks(ctx)
+	if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	res := &ListWebhooksResponse{}
+	for _, sub := range list {
+		res.List = append(res.List, webhookResponse(sub))
+	}
+	return res, nil
+}
+
+func (s *Server) DeleteWebhook(ctx context.Context, req *DeleteWebhookRequest) (*WebhookResponse, error) {
+	sub, err := s.app.DeleteWebhook(ctx, req.Id)
+	if errors.Is(err, app.ErrBadRequest) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return webhookResponse(sub), nil
+}
+
+func (s *Server) ListWebhookDeliveries(ctx context.Context, req *ListWebhookDeliveriesRequest) (*ListWebhookDeliveriesResponse, error) {
+	list, err := s.app.WebhookDeliveries(ctx, req.WebhookId)
+	if errors.Is(err, app.ErrBadRequest) {
+		return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+	} else if errors.Is(err, app.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	} else if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	res := &ListWebhookDeliveriesResponse{}
+	for _, d := range list {
+		res.List = append(res.List, webhookDelivery(d))
+	}
+	return res, nil
+}
+
+func (s *Server) Login(ctx context.Context, req *LoginRequest) (*TokenResponse, error) {
+	t, err := s.app.Login(ctx, req.UserId, req.Password)
+	if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return tokenResponse(t), nil
+}
+
+func (s *Server) Refresh(ctx context.Context, req *RefreshRequest) (*TokenResponse, error) {
+	t, err := s.app.Refresh(ctx, req.RefreshToken)
+	if errors.Is(err, app.ErrUnauthorized) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+
+	return tokenResponse(t), nil
+}
+
+func adResponse(ad *ads.Ad) *AdResponse {
+	res := &AdResponse{
+		Id:            ad.ID,
+		Title:         ad.Title,
+		Text:          ad.Text,
+		UserId:        ad.UserID,
+		CategoryId:    ad.CategoryID,
+		Favorites:     int64(ad.Favorites),
+		Price:         ad.Price.Amount,
+		Currency:      ad.Price.Currency,
+		Published:     ad.Published(),
+		Version:       ad.Version,
+		Status:        string(ad.Status),
+		StatusChanged: timestamppb.New(ad.Created),
+		Distance:      ad.Distance,
+	}
+	if ad.Location != nil {
+		res.Location = &Location{Lat: ad.Location.Lat, Lon: ad.Location.Lon, City: ad.Location.City}
+	}
+	if t := ad.LastTransition(); t != nil {
+		res.StatusReason = t.Reason
+		res.StatusChanged = timestamppb.New(t.At)
+	}
+	if !ad.Expires.IsZero() {
+		res.Expires = timestamppb.New(ad.Expires)
+	}
+	for _, img := range ad.Images {
+		res.Images = append(res.Images, &Image{
+			Id:           img.ID,
+			Url:          images.URL(img.ID),
+			ThumbnailUrl: images.URL(img.Thumb),
+			ContentType:  img.ContentType,
+			Width:        int32(img.Width),
+			Height:       int32(img.Height),
+		})
+	}
+	return res
+}
+
+func historyEntry(e *audit.Entry) *AdHistoryEntry {
+	res := &AdHistoryEntry{
+		Id:       e.ID,
+		Revision: e.Revision,
+		ActorId:  e.ActorID,
+		Op:       string(e.Op),
+		At:       timestamppb.New(e.At),
+	}
+	if e.Before.Ad != nil {
+		res.Before = adResponse(e.Before.Ad)
+	}
+	if e.After.Ad != nil {
+		res.After = adResponse(e.After.Ad)
+	}
+	return res
+}
+
+func location(l *Location) *ads.Location {
+	if l == nil {
+		return nil
+	}
+	return &ads.Location{Point: ads.Point{Lat: l.Lat, Lon: l.Lon}, City: l.City}
+}
+
+func webhookResponse(sub *webhooks.Subscription) *WebhookResponse {
+	res := &WebhookResponse{
+		Id:      sub.ID,
+		Url:     sub.URL,
+		Created: timestamppb.New(sub.Created),
+	}
+	for _, t := range sub.Types {
+		res.Events = append(res.Events, string(t))
+	}
+	return res
+}
+
+func webhookDelivery(d *webhooks.Delivery) *WebhookDelivery {
+	res := &WebhookDelivery{
+		Id:      d.ID,
+		EventId: d.Event.ID,
+		Event:   string(d.Event.Type),
+		Status:  string(d.Status),
+		Created: timestamppb.New(d.Created),
+	}
+	if d.Status == webhooks.DeliveryPending {
+		res.NextAttempt = timestamppb.New(d.NextAttempt)
+	}
+	for _, a := range d.Attempts {
+		res.Attempts = append(res.Attempts, &WebhookAttempt{
+			At:         timestamppb.New(a.At),
+			StatusCode: int32(a.StatusCode),
+			Error:      a.Error,
+			DurationMs: a.Duration.Milliseconds(),
+		})
+	}
+	return res
+}
+
+func savedSearchResponse(found *searches.Search) *SavedSearchResponse {
+	return &SavedSearchResponse{
+		Id:      found.ID,
+		UserId:  found.UserID,
+		Name:    found.Name,
+		Filter:  listAdsRequest(found.Query),
+		Created: timestamppb.New(found.Created),
+		Updated: timestamppb.New(found.Updated),
+	}
+}
+
+// listAdsRequest - обратное к createAdQuery преобразование сохранённых условий выборки
+func listAdsRequest(q ads.Query) *ListAdsRequest {
+	res := &ListAdsRequest{
+		Query:      q.Query,
+		Filter:     q.Filter,
+		Title:      q.Title,
+		UserId:     q.UserID,
+		Published:  q.Published,
+		CategoryId: q.Category,
+		PriceMin:   q.PriceMin,
+		PriceMax:   q.PriceMax,
+		Currency:   q.Currency,
+	}
+	if q.Created != nil {
+		res.Created = timestamppb.New(*q.Created)
+	}
+	for _, st := range q.Statuses {
+		res.Status = append(res.Status, string(st))
+	}
+	if q.Near != nil {
+		res.Near = &Area{Lat: q.Near.Center.Lat, Lon: q.Near.Center.Lon, Radius: q.Near.Radius}
+	}
+	return res
+}
+
+func categoryResponse(c *categories.Category) *CategoryResponse {
+	return &CategoryResponse{
+		Id:       c.ID,
+		ParentId: c.ParentID,
+		Name:     c.Name,
+		Version:  c.Version,
+	}
+}
+
+func messageResponse(m *messages.Message) *MessageResponse {
+	return &MessageResponse{
+		Id:       m.ID,
+		ThreadId: m.ThreadID,
+		SenderId: m.SenderID,
+		Text:     m.Text,
+		Sent:     timestamppb.New(m.Sent),
+	}
+}
+
+func tokenResponse(t auth.Tokens) *TokenResponse {
+	return &TokenResponse{
+		AccessToken:  t.Access,
+		RefreshToken: t.Refresh,
+		ExpiresIn:    int64(t.ExpiresIn.Seconds()),
+	}
+}
+
 // Метод для генерации шаблона для выборки объявлений
-func createAdPattern(req *ListAdsRequest) *ads.Pattern {
-	f := ads.DefaultPattern()
+func adEvent(e *events.Event) *AdEvent {
+	return &AdEvent{
+		Id:      e.ID,
+		Type:    string(e.Type),
+		At:      timestamppb.New(e.At),
+		ActorId: e.ActorID,
+		Ad:      adResponse(e.Ad),
+	}
+}
 
-	if req.Title != nil {
-		f.TitleFits = func(title string) bool {
-			return title == *req.Title
-		}
+func createAdPattern(req *ListAdsRequest) (*ads.Pattern, error) {
+	q, err := createAdQuery(req)
+	if err != nil {
+		return nil, err
 	}
+
+	return q.Pattern()
+}
+
+// createSearchQuery собирает условия сохранённого поиска; не заданные условия - любые объявления
+func createSearchQuery(req *ListAdsRequest) (ads.Query, error) {
+	if req == nil {
+		return ads.Query{}, nil
+	}
+
+	return createAdQuery(req)
+}
+
+func createAdQuery(req *ListAdsRequest) (ads.Query, error) {
+	q := ads.Query{
+		Query:     req.Query,
+		Filter:    req.Filter,
+		Title:     req.Title,
+		UserID:    req.UserId,
+		Published: req.Published,
+		Category:  req.CategoryId,
+		PriceMin:  req.PriceMin,
+		PriceMax:  req.PriceMax,
+		Currency:  req.Currency,
+	}
+
 	if req.Created != nil {
-		f.CreatedFits = func(created time.Time) bool {
-			pY, pM, pD := req.Created.AsTime().UTC().Date()
-			y, m, d := created.UTC().Date()
-			return y == pY && m == pM && d == pD
-		}
+		created := req.Created.AsTime()
+		q.Created = &created
 	}
-	if req.UserId != nil {
-		f.UserIDFits = func(userID int64) bool {
-			return userID == *req.UserId
+	if len(req.Status) > 0 {
+		statuses, err := ads.ParseStatuses(strings.Join(req.Status, ","))
+		if err != nil {
+			return ads.Query{}, err
 		}
+		q.Statuses = statuses
 	}
-	if req.Published != nil {
-		f.PublishedFits = func(published bool) bool {
-			return published == *req.Published
-		}
+	if req.Near != nil {
+		q.Near = &ads.Circle{Center: ads.Point{Lat: req.Near.Lat, Lon: req.Near.Lon}, Radius: req.Near.Radius}
 	}
 
-	return f
+	return q, nil
 }
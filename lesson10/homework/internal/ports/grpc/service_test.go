@@ -1,20 +1,48 @@
 package grpc
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"homework10/internal/ads"
 	"homework10/internal/app"
 	"homework10/internal/app/mocks"
+	"homework10/internal/audit"
+	"homework10/internal/auth"
+	"homework10/internal/categories"
+	"homework10/internal/events"
+	"homework10/internal/feed"
+	"homework10/internal/health"
+	"homework10/internal/logging"
+	"homework10/internal/messages"
+	"homework10/internal/metrics"
+	"homework10/internal/ratelimit"
+	"homework10/internal/searches"
+	"homework10/internal/tracing"
 	"homework10/internal/users"
+	"homework10/internal/webhooks"
 )
 
 func TestGRPCService_CreateAd(t *testing.T) {
@@ -41,7 +69,7 @@ func TestGRPCService_CreateAd(t *testing.T) {
 			},
 			setMock: func() {
 				a.
-					On("CreateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					On("CreateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return(nil, app.ErrBadRequest).
 					Once()
 			},
@@ -49,6 +77,22 @@ func TestGRPCService_CreateAd(t *testing.T) {
 			wantErr: true,
 			err:     status.Error(codes.InvalidArgument, "Invalid argument"),
 		},
+		{
+			name: "unauthenticated error",
+			args: args{
+				ctx: context.Background(),
+				req: &CreateAdRequest{},
+			},
+			setMock: func() {
+				a.
+					On("CreateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, app.ErrUnauthorized).
+					Once()
+			},
+			want:    nil,
+			wantErr: true,
+			err:     status.Error(codes.Unauthenticated, "Unauthenticated"),
+		},
 		{
 			name: "internal error",
 			args: args{
@@ -57,7 +101,7 @@ func TestGRPCService_CreateAd(t *testing.T) {
 			},
 			setMock: func() {
 				a.
-					On("CreateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					On("CreateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return(nil, fmt.Errorf("some internal error")).
 					Once()
 			},
@@ -73,13 +117,13 @@ func TestGRPCService_CreateAd(t *testing.T) {
 			},
 			setMock: func() {
 				a.
-					On("CreateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					On("CreateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return(&ads.Ad{
-						ID:        0,
-						Title:     "title",
-						Text:      "text",
-						UserID:    0,
-						Published: false,
+						ID:     0,
+						Title:  "title",
+						Text:   "text",
+						UserID: 0,
+						Status: ads.StatusDraft,
 					}, nil).
 					Once()
 			},
@@ -168,11 +212,11 @@ func TestGRPCService_GetAd(t *testing.T) {
 				a.
 					On("AdByID", mock.Anything, mock.Anything).
 					Return(&ads.Ad{
-						ID:        0,
-						Title:     "title",
-						Text:      "text",
-						UserID:    0,
-						Published: false,
+						ID:     0,
+						Title:  "title",
+						Text:   "text",
+						UserID: 0,
+						Status: ads.StatusDraft,
 					}, nil).
 					Once()
 			},
@@ -233,14 +277,25 @@ func TestGRPCService_ListAds(t *testing.T) {
 			},
 			setMock: func() {
 				a.
-					On("AdsByPattern", mock.Anything, mock.Anything).
-					Return(nil, app.ErrBadRequest).
+					On("AdsByPattern", mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, "", app.ErrBadRequest).
 					Once()
 			},
 			want:    nil,
 			wantErr: true,
 			err:     status.Error(codes.InvalidArgument, "Invalid argument"),
 		},
+		{
+			name: "invalid filter",
+			args: args{
+				ctx: context.Background(),
+				req: &ListAdsRequest{Filter: "published = yes"},
+			},
+			setMock: func() {},
+			want:    nil,
+			wantErr: true,
+			err:     status.Error(codes.InvalidArgument, `bad filter at position 13: expected bool value for "published", got "yes"`),
+		},
 		{
 			name: "internal error",
 			args: args{
@@ -249,8 +304,8 @@ func TestGRPCService_ListAds(t *testing.T) {
 			},
 			setMock: func() {
 				a.
-					On("AdsByPattern", mock.Anything, mock.Anything).
-					Return(nil, fmt.Errorf("some internal error")).
+					On("AdsByPattern", mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, "", fmt.Errorf("some internal error")).
 					Once()
 			},
 			want:    nil,
@@ -262,64 +317,81 @@ func TestGRPCService_ListAds(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 				req: &ListAdsRequest{
-					Title:     &listAdsRequestFields.title,
-					Created:   &listAdsRequestFields.created,
-					UserId:    &listAdsRequestFields.userID,
-					Published: &listAdsRequestFields.published,
+					Title:      &listAdsRequestFields.title,
+					Created:    &listAdsRequestFields.created,
+					UserId:     &listAdsRequestFields.userID,
+					Published:  &listAdsRequestFields.published,
+					CategoryId: 2,
 				},
 			},
 			setMock: func() {
 				a.
-					On("AdsByPattern", mock.Anything, mock.Anything).
+					On("CategoryCounts", mock.Anything, mock.MatchedBy(func(p *ads.Pattern) bool { return p.Category == 2 })).
+					Return([]categories.Count{
+						{Category: &categories.Category{ID: 2, Name: "Телефоны", ParentID: 1}, Path: []string{"Электроника", "Телефоны"}, Ads: 3},
+					}, nil).
+					Once()
+
+				a.
+					On("AdsByPattern", mock.Anything, mock.Anything, mock.Anything).
 					Return([]*ads.Ad{
 						{
-							ID:        0,
-							Title:     "1st title",
-							Text:      "1st text",
-							UserID:    0,
-							Published: false,
+							ID:     0,
+							Title:  "1st title",
+							Text:   "1st text",
+							UserID: 0,
+							Status: ads.StatusDraft,
 						},
 						{
-							ID:        1,
-							Title:     "2nd title",
-							Text:      "2nd text",
-							UserID:    0,
-							Published: false,
+							ID:     1,
+							Title:  "2nd title",
+							Text:   "2nd text",
+							UserID: 0,
+							Status: ads.StatusDraft,
 						},
 						{
-							ID:        1,
-							Title:     "3rd title",
-							Text:      "3rd text",
-							UserID:    0,
-							Published: false,
+							ID:     1,
+							Title:  "3rd title",
+							Text:   "3rd text",
+							UserID: 0,
+							Status: ads.StatusDraft,
 						},
-					}, nil).
+					}, "", nil).
 					Once()
 			},
 			want: &ListAdResponse{
 				List: []*AdResponse{
 					{
-						Id:        0,
-						Title:     "1st title",
-						Text:      "1st text",
-						UserId:    0,
-						Published: false,
+						Id:            0,
+						Title:         "1st title",
+						Text:          "1st text",
+						UserId:        0,
+						Published:     false,
+						Status:        "draft",
+						StatusChanged: timestamppb.New(time.Time{}),
 					},
 					{
-						Id:        1,
-						Title:     "2nd title",
-						Text:      "2nd text",
-						UserId:    0,
-						Published: false,
+						Id:            1,
+						Title:         "2nd title",
+						Text:          "2nd text",
+						UserId:        0,
+						Published:     false,
+						Status:        "draft",
+						StatusChanged: timestamppb.New(time.Time{}),
 					},
 					{
-						Id:        1,
-						Title:     "3rd title",
-						Text:      "3rd text",
-						UserId:    0,
-						Published: false,
+						Id:            1,
+						Title:         "3rd title",
+						Text:          "3rd text",
+						UserId:        0,
+						Published:     false,
+						Status:        "draft",
+						StatusChanged: timestamppb.New(time.Time{}),
 					},
 				},
+				CategoryCounts: []*CategoryCount{
+					{CategoryId: 2, Name: "Телефоны", Path: []string{"Электроника", "Телефоны"}, Count: 3},
+				},
 			},
 			wantErr: false,
 		},
@@ -335,10 +407,161 @@ func TestGRPCService_ListAds(t *testing.T) {
 			for i := range resp.List {
 				assert.Contains(t, resp.List, tt.want.List[i])
 			}
+			assert.Equal(t, tt.want.CategoryCounts, resp.CategoryCounts)
 		}
 	}
 }
 
+func TestGRPCService_ListAds_Price(t *testing.T) {
+	a := mocks.NewApp(t)
+	s := NewService(a)
+
+	_, err := s.ListAds(context.Background(), &ListAdsRequest{Currency: "euro"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	priceMin := int64(100)
+	a.
+		On("AdsByPattern", mock.Anything,
+			mock.MatchedBy(func(p *ads.Pattern) bool {
+				return p.String() == `not (currency = "") and price >= 100 and currency = "EUR"`
+			}),
+			mock.Anything).
+		Return([]*ads.Ad{{ID: 1, Price: ads.Price{Amount: 150, Currency: "EUR"}}}, "", nil).
+		Once()
+	a.
+		On("CategoryCounts", mock.Anything, mock.Anything).
+		Return(nil, nil).
+		Once()
+	resp, err := s.ListAds(context.Background(), &ListAdsRequest{PriceMin: &priceMin, Currency: "eur"})
+	assert.NoError(t, err)
+	if assert.Len(t, resp.List, 1) {
+		assert.Equal(t, int64(150), resp.List[0].Price)
+		assert.Equal(t, "EUR", resp.List[0].Currency)
+	}
+}
+
+func TestGRPCService_ListAds_Near(t *testing.T) {
+	a := mocks.NewApp(t)
+	s := NewService(a)
+
+	_, err := s.ListAds(context.Background(), &ListAdsRequest{Near: &Area{Lat: 55.75, Lon: 37.62}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	distance := 2.5
+	a.
+		On("AdsByPattern", mock.Anything,
+			mock.MatchedBy(func(p *ads.Pattern) bool {
+				return p.Near != nil && p.Near.Radius == 10
+			}),
+			mock.Anything).
+		Return([]*ads.Ad{{
+			ID:       1,
+			Location: &ads.Location{Point: ads.Point{Lat: 55.77, Lon: 37.6}, City: "Москва"},
+			Distance: &distance,
+		}}, "", nil).
+		Once()
+	a.
+		On("CategoryCounts", mock.Anything, mock.Anything).
+		Return(nil, nil).
+		Once()
+	resp, err := s.ListAds(context.Background(), &ListAdsRequest{Near: &Area{Lat: 55.75, Lon: 37.62, Radius: 10}, Sort: "distance"})
+	assert.NoError(t, err)
+	if assert.Len(t, resp.List, 1) {
+		assert.Equal(t, "Москва", resp.List[0].Location.City)
+		assert.Equal(t, 2.5, resp.List[0].GetDistance())
+	}
+}
+
+// watchStream - серверная сторона потока WatchAds, запоминающая отправленные клиенту события
+type watchStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	sent   []*AdEvent
+	header metadata.MD
+}
+
+func (w *watchStream) Context() context.Context {
+	return w.ctx
+}
+
+func (w *watchStream) SetHeader(md metadata.MD) error {
+	w.header = metadata.Join(w.header, md)
+	return nil
+}
+
+func (w *watchStream) Send(e *AdEvent) error {
+	w.sent = append(w.sent, e)
+	return nil
+}
+
+func TestGRPCService_WatchAds(t *testing.T) {
+	a := mocks.NewApp(t)
+	s := NewService(a)
+	everything := func(*events.Event) bool { return true }
+	at := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("invalid filter", func(t *testing.T) {
+		err := s.WatchAds(&WatchAdsRequest{Filter: &ListAdsRequest{Filter: "title"}}, &watchStream{ctx: context.Background()})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("unavailable", func(t *testing.T) {
+		a.On("WatchAds", mock.Anything, mock.Anything).Return(nil, app.ErrUnavailable).Once()
+		err := s.WatchAds(&WatchAdsRequest{}, &watchStream{ctx: context.Background()})
+		assert.ErrorIs(t, err, status.Error(codes.Unavailable, "Unavailable"))
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		hub := feed.NewHub(0)
+		sub, _ := hub.Subscribe(everything)
+		hub.Publish(&events.Event{ID: 1, Type: events.AdPublished, At: at, ActorID: 2, Ad: &ads.Ad{ID: 3, Title: "title", CategoryID: 4}})
+		hub.Close()
+
+		a.
+			On("WatchAds", mock.Anything, mock.MatchedBy(func(p *ads.Pattern) bool {
+				return p.Category == 4 && p.Query == "title"
+			})).
+			Return(sub, nil).
+			Once()
+		stream := &watchStream{ctx: context.Background()}
+		err := s.WatchAds(&WatchAdsRequest{Filter: &ListAdsRequest{CategoryId: 4, Query: "title"}}, stream)
+		assert.ErrorIs(t, err, status.Error(codes.Unavailable, "Unavailable"))
+		if assert.Len(t, stream.sent, 1) {
+			assert.Equal(t, int64(1), stream.sent[0].Id)
+			assert.Equal(t, "ad.published", stream.sent[0].Type)
+			assert.Equal(t, at, stream.sent[0].At.AsTime())
+			assert.Equal(t, int64(2), stream.sent[0].ActorId)
+			assert.Equal(t, "title", stream.sent[0].Ad.Title)
+		}
+	})
+
+	t.Run("slow consumer", func(t *testing.T) {
+		hub := feed.NewHub(1)
+		sub, _ := hub.Subscribe(everything)
+		hub.Publish(&events.Event{ID: 1, Type: events.AdUpdated, Ad: &ads.Ad{ID: 3}})
+		hub.Publish(&events.Event{ID: 2, Type: events.AdUpdated, Ad: &ads.Ad{ID: 3}})
+
+		a.On("WatchAds", mock.Anything, mock.Anything).Return(sub, nil).Once()
+		stream := &watchStream{ctx: context.Background()}
+		err := s.WatchAds(&WatchAdsRequest{}, stream)
+		assert.ErrorIs(t, err, status.Error(codes.ResourceExhausted, "Slow consumer"))
+		assert.Len(t, stream.sent, 1)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		hub := feed.NewHub(0)
+		sub, _ := hub.Subscribe(everything)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		a.On("WatchAds", mock.Anything, mock.Anything).Return(sub, nil).Once()
+		err := s.WatchAds(&WatchAdsRequest{}, &watchStream{ctx: ctx})
+		assert.Equal(t, codes.Canceled, status.Code(err))
+		// отключившийся клиент отписывается
+		assert.Equal(t, 0, hub.Len())
+	})
+}
+
 func TestGRPCService_UpdateAd(t *testing.T) {
 	a := mocks.NewApp(t)
 	s := NewService(a)
@@ -363,7 +586,7 @@ func TestGRPCService_UpdateAd(t *testing.T) {
 			},
 			setMock: func() {
 				a.
-					On("UpdateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					On("UpdateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return(nil, app.ErrBadRequest).
 					Once()
 			},
@@ -379,7 +602,7 @@ func TestGRPCService_UpdateAd(t *testing.T) {
 			},
 			setMock: func() {
 				a.
-					On("UpdateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					On("UpdateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return(nil, app.ErrForbidden).
 					Once()
 			},
@@ -387,6 +610,22 @@ func TestGRPCService_UpdateAd(t *testing.T) {
 			wantErr: true,
 			err:     status.Error(codes.PermissionDenied, "Permission denied"),
 		},
+		{
+			name: "version conflict error",
+			args: args{
+				ctx: context.Background(),
+				req: &UpdateAdRequest{Version: 1},
+			},
+			setMock: func() {
+				a.
+					On("UpdateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, app.ErrConflict).
+					Once()
+			},
+			want:    nil,
+			wantErr: true,
+			err:     status.Error(codes.Aborted, "Version conflict"),
+		},
 		{
 			name: "internal error",
 			args: args{
@@ -395,7 +634,7 @@ func TestGRPCService_UpdateAd(t *testing.T) {
 			},
 			setMock: func() {
 				a.
-					On("UpdateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					On("UpdateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return(nil, fmt.Errorf("some internal error")).
 					Once()
 			},
@@ -411,13 +650,14 @@ func TestGRPCService_UpdateAd(t *testing.T) {
 			},
 			setMock: func() {
 				a.
-					On("UpdateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					On("UpdateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return(&ads.Ad{
-						ID:        0,
-						Title:     "new title",
-						Text:      "new text",
-						UserID:    0,
-						Published: false,
+						ID:      0,
+						Title:   "new title",
+						Text:    "new text",
+						UserID:  0,
+						Status:  ads.StatusDraft,
+						Version: 2,
 					}, nil).
 					Once()
 			},
@@ -427,6 +667,7 @@ func TestGRPCService_UpdateAd(t *testing.T) {
 				Text:      "new text",
 				UserId:    0,
 				Published: false,
+				Version:   2,
 			},
 			wantErr: false,
 		},
@@ -444,6 +685,7 @@ func TestGRPCService_UpdateAd(t *testing.T) {
 			assert.Equal(t, tt.want.Text, resp.Text)
 			assert.Equal(t, tt.want.UserId, resp.UserId)
 			assert.Equal(t, tt.want.Published, resp.Published)
+			assert.Equal(t, tt.want.Version, resp.Version)
 		}
 	}
 }
@@ -496,6 +738,22 @@ func TestGRPCService_ChangeAdStatus(t *testing.T) {
 			wantErr: true,
 			err:     status.Error(codes.PermissionDenied, "Permission denied"),
 		},
+		{
+			name: "version conflict error",
+			args: args{
+				ctx: context.Background(),
+				req: &ChangeAdStatusRequest{Version: 1},
+			},
+			setMock: func() {
+				a.
+					On("ChangeAdStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, app.ErrConflict).
+					Once()
+			},
+			want:    nil,
+			wantErr: true,
+			err:     status.Error(codes.Aborted, "Version conflict"),
+		},
 		{
 			name: "internal error",
 			args: args{
@@ -522,11 +780,11 @@ func TestGRPCService_ChangeAdStatus(t *testing.T) {
 				a.
 					On("ChangeAdStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return(&ads.Ad{
-						ID:        0,
-						Title:     "title",
-						Text:      "text",
-						UserID:    0,
-						Published: true,
+						ID:     0,
+						Title:  "title",
+						Text:   "text",
+						UserID: 0,
+						Status: ads.StatusPublished,
 					}, nil).
 					Once()
 			},
@@ -557,330 +815,323 @@ func TestGRPCService_ChangeAdStatus(t *testing.T) {
 	}
 }
 
-func TestGRPCService_DeleteAd(t *testing.T) {
+func TestGRPCService_TransitionAd(t *testing.T) {
 	a := mocks.NewApp(t)
 	s := NewService(a)
+	changed := time.Date(2023, 4, 1, 15, 30, 0, 0, time.UTC)
 
-	type args struct {
-		ctx context.Context
-		req *DeleteAdRequest
-	}
 	tests := []struct {
 		name    string
-		args    args
+		req     *TransitionAdRequest
 		setMock func()
 		want    *AdResponse
 		wantErr bool
 		err     error
 	}{
 		{
-			name: "invalid argument error",
-			args: args{
-				ctx: context.Background(),
-				req: &DeleteAdRequest{},
-			},
+			name: "failed precondition error",
+			req:  &TransitionAdRequest{AdId: 1, Event: "approve"},
 			setMock: func() {
 				a.
-					On("DeleteAd", mock.Anything, mock.Anything, mock.Anything).
-					Return(nil, app.ErrBadRequest).
+					On("TransitionAd", mock.Anything, int64(1), int64(0), ads.EventApprove, "").
+					Return(nil, app.ErrTransition).
 					Once()
 			},
-			want:    nil,
 			wantErr: true,
-			err:     status.Error(codes.InvalidArgument, "Invalid argument"),
+			err:     status.Error(codes.FailedPrecondition, "Transition is not allowed"),
 		},
 		{
 			name: "permission denied error",
-			args: args{
-				ctx: context.Background(),
-				req: &DeleteAdRequest{},
-			},
+			req:  &TransitionAdRequest{AdId: 1, Event: "approve"},
 			setMock: func() {
 				a.
-					On("DeleteAd", mock.Anything, mock.Anything, mock.Anything).
+					On("TransitionAd", mock.Anything, int64(1), int64(0), ads.EventApprove, "").
 					Return(nil, app.ErrForbidden).
 					Once()
 			},
-			want:    nil,
 			wantErr: true,
 			err:     status.Error(codes.PermissionDenied, "Permission denied"),
 		},
-		{
-			name: "internal error",
-			args: args{
-				ctx: context.Background(),
-				req: &DeleteAdRequest{},
-			},
-			setMock: func() {
-				a.
-					On("DeleteAd", mock.Anything, mock.Anything, mock.Anything).
-					Return(nil, fmt.Errorf("some internal error")).
-					Once()
-			},
-			want:    nil,
-			wantErr: true,
-			err:     status.Error(codes.Internal, "Internal server error"),
-		},
 		{
 			name: "ok",
-			args: args{
-				ctx: context.Background(),
-				req: &DeleteAdRequest{},
-			},
+			req:  &TransitionAdRequest{AdId: 1, Event: "reject", Reason: "spam", Version: 3},
 			setMock: func() {
 				a.
-					On("DeleteAd", mock.Anything, mock.Anything, mock.Anything).
+					On("TransitionAd", mock.Anything, int64(1), int64(3), ads.EventReject, "spam").
 					Return(&ads.Ad{
-						ID:        0,
-						Title:     "title",
-						Text:      "text",
-						UserID:    0,
-						Published: true,
+						ID:     1,
+						Status: ads.StatusRejected,
+						Transitions: []ads.Transition{
+							{Event: ads.EventReject, From: ads.StatusPublished, To: ads.StatusRejected, At: changed, Reason: "spam"},
+						},
 					}, nil).
 					Once()
 			},
-			want: &AdResponse{
-				Id:        0,
-				Title:     "title",
-				Text:      "text",
-				UserId:    0,
-				Published: true,
-			},
-			wantErr: false,
+			want: &AdResponse{Id: 1, Status: "rejected", StatusReason: "spam", StatusChanged: timestamppb.New(changed)},
 		},
 	}
 
 	for _, tt := range tests {
 		tt.setMock()
-		resp, err := s.DeleteAd(tt.args.ctx, tt.args.req)
+		resp, err := s.TransitionAd(context.Background(), tt.req)
 		if tt.wantErr {
 			assert.ErrorIs(t, err, tt.err)
 		} else {
 			assert.NoError(t, err)
 			assert.Equal(t, tt.want.Id, resp.Id)
-			assert.Equal(t, tt.want.Title, resp.Title)
-			assert.Equal(t, tt.want.Text, resp.Text)
-			assert.Equal(t, tt.want.UserId, resp.UserId)
 			assert.Equal(t, tt.want.Published, resp.Published)
+			assert.Equal(t, tt.want.Status, resp.Status)
+			assert.Equal(t, tt.want.StatusReason, resp.StatusReason)
+			assert.Equal(t, tt.want.StatusChanged.AsTime(), resp.StatusChanged.AsTime())
 		}
 	}
 }
 
-func TestGRPCService_CreateUser(t *testing.T) {
+func TestGRPCService_RenewAd(t *testing.T) {
 	a := mocks.NewApp(t)
 	s := NewService(a)
+	expires := time.Date(2023, 5, 1, 15, 30, 0, 0, time.UTC)
 
-	type args struct {
-		ctx context.Context
-		req *CreateUserRequest
-	}
 	tests := []struct {
 		name    string
-		args    args
+		req     *RenewAdRequest
 		setMock func()
-		want    *UserResponse
+		want    *AdResponse
 		wantErr bool
 		err     error
 	}{
 		{
-			name: "invalid argument error",
-			args: args{
-				ctx: context.Background(),
-				req: &CreateUserRequest{},
-			},
+			name: "version conflict error",
+			req:  &RenewAdRequest{AdId: 1, Version: 2},
 			setMock: func() {
 				a.
-					On("CreateUser", mock.Anything, mock.Anything, mock.Anything).
-					Return(nil, app.ErrBadRequest).
+					On("RenewAd", mock.Anything, int64(1), int64(2), time.Duration(0)).
+					Return(nil, app.ErrConflict).
 					Once()
 			},
-			want:    nil,
 			wantErr: true,
-			err:     status.Error(codes.InvalidArgument, "Invalid argument"),
+			err:     status.Error(codes.Aborted, "Version conflict"),
 		},
 		{
-			name: "internal error",
-			args: args{
-				ctx: context.Background(),
-				req: &CreateUserRequest{},
-			},
-			setMock: func() {
-				a.
-					On("CreateUser", mock.Anything, mock.Anything, mock.Anything).
-					Return(nil, fmt.Errorf("some internal error")).
-					Once()
-			},
-			want:    nil,
+			name:    "huge ttl",
+			req:     &RenewAdRequest{AdId: 1, Ttl: 18446744074},
+			setMock: func() {},
 			wantErr: true,
-			err:     status.Error(codes.Internal, "Internal server error"),
+			err:     status.Error(codes.InvalidArgument, "Invalid argument"),
 		},
 		{
 			name: "ok",
-			args: args{
-				ctx: context.Background(),
-				req: &CreateUserRequest{},
-			},
+			req:  &RenewAdRequest{AdId: 1, Ttl: 3600},
 			setMock: func() {
 				a.
-					On("CreateUser", mock.Anything, mock.Anything, mock.Anything).
-					Return(&users.User{
-						ID:       0,
-						Nickname: "user",
-						Email:    "user@gmail.com",
-					}, nil).
+					On("RenewAd", mock.Anything, int64(1), int64(0), time.Hour).
+					Return(&ads.Ad{ID: 1, Expires: expires}, nil).
 					Once()
 			},
-			want: &UserResponse{
-				Id:       0,
-				Nickname: "user",
-				Email:    "user@gmail.com",
-			},
-			wantErr: false,
+			want: &AdResponse{Id: 1, Expires: timestamppb.New(expires)},
 		},
 	}
 
 	for _, tt := range tests {
 		tt.setMock()
-		resp, err := s.CreateUser(tt.args.ctx, tt.args.req)
+		resp, err := s.RenewAd(context.Background(), tt.req)
 		if tt.wantErr {
 			assert.ErrorIs(t, err, tt.err)
 		} else {
 			assert.NoError(t, err)
 			assert.Equal(t, tt.want.Id, resp.Id)
-			assert.Equal(t, tt.want.Nickname, resp.Nickname)
-			assert.Equal(t, tt.want.Email, resp.Email)
+			assert.Equal(t, tt.want.Expires.AsTime(), resp.Expires.AsTime())
 		}
 	}
 }
 
-func TestGRPCService_GetUser(t *testing.T) {
+func TestGRPCService_GetAdHistory(t *testing.T) {
 	a := mocks.NewApp(t)
 	s := NewService(a)
+	at := time.Date(2023, 5, 1, 15, 30, 0, 0, time.UTC)
 
-	type args struct {
-		ctx context.Context
-		req *GetUserRequest
-	}
 	tests := []struct {
 		name    string
-		args    args
+		req     *GetAdHistoryRequest
 		setMock func()
-		want    *UserResponse
+		want    *AdHistoryResponse
 		wantErr bool
 		err     error
 	}{
 		{
-			name: "invalid argument error",
-			args: args{
-				ctx: context.Background(),
-				req: &GetUserRequest{},
-			},
-			setMock: func() {
-				a.
-					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, app.ErrBadRequest).
-					Once()
-			},
-			want:    nil,
-			wantErr: true,
-			err:     status.Error(codes.InvalidArgument, "Invalid argument"),
-		},
-		{
-			name: "internal error",
-			args: args{
-				ctx: context.Background(),
-				req: &GetUserRequest{},
-			},
+			name: "permission denied error",
+			req:  &GetAdHistoryRequest{AdId: 1},
 			setMock: func() {
 				a.
-					On("UserByID", mock.Anything, mock.Anything).
-					Return(nil, fmt.Errorf("some internal error")).
+					On("AdHistory", mock.Anything, int64(1)).
+					Return(nil, app.ErrForbidden).
 					Once()
 			},
-			want:    nil,
 			wantErr: true,
-			err:     status.Error(codes.Internal, "Internal server error"),
+			err:     status.Error(codes.PermissionDenied, "Permission denied"),
 		},
 		{
 			name: "ok",
-			args: args{
-				ctx: context.Background(),
-				req: &GetUserRequest{},
-			},
+			req:  &GetAdHistoryRequest{AdId: 1},
 			setMock: func() {
 				a.
-					On("UserByID", mock.Anything, mock.Anything).
-					Return(&users.User{
-						ID:       0,
-						Nickname: "user",
-						Email:    "user@gmail.com",
+					On("AdHistory", mock.Anything, int64(1)).
+					Return([]*audit.Entry{
+						{ID: 1, Revision: 1, ActorID: 2, Op: audit.OpCreate, At: at, After: audit.AdSnapshot(&ads.Ad{ID: 1, Title: "title"})},
+						{ID: 3, Revision: 2, ActorID: 2, Op: audit.OpDelete, At: at, Before: audit.AdSnapshot(&ads.Ad{ID: 1, Title: "title"})},
 					}, nil).
 					Once()
 			},
-			want: &UserResponse{
-				Id:       0,
-				Nickname: "user",
-				Email:    "user@gmail.com",
-			},
-			wantErr: false,
+			want: &AdHistoryResponse{Entries: []*AdHistoryEntry{
+				{Id: 1, Revision: 1, ActorId: 2, Op: "create", At: timestamppb.New(at), After: &AdResponse{Id: 1, Title: "title"}},
+				{Id: 3, Revision: 2, ActorId: 2, Op: "delete", At: timestamppb.New(at), Before: &AdResponse{Id: 1, Title: "title"}},
+			}},
 		},
 	}
 
 	for _, tt := range tests {
 		tt.setMock()
-		resp, err := s.GetUser(tt.args.ctx, tt.args.req)
+		resp, err := s.GetAdHistory(context.Background(), tt.req)
 		if tt.wantErr {
 			assert.ErrorIs(t, err, tt.err)
-		} else {
-			assert.NoError(t, err)
-			assert.Equal(t, tt.want.Id, resp.Id)
-			assert.Equal(t, tt.want.Nickname, resp.Nickname)
-			assert.Equal(t, tt.want.Email, resp.Email)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Len(t, resp.Entries, len(tt.want.Entries))
+		for i, e := range tt.want.Entries {
+			assert.Equal(t, e.Id, resp.Entries[i].Id)
+			assert.Equal(t, e.Revision, resp.Entries[i].Revision)
+			assert.Equal(t, e.ActorId, resp.Entries[i].ActorId)
+			assert.Equal(t, e.Op, resp.Entries[i].Op)
+			assert.Equal(t, e.At.AsTime(), resp.Entries[i].At.AsTime())
+			assert.Equal(t, e.Before == nil, resp.Entries[i].Before == nil)
+			assert.Equal(t, e.After == nil, resp.Entries[i].After == nil)
 		}
+		assert.Equal(t, "title", resp.Entries[0].After.Title)
+		assert.Equal(t, "title", resp.Entries[1].Before.Title)
 	}
 }
 
-func TestGRPCService_UpdateUser(t *testing.T) {
+func TestGRPCService_RevertAd(t *testing.T) {
 	a := mocks.NewApp(t)
 	s := NewService(a)
 
-	type args struct {
-		ctx context.Context
-		req *UpdateUserRequest
-	}
 	tests := []struct {
 		name    string
-		args    args
+		req     *RevertAdRequest
 		setMock func()
-		want    *UserResponse
+		want    *AdResponse
 		wantErr bool
 		err     error
 	}{
 		{
 			name: "invalid argument error",
-			args: args{
-				ctx: context.Background(),
-				req: &UpdateUserRequest{},
-			},
+			req:  &RevertAdRequest{AdId: 1, Revision: 10},
 			setMock: func() {
 				a.
-					On("UpdateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					On("RevertAd", mock.Anything, int64(1), int64(0), int64(10)).
 					Return(nil, app.ErrBadRequest).
 					Once()
 			},
-			want:    nil,
 			wantErr: true,
 			err:     status.Error(codes.InvalidArgument, "Invalid argument"),
 		},
 		{
-			name: "internal error",
-			args: args{
-				ctx: context.Background(),
-				req: &UpdateUserRequest{},
+			name: "version conflict error",
+			req:  &RevertAdRequest{AdId: 1, Version: 2, Revision: 1},
+			setMock: func() {
+				a.
+					On("RevertAd", mock.Anything, int64(1), int64(2), int64(1)).
+					Return(nil, app.ErrConflict).
+					Once()
+			},
+			wantErr: true,
+			err:     status.Error(codes.Aborted, "Version conflict"),
+		},
+		{
+			name: "ok",
+			req:  &RevertAdRequest{AdId: 1, Version: 3, Revision: 1},
+			setMock: func() {
+				a.
+					On("RevertAd", mock.Anything, int64(1), int64(3), int64(1)).
+					Return(&ads.Ad{ID: 1, Title: "title", Version: 4}, nil).
+					Once()
+			},
+			want: &AdResponse{Id: 1, Title: "title", Version: 4},
+		},
+	}
+
+	for _, tt := range tests {
+		tt.setMock()
+		resp, err := s.RevertAd(context.Background(), tt.req)
+		if tt.wantErr {
+			assert.ErrorIs(t, err, tt.err)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.Id, resp.Id)
+			assert.Equal(t, tt.want.Title, resp.Title)
+			assert.Equal(t, tt.want.Version, resp.Version)
+		}
+	}
+}
+
+func TestGRPCService_DeleteAd(t *testing.T) {
+	a := mocks.NewApp(t)
+	s := NewService(a)
+
+	type args struct {
+		ctx context.Context
+		req *DeleteAdRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		setMock func()
+		want    *AdResponse
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "invalid argument error",
+			args: args{
+				ctx: context.Background(),
+				req: &DeleteAdRequest{},
+			},
+			setMock: func() {
+				a.
+					On("DeleteAd", mock.Anything, mock.Anything).
+					Return(nil, app.ErrBadRequest).
+					Once()
+			},
+			want:    nil,
+			wantErr: true,
+			err:     status.Error(codes.InvalidArgument, "Invalid argument"),
+		},
+		{
+			name: "permission denied error",
+			args: args{
+				ctx: context.Background(),
+				req: &DeleteAdRequest{},
 			},
 			setMock: func() {
 				a.
-					On("UpdateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					On("DeleteAd", mock.Anything, mock.Anything).
+					Return(nil, app.ErrForbidden).
+					Once()
+			},
+			want:    nil,
+			wantErr: true,
+			err:     status.Error(codes.PermissionDenied, "Permission denied"),
+		},
+		{
+			name: "internal error",
+			args: args{
+				ctx: context.Background(),
+				req: &DeleteAdRequest{},
+			},
+			setMock: func() {
+				a.
+					On("DeleteAd", mock.Anything, mock.Anything).
 					Return(nil, fmt.Errorf("some internal error")).
 					Once()
 			},
@@ -892,11 +1143,104 @@ func TestGRPCService_UpdateUser(t *testing.T) {
 			name: "ok",
 			args: args{
 				ctx: context.Background(),
-				req: &UpdateUserRequest{},
+				req: &DeleteAdRequest{},
+			},
+			setMock: func() {
+				a.
+					On("DeleteAd", mock.Anything, mock.Anything).
+					Return(&ads.Ad{
+						ID:     0,
+						Title:  "title",
+						Text:   "text",
+						UserID: 0,
+						Status: ads.StatusPublished,
+					}, nil).
+					Once()
+			},
+			want: &AdResponse{
+				Id:        0,
+				Title:     "title",
+				Text:      "text",
+				UserId:    0,
+				Published: true,
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt.setMock()
+		resp, err := s.DeleteAd(tt.args.ctx, tt.args.req)
+		if tt.wantErr {
+			assert.ErrorIs(t, err, tt.err)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.Id, resp.Id)
+			assert.Equal(t, tt.want.Title, resp.Title)
+			assert.Equal(t, tt.want.Text, resp.Text)
+			assert.Equal(t, tt.want.UserId, resp.UserId)
+			assert.Equal(t, tt.want.Published, resp.Published)
+		}
+	}
+}
+
+func TestGRPCService_CreateUser(t *testing.T) {
+	a := mocks.NewApp(t)
+	s := NewService(a)
+
+	type args struct {
+		ctx context.Context
+		req *CreateUserRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		setMock func()
+		want    *UserResponse
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "invalid argument error",
+			args: args{
+				ctx: context.Background(),
+				req: &CreateUserRequest{},
+			},
+			setMock: func() {
+				a.
+					On("CreateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, app.ErrBadRequest).
+					Once()
+			},
+			want:    nil,
+			wantErr: true,
+			err:     status.Error(codes.InvalidArgument, "Invalid argument"),
+		},
+		{
+			name: "internal error",
+			args: args{
+				ctx: context.Background(),
+				req: &CreateUserRequest{},
+			},
+			setMock: func() {
+				a.
+					On("CreateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("some internal error")).
+					Once()
+			},
+			want:    nil,
+			wantErr: true,
+			err:     status.Error(codes.Internal, "Internal server error"),
+		},
+		{
+			name: "ok",
+			args: args{
+				ctx: context.Background(),
+				req: &CreateUserRequest{},
 			},
 			setMock: func() {
 				a.
-					On("UpdateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					On("CreateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return(&users.User{
 						ID:       0,
 						Nickname: "user",
@@ -915,7 +1259,7 @@ func TestGRPCService_UpdateUser(t *testing.T) {
 
 	for _, tt := range tests {
 		tt.setMock()
-		resp, err := s.UpdateUser(tt.args.ctx, tt.args.req)
+		resp, err := s.CreateUser(tt.args.ctx, tt.args.req)
 		if tt.wantErr {
 			assert.ErrorIs(t, err, tt.err)
 		} else {
@@ -927,13 +1271,13 @@ func TestGRPCService_UpdateUser(t *testing.T) {
 	}
 }
 
-func TestGRPCService_DeleteUser(t *testing.T) {
+func TestGRPCService_GetUser(t *testing.T) {
 	a := mocks.NewApp(t)
 	s := NewService(a)
 
 	type args struct {
 		ctx context.Context
-		req *DeleteUserRequest
+		req *GetUserRequest
 	}
 	tests := []struct {
 		name    string
@@ -947,11 +1291,11 @@ func TestGRPCService_DeleteUser(t *testing.T) {
 			name: "invalid argument error",
 			args: args{
 				ctx: context.Background(),
-				req: &DeleteUserRequest{},
+				req: &GetUserRequest{},
 			},
 			setMock: func() {
 				a.
-					On("DeleteUser", mock.Anything, mock.Anything).
+					On("UserByID", mock.Anything, mock.Anything).
 					Return(nil, app.ErrBadRequest).
 					Once()
 			},
@@ -963,11 +1307,11 @@ func TestGRPCService_DeleteUser(t *testing.T) {
 			name: "internal error",
 			args: args{
 				ctx: context.Background(),
-				req: &DeleteUserRequest{},
+				req: &GetUserRequest{},
 			},
 			setMock: func() {
 				a.
-					On("DeleteUser", mock.Anything, mock.Anything).
+					On("UserByID", mock.Anything, mock.Anything).
 					Return(nil, fmt.Errorf("some internal error")).
 					Once()
 			},
@@ -979,11 +1323,11 @@ func TestGRPCService_DeleteUser(t *testing.T) {
 			name: "ok",
 			args: args{
 				ctx: context.Background(),
-				req: &DeleteUserRequest{},
+				req: &GetUserRequest{},
 			},
 			setMock: func() {
 				a.
-					On("DeleteUser", mock.Anything, mock.Anything).
+					On("UserByID", mock.Anything, mock.Anything).
 					Return(&users.User{
 						ID:       0,
 						Nickname: "user",
@@ -1002,7 +1346,7 @@ func TestGRPCService_DeleteUser(t *testing.T) {
 
 	for _, tt := range tests {
 		tt.setMock()
-		resp, err := s.DeleteUser(tt.args.ctx, tt.args.req)
+		resp, err := s.GetUser(tt.args.ctx, tt.args.req)
 		if tt.wantErr {
 			assert.ErrorIs(t, err, tt.err)
 		} else {
@@ -1013,3 +1357,1122 @@ func TestGRPCService_DeleteUser(t *testing.T) {
 		}
 	}
 }
+
+func TestGRPCService_UpdateUser(t *testing.T) {
+	a := mocks.NewApp(t)
+	s := NewService(a)
+
+	type args struct {
+		ctx context.Context
+		req *UpdateUserRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		setMock func()
+		want    *UserResponse
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "invalid argument error",
+			args: args{
+				ctx: context.Background(),
+				req: &UpdateUserRequest{},
+			},
+			setMock: func() {
+				a.
+					On("UpdateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, app.ErrBadRequest).
+					Once()
+			},
+			want:    nil,
+			wantErr: true,
+			err:     status.Error(codes.InvalidArgument, "Invalid argument"),
+		},
+		{
+			name: "version conflict error",
+			args: args{
+				ctx: context.Background(),
+				req: &UpdateUserRequest{Version: 1},
+			},
+			setMock: func() {
+				a.
+					On("UpdateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, app.ErrConflict).
+					Once()
+			},
+			want:    nil,
+			wantErr: true,
+			err:     status.Error(codes.Aborted, "Version conflict"),
+		},
+		{
+			name: "internal error",
+			args: args{
+				ctx: context.Background(),
+				req: &UpdateUserRequest{},
+			},
+			setMock: func() {
+				a.
+					On("UpdateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("some internal error")).
+					Once()
+			},
+			want:    nil,
+			wantErr: true,
+			err:     status.Error(codes.Internal, "Internal server error"),
+		},
+		{
+			name: "ok",
+			args: args{
+				ctx: context.Background(),
+				req: &UpdateUserRequest{},
+			},
+			setMock: func() {
+				a.
+					On("UpdateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(&users.User{
+						ID:       0,
+						Nickname: "user",
+						Email:    "user@gmail.com",
+					}, nil).
+					Once()
+			},
+			want: &UserResponse{
+				Id:       0,
+				Nickname: "user",
+				Email:    "user@gmail.com",
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt.setMock()
+		resp, err := s.UpdateUser(tt.args.ctx, tt.args.req)
+		if tt.wantErr {
+			assert.ErrorIs(t, err, tt.err)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.Id, resp.Id)
+			assert.Equal(t, tt.want.Nickname, resp.Nickname)
+			assert.Equal(t, tt.want.Email, resp.Email)
+		}
+	}
+}
+
+func TestGRPCService_DeleteUser(t *testing.T) {
+	a := mocks.NewApp(t)
+	s := NewService(a)
+
+	type args struct {
+		ctx context.Context
+		req *DeleteUserRequest
+	}
+	tests := []struct {
+		name    string
+		args    args
+		setMock func()
+		want    *UserResponse
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "invalid argument error",
+			args: args{
+				ctx: context.Background(),
+				req: &DeleteUserRequest{},
+			},
+			setMock: func() {
+				a.
+					On("DeleteUser", mock.Anything, mock.Anything).
+					Return(nil, app.ErrBadRequest).
+					Once()
+			},
+			want:    nil,
+			wantErr: true,
+			err:     status.Error(codes.InvalidArgument, "Invalid argument"),
+		},
+		{
+			name: "internal error",
+			args: args{
+				ctx: context.Background(),
+				req: &DeleteUserRequest{},
+			},
+			setMock: func() {
+				a.
+					On("DeleteUser", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("some internal error")).
+					Once()
+			},
+			want:    nil,
+			wantErr: true,
+			err:     status.Error(codes.Internal, "Internal server error"),
+		},
+		{
+			name: "ok",
+			args: args{
+				ctx: context.Background(),
+				req: &DeleteUserRequest{},
+			},
+			setMock: func() {
+				a.
+					On("DeleteUser", mock.Anything, mock.Anything).
+					Return(&users.User{
+						ID:       0,
+						Nickname: "user",
+						Email:    "user@gmail.com",
+					}, nil).
+					Once()
+			},
+			want: &UserResponse{
+				Id:       0,
+				Nickname: "user",
+				Email:    "user@gmail.com",
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt.setMock()
+		resp, err := s.DeleteUser(tt.args.ctx, tt.args.req)
+		if tt.wantErr {
+			assert.ErrorIs(t, err, tt.err)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.Id, resp.Id)
+			assert.Equal(t, tt.want.Nickname, resp.Nickname)
+			assert.Equal(t, tt.want.Email, resp.Email)
+		}
+	}
+}
+
+func TestGRPCService_ChangeUserRole(t *testing.T) {
+	a := mocks.NewApp(t)
+	s := NewService(a)
+
+	tests := []struct {
+		name    string
+		req     *ChangeUserRoleRequest
+		setMock func()
+		want    *UserResponse
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "permission denied error",
+			req:  &ChangeUserRoleRequest{Id: 1, Role: "admin"},
+			setMock: func() {
+				a.
+					On("ChangeUserRole", mock.Anything, int64(1), int64(0), users.RoleAdmin).
+					Return(nil, app.ErrForbidden).
+					Once()
+			},
+			wantErr: true,
+			err:     status.Error(codes.PermissionDenied, "Permission denied"),
+		},
+		{
+			name: "invalid argument error",
+			req:  &ChangeUserRoleRequest{Id: 1, Role: "king"},
+			setMock: func() {
+				a.
+					On("ChangeUserRole", mock.Anything, int64(1), int64(0), users.Role("king")).
+					Return(nil, app.ErrBadRequest).
+					Once()
+			},
+			wantErr: true,
+			err:     status.Error(codes.InvalidArgument, "Invalid argument"),
+		},
+		{
+			name: "ok",
+			req:  &ChangeUserRoleRequest{Id: 1, Role: "moderator"},
+			setMock: func() {
+				a.
+					On("ChangeUserRole", mock.Anything, int64(1), int64(0), users.RoleModerator).
+					Return(&users.User{ID: 1, Nickname: "user", Role: users.RoleModerator}, nil).
+					Once()
+			},
+			want: &UserResponse{Id: 1, Nickname: "user", Role: "moderator"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt.setMock()
+		resp, err := s.ChangeUserRole(context.Background(), tt.req)
+		if tt.wantErr {
+			assert.ErrorIs(t, err, tt.err)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.Id, resp.Id)
+			assert.Equal(t, tt.want.Nickname, resp.Nickname)
+			assert.Equal(t, tt.want.Role, resp.Role)
+		}
+	}
+}
+
+func TestGRPCService_CreateCategory(t *testing.T) {
+	a := mocks.NewApp(t)
+	s := NewService(a)
+
+	tests := []struct {
+		name    string
+		req     *CreateCategoryRequest
+		setMock func()
+		want    *CategoryResponse
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "permission denied error",
+			req:  &CreateCategoryRequest{Name: "Телефоны", ParentId: 1},
+			setMock: func() {
+				a.
+					On("CreateCategory", mock.Anything, "Телефоны", int64(1)).
+					Return(nil, app.ErrForbidden).
+					Once()
+			},
+			wantErr: true,
+			err:     status.Error(codes.PermissionDenied, "Permission denied"),
+		},
+		{
+			name: "invalid argument error",
+			req:  &CreateCategoryRequest{Name: "", ParentId: 1},
+			setMock: func() {
+				a.
+					On("CreateCategory", mock.Anything, "", int64(1)).
+					Return(nil, app.ErrBadRequest).
+					Once()
+			},
+			wantErr: true,
+			err:     status.Error(codes.InvalidArgument, "Invalid argument"),
+		},
+		{
+			name: "ok",
+			req:  &CreateCategoryRequest{Name: "Телефоны", ParentId: 1},
+			setMock: func() {
+				a.
+					On("CreateCategory", mock.Anything, "Телефоны", int64(1)).
+					Return(&categories.Category{ID: 2, ParentID: 1, Name: "Телефоны", Version: 1}, nil).
+					Once()
+			},
+			want: &CategoryResponse{Id: 2, ParentId: 1, Name: "Телефоны", Version: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		tt.setMock()
+		resp, err := s.CreateCategory(context.Background(), tt.req)
+		if tt.wantErr {
+			assert.ErrorIs(t, err, tt.err)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, resp)
+		}
+	}
+}
+
+func TestGRPCService_UpdateCategory(t *testing.T) {
+	a := mocks.NewApp(t)
+	s := NewService(a)
+
+	a.
+		On("UpdateCategory", mock.Anything, int64(2), int64(1), "Смартфоны", int64(1)).
+		Return(nil, app.ErrConflict).
+		Once()
+	_, err := s.UpdateCategory(context.Background(), &UpdateCategoryRequest{Id: 2, Name: "Смартфоны", ParentId: 1, Version: 1})
+	assert.ErrorIs(t, err, status.Error(codes.Aborted, "Version conflict"))
+
+	a.
+		On("UpdateCategory", mock.Anything, int64(2), int64(0), "Смартфоны", int64(1)).
+		Return(&categories.Category{ID: 2, ParentID: 1, Name: "Смартфоны", Version: 3}, nil).
+		Once()
+	resp, err := s.UpdateCategory(context.Background(), &UpdateCategoryRequest{Id: 2, Name: "Смартфоны", ParentId: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, &CategoryResponse{Id: 2, ParentId: 1, Name: "Смартфоны", Version: 3}, resp)
+}
+
+func TestGRPCService_DeleteCategory(t *testing.T) {
+	a := mocks.NewApp(t)
+	s := NewService(a)
+
+	a.
+		On("DeleteCategory", mock.Anything, int64(1)).
+		Return(nil, app.ErrBadRequest).
+		Once()
+	_, err := s.DeleteCategory(context.Background(), &DeleteCategoryRequest{Id: 1})
+	assert.ErrorIs(t, err, status.Error(codes.InvalidArgument, "Invalid argument"))
+
+	a.
+		On("DeleteCategory", mock.Anything, int64(2)).
+		Return(&categories.Category{ID: 2, ParentID: 1, Name: "Телефоны", Version: 1}, nil).
+		Once()
+	resp, err := s.DeleteCategory(context.Background(), &DeleteCategoryRequest{Id: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), resp.Id)
+}
+
+func TestGRPCService_ListCategories(t *testing.T) {
+	a := mocks.NewApp(t)
+	s := NewService(a)
+
+	a.
+		On("Categories", mock.Anything).
+		Return(categories.NewTree([]*categories.Category{
+			{ID: 1, Name: "Электроника", Version: 1},
+			{ID: 2, ParentID: 1, Name: "Телефоны", Version: 2},
+		}), nil).
+		Once()
+
+	resp, err := s.ListCategories(context.Background(), &ListCategoriesRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, []*CategoryResponse{
+		{Id: 1, Name: "Электроника", Version: 1, Path: []string{"Электроника"}},
+		{Id: 2, ParentId: 1, Name: "Телефоны", Version: 2, Path: []string{"Электроника", "Телефоны"}},
+	}, resp.List)
+}
+
+func TestGRPCService_CreateWebhook(t *testing.T) {
+	a := mocks.NewApp(t)
+	s := NewService(a)
+	created := time.Date(2023, 5, 1, 15, 30, 0, 0, time.UTC)
+
+	a.
+		On("CreateWebhook", mock.Anything, "http://example.com", []events.Type(nil)).
+		Return(nil, app.ErrForbidden).
+		Once()
+	_, err := s.CreateWebhook(context.Background(), &CreateWebhookRequest{Url: "http://example.com"})
+	assert.ErrorIs(t, err, status.Error(codes.PermissionDenied, "Permission denied"))
+
+	a.
+		On("CreateWebhook", mock.Anything, "http://example.com", []events.Type{"ad.viewed"}).
+		Return(nil, app.ErrBadRequest).
+		Once()
+	_, err = s.CreateWebhook(context.Background(), &CreateWebhookRequest{Url: "http://example.com", Events: []string{"ad.viewed"}})
+	assert.ErrorIs(t, err, status.Error(codes.InvalidArgument, "Invalid argument"))
+
+	a.
+		On("CreateWebhook", mock.Anything, "http://example.com", []events.Type{events.AdCreated}).
+		Return(&webhooks.Subscription{ID: 1, URL: "http://example.com", Types: []events.Type{events.AdCreated}, Secret: "secret", Created: created}, nil).
+		Once()
+	resp, err := s.CreateWebhook(context.Background(), &CreateWebhookRequest{Url: "http://example.com", Events: []string{"ad.created"}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), resp.Id)
+	assert.Equal(t, []string{"ad.created"}, resp.Events)
+	assert.Equal(t, "secret", resp.Secret)
+	assert.Equal(t, created, resp.Created.AsTime())
+}
+
+func TestGRPCService_ListWebhooks(t *testing.T) {
+	a := mocks.NewApp(t)
+	s := NewService(a)
+
+	a.
+		On("Webhooks", mock.Anything).
+		Return(nil, app.ErrUnauthorized).
+		Once()
+	_, err := s.ListWebhooks(context.Background(), &ListWebhooksRequest{})
+	assert.ErrorIs(t, err, status.Error(codes.Unauthenticated, "Unauthenticated"))
+
+	a.
+		On("Webhooks", mock.Anything).
+		Return([]*webhooks.Subscription{{ID: 1, URL: "http://a.example", Secret: "secret"}, {ID: 2, URL: "http://b.example"}}, nil).
+		Once()
+	resp, err := s.ListWebhooks(context.Background(), &ListWebhooksRequest{})
+	assert.NoError(t, err)
+	if assert.Len(t, resp.List, 2) {
+		assert.Equal(t, "http://a.example", resp.List[0].Url)
+		// секрет показывается только при создании
+		assert.Empty(t, resp.List[0].Secret)
+		assert.Equal(t, int64(2), resp.List[1].Id)
+	}
+}
+
+func TestGRPCService_DeleteWebhook(t *testing.T) {
+	a := mocks.NewApp(t)
+	s := NewService(a)
+
+	a.
+		On("DeleteWebhook", mock.Anything, int64(2)).
+		Return(nil, app.ErrBadRequest).
+		Once()
+	_, err := s.DeleteWebhook(context.Background(), &DeleteWebhookRequest{Id: 2})
+	assert.ErrorIs(t, err, status.Error(codes.InvalidArgument, "Invalid argument"))
+
+	a.
+		On("DeleteWebhook", mock.Anything, int64(1)).
+		Return(&webhooks.Subscription{ID: 1, URL: "http://a.example"}, nil).
+		Once()
+	resp, err := s.DeleteWebhook(context.Background(), &DeleteWebhookRequest{Id: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), resp.Id)
+}
+
+func TestGRPCService_ListWebhookDeliveries(t *testing.T) {
+	a := mocks.NewApp(t)
+	s := NewService(a)
+	at := time.Date(2023, 5, 1, 15, 30, 0, 0, time.UTC)
+
+	a.
+		On("WebhookDeliveries", mock.Anything, int64(1)).
+		Return(nil, app.ErrInternalHookRepoError).
+		Once()
+	_, err := s.ListWebhookDeliveries(context.Background(), &ListWebhookDeliveriesRequest{WebhookId: 1})
+	assert.ErrorIs(t, err, status.Error(codes.Internal, "Internal server error"))
+
+	a.
+		On("WebhookDeliveries", mock.Anything, int64(1)).
+		Return([]*webhooks.Delivery{
+			{
+				ID:          2,
+				Event:       events.Event{ID: 5, Type: events.AdPublished},
+				Status:      webhooks.DeliveryPending,
+				Attempts:    []webhooks.Attempt{{At: at, StatusCode: 500, Error: "unexpected status 500", Duration: 15 * time.Millisecond}},
+				NextAttempt: at.Add(time.Minute),
+				Created:     at,
+			},
+			{
+				ID:       1,
+				Event:    events.Event{ID: 4, Type: events.AdCreated},
+				Status:   webhooks.DeliveryDelivered,
+				Attempts: []webhooks.Attempt{{At: at, StatusCode: 200}},
+				Created:  at,
+			},
+		}, nil).
+		Once()
+	resp, err := s.ListWebhookDeliveries(context.Background(), &ListWebhookDeliveriesRequest{WebhookId: 1})
+	assert.NoError(t, err)
+	if assert.Len(t, resp.List, 2) {
+		assert.Equal(t, int64(5), resp.List[0].EventId)
+		assert.Equal(t, "ad.published", resp.List[0].Event)
+		assert.Equal(t, "pending", resp.List[0].Status)
+		assert.Equal(t, at.Add(time.Minute), resp.List[0].NextAttempt.AsTime())
+		if assert.Len(t, resp.List[0].Attempts, 1) {
+			assert.Equal(t, int32(500), resp.List[0].Attempts[0].StatusCode)
+			assert.Equal(t, int64(15), resp.List[0].Attempts[0].DurationMs)
+		}
+		assert.Equal(t, "delivered", resp.List[1].Status)
+		assert.Nil(t, resp.List[1].NextAttempt)
+	}
+}
+
+func TestGRPCService_ListFavorites(t *testing.T) {
+	a := mocks.NewApp(t)
+	s := NewService(a)
+
+	a.
+		On("Favorites", mock.Anything, int64(2)).
+		Return(nil, app.ErrForbidden).
+		Once()
+	_, err := s.ListFavorites(context.Background(), &ListFavoritesRequest{UserId: 2})
+	assert.ErrorIs(t, err, status.Error(codes.PermissionDenied, "Permission denied"))
+
+	a.
+		On("Favorites", mock.Anything, int64(1)).
+		Return([]*ads.Ad{{ID: 5, Title: "title", Text: "text", Favorites: 3}, {ID: 3, Title: "title", Text: "text", Favorites: 1}}, nil).
+		Once()
+	resp, err := s.ListFavorites(context.Background(), &ListFavoritesRequest{UserId: 1})
+	assert.NoError(t, err)
+	if assert.Len(t, resp.List, 2) {
+		assert.Equal(t, int64(5), resp.List[0].Id)
+		assert.Equal(t, int64(3), resp.List[0].Favorites)
+		assert.Equal(t, int64(3), resp.List[1].Id)
+	}
+}
+
+func TestGRPCService_ChangeFavorite(t *testing.T) {
+	a := mocks.NewApp(t)
+	s := NewService(a)
+
+	a.
+		On("AddFavorite", mock.Anything, int64(1), int64(5)).
+		Return(nil, app.ErrUnauthorized).
+		Once()
+	_, err := s.AddFavorite(context.Background(), &FavoriteRequest{UserId: 1, AdId: 5})
+	assert.ErrorIs(t, err, status.Error(codes.Unauthenticated, "Unauthenticated"))
+
+	a.
+		On("AddFavorite", mock.Anything, int64(1), int64(5)).
+		Return(&ads.Ad{ID: 5, Title: "title", Text: "text", Favorites: 1}, nil).
+		Once()
+	resp, err := s.AddFavorite(context.Background(), &FavoriteRequest{UserId: 1, AdId: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), resp.Favorites)
+
+	a.
+		On("DeleteFavorite", mock.Anything, int64(1), int64(5)).
+		Return(nil, app.ErrBadRequest).
+		Once()
+	_, err = s.DeleteFavorite(context.Background(), &FavoriteRequest{UserId: 1, AdId: 5})
+	assert.ErrorIs(t, err, status.Error(codes.InvalidArgument, "Invalid argument"))
+
+	a.
+		On("DeleteFavorite", mock.Anything, int64(1), int64(5)).
+		Return(&ads.Ad{ID: 5, Title: "title", Text: "text"}, nil).
+		Once()
+	resp, err = s.DeleteFavorite(context.Background(), &FavoriteRequest{UserId: 1, AdId: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), resp.Favorites)
+}
+
+func TestGRPCService_SavedSearches(t *testing.T) {
+	a := mocks.NewApp(t)
+	s := NewService(a)
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	priceMax := int64(1000)
+
+	_, err := s.CreateSavedSearch(context.Background(), &CreateSavedSearchRequest{
+		UserId: 1,
+		Name:   "Плохой",
+		Filter: &ListAdsRequest{Status: []string{"unknown"}},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	q := ads.Query{Query: "велосипед", Category: 4, PriceMax: &priceMax, Currency: "RUB", Statuses: []ads.Status{ads.StatusPublished}}
+	a.
+		On("CreateSavedSearch", mock.Anything, int64(1), "Велосипеды", q).
+		Return(&searches.Search{ID: 3, UserID: 1, Name: "Велосипеды", Query: q, Created: created, Updated: created}, nil).
+		Once()
+	filter := &ListAdsRequest{Query: "велосипед", CategoryId: 4, PriceMax: &priceMax, Currency: "RUB", Status: []string{"published"}}
+	resp, err := s.CreateSavedSearch(context.Background(), &CreateSavedSearchRequest{UserId: 1, Name: "Велосипеды", Filter: filter})
+	assert.NoError(t, err)
+	assert.Equal(t, &SavedSearchResponse{
+		Id:      3,
+		UserId:  1,
+		Name:    "Велосипеды",
+		Filter:  filter,
+		Created: timestamppb.New(created),
+		Updated: timestamppb.New(created),
+	}, resp)
+
+	a.
+		On("UpdateSavedSearch", mock.Anything, int64(1), int64(5), "Всё", ads.Query{}).
+		Return(nil, app.ErrBadRequest).
+		Once()
+	_, err = s.UpdateSavedSearch(context.Background(), &UpdateSavedSearchRequest{UserId: 1, Id: 5, Name: "Всё"})
+	assert.ErrorIs(t, err, status.Error(codes.InvalidArgument, "Invalid argument"))
+
+	a.
+		On("DeleteSavedSearch", mock.Anything, int64(2), int64(3)).
+		Return(nil, app.ErrForbidden).
+		Once()
+	_, err = s.DeleteSavedSearch(context.Background(), &DeleteSavedSearchRequest{UserId: 2, Id: 3})
+	assert.ErrorIs(t, err, status.Error(codes.PermissionDenied, "Permission denied"))
+
+	a.
+		On("SavedSearches", mock.Anything, int64(1)).
+		Return([]*searches.Search{{ID: 3, UserID: 1, Name: "Велосипеды", Query: q}}, nil).
+		Once()
+	list, err := s.ListSavedSearches(context.Background(), &ListSavedSearchesRequest{UserId: 1})
+	assert.NoError(t, err)
+	if assert.Len(t, list.List, 1) {
+		assert.Equal(t, int64(3), list.List[0].Id)
+		assert.Equal(t, "велосипед", list.List[0].Filter.Query)
+	}
+}
+
+func TestGRPCService_Notifications(t *testing.T) {
+	a := mocks.NewApp(t)
+	s := NewService(a)
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	a.
+		On("Notifications", mock.Anything, int64(2)).
+		Return(nil, 0, app.ErrUnauthorized).
+		Once()
+	_, err := s.ListNotifications(context.Background(), &ListNotificationsRequest{UserId: 2})
+	assert.ErrorIs(t, err, status.Error(codes.Unauthenticated, "Unauthenticated"))
+
+	a.
+		On("ReadNotifications", mock.Anything, int64(1), int64(0)).
+		Return(app.ErrBadRequest).
+		Once()
+	_, err = s.ReadNotifications(context.Background(), &ReadNotificationsRequest{UserId: 1})
+	assert.ErrorIs(t, err, status.Error(codes.InvalidArgument, "Invalid argument"))
+
+	a.
+		On("ReadNotifications", mock.Anything, int64(1), int64(1)).
+		Return(nil).
+		Once()
+	a.
+		On("Notifications", mock.Anything, int64(1)).
+		Return([]*searches.Notification{
+			{ID: 2, UserID: 1, SearchID: 3, AdID: 7, Title: "Велосипед", Created: created},
+			{ID: 1, UserID: 1, SearchID: 3, AdID: 5, Title: "Самокат", Created: created, Read: true},
+		}, 1, nil).
+		Once()
+	resp, err := s.ReadNotifications(context.Background(), &ReadNotificationsRequest{UserId: 1, UpTo: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), resp.UnreadTotal)
+	if assert.Len(t, resp.List, 2) {
+		assert.Equal(t, &NotificationResponse{Id: 2, SearchId: 3, AdId: 7, Title: "Велосипед", Created: timestamppb.New(created)}, resp.List[0])
+		assert.True(t, resp.List[1].Read)
+	}
+}
+
+func TestGRPCService_Messages(t *testing.T) {
+	a := mocks.NewApp(t)
+	s := NewService(a)
+	sent := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	a.
+		On("ContactSeller", mock.Anything, int64(5), "").
+		Return(nil, app.ErrBadRequest).
+		Once()
+	_, err := s.ContactSeller(context.Background(), &ContactSellerRequest{AdId: 5})
+	assert.ErrorIs(t, err, status.Error(codes.InvalidArgument, "Invalid argument"))
+
+	a.
+		On("ContactSeller", mock.Anything, int64(5), "Здравствуйте").
+		Return(&messages.Message{ID: 1, ThreadID: 3, SenderID: 2, Text: "Здравствуйте", Sent: sent}, nil).
+		Once()
+	m, err := s.ContactSeller(context.Background(), &ContactSellerRequest{AdId: 5, Text: "Здравствуйте"})
+	assert.NoError(t, err)
+	assert.Equal(t, &MessageResponse{Id: 1, ThreadId: 3, SenderId: 2, Text: "Здравствуйте", Sent: timestamppb.New(sent)}, m)
+
+	a.
+		On("SendMessage", mock.Anything, int64(3), "Да").
+		Return(nil, app.ErrForbidden).
+		Once()
+	_, err = s.SendMessage(context.Background(), &SendMessageRequest{ThreadId: 3, Text: "Да"})
+	assert.ErrorIs(t, err, status.Error(codes.PermissionDenied, "Permission denied"))
+
+	a.
+		On("Messages", mock.Anything, int64(3), messages.Page{Limit: 1}).
+		Return([]*messages.Message{{ID: 2, ThreadID: 3}}, "MQ", nil).
+		Once()
+	list, err := s.ListMessages(context.Background(), &ListMessagesRequest{ThreadId: 3, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "MQ", list.NextCursor)
+	if assert.Len(t, list.List, 1) {
+		assert.Equal(t, int64(2), list.List[0].Id)
+	}
+}
+
+func TestGRPCService_ListThreads(t *testing.T) {
+	a := mocks.NewApp(t)
+	s := NewService(a)
+
+	a.
+		On("Threads", mock.Anything, int64(2)).
+		Return(nil, app.ErrForbidden).
+		Once()
+	_, err := s.ListThreads(context.Background(), &ListThreadsRequest{UserId: 2})
+	assert.ErrorIs(t, err, status.Error(codes.PermissionDenied, "Permission denied"))
+
+	a.
+		On("Threads", mock.Anything, int64(1)).
+		Return([]*messages.Thread{
+			{ID: 4, AdID: 7, BuyerID: 1, SellerID: 2, BuyerUnread: 2, SellerUnread: 5},
+			{ID: 3, AdID: 5, BuyerID: 3, SellerID: 1, BuyerUnread: 1, SellerUnread: 1},
+		}, nil).
+		Once()
+	resp, err := s.ListThreads(context.Background(), &ListThreadsRequest{UserId: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), resp.UnreadTotal)
+	if assert.Len(t, resp.List, 2) {
+		assert.Equal(t, int64(4), resp.List[0].Id)
+		assert.Equal(t, int64(2), resp.List[0].Unread)
+		assert.Equal(t, int64(1), resp.List[1].Unread)
+	}
+}
+
+func TestGRPCService_Login(t *testing.T) {
+	a := mocks.NewApp(t)
+	s := NewService(a)
+
+	tests := []struct {
+		name    string
+		req     *LoginRequest
+		setMock func()
+		want    *TokenResponse
+		wantErr bool
+		err     error
+	}{
+		{
+			name: "unauthenticated error",
+			req:  &LoginRequest{UserId: 0, Password: "wrong"},
+			setMock: func() {
+				a.
+					On("Login", mock.Anything, int64(0), "wrong").
+					Return(auth.Tokens{}, app.ErrUnauthorized).
+					Once()
+			},
+			wantErr: true,
+			err:     status.Error(codes.Unauthenticated, "Unauthenticated"),
+		},
+		{
+			name: "ok",
+			req:  &LoginRequest{UserId: 0, Password: "password"},
+			setMock: func() {
+				a.
+					On("Login", mock.Anything, int64(0), "password").
+					Return(auth.Tokens{Access: "access", Refresh: "refresh", ExpiresIn: time.Minute}, nil).
+					Once()
+			},
+			want: &TokenResponse{
+				AccessToken:  "access",
+				RefreshToken: "refresh",
+				ExpiresIn:    60,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt.setMock()
+		resp, err := s.Login(context.Background(), tt.req)
+		if tt.wantErr {
+			assert.ErrorIs(t, err, tt.err)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.AccessToken, resp.AccessToken)
+			assert.Equal(t, tt.want.RefreshToken, resp.RefreshToken)
+			assert.Equal(t, tt.want.ExpiresIn, resp.ExpiresIn)
+		}
+	}
+}
+
+func TestGRPCUnaryAuthInterceptor(t *testing.T) {
+	a := mocks.NewApp(t)
+	interceptor := UnaryAuthInterceptor(a)
+
+	tests := []struct {
+		name          string
+		md            metadata.MD
+		setMock       func()
+		wantErr       bool
+		err           error
+		userID        int64
+		authenticated bool
+	}{
+		{
+			name:    "anonymous",
+			setMock: func() {},
+		},
+		{
+			name:    "not a bearer token",
+			md:      metadata.Pairs("authorization", "Basic dXNlcjpwYXNz"),
+			setMock: func() {},
+			wantErr: true,
+			err:     status.Error(codes.Unauthenticated, "Unauthenticated"),
+		},
+		{
+			name: "bad token",
+			md:   metadata.Pairs("authorization", "Bearer bad"),
+			setMock: func() {
+				a.
+					On("Authenticate", mock.Anything, "bad").
+					Return(int64(0), app.ErrUnauthorized).
+					Once()
+			},
+			wantErr: true,
+			err:     status.Error(codes.Unauthenticated, "Unauthenticated"),
+		},
+		{
+			name: "ok",
+			md:   metadata.Pairs("authorization", "Bearer good"),
+			setMock: func() {
+				a.
+					On("Authenticate", mock.Anything, "good").
+					Return(int64(7), nil).
+					Once()
+			},
+			userID:        7,
+			authenticated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt.setMock()
+		ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+
+		var called bool
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+			called = true
+			userID, ok := auth.UserID(ctx)
+			assert.Equal(t, tt.authenticated, ok)
+			assert.Equal(t, tt.userID, userID)
+			return nil, nil
+		})
+		if tt.wantErr {
+			assert.ErrorIs(t, err, tt.err)
+			assert.False(t, called)
+		} else {
+			assert.NoError(t, err)
+			assert.True(t, called)
+		}
+	}
+}
+
+func TestGRPCStreamAuthInterceptor(t *testing.T) {
+	a := mocks.NewApp(t)
+	interceptor := StreamAuthInterceptor(a)
+
+	a.On("Authenticate", mock.Anything, "bad").Return(int64(0), app.ErrUnauthorized).Once()
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer bad"))
+	err := interceptor(nil, &watchStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(any, grpc.ServerStream) error {
+		t.Error("handler must not be called")
+		return nil
+	})
+	assert.ErrorIs(t, err, status.Error(codes.Unauthenticated, "Unauthenticated"))
+
+	a.On("Authenticate", mock.Anything, "good").Return(int64(7), nil).Once()
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer good"))
+	err = interceptor(nil, &watchStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(_ any, ss grpc.ServerStream) error {
+		userID, ok := auth.UserID(ss.Context())
+		assert.True(t, ok)
+		assert.Equal(t, int64(7), userID)
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestGRPCLogInterceptor(t *testing.T) {
+	var out bytes.Buffer
+	l := logging.New(&out, slog.LevelInfo)
+	info := &grpc.UnaryServerInfo{FullMethod: "/ad.AdService/GetAd"}
+	var seen string
+	handler := func(ctx context.Context, req any) (any, error) {
+		seen, _ = logging.RequestID(ctx)
+		return nil, status.Error(codes.NotFound, "Not found")
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(MetadataRequestID, "client-id-1"))
+	_, err := UnaryLogInterceptor(l)(ctx, nil, info, handler)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.Equal(t, "client-id-1", seen)
+
+	var record map[string]any
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	assert.Equal(t, "WARN", record["level"])
+	assert.Equal(t, "/ad.AdService/GetAd", record["method"])
+	assert.Equal(t, "NotFound", record["code"])
+	assert.Equal(t, "client-id-1", record["request_id"])
+
+	// без ID в метаданных он генерируется и возвращается клиенту в заголовке
+	out.Reset()
+	ss := &watchStream{ctx: context.Background()}
+	err = StreamLogInterceptor(l)(nil, ss, &grpc.StreamServerInfo{FullMethod: "/ad.AdService/WatchAds"},
+		func(_ any, stream grpc.ServerStream) error {
+			seen, _ = logging.RequestID(stream.Context())
+			return nil
+		})
+	assert.NoError(t, err)
+	assert.Len(t, seen, 32)
+	assert.Equal(t, []string{seen}, ss.header.Get(MetadataRequestID))
+	assert.Contains(t, out.String(), `"level":"INFO","msg":"call","method":"/ad.AdService/WatchAds","code":"OK"`)
+	assert.Contains(t, out.String(), `"request_id":"`+seen+`"`)
+}
+
+func TestGRPCMetricsInterceptor(t *testing.T) {
+	m := metrics.New()
+	_, err := UnaryMetricsInterceptor(m)(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/ad.AdService/GetAd"},
+		func(ctx context.Context, req any) (any, error) {
+			return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+		})
+	assert.Error(t, err)
+	err = StreamMetricsInterceptor(m)(nil, &watchStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/ad.AdService/WatchAds"},
+		func(any, grpc.ServerStream) error {
+			return nil
+		})
+	assert.NoError(t, err)
+
+	r := httptest.NewRecorder()
+	m.Handler().ServeHTTP(r, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, r.Body.String(), `adapp_grpc_requests_total{code="InvalidArgument",method="/ad.AdService/GetAd"} 1`)
+	assert.Contains(t, r.Body.String(), `adapp_grpc_requests_total{code="OK",method="/ad.AdService/WatchAds"} 1`)
+}
+
+func TestGRPCTraceInterceptor(t *testing.T) {
+	tp, sr := tracing.NewRecorder()
+	info := &grpc.UnaryServerInfo{FullMethod: "/ad.AdService/GetAd"}
+	var seen trace.SpanContext
+	handler := func(ctx context.Context, req any) (any, error) {
+		seen = trace.SpanContextFromContext(ctx)
+		return nil, status.Error(codes.NotFound, "Not found")
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"))
+	_, err := UnaryTraceInterceptor(tp)(ctx, nil, info, handler)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	spans := sr.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "/ad.AdService/GetAd", spans[0].Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+	assert.Equal(t, spans[0].SpanContext(), seen)
+	assert.Contains(t, spans[0].Attributes(), semconv.RPCService("ad.AdService"))
+	assert.Contains(t, spans[0].Attributes(), semconv.RPCMethod("GetAd"))
+	assert.Contains(t, spans[0].Attributes(), semconv.RPCGRPCStatusCodeNotFound)
+	// NotFound - ошибка клиента, спан не ошибочный
+	assert.Equal(t, otelcodes.Unset, spans[0].Status().Code)
+
+	err = StreamTraceInterceptor(tp)(nil, &watchStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/ad.AdService/WatchAds"},
+		func(_ any, stream grpc.ServerStream) error {
+			seen = trace.SpanContextFromContext(stream.Context())
+			return status.Error(codes.Internal, "Internal server error")
+		})
+	assert.Error(t, err)
+	spans = sr.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, spans[1].SpanContext(), seen)
+	assert.False(t, spans[1].Parent().IsValid())
+	assert.Equal(t, otelcodes.Error, spans[1].Status().Code)
+}
+
+func TestGRPCRateLimitInterceptor(t *testing.T) {
+	l := ratelimit.New(ratelimit.Rules{
+		Routes: map[string]ratelimit.Limit{"/ad.AdService/CreateAd": {Count: 1, Per: time.Minute}},
+	}, 0)
+	unary := UnaryRateLimitInterceptor(l)
+	stream := StreamRateLimitInterceptor(l)
+	create := &grpc.UnaryServerInfo{FullMethod: "/ad.AdService/CreateAd"}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return "ok", nil
+	}
+	from := func(ctx context.Context, ip string) context.Context {
+		return peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 5000}})
+	}
+
+	resp, err := unary(from(context.Background(), "10.0.0.1"), nil, create, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+	_, err = unary(from(context.Background(), "10.0.0.1"), nil, create, handler)
+	assert.ErrorIs(t, err, status.Error(codes.ResourceExhausted, "Rate limit exceeded"))
+
+	// у другого адреса и у пользователя с того же адреса свои лимиты, у методов без лимита ограничений нет
+	_, err = unary(from(context.Background(), "10.0.0.2"), nil, create, handler)
+	assert.NoError(t, err)
+	_, err = unary(from(auth.WithUserID(context.Background(), 7), "10.0.0.1"), nil, create, handler)
+	assert.NoError(t, err)
+	_, err = unary(from(context.Background(), "10.0.0.1"), nil, &grpc.UnaryServerInfo{FullMethod: "/ad.AdService/ListAds"}, handler)
+	assert.NoError(t, err)
+
+	err = stream(nil, &watchStream{ctx: from(context.Background(), "10.0.0.1")}, &grpc.StreamServerInfo{FullMethod: "/ad.AdService/CreateAd"},
+		func(any, grpc.ServerStream) error {
+			t.Error("handler must not be called")
+			return nil
+		})
+	assert.ErrorIs(t, err, status.Error(codes.ResourceExhausted, "Rate limit exceeded"))
+}
+
+func TestGRPCRateLimitInterceptor_Health(t *testing.T) {
+	l := ratelimit.New(ratelimit.Rules{Default: ratelimit.Limit{Count: 1, Per: time.Minute}}, 0)
+	check := &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return "ok", nil
+	}
+
+	// проверки оркестратора не упираются в лимит
+	for i := 0; i < 3; i++ {
+		_, err := UnaryRateLimitInterceptor(l)(context.Background(), nil, check, handler)
+		assert.NoError(t, err)
+	}
+}
+
+func TestWatchHealth(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := grpchealth.NewServer()
+	p := health.New()
+	done := make(chan error)
+	go func() {
+		done <- WatchHealth(ctx, s, p, time.Millisecond)
+	}()
+
+	check := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := s.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			return healthpb.HealthCheckResponse_UNKNOWN
+		}
+		return resp.Status
+	}
+	assert.Eventually(t, func() bool {
+		return check("") == healthpb.HealthCheckResponse_SERVING && check("ad.AdService") == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, time.Millisecond)
+
+	p.Shutdown()
+	assert.Eventually(t, func() bool {
+		return check("") == healthpb.HealthCheckResponse_NOT_SERVING && check("ad.AdService") == healthpb.HealthCheckResponse_NOT_SERVING
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestGRPCIdempotencyInterceptor(t *testing.T) {
+	interceptor := UnaryIdempotencyInterceptor(time.Hour)
+	create := &grpc.UnaryServerInfo{FullMethod: "/ad.AdService/CreateAd"}
+	var created int64
+	handler := func(ctx context.Context, req any) (any, error) {
+		if req.(*CreateAdRequest).Title == "fail" {
+			return nil, status.Error(codes.Internal, "Internal server error")
+		}
+		if req.(*CreateAdRequest).Title == "" {
+			return nil, status.Error(codes.InvalidArgument, "Invalid argument")
+		}
+		created++
+		return &AdResponse{Id: created, Title: req.(*CreateAdRequest).Title}, nil
+	}
+	callFrom := func(ip, key string, req *CreateAdRequest) (any, error) {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 5000}})
+		if key != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("idempotency-key", key))
+		}
+		return interceptor(ctx, req, create, handler)
+	}
+	call := func(key string, req *CreateAdRequest) (any, error) {
+		return callFrom("10.0.0.1", key, req)
+	}
+
+	first, err := call("key", &CreateAdRequest{Title: "title", Text: "text"})
+	assert.NoError(t, err)
+	replayed, err := call("key", &CreateAdRequest{Title: "title", Text: "text"})
+	assert.NoError(t, err)
+	assert.Equal(t, first, replayed)
+	assert.Equal(t, int64(1), created)
+
+	_, err = call("key", &CreateAdRequest{Title: "title", Text: "other"})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	// анонимный клиент с другого адреса с тем же ключом не получает чужой ответ
+	neighbour, err := callFrom("10.0.0.2", "key", &CreateAdRequest{Title: "title", Text: "text"})
+	assert.NoError(t, err)
+	assert.NotEqual(t, first, neighbour)
+	assert.Equal(t, int64(2), created)
+
+	// ошибка в запросе сохраняется, временная ошибка - нет
+	_, err = call("empty", &CreateAdRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = call("empty", &CreateAdRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = call("failed", &CreateAdRequest{Title: "fail"})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	_, err = call("failed", &CreateAdRequest{Title: "fail"})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	// без ключа и в других методах вызов выполняется каждый раз
+	_, err = call("", &CreateAdRequest{Title: "title"})
+	assert.NoError(t, err)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("idempotency-key", "key"))
+	_, err = interceptor(ctx, &CreateAdRequest{Title: "title", Text: "text"}, &grpc.UnaryServerInfo{FullMethod: "/ad.AdService/UpdateAd"}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), created)
+}
+
+func TestAdResponse_Images(t *testing.T) {
+	resp := adResponse(&ads.Ad{
+		ID:     1,
+		Images: []ads.Image{{ID: "photo", Thumb: "thumb", ContentType: "image/jpeg", Width: 640, Height: 480}},
+	})
+
+	if assert.Len(t, resp.Images, 1) {
+		assert.Equal(t, "photo", resp.Images[0].Id)
+		assert.Equal(t, "/api/v1/images/photo", resp.Images[0].Url)
+		assert.Equal(t, "/api/v1/images/thumb", resp.Images[0].ThumbnailUrl)
+		assert.Equal(t, "image/jpeg", resp.Images[0].ContentType)
+		assert.Equal(t, int32(640), resp.Images[0].Width)
+		assert.Equal(t, int32(480), resp.Images[0].Height)
+	}
+}
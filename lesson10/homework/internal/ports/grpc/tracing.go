@@ -0,0 +1,84 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"homework10/internal/tracing"
+)
+
+// UnaryTraceInterceptor начинает для каждого вызова серверный спан, продолжая трассировку клиента
+// из метаданных traceparent, и кладёт его в контекст вызова
+func UnaryTraceInterceptor(tp trace.TracerProvider) grpc.UnaryServerInterceptor {
+	t := tp.Tracer(tracing.Instrumentation)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, span := startSpan(ctx, t, info.FullMethod)
+		resp, err := handler(ctx, req)
+		endSpan(span, err)
+		return resp, err
+	}
+}
+
+// StreamTraceInterceptor - то же, что UnaryTraceInterceptor, для потоковых вызовов: спан длится до закрытия потока
+func StreamTraceInterceptor(tp trace.TracerProvider) grpc.StreamServerInterceptor {
+	t := tp.Tracer(tracing.Instrumentation)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, span := startSpan(ss.Context(), t, info.FullMethod)
+		wrapped := middleware.WrapServerStream(ss)
+		wrapped.WrappedContext = ctx
+		err := handler(srv, wrapped)
+		endSpan(span, err)
+		return err
+	}
+}
+
+// metadataCarrier позволяет читать контекст трассировки из метаданных вызова
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func startSpan(ctx context.Context, t trace.Tracer, fullMethod string) (context.Context, trace.Span) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = tracing.Propagator.Extract(ctx, metadataCarrier(md))
+	service, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	return t.Start(ctx, fullMethod, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+		semconv.RPCSystemGRPC,
+		semconv.RPCService(service),
+		semconv.RPCMethod(method),
+	))
+}
+
+func endSpan(span trace.Span, err error) {
+	s := status.Convert(err)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(s.Code())))
+	// как и в логах, ошибочными считаются только вызовы, завершившиеся по вине сервера
+	if serverError(s.Code()) {
+		span.SetStatus(otelcodes.Error, s.Message())
+	}
+	span.End()
+}
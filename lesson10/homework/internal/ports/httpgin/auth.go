@@ -0,0 +1,52 @@
+package httpgin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"homework10/internal/app"
+	"homework10/internal/auth"
+)
+
+var errBadAuthorization = fmt.Errorf(`authorization header must look like "Bearer <token>"`)
+
+// authenticate по заголовку "Authorization: Bearer <access-токен>" определяет пользователя
+// и кладёт его ID в контекст запроса. Запросы без заголовка выполняются анонимно,
+// а методы, которым нужен пользователь, сами ответят 401.
+func authenticate(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			unauthorized(c, errBadAuthorization)
+			return
+		}
+
+		userID, err := a.Authenticate(c, token)
+		if err != nil {
+			if errors.Is(err, app.ErrUnauthorized) {
+				unauthorized(c, err)
+			} else {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		c.Request = c.Request.WithContext(auth.WithUserID(c.Request.Context(), userID))
+		c.Next()
+	}
+}
+
+func unauthorized(c *gin.Context, err error) {
+	c.Header("WWW-Authenticate", "Bearer")
+	c.AbortWithStatusJSON(http.StatusUnauthorized, ErrorResponse(err))
+}
@@ -0,0 +1,123 @@
+package httpgin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"homework10/internal/app"
+)
+
+// Метод для получения дерева категорий
+func listCategories(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tree, err := a.Categories(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, CategoriesSuccessResponse(tree))
+	}
+}
+
+// Метод для создания категории (доступен только администраторам)
+func createCategory(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody categoryRequest
+		if err := c.Bind(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		cat, err := a.CreateCategory(c, reqBody.Name, reqBody.ParentID)
+		if err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		setETag(c, cat.Version)
+		c.JSON(http.StatusOK, CategorySuccessResponse(cat))
+	}
+}
+
+// Метод для переименования категории или её переноса в другую (доступен только администраторам)
+func updateCategory(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody categoryRequest
+		if err := c.Bind(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		v := c.Param("category_id")
+		categoryID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		version, err := ifMatchVersion(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		cat, err := a.UpdateCategory(c, int64(categoryID), version, reqBody.Name, reqBody.ParentID)
+		if err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrConflict) {
+				c.JSON(conflictStatus(c), ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		setETag(c, cat.Version)
+		c.JSON(http.StatusOK, CategorySuccessResponse(cat))
+	}
+}
+
+// Метод для удаления пустой категории (доступен только администраторам)
+func deleteCategory(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v := c.Param("category_id")
+		categoryID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		cat, err := a.DeleteCategory(c, int64(categoryID))
+		if err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, CategorySuccessResponse(cat))
+	}
+}
@@ -0,0 +1,46 @@
+package httpgin
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Версия объявления или пользователя отдаётся клиенту в заголовке ETag,
+// а при изменении клиент может передать ожидаемую версию в заголовке If-Match
+
+var errBadIfMatch = fmt.Errorf("bad If-Match header")
+
+func setETag(c *gin.Context, version int64) {
+	c.Header("ETag", strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
+// ifMatchVersion возвращает версию из If-Match или 0, если заголовка нет (или он равен "*")
+func ifMatchVersion(c *gin.Context) (int64, error) {
+	v := strings.TrimSpace(c.GetHeader("If-Match"))
+	if v == "" || v == "*" {
+		return 0, nil
+	}
+
+	unq, err := strconv.Unquote(v)
+	if err != nil {
+		return 0, errBadIfMatch
+	}
+	version, err := strconv.ParseInt(unq, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, errBadIfMatch
+	}
+
+	return version, nil
+}
+
+// conflictStatus - при явно заданном If-Match конфликт версий означает невыполненное предусловие
+func conflictStatus(c *gin.Context) int {
+	if c.GetHeader("If-Match") != "" {
+		return http.StatusPreconditionFailed
+	}
+	return http.StatusConflict
+}
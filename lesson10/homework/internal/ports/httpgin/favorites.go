@@ -0,0 +1,86 @@
+package httpgin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"homework10/internal/ads"
+	"homework10/internal/app"
+)
+
+// Метод для получения избранного пользователя (доступен только ему самому)
+func listFavorites(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v := c.Param("user_id")
+		userID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		adverts, err := a.Favorites(c, int64(userID))
+		if err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, FavoritesSuccessResponse(adverts))
+	}
+}
+
+// Метод для добавления объявления в избранное; повторное добавление ничего не меняет
+func addFavorite(a app.App) gin.HandlerFunc {
+	return changeFavorite(a.AddFavorite)
+}
+
+// Метод для удаления объявления из избранного
+func deleteFavorite(a app.App) gin.HandlerFunc {
+	return changeFavorite(a.DeleteFavorite)
+}
+
+// changeFavorite - общая часть добавления и удаления: change - соответствующий метод приложения
+func changeFavorite(change func(ctx context.Context, userID, adID int64) (*ads.Ad, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v := c.Param("user_id")
+		userID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		v = c.Param("ad_id")
+		adID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		ad, err := change(c, int64(userID), int64(adID))
+		if err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, AdSuccessResponse(ad))
+	}
+}
@@ -2,14 +2,15 @@ package httpgin
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
-	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"homework10/internal/ads"
 	"homework10/internal/app"
+	"homework10/internal/users"
 )
 
 // Метод для создания объявления (ad)
@@ -22,10 +23,19 @@ func createAd(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		ad, err := a.CreateAd(c, reqBody.Title, reqBody.Text, reqBody.UserID)
+		ttl, err := app.TTLFromSeconds(reqBody.TTL)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		price := ads.Price{Amount: reqBody.Price, Currency: reqBody.Currency}
+		ad, err := a.CreateAd(c, reqBody.Title, reqBody.Text, reqBody.CategoryID, price, reqBody.Location.location(), ttl)
 		if err != nil {
 			if errors.Is(err, app.ErrForbidden) {
 				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
 			} else if errors.Is(err, app.ErrBadRequest) {
 				c.JSON(http.StatusBadRequest, ErrorResponse(err))
 			} else {
@@ -34,6 +44,7 @@ func createAd(a app.App) gin.HandlerFunc {
 			return
 		}
 
+		setETag(c, ad.Version)
 		c.JSON(http.StatusOK, AdSuccessResponse(ad))
 	}
 }
@@ -54,18 +65,125 @@ func changeAdStatus(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		ad, err := a.ChangeAdStatus(c, int64(adID), reqBody.UserID, reqBody.Published)
+		version, err := ifMatchVersion(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		ad, err := a.ChangeAdStatus(c, int64(adID), version, reqBody.Published)
 		if err != nil {
 			if errors.Is(err, app.ErrForbidden) {
 				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
 			} else if errors.Is(err, app.ErrBadRequest) {
 				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrConflict) {
+				c.JSON(conflictStatus(c), ErrorResponse(err))
+			} else if errors.Is(err, app.ErrTransition) {
+				c.JSON(http.StatusConflict, ErrorResponse(err))
 			} else {
 				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
 			}
 			return
 		}
 
+		setETag(c, ad.Version)
+		c.JSON(http.StatusOK, AdSuccessResponse(ad))
+	}
+}
+
+// Метод для перевода объявления по жизненному циклу: submit, withdraw, approve, reject, publish, unpublish, archive, restore
+func transitionAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody transitionAdRequest
+		if err := c.Bind(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		v := c.Param("ad_id")
+		adID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		version, err := ifMatchVersion(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		ad, err := a.TransitionAd(c, int64(adID), version, ads.Event(reqBody.Event), reqBody.Reason)
+		if err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrConflict) {
+				c.JSON(conflictStatus(c), ErrorResponse(err))
+			} else if errors.Is(err, app.ErrTransition) {
+				c.JSON(http.StatusConflict, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		setETag(c, ad.Version)
+		c.JSON(http.StatusOK, AdSuccessResponse(ad))
+	}
+}
+
+// Метод для продления срока жизни объявления
+func renewAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody renewAdRequest
+		if err := c.Bind(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		v := c.Param("ad_id")
+		adID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		version, err := ifMatchVersion(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		ttl, err := app.TTLFromSeconds(reqBody.TTL)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		ad, err := a.RenewAd(c, int64(adID), version, ttl)
+		if err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrConflict) {
+				c.JSON(conflictStatus(c), ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		setETag(c, ad.Version)
 		c.JSON(http.StatusOK, AdSuccessResponse(ad))
 	}
 }
@@ -86,18 +204,29 @@ func updateAd(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		ad, err := a.UpdateAd(c, int64(adID), reqBody.UserID, reqBody.Title, reqBody.Text)
+		version, err := ifMatchVersion(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		ad, err := a.UpdateAd(c, int64(adID), version, reqBody.Title, reqBody.Text, reqBody.price(), reqBody.Location.location(), reqBody.ClearLocation)
 		if err != nil {
 			if errors.Is(err, app.ErrForbidden) {
 				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
 			} else if errors.Is(err, app.ErrBadRequest) {
 				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrConflict) {
+				c.JSON(conflictStatus(c), ErrorResponse(err))
 			} else {
 				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
 			}
 			return
 		}
 
+		setETag(c, ad.Version)
 		c.JSON(http.StatusOK, AdSuccessResponse(ad))
 	}
 }
@@ -124,11 +253,12 @@ func showAd(a app.App) gin.HandlerFunc {
 			return
 		}
 
+		setETag(c, ad.Version)
 		c.JSON(http.StatusOK, AdSuccessResponse(ad))
 	}
 }
 
-// Метод для получения всех опубликованных объявлений
+// Метод для получения страницы объявлений, подходящих под фильтры, вместе с количествами таких объявлений по категориям
 func listAds(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqParams listAdsRequest
@@ -144,7 +274,13 @@ func listAds(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		adverts, err := a.AdsByPattern(c, p)
+		page, err := createAdsPage(reqParams)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		adverts, next, err := a.AdsByPattern(c, p, page)
 
 		if err != nil {
 			if errors.Is(err, app.ErrForbidden) {
@@ -157,19 +293,23 @@ func listAds(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(http.StatusOK, AdsSuccessResponse(adverts))
+		counts, err := a.CategoryCounts(c, p)
+		if err != nil {
+			if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, AdsSuccessResponse(adverts, next, counts))
 	}
 }
 
 // Метод для удаления объявления по его ID
 func deleteAd(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var reqBody deleteAdRequest
-		if err := c.Bind(&reqBody); err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse(err))
-			return
-		}
-
 		v := c.Param("ad_id")
 		adID, err := strconv.Atoi(v)
 		if err != nil {
@@ -177,10 +317,12 @@ func deleteAd(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		ad, err := a.DeleteAd(c, int64(adID), reqBody.UserID)
+		ad, err := a.DeleteAd(c, int64(adID))
 		if err != nil {
 			if errors.Is(err, app.ErrForbidden) {
 				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
 			} else if errors.Is(err, app.ErrBadRequest) {
 				c.JSON(http.StatusBadRequest, ErrorResponse(err))
 			} else {
@@ -203,7 +345,7 @@ func createUser(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		u, err := a.CreateUser(c, reqBody.Nickname, reqBody.Email)
+		u, err := a.CreateUser(c, reqBody.Nickname, reqBody.Email, reqBody.Password)
 		if err != nil {
 			if errors.Is(err, app.ErrForbidden) {
 				c.JSON(http.StatusForbidden, ErrorResponse(err))
@@ -215,6 +357,7 @@ func createUser(a app.App) gin.HandlerFunc {
 			return
 		}
 
+		setETag(c, u.Version)
 		c.JSON(http.StatusOK, UserSuccessResponse(u))
 	}
 }
@@ -235,18 +378,72 @@ func updateUser(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		u, err := a.UpdateUser(c, int64(userID), reqBody.Nickname, reqBody.Email)
+		version, err := ifMatchVersion(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		u, err := a.UpdateUser(c, int64(userID), version, reqBody.Nickname, reqBody.Email)
+		if err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrConflict) {
+				c.JSON(conflictStatus(c), ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		setETag(c, u.Version)
+		c.JSON(http.StatusOK, UserSuccessResponse(u))
+	}
+}
+
+// Метод для назначения пользователю роли (доступен только администраторам)
+func changeUserRole(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody changeUserRoleRequest
+		if err := c.Bind(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		v := c.Param("user_id")
+		userID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		version, err := ifMatchVersion(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		u, err := a.ChangeUserRole(c, int64(userID), version, users.Role(reqBody.Role))
 		if err != nil {
 			if errors.Is(err, app.ErrForbidden) {
 				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
 			} else if errors.Is(err, app.ErrBadRequest) {
 				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrConflict) {
+				c.JSON(conflictStatus(c), ErrorResponse(err))
 			} else {
 				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
 			}
 			return
 		}
 
+		setETag(c, u.Version)
 		c.JSON(http.StatusOK, UserSuccessResponse(u))
 	}
 }
@@ -272,6 +469,7 @@ func showUser(a app.App) gin.HandlerFunc {
 			return
 		}
 
+		setETag(c, u.Version)
 		c.JSON(http.StatusOK, UserSuccessResponse(u))
 	}
 }
@@ -290,6 +488,8 @@ func deleteUser(a app.App) gin.HandlerFunc {
 		if err != nil {
 			if errors.Is(err, app.ErrForbidden) {
 				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
 			} else if errors.Is(err, app.ErrBadRequest) {
 				c.JSON(http.StatusBadRequest, ErrorResponse(err))
 			} else {
@@ -304,41 +504,126 @@ func deleteUser(a app.App) gin.HandlerFunc {
 
 // Метод для генерации шаблона для выборки объявлений
 func createAdPattern(c *gin.Context, params listAdsRequest) (*ads.Pattern, error) {
-	f := ads.DefaultPattern()
+	q, err := createAdQuery(c, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return q.Pattern()
+}
+
+// Метод для сбора условий выборки объявлений из параметров запроса; учитываются только переданные параметры
+func createAdQuery(c *gin.Context, params listAdsRequest) (ads.Query, error) {
+	q := ads.Query{
+		Query:    params.Query,
+		Filter:   params.Filter,
+		Currency: params.Currency,
+	}
 
 	if _, ok := c.GetQuery("title"); ok {
-		f.TitleFits = func(title string) bool {
-			return params.Title == title
-		}
+		q.Title = &params.Title
 	}
 
 	if _, ok := c.GetQuery("created"); ok {
-		f.CreatedFits = func(created time.Time) bool {
-			pY, pM, pD := params.Created.UTC().Date()
-			y, m, d := created.UTC().Date()
-			return y == pY && m == pM && d == pD
-		}
+		q.Created = &params.Created
+	}
+
+	if _, ok := c.GetQuery("user_id"); ok {
+		q.UserID = &params.UserID
 	}
 
-	if v, ok := c.GetQuery("user_id"); ok {
-		id, err := strconv.Atoi(v)
+	if _, ok := c.GetQuery("published"); ok {
+		q.Published = &params.Published
+	}
+
+	if _, ok := c.GetQuery("category"); ok {
+		q.Category = params.Category
+	}
+
+	if _, ok := c.GetQuery("status"); ok {
+		statuses, err := ads.ParseStatuses(params.Status)
 		if err != nil {
-			return nil, err
+			return ads.Query{}, err
 		}
-		f.UserIDFits = func(userID int64) bool {
-			return int64(id) == userID
+		q.Statuses = statuses
+	}
+
+	if _, ok := c.GetQuery("price_min"); ok {
+		q.PriceMin = &params.PriceMin
+	}
+	if _, ok := c.GetQuery("price_max"); ok {
+		q.PriceMax = &params.PriceMax
+	}
+
+	_, hasLat := c.GetQuery("lat")
+	_, hasLon := c.GetQuery("lon")
+	_, hasRadius := c.GetQuery("radius")
+	if hasLat || hasLon || hasRadius {
+		if !hasLat || !hasLon || !hasRadius {
+			return ads.Query{}, fmt.Errorf("%w: lat, lon and radius must be set together", ads.ErrBadFilter)
 		}
+		q.Near = &ads.Circle{Center: ads.Point{Lat: params.Lat, Lon: params.Lon}, Radius: params.Radius}
 	}
 
-	if v, ok := c.GetQuery("published"); ok {
-		p, err := strconv.ParseBool(v)
-		if err != nil {
-			return nil, err
+	return q, nil
+}
+
+// Метод для генерации параметров страницы выборки объявлений
+func createAdsPage(params listAdsRequest) (ads.Page, error) {
+	sort, err := ads.ParseSort(params.Sort)
+	if err != nil {
+		return ads.Page{}, err
+	}
+
+	return ads.Page{
+		Limit:  params.Limit,
+		Cursor: params.Cursor,
+		Sort:   sort,
+	}, nil
+}
+
+// Метод для входа пользователя по паролю, возвращает пару access/refresh токенов
+func login(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody loginRequest
+		if err := c.Bind(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
 		}
-		f.PublishedFits = func(published bool) bool {
-			return published == p
+
+		t, err := a.Login(c, reqBody.UserID, reqBody.Password)
+		if err != nil {
+			if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
 		}
+
+		c.JSON(http.StatusOK, TokensSuccessResponse(t))
 	}
+}
+
+// Метод для обмена refresh-токена на новую пару токенов
+func refresh(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody refreshRequest
+		if err := c.Bind(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		t, err := a.Refresh(c, reqBody.RefreshToken)
+		if err != nil {
+			if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
 
-	return f, nil
+		c.JSON(http.StatusOK, TokensSuccessResponse(t))
+	}
 }
@@ -4,19 +4,41 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
 
 	"homework10/internal/ads"
 	"homework10/internal/app"
 	"homework10/internal/app/mocks"
+	"homework10/internal/audit"
+	"homework10/internal/auth"
+	"homework10/internal/categories"
+	"homework10/internal/events"
+	"homework10/internal/feed"
+	"homework10/internal/idempotency"
+	"homework10/internal/images"
+	"homework10/internal/logging"
+	"homework10/internal/messages"
+	"homework10/internal/metrics"
+	"homework10/internal/ratelimit"
+	"homework10/internal/searches"
+	"homework10/internal/tracing"
 	"homework10/internal/users"
+	"homework10/internal/webhooks"
 )
 
 type HTTPGINTestSuite struct {
@@ -60,13 +82,12 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_CreateAd() {
 		{
 			name: "forbidden error",
 			reqBody: map[string]any{
-				"user_id": 0,
-				"title":   "title",
-				"text":    "text",
+				"title": "title",
+				"text":  "text",
 			},
 			setMock: func() {
 				s.a.
-					On("CreateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					On("CreateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return(nil, app.ErrForbidden).
 					Once()
 			},
@@ -78,16 +99,35 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_CreateAd() {
 				},
 			},
 		},
+		{
+			name: "unauthorized error",
+			reqBody: map[string]any{
+				"title": "title",
+				"text":  "text",
+			},
+			setMock: func() {
+				s.a.
+					On("CreateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, app.ErrUnauthorized).
+					Once()
+			},
+			want: want{
+				code: http.StatusUnauthorized,
+				resp: gin.H{
+					"data":  nil,
+					"error": app.ErrUnauthorized.Error(),
+				},
+			},
+		},
 		{
 			name: "bad request error",
 			reqBody: map[string]any{
-				"user_id": 0,
-				"title":   "title",
-				"text":    "text",
+				"title": "title",
+				"text":  "text",
 			},
 			setMock: func() {
 				s.a.
-					On("CreateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					On("CreateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return(nil, app.ErrBadRequest).
 					Once()
 			},
@@ -102,13 +142,12 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_CreateAd() {
 		{
 			name: "internal server error",
 			reqBody: map[string]any{
-				"user_id": 0,
-				"title":   "title",
-				"text":    "text",
+				"title": "title",
+				"text":  "text",
 			},
 			setMock: func() {
 				s.a.
-					On("CreateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					On("CreateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return(nil, fmt.Errorf("untracked internal server error")).
 					Once()
 			},
@@ -123,19 +162,18 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_CreateAd() {
 		{
 			name: "ok",
 			reqBody: map[string]any{
-				"user_id": 0,
-				"title":   "title",
-				"text":    "text",
+				"title": "title",
+				"text":  "text",
 			},
 			setMock: func() {
 				s.a.
-					On("CreateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					On("CreateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return(&ads.Ad{
-						ID:        0,
-						Title:     "title",
-						Text:      "text",
-						UserID:    0,
-						Published: false,
+						ID:     0,
+						Title:  "title",
+						Text:   "text",
+						UserID: 0,
+						Status: ads.StatusDraft,
 					}, nil).
 					Once()
 			},
@@ -148,6 +186,7 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_CreateAd() {
 						Text:      "text",
 						AuthorID:  0,
 						Published: false,
+						Status:    "draft",
 					},
 					"error": nil,
 				},
@@ -183,7 +222,6 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ChangeAdStatus() {
 		{
 			name: "forbidden error",
 			reqBody: map[string]any{
-				"user_id":   0,
 				"published": true,
 			},
 			setMock: func() {
@@ -203,7 +241,6 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ChangeAdStatus() {
 		{
 			name: "bad request error",
 			reqBody: map[string]any{
-				"user_id":   0,
 				"published": true,
 			},
 			setMock: func() {
@@ -223,7 +260,6 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ChangeAdStatus() {
 		{
 			name: "internal server error",
 			reqBody: map[string]any{
-				"user_id":   0,
 				"published": true,
 			},
 			setMock: func() {
@@ -243,18 +279,17 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ChangeAdStatus() {
 		{
 			name: "ok",
 			reqBody: map[string]any{
-				"user_id":   0,
 				"published": true,
 			},
 			setMock: func() {
 				s.a.
 					On("ChangeAdStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return(&ads.Ad{
-						ID:        0,
-						Title:     "title",
-						Text:      "text",
-						UserID:    0,
-						Published: true,
+						ID:     0,
+						Title:  "title",
+						Text:   "text",
+						UserID: 0,
+						Status: ads.StatusPublished,
 					}, nil).
 					Once()
 			},
@@ -267,6 +302,7 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ChangeAdStatus() {
 						Text:      "text",
 						AuthorID:  0,
 						Published: true,
+						Status:    "published",
 					},
 					"error": nil,
 				},
@@ -287,8 +323,9 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ChangeAdStatus() {
 	}
 }
 
-func (s *HTTPGINTestSuite) TestHTTPGINHandlers_UpdateAd() {
-	handler := updateAd(s.a)
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_TransitionAd() {
+	handler := transitionAd(s.a)
+	changed := time.Date(2023, 4, 1, 15, 30, 0, 0, time.UTC)
 
 	type want struct {
 		code int
@@ -301,84 +338,60 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_UpdateAd() {
 		want    want
 	}{
 		{
-			name: "forbidden error",
-			reqBody: map[string]any{
-				"user_id": 0,
-				"title":   "new title",
-				"text":    "new text",
-			},
-			setMock: func() {
-				s.a.
-					On("UpdateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
-					Return(nil, app.ErrForbidden).
-					Once()
-			},
-			want: want{
-				code: http.StatusForbidden,
-				resp: gin.H{
-					"data":  nil,
-					"error": app.ErrForbidden.Error(),
-				},
-			},
-		},
-		{
-			name: "bad request error",
+			name: "transition is not allowed",
 			reqBody: map[string]any{
-				"user_id": 0,
-				"title":   "new title",
-				"text":    "new text",
+				"event": "approve",
 			},
 			setMock: func() {
 				s.a.
-					On("UpdateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
-					Return(nil, app.ErrBadRequest).
+					On("TransitionAd", mock.Anything, int64(0), int64(0), ads.EventApprove, "").
+					Return(nil, app.ErrTransition).
 					Once()
 			},
 			want: want{
-				code: http.StatusBadRequest,
+				code: http.StatusConflict,
 				resp: gin.H{
 					"data":  nil,
-					"error": app.ErrBadRequest.Error(),
+					"error": app.ErrTransition.Error(),
 				},
 			},
 		},
 		{
-			name: "internal server error",
+			name: "forbidden error",
 			reqBody: map[string]any{
-				"user_id": 0,
-				"title":   "new title",
-				"text":    "new text",
+				"event": "approve",
 			},
 			setMock: func() {
 				s.a.
-					On("UpdateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
-					Return(nil, fmt.Errorf("untracked internal server error")).
+					On("TransitionAd", mock.Anything, int64(0), int64(0), ads.EventApprove, "").
+					Return(nil, app.ErrForbidden).
 					Once()
 			},
 			want: want{
-				code: http.StatusInternalServerError,
+				code: http.StatusForbidden,
 				resp: gin.H{
 					"data":  nil,
-					"error": fmt.Errorf("untracked internal server error").Error(),
+					"error": app.ErrForbidden.Error(),
 				},
 			},
 		},
 		{
 			name: "ok",
 			reqBody: map[string]any{
-				"user_id": 0,
-				"title":   "new title",
-				"text":    "new text",
+				"event":  "reject",
+				"reason": "spam",
 			},
 			setMock: func() {
 				s.a.
-					On("UpdateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					On("TransitionAd", mock.Anything, int64(0), int64(0), ads.EventReject, "spam").
 					Return(&ads.Ad{
-						ID:        0,
-						Title:     "title",
-						Text:      "text",
-						UserID:    0,
-						Published: true,
+						ID:     0,
+						Title:  "title",
+						Text:   "text",
+						Status: ads.StatusRejected,
+						Transitions: []ads.Transition{
+							{Event: ads.EventReject, From: ads.StatusPending, To: ads.StatusRejected, At: changed, ActorID: 2, Reason: "spam"},
+						},
 					}, nil).
 					Once()
 			},
@@ -386,11 +399,12 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_UpdateAd() {
 				code: http.StatusOK,
 				resp: gin.H{
 					"data": adResponse{
-						ID:        0,
-						Title:     "title",
-						Text:      "text",
-						AuthorID:  0,
-						Published: true,
+						ID:            0,
+						Title:         "title",
+						Text:          "text",
+						Status:        "rejected",
+						StatusReason:  "spam",
+						StatusChanged: changed,
 					},
 					"error": nil,
 				},
@@ -402,7 +416,7 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_UpdateAd() {
 		s.Run(tt.name, func() {
 			tt.setMock()
 			s.c.AddParam("ad_id", "0")
-			s.setReqBody(http.MethodPut, tt.reqBody)
+			s.setReqBody(http.MethodPost, tt.reqBody)
 			handler(s.c)
 			data, _ := json.Marshal(tt.want.resp)
 			assert.Equal(s.T(), tt.want.code, s.r.Code)
@@ -411,8 +425,9 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_UpdateAd() {
 	}
 }
 
-func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ShowAd() {
-	handler := showAd(s.a)
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_RenewAd() {
+	handler := renewAd(s.a)
+	expires := time.Date(2023, 5, 1, 15, 30, 0, 0, time.UTC)
 
 	type want struct {
 		code int
@@ -420,33 +435,16 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ShowAd() {
 	}
 	tests := []struct {
 		name    string
+		reqBody map[string]any
 		setMock func()
 		want    want
 	}{
-		{
-			name: "forbidden error",
-			setMock: func() {
-				s.a.
-					On("AdByID", mock.Anything, mock.Anything).
-					Return(nil, app.ErrForbidden).
-					Once()
-			},
-			want: want{
-				code: http.StatusForbidden,
-				resp: gin.H{
-					"data":  nil,
-					"error": app.ErrForbidden.Error(),
-				},
-			},
-		},
 		{
 			name: "bad request error",
-			setMock: func() {
-				s.a.
-					On("AdByID", mock.Anything, mock.Anything).
-					Return(nil, app.ErrBadRequest).
-					Once()
+			reqBody: map[string]any{
+				"ttl": -1,
 			},
+			setMock: func() {},
 			want: want{
 				code: http.StatusBadRequest,
 				resp: gin.H{
@@ -456,32 +454,34 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ShowAd() {
 			},
 		},
 		{
-			name: "internal server error",
-			setMock: func() {
-				s.a.
-					On("AdByID", mock.Anything, mock.Anything).
-					Return(nil, fmt.Errorf("untracked internal server error")).
-					Once()
+			// при умножении на time.Second срок переполнил бы int64 и стал меньше секунды
+			name: "huge ttl",
+			reqBody: map[string]any{
+				"ttl": 18446744074,
 			},
+			setMock: func() {},
 			want: want{
-				code: http.StatusInternalServerError,
+				code: http.StatusBadRequest,
 				resp: gin.H{
 					"data":  nil,
-					"error": fmt.Errorf("untracked internal server error").Error(),
+					"error": app.ErrBadRequest.Error(),
 				},
 			},
 		},
 		{
 			name: "ok",
+			reqBody: map[string]any{
+				"ttl": 3600,
+			},
 			setMock: func() {
 				s.a.
-					On("AdByID", mock.Anything, mock.Anything).
+					On("RenewAd", mock.Anything, int64(0), int64(0), time.Hour).
 					Return(&ads.Ad{
-						ID:        0,
-						Title:     "title",
-						Text:      "text",
-						UserID:    0,
-						Published: true,
+						ID:      0,
+						Title:   "title",
+						Text:    "text",
+						Status:  ads.StatusPublished,
+						Expires: expires,
 					}, nil).
 					Once()
 			},
@@ -492,8 +492,9 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ShowAd() {
 						ID:        0,
 						Title:     "title",
 						Text:      "text",
-						AuthorID:  0,
 						Published: true,
+						Status:    "published",
+						Expires:   &expires,
 					},
 					"error": nil,
 				},
@@ -505,7 +506,7 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ShowAd() {
 		s.Run(tt.name, func() {
 			tt.setMock()
 			s.c.AddParam("ad_id", "0")
-			s.setReqBody(http.MethodGet, nil)
+			s.setReqBody(http.MethodPost, tt.reqBody)
 			handler(s.c)
 			data, _ := json.Marshal(tt.want.resp)
 			assert.Equal(s.T(), tt.want.code, s.r.Code)
@@ -514,8 +515,11 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ShowAd() {
 	}
 }
 
-func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ListAds() {
-	handler := listAds(s.a)
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_AdHistory() {
+	handler := adHistory(s.a)
+	at := time.Date(2023, 5, 1, 15, 30, 0, 0, time.UTC)
+	before := &ads.Ad{ID: 0, Title: "title", Text: "text", UserID: 1, Status: ads.StatusDraft, Created: at}
+	after := &ads.Ad{ID: 0, Title: "new title", Text: "text", UserID: 1, Status: ads.StatusDraft, Created: at}
 
 	type want struct {
 		code int
@@ -530,7 +534,7 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ListAds() {
 			name: "forbidden error",
 			setMock: func() {
 				s.a.
-					On("AdsByPattern", mock.Anything, mock.Anything).
+					On("AdHistory", mock.Anything, int64(0)).
 					Return(nil, app.ErrForbidden).
 					Once()
 			},
@@ -543,34 +547,18 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ListAds() {
 			},
 		},
 		{
-			name: "bad request error",
-			setMock: func() {
-				s.a.
-					On("AdsByPattern", mock.Anything, mock.Anything).
-					Return(nil, app.ErrBadRequest).
-					Once()
-			},
-			want: want{
-				code: http.StatusBadRequest,
-				resp: gin.H{
-					"data":  nil,
-					"error": app.ErrBadRequest.Error(),
-				},
-			},
-		},
-		{
-			name: "internal server error",
+			name: "internal error",
 			setMock: func() {
 				s.a.
-					On("AdsByPattern", mock.Anything, mock.Anything).
-					Return(nil, fmt.Errorf("untracked internal server error")).
+					On("AdHistory", mock.Anything, int64(0)).
+					Return(nil, app.ErrInternalAuditRepoError).
 					Once()
 			},
 			want: want{
 				code: http.StatusInternalServerError,
 				resp: gin.H{
 					"data":  nil,
-					"error": fmt.Errorf("untracked internal server error").Error(),
+					"error": app.ErrInternalAuditRepoError.Error(),
 				},
 			},
 		},
@@ -578,56 +566,33 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ListAds() {
 			name: "ok",
 			setMock: func() {
 				s.a.
-					On("AdsByPattern", mock.Anything, mock.Anything).
-					Return([]*ads.Ad{
-						{
-							ID:        0,
-							Title:     "1st title",
-							Text:      "1st text",
-							UserID:    0,
-							Published: true,
-						},
-						{
-							ID:        1,
-							Title:     "2nd title",
-							Text:      "2nd text",
-							UserID:    0,
-							Published: true,
-						},
-						{
-							ID:        2,
-							Title:     "3rd title",
-							Text:      "3rd text",
-							UserID:    0,
-							Published: true,
-						},
+					On("AdHistory", mock.Anything, int64(0)).
+					Return([]*audit.Entry{
+						{ID: 1, Revision: 1, ActorID: 1, Op: audit.OpCreate, At: at, After: audit.AdSnapshot(before)},
+						{ID: 4, Revision: 2, ActorID: 1, Op: audit.OpUpdate, At: at, Before: audit.AdSnapshot(before), After: audit.AdSnapshot(after)},
 					}, nil).
 					Once()
 			},
 			want: want{
 				code: http.StatusOK,
 				resp: gin.H{
-					"data": []adResponse{
-						{
-							ID:        0,
-							Title:     "1st title",
-							Text:      "1st text",
-							AuthorID:  0,
-							Published: true,
-						},
+					"data": []historyEntryResponse{
 						{
-							ID:        1,
-							Title:     "2nd title",
-							Text:      "2nd text",
-							AuthorID:  0,
-							Published: true,
+							ID:       1,
+							Revision: 1,
+							ActorID:  1,
+							Op:       "create",
+							At:       at,
+							After:    &adResponse{Title: "title", Text: "text", AuthorID: 1, Status: "draft", StatusChanged: at},
 						},
 						{
-							ID:        2,
-							Title:     "3rd title",
-							Text:      "3rd text",
-							AuthorID:  0,
-							Published: true,
+							ID:       4,
+							Revision: 2,
+							ActorID:  1,
+							Op:       "update",
+							At:       at,
+							Before:   &adResponse{Title: "title", Text: "text", AuthorID: 1, Status: "draft", StatusChanged: at},
+							After:    &adResponse{Title: "new title", Text: "text", AuthorID: 1, Status: "draft", StatusChanged: at},
 						},
 					},
 					"error": nil,
@@ -639,7 +604,8 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ListAds() {
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
 			tt.setMock()
-			s.setReqBody(http.MethodGet, nil)
+			s.c.AddParam("ad_id", "0")
+			s.c.Request = httptest.NewRequest(http.MethodGet, "http://not.nil.url", nil)
 			handler(s.c)
 			data, _ := json.Marshal(tt.want.resp)
 			assert.Equal(s.T(), tt.want.code, s.r.Code)
@@ -648,8 +614,8 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ListAds() {
 	}
 }
 
-func (s *HTTPGINTestSuite) TestHTTPGINHandlers_DeleteAd() {
-	handler := deleteAd(s.a)
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_RevertAd() {
+	handler := revertAd(s.a)
 
 	type want struct {
 		code int
@@ -657,30 +623,16 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_DeleteAd() {
 	}
 	tests := []struct {
 		name    string
+		reqBody map[string]any
 		setMock func()
 		want    want
 	}{
 		{
-			name: "forbidden error",
-			setMock: func() {
-				s.a.
-					On("DeleteAd", mock.Anything, mock.Anything, mock.Anything).
-					Return(nil, app.ErrForbidden).
-					Once()
-			},
-			want: want{
-				code: http.StatusForbidden,
-				resp: gin.H{
-					"data":  nil,
-					"error": app.ErrForbidden.Error(),
-				},
-			},
-		},
-		{
-			name: "bad request error",
+			name:    "bad request error",
+			reqBody: map[string]any{"revision": 10},
 			setMock: func() {
 				s.a.
-					On("DeleteAd", mock.Anything, mock.Anything, mock.Anything).
+					On("RevertAd", mock.Anything, int64(0), int64(0), int64(10)).
 					Return(nil, app.ErrBadRequest).
 					Once()
 			},
@@ -693,45 +645,35 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_DeleteAd() {
 			},
 		},
 		{
-			name: "internal server error",
+			name:    "forbidden error",
+			reqBody: map[string]any{"revision": 1},
 			setMock: func() {
 				s.a.
-					On("DeleteAd", mock.Anything, mock.Anything, mock.Anything).
-					Return(nil, fmt.Errorf("untracked internal server error")).
+					On("RevertAd", mock.Anything, int64(0), int64(0), int64(1)).
+					Return(nil, app.ErrForbidden).
 					Once()
 			},
 			want: want{
-				code: http.StatusInternalServerError,
+				code: http.StatusForbidden,
 				resp: gin.H{
 					"data":  nil,
-					"error": fmt.Errorf("untracked internal server error").Error(),
+					"error": app.ErrForbidden.Error(),
 				},
 			},
 		},
 		{
-			name: "ok",
+			name:    "ok",
+			reqBody: map[string]any{"revision": 1},
 			setMock: func() {
 				s.a.
-					On("DeleteAd", mock.Anything, mock.Anything, mock.Anything).
-					Return(&ads.Ad{
-						ID:        0,
-						Title:     "title",
-						Text:      "text",
-						UserID:    0,
-						Published: true,
-					}, nil).
+					On("RevertAd", mock.Anything, int64(0), int64(0), int64(1)).
+					Return(&ads.Ad{ID: 0, Title: "title", Text: "text", Status: ads.StatusDraft}, nil).
 					Once()
 			},
 			want: want{
 				code: http.StatusOK,
 				resp: gin.H{
-					"data": adResponse{
-						ID:        0,
-						Title:     "title",
-						Text:      "text",
-						AuthorID:  0,
-						Published: true,
-					},
+					"data":  adResponse{Title: "title", Text: "text", Status: "draft"},
 					"error": nil,
 				},
 			},
@@ -742,7 +684,7 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_DeleteAd() {
 		s.Run(tt.name, func() {
 			tt.setMock()
 			s.c.AddParam("ad_id", "0")
-			s.setReqBody(http.MethodDelete, nil)
+			s.setReqBody(http.MethodPost, tt.reqBody)
 			handler(s.c)
 			data, _ := json.Marshal(tt.want.resp)
 			assert.Equal(s.T(), tt.want.code, s.r.Code)
@@ -751,8 +693,18 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_DeleteAd() {
 	}
 }
 
-func (s *HTTPGINTestSuite) TestHTTPGINHandlers_CreateUser() {
-	handler := createUser(s.a)
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_AddAdImage() {
+	handler := addAdImage(s.a)
+	key := images.Key([]byte("photo"))
+
+	form := func(field string, data []byte) (*bytes.Buffer, string) {
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		fw, _ := w.CreateFormFile(field, "photo.png")
+		_, _ = fw.Write(data)
+		_ = w.Close()
+		return &buf, w.FormDataContentType()
+	}
 
 	type want struct {
 		code int
@@ -760,93 +712,83 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_CreateUser() {
 	}
 	tests := []struct {
 		name    string
-		reqBody map[string]any
+		field   string
+		data    []byte
 		setMock func()
 		want    want
 	}{
 		{
-			name: "forbidden error",
-			reqBody: map[string]any{
-				"nickname": "user",
-				"email":    "user@gmail.com",
-			},
-			setMock: func() {
-				s.a.
-					On("CreateUser", mock.Anything, mock.Anything, mock.Anything).
-					Return(nil, app.ErrForbidden).
-					Once()
-			},
+			name:  "no image field",
+			field: "file",
+			data:  []byte("photo"),
 			want: want{
-				code: http.StatusForbidden,
+				code: http.StatusBadRequest,
 				resp: gin.H{
 					"data":  nil,
-					"error": app.ErrForbidden.Error(),
+					"error": http.ErrMissingFile.Error(),
 				},
 			},
 		},
 		{
-			name: "bad request error",
-			reqBody: map[string]any{
-				"nickname": "user",
-				"email":    "user@gmail.com",
-			},
-			setMock: func() {
-				s.a.
-					On("CreateUser", mock.Anything, mock.Anything, mock.Anything).
-					Return(nil, app.ErrBadRequest).
-					Once()
-			},
+			name:  "too large file",
+			field: "image",
+			data:  make([]byte, images.MaxSize+1),
 			want: want{
-				code: http.StatusBadRequest,
+				code: http.StatusRequestEntityTooLarge,
 				resp: gin.H{
 					"data":  nil,
-					"error": app.ErrBadRequest.Error(),
+					"error": errImageTooLarge.Error(),
 				},
 			},
 		},
 		{
-			name: "internal server error",
-			reqBody: map[string]any{
-				"nickname": "user",
-				"email":    "user@gmail.com",
-			},
+			name:  "too large image",
+			field: "image",
+			data:  []byte("photo"),
 			setMock: func() {
 				s.a.
-					On("CreateUser", mock.Anything, mock.Anything, mock.Anything).
-					Return(nil, fmt.Errorf("untracked internal server error")).
+					On("AddAdImage", mock.Anything, int64(0), int64(0), []byte("photo")).
+					Return(nil, app.ErrTooLarge).
 					Once()
 			},
 			want: want{
-				code: http.StatusInternalServerError,
+				code: http.StatusRequestEntityTooLarge,
 				resp: gin.H{
 					"data":  nil,
-					"error": fmt.Errorf("untracked internal server error").Error(),
+					"error": app.ErrTooLarge.Error(),
 				},
 			},
 		},
 		{
-			name: "ok",
-			reqBody: map[string]any{
-				"nickname": "user",
-				"email":    "user@gmail.com",
-			},
+			name:  "ok",
+			field: "image",
+			data:  []byte("photo"),
 			setMock: func() {
 				s.a.
-					On("CreateUser", mock.Anything, mock.Anything, mock.Anything).
-					Return(&users.User{
-						ID:       0,
-						Nickname: "user",
-						Email:    "user@gmail.com",
+					On("AddAdImage", mock.Anything, int64(0), int64(0), []byte("photo")).
+					Return(&ads.Ad{
+						Title:  "title",
+						Text:   "text",
+						Status: ads.StatusDraft,
+						Images: []ads.Image{{ID: key, Thumb: "thumb", ContentType: "image/png", Width: 640, Height: 480}},
 					}, nil).
 					Once()
 			},
 			want: want{
 				code: http.StatusOK,
 				resp: gin.H{
-					"data": userResponse{
-						ID:       0,
-						Nickname: "user",
-						Email:    "user@gmail.com",
+					"data": adResponse{
+						Title:  "title",
+						Text:   "text",
+						Status: "draft",
+						Images: []imageResponse{{
+							ID:           key,
+							URL:          "/api/v1/images/" + key,
+							ThumbnailURL: "/api/v1/images/thumb",
+							ContentType:  "image/png",
+							Width:        640,
+							Height:       480,
+						}},
 					},
 					"error": nil,
 				},
@@ -856,8 +798,13 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_CreateUser() {
 
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
-			tt.setMock()
-			s.setReqBody(http.MethodPost, tt.reqBody)
+			if tt.setMock != nil {
+				tt.setMock()
+			}
+			s.c.AddParam("ad_id", "0")
+			body, ct := form(tt.field, tt.data)
+			s.c.Request = httptest.NewRequest(http.MethodPost, "http://not.nil.url", body)
+			s.c.Request.Header.Set("Content-Type", ct)
 			handler(s.c)
 			data, _ := json.Marshal(tt.want.resp)
 			assert.Equal(s.T(), tt.want.code, s.r.Code)
@@ -866,8 +813,39 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_CreateUser() {
 	}
 }
 
-func (s *HTTPGINTestSuite) TestHTTPGINHandlers_UpdateUser() {
-	handler := updateUser(s.a)
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ShowImage() {
+	handler := showImage(s.a)
+
+	s.Run("not found", func() {
+		s.a.
+			On("Image", mock.Anything, "missing").
+			Return(nil, app.ErrBadRequest).
+			Once()
+		s.c.AddParam("key", "missing")
+		s.setReqBody(http.MethodGet, nil)
+		handler(s.c)
+		assert.Equal(s.T(), http.StatusNotFound, s.r.Code)
+	})
+
+	s.Run("ok", func() {
+		var buf bytes.Buffer
+		buf.WriteString("\x89PNG\r\n\x1a\n")
+		s.a.
+			On("Image", mock.Anything, "key").
+			Return(buf.Bytes(), nil).
+			Once()
+		s.c.AddParam("key", "key")
+		s.setReqBody(http.MethodGet, nil)
+		handler(s.c)
+		assert.Equal(s.T(), http.StatusOK, s.r.Code)
+		assert.Equal(s.T(), "image/png", s.r.Header().Get("Content-Type"))
+		assert.Contains(s.T(), s.r.Header().Get("Cache-Control"), "immutable")
+		assert.Equal(s.T(), buf.Bytes(), s.r.Body.Bytes())
+	})
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_UpdateAd() {
+	handler := updateAd(s.a)
 
 	type want struct {
 		code int
@@ -882,13 +860,12 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_UpdateUser() {
 		{
 			name: "forbidden error",
 			reqBody: map[string]any{
-				"user_id":  0,
-				"nickname": "user",
-				"email":    "user@gmail.com",
+				"title": "new title",
+				"text":  "new text",
 			},
 			setMock: func() {
 				s.a.
-					On("UpdateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					On("UpdateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return(nil, app.ErrForbidden).
 					Once()
 			},
@@ -903,13 +880,12 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_UpdateUser() {
 		{
 			name: "bad request error",
 			reqBody: map[string]any{
-				"user_id":  0,
-				"nickname": "user",
-				"email":    "user@gmail.com",
+				"title": "new title",
+				"text":  "new text",
 			},
 			setMock: func() {
 				s.a.
-					On("UpdateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					On("UpdateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return(nil, app.ErrBadRequest).
 					Once()
 			},
@@ -924,13 +900,12 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_UpdateUser() {
 		{
 			name: "internal server error",
 			reqBody: map[string]any{
-				"user_id":  0,
-				"nickname": "user",
-				"email":    "user@gmail.com",
+				"title": "new title",
+				"text":  "new text",
 			},
 			setMock: func() {
 				s.a.
-					On("UpdateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					On("UpdateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return(nil, fmt.Errorf("untracked internal server error")).
 					Once()
 			},
@@ -945,27 +920,64 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_UpdateUser() {
 		{
 			name: "ok",
 			reqBody: map[string]any{
-				"user_id":  0,
-				"nickname": "user",
-				"email":    "user@gmail.com",
+				"title": "new title",
+				"text":  "new text",
 			},
 			setMock: func() {
 				s.a.
-					On("UpdateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
-					Return(&users.User{
-						ID:       0,
-						Nickname: "user",
-						Email:    "user@gmail.com",
+					On("UpdateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(&ads.Ad{
+						ID:     0,
+						Title:  "title",
+						Text:   "text",
+						UserID: 0,
+						Status: ads.StatusPublished,
 					}, nil).
 					Once()
 			},
 			want: want{
 				code: http.StatusOK,
 				resp: gin.H{
-					"data": userResponse{
-						ID:       0,
-						Nickname: "user",
-						Email:    "user@gmail.com",
+					"data": adResponse{
+						ID:        0,
+						Title:     "title",
+						Text:      "text",
+						AuthorID:  0,
+						Published: true,
+						Status:    "published",
+					},
+					"error": nil,
+				},
+			},
+		},
+		{
+			name: "ok with price",
+			reqBody: map[string]any{
+				"title": "new title",
+				"text":  "new text",
+				"price": 500,
+			},
+			setMock: func() {
+				s.a.
+					On("UpdateAd", mock.Anything, int64(0), int64(0), "new title", "new text", &ads.Price{Amount: 500}, (*ads.Location)(nil), false).
+					Return(&ads.Ad{
+						ID:     0,
+						Title:  "new title",
+						Text:   "new text",
+						Price:  ads.Price{Amount: 500},
+						Status: ads.StatusDraft,
+					}, nil).
+					Once()
+			},
+			want: want{
+				code: http.StatusOK,
+				resp: gin.H{
+					"data": adResponse{
+						ID:     0,
+						Title:  "new title",
+						Text:   "new text",
+						Price:  500,
+						Status: "draft",
 					},
 					"error": nil,
 				},
@@ -976,8 +988,8 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_UpdateUser() {
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
 			tt.setMock()
-			s.c.AddParam("user_id", "0")
-			s.setReqBody(http.MethodPost, tt.reqBody)
+			s.c.AddParam("ad_id", "0")
+			s.setReqBody(http.MethodPut, tt.reqBody)
 			handler(s.c)
 			data, _ := json.Marshal(tt.want.resp)
 			assert.Equal(s.T(), tt.want.code, s.r.Code)
@@ -986,8 +998,88 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_UpdateUser() {
 	}
 }
 
-func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ShowUser() {
-	handler := showUser(s.a)
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_UpdateAdVersion() {
+	handler := updateAd(s.a)
+
+	type want struct {
+		code int
+		etag string
+	}
+	tests := []struct {
+		name    string
+		ifMatch string
+		setMock func()
+		want    want
+	}{
+		{
+			name:    "malformed If-Match",
+			ifMatch: "abc",
+			setMock: func() {},
+			want: want{
+				code: http.StatusBadRequest,
+			},
+		},
+		{
+			name:    "precondition failed",
+			ifMatch: `"3"`,
+			setMock: func() {
+				s.a.
+					On("UpdateAd", mock.Anything, int64(0), int64(3), "new title", "new text", (*ads.Price)(nil), (*ads.Location)(nil), false).
+					Return(nil, app.ErrConflict).
+					Once()
+			},
+			want: want{
+				code: http.StatusPreconditionFailed,
+			},
+		},
+		{
+			name: "conflict without If-Match",
+			setMock: func() {
+				s.a.
+					On("UpdateAd", mock.Anything, int64(0), int64(0), "new title", "new text", (*ads.Price)(nil), (*ads.Location)(nil), false).
+					Return(nil, app.ErrConflict).
+					Once()
+			},
+			want: want{
+				code: http.StatusConflict,
+			},
+		},
+		{
+			name:    "ok",
+			ifMatch: `"3"`,
+			setMock: func() {
+				s.a.
+					On("UpdateAd", mock.Anything, int64(0), int64(3), "new title", "new text", (*ads.Price)(nil), (*ads.Location)(nil), false).
+					Return(&ads.Ad{Title: "new title", Text: "new text", Version: 4}, nil).
+					Once()
+			},
+			want: want{
+				code: http.StatusOK,
+				etag: `"4"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			tt.setMock()
+			s.c.AddParam("ad_id", "0")
+			s.setReqBody(http.MethodPut, map[string]any{
+				"title": "new title",
+				"text":  "new text",
+			})
+			if tt.ifMatch != "" {
+				s.c.Request.Header.Set("If-Match", tt.ifMatch)
+			}
+			handler(s.c)
+			assert.Equal(s.T(), tt.want.code, s.r.Code)
+			assert.Equal(s.T(), tt.want.etag, s.r.Header().Get("ETag"))
+		})
+	}
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ShowAd() {
+	handler := showAd(s.a)
 
 	type want struct {
 		code int
@@ -1002,7 +1094,7 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ShowUser() {
 			name: "forbidden error",
 			setMock: func() {
 				s.a.
-					On("UserByID", mock.Anything, mock.Anything).
+					On("AdByID", mock.Anything, mock.Anything).
 					Return(nil, app.ErrForbidden).
 					Once()
 			},
@@ -1018,7 +1110,7 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ShowUser() {
 			name: "bad request error",
 			setMock: func() {
 				s.a.
-					On("UserByID", mock.Anything, mock.Anything).
+					On("AdByID", mock.Anything, mock.Anything).
 					Return(nil, app.ErrBadRequest).
 					Once()
 			},
@@ -1034,7 +1126,7 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ShowUser() {
 			name: "internal server error",
 			setMock: func() {
 				s.a.
-					On("UserByID", mock.Anything, mock.Anything).
+					On("AdByID", mock.Anything, mock.Anything).
 					Return(nil, fmt.Errorf("untracked internal server error")).
 					Once()
 			},
@@ -1050,21 +1142,26 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ShowUser() {
 			name: "ok",
 			setMock: func() {
 				s.a.
-					On("UserByID", mock.Anything, mock.Anything).
-					Return(&users.User{
-						ID:       0,
-						Nickname: "user",
-						Email:    "user@gmail.com",
+					On("AdByID", mock.Anything, mock.Anything).
+					Return(&ads.Ad{
+						ID:     0,
+						Title:  "title",
+						Text:   "text",
+						UserID: 0,
+						Status: ads.StatusPublished,
 					}, nil).
 					Once()
 			},
 			want: want{
 				code: http.StatusOK,
 				resp: gin.H{
-					"data": userResponse{
-						ID:       0,
-						Nickname: "user",
-						Email:    "user@gmail.com",
+					"data": adResponse{
+						ID:        0,
+						Title:     "title",
+						Text:      "text",
+						AuthorID:  0,
+						Published: true,
+						Status:    "published",
 					},
 					"error": nil,
 				},
@@ -1075,7 +1172,7 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ShowUser() {
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
 			tt.setMock()
-			s.c.AddParam("user_id", "0")
+			s.c.AddParam("ad_id", "0")
 			s.setReqBody(http.MethodGet, nil)
 			handler(s.c)
 			data, _ := json.Marshal(tt.want.resp)
@@ -1085,8 +1182,8 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ShowUser() {
 	}
 }
 
-func (s *HTTPGINTestSuite) TestHTTPGINHandlers_DeleteUser() {
-	handler := deleteUser(s.a)
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ListAds() {
+	handler := listAds(s.a)
 
 	type want struct {
 		code int
@@ -1101,8 +1198,8 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_DeleteUser() {
 			name: "forbidden error",
 			setMock: func() {
 				s.a.
-					On("DeleteUser", mock.Anything, mock.Anything).
-					Return(nil, app.ErrForbidden).
+					On("AdsByPattern", mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, "", app.ErrForbidden).
 					Once()
 			},
 			want: want{
@@ -1117,8 +1214,8 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_DeleteUser() {
 			name: "bad request error",
 			setMock: func() {
 				s.a.
-					On("DeleteUser", mock.Anything, mock.Anything).
-					Return(nil, app.ErrBadRequest).
+					On("AdsByPattern", mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, "", app.ErrBadRequest).
 					Once()
 			},
 			want: want{
@@ -1133,8 +1230,8 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_DeleteUser() {
 			name: "internal server error",
 			setMock: func() {
 				s.a.
-					On("DeleteUser", mock.Anything, mock.Anything).
-					Return(nil, fmt.Errorf("untracked internal server error")).
+					On("AdsByPattern", mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, "", fmt.Errorf("untracked internal server error")).
 					Once()
 			},
 			want: want{
@@ -1145,25 +1242,94 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_DeleteUser() {
 				},
 			},
 		},
+		{
+			name: "category counts error",
+			setMock: func() {
+				s.a.
+					On("AdsByPattern", mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, "", nil).
+					Once()
+				s.a.
+					On("CategoryCounts", mock.Anything, mock.Anything).
+					Return(nil, app.ErrInternalCatRepoError).
+					Once()
+			},
+			want: want{
+				code: http.StatusInternalServerError,
+				resp: gin.H{
+					"data":  nil,
+					"error": app.ErrInternalCatRepoError.Error(),
+				},
+			},
+		},
 		{
 			name: "ok",
 			setMock: func() {
 				s.a.
-					On("DeleteUser", mock.Anything, mock.Anything).
-					Return(&users.User{
-						ID:       0,
-						Nickname: "user",
-						Email:    "user@gmail.com",
+					On("AdsByPattern", mock.Anything, mock.Anything, mock.Anything).
+					Return([]*ads.Ad{
+						{
+							ID:     0,
+							Title:  "1st title",
+							Text:   "1st text",
+							UserID: 0,
+							Status: ads.StatusPublished,
+						},
+						{
+							ID:     1,
+							Title:  "2nd title",
+							Text:   "2nd text",
+							UserID: 0,
+							Status: ads.StatusPublished,
+						},
+						{
+							ID:     2,
+							Title:  "3rd title",
+							Text:   "3rd text",
+							UserID: 0,
+							Status: ads.StatusPublished,
+						},
+					}, "", nil).
+					Once()
+				s.a.
+					On("CategoryCounts", mock.Anything, mock.Anything).
+					Return([]categories.Count{
+						{Category: &categories.Category{ID: 1, Name: "Электроника"}, Path: []string{"Электроника"}, Ads: 3},
 					}, nil).
 					Once()
 			},
 			want: want{
 				code: http.StatusOK,
 				resp: gin.H{
-					"data": userResponse{
-						ID:       0,
-						Nickname: "user",
-						Email:    "user@gmail.com",
+					"data": []adResponse{
+						{
+							ID:        0,
+							Title:     "1st title",
+							Text:      "1st text",
+							AuthorID:  0,
+							Published: true,
+							Status:    "published",
+						},
+						{
+							ID:        1,
+							Title:     "2nd title",
+							Text:      "2nd text",
+							AuthorID:  0,
+							Published: true,
+							Status:    "published",
+						},
+						{
+							ID:        2,
+							Title:     "3rd title",
+							Text:      "3rd text",
+							AuthorID:  0,
+							Published: true,
+							Status:    "published",
+						},
+					},
+					"next_cursor": "",
+					"category_counts": []categoryCountResponse{
+						{ID: 1, Name: "Электроника", Path: []string{"Электроника"}, Count: 3},
 					},
 					"error": nil,
 				},
@@ -1174,8 +1340,7 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_DeleteUser() {
 	for _, tt := range tests {
 		s.Run(tt.name, func() {
 			tt.setMock()
-			s.c.AddParam("user_id", "0")
-			s.setReqBody(http.MethodDelete, nil)
+			s.setReqBody(http.MethodGet, nil)
 			handler(s.c)
 			data, _ := json.Marshal(tt.want.resp)
 			assert.Equal(s.T(), tt.want.code, s.r.Code)
@@ -1184,6 +1349,1979 @@ func (s *HTTPGINTestSuite) TestHTTPGINHandlers_DeleteUser() {
 	}
 }
 
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ListAds_Price() {
+	handler := listAds(s.a)
+
+	s.Run("unknown currency", func() {
+		s.c.Request = httptest.NewRequest(http.MethodGet, "http://not.nil.url?currency=euro", nil)
+		handler(s.c)
+		assert.Equal(s.T(), http.StatusBadRequest, s.r.Code)
+	})
+
+	s.Run("ok", func() {
+		ad := &ads.Ad{ID: 1, Title: "title", Text: "text", Price: ads.Price{Amount: 25000, Currency: "RUB"}}
+		s.a.
+			On("AdsByPattern", mock.Anything,
+				mock.MatchedBy(func(p *ads.Pattern) bool {
+					return p.String() == `not (currency = "") and price between 10000 and 50000 and currency = "RUB"`
+				}),
+				mock.MatchedBy(func(page ads.Page) bool {
+					return page.Sort == ads.Sort{Field: ads.SortByPrice, Desc: true}
+				})).
+			Return([]*ads.Ad{ad}, "", nil).
+			Once()
+		s.a.
+			On("CategoryCounts", mock.Anything, mock.Anything).
+			Return(nil, nil).
+			Once()
+
+		s.c.Request = httptest.NewRequest(http.MethodGet, "http://not.nil.url?price_min=10000&price_max=50000&currency=rub&sort=price:desc", nil)
+		handler(s.c)
+		data, _ := json.Marshal(gin.H{
+			"data":            []adResponse{{ID: 1, Title: "title", Text: "text", Price: 25000, Currency: "RUB"}},
+			"next_cursor":     "",
+			"category_counts": []categoryCountResponse{},
+			"error":           nil,
+		})
+		assert.Equal(s.T(), http.StatusOK, s.r.Code)
+		assert.Equal(s.T(), data, s.r.Body.Bytes())
+	})
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ListAds_Near() {
+	handler := listAds(s.a)
+
+	s.Run("radius without center", func() {
+		s.c.Request = httptest.NewRequest(http.MethodGet, "http://not.nil.url?radius=10", nil)
+		handler(s.c)
+		assert.Equal(s.T(), http.StatusBadRequest, s.r.Code)
+	})
+
+	s.Run("radius too large", func() {
+		s.c.Request = httptest.NewRequest(http.MethodGet, "http://not.nil.url?lat=55.75&lon=37.62&radius=1000", nil)
+		handler(s.c)
+		assert.Equal(s.T(), http.StatusBadRequest, s.r.Code)
+	})
+
+	s.Run("ok", func() {
+		distance := 1.5
+		location := &ads.Location{Point: ads.Point{Lat: 55.76, Lon: 37.61}, City: "Москва"}
+		ad := &ads.Ad{ID: 1, Title: "title", Text: "text", Location: location, Distance: &distance}
+		s.a.
+			On("AdsByPattern", mock.Anything,
+				mock.MatchedBy(func(p *ads.Pattern) bool {
+					return p.Near != nil && *p.Near == ads.Circle{Center: ads.Point{Lat: 55.75, Lon: 37.62}, Radius: 5}
+				}),
+				mock.MatchedBy(func(page ads.Page) bool {
+					return page.Sort == ads.Sort{Field: ads.SortByDistance}
+				})).
+			Return([]*ads.Ad{ad}, "", nil).
+			Once()
+		s.a.
+			On("CategoryCounts", mock.Anything, mock.Anything).
+			Return(nil, nil).
+			Once()
+
+		s.c.Request = httptest.NewRequest(http.MethodGet, "http://not.nil.url?lat=55.75&lon=37.62&radius=5&sort=distance", nil)
+		handler(s.c)
+		data, _ := json.Marshal(gin.H{
+			"data": []adResponse{{
+				ID:       1,
+				Title:    "title",
+				Text:     "text",
+				Location: &locationResponse{Lat: 55.76, Lon: 37.61, City: "Москва"},
+				Distance: &distance,
+			}},
+			"next_cursor":     "",
+			"category_counts": []categoryCountResponse{},
+			"error":           nil,
+		})
+		assert.Equal(s.T(), http.StatusOK, s.r.Code)
+		assert.Equal(s.T(), data, s.r.Body.Bytes())
+	})
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_WatchAds() {
+	handler := watchAds(s.a)
+	at := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+	event := func(e *events.Event) string {
+		data, _ := json.Marshal(AdEventResponse(e))
+		return fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	}
+	failure := func(err error) string {
+		data, _ := json.Marshal(ErrorResponse(err))
+		return fmt.Sprintf("event: error\ndata: %s\n\n", data)
+	}
+
+	s.Run("bad filter", func() {
+		s.c.Request = httptest.NewRequest(http.MethodGet, "http://not.nil.url?filter=title", nil)
+		handler(s.c)
+		assert.Equal(s.T(), http.StatusBadRequest, s.r.Code)
+	})
+
+	s.Run("unavailable", func() {
+		s.a.On("WatchAds", mock.Anything, mock.Anything).Return(nil, app.ErrUnavailable).Once()
+		s.c.Request = httptest.NewRequest(http.MethodGet, "http://not.nil.url", nil)
+		handler(s.c)
+		assert.Equal(s.T(), http.StatusServiceUnavailable, s.r.Code)
+	})
+
+	s.Run("closed", func() {
+		hub := feed.NewHub(0)
+		sub, _ := hub.Subscribe(func(*events.Event) bool { return true })
+		created := &events.Event{ID: 1, Type: events.AdCreated, At: at, ActorID: 2, Ad: &ads.Ad{ID: 3, Title: "title", UserID: 2}}
+		hub.Publish(created)
+		hub.Close()
+
+		s.a.
+			On("WatchAds", mock.Anything, mock.MatchedBy(func(p *ads.Pattern) bool {
+				return p.Category == 4 && p.String() == "published = true"
+			})).
+			Return(sub, nil).
+			Once()
+		s.c.Request = httptest.NewRequest(http.MethodGet, "http://not.nil.url?published=true&category=4", nil)
+		handler(s.c)
+		assert.Equal(s.T(), http.StatusOK, s.r.Code)
+		assert.Equal(s.T(), "text/event-stream", s.r.Header().Get("Content-Type"))
+		assert.Equal(s.T(), event(created)+failure(feed.ErrClosed), s.r.Body.String())
+	})
+
+	s.Run("slow consumer", func() {
+		hub := feed.NewHub(1)
+		sub, _ := hub.Subscribe(func(*events.Event) bool { return true })
+		updated := &events.Event{ID: 5, Type: events.AdUpdated, At: at, Ad: &ads.Ad{ID: 3}}
+		hub.Publish(updated)
+		hub.Publish(&events.Event{ID: 6, Type: events.AdDeleted, At: at, Ad: &ads.Ad{ID: 3}})
+
+		s.a.On("WatchAds", mock.Anything, mock.Anything).Return(sub, nil).Once()
+		s.c.Request = httptest.NewRequest(http.MethodGet, "http://not.nil.url", nil)
+		handler(s.c)
+		assert.Equal(s.T(), event(updated)+failure(feed.ErrSlowConsumer), s.r.Body.String())
+		assert.Equal(s.T(), 0, hub.Len())
+	})
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_DeleteAd() {
+	handler := deleteAd(s.a)
+
+	type want struct {
+		code int
+		resp gin.H
+	}
+	tests := []struct {
+		name    string
+		setMock func()
+		want    want
+	}{
+		{
+			name: "forbidden error",
+			setMock: func() {
+				s.a.
+					On("DeleteAd", mock.Anything, mock.Anything).
+					Return(nil, app.ErrForbidden).
+					Once()
+			},
+			want: want{
+				code: http.StatusForbidden,
+				resp: gin.H{
+					"data":  nil,
+					"error": app.ErrForbidden.Error(),
+				},
+			},
+		},
+		{
+			name: "bad request error",
+			setMock: func() {
+				s.a.
+					On("DeleteAd", mock.Anything, mock.Anything).
+					Return(nil, app.ErrBadRequest).
+					Once()
+			},
+			want: want{
+				code: http.StatusBadRequest,
+				resp: gin.H{
+					"data":  nil,
+					"error": app.ErrBadRequest.Error(),
+				},
+			},
+		},
+		{
+			name: "internal server error",
+			setMock: func() {
+				s.a.
+					On("DeleteAd", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("untracked internal server error")).
+					Once()
+			},
+			want: want{
+				code: http.StatusInternalServerError,
+				resp: gin.H{
+					"data":  nil,
+					"error": fmt.Errorf("untracked internal server error").Error(),
+				},
+			},
+		},
+		{
+			name: "ok",
+			setMock: func() {
+				s.a.
+					On("DeleteAd", mock.Anything, mock.Anything).
+					Return(&ads.Ad{
+						ID:     0,
+						Title:  "title",
+						Text:   "text",
+						UserID: 0,
+						Status: ads.StatusPublished,
+					}, nil).
+					Once()
+			},
+			want: want{
+				code: http.StatusOK,
+				resp: gin.H{
+					"data": adResponse{
+						ID:        0,
+						Title:     "title",
+						Text:      "text",
+						AuthorID:  0,
+						Published: true,
+						Status:    "published",
+					},
+					"error": nil,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			tt.setMock()
+			s.c.AddParam("ad_id", "0")
+			s.setReqBody(http.MethodDelete, nil)
+			handler(s.c)
+			data, _ := json.Marshal(tt.want.resp)
+			assert.Equal(s.T(), tt.want.code, s.r.Code)
+			assert.Equal(s.T(), data, s.r.Body.Bytes())
+		})
+	}
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_CreateUser() {
+	handler := createUser(s.a)
+
+	type want struct {
+		code int
+		resp gin.H
+	}
+	tests := []struct {
+		name    string
+		reqBody map[string]any
+		setMock func()
+		want    want
+	}{
+		{
+			name: "forbidden error",
+			reqBody: map[string]any{
+				"nickname": "user",
+				"email":    "user@gmail.com",
+			},
+			setMock: func() {
+				s.a.
+					On("CreateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, app.ErrForbidden).
+					Once()
+			},
+			want: want{
+				code: http.StatusForbidden,
+				resp: gin.H{
+					"data":  nil,
+					"error": app.ErrForbidden.Error(),
+				},
+			},
+		},
+		{
+			name: "bad request error",
+			reqBody: map[string]any{
+				"nickname": "user",
+				"email":    "user@gmail.com",
+			},
+			setMock: func() {
+				s.a.
+					On("CreateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, app.ErrBadRequest).
+					Once()
+			},
+			want: want{
+				code: http.StatusBadRequest,
+				resp: gin.H{
+					"data":  nil,
+					"error": app.ErrBadRequest.Error(),
+				},
+			},
+		},
+		{
+			name: "internal server error",
+			reqBody: map[string]any{
+				"nickname": "user",
+				"email":    "user@gmail.com",
+			},
+			setMock: func() {
+				s.a.
+					On("CreateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("untracked internal server error")).
+					Once()
+			},
+			want: want{
+				code: http.StatusInternalServerError,
+				resp: gin.H{
+					"data":  nil,
+					"error": fmt.Errorf("untracked internal server error").Error(),
+				},
+			},
+		},
+		{
+			name: "ok",
+			reqBody: map[string]any{
+				"nickname": "user",
+				"email":    "user@gmail.com",
+			},
+			setMock: func() {
+				s.a.
+					On("CreateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(&users.User{
+						ID:       0,
+						Nickname: "user",
+						Email:    "user@gmail.com",
+					}, nil).
+					Once()
+			},
+			want: want{
+				code: http.StatusOK,
+				resp: gin.H{
+					"data": userResponse{
+						ID:       0,
+						Nickname: "user",
+						Email:    "user@gmail.com",
+						Role:     "user",
+					},
+					"error": nil,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			tt.setMock()
+			s.setReqBody(http.MethodPost, tt.reqBody)
+			handler(s.c)
+			data, _ := json.Marshal(tt.want.resp)
+			assert.Equal(s.T(), tt.want.code, s.r.Code)
+			assert.Equal(s.T(), data, s.r.Body.Bytes())
+		})
+	}
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_UpdateUser() {
+	handler := updateUser(s.a)
+
+	type want struct {
+		code int
+		resp gin.H
+	}
+	tests := []struct {
+		name    string
+		reqBody map[string]any
+		setMock func()
+		want    want
+	}{
+		{
+			name: "forbidden error",
+			reqBody: map[string]any{
+				"nickname": "user",
+				"email":    "user@gmail.com",
+			},
+			setMock: func() {
+				s.a.
+					On("UpdateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, app.ErrForbidden).
+					Once()
+			},
+			want: want{
+				code: http.StatusForbidden,
+				resp: gin.H{
+					"data":  nil,
+					"error": app.ErrForbidden.Error(),
+				},
+			},
+		},
+		{
+			name: "bad request error",
+			reqBody: map[string]any{
+				"nickname": "user",
+				"email":    "user@gmail.com",
+			},
+			setMock: func() {
+				s.a.
+					On("UpdateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, app.ErrBadRequest).
+					Once()
+			},
+			want: want{
+				code: http.StatusBadRequest,
+				resp: gin.H{
+					"data":  nil,
+					"error": app.ErrBadRequest.Error(),
+				},
+			},
+		},
+		{
+			name: "internal server error",
+			reqBody: map[string]any{
+				"nickname": "user",
+				"email":    "user@gmail.com",
+			},
+			setMock: func() {
+				s.a.
+					On("UpdateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("untracked internal server error")).
+					Once()
+			},
+			want: want{
+				code: http.StatusInternalServerError,
+				resp: gin.H{
+					"data":  nil,
+					"error": fmt.Errorf("untracked internal server error").Error(),
+				},
+			},
+		},
+		{
+			name: "ok",
+			reqBody: map[string]any{
+				"nickname": "user",
+				"email":    "user@gmail.com",
+			},
+			setMock: func() {
+				s.a.
+					On("UpdateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return(&users.User{
+						ID:       0,
+						Nickname: "user",
+						Email:    "user@gmail.com",
+					}, nil).
+					Once()
+			},
+			want: want{
+				code: http.StatusOK,
+				resp: gin.H{
+					"data": userResponse{
+						ID:       0,
+						Nickname: "user",
+						Email:    "user@gmail.com",
+						Role:     "user",
+					},
+					"error": nil,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			tt.setMock()
+			s.c.AddParam("user_id", "0")
+			s.setReqBody(http.MethodPost, tt.reqBody)
+			handler(s.c)
+			data, _ := json.Marshal(tt.want.resp)
+			assert.Equal(s.T(), tt.want.code, s.r.Code)
+			assert.Equal(s.T(), data, s.r.Body.Bytes())
+		})
+	}
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ShowUser() {
+	handler := showUser(s.a)
+
+	type want struct {
+		code int
+		resp gin.H
+	}
+	tests := []struct {
+		name    string
+		setMock func()
+		want    want
+	}{
+		{
+			name: "forbidden error",
+			setMock: func() {
+				s.a.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(nil, app.ErrForbidden).
+					Once()
+			},
+			want: want{
+				code: http.StatusForbidden,
+				resp: gin.H{
+					"data":  nil,
+					"error": app.ErrForbidden.Error(),
+				},
+			},
+		},
+		{
+			name: "bad request error",
+			setMock: func() {
+				s.a.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(nil, app.ErrBadRequest).
+					Once()
+			},
+			want: want{
+				code: http.StatusBadRequest,
+				resp: gin.H{
+					"data":  nil,
+					"error": app.ErrBadRequest.Error(),
+				},
+			},
+		},
+		{
+			name: "internal server error",
+			setMock: func() {
+				s.a.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("untracked internal server error")).
+					Once()
+			},
+			want: want{
+				code: http.StatusInternalServerError,
+				resp: gin.H{
+					"data":  nil,
+					"error": fmt.Errorf("untracked internal server error").Error(),
+				},
+			},
+		},
+		{
+			name: "ok",
+			setMock: func() {
+				s.a.
+					On("UserByID", mock.Anything, mock.Anything).
+					Return(&users.User{
+						ID:       0,
+						Nickname: "user",
+						Email:    "user@gmail.com",
+					}, nil).
+					Once()
+			},
+			want: want{
+				code: http.StatusOK,
+				resp: gin.H{
+					"data": userResponse{
+						ID:       0,
+						Nickname: "user",
+						Email:    "user@gmail.com",
+						Role:     "user",
+					},
+					"error": nil,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			tt.setMock()
+			s.c.AddParam("user_id", "0")
+			s.setReqBody(http.MethodGet, nil)
+			handler(s.c)
+			data, _ := json.Marshal(tt.want.resp)
+			assert.Equal(s.T(), tt.want.code, s.r.Code)
+			assert.Equal(s.T(), data, s.r.Body.Bytes())
+		})
+	}
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_DeleteUser() {
+	handler := deleteUser(s.a)
+
+	type want struct {
+		code int
+		resp gin.H
+	}
+	tests := []struct {
+		name    string
+		setMock func()
+		want    want
+	}{
+		{
+			name: "forbidden error",
+			setMock: func() {
+				s.a.
+					On("DeleteUser", mock.Anything, mock.Anything).
+					Return(nil, app.ErrForbidden).
+					Once()
+			},
+			want: want{
+				code: http.StatusForbidden,
+				resp: gin.H{
+					"data":  nil,
+					"error": app.ErrForbidden.Error(),
+				},
+			},
+		},
+		{
+			name: "bad request error",
+			setMock: func() {
+				s.a.
+					On("DeleteUser", mock.Anything, mock.Anything).
+					Return(nil, app.ErrBadRequest).
+					Once()
+			},
+			want: want{
+				code: http.StatusBadRequest,
+				resp: gin.H{
+					"data":  nil,
+					"error": app.ErrBadRequest.Error(),
+				},
+			},
+		},
+		{
+			name: "internal server error",
+			setMock: func() {
+				s.a.
+					On("DeleteUser", mock.Anything, mock.Anything).
+					Return(nil, fmt.Errorf("untracked internal server error")).
+					Once()
+			},
+			want: want{
+				code: http.StatusInternalServerError,
+				resp: gin.H{
+					"data":  nil,
+					"error": fmt.Errorf("untracked internal server error").Error(),
+				},
+			},
+		},
+		{
+			name: "ok",
+			setMock: func() {
+				s.a.
+					On("DeleteUser", mock.Anything, mock.Anything).
+					Return(&users.User{
+						ID:       0,
+						Nickname: "user",
+						Email:    "user@gmail.com",
+					}, nil).
+					Once()
+			},
+			want: want{
+				code: http.StatusOK,
+				resp: gin.H{
+					"data": userResponse{
+						ID:       0,
+						Nickname: "user",
+						Email:    "user@gmail.com",
+						Role:     "user",
+					},
+					"error": nil,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			tt.setMock()
+			s.c.AddParam("user_id", "0")
+			s.setReqBody(http.MethodDelete, nil)
+			handler(s.c)
+			data, _ := json.Marshal(tt.want.resp)
+			assert.Equal(s.T(), tt.want.code, s.r.Code)
+			assert.Equal(s.T(), data, s.r.Body.Bytes())
+		})
+	}
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ChangeUserRole() {
+	handler := changeUserRole(s.a)
+
+	type want struct {
+		code int
+		resp gin.H
+	}
+	tests := []struct {
+		name    string
+		setMock func()
+		want    want
+	}{
+		{
+			name: "forbidden error",
+			setMock: func() {
+				s.a.
+					On("ChangeUserRole", mock.Anything, int64(0), int64(0), users.RoleModerator).
+					Return(nil, app.ErrForbidden).
+					Once()
+			},
+			want: want{
+				code: http.StatusForbidden,
+				resp: gin.H{
+					"data":  nil,
+					"error": app.ErrForbidden.Error(),
+				},
+			},
+		},
+		{
+			name: "ok",
+			setMock: func() {
+				s.a.
+					On("ChangeUserRole", mock.Anything, int64(0), int64(0), users.RoleModerator).
+					Return(&users.User{
+						ID:       0,
+						Nickname: "user",
+						Email:    "user@gmail.com",
+						Role:     users.RoleModerator,
+					}, nil).
+					Once()
+			},
+			want: want{
+				code: http.StatusOK,
+				resp: gin.H{
+					"data": userResponse{
+						ID:       0,
+						Nickname: "user",
+						Email:    "user@gmail.com",
+						Role:     "moderator",
+					},
+					"error": nil,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			tt.setMock()
+			s.c.AddParam("user_id", "0")
+			s.setReqBody(http.MethodPut, map[string]any{
+				"role": "moderator",
+			})
+			handler(s.c)
+			data, _ := json.Marshal(tt.want.resp)
+			assert.Equal(s.T(), tt.want.code, s.r.Code)
+			assert.Equal(s.T(), data, s.r.Body.Bytes())
+		})
+	}
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_Login() {
+	handler := login(s.a)
+
+	type want struct {
+		code int
+		resp gin.H
+	}
+	tests := []struct {
+		name    string
+		setMock func()
+		want    want
+	}{
+		{
+			name: "unauthorized error",
+			setMock: func() {
+				s.a.
+					On("Login", mock.Anything, int64(0), "password").
+					Return(auth.Tokens{}, app.ErrUnauthorized).
+					Once()
+			},
+			want: want{
+				code: http.StatusUnauthorized,
+				resp: gin.H{
+					"data":  nil,
+					"error": app.ErrUnauthorized.Error(),
+				},
+			},
+		},
+		{
+			name: "ok",
+			setMock: func() {
+				s.a.
+					On("Login", mock.Anything, int64(0), "password").
+					Return(auth.Tokens{Access: "access", Refresh: "refresh", ExpiresIn: time.Minute}, nil).
+					Once()
+			},
+			want: want{
+				code: http.StatusOK,
+				resp: gin.H{
+					"data": tokensResponse{
+						AccessToken:  "access",
+						RefreshToken: "refresh",
+						TokenType:    "Bearer",
+						ExpiresIn:    60,
+					},
+					"error": nil,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			tt.setMock()
+			s.setReqBody(http.MethodPost, map[string]any{
+				"user_id":  0,
+				"password": "password",
+			})
+			handler(s.c)
+			data, _ := json.Marshal(tt.want.resp)
+			assert.Equal(s.T(), tt.want.code, s.r.Code)
+			assert.Equal(s.T(), data, s.r.Body.Bytes())
+		})
+	}
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINMiddleware_Authenticate() {
+	middleware := authenticate(s.a)
+
+	tests := []struct {
+		name          string
+		authorization string
+		setMock       func()
+		code          int
+		userID        int64
+		authenticated bool
+	}{
+		{
+			name:    "anonymous",
+			setMock: func() {},
+			code:    http.StatusOK,
+		},
+		{
+			name:          "not a bearer token",
+			authorization: "Basic dXNlcjpwYXNz",
+			setMock:       func() {},
+			code:          http.StatusUnauthorized,
+		},
+		{
+			name:          "bad token",
+			authorization: "Bearer bad",
+			setMock: func() {
+				s.a.
+					On("Authenticate", mock.Anything, "bad").
+					Return(int64(0), app.ErrUnauthorized).
+					Once()
+			},
+			code: http.StatusUnauthorized,
+		},
+		{
+			name:          "ok",
+			authorization: "Bearer good",
+			setMock: func() {
+				s.a.
+					On("Authenticate", mock.Anything, "good").
+					Return(int64(7), nil).
+					Once()
+			},
+			code:          http.StatusOK,
+			userID:        7,
+			authenticated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			tt.setMock()
+			s.setReqBody(http.MethodGet, nil)
+			if tt.authorization != "" {
+				s.c.Request.Header.Set("Authorization", tt.authorization)
+			}
+			middleware(s.c)
+			assert.Equal(s.T(), tt.code, s.r.Code)
+			assert.Equal(s.T(), tt.code != http.StatusOK, s.c.IsAborted())
+
+			userID, ok := auth.UserID(s.c.Request.Context())
+			assert.Equal(s.T(), tt.authenticated, ok)
+			assert.Equal(s.T(), tt.userID, userID)
+		})
+	}
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINMiddleware_RateLimit() {
+	l := ratelimit.New(ratelimit.Rules{
+		Routes: map[string]ratelimit.Limit{"POST /ads": {Count: 2, Per: time.Minute}},
+	}, 0)
+	router := gin.New()
+	router.POST("/ads", rateLimit(l), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.GET("/ads", rateLimit(l), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	do := func(method, ip string, userID int64) *httptest.ResponseRecorder {
+		r := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/ads", nil)
+		req.RemoteAddr = ip + ":5000"
+		if userID > 0 {
+			req = req.WithContext(auth.WithUserID(req.Context(), userID))
+		}
+		router.ServeHTTP(r, req)
+		return r
+	}
+
+	for i := 0; i < 2; i++ {
+		assert.Equal(s.T(), http.StatusOK, do(http.MethodPost, "10.0.0.1", 0).Code)
+	}
+	r := do(http.MethodPost, "10.0.0.1", 0)
+	assert.Equal(s.T(), http.StatusTooManyRequests, r.Code)
+	assert.Equal(s.T(), "30", r.Header().Get("Retry-After"))
+	data, _ := json.Marshal(ErrorResponse(ratelimit.ErrLimited))
+	assert.Equal(s.T(), data, r.Body.Bytes())
+
+	// у другого адреса и у пользователя с того же адреса свои лимиты, у маршрутов без лимита ограничений нет
+	assert.Equal(s.T(), http.StatusOK, do(http.MethodPost, "10.0.0.2", 0).Code)
+	assert.Equal(s.T(), http.StatusOK, do(http.MethodPost, "10.0.0.1", 7).Code)
+	assert.Equal(s.T(), http.StatusOK, do(http.MethodGet, "10.0.0.1", 0).Code)
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINMiddleware_Idempotent() {
+	keys := idempotency.New[storedResponse](time.Hour)
+	var created int
+	router := gin.New()
+	router.POST("/ads", idempotent(keys), func(c *gin.Context) {
+		var reqBody createAdRequest
+		if err := c.Bind(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+		if reqBody.Title == "fail" {
+			c.JSON(http.StatusInternalServerError, ErrorResponse(app.ErrInternalAdRepoError))
+			return
+		}
+
+		created++
+		setETag(c, 1)
+		c.JSON(http.StatusOK, AdSuccessResponse(&ads.Ad{ID: int64(created), Title: reqBody.Title}))
+	})
+
+	doFrom := func(ip, key, title string) *httptest.ResponseRecorder {
+		r := httptest.NewRecorder()
+		data, _ := json.Marshal(map[string]any{"title": title})
+		req := httptest.NewRequest(http.MethodPost, "/ads", bytes.NewReader(data))
+		req.RemoteAddr = ip + ":5000"
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set(HeaderIdempotencyKey, key)
+		}
+		router.ServeHTTP(r, req)
+		return r
+	}
+	do := func(key, title string) *httptest.ResponseRecorder {
+		return doFrom("10.0.0.1", key, title)
+	}
+
+	first := do("key", "title")
+	assert.Equal(s.T(), http.StatusOK, first.Code)
+	assert.Empty(s.T(), first.Header().Get(HeaderIdempotentReplayed))
+
+	// повтор получает тот же ответ, объявление не создаётся заново
+	replayed := do("key", "title")
+	assert.Equal(s.T(), http.StatusOK, replayed.Code)
+	assert.Equal(s.T(), "true", replayed.Header().Get(HeaderIdempotentReplayed))
+	assert.Equal(s.T(), first.Header().Get("ETag"), replayed.Header().Get("ETag"))
+	assert.Equal(s.T(), first.Body.Bytes(), replayed.Body.Bytes())
+	assert.Equal(s.T(), 1, created)
+
+	// тот же ключ с другим телом - конфликт
+	conflict := do("key", "other")
+	assert.Equal(s.T(), http.StatusConflict, conflict.Code)
+	data, _ := json.Marshal(ErrorResponse(idempotency.ErrMismatch))
+	assert.Equal(s.T(), data, conflict.Body.Bytes())
+
+	// ошибка сервера не сохраняется, без ключа запрос выполняется каждый раз
+	assert.Equal(s.T(), http.StatusInternalServerError, do("failed", "fail").Code)
+	assert.Equal(s.T(), http.StatusOK, do("failed", "title").Code)
+	assert.Equal(s.T(), http.StatusOK, do("", "title").Code)
+	assert.Equal(s.T(), http.StatusOK, do("", "title").Code)
+	assert.Equal(s.T(), 4, created)
+
+	// анонимный клиент с другого адреса с тем же ключом не получает чужой ответ
+	neighbour := doFrom("10.0.0.2", "key", "title")
+	assert.Equal(s.T(), http.StatusOK, neighbour.Code)
+	assert.Empty(s.T(), neighbour.Header().Get(HeaderIdempotentReplayed))
+	assert.NotEqual(s.T(), first.Body.Bytes(), neighbour.Body.Bytes())
+	assert.Equal(s.T(), 5, created)
+
+	assert.Equal(s.T(), http.StatusBadRequest, do(strings.Repeat("k", idempotency.MaxKeyLength+1), "title").Code)
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_CreateWebhook() {
+	handler := createWebhook(s.a)
+	created := time.Date(2023, 5, 1, 15, 30, 0, 0, time.UTC)
+
+	type want struct {
+		code int
+		resp gin.H
+	}
+	tests := []struct {
+		name    string
+		reqBody map[string]any
+		setMock func()
+		want    want
+	}{
+		{
+			name:    "forbidden error",
+			reqBody: map[string]any{"url": "http://example.com"},
+			setMock: func() {
+				s.a.
+					On("CreateWebhook", mock.Anything, "http://example.com", []events.Type(nil)).
+					Return(nil, app.ErrForbidden).
+					Once()
+			},
+			want: want{
+				code: http.StatusForbidden,
+				resp: gin.H{
+					"data":  nil,
+					"error": app.ErrForbidden.Error(),
+				},
+			},
+		},
+		{
+			name:    "bad request error",
+			reqBody: map[string]any{"url": "http://example.com", "events": []string{"ad.viewed"}},
+			setMock: func() {
+				s.a.
+					On("CreateWebhook", mock.Anything, "http://example.com", []events.Type{"ad.viewed"}).
+					Return(nil, app.ErrBadRequest).
+					Once()
+			},
+			want: want{
+				code: http.StatusBadRequest,
+				resp: gin.H{
+					"data":  nil,
+					"error": app.ErrBadRequest.Error(),
+				},
+			},
+		},
+		{
+			name:    "ok",
+			reqBody: map[string]any{"url": "http://example.com", "events": []string{"ad.created"}},
+			setMock: func() {
+				s.a.
+					On("CreateWebhook", mock.Anything, "http://example.com", []events.Type{events.AdCreated}).
+					Return(&webhooks.Subscription{ID: 1, URL: "http://example.com", Types: []events.Type{events.AdCreated}, Secret: "secret", Created: created}, nil).
+					Once()
+			},
+			want: want{
+				code: http.StatusOK,
+				resp: gin.H{
+					"data":  webhookResponse{ID: 1, URL: "http://example.com", Events: []string{"ad.created"}, Secret: "secret", Created: created},
+					"error": nil,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			tt.setMock()
+			s.setReqBody(http.MethodPost, tt.reqBody)
+			handler(s.c)
+			data, _ := json.Marshal(tt.want.resp)
+			assert.Equal(s.T(), tt.want.code, s.r.Code)
+			assert.Equal(s.T(), data, s.r.Body.Bytes())
+		})
+	}
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ListWebhooks() {
+	handler := listWebhooks(s.a)
+	created := time.Date(2023, 5, 1, 15, 30, 0, 0, time.UTC)
+
+	type want struct {
+		code int
+		resp gin.H
+	}
+	tests := []struct {
+		name    string
+		setMock func()
+		want    want
+	}{
+		{
+			name: "unauthorized error",
+			setMock: func() {
+				s.a.
+					On("Webhooks", mock.Anything).
+					Return(nil, app.ErrUnauthorized).
+					Once()
+			},
+			want: want{
+				code: http.StatusUnauthorized,
+				resp: gin.H{
+					"data":  nil,
+					"error": app.ErrUnauthorized.Error(),
+				},
+			},
+		},
+		{
+			name: "ok without secrets",
+			setMock: func() {
+				s.a.
+					On("Webhooks", mock.Anything).
+					Return([]*webhooks.Subscription{{ID: 1, URL: "http://example.com", Secret: "secret", Created: created}}, nil).
+					Once()
+			},
+			want: want{
+				code: http.StatusOK,
+				resp: gin.H{
+					"data":  []webhookResponse{{ID: 1, URL: "http://example.com", Events: []string{}, Created: created}},
+					"error": nil,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			tt.setMock()
+			s.c.Request = httptest.NewRequest(http.MethodGet, "http://not.nil.url", nil)
+			handler(s.c)
+			data, _ := json.Marshal(tt.want.resp)
+			assert.Equal(s.T(), tt.want.code, s.r.Code)
+			assert.Equal(s.T(), data, s.r.Body.Bytes())
+		})
+	}
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_DeleteWebhook() {
+	handler := deleteWebhook(s.a)
+
+	type want struct {
+		code int
+		resp gin.H
+	}
+	tests := []struct {
+		name    string
+		param   string
+		setMock func()
+		want    want
+	}{
+		{
+			name:    "bad request error for wrong id",
+			param:   "one",
+			setMock: func() {},
+			want: want{
+				code: http.StatusBadRequest,
+				resp: gin.H{
+					"data":  nil,
+					"error": `strconv.Atoi: parsing "one": invalid syntax`,
+				},
+			},
+		},
+		{
+			name:  "bad request error for unknown subscription",
+			param: "2",
+			setMock: func() {
+				s.a.
+					On("DeleteWebhook", mock.Anything, int64(2)).
+					Return(nil, app.ErrBadRequest).
+					Once()
+			},
+			want: want{
+				code: http.StatusBadRequest,
+				resp: gin.H{
+					"data":  nil,
+					"error": app.ErrBadRequest.Error(),
+				},
+			},
+		},
+		{
+			name:  "ok",
+			param: "1",
+			setMock: func() {
+				s.a.
+					On("DeleteWebhook", mock.Anything, int64(1)).
+					Return(&webhooks.Subscription{ID: 1, URL: "http://example.com"}, nil).
+					Once()
+			},
+			want: want{
+				code: http.StatusOK,
+				resp: gin.H{
+					"data":  webhookResponse{ID: 1, URL: "http://example.com", Events: []string{}},
+					"error": nil,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			tt.setMock()
+			s.c.AddParam("webhook_id", tt.param)
+			s.c.Request = httptest.NewRequest(http.MethodDelete, "http://not.nil.url", nil)
+			handler(s.c)
+			data, _ := json.Marshal(tt.want.resp)
+			assert.Equal(s.T(), tt.want.code, s.r.Code)
+			assert.Equal(s.T(), data, s.r.Body.Bytes())
+		})
+	}
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_WebhookDeliveries() {
+	handler := listWebhookDeliveries(s.a)
+	at := time.Date(2023, 5, 1, 15, 30, 0, 0, time.UTC)
+	next := at.Add(time.Minute)
+
+	type want struct {
+		code int
+		resp gin.H
+	}
+	tests := []struct {
+		name    string
+		setMock func()
+		want    want
+	}{
+		{
+			name: "forbidden error",
+			setMock: func() {
+				s.a.
+					On("WebhookDeliveries", mock.Anything, int64(1)).
+					Return(nil, app.ErrForbidden).
+					Once()
+			},
+			want: want{
+				code: http.StatusForbidden,
+				resp: gin.H{
+					"data":  nil,
+					"error": app.ErrForbidden.Error(),
+				},
+			},
+		},
+		{
+			name: "ok",
+			setMock: func() {
+				s.a.
+					On("WebhookDeliveries", mock.Anything, int64(1)).
+					Return([]*webhooks.Delivery{
+						{
+							ID:             2,
+							SubscriptionID: 1,
+							Event:          events.Event{ID: 5, Type: events.AdPublished},
+							Status:         webhooks.DeliveryPending,
+							Attempts:       []webhooks.Attempt{{At: at, StatusCode: 500, Error: "unexpected status 500", Duration: 15 * time.Millisecond}},
+							NextAttempt:    next,
+							Created:        at,
+						},
+						{
+							ID:             1,
+							SubscriptionID: 1,
+							Event:          events.Event{ID: 4, Type: events.AdCreated},
+							Status:         webhooks.DeliveryDelivered,
+							Attempts:       []webhooks.Attempt{{At: at, StatusCode: 200}},
+							NextAttempt:    at,
+							Created:        at,
+						},
+					}, nil).
+					Once()
+			},
+			want: want{
+				code: http.StatusOK,
+				resp: gin.H{
+					"data": []deliveryResponse{
+						{
+							ID:          2,
+							EventID:     5,
+							Event:       "ad.published",
+							Status:      "pending",
+							Attempts:    []attemptResponse{{At: at, StatusCode: 500, Error: "unexpected status 500", DurationMs: 15}},
+							NextAttempt: &next,
+							Created:     at,
+						},
+						{
+							ID:       1,
+							EventID:  4,
+							Event:    "ad.created",
+							Status:   "delivered",
+							Attempts: []attemptResponse{{At: at, StatusCode: 200}},
+							Created:  at,
+						},
+					},
+					"error": nil,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			tt.setMock()
+			s.c.AddParam("webhook_id", "1")
+			s.c.Request = httptest.NewRequest(http.MethodGet, "http://not.nil.url", nil)
+			handler(s.c)
+			data, _ := json.Marshal(tt.want.resp)
+			assert.Equal(s.T(), tt.want.code, s.r.Code)
+			assert.Equal(s.T(), data, s.r.Body.Bytes())
+		})
+	}
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_CreateCategory() {
+	handler := createCategory(s.a)
+
+	type want struct {
+		code int
+		resp gin.H
+	}
+	tests := []struct {
+		name    string
+		reqBody map[string]any
+		setMock func()
+		want    want
+	}{
+		{
+			name:    "forbidden error",
+			reqBody: map[string]any{"name": "Телефоны", "parent_id": 1},
+			setMock: func() {
+				s.a.
+					On("CreateCategory", mock.Anything, "Телефоны", int64(1)).
+					Return(nil, app.ErrForbidden).
+					Once()
+			},
+			want: want{
+				code: http.StatusForbidden,
+				resp: gin.H{
+					"data":  nil,
+					"error": app.ErrForbidden.Error(),
+				},
+			},
+		},
+		{
+			name:    "bad request error",
+			reqBody: map[string]any{"name": "", "parent_id": 1},
+			setMock: func() {
+				s.a.
+					On("CreateCategory", mock.Anything, "", int64(1)).
+					Return(nil, app.ErrBadRequest).
+					Once()
+			},
+			want: want{
+				code: http.StatusBadRequest,
+				resp: gin.H{
+					"data":  nil,
+					"error": app.ErrBadRequest.Error(),
+				},
+			},
+		},
+		{
+			name:    "ok",
+			reqBody: map[string]any{"name": "Телефоны", "parent_id": 1},
+			setMock: func() {
+				s.a.
+					On("CreateCategory", mock.Anything, "Телефоны", int64(1)).
+					Return(&categories.Category{ID: 2, ParentID: 1, Name: "Телефоны", Version: 1}, nil).
+					Once()
+			},
+			want: want{
+				code: http.StatusOK,
+				resp: gin.H{
+					"data":  categoryResponse{ID: 2, ParentID: 1, Name: "Телефоны"},
+					"error": nil,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			tt.setMock()
+			s.setReqBody(http.MethodPost, tt.reqBody)
+			handler(s.c)
+			data, _ := json.Marshal(tt.want.resp)
+			assert.Equal(s.T(), tt.want.code, s.r.Code)
+			assert.Equal(s.T(), data, s.r.Body.Bytes())
+		})
+	}
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_UpdateCategory() {
+	handler := updateCategory(s.a)
+
+	s.Run("precondition failed", func() {
+		s.a.
+			On("UpdateCategory", mock.Anything, int64(2), int64(1), "Смартфоны", int64(1)).
+			Return(nil, app.ErrConflict).
+			Once()
+
+		s.c.AddParam("category_id", "2")
+		s.setReqBody(http.MethodPut, map[string]any{"name": "Смартфоны", "parent_id": 1})
+		s.c.Request.Header.Set("If-Match", `"1"`)
+		handler(s.c)
+		assert.Equal(s.T(), http.StatusPreconditionFailed, s.r.Code)
+	})
+
+	s.Run("ok", func() {
+		s.a.
+			On("UpdateCategory", mock.Anything, int64(2), int64(0), "Смартфоны", int64(1)).
+			Return(&categories.Category{ID: 2, ParentID: 1, Name: "Смартфоны", Version: 2}, nil).
+			Once()
+
+		s.c.AddParam("category_id", "2")
+		s.setReqBody(http.MethodPut, map[string]any{"name": "Смартфоны", "parent_id": 1})
+		handler(s.c)
+		assert.Equal(s.T(), http.StatusOK, s.r.Code)
+		assert.Equal(s.T(), `"2"`, s.r.Header().Get("ETag"))
+	})
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ListCategories() {
+	handler := listCategories(s.a)
+
+	s.Run("ok", func() {
+		s.a.
+			On("Categories", mock.Anything).
+			Return(categories.NewTree([]*categories.Category{
+				{ID: 1, Name: "Электроника"},
+				{ID: 2, ParentID: 1, Name: "Телефоны"},
+			}), nil).
+			Once()
+
+		s.setReqBody(http.MethodGet, nil)
+		handler(s.c)
+		data, _ := json.Marshal(gin.H{
+			"data": []categoryResponse{
+				{ID: 1, Name: "Электроника", Path: []string{"Электроника"}},
+				{ID: 2, ParentID: 1, Name: "Телефоны", Path: []string{"Электроника", "Телефоны"}},
+			},
+			"error": nil,
+		})
+		assert.Equal(s.T(), http.StatusOK, s.r.Code)
+		assert.Equal(s.T(), data, s.r.Body.Bytes())
+	})
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ListFavorites() {
+	handler := listFavorites(s.a)
+
+	s.Run("forbidden error", func() {
+		s.a.
+			On("Favorites", mock.Anything, int64(2)).
+			Return(nil, app.ErrForbidden).
+			Once()
+
+		s.c.AddParam("user_id", "2")
+		s.setReqBody(http.MethodGet, nil)
+		handler(s.c)
+		data, _ := json.Marshal(ErrorResponse(app.ErrForbidden))
+		assert.Equal(s.T(), http.StatusForbidden, s.r.Code)
+		assert.Equal(s.T(), data, s.r.Body.Bytes())
+	})
+
+	s.Run("ok", func() {
+		s.a.
+			On("Favorites", mock.Anything, int64(1)).
+			Return([]*ads.Ad{{ID: 5, Title: "title", Text: "text", Favorites: 2}}, nil).
+			Once()
+
+		s.c.AddParam("user_id", "1")
+		s.setReqBody(http.MethodGet, nil)
+		handler(s.c)
+		data, _ := json.Marshal(gin.H{
+			"data":  []adResponse{{ID: 5, Title: "title", Text: "text", Favorites: 2}},
+			"error": nil,
+		})
+		assert.Equal(s.T(), http.StatusOK, s.r.Code)
+		assert.Equal(s.T(), data, s.r.Body.Bytes())
+	})
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ChangeFavorite() {
+	type want struct {
+		code int
+		resp gin.H
+	}
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		adID    string
+		setMock func()
+		want    want
+	}{
+		{
+			name:    "bad ad id",
+			handler: addFavorite(s.a),
+			adID:    "five",
+			setMock: func() {},
+			want: want{
+				code: http.StatusBadRequest,
+				resp: ErrorResponse(&strconv.NumError{Func: "Atoi", Num: "five", Err: strconv.ErrSyntax}),
+			},
+		},
+		{
+			name:    "unauthorized error",
+			handler: addFavorite(s.a),
+			adID:    "5",
+			setMock: func() {
+				s.a.
+					On("AddFavorite", mock.Anything, int64(1), int64(5)).
+					Return(nil, app.ErrUnauthorized).
+					Once()
+			},
+			want: want{
+				code: http.StatusUnauthorized,
+				resp: ErrorResponse(app.ErrUnauthorized),
+			},
+		},
+		{
+			name:    "ok add",
+			handler: addFavorite(s.a),
+			adID:    "5",
+			setMock: func() {
+				s.a.
+					On("AddFavorite", mock.Anything, int64(1), int64(5)).
+					Return(&ads.Ad{ID: 5, Title: "title", Text: "text", Favorites: 1}, nil).
+					Once()
+			},
+			want: want{
+				code: http.StatusOK,
+				resp: AdSuccessResponse(&ads.Ad{ID: 5, Title: "title", Text: "text", Favorites: 1}),
+			},
+		},
+		{
+			name:    "bad request error on delete",
+			handler: deleteFavorite(s.a),
+			adID:    "5",
+			setMock: func() {
+				s.a.
+					On("DeleteFavorite", mock.Anything, int64(1), int64(5)).
+					Return(nil, app.ErrBadRequest).
+					Once()
+			},
+			want: want{
+				code: http.StatusBadRequest,
+				resp: ErrorResponse(app.ErrBadRequest),
+			},
+		},
+		{
+			name:    "ok delete",
+			handler: deleteFavorite(s.a),
+			adID:    "5",
+			setMock: func() {
+				s.a.
+					On("DeleteFavorite", mock.Anything, int64(1), int64(5)).
+					Return(&ads.Ad{ID: 5, Title: "title", Text: "text"}, nil).
+					Once()
+			},
+			want: want{
+				code: http.StatusOK,
+				resp: AdSuccessResponse(&ads.Ad{ID: 5, Title: "title", Text: "text"}),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			tt.setMock()
+			s.c.AddParam("user_id", "1")
+			s.c.AddParam("ad_id", tt.adID)
+			s.setReqBody(http.MethodPut, nil)
+			tt.handler(s.c)
+			data, _ := json.Marshal(tt.want.resp)
+			assert.Equal(s.T(), tt.want.code, s.r.Code)
+			assert.Equal(s.T(), data, s.r.Body.Bytes())
+		})
+	}
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ContactSeller() {
+	handler := contactSeller(s.a)
+	sent := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	s.Run("bad request error", func() {
+		s.a.
+			On("ContactSeller", mock.Anything, int64(5), "").
+			Return(nil, app.ErrBadRequest).
+			Once()
+
+		s.c.AddParam("ad_id", "5")
+		s.setReqBody(http.MethodPost, map[string]any{"text": ""})
+		handler(s.c)
+		data, _ := json.Marshal(ErrorResponse(app.ErrBadRequest))
+		assert.Equal(s.T(), http.StatusBadRequest, s.r.Code)
+		assert.Equal(s.T(), data, s.r.Body.Bytes())
+	})
+
+	s.Run("ok", func() {
+		m := &messages.Message{ID: 1, ThreadID: 3, SenderID: 2, Text: "Здравствуйте", Sent: sent}
+		s.a.
+			On("ContactSeller", mock.Anything, int64(5), "Здравствуйте").
+			Return(m, nil).
+			Once()
+
+		s.c.AddParam("ad_id", "5")
+		s.setReqBody(http.MethodPost, map[string]any{"text": "Здравствуйте"})
+		handler(s.c)
+		data, _ := json.Marshal(gin.H{
+			"data":  messageResponse{ID: 1, ThreadID: 3, SenderID: 2, Text: "Здравствуйте", Sent: sent},
+			"error": nil,
+		})
+		assert.Equal(s.T(), http.StatusOK, s.r.Code)
+		assert.Equal(s.T(), data, s.r.Body.Bytes())
+	})
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_SendMessage() {
+	handler := sendMessage(s.a)
+
+	s.Run("forbidden error", func() {
+		s.a.
+			On("SendMessage", mock.Anything, int64(3), "Да").
+			Return(nil, app.ErrForbidden).
+			Once()
+
+		s.c.AddParam("thread_id", "3")
+		s.setReqBody(http.MethodPost, map[string]any{"text": "Да"})
+		handler(s.c)
+		data, _ := json.Marshal(ErrorResponse(app.ErrForbidden))
+		assert.Equal(s.T(), http.StatusForbidden, s.r.Code)
+		assert.Equal(s.T(), data, s.r.Body.Bytes())
+	})
+
+	s.Run("ok", func() {
+		m := &messages.Message{ID: 2, ThreadID: 3, SenderID: 1, Text: "Да"}
+		s.a.
+			On("SendMessage", mock.Anything, int64(3), "Да").
+			Return(m, nil).
+			Once()
+
+		s.c.AddParam("thread_id", "3")
+		s.setReqBody(http.MethodPost, map[string]any{"text": "Да"})
+		handler(s.c)
+		data, _ := json.Marshal(MessageSuccessResponse(m))
+		assert.Equal(s.T(), http.StatusOK, s.r.Code)
+		assert.Equal(s.T(), data, s.r.Body.Bytes())
+	})
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ListThreads() {
+	handler := listThreads(s.a)
+
+	s.Run("forbidden error", func() {
+		s.a.
+			On("Threads", mock.Anything, int64(2)).
+			Return(nil, app.ErrForbidden).
+			Once()
+
+		s.c.AddParam("user_id", "2")
+		s.setReqBody(http.MethodGet, nil)
+		handler(s.c)
+		data, _ := json.Marshal(ErrorResponse(app.ErrForbidden))
+		assert.Equal(s.T(), http.StatusForbidden, s.r.Code)
+		assert.Equal(s.T(), data, s.r.Body.Bytes())
+	})
+
+	s.Run("ok", func() {
+		s.a.
+			On("Threads", mock.Anything, int64(1)).
+			Return([]*messages.Thread{
+				{ID: 4, AdID: 7, BuyerID: 1, SellerID: 2, BuyerUnread: 2, SellerUnread: 5},
+				{ID: 3, AdID: 5, BuyerID: 3, SellerID: 1, BuyerUnread: 1, SellerUnread: 1},
+			}, nil).
+			Once()
+
+		s.c.AddParam("user_id", "1")
+		s.setReqBody(http.MethodGet, nil)
+		handler(s.c)
+		data, _ := json.Marshal(gin.H{
+			"data": []threadResponse{
+				{ID: 4, AdID: 7, BuyerID: 1, SellerID: 2, Unread: 2},
+				{ID: 3, AdID: 5, BuyerID: 3, SellerID: 1, Unread: 1},
+			},
+			"unread_total": 3,
+			"error":        nil,
+		})
+		assert.Equal(s.T(), http.StatusOK, s.r.Code)
+		assert.Equal(s.T(), data, s.r.Body.Bytes())
+	})
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ListMessages() {
+	handler := listMessages(s.a)
+
+	s.Run("bad request error", func() {
+		s.a.
+			On("Messages", mock.Anything, int64(3), messages.Page{Cursor: "bad"}).
+			Return(nil, "", app.ErrBadRequest).
+			Once()
+
+		s.c.AddParam("thread_id", "3")
+		s.c.Request = httptest.NewRequest(http.MethodGet, "http://not.nil.url?cursor=bad", nil)
+		handler(s.c)
+		data, _ := json.Marshal(ErrorResponse(app.ErrBadRequest))
+		assert.Equal(s.T(), http.StatusBadRequest, s.r.Code)
+		assert.Equal(s.T(), data, s.r.Body.Bytes())
+	})
+
+	s.Run("ok", func() {
+		list := []*messages.Message{{ID: 2, ThreadID: 3, SenderID: 1, Text: "Да"}}
+		s.a.
+			On("Messages", mock.Anything, int64(3), messages.Page{Limit: 1}).
+			Return(list, "MQ", nil).
+			Once()
+
+		s.c.AddParam("thread_id", "3")
+		s.c.Request = httptest.NewRequest(http.MethodGet, "http://not.nil.url?limit=1", nil)
+		handler(s.c)
+		data, _ := json.Marshal(gin.H{
+			"data":        []messageResponse{{ID: 2, ThreadID: 3, SenderID: 1, Text: "Да"}},
+			"next_cursor": "MQ",
+			"error":       nil,
+		})
+		assert.Equal(s.T(), http.StatusOK, s.r.Code)
+		assert.Equal(s.T(), data, s.r.Body.Bytes())
+	})
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_CreateSavedSearch() {
+	handler := createSavedSearch(s.a)
+	request := func(query string, name string) {
+		data, _ := json.Marshal(map[string]any{"name": name})
+		s.c.Request = httptest.NewRequest(http.MethodPost, "http://not.nil.url"+query, bytes.NewReader(data))
+		s.c.Request.Header.Add("Content-Type", "application/json")
+	}
+
+	s.Run("radius without center", func() {
+		s.c.AddParam("user_id", "1")
+		request("?radius=5", "Рядом")
+		handler(s.c)
+		assert.Equal(s.T(), http.StatusBadRequest, s.r.Code)
+	})
+
+	s.Run("bad request error", func() {
+		s.a.
+			On("CreateSavedSearch", mock.Anything, int64(1), "", mock.Anything).
+			Return(nil, app.ErrBadRequest).
+			Once()
+
+		s.c.AddParam("user_id", "1")
+		request("", "")
+		handler(s.c)
+		data, _ := json.Marshal(ErrorResponse(app.ErrBadRequest))
+		assert.Equal(s.T(), http.StatusBadRequest, s.r.Code)
+		assert.Equal(s.T(), data, s.r.Body.Bytes())
+	})
+
+	s.Run("ok", func() {
+		published := true
+		q := ads.Query{Query: "iphone", Published: &published, Category: 2, Statuses: []ads.Status{ads.StatusPublished}}
+		s.a.
+			On("CreateSavedSearch", mock.Anything, int64(1), "Айфоны", q).
+			Return(&searches.Search{ID: 3, UserID: 1, Name: "Айфоны", Query: q}, nil).
+			Once()
+
+		s.c.AddParam("user_id", "1")
+		request("?q=iphone&published=true&category=2&status=published", "Айфоны")
+		handler(s.c)
+		data, _ := json.Marshal(gin.H{
+			"data": savedSearchResponse{
+				ID:     3,
+				UserID: 1,
+				Name:   "Айфоны",
+				Query:  searchQueryResponse{Query: "iphone", Published: &published, Category: 2, Status: "published"},
+			},
+			"error": nil,
+		})
+		assert.Equal(s.T(), http.StatusOK, s.r.Code)
+		assert.Equal(s.T(), data, s.r.Body.Bytes())
+	})
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_DeleteSavedSearch() {
+	handler := deleteSavedSearch(s.a)
+
+	s.Run("bad search_id", func() {
+		s.c.AddParam("user_id", "1")
+		s.c.AddParam("search_id", "first")
+		s.setReqBody(http.MethodDelete, nil)
+		handler(s.c)
+		assert.Equal(s.T(), http.StatusBadRequest, s.r.Code)
+	})
+
+	s.Run("forbidden error", func() {
+		s.a.
+			On("DeleteSavedSearch", mock.Anything, int64(2), int64(3)).
+			Return(nil, app.ErrForbidden).
+			Once()
+
+		s.c.AddParam("user_id", "2")
+		s.c.AddParam("search_id", "3")
+		s.setReqBody(http.MethodDelete, nil)
+		handler(s.c)
+		assert.Equal(s.T(), http.StatusForbidden, s.r.Code)
+	})
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ListNotifications() {
+	handler := listNotifications(s.a)
+	created := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	s.Run("ok", func() {
+		s.a.
+			On("Notifications", mock.Anything, int64(1)).
+			Return([]*searches.Notification{
+				{ID: 2, UserID: 1, SearchID: 3, AdID: 7, Title: "iPhone", Created: created},
+				{ID: 1, UserID: 1, SearchID: 3, AdID: 5, Title: "iPhone 12", Created: created, Read: true},
+			}, 1, nil).
+			Once()
+
+		s.c.AddParam("user_id", "1")
+		s.setReqBody(http.MethodGet, nil)
+		handler(s.c)
+		data, _ := json.Marshal(gin.H{
+			"data": []notificationResponse{
+				{ID: 2, SearchID: 3, AdID: 7, Title: "iPhone", Created: created},
+				{ID: 1, SearchID: 3, AdID: 5, Title: "iPhone 12", Created: created, Read: true},
+			},
+			"unread_total": 1,
+			"error":        nil,
+		})
+		assert.Equal(s.T(), http.StatusOK, s.r.Code)
+		assert.Equal(s.T(), data, s.r.Body.Bytes())
+	})
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINHandlers_ReadNotifications() {
+	handler := readNotifications(s.a)
+
+	s.Run("bad request error", func() {
+		s.a.
+			On("ReadNotifications", mock.Anything, int64(1), int64(0)).
+			Return(app.ErrBadRequest).
+			Once()
+
+		s.c.AddParam("user_id", "1")
+		s.setReqBody(http.MethodPost, map[string]any{})
+		handler(s.c)
+		assert.Equal(s.T(), http.StatusBadRequest, s.r.Code)
+	})
+
+	s.Run("ok", func() {
+		s.a.
+			On("ReadNotifications", mock.Anything, int64(1), int64(2)).
+			Return(nil).
+			Once()
+		s.a.
+			On("Notifications", mock.Anything, int64(1)).
+			Return([]*searches.Notification{{ID: 2, UserID: 1, SearchID: 3, AdID: 7, Read: true}}, 0, nil).
+			Once()
+
+		s.c.AddParam("user_id", "1")
+		s.setReqBody(http.MethodPost, map[string]any{"up_to": 2})
+		handler(s.c)
+		data, _ := json.Marshal(gin.H{
+			"data":         []notificationResponse{{ID: 2, SearchID: 3, AdID: 7, Read: true}},
+			"unread_total": 0,
+			"error":        nil,
+		})
+		assert.Equal(s.T(), http.StatusOK, s.r.Code)
+		assert.Equal(s.T(), data, s.r.Body.Bytes())
+	})
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINMiddleware_RequestLogger() {
+	var out bytes.Buffer
+	router := gin.New()
+	router.ContextWithFallback = true
+	router.Use(requestLogger(logging.New(&out, slog.LevelInfo)), recovery(logging.New(&out, slog.LevelInfo)))
+	var seen string
+	router.GET("/ads/:ad_id", func(c *gin.Context) {
+		// ID запроса есть в контексте обработчика и попадает в записи, сделанные с ним
+		seen, _ = logging.RequestID(c)
+		c.Status(http.StatusNotFound)
+	})
+	router.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	r := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/ads/5", nil)
+	req.Header.Set(HeaderRequestID, "client-id-1")
+	router.ServeHTTP(r, req)
+	assert.Equal(s.T(), "client-id-1", r.Header().Get(HeaderRequestID))
+	assert.Equal(s.T(), "client-id-1", seen)
+
+	var record map[string]any
+	assert.NoError(s.T(), json.Unmarshal(out.Bytes(), &record))
+	assert.Equal(s.T(), "WARN", record["level"])
+	assert.Equal(s.T(), "client-id-1", record["request_id"])
+	assert.Equal(s.T(), "/ads/:ad_id", record["route"])
+	assert.Equal(s.T(), float64(http.StatusNotFound), record["status"])
+
+	// недопустимый ID клиента заменяется сгенерированным
+	out.Reset()
+	r = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set(HeaderRequestID, "bad id\n")
+	router.ServeHTTP(r, req)
+	assert.Equal(s.T(), http.StatusInternalServerError, r.Code)
+	id := r.Header().Get(HeaderRequestID)
+	assert.Len(s.T(), id, 32)
+	// и паника, и сам запрос пишутся с ID запроса
+	assert.Contains(s.T(), out.String(), `"msg":"panic","panic":"boom"`)
+	assert.Contains(s.T(), out.String(), `"msg":"request","method":"GET","path":"/panic"`)
+	assert.Equal(s.T(), 2, strings.Count(out.String(), `"request_id":"`+id+`"`))
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINMiddleware_Instrument() {
+	m := metrics.New()
+	router := gin.New()
+	router.Use(instrument(m))
+	router.GET("/ads/:ad_id", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/ads/1", "/ads/2", "/unknown"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	r := httptest.NewRecorder()
+	m.Handler().ServeHTTP(r, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	// запросы считаются по шаблону маршрута, а не по пути
+	assert.Contains(s.T(), r.Body.String(), `adapp_http_requests_total{code="200",method="GET",route="/ads/:ad_id"} 2`)
+	assert.Contains(s.T(), r.Body.String(), `adapp_http_requests_total{code="404",method="GET",route=""} 1`)
+}
+
+func (s *HTTPGINTestSuite) TestHTTPGINMiddleware_Tracer() {
+	tp, sr := tracing.NewRecorder()
+	router := gin.New()
+	router.Use(tracer(tp))
+	var seen trace.SpanContext
+	router.GET("/ads/:ad_id", func(c *gin.Context) {
+		seen = trace.SpanContextFromContext(c.Request.Context())
+		c.Status(http.StatusInternalServerError)
+	})
+
+	// трассировка клиента продолжается: спан запроса - дочерний для спана из traceparent
+	r := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/ads/5", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(r, req)
+
+	spans := sr.Ended()
+	assert.Len(s.T(), spans, 1)
+	span := spans[0]
+	assert.Equal(s.T(), "GET /ads/:ad_id", span.Name())
+	assert.Equal(s.T(), trace.SpanKindServer, span.SpanKind())
+	assert.Equal(s.T(), "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(s.T(), "00f067aa0ba902b7", span.Parent().SpanID().String())
+	assert.Equal(s.T(), span.SpanContext(), seen)
+	assert.Equal(s.T(), codes.Error, span.Status().Code)
+	assert.Contains(s.T(), span.Attributes(), semconv.HTTPRoute("/ads/:ad_id"))
+	assert.Contains(s.T(), span.Attributes(), semconv.HTTPStatusCode(http.StatusInternalServerError))
+
+	// без traceparent начинается новая трассировка
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	spans = sr.Ended()
+	assert.Len(s.T(), spans, 2)
+	assert.Equal(s.T(), "GET", spans[1].Name())
+	assert.False(s.T(), spans[1].Parent().IsValid())
+	assert.Equal(s.T(), codes.Unset, spans[1].Status().Code)
+}
+
 func TestHTTPGINTestSuite(t *testing.T) {
 	suite.Run(t, new(HTTPGINTestSuite))
 }
@@ -0,0 +1,32 @@
+package httpgin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"homework10/internal/health"
+)
+
+// healthz отвечает 200, пока сервер обрабатывает запросы, - проверка того, что процесс жив
+func healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"data":  gin.H{"status": "ok"},
+		"error": nil,
+	})
+}
+
+// readyz отвечает 200, если сервис готов принимать запросы, и 503 с причиной, если нет
+func readyz(p *health.Probe) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := p.Ready(c.Request.Context()); err != nil {
+			c.JSON(http.StatusServiceUnavailable, ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"data":  gin.H{"status": "ready"},
+			"error": nil,
+		})
+	}
+}
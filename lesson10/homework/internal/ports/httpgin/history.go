@@ -0,0 +1,82 @@
+package httpgin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"homework10/internal/app"
+)
+
+// Метод для получения истории изменений объявления (доступен автору и модераторам)
+func adHistory(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v := c.Param("ad_id")
+		adID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		list, err := a.AdHistory(c, int64(adID))
+		if err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, HistorySuccessResponse(list))
+	}
+}
+
+// Метод для отката объявления к одному из прежних состояний (доступен только автору)
+func revertAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody revertAdRequest
+		if err := c.Bind(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		v := c.Param("ad_id")
+		adID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		version, err := ifMatchVersion(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		ad, err := a.RevertAd(c, int64(adID), version, reqBody.Revision)
+		if err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrConflict) {
+				c.JSON(conflictStatus(c), ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		setETag(c, ad.Version)
+		c.JSON(http.StatusOK, AdSuccessResponse(ad))
+	}
+}
@@ -0,0 +1,111 @@
+package httpgin
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"homework10/internal/idempotency"
+)
+
+const (
+	// HeaderIdempotencyKey - заголовок, в котором клиент передаёт ключ идемпотентности создающего запроса
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed - заголовок повторённого ответа
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+// storedResponse - сохранённый ответ на запрос с ключом идемпотентности
+type storedResponse struct {
+	code   int
+	header http.Header
+	body   []byte
+}
+
+// bodyRecorder пишет ответ клиенту и заодно запоминает тело
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotent делает создающий запрос с заголовком Idempotency-Key идемпотентным: ответ на первый запрос
+// сохраняется, и повтор с тем же ключом и тем же телом получает его с заголовком Idempotent-Replayed,
+// не создавая ничего заново. Тот же ключ с другим телом, как и повтор ещё не завершённого запроса, - 409.
+// Ответы 5xx и 429 не сохраняются: повтор после них выполняет запрос заново
+func idempotent(keys *idempotency.Store[storedResponse]) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderIdempotencyKey)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if err := idempotency.CheckKey(key); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		k := idempotency.Key(c.Request.Context(), c.Request.Method+" "+c.Request.URL.Path, c.ClientIP(), key)
+		stored, done, err := keys.Begin(k, idempotency.Fingerprint([]byte(c.Request.URL.RawQuery), body))
+		if err != nil {
+			if errors.Is(err, idempotency.ErrMismatch) || errors.Is(err, idempotency.ErrInProgress) {
+				c.AbortWithStatusJSON(http.StatusConflict, ErrorResponse(err))
+			} else {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+		if done {
+			for name, values := range stored.header {
+				c.Writer.Header()[name] = values
+			}
+			c.Header(HeaderIdempotentReplayed, "true")
+			c.Status(stored.code)
+			_, _ = c.Writer.Write(stored.body)
+			c.Abort()
+			return
+		}
+
+		// если обработчик упадёт, ключ освобождается, и повтор выполнит запрос заново
+		finished := false
+		defer func() {
+			if !finished {
+				keys.Abort(k)
+			}
+		}()
+
+		w := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		code := w.Status()
+		if code >= http.StatusInternalServerError || code == http.StatusTooManyRequests {
+			keys.Abort(k)
+		} else {
+			// у повтора свой ID запроса, поэтому заголовок с ID первого не сохраняется
+			header := w.Header().Clone()
+			header.Del(HeaderRequestID)
+			keys.Finish(k, storedResponse{code: code, header: header, body: w.body.Bytes()})
+		}
+		finished = true
+	}
+}
@@ -0,0 +1,151 @@
+package httpgin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"homework10/internal/app"
+	"homework10/internal/images"
+)
+
+// multipartOverhead - запас на заголовки multipart сверх размера самого изображения
+const multipartOverhead = 64 << 10
+
+var errImageTooLarge = fmt.Errorf("image must not exceed %d bytes", images.MaxSize)
+
+// readImage читает файл изображения из поля "image" multipart-формы
+func readImage(c *gin.Context) ([]byte, int, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, images.MaxSize+multipartOverhead)
+
+	fh, err := c.FormFile("image")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return nil, http.StatusRequestEntityTooLarge, errImageTooLarge
+	} else if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	if fh.Size > images.MaxSize {
+		return nil, http.StatusRequestEntityTooLarge, errImageTooLarge
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	return data, http.StatusOK, nil
+}
+
+// Метод для загрузки фотографии объявления (multipart/form-data, поле image)
+func addAdImage(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v := c.Param("ad_id")
+		adID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		version, err := ifMatchVersion(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		data, code, err := readImage(c)
+		if err != nil {
+			c.JSON(code, ErrorResponse(err))
+			return
+		}
+
+		ad, err := a.AddAdImage(c, int64(adID), version, data)
+		if err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrTooLarge) {
+				c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrConflict) {
+				c.JSON(conflictStatus(c), ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		setETag(c, ad.Version)
+		c.JSON(http.StatusOK, AdSuccessResponse(ad))
+	}
+}
+
+// Метод для удаления фотографии объявления
+func deleteAdImage(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v := c.Param("ad_id")
+		adID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		version, err := ifMatchVersion(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		ad, err := a.DeleteAdImage(c, int64(adID), version, c.Param("image_id"))
+		if err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrConflict) {
+				c.JSON(conflictStatus(c), ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		setETag(c, ad.Version)
+		c.JSON(http.StatusOK, AdSuccessResponse(ad))
+	}
+}
+
+// Метод, отдающий файл изображения или миниатюры; файлы адресуются по содержимому и не меняются,
+// поэтому их можно кэшировать бессрочно
+func showImage(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.Param("key")
+		data, err := a.Image(c, key)
+		if err != nil {
+			if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusNotFound, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+		c.Header("ETag", strconv.Quote(key))
+		c.Data(http.StatusOK, http.DetectContentType(data), data)
+	}
+}
@@ -1,40 +1,63 @@
 package httpgin
 
 import (
-	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
+	"runtime/debug"
+	"time"
 
 	"github.com/gin-gonic/gin"
-)
 
-const (
-	green  = "\033[97;42m"
-	yellow = "\033[90;43m"
-	red    = "\033[97;41m"
-	cyan   = "\033[97;46m"
-	reset  = "\033[0m"
+	"homework10/internal/logging"
 )
 
-func logger() gin.HandlerFunc {
+// HeaderRequestID - заголовок с ID запроса: клиент может прислать свой, иначе он генерируется;
+// в ответе заголовок есть всегда
+const HeaderRequestID = "X-Request-ID"
+
+// requestLogger присваивает запросу ID, кладёт его в контекст запроса и после ответа пишет запрос в l:
+// ответы 5xx - с уровнем ERROR, 4xx - WARN, остальные - INFO
+func requestLogger(l *slog.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		start := time.Now()
+		id := logging.RequestIDOrNew(c.GetHeader(HeaderRequestID))
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Header(HeaderRequestID, id)
+
 		c.Next()
-		log.SetPrefix("[ADAPP] - ")
-		code := fmt.Sprintf("<"+statusCodeColor(c.Writer.Status())+"%d"+reset+">", c.Writer.Status())
-		path := c.Request.URL.Path
-		log.Print("- ", code, " - "+c.Request.Method+":\t", path)
+
+		code := c.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", code),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("ip", c.ClientIP()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
+		}
+		l.LogAttrs(c.Request.Context(), statusLevel(code), "request", attrs...)
 	}
 }
 
-func statusCodeColor(code int) string {
+func statusLevel(code int) slog.Level {
 	switch {
-	case code >= http.StatusOK && code < http.StatusMultipleChoices:
-		return green
-	case code >= http.StatusMultipleChoices && code < http.StatusBadRequest:
-		return cyan
-	case code >= http.StatusBadRequest && code < http.StatusInternalServerError:
-		return yellow
+	case code >= http.StatusInternalServerError:
+		return slog.LevelError
+	case code >= http.StatusBadRequest:
+		return slog.LevelWarn
 	default:
-		return red
+		return slog.LevelInfo
 	}
 }
+
+// recovery отвечает 500 на запрос, обработчик которого запаниковал, и пишет панику в l вместе со стеком
+func recovery(l *slog.Logger) gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err any) {
+		l.ErrorContext(c.Request.Context(), "panic", slog.Any("panic", err), slog.String("stack", string(debug.Stack())))
+		c.AbortWithStatus(http.StatusInternalServerError)
+	})
+}
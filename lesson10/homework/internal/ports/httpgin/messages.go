@@ -0,0 +1,139 @@
+package httpgin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"homework10/internal/app"
+	"homework10/internal/messages"
+)
+
+// Метод для отправки сообщения автору объявления; первое сообщение начинает переписку
+func contactSeller(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody messageRequest
+		if err := c.Bind(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		v := c.Param("ad_id")
+		adID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		m, err := a.ContactSeller(c, int64(adID), reqBody.Text)
+		if err != nil {
+			if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, MessageSuccessResponse(m))
+	}
+}
+
+// Метод для отправки сообщения в переписку (доступен только её участникам)
+func sendMessage(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody messageRequest
+		if err := c.Bind(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		v := c.Param("thread_id")
+		threadID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		m, err := a.SendMessage(c, int64(threadID), reqBody.Text)
+		if err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, MessageSuccessResponse(m))
+	}
+}
+
+// Метод для получения переписок пользователя с количеством непрочитанных сообщений (доступен только ему самому)
+func listThreads(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v := c.Param("user_id")
+		userID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		threads, err := a.Threads(c, int64(userID))
+		if err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, ThreadsSuccessResponse(threads, int64(userID)))
+	}
+}
+
+// Метод для получения страницы сообщений переписки; заодно отмечает переписку прочитанной
+func listMessages(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqParams listMessagesRequest
+		if err := c.Bind(&reqParams); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		v := c.Param("thread_id")
+		threadID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		page := messages.Page{Limit: reqParams.Limit, Cursor: reqParams.Cursor}
+		list, next, err := a.Messages(c, int64(threadID), page)
+		if err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, MessagesSuccessResponse(list, next))
+	}
+}
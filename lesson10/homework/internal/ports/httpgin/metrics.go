@@ -0,0 +1,20 @@
+package httpgin
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"homework10/internal/metrics"
+)
+
+// instrument учитывает в m каждый запрос: число запросов по маршруту и коду ответа и время ответа.
+// Маршрут - шаблон пути (c.FullPath), а не сам путь, чтобы ID в пути не плодили метрики; у запросов
+// к несуществующим маршрутам он пустой
+func instrument(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		m.ObserveHTTP(c.Request.Method, c.FullPath(), c.Writer.Status(), time.Since(start))
+	}
+}
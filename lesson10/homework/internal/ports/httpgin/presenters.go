@@ -1,59 +1,285 @@
 package httpgin
 
 import (
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"homework10/internal/ads"
+	"homework10/internal/audit"
+	"homework10/internal/auth"
+	"homework10/internal/categories"
+	"homework10/internal/events"
+	"homework10/internal/images"
+	"homework10/internal/messages"
+	"homework10/internal/searches"
 	"homework10/internal/users"
+	"homework10/internal/webhooks"
 )
 
 type createAdRequest struct {
-	Title  string `json:"title"`
-	Text   string `json:"text"`
-	UserID int64  `json:"user_id"`
+	Title      string           `json:"title"`
+	Text       string           `json:"text"`
+	CategoryID int64            `json:"category_id"`
+	Price      int64            `json:"price"`    // в минимальных единицах валюты (копейках, центах)
+	Currency   string           `json:"currency"` // код ISO 4217, пустой - цена не указана
+	Location   *locationRequest `json:"location"` // nil - местоположение не указано
+	TTL        int64            `json:"ttl"`      // срок жизни в секундах, 0 - по умолчанию
+}
+
+type locationRequest struct {
+	Lat  float64 `json:"lat"`
+	Lon  float64 `json:"lon"`
+	City string  `json:"city"`
+}
+
+type locationResponse struct {
+	Lat  float64 `json:"lat"`
+	Lon  float64 `json:"lon"`
+	City string  `json:"city"`
 }
 
 type adResponse struct {
-	ID        int64  `json:"id"`
-	Title     string `json:"title"`
-	Text      string `json:"text"`
-	AuthorID  int64  `json:"author_id"`
-	Published bool   `json:"published"`
+	ID            int64             `json:"id"`
+	Title         string            `json:"title"`
+	Text          string            `json:"text"`
+	AuthorID      int64             `json:"author_id"`
+	CategoryID    int64             `json:"category_id,omitempty"` // 0 - объявление создано до появления категорий
+	Price         int64             `json:"price"`
+	Currency      string            `json:"currency,omitempty"` // пустая - цена не указана
+	Published     bool              `json:"published"`
+	Status        string            `json:"status"`
+	StatusReason  string            `json:"status_reason,omitempty"` // причина последней смены статуса, например отклонения
+	StatusChanged time.Time         `json:"status_changed"`
+	Expires       *time.Time        `json:"expires,omitempty"` // nil - бессрочное
+	Images        []imageResponse   `json:"images,omitempty"`
+	Favorites     int               `json:"favorites"` // у скольких пользователей объявление в избранном
+	Location      *locationResponse `json:"location,omitempty"`
+	Distance      *float64          `json:"distance,omitempty"` // в километрах от центра поиска, только при поиске по радиусу
+}
+
+type adEventResponse struct {
+	ID      int64      `json:"id"`
+	Type    string     `json:"type"`
+	At      time.Time  `json:"at"`
+	ActorID int64      `json:"actor_id"` // -1 - сам сервис
+	Ad      adResponse `json:"ad"`       // после события, у удаления - до него; favorites не заполняется
+}
+
+type imageResponse struct {
+	ID           string `json:"id"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	ContentType  string `json:"content_type"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
 }
 
 type changeAdStatusRequest struct {
-	Published bool  `json:"published"`
-	UserID    int64 `json:"user_id"`
+	Published bool `json:"published"`
+}
+
+type renewAdRequest struct {
+	TTL int64 `json:"ttl"` // на сколько секунд продлить, 0 - на срок по умолчанию
+}
+
+type revertAdRequest struct {
+	Revision int64 `json:"revision"` // номер изменения из истории объявления
+}
+
+type historyEntryResponse struct {
+	ID       int64       `json:"id"`
+	Revision int64       `json:"revision"`
+	ActorID  int64       `json:"actor_id"` // -1 - изменение сделал сам сервис
+	Op       string      `json:"op"`
+	At       time.Time   `json:"at"`
+	Before   *adResponse `json:"before"` // null - объявления ещё не было
+	After    *adResponse `json:"after"`  // null - объявление удалено
+}
+
+type transitionAdRequest struct {
+	Event  string `json:"event"`
+	Reason string `json:"reason"`
 }
 
 type updateAdRequest struct {
-	Title  string `json:"title"`
-	Text   string `json:"text"`
-	UserID int64  `json:"user_id"`
+	Title    string           `json:"title"`
+	Text     string           `json:"text"`
+	Price    *int64           `json:"price"`    // цена меняется, только если задана цена или валюта
+	Currency *string          `json:"currency"` // незаданная часть цены при этом сбрасывается
+	Location *locationRequest `json:"location"` // заменяет местоположение целиком, nil - не менять
+	// ClearLocation убирает местоположение; вместе с location - ошибка
+	ClearLocation bool `json:"clear_location"`
+}
+
+// price возвращает новую цену или nil, если цена в запросе не задана
+func (r *updateAdRequest) price() *ads.Price {
+	if r.Price == nil && r.Currency == nil {
+		return nil
+	}
+	var p ads.Price
+	if r.Price != nil {
+		p.Amount = *r.Price
+	}
+	if r.Currency != nil {
+		p.Currency = *r.Currency
+	}
+	return &p
 }
 
 type listAdsRequest struct {
 	Title     string    `form:"title"`
 	UserID    int64     `form:"user_id"`
 	Published bool      `form:"published"`
+	Status    string    `form:"status"`   // статусы через запятую
+	Category  int64     `form:"category"` // категория вместе с подкатегориями
+	PriceMin  int64     `form:"price_min"`
+	PriceMax  int64     `form:"price_max"`
+	Currency  string    `form:"currency"`
+	Lat       float64   `form:"lat"`
+	Lon       float64   `form:"lon"`
+	Radius    float64   `form:"radius"` // в километрах, вместе с lat и lon
 	Created   time.Time `form:"created" time_format:"2006-01-02T15:04:05"`
+	Limit     int       `form:"limit"`
+	Cursor    string    `form:"cursor"`
+	Sort      string    `form:"sort"`
+	Query     string    `form:"q"`
+	Filter    string    `form:"filter"`
+}
+
+type categoryRequest struct {
+	Name     string `json:"name"`
+	ParentID int64  `json:"parent_id"` // 0 - категория верхнего уровня
+}
+
+type categoryResponse struct {
+	ID       int64    `json:"id"`
+	ParentID int64    `json:"parent_id"`
+	Name     string   `json:"name"`
+	Path     []string `json:"path,omitempty"` // имена от категории верхнего уровня до этой, только в списке категорий
+}
+
+type webhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"` // пустой - все события
+}
+
+type webhookResponse struct {
+	ID      int64     `json:"id"`
+	URL     string    `json:"url"`
+	Events  []string  `json:"events"`
+	Secret  string    `json:"secret,omitempty"` // только в ответе на создание подписки
+	Created time.Time `json:"created"`
+}
+
+type deliveryResponse struct {
+	ID          int64             `json:"id"`
+	EventID     int64             `json:"event_id"`
+	Event       string            `json:"event"`
+	Status      string            `json:"status"`
+	Attempts    []attemptResponse `json:"attempts"`
+	NextAttempt *time.Time        `json:"next_attempt"` // null - попыток больше не будет
+	Created     time.Time         `json:"created"`
+}
+
+type attemptResponse struct {
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"status_code"` // 0 - ответа не было
+	Error      string    `json:"error"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+type categoryCountResponse struct {
+	ID    int64    `json:"id"`
+	Name  string   `json:"name"`
+	Path  []string `json:"path"`
+	Count int      `json:"count"`
+}
+
+type messageRequest struct {
+	Text string `json:"text"`
+}
+
+type listMessagesRequest struct {
+	Limit  int    `form:"limit"`
+	Cursor string `form:"cursor"`
+}
+
+type messageResponse struct {
+	ID       int64     `json:"id"`
+	ThreadID int64     `json:"thread_id"`
+	SenderID int64     `json:"sender_id"`
+	Text     string    `json:"text"`
+	Sent     time.Time `json:"sent"`
+}
+
+type threadResponse struct {
+	ID       int64     `json:"id"`
+	AdID     int64     `json:"ad_id"`
+	BuyerID  int64     `json:"buyer_id"`
+	SellerID int64     `json:"seller_id"`
+	Updated  time.Time `json:"updated"`
+	Unread   int       `json:"unread"` // сколько сообщений собеседника пользователь ещё не прочитал
+}
+
+// savedSearchRequest - тело запроса сохранённого поиска; условия выборки передаются в строке запроса,
+// теми же параметрами, что и при получении списка объявлений
+type savedSearchRequest struct {
+	Name string `json:"name"`
+}
+
+type savedSearchResponse struct {
+	ID      int64               `json:"id"`
+	UserID  int64               `json:"user_id"`
+	Name    string              `json:"name"`
+	Query   searchQueryResponse `json:"query"`
+	Created time.Time           `json:"created"`
+	Updated time.Time           `json:"updated"`
+}
+
+// searchQueryResponse - условия сохранённого поиска под именами параметров списка объявлений; незаданные опущены
+type searchQueryResponse struct {
+	Query     string     `json:"q,omitempty"`
+	Filter    string     `json:"filter,omitempty"`
+	Title     *string    `json:"title,omitempty"`
+	Created   *time.Time `json:"created,omitempty"`
+	UserID    *int64     `json:"user_id,omitempty"`
+	Published *bool      `json:"published,omitempty"`
+	Status    string     `json:"status,omitempty"`
+	Category  int64      `json:"category,omitempty"`
+	PriceMin  *int64     `json:"price_min,omitempty"`
+	PriceMax  *int64     `json:"price_max,omitempty"`
+	Currency  string     `json:"currency,omitempty"`
+	Lat       *float64   `json:"lat,omitempty"`
+	Lon       *float64   `json:"lon,omitempty"`
+	Radius    *float64   `json:"radius,omitempty"`
+}
+
+type readNotificationsRequest struct {
+	UpTo int64 `json:"up_to"` // прочитаны уведомления с ID не больше up_to
 }
 
-type deleteAdRequest struct {
-	UserID int64 `json:"user_id"`
+type notificationResponse struct {
+	ID       int64     `json:"id"`
+	SearchID int64     `json:"search_id"`
+	AdID     int64     `json:"ad_id"`
+	Title    string    `json:"title"` // заголовок объявления на момент уведомления
+	Created  time.Time `json:"created"`
+	Read     bool      `json:"read"`
 }
 
 type createUserRequest struct {
 	Nickname string `json:"nickname"`
 	Email    string `json:"email" `
+	Password string `json:"password"`
 }
 
 type userResponse struct {
 	ID       int64  `json:"id"`
 	Nickname string `json:"nickname"`
 	Email    string `json:"email"`
+	Role     string `json:"role"`
 }
 
 type updateUserRequest struct {
@@ -61,19 +287,121 @@ type updateUserRequest struct {
 	Email    string `json:"email"` // про тег  binding:"email" знаю, просто решил реализовать свою валидацию email в пакете vld
 }
 
+type changeUserRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type loginRequest struct {
+	UserID   int64  `json:"user_id"`
+	Password string `json:"password"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (l *locationRequest) location() *ads.Location {
+	if l == nil {
+		return nil
+	}
+	return &ads.Location{Point: ads.Point{Lat: l.Lat, Lon: l.Lon}, City: l.City}
+}
+
+type tokensResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"` // время жизни access-токена в секундах
+}
+
+func newAdResponse(ad *ads.Ad) adResponse {
+	res := adResponse{
+		ID:            ad.ID,
+		Title:         ad.Title,
+		Text:          ad.Text,
+		AuthorID:      ad.UserID,
+		CategoryID:    ad.CategoryID,
+		Price:         ad.Price.Amount,
+		Currency:      ad.Price.Currency,
+		Published:     ad.Published(),
+		Status:        string(ad.Status),
+		StatusChanged: ad.Created,
+		Favorites:     ad.Favorites,
+		Distance:      ad.Distance,
+	}
+	if ad.Location != nil {
+		res.Location = &locationResponse{Lat: ad.Location.Lat, Lon: ad.Location.Lon, City: ad.Location.City}
+	}
+	if t := ad.LastTransition(); t != nil {
+		res.StatusReason = t.Reason
+		res.StatusChanged = t.At
+	}
+	if !ad.Expires.IsZero() {
+		expires := ad.Expires
+		res.Expires = &expires
+	}
+	for _, img := range ad.Images {
+		res.Images = append(res.Images, imageResponse{
+			ID:           img.ID,
+			URL:          images.URL(img.ID),
+			ThumbnailURL: images.URL(img.Thumb),
+			ContentType:  img.ContentType,
+			Width:        img.Width,
+			Height:       img.Height,
+		})
+	}
+	return res
+}
+
 func AdSuccessResponse(ad *ads.Ad) gin.H {
 	return gin.H{
-		"data": adResponse{
-			ID:        ad.ID,
-			Title:     ad.Title,
-			Text:      ad.Text,
-			AuthorID:  ad.UserID,
-			Published: ad.Published,
+		"data":  newAdResponse(ad),
+		"error": nil,
+	}
+}
+
+// AdEventResponse - событие из потока изменений объявлений
+func AdEventResponse(e *events.Event) gin.H {
+	return gin.H{
+		"data": adEventResponse{
+			ID:      e.ID,
+			Type:    string(e.Type),
+			At:      e.At,
+			ActorID: e.ActorID,
+			Ad:      newAdResponse(e.Ad),
 		},
 		"error": nil,
 	}
 }
 
+// HistorySuccessResponse - изменения объявления в порядке их выполнения
+func HistorySuccessResponse(list []*audit.Entry) gin.H {
+	response := make([]historyEntryResponse, 0, len(list))
+	for _, e := range list {
+		entry := historyEntryResponse{
+			ID:       e.ID,
+			Revision: e.Revision,
+			ActorID:  e.ActorID,
+			Op:       string(e.Op),
+			At:       e.At,
+		}
+		if e.Before.Ad != nil {
+			before := newAdResponse(e.Before.Ad)
+			entry.Before = &before
+		}
+		if e.After.Ad != nil {
+			after := newAdResponse(e.After.Ad)
+			entry.After = &after
+		}
+		response = append(response, entry)
+	}
+
+	return gin.H{
+		"data":  response,
+		"error": nil,
+	}
+}
+
 func ErrorResponse(err error) gin.H {
 	return gin.H{
 		"data":  nil,
@@ -81,30 +409,307 @@ func ErrorResponse(err error) gin.H {
 	}
 }
 
-func AdsSuccessResponse(a []*ads.Ad) gin.H {
+// AdsSuccessResponse - страница объявлений и количества всех подходящих под запрос объявлений по категориям
+func AdsSuccessResponse(a []*ads.Ad, nextCursor string, counts []categories.Count) gin.H {
 	var response []adResponse
 	for i := range a {
-		response = append(response, adResponse{
-			ID:        a[i].ID,
-			Title:     a[i].Title,
-			Text:      a[i].Text,
-			AuthorID:  a[i].UserID,
-			Published: a[i].Published,
+		response = append(response, newAdResponse(a[i]))
+	}
+
+	countsResponse := make([]categoryCountResponse, 0, len(counts))
+	for _, cnt := range counts {
+		countsResponse = append(countsResponse, categoryCountResponse{
+			ID:    cnt.Category.ID,
+			Name:  cnt.Category.Name,
+			Path:  cnt.Path,
+			Count: cnt.Ads,
 		})
 	}
 
+	return gin.H{
+		"data":            response,
+		"next_cursor":     nextCursor,
+		"category_counts": countsResponse,
+		"error":           nil,
+	}
+}
+
+// FavoritesSuccessResponse - объявления из избранного пользователя
+func FavoritesSuccessResponse(a []*ads.Ad) gin.H {
+	response := make([]adResponse, 0, len(a))
+	for i := range a {
+		response = append(response, newAdResponse(a[i]))
+	}
+
 	return gin.H{
 		"data":  response,
 		"error": nil,
 	}
 }
 
+func newMessageResponse(m *messages.Message) messageResponse {
+	return messageResponse{
+		ID:       m.ID,
+		ThreadID: m.ThreadID,
+		SenderID: m.SenderID,
+		Text:     m.Text,
+		Sent:     m.Sent,
+	}
+}
+
+func MessageSuccessResponse(m *messages.Message) gin.H {
+	return gin.H{
+		"data":  newMessageResponse(m),
+		"error": nil,
+	}
+}
+
+// MessagesSuccessResponse - страница сообщений переписки, от новых к старым
+func MessagesSuccessResponse(list []*messages.Message, nextCursor string) gin.H {
+	response := make([]messageResponse, 0, len(list))
+	for _, m := range list {
+		response = append(response, newMessageResponse(m))
+	}
+
+	return gin.H{
+		"data":        response,
+		"next_cursor": nextCursor,
+		"error":       nil,
+	}
+}
+
+// ThreadsSuccessResponse - переписки пользователя userID с непрочитанными им сообщениями
+func ThreadsSuccessResponse(threads []*messages.Thread, userID int64) gin.H {
+	response := make([]threadResponse, 0, len(threads))
+	total := 0
+	for _, t := range threads {
+		unread := t.UnreadFor(userID)
+		total += unread
+		response = append(response, threadResponse{
+			ID:       t.ID,
+			AdID:     t.AdID,
+			BuyerID:  t.BuyerID,
+			SellerID: t.SellerID,
+			Updated:  t.Updated,
+			Unread:   unread,
+		})
+	}
+
+	return gin.H{
+		"data":         response,
+		"unread_total": total,
+		"error":        nil,
+	}
+}
+
+func newSavedSearchResponse(found *searches.Search) savedSearchResponse {
+	q := found.Query
+	query := searchQueryResponse{
+		Query:     q.Query,
+		Filter:    q.Filter,
+		Title:     q.Title,
+		Created:   q.Created,
+		UserID:    q.UserID,
+		Published: q.Published,
+		Category:  q.Category,
+		PriceMin:  q.PriceMin,
+		PriceMax:  q.PriceMax,
+		Currency:  q.Currency,
+	}
+	if len(q.Statuses) > 0 {
+		statuses := make([]string, len(q.Statuses))
+		for i, st := range q.Statuses {
+			statuses[i] = string(st)
+		}
+		query.Status = strings.Join(statuses, ",")
+	}
+	if q.Near != nil {
+		query.Lat = &q.Near.Center.Lat
+		query.Lon = &q.Near.Center.Lon
+		query.Radius = &q.Near.Radius
+	}
+
+	return savedSearchResponse{
+		ID:      found.ID,
+		UserID:  found.UserID,
+		Name:    found.Name,
+		Query:   query,
+		Created: found.Created,
+		Updated: found.Updated,
+	}
+}
+
+func SavedSearchSuccessResponse(found *searches.Search) gin.H {
+	return gin.H{
+		"data":  newSavedSearchResponse(found),
+		"error": nil,
+	}
+}
+
+func SavedSearchesSuccessResponse(list []*searches.Search) gin.H {
+	response := make([]savedSearchResponse, 0, len(list))
+	for _, found := range list {
+		response = append(response, newSavedSearchResponse(found))
+	}
+
+	return gin.H{
+		"data":  response,
+		"error": nil,
+	}
+}
+
+// NotificationsSuccessResponse - последние уведомления пользователя, от новых к старым, и сколько всего из них не прочитано
+func NotificationsSuccessResponse(list []*searches.Notification, unread int) gin.H {
+	response := make([]notificationResponse, 0, len(list))
+	for _, n := range list {
+		response = append(response, notificationResponse{
+			ID:       n.ID,
+			SearchID: n.SearchID,
+			AdID:     n.AdID,
+			Title:    n.Title,
+			Created:  n.Created,
+			Read:     n.Read,
+		})
+	}
+
+	return gin.H{
+		"data":         response,
+		"unread_total": unread,
+		"error":        nil,
+	}
+}
+
+func CategorySuccessResponse(cat *categories.Category) gin.H {
+	return gin.H{
+		"data": categoryResponse{
+			ID:       cat.ID,
+			ParentID: cat.ParentID,
+			Name:     cat.Name,
+		},
+		"error": nil,
+	}
+}
+
+// CategoriesSuccessResponse - все категории дерева в порядке обхода в глубину
+func CategoriesSuccessResponse(tree *categories.Tree) gin.H {
+	response := make([]categoryResponse, 0)
+	for _, cat := range tree.All() {
+		response = append(response, categoryResponse{
+			ID:       cat.ID,
+			ParentID: cat.ParentID,
+			Name:     cat.Name,
+			Path:     tree.Path(cat.ID),
+		})
+	}
+
+	return gin.H{
+		"data":  response,
+		"error": nil,
+	}
+}
+
+func (r webhookRequest) events() []events.Type {
+	var res []events.Type
+	for _, e := range r.Events {
+		res = append(res, events.Type(e))
+	}
+	return res
+}
+
+func newWebhookResponse(s *webhooks.Subscription) webhookResponse {
+	res := webhookResponse{
+		ID:      s.ID,
+		URL:     s.URL,
+		Events:  make([]string, 0, len(s.Types)),
+		Created: s.Created,
+	}
+	for _, t := range s.Types {
+		res.Events = append(res.Events, string(t))
+	}
+	return res
+}
+
+func WebhookSuccessResponse(s *webhooks.Subscription) gin.H {
+	return gin.H{
+		"data":  newWebhookResponse(s),
+		"error": nil,
+	}
+}
+
+// WebhookCreatedResponse - созданная подписка вместе с секретом: больше он нигде не показывается
+func WebhookCreatedResponse(s *webhooks.Subscription) gin.H {
+	response := newWebhookResponse(s)
+	response.Secret = s.Secret
+
+	return gin.H{
+		"data":  response,
+		"error": nil,
+	}
+}
+
+func WebhooksSuccessResponse(list []*webhooks.Subscription) gin.H {
+	response := make([]webhookResponse, 0, len(list))
+	for _, s := range list {
+		response = append(response, newWebhookResponse(s))
+	}
+
+	return gin.H{
+		"data":  response,
+		"error": nil,
+	}
+}
+
+func DeliveriesSuccessResponse(list []*webhooks.Delivery) gin.H {
+	response := make([]deliveryResponse, 0, len(list))
+	for _, d := range list {
+		dr := deliveryResponse{
+			ID:       d.ID,
+			EventID:  d.Event.ID,
+			Event:    string(d.Event.Type),
+			Status:   string(d.Status),
+			Attempts: make([]attemptResponse, 0, len(d.Attempts)),
+			Created:  d.Created,
+		}
+		if d.Status == webhooks.DeliveryPending {
+			next := d.NextAttempt
+			dr.NextAttempt = &next
+		}
+		for _, a := range d.Attempts {
+			dr.Attempts = append(dr.Attempts, attemptResponse{
+				At:         a.At,
+				StatusCode: a.StatusCode,
+				Error:      a.Error,
+				DurationMs: a.Duration.Milliseconds(),
+			})
+		}
+		response = append(response, dr)
+	}
+
+	return gin.H{
+		"data":  response,
+		"error": nil,
+	}
+}
+
+func TokensSuccessResponse(t auth.Tokens) gin.H {
+	return gin.H{
+		"data": tokensResponse{
+			AccessToken:  t.Access,
+			RefreshToken: t.Refresh,
+			TokenType:    "Bearer",
+			ExpiresIn:    int64(t.ExpiresIn.Seconds()),
+		},
+		"error": nil,
+	}
+}
+
 func UserSuccessResponse(u *users.User) gin.H {
 	return gin.H{
 		"data": userResponse{
 			ID:       u.ID,
 			Nickname: u.Nickname,
 			Email:    u.Email,
+			Role:     string(u.RoleOf()),
 		},
 		"error": nil,
 	}
@@ -0,0 +1,25 @@
+package httpgin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"homework10/internal/ratelimit"
+)
+
+// rateLimit ограничивает запросы клиента к каждому маршруту; клиент - пользователь, определённый authenticate,
+// или IP-адрес. Превысивший лимит получает 429 и в Retry-After - через сколько секунд повторить запрос
+func rateLimit(l *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+		ok, wait := l.Allow(route, ratelimit.Principal(c.Request.Context(), c.ClientIP()))
+		if !ok {
+			c.Header("Retry-After", ratelimit.RetryAfter(wait))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, ErrorResponse(ratelimit.ErrLimited))
+			return
+		}
+
+		c.Next()
+	}
+}
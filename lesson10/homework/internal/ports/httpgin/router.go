@@ -4,26 +4,75 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"homework10/internal/app"
+	"homework10/internal/idempotency"
+	"homework10/internal/ratelimit"
 )
 
-func AppRouter(r gin.IRouter, a app.App) {
-	g := r.Group("/api/v1")
+// AppRouter регистрирует методы приложения a; запросы ограничивает l, ответы на создающие запросы
+// с ключом идемпотентности хранит keys
+func AppRouter(r gin.IRouter, a app.App, l *ratelimit.Limiter, keys *idempotency.Store[storedResponse]) {
+	g := r.Group("/api/v1", authenticate(a), rateLimit(l))
+
+	g.POST("/auth/login", login(a))
+	g.POST("/auth/refresh", refresh(a))
+	g.GET("/images/:key", showImage(a))
 
 	users := g.Group("/users")
 	{
-		users.POST("", createUser(a))
+		users.POST("", idempotent(keys), createUser(a))
 		users.PUT("/:user_id", updateUser(a))
 		users.GET("/:user_id", showUser(a))
 		users.DELETE("/:user_id", deleteUser(a))
+		users.PUT("/:user_id/role", changeUserRole(a))
+		users.GET("/:user_id/favorites", listFavorites(a))
+		users.PUT("/:user_id/favorites/:ad_id", addFavorite(a))
+		users.DELETE("/:user_id/favorites/:ad_id", deleteFavorite(a))
+		users.GET("/:user_id/threads", listThreads(a))
+		users.GET("/:user_id/searches", listSavedSearches(a))
+		users.POST("/:user_id/searches", idempotent(keys), createSavedSearch(a))
+		users.PUT("/:user_id/searches/:search_id", updateSavedSearch(a))
+		users.DELETE("/:user_id/searches/:search_id", deleteSavedSearch(a))
+		users.GET("/:user_id/notifications", listNotifications(a))
+		users.POST("/:user_id/notifications/read", readNotifications(a))
+	}
+
+	threads := g.Group("/threads")
+	{
+		threads.GET("/:thread_id/messages", listMessages(a))
+		threads.POST("/:thread_id/messages", idempotent(keys), sendMessage(a))
+	}
+
+	categories := g.Group("/categories")
+	{
+		categories.GET("", listCategories(a))
+		categories.POST("", idempotent(keys), createCategory(a))
+		categories.PUT("/:category_id", updateCategory(a))
+		categories.DELETE("/:category_id", deleteCategory(a))
+	}
+
+	hooks := g.Group("/webhooks")
+	{
+		hooks.GET("", listWebhooks(a))
+		hooks.POST("", idempotent(keys), createWebhook(a))
+		hooks.DELETE("/:webhook_id", deleteWebhook(a))
+		hooks.GET("/:webhook_id/deliveries", listWebhookDeliveries(a))
 	}
 
 	ads := g.Group("/ads")
 	{
 		ads.GET("", listAds(a))
-		ads.POST("", createAd(a))
+		ads.GET("/watch", watchAds(a))
+		ads.POST("", idempotent(keys), createAd(a))
 		ads.GET("/:ad_id", showAd(a))
 		ads.DELETE("/:ad_id", deleteAd(a))
 		ads.PUT("/:ad_id", updateAd(a))
 		ads.PUT("/:ad_id/status", changeAdStatus(a))
+		ads.POST("/:ad_id/transitions", transitionAd(a))
+		ads.POST("/:ad_id/renew", renewAd(a))
+		ads.GET("/:ad_id/history", adHistory(a))
+		ads.POST("/:ad_id/revert", revertAd(a))
+		ads.POST("/:ad_id/images", addAdImage(a))
+		ads.DELETE("/:ad_id/images/:image_id", deleteAdImage(a))
+		ads.POST("/:ad_id/messages", idempotent(keys), contactSeller(a))
 	}
 }
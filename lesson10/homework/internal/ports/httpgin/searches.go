@@ -0,0 +1,236 @@
+package httpgin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"homework10/internal/app"
+)
+
+// Метод для получения сохранённых поисков пользователя (доступен только ему самому)
+func listSavedSearches(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v := c.Param("user_id")
+		userID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		list, err := a.SavedSearches(c, int64(userID))
+		if err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, SavedSearchesSuccessResponse(list))
+	}
+}
+
+// Метод для сохранения поиска: название в теле запроса, условия - в строке запроса, как у списка объявлений
+func createSavedSearch(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v := c.Param("user_id")
+		userID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		var reqBody savedSearchRequest
+		if err = c.Bind(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		var reqParams listAdsRequest
+		if err = c.BindQuery(&reqParams); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		q, err := createAdQuery(c, reqParams)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		found, err := a.CreateSavedSearch(c, int64(userID), reqBody.Name, q)
+		if err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, SavedSearchSuccessResponse(found))
+	}
+}
+
+// Метод для замены названия и условий сохранённого поиска, условия передаются так же, как при создании
+func updateSavedSearch(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v := c.Param("user_id")
+		userID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		v = c.Param("search_id")
+		searchID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		var reqBody savedSearchRequest
+		if err = c.Bind(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		var reqParams listAdsRequest
+		if err = c.BindQuery(&reqParams); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		q, err := createAdQuery(c, reqParams)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		found, err := a.UpdateSavedSearch(c, int64(userID), int64(searchID), reqBody.Name, q)
+		if err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, SavedSearchSuccessResponse(found))
+	}
+}
+
+// Метод для удаления сохранённого поиска
+func deleteSavedSearch(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v := c.Param("user_id")
+		userID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		v = c.Param("search_id")
+		searchID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		found, err := a.DeleteSavedSearch(c, int64(userID), int64(searchID))
+		if err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, SavedSearchSuccessResponse(found))
+	}
+}
+
+// Метод для получения уведомлений пользователя о подошедших под его сохранённые поиски объявлениях
+func listNotifications(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v := c.Param("user_id")
+		userID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		list, unread, err := a.Notifications(c, int64(userID))
+		if err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, NotificationsSuccessResponse(list, unread))
+	}
+}
+
+// Метод для отметки уведомлений прочитанными, возвращает уведомления после отметки
+func readNotifications(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v := c.Param("user_id")
+		userID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		var reqBody readNotificationsRequest
+		if err = c.Bind(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		if err = a.ReadNotifications(c, int64(userID), reqBody.UpTo); err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		list, unread, err := a.Notifications(c, int64(userID))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, NotificationsSuccessResponse(list, unread))
+	}
+}
@@ -1,24 +1,47 @@
 package httpgin
 
 import (
+	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
 
 	"homework10/internal/app"
+	"homework10/internal/health"
+	"homework10/internal/idempotency"
+	"homework10/internal/metrics"
+	"homework10/internal/ratelimit"
 )
 
-func NewHTTPServer(port string, a app.App) *http.Server {
+// NewHTTPServer создаёт сервер приложения a, запросы к которому ограничивает l; ответы на создающие запросы
+// с заголовком Idempotency-Key хранятся window, запросы и паники пишутся в logger, метрики запросов
+// учитываются в m и отдаются по GET /metrics, спаны запросов создаются провайдером tp, а готовность
+// сервиса по p отдаётся по GET /readyz
+func NewHTTPServer(port string, a app.App, l *ratelimit.Limiter, window time.Duration, logger *slog.Logger,
+	m *metrics.Metrics, tp trace.TracerProvider, p *health.Probe) *http.Server {
 	gin.SetMode(gin.ReleaseMode)
 	handler := gin.New()
-	handler.Use(gin.Recovery(), logger(), cors.New(cors.Config{
+	// пользователь, определённый authenticate, кладётся в контекст http-запроса
+	handler.ContextWithFallback = true
+	// IP-адрес клиента, по которому ограничиваются анонимные запросы, берётся из соединения:
+	// без известных прокси заголовок X-Forwarded-For может подделать кто угодно
+	_ = handler.SetTrustedProxies(nil)
+	// tracer - первым, чтобы спан запроса был у всех следующих обработчиков, requestLogger - сразу за ним,
+	// чтобы ID запроса был у всех следующих обработчиков и в лог попадали и запаниковавшие запросы
+	handler.Use(tracer(tp), requestLogger(logger), instrument(m), recovery(logger), cors.New(cors.Config{
 		AllowOrigins: []string{"*"},
 		AllowMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
-		AllowHeaders: []string{"Origin", "Content-Length", "Content-Type"},
+		AllowHeaders: []string{"Origin", "Content-Length", "Content-Type", "Authorization", "If-Match", HeaderIdempotencyKey,
+			HeaderRequestID, "traceparent", "tracestate"},
 	}))
 
-	AppRouter(handler, a)
+	handler.GET("/metrics", gin.WrapH(m.Handler()))
+	handler.GET("/healthz", healthz)
+	handler.GET("/readyz", readyz(p))
+	AppRouter(handler, a, l, idempotency.New[storedResponse](window))
 	s := &http.Server{Addr: port, Handler: handler}
 
 	return s
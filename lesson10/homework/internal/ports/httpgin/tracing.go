@@ -0,0 +1,44 @@
+package httpgin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"homework10/internal/tracing"
+)
+
+// tracer начинает для каждого запроса серверный спан, продолжая трассировку клиента из заголовка traceparent,
+// и кладёт его в контекст запроса. Спан, как и метрики, назван по шаблону маршрута, а не по пути
+func tracer(tp trace.TracerProvider) gin.HandlerFunc {
+	t := tp.Tracer(tracing.Instrumentation)
+	return func(c *gin.Context) {
+		ctx := tracing.Propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		name := c.Request.Method
+		if route := c.FullPath(); route != "" {
+			name += " " + route
+		}
+		ctx, span := t.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+			semconv.HTTPMethod(c.Request.Method),
+			semconv.HTTPRoute(c.FullPath()),
+		))
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		code := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPStatusCode(code))
+		// ошибки клиента (4xx) - не ошибки сервера, спан с ними ошибочным не считается
+		if code >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(code))
+		}
+		if len(c.Errors) > 0 {
+			span.RecordError(c.Errors.Last())
+		}
+	}
+}
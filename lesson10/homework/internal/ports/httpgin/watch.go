@@ -0,0 +1,101 @@
+package httpgin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"homework10/internal/app"
+	"homework10/internal/feed"
+)
+
+// sseHeartbeat - как часто в поток без событий пишется комментарий, чтобы прокси не закрывали соединение
+var sseHeartbeat = 15 * time.Second
+
+// Метод для получения потока изменений подходящих под фильтр опубликованных объявлений (Server-Sent Events).
+// Фильтр задаётся так же, как у списка объявлений; limit, cursor и sort не используются.
+// Когда поток закрывает сервис, последним приходит событие error
+func watchAds(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqParams listAdsRequest
+
+		if err := c.Bind(&reqParams); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		p, err := createAdPattern(c, reqParams)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		sub, err := a.WatchAds(c, p)
+		if err != nil {
+			if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnavailable) {
+				c.JSON(http.StatusServiceUnavailable, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+		defer sub.Close()
+
+		c.Header("Content-Type", "text/event-stream")
+		c.Header("Cache-Control", "no-cache")
+		c.Header("X-Accel-Buffering", "no")
+		c.Status(http.StatusOK)
+		c.Writer.Flush()
+
+		heartbeat := time.NewTicker(sseHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-c.Request.Context().Done():
+				return
+			case <-heartbeat.C:
+				_, err = io.WriteString(c.Writer, ": ping\n\n")
+			case e, ok := <-sub.Events():
+				if !ok {
+					reason := sub.Err()
+					if reason == nil {
+						reason = feed.ErrClosed
+					}
+					_ = writeEvent(c.Writer, "", "error", ErrorResponse(reason))
+					c.Writer.Flush()
+					return
+				}
+				err = writeEvent(c.Writer, strconv.FormatInt(e.ID, 10), string(e.Type), AdEventResponse(e))
+			}
+			if err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
+}
+
+// writeEvent пишет событие в формате text/event-stream; пустой id не передаётся
+func writeEvent(w io.Writer, id, name string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if id != "" {
+		if _, err = fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, payload)
+	return err
+}
@@ -0,0 +1,113 @@
+package httpgin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"homework10/internal/app"
+)
+
+// Метод для подписки на события сервиса (доступен только администраторам)
+func createWebhook(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody webhookRequest
+		if err := c.Bind(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		s, err := a.CreateWebhook(c, reqBody.URL, reqBody.events())
+		if err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, WebhookCreatedResponse(s))
+	}
+}
+
+// Метод для получения всех подписок на события (доступен только администраторам)
+func listWebhooks(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		list, err := a.Webhooks(c)
+		if err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, WebhooksSuccessResponse(list))
+	}
+}
+
+// Метод для удаления подписки (доступен только администраторам)
+func deleteWebhook(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v := c.Param("webhook_id")
+		webhookID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		s, err := a.DeleteWebhook(c, int64(webhookID))
+		if err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, WebhookSuccessResponse(s))
+	}
+}
+
+// Метод для получения журнала последних доставок подписки (доступен только администраторам)
+func listWebhookDeliveries(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v := c.Param("webhook_id")
+		webhookID, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			return
+		}
+
+		list, err := a.WebhookDeliveries(c, int64(webhookID))
+		if err != nil {
+			if errors.Is(err, app.ErrForbidden) {
+				c.JSON(http.StatusForbidden, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrUnauthorized) {
+				c.JSON(http.StatusUnauthorized, ErrorResponse(err))
+			} else if errors.Is(err, app.ErrBadRequest) {
+				c.JSON(http.StatusBadRequest, ErrorResponse(err))
+			} else {
+				c.JSON(http.StatusInternalServerError, ErrorResponse(err))
+			}
+			return
+		}
+
+		c.JSON(http.StatusOK, DeliveriesSuccessResponse(list))
+	}
+}
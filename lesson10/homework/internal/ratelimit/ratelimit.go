@@ -0,0 +1,217 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"homework10/internal/auth"
+)
+
+// DefaultIdle - сколько по умолчанию хранится состояние клиента, который перестал присылать запросы
+const DefaultIdle = 10 * time.Minute
+
+var (
+	ErrLimited  = fmt.Errorf("rate limit exceeded")
+	ErrBadLimit = fmt.Errorf("bad rate limit")
+)
+
+// Limit - не больше Count запросов за Per: Count запросов можно сделать сразу, дальше они восстанавливаются
+// равномерно, по одному каждые Per/Count. Нулевой Limit - без ограничений
+type Limit struct {
+	Count int
+	Per   time.Duration
+}
+
+// Unlimited сообщает, что лимит ничего не ограничивает
+func (l Limit) Unlimited() bool {
+	return l.Count <= 0 || l.Per <= 0
+}
+
+func (l Limit) String() string {
+	if l.Unlimited() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", l.Count, l.Per)
+}
+
+// ParseLimit разбирает лимит вида "10/1m"; "off" - без ограничений
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "off" {
+		return Limit{}, nil
+	}
+
+	count, per, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("%w %q: must look like 10/1m", ErrBadLimit, s)
+	}
+
+	n, err := strconv.Atoi(count)
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("%w %q: count must be a positive number", ErrBadLimit, s)
+	}
+	d, err := time.ParseDuration(per)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("%w %q: period must be a positive duration", ErrBadLimit, s)
+	}
+
+	return Limit{Count: n, Per: d}, nil
+}
+
+// Rules - лимиты запросов одного клиента: Routes - для отдельных маршрутов, Default - для каждого из остальных.
+// Маршрут HTTP - метод и шаблон пути ("POST /api/v1/ads"), маршрут gRPC - полное имя метода ("/ad.AdService/CreateAd")
+type Rules struct {
+	Default Limit
+	Routes  map[string]Limit
+}
+
+// ParseRoutes разбирает лимиты маршрутов вида "POST /api/v1/ads=10/1m,GET /api/v1/ads=off"
+func ParseRoutes(s string) (map[string]Limit, error) {
+	routes := make(map[string]Limit)
+	if strings.TrimSpace(s) == "" {
+		return routes, nil
+	}
+
+	for _, rule := range strings.Split(s, ",") {
+		route, limit, ok := strings.Cut(rule, "=")
+		route = strings.TrimSpace(route)
+		if !ok || route == "" {
+			return nil, fmt.Errorf("%w %q: must look like route=10/1m", ErrBadLimit, rule)
+		}
+
+		l, err := ParseLimit(limit)
+		if err != nil {
+			return nil, err
+		}
+		routes[route] = l
+	}
+
+	return routes, nil
+}
+
+func (r Rules) limit(route string) Limit {
+	if l, ok := r.Routes[route]; ok {
+		return l
+	}
+	return r.Default
+}
+
+// Principal - от чьего имени считаются запросы: пользователь, если он определён по токену, иначе IP-адрес
+func Principal(ctx context.Context, ip string) string {
+	if userID, ok := auth.UserID(ctx); ok {
+		return "user:" + strconv.FormatInt(userID, 10)
+	}
+	return "ip:" + ip
+}
+
+type key struct {
+	route     string
+	principal string
+}
+
+// bucket - запросы, доступные клиенту на маршруте, на момент last
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter ограничивает запросы клиентов по алгоритму token bucket, отдельно на каждом маршруте.
+// Состояние клиента, который не присылал запросов дольше idle и успел за это время восстановить
+// все запросы, забывается: новое состояние с полным запасом от него ничем не отличается
+type Limiter struct {
+	mu      sync.Mutex
+	rules   Rules
+	idle    time.Duration
+	buckets map[key]*bucket
+	swept   time.Time
+	now     func() time.Time
+}
+
+// New создаёт ограничитель; idle <= 0 - DefaultIdle
+func New(rules Rules, idle time.Duration) *Limiter {
+	if idle <= 0 {
+		idle = DefaultIdle
+	}
+
+	return &Limiter{
+		rules:   rules,
+		idle:    idle,
+		buckets: make(map[key]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow расходует запрос principal к route. Если запросов не осталось, возвращает false
+// и через сколько появится следующий
+func (l *Limiter) Allow(route, principal string) (bool, time.Duration) {
+	limit := l.rules.limit(route)
+	if limit.Unlimited() {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	k := key{route: route, principal: principal}
+	b, ok := l.buckets[k]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Count), last: now}
+		l.buckets[k] = b
+	}
+
+	// запросы за время с last; больше Count не копится
+	perToken := limit.Per / time.Duration(limit.Count)
+	b.tokens += float64(now.Sub(b.last)) / float64(perToken)
+	if b.tokens > float64(limit.Count) {
+		b.tokens = float64(limit.Count)
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	return false, time.Duration((1 - b.tokens) * float64(perToken))
+}
+
+// sweep не чаще раза в idle забывает клиентов, которые не присылали запросов дольше idle
+// и за это время восстановили все запросы (на это уходит не больше Per)
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < l.idle {
+		return
+	}
+	l.swept = now
+
+	for k, b := range l.buckets {
+		limit := l.rules.limit(k.route)
+		idle := now.Sub(b.last)
+		if idle >= l.idle && idle >= limit.Per {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// size - у скольких клиентов сейчас хранится состояние
+func (l *Limiter) size() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.buckets)
+}
+
+// RetryAfter - значение заголовка Retry-After для паузы wait: целое число секунд, округлённое вверх
+func RetryAfter(wait time.Duration) string {
+	seconds := (wait + time.Second - 1) / time.Second
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.FormatInt(int64(seconds), 10)
+}
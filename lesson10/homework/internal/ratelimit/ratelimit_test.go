@@ -0,0 +1,158 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/auth"
+)
+
+// clock - часы, которые идут только вручную
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func (c *clock) advance(d time.Duration) {
+	c.t = c.t.Add(d)
+}
+
+func newTestLimiter(rules Rules, idle time.Duration) (*Limiter, *clock) {
+	c := &clock{t: time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)}
+	l := New(rules, idle)
+	l.now = c.now
+	return l, c
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Limit
+		err  error
+	}{
+		{s: "10/1m", want: Limit{Count: 10, Per: time.Minute}},
+		{s: " 5/2s ", want: Limit{Count: 5, Per: 2 * time.Second}},
+		{s: "off", want: Limit{}},
+		{s: "10", err: ErrBadLimit},
+		{s: "0/1m", err: ErrBadLimit},
+		{s: "10/minute", err: ErrBadLimit},
+		{s: "10/-1s", err: ErrBadLimit},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			l, err := ParseLimit(tt.s)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, l)
+		})
+	}
+}
+
+func TestParseRoutes(t *testing.T) {
+	routes, err := ParseRoutes("POST /api/v1/ads=10/1m, /ad.AdService/ListAds=off")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Limit{
+		"POST /api/v1/ads":      {Count: 10, Per: time.Minute},
+		"/ad.AdService/ListAds": {},
+	}, routes)
+
+	routes, err = ParseRoutes("")
+	assert.NoError(t, err)
+	assert.Empty(t, routes)
+
+	_, err = ParseRoutes("POST /api/v1/ads")
+	assert.ErrorIs(t, err, ErrBadLimit)
+	_, err = ParseRoutes("=10/1m")
+	assert.ErrorIs(t, err, ErrBadLimit)
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	l, c := newTestLimiter(Rules{
+		Default: Limit{Count: 100, Per: time.Minute},
+		Routes:  map[string]Limit{"create": {Count: 2, Per: time.Minute}, "list": {}},
+	}, time.Hour)
+
+	// запас из Count запросов расходуется сразу
+	ok, _ := l.Allow("create", "user:1")
+	assert.True(t, ok)
+	ok, _ = l.Allow("create", "user:1")
+	assert.True(t, ok)
+	ok, wait := l.Allow("create", "user:1")
+	assert.False(t, ok)
+	assert.Equal(t, 30*time.Second, wait)
+
+	// у других клиентов и на других маршрутах свои запасы
+	ok, _ = l.Allow("create", "user:2")
+	assert.True(t, ok)
+	ok, _ = l.Allow("update", "user:1")
+	assert.True(t, ok)
+	for i := 0; i < 10; i++ {
+		ok, _ = l.Allow("list", "user:1")
+		assert.True(t, ok)
+	}
+
+	// запросы восстанавливаются по одному каждые Per/Count
+	c.advance(20 * time.Second)
+	ok, wait = l.Allow("create", "user:1")
+	assert.False(t, ok)
+	assert.Equal(t, 10*time.Second, wait)
+	c.advance(10 * time.Second)
+	ok, _ = l.Allow("create", "user:1")
+	assert.True(t, ok)
+
+	// и копятся не больше Count
+	c.advance(time.Hour)
+	for i := 0; i < 2; i++ {
+		ok, _ = l.Allow("create", "user:1")
+		assert.True(t, ok)
+	}
+	ok, _ = l.Allow("create", "user:1")
+	assert.False(t, ok)
+}
+
+func TestLimiter_Evict(t *testing.T) {
+	l, c := newTestLimiter(Rules{
+		Default: Limit{Count: 1, Per: time.Second},
+		Routes:  map[string]Limit{"slow": {Count: 1, Per: time.Hour}},
+	}, time.Minute)
+
+	l.Allow("fast", "ip:10.0.0.1")
+	l.Allow("fast", "ip:10.0.0.2")
+	l.Allow("slow", "ip:10.0.0.1")
+	assert.Equal(t, 3, l.size())
+
+	// клиенты, простоявшие idle, забываются, если уже восстановили запас
+	c.advance(time.Minute)
+	l.Allow("fast", "ip:10.0.0.3")
+	assert.Equal(t, 2, l.size())
+
+	// забытый клиент не получает лишних запросов: на медленном маршруте он ещё ждёт
+	ok, _ := l.Allow("slow", "ip:10.0.0.1")
+	assert.False(t, ok)
+
+	c.advance(time.Hour)
+	l.Allow("fast", "ip:10.0.0.3")
+	assert.Equal(t, 1, l.size())
+}
+
+func TestPrincipal(t *testing.T) {
+	assert.Equal(t, "ip:10.0.0.1", Principal(context.Background(), "10.0.0.1"))
+	assert.Equal(t, "user:5", Principal(auth.WithUserID(context.Background(), 5), "10.0.0.1"))
+}
+
+func TestRetryAfter(t *testing.T) {
+	assert.Equal(t, "1", RetryAfter(0))
+	assert.Equal(t, "1", RetryAfter(300*time.Millisecond))
+	assert.Equal(t, "30", RetryAfter(30*time.Second))
+	assert.Equal(t, "31", RetryAfter(30*time.Second+time.Millisecond))
+}
@@ -0,0 +1,32 @@
+package searches
+
+import (
+	"context"
+
+	"homework10/internal/ads"
+)
+
+//go:generate mockery --name Repository
+type Repository interface {
+	AddSearch(ctx context.Context, s *Search) (int64, error)
+	SearchByID(ctx context.Context, ID int64) (*Search, error)
+	UpdateSearch(ctx context.Context, s *Search) error
+	DeleteSearch(ctx context.Context, ID int64) error
+	// Searches возвращает поиски пользователя в порядке создания
+	Searches(ctx context.Context, userID int64) ([]*Search, error)
+	// Matching возвращает поиски, под которые подходит объявление. Дерево категорий знает только приложение:
+	// within сообщает, лежит ли категория объявления в поддереве категории поиска
+	Matching(ctx context.Context, ad *ads.Ad, within func(category int64) bool) ([]*Search, error)
+
+	// AddNotification сохраняет уведомление; повторное уведомление по тому же поиску и объявлению не сохраняется
+	AddNotification(ctx context.Context, n *Notification) (int64, error)
+	// Notifications возвращает до limit последних уведомлений пользователя, начиная с самых новых
+	Notifications(ctx context.Context, userID int64, limit int) ([]*Notification, error)
+	// Unread считает непрочитанные уведомления пользователя
+	Unread(ctx context.Context, userID int64) (int, error)
+	// MarkRead отмечает прочитанными уведомления пользователя с ID не больше upTo
+	MarkRead(ctx context.Context, userID, upTo int64) error
+
+	// DeleteByUser удаляет поиски и уведомления пользователя
+	DeleteByUser(ctx context.Context, userID int64) error
+}
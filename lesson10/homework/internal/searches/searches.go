@@ -0,0 +1,38 @@
+package searches
+
+import (
+	"time"
+
+	"homework10/internal/ads"
+)
+
+// Search - сохранённый пользователем поиск объявлений: о новых подходящих под него объявлениях
+// пользователь получает уведомления
+type Search struct {
+	ID      int64
+	UserID  int64
+	Name    string `validate:"min:1;max:100"`
+	Query   ads.Query
+	Created time.Time
+	Updated time.Time
+}
+
+// Copy возвращает копию поиска, не разделяющую с ним условия выборки
+func (s *Search) Copy() *Search {
+	cp := *s
+	cp.Query = s.Query.Copy()
+	return &cp
+}
+
+// Notification - уведомление пользователя о том, что объявление подошло под его сохранённый поиск.
+// По каждому поиску об объявлении уведомляют не больше одного раза
+type Notification struct {
+	ID       int64
+	UserID   int64
+	SearchID int64
+	AdID     int64
+	// Title - заголовок объявления на момент уведомления: объявление к моменту прочтения могут изменить или удалить
+	Title   string
+	Created time.Time
+	Read    bool
+}
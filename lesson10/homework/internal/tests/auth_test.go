@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func TestLogin(t *testing.T) {
+	client := getTestHTTPClient()
+
+	_, err := client.createUser("jenny", "jenny@gmail.com")
+	assert.NoError(t, err)
+
+	_, err = client.login(0, "wrong password")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	_, err = client.login(1, testPassword)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	tokens, err := client.login(0, testPassword)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer", tokens.Data.TokenType)
+	assert.Positive(t, tokens.Data.ExpiresIn)
+
+	refreshed, err := client.refresh(tokens.Data.RefreshToken)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, refreshed.Data.AccessToken)
+
+	// access-токен нельзя использовать вместо refresh-токена
+	_, err = client.refresh(tokens.Data.AccessToken)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestBadAccessToken(t *testing.T) {
+	client := getTestHTTPClient()
+
+	req, err := http.NewRequest(http.MethodGet, client.baseURL+"/api/v1/ads", nil)
+	assert.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer garbage")
+
+	var response adsResponse
+	err = client.getResponse(req, &response)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestActAsOtherUser(t *testing.T) {
+	client := getTestHTTPClient()
+
+	_, err := client.createUser("jenny", "jenny@gmail.com")
+	assert.NoError(t, err)
+
+	_, err = client.createUser("polly", "polly@gmail.com")
+	assert.NoError(t, err)
+
+	ad, err := client.createAd(0, "hello", "world")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), ad.Data.AuthorID)
+
+	_, err = client.updateAd(1, ad.Data.ID, "title", "text")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	// пользователь 1 подписывает своим токеном запрос на изменение пользователя 0
+	client.tokens[0] = client.tokens[1]
+	_, err = client.updateUser(0, "molly", "molly@gmail.com")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	err = client.deleteUser(0)
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestGRPCLogin(t *testing.T) {
+	ctx, client := getTestGRCPClient(t)
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com", Password: testPassword})
+	assert.NoError(t, err, "client.CreateUser")
+
+	_, err = client.Login(ctx, &grpcPort.LoginRequest{UserId: 0, Password: "wrong password"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "Title", Text: "Text", CategoryId: testCategoryID})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	badCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer garbage")
+	_, err = client.CreateAd(badCtx, &grpcPort.CreateAdRequest{Title: "Title", Text: "Text", CategoryId: testCategoryID})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	tokens, err := client.Login(ctx, &grpcPort.LoginRequest{UserId: 0, Password: testPassword})
+	assert.NoError(t, err, "client.Login")
+
+	refreshed, err := client.Refresh(ctx, &grpcPort.RefreshRequest{RefreshToken: tokens.RefreshToken})
+	assert.NoError(t, err, "client.Refresh")
+
+	authCtx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+refreshed.AccessToken)
+	ad, err := client.CreateAd(authCtx, &grpcPort.CreateAdRequest{Title: "Title", Text: "Text", CategoryId: testCategoryID})
+	assert.NoError(t, err, "client.CreateAd")
+	assert.Zero(t, ad.UserId)
+}
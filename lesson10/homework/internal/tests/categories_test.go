@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"homework10/internal/adapters/userrepo"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/users"
+)
+
+func TestCategories(t *testing.T) {
+	userRepo := userrepo.New()
+	client := getTestHTTPClientWithApp(newTestAppWithUsers(userRepo))
+
+	admin, err := client.createUser("admin", "admin@gmail.com")
+	assert.NoError(t, err)
+	promote(t, userRepo, admin.Data.ID, users.RoleAdmin)
+
+	author, err := client.createUser("jenny", "jenny@gmail.com")
+	assert.NoError(t, err)
+
+	// менять дерево категорий может только администратор
+	_, err = client.createCategory(author.Data.ID, "Электроника", 0)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	electronics, err := client.createCategory(admin.Data.ID, "Электроника", 0)
+	assert.NoError(t, err)
+	phones, err := client.createCategory(admin.Data.ID, "Телефоны", electronics.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, electronics.Data.ID, phones.Data.ParentID)
+	laptops, err := client.createCategory(admin.Data.ID, "Ноутбуки", electronics.Data.ID)
+	assert.NoError(t, err)
+
+	_, err = client.createCategory(admin.Data.ID, "телефоны", electronics.Data.ID)
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.updateCategory(admin.Data.ID, electronics.Data.ID, "Электроника", phones.Data.ID)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	list, err := client.listCategories()
+	assert.NoError(t, err)
+	if assert.Len(t, list.Data, 4) {
+		assert.Equal(t, "Разное", list.Data[0].Name)
+		assert.Equal(t, "Электроника", list.Data[1].Name)
+		assert.Equal(t, []string{"Электроника", "Ноутбуки"}, list.Data[2].Path)
+		assert.Equal(t, []string{"Электроника", "Телефоны"}, list.Data[3].Path)
+	}
+
+	_, err = client.createAdIn(author.Data.ID, 100, "Продам телефон", "Почти новый")
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	phone, err := client.createAdIn(author.Data.ID, phones.Data.ID, "Продам телефон", "Почти новый")
+	assert.NoError(t, err)
+	assert.Equal(t, phones.Data.ID, phone.Data.Category)
+	_, err = client.createAdIn(author.Data.ID, laptops.Data.ID, "Продам ноутбук", "Мощный")
+	assert.NoError(t, err)
+	_, err = client.createAdIn(author.Data.ID, laptops.Data.ID, "Куплю ноутбук", "Недорого")
+	assert.NoError(t, err)
+	_, err = client.createAd(author.Data.ID, "Отдам кота", "Бесплатно")
+	assert.NoError(t, err)
+
+	// в выдачу по категории попадают и объявления подкатегорий
+	resp, err := client.listAds(map[string]string{"filter": "published = false", "category": "2"})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data, 3)
+	if assert.Len(t, resp.CategoryCounts, 3) {
+		assert.Equal(t, categoryCount{ID: electronics.Data.ID, Name: "Электроника", Path: []string{"Электроника"}, Count: 3}, resp.CategoryCounts[0])
+		assert.Equal(t, laptops.Data.ID, resp.CategoryCounts[1].ID)
+		assert.Equal(t, 2, resp.CategoryCounts[1].Count)
+		assert.Equal(t, phones.Data.ID, resp.CategoryCounts[2].ID)
+		assert.Equal(t, 1, resp.CategoryCounts[2].Count)
+	}
+
+	resp, err = client.listAds(map[string]string{"filter": "published = false", "category": "3"})
+	assert.NoError(t, err)
+	if assert.Len(t, resp.Data, 1) {
+		assert.Equal(t, phone.Data.ID, resp.Data[0].ID)
+	}
+
+	resp, err = client.listAds(map[string]string{"filter": "published = false"})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data, 4)
+	assert.Len(t, resp.CategoryCounts, 4)
+
+	_, err = client.listAds(map[string]string{"category": "100"})
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	// непустую категорию удалить нельзя
+	err = client.deleteCategory(admin.Data.ID, electronics.Data.ID)
+	assert.ErrorIs(t, err, ErrBadRequest)
+	err = client.deleteCategory(admin.Data.ID, phones.Data.ID)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	err = client.deleteAd(author.Data.ID, phone.Data.ID)
+	assert.NoError(t, err)
+	err = client.deleteCategory(author.Data.ID, phones.Data.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+	err = client.deleteCategory(admin.Data.ID, phones.Data.ID)
+	assert.NoError(t, err)
+
+	moved, err := client.updateCategory(admin.Data.ID, laptops.Data.ID, "Компьютеры", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), moved.Data.ParentID)
+
+	list, err = client.listCategories()
+	assert.NoError(t, err)
+	if assert.Len(t, list.Data, 3) {
+		assert.Equal(t, []string{"Компьютеры"}, list.Data[0].Path)
+	}
+}
+
+func TestGRPCCategories(t *testing.T) {
+	ctx, client := getTestGRCPClient(t)
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@gmail.com", Password: testPassword})
+	assert.NoError(t, err)
+	userCtx := loginGRPC(t, ctx, client, 0)
+
+	_, err = client.CreateCategory(userCtx, &grpcPort.CreateCategoryRequest{Name: "Электроника"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.CreateAd(userCtx, &grpcPort.CreateAdRequest{Title: "title", Text: "text", CategoryId: 100})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.CreateAd(userCtx, &grpcPort.CreateAdRequest{Title: "title", Text: "text", CategoryId: testCategoryID})
+	assert.NoError(t, err)
+
+	res, err := client.ListAds(ctx, &grpcPort.ListAdsRequest{Filter: "published = false", CategoryId: testCategoryID})
+	assert.NoError(t, err)
+	assert.Len(t, res.List, 1)
+	if assert.Len(t, res.CategoryCounts, 1) {
+		assert.Equal(t, int64(1), res.CategoryCounts[0].Count)
+		assert.Equal(t, []string{"Разное"}, res.CategoryCounts[0].Path)
+	}
+
+	list, err := client.ListCategories(ctx, &grpcPort.ListCategoriesRequest{})
+	assert.NoError(t, err)
+	if assert.Len(t, list.List, 1) {
+		assert.Equal(t, "Разное", list.List[0].Name)
+	}
+}
@@ -45,7 +45,7 @@ func TestCreateAd_ID(t *testing.T) {
 	assert.NoError(t, err)
 
 	_, err = client.createAd(123, "hello", "world")
-	assert.ErrorIs(t, err, ErrBadRequest)
+	assert.ErrorIs(t, err, ErrUnauthorized)
 
 	resp, err := client.createAd(0, "hello", "world")
 	assert.NoError(t, err)
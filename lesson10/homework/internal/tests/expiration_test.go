@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"homework10/internal/app"
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func TestExpiration(t *testing.T) {
+	a := newTestApp()
+	client := getTestHTTPClientWithApp(a)
+
+	author, err := client.createUser("jenny", "jenny@gmail.com")
+	assert.NoError(t, err)
+	other, err := client.createUser("polly", "polly@gmail.com")
+	assert.NoError(t, err)
+
+	ad, err := client.createAd(author.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+	if assert.NotNil(t, ad.Data.Expires) {
+		assert.WithinDuration(t, time.Now().Add(app.DefaultAdTTL), *ad.Data.Expires, time.Minute)
+	}
+	_, err = client.changeAdStatus(author.Data.ID, ad.Data.ID, true)
+	assert.NoError(t, err)
+
+	// продлить объявление может только автор и не больше чем на MaxAdTTL
+	_, err = client.renewAd(other.Data.ID, ad.Data.ID, time.Hour)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.renewAd(author.Data.ID, ad.Data.ID, app.MaxAdTTL+time.Hour)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	renewed, err := client.renewAd(author.Data.ID, ad.Data.ID, 2*app.DefaultAdTTL)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(2*app.DefaultAdTTL), *renewed.Data.Expires, time.Minute)
+
+	short, err := client.createAd(author.Data.ID, "short", "lived")
+	assert.NoError(t, err)
+
+	// через DefaultAdTTL истекает только непродлённое объявление
+	n, err := a.ExpireAds(context.Background(), time.Now().Add(app.DefaultAdTTL+time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	res, err := client.showAd(short.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "archived", res.Data.Status)
+
+	res, err = client.showAd(ad.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "published", res.Data.Status)
+
+	// архивные объявления повторно не истекают
+	n, err = a.ExpireAds(context.Background(), time.Now().Add(app.DefaultAdTTL+time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestGRPCExpiration(t *testing.T) {
+	ctx, client := getTestGRCPClient(t)
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com", Password: testPassword})
+	assert.NoError(t, err, "client.CreateUser")
+
+	ad, err := client.CreateAd(loginGRPC(t, ctx, client, 0), &grpcPort.CreateAdRequest{Title: "Title", Text: "Text", Ttl: 3600, CategoryId: testCategoryID})
+	assert.NoError(t, err, "client.CreateAd")
+	assert.WithinDuration(t, time.Now().Add(time.Hour), ad.Expires.AsTime(), time.Minute)
+
+	_, err = client.CreateAd(loginGRPC(t, ctx, client, 0), &grpcPort.CreateAdRequest{Title: "Title", Text: "Text", Ttl: -1, CategoryId: testCategoryID})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// срок, переполняющий time.Duration, отклоняется, а не превращается в доли секунды
+	_, err = client.CreateAd(loginGRPC(t, ctx, client, 0), &grpcPort.CreateAdRequest{Title: "Title", Text: "Text", Ttl: 18446744074, CategoryId: testCategoryID})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.RenewAd(loginGRPC(t, ctx, client, 0), &grpcPort.RenewAdRequest{AdId: ad.Id, Ttl: 18446744074})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	res, err := client.RenewAd(loginGRPC(t, ctx, client, 0), &grpcPort.RenewAdRequest{AdId: ad.Id, Ttl: 7200, Version: ad.Version})
+	assert.NoError(t, err, "client.RenewAd")
+	assert.WithinDuration(t, time.Now().Add(2*time.Hour), res.Expires.AsTime(), time.Minute)
+}
@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func TestFavorites(t *testing.T) {
+	client := getTestHTTPClient()
+
+	author, err := client.createUser("jenny", "jenny@gmail.com")
+	assert.NoError(t, err)
+	fan, err := client.createUser("oleg", "oleg@gmail.com")
+	assert.NoError(t, err)
+	other, err := client.createUser("polly", "polly@gmail.com")
+	assert.NoError(t, err)
+
+	bike, err := client.createAd(author.Data.ID, "Продам велосипед", "Почти новый")
+	assert.NoError(t, err)
+	sofa, err := client.createAd(author.Data.ID, "Продам диван", "Раскладной")
+	assert.NoError(t, err)
+
+	_, err = client.addFavorite(fan.Data.ID, 100)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	res, err := client.addFavorite(fan.Data.ID, bike.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, res.Data.Favorites)
+
+	// повторное добавление ничего не меняет
+	res, err = client.addFavorite(fan.Data.ID, bike.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, res.Data.Favorites)
+
+	_, err = client.addFavorite(fan.Data.ID, sofa.Data.ID)
+	assert.NoError(t, err)
+	_, err = client.addFavorite(other.Data.ID, bike.Data.ID)
+	assert.NoError(t, err)
+
+	shown, err := client.showAd(bike.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, shown.Data.Favorites)
+
+	list, err := client.listFavorites(fan.Data.ID, fan.Data.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, list.Data, 2) {
+		assert.Equal(t, sofa.Data.ID, list.Data[0].ID)
+		assert.Equal(t, bike.Data.ID, list.Data[1].ID)
+		assert.Equal(t, 2, list.Data[1].Favorites)
+	}
+
+	// чужое избранное не видно и не меняется
+	_, err = client.listFavorites(other.Data.ID, fan.Data.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.deleteFavorite(other.Data.ID, sofa.Data.ID)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	res, err = client.deleteFavorite(other.Data.ID, bike.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, res.Data.Favorites)
+
+	// удалённое объявление пропадает из избранного
+	err = client.deleteAd(author.Data.ID, sofa.Data.ID)
+	assert.NoError(t, err)
+	list, err = client.listFavorites(fan.Data.ID, fan.Data.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, list.Data, 1) {
+		assert.Equal(t, bike.Data.ID, list.Data[0].ID)
+	}
+
+	// как и избранное удалённого пользователя
+	err = client.deleteUser(fan.Data.ID)
+	assert.NoError(t, err)
+	shown, err = client.showAd(bike.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, shown.Data.Favorites)
+}
+
+func TestGRPCFavorites(t *testing.T) {
+	ctx, client := getTestGRCPClient(t)
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@gmail.com", Password: testPassword})
+	assert.NoError(t, err)
+	_, err = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Polly", Email: "polly@gmail.com", Password: testPassword})
+	assert.NoError(t, err)
+	olegCtx := loginGRPC(t, ctx, client, 0)
+	pollyCtx := loginGRPC(t, ctx, client, 1)
+
+	ad, err := client.CreateAd(olegCtx, &grpcPort.CreateAdRequest{Title: "title", Text: "text", CategoryId: testCategoryID})
+	assert.NoError(t, err)
+
+	_, err = client.AddFavorite(ctx, &grpcPort.FavoriteRequest{UserId: 1, AdId: ad.Id})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = client.AddFavorite(olegCtx, &grpcPort.FavoriteRequest{UserId: 1, AdId: ad.Id})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	res, err := client.AddFavorite(pollyCtx, &grpcPort.FavoriteRequest{UserId: 1, AdId: ad.Id})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), res.Favorites)
+
+	list, err := client.ListFavorites(pollyCtx, &grpcPort.ListFavoritesRequest{UserId: 1})
+	assert.NoError(t, err)
+	if assert.Len(t, list.List, 1) {
+		assert.Equal(t, ad.Id, list.List[0].Id)
+	}
+
+	_, err = client.DeleteAd(olegCtx, &grpcPort.DeleteAdRequest{AdId: ad.Id})
+	assert.NoError(t, err)
+
+	list, err = client.ListFavorites(pollyCtx, &grpcPort.ListFavoritesRequest{UserId: 1})
+	assert.NoError(t, err)
+	assert.Empty(t, list.List)
+}
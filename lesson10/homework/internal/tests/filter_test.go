@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func TestListAds_Filter(t *testing.T) {
+	client := getTestHTTPClient()
+
+	_, err := client.createUser("jenny", "jenny@gmail.com")
+	assert.NoError(t, err)
+	_, err = client.createUser("oleg", "oleg@gmail.com")
+	assert.NoError(t, err)
+
+	_, err = client.createAd(0, "Продам велосипед", "Почти новый")
+	assert.NoError(t, err)
+	_, err = client.createAd(1, "Куплю велосипед", "Недорого")
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(1, 1, true)
+	assert.NoError(t, err)
+	_, err = client.createAd(1, "Продам диван", "Раскладной")
+	assert.NoError(t, err)
+
+	resp, err := client.listAds(map[string]string{"filter": `published = false and title contains "продам"`})
+	assert.NoError(t, err)
+	if assert.Len(t, resp.Data, 2) {
+		assert.Equal(t, int64(0), resp.Data[0].ID)
+		assert.Equal(t, int64(2), resp.Data[1].ID)
+	}
+
+	// фильтр объединяется с остальными параметрами через "и"
+	resp, err = client.listAds(map[string]string{"filter": `title contains "велосипед"`, "user_id": "1"})
+	assert.NoError(t, err)
+	if assert.Len(t, resp.Data, 1) {
+		assert.Equal(t, int64(1), resp.Data[0].ID)
+	}
+
+	_, err = client.listAds(map[string]string{"filter": `published = yes`})
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestGRPCListAds_Filter(t *testing.T) {
+	ctx, client := getTestGRCPClient(t)
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@gmail.com", Password: testPassword})
+	assert.NoError(t, err)
+	for _, title := range []string{"first", "second", "third"} {
+		_, err = client.CreateAd(loginGRPC(t, ctx, client, 0), &grpcPort.CreateAdRequest{Title: title, Text: "text", CategoryId: testCategoryID})
+		assert.NoError(t, err)
+	}
+
+	res, err := client.ListAds(ctx, &grpcPort.ListAdsRequest{Filter: `id in (0, 2) or title = "second"`, Sort: "title"})
+	assert.NoError(t, err)
+	if assert.Len(t, res.List, 3) {
+		assert.Equal(t, "first", res.List[0].Title)
+	}
+
+	_, err = client.ListAds(ctx, &grpcPort.ListAdsRequest{Filter: `id in (0, 2`})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "position 12")
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func TestGeo(t *testing.T) {
+	client := getTestHTTPClient()
+
+	user, err := client.createUser("jenny", "jenny@gmail.com")
+	assert.NoError(t, err)
+
+	_, err = client.createLocatedAd(user.Data.ID, "Без города", 55.75, 37.62, "")
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.createLocatedAd(user.Data.ID, "Не на Земле", 100, 37.62, "Москва")
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	kremlin, err := client.createLocatedAd(user.Data.ID, "Велосипед", 55.7520, 37.6175, "Москва")
+	assert.NoError(t, err)
+	assert.Equal(t, "Москва", kremlin.Data.Location.City)
+	assert.Nil(t, kremlin.Data.Distance)
+
+	khimki, err := client.createLocatedAd(user.Data.ID, "Самокат", 55.8970, 37.4297, "Химки")
+	assert.NoError(t, err)
+	_, err = client.createLocatedAd(user.Data.ID, "Лыжи", 59.9386, 30.3141, "Санкт-Петербург")
+	assert.NoError(t, err)
+	nowhere, err := client.createAd(user.Data.ID, "Котята", "Отдам в добрые руки")
+	assert.NoError(t, err)
+	assert.Nil(t, nowhere.Data.Location)
+
+	// от Красной площади: Кремль в сотне метров, Химки в ~20 км, Петербург в ~630 км
+	res, err := client.listAds(map[string]string{"lat": "55.7539", "lon": "37.6208", "radius": "30", "sort": "distance"})
+	assert.NoError(t, err)
+	if assert.Len(t, res.Data, 2) {
+		assert.Equal(t, kremlin.Data.ID, res.Data[0].ID)
+		assert.Equal(t, khimki.Data.ID, res.Data[1].ID)
+		assert.InDelta(t, 0.3, *res.Data[0].Distance, 0.1)
+		assert.InDelta(t, 19.7, *res.Data[1].Distance, 1)
+	}
+
+	res, err = client.listAds(map[string]string{"lat": "55.7539", "lon": "37.6208", "radius": "5", "q": "велосипед"})
+	assert.NoError(t, err)
+	if assert.Len(t, res.Data, 1) {
+		assert.Equal(t, kremlin.Data.ID, res.Data[0].ID)
+	}
+
+	_, err = client.listAds(map[string]string{"lat": "55.7539", "lon": "37.6208", "radius": "1000"})
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.listAds(map[string]string{"lat": "55.7539", "radius": "10"})
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.listAds(map[string]string{"sort": "distance"})
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestGRPCGeo(t *testing.T) {
+	ctx, client := getTestGRCPClient(t)
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@gmail.com", Password: testPassword})
+	assert.NoError(t, err)
+	ctx = loginGRPC(t, ctx, client, 0)
+
+	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "title", Text: "text", CategoryId: testCategoryID, Location: &grpcPort.Location{Lat: 55.75, Lon: 200, City: "Москва"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	ad, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "title", Text: "text", CategoryId: testCategoryID, Location: &grpcPort.Location{Lat: 56.8587, Lon: 35.9176, City: "Тверь"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "Тверь", ad.Location.City)
+
+	near := &grpcPort.Area{Lat: 55.7539, Lon: 37.6208, Radius: 50}
+	list, err := client.ListAds(ctx, &grpcPort.ListAdsRequest{Near: near})
+	assert.NoError(t, err)
+	assert.Empty(t, list.List)
+
+	// объявление переезжает в Москву
+	ad, err = client.UpdateAd(ctx, &grpcPort.UpdateAdRequest{AdId: ad.Id, Title: "title", Text: "text", Location: &grpcPort.Location{Lat: 55.7558, Lon: 37.6173, City: "Москва"}})
+	assert.NoError(t, err)
+	list, err = client.ListAds(ctx, &grpcPort.ListAdsRequest{Near: near, Sort: "distance"})
+	assert.NoError(t, err)
+	if assert.Len(t, list.List, 1) {
+		assert.Equal(t, ad.Id, list.List[0].Id)
+		assert.InDelta(t, 0.4, list.List[0].GetDistance(), 0.2)
+	}
+
+	// изменение без местоположения его не трогает
+	ad, err = client.UpdateAd(ctx, &grpcPort.UpdateAdRequest{AdId: ad.Id, Title: "new title", Text: "text"})
+	assert.NoError(t, err)
+	if assert.NotNil(t, ad.Location) {
+		assert.Equal(t, "Москва", ad.Location.City)
+	}
+
+	_, err = client.UpdateAd(ctx, &grpcPort.UpdateAdRequest{AdId: ad.Id, Title: "title", Text: "text", Location: ad.Location, ClearLocation: true})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// без местоположения объявление пропадает из поиска по радиусу
+	_, err = client.UpdateAd(ctx, &grpcPort.UpdateAdRequest{AdId: ad.Id, Title: "title", Text: "text", ClearLocation: true})
+	assert.NoError(t, err)
+	list, err = client.ListAds(ctx, &grpcPort.ListAdsRequest{Near: near})
+	assert.NoError(t, err)
+	assert.Empty(t, list.List)
+
+	_, err = client.ListAds(ctx, &grpcPort.ListAdsRequest{Near: &grpcPort.Area{Lat: 55.75, Lon: 37.62}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -12,9 +12,7 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/test/bufconn"
 
-	"homework10/internal/adapters/adrepo"
-	"homework10/internal/adapters/userrepo"
-	"homework10/internal/app"
+	"homework10/internal/logging"
 	grpcPort "homework10/internal/ports/grpc"
 )
 
@@ -26,7 +24,7 @@ func TestGRRPCCreateUser(t *testing.T) {
 
 	s := grpc.NewServer(
 		grpc.ChainUnaryInterceptor(
-			grpcPort.UnaryLogInterceptor,
+			grpcPort.UnaryLogInterceptor(logging.Discard()),
 			recovery.UnaryServerInterceptor(),
 		),
 	)
@@ -34,7 +32,7 @@ func TestGRRPCCreateUser(t *testing.T) {
 		s.Stop()
 	})
 
-	srv := grpcPort.NewService(app.NewApp(adrepo.New(), userrepo.New()))
+	srv := grpcPort.NewService(newTestApp())
 	grpcPort.RegisterAdServiceServer(s, srv)
 
 	go func() {
@@ -58,7 +56,7 @@ func TestGRRPCCreateUser(t *testing.T) {
 	})
 
 	client := grpcPort.NewAdServiceClient(conn)
-	res, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@gmail.com"})
+	res, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@gmail.com", Password: testPassword})
 	assert.NoError(t, err, "client.CreateUser")
 
 	assert.Equal(t, "Oleg", res.Nickname)
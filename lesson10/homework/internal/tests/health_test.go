@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"homework10/internal/adapters/userrepo"
+	"homework10/internal/health"
+	usersMock "homework10/internal/users/mocks"
+)
+
+type healthResponse struct {
+	Data *struct {
+		Status string `json:"status"`
+	} `json:"data"`
+	Error *string `json:"error"`
+}
+
+func (tc *testHTTPClient) probe(path string) (int, healthResponse) {
+	resp, err := tc.client.Get(tc.baseURL + path)
+	if err != nil {
+		return 0, healthResponse{}
+	}
+	defer resp.Body.Close()
+
+	var response healthResponse
+	_ = json.NewDecoder(resp.Body).Decode(&response)
+	return resp.StatusCode, response
+}
+
+func TestHealth(t *testing.T) {
+	p := health.New()
+	p.Add("users", health.Users(userrepo.New()))
+	client := getTestHTTPClientWithProbe(newTestApp(), p)
+
+	code, response := client.probe("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", response.Data.Status)
+
+	code, response = client.probe("/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", response.Data.Status)
+
+	// при остановке сервис перестаёт быть готовым, но живым остаётся, пока дорабатывает запросы
+	p.Shutdown()
+	code, response = client.probe("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.ErrShuttingDown.Error(), *response.Error)
+
+	code, _ = client.probe("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestHealth_RepoUnavailable(t *testing.T) {
+	broken := usersMock.NewRepository(t)
+	broken.On("CountUsers", mock.Anything).Return(0, fmt.Errorf("repo is down"))
+	p := health.New()
+	p.Add("users", health.Users(broken))
+	client := getTestHTTPClientWithProbe(newTestApp(), p)
+
+	code, response := client.probe("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "users: repo is down", *response.Error)
+
+	code, _ = client.probe("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func TestAdHistory(t *testing.T) {
+	client := getTestHTTPClient()
+
+	owner, err := client.createUser("jenny", "jenny@gmail.com")
+	assert.NoError(t, err)
+	stranger, err := client.createUser("oleg", "oleg@gmail.com")
+	assert.NoError(t, err)
+
+	ad, err := client.createAd(owner.Data.ID, "Велосипед", "Горный, почти новый")
+	assert.NoError(t, err)
+	_, err = client.updateAd(owner.Data.ID, ad.Data.ID, "Велосипед б/у", "Горный, есть царапины")
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(owner.Data.ID, ad.Data.ID, true)
+	assert.NoError(t, err)
+
+	res, err := client.adHistory(owner.Data.ID, ad.Data.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, res.Data, 3) {
+		assert.Equal(t, int64(1), res.Data[0].Revision)
+		assert.Equal(t, "create", res.Data[0].Op)
+		assert.Equal(t, owner.Data.ID, res.Data[0].ActorID)
+		assert.Nil(t, res.Data[0].Before)
+		assert.Equal(t, "Велосипед", res.Data[0].After.Title)
+
+		assert.Equal(t, "update", res.Data[1].Op)
+		assert.Equal(t, "Велосипед", res.Data[1].Before.Title)
+		assert.Equal(t, "Велосипед б/у", res.Data[1].After.Title)
+
+		assert.Equal(t, int64(3), res.Data[2].Revision)
+		assert.False(t, res.Data[2].Before.Published)
+		assert.True(t, res.Data[2].After.Published)
+	}
+
+	_, err = client.adHistory(stranger.Data.ID, ad.Data.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = client.revertAd(stranger.Data.ID, ad.Data.ID, 1)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.revertAd(owner.Data.ID, ad.Data.ID, 10)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	reverted, err := client.revertAd(owner.Data.ID, ad.Data.ID, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Велосипед", reverted.Data.Title)
+	assert.Equal(t, "Горный, почти новый", reverted.Data.Text)
+	assert.True(t, reverted.Data.Published)
+
+	res, err = client.adHistory(owner.Data.ID, ad.Data.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, res.Data, 4) {
+		assert.Equal(t, "revert", res.Data[3].Op)
+		assert.Equal(t, "Велосипед б/у", res.Data[3].Before.Title)
+		assert.Equal(t, "Велосипед", res.Data[3].After.Title)
+	}
+
+	// история остаётся и после удаления объявления
+	err = client.deleteAd(owner.Data.ID, ad.Data.ID)
+	assert.NoError(t, err)
+	res, err = client.adHistory(owner.Data.ID, ad.Data.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, res.Data, 5) {
+		assert.Equal(t, "delete", res.Data[4].Op)
+		assert.Nil(t, res.Data[4].After)
+	}
+}
+
+func TestGRPCAdHistory(t *testing.T) {
+	ctx, client := getTestGRCPClient(t)
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@gmail.com", Password: testPassword})
+	assert.NoError(t, err)
+	_, err = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com", Password: testPassword})
+	assert.NoError(t, err)
+	ownerCtx := loginGRPC(t, ctx, client, 0)
+	strangerCtx := loginGRPC(t, ctx, client, 1)
+
+	ad, err := client.CreateAd(ownerCtx, &grpcPort.CreateAdRequest{Title: "title", Text: "text", CategoryId: testCategoryID})
+	assert.NoError(t, err)
+	ad, err = client.UpdateAd(ownerCtx, &grpcPort.UpdateAdRequest{AdId: ad.Id, Title: "new title", Text: "new text"})
+	assert.NoError(t, err)
+
+	_, err = client.GetAdHistory(strangerCtx, &grpcPort.GetAdHistoryRequest{AdId: ad.Id})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	res, err := client.GetAdHistory(ownerCtx, &grpcPort.GetAdHistoryRequest{AdId: ad.Id})
+	assert.NoError(t, err)
+	if assert.Len(t, res.Entries, 2) {
+		assert.Equal(t, "create", res.Entries[0].Op)
+		assert.Nil(t, res.Entries[0].Before)
+		assert.Equal(t, "title", res.Entries[0].After.Title)
+		assert.Equal(t, "update", res.Entries[1].Op)
+		assert.Equal(t, "new title", res.Entries[1].After.Title)
+	}
+
+	_, err = client.RevertAd(ownerCtx, &grpcPort.RevertAdRequest{AdId: ad.Id, Version: ad.Version + 1, Revision: 1})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	reverted, err := client.RevertAd(ownerCtx, &grpcPort.RevertAdRequest{AdId: ad.Id, Version: ad.Version, Revision: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "title", reverted.Title)
+	assert.Equal(t, "text", reverted.Text)
+	assert.Equal(t, ad.Version+1, reverted.Version)
+}
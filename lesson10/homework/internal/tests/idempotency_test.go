@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	client := getTestHTTPClient()
+
+	// повтор создания пользователя с тем же ключом не создаёт второго
+	jenny, err := client.createUserWithKey("jenny", "jenny@gmail.com", "user-1")
+	assert.NoError(t, err)
+	again, err := client.createUserWithKey("jenny", "jenny@gmail.com", "user-1")
+	assert.NoError(t, err)
+	assert.Equal(t, jenny.Data, again.Data)
+	oleg, err := client.createUser("oleg", "oleg@gmail.com")
+	assert.NoError(t, err)
+	assert.Equal(t, jenny.Data.ID+1, oleg.Data.ID)
+
+	body := map[string]any{"title": "hello", "text": "world", "category_id": testCategoryID}
+	ad, err := client.postAdWithKey(jenny.Data.ID, "ad-1", body)
+	assert.NoError(t, err)
+	retried, err := client.postAdWithKey(jenny.Data.ID, "ad-1", body)
+	assert.NoError(t, err)
+	assert.Equal(t, ad.Data.ID, retried.Data.ID)
+
+	// тот же ключ с другим объявлением отклоняется, а у другого пользователя ключи свои
+	_, err = client.postAdWithKey(jenny.Data.ID, "ad-1", map[string]any{"title": "other", "text": "world", "category_id": testCategoryID})
+	assert.ErrorIs(t, err, ErrConflict)
+	other, err := client.postAdWithKey(oleg.Data.ID, "ad-1", body)
+	assert.NoError(t, err)
+	assert.NotEqual(t, ad.Data.ID, other.Data.ID)
+
+	list, err := client.listAds(map[string]string{"status": "draft,pending,published,archived,rejected"})
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 2)
+}
+
+func TestGRPCIdempotencyKey(t *testing.T) {
+	ctx, client := getTestGRCPClient(t)
+
+	keyCtx := metadata.AppendToOutgoingContext(ctx, "idempotency-key", "user-1")
+	user, err := client.CreateUser(keyCtx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@gmail.com", Password: testPassword})
+	assert.NoError(t, err)
+	var header metadata.MD
+	again, err := client.CreateUser(keyCtx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@gmail.com", Password: testPassword}, grpc.Header(&header))
+	assert.NoError(t, err)
+	assert.Equal(t, user.Id, again.Id)
+	assert.Equal(t, []string{"true"}, header.Get("idempotent-replayed"))
+
+	olegCtx := metadata.AppendToOutgoingContext(loginGRPC(t, ctx, client, user.Id), "idempotency-key", "ad-1")
+	ad, err := client.CreateAd(olegCtx, &grpcPort.CreateAdRequest{Title: "title", Text: "text", CategoryId: testCategoryID})
+	assert.NoError(t, err)
+	retried, err := client.CreateAd(olegCtx, &grpcPort.CreateAdRequest{Title: "title", Text: "text", CategoryId: testCategoryID})
+	assert.NoError(t, err)
+	assert.Equal(t, ad.Id, retried.Id)
+
+	_, err = client.CreateAd(olegCtx, &grpcPort.CreateAdRequest{Title: "other", Text: "text", CategoryId: testCategoryID})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+}
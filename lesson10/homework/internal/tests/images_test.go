@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/app"
+	"homework10/internal/images"
+)
+
+func testJPEG(t *testing.T, w, h int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h)), nil))
+	return buf.Bytes()
+}
+
+func TestAdImages(t *testing.T) {
+	client := getTestHTTPClient()
+
+	author, err := client.createUser("jenny", "jenny@gmail.com")
+	assert.NoError(t, err)
+	other, err := client.createUser("polly", "polly@gmail.com")
+	assert.NoError(t, err)
+
+	ad, err := client.createAd(author.Data.ID, "bike", "almost new")
+	assert.NoError(t, err)
+	assert.Empty(t, ad.Data.Images)
+
+	photo := testJPEG(t, 1024, 512)
+
+	_, err = client.addAdImage(other.Data.ID, ad.Data.ID, photo)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.addAdImage(author.Data.ID, ad.Data.ID, []byte("not an image"))
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.addAdImage(author.Data.ID, ad.Data.ID, make([]byte, images.MaxSize+1))
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	res, err := client.addAdImage(author.Data.ID, ad.Data.ID, photo)
+	assert.NoError(t, err)
+	if !assert.Len(t, res.Data.Images, 1) {
+		return
+	}
+	img := res.Data.Images[0]
+	assert.Equal(t, 1024, img.Width)
+	assert.Equal(t, 512, img.Height)
+
+	data, err := client.getImage(img.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, photo, data)
+
+	thumb, err := client.getImage(img.ThumbnailURL)
+	assert.NoError(t, err)
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(thumb))
+	assert.NoError(t, err)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, images.ThumbSize, cfg.Width)
+	assert.Equal(t, images.ThumbSize/2, cfg.Height)
+
+	// та же фотография у другого объявления хранится один раз и переживает удаление первого
+	second, err := client.createAd(author.Data.ID, "bike", "the same bike")
+	assert.NoError(t, err)
+	_, err = client.addAdImage(author.Data.ID, second.Data.ID, photo)
+	assert.NoError(t, err)
+
+	err = client.deleteAd(author.Data.ID, ad.Data.ID)
+	assert.NoError(t, err)
+	_, err = client.getImage(img.URL)
+	assert.NoError(t, err)
+
+	res, err = client.deleteAdImage(author.Data.ID, second.Data.ID, img.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, res.Data.Images)
+
+	_, err = client.getImage(img.URL)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = client.getImage(img.ThumbnailURL)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestAdImages_Limit(t *testing.T) {
+	client := getTestHTTPClient()
+
+	author, err := client.createUser("jenny", "jenny@gmail.com")
+	assert.NoError(t, err)
+	ad, err := client.createAd(author.Data.ID, "bike", "almost new")
+	assert.NoError(t, err)
+
+	for i := 0; i < app.MaxAdImages; i++ {
+		_, err = client.addAdImage(author.Data.ID, ad.Data.ID, testJPEG(t, 10+i, 10))
+		assert.NoError(t, err)
+	}
+
+	_, err = client.addAdImage(author.Data.ID, ad.Data.ID, testJPEG(t, 100, 10))
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
@@ -11,9 +11,6 @@ import (
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/test/bufconn"
 
-	"homework10/internal/adapters/adrepo"
-	"homework10/internal/adapters/userrepo"
-	"homework10/internal/app"
 	grpcPort "homework10/internal/ports/grpc"
 )
 
@@ -54,14 +51,14 @@ func BenchmarkCreateUserGRPC(b *testing.B) {
 	b.ResetTimer()
 
 	for i := 0; i < b.N; i++ {
-		_, _ = client.CreateUser(context.Background(), &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com"})
+		_, _ = client.CreateUser(context.Background(), &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com", Password: testPassword})
 	}
 }
 
 func setupGRPC() (grpcPort.AdServiceClient, func()) {
 	lis := bufconn.Listen(1024 * 1024)
 	server := grpc.NewServer()
-	service := grpcPort.NewService(app.NewApp(adrepo.New(), userrepo.New()))
+	service := grpcPort.NewService(newTestApp())
 	grpcPort.RegisterAdServiceServer(server, service)
 
 	go func() {
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"homework10/internal/adapters/userrepo"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/users"
+)
+
+func TestLifecycle(t *testing.T) {
+	userRepo := userrepo.New()
+	client := getTestHTTPClientWithApp(newTestAppWithUsers(userRepo))
+
+	author, err := client.createUser("jenny", "jenny@gmail.com")
+	assert.NoError(t, err)
+	moderator, err := client.createUser("polly", "polly@gmail.com")
+	assert.NoError(t, err)
+	promote(t, userRepo, moderator.Data.ID, users.RoleModerator)
+
+	ad, err := client.createAd(author.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+	assert.Equal(t, "draft", ad.Data.Status)
+
+	// черновик нельзя одобрить, пока автор не отправил его на модерацию
+	_, err = client.transitionAd(moderator.Data.ID, ad.Data.ID, "approve", "")
+	assert.ErrorIs(t, err, ErrConflict)
+
+	pending, err := client.transitionAd(author.Data.ID, ad.Data.ID, "submit", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "pending", pending.Data.Status)
+	assert.False(t, pending.Data.Published)
+
+	// автор не может одобрить своё объявление, а модератор не может отклонить его без причины
+	_, err = client.transitionAd(author.Data.ID, ad.Data.ID, "approve", "")
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.transitionAd(moderator.Data.ID, ad.Data.ID, "reject", "")
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	rejected, err := client.transitionAd(moderator.Data.ID, ad.Data.ID, "reject", "no photos")
+	assert.NoError(t, err)
+	assert.Equal(t, "rejected", rejected.Data.Status)
+	assert.Equal(t, "no photos", rejected.Data.Reason)
+
+	_, err = client.transitionAd(author.Data.ID, ad.Data.ID, "submit", "")
+	assert.NoError(t, err)
+	published, err := client.transitionAd(moderator.Data.ID, ad.Data.ID, "approve", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "published", published.Data.Status)
+	assert.True(t, published.Data.Published)
+	assert.Empty(t, published.Data.Reason)
+
+	draft, err := client.createAd(author.Data.ID, "draft", "text")
+	assert.NoError(t, err)
+
+	// событием publish черновик в обход модерации публикует только модератор
+	_, err = client.transitionAd(author.Data.ID, draft.Data.ID, "publish", "")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	// фильтр по статусу и прежний фильтр published=true/false
+	list, err := client.listAds(map[string]string{"status": "published"})
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 1)
+	assert.Equal(t, ad.Data.ID, list.Data[0].ID)
+
+	list, err = client.listAds(map[string]string{"status": "draft,pending"})
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 1)
+	assert.Equal(t, draft.Data.ID, list.Data[0].ID)
+
+	list, err = client.listAds(map[string]string{"published": "false"})
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 1)
+	assert.Equal(t, draft.Data.ID, list.Data[0].ID)
+
+	_, err = client.listAds(map[string]string{"status": "deleted"})
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	archived, err := client.transitionAd(moderator.Data.ID, ad.Data.ID, "archive", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "archived", archived.Data.Status)
+
+	// из архива объявление возвращает только автор
+	_, err = client.transitionAd(moderator.Data.ID, ad.Data.ID, "restore", "")
+	assert.ErrorIs(t, err, ErrForbidden)
+	restored, err := client.transitionAd(author.Data.ID, ad.Data.ID, "restore", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "draft", restored.Data.Status)
+}
+
+func TestGRPCLifecycle(t *testing.T) {
+	userRepo := userrepo.New()
+	ctx, client := getTestGRCPClientWithApp(t, newTestAppWithUsers(userRepo))
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com", Password: testPassword})
+	assert.NoError(t, err, "client.CreateUser")
+	_, err = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Polly", Email: "polly@gmail.com", Password: testPassword})
+	assert.NoError(t, err, "client.CreateUser")
+	promote(t, userRepo, 1, users.RoleModerator)
+
+	ad, err := client.CreateAd(loginGRPC(t, ctx, client, 0), &grpcPort.CreateAdRequest{Title: "Title", Text: "Text", CategoryId: testCategoryID})
+	assert.NoError(t, err, "client.CreateAd")
+	assert.Equal(t, "draft", ad.Status)
+
+	_, err = client.TransitionAd(loginGRPC(t, ctx, client, 1), &grpcPort.TransitionAdRequest{AdId: ad.Id, Event: "approve"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = client.TransitionAd(loginGRPC(t, ctx, client, 0), &grpcPort.TransitionAdRequest{AdId: ad.Id, Event: "submit"})
+	assert.NoError(t, err, "client.TransitionAd")
+
+	res, err := client.TransitionAd(loginGRPC(t, ctx, client, 1), &grpcPort.TransitionAdRequest{AdId: ad.Id, Event: "reject", Reason: "spam"})
+	assert.NoError(t, err, "client.TransitionAd")
+	assert.Equal(t, "rejected", res.Status)
+	assert.Equal(t, "spam", res.StatusReason)
+	assert.False(t, res.StatusChanged.AsTime().IsZero())
+
+	list, err := client.ListAds(ctx, &grpcPort.ListAdsRequest{Status: []string{"rejected"}})
+	assert.NoError(t, err, "client.ListAds")
+	assert.Len(t, list.List, 1)
+
+	_, err = client.ListAds(ctx, &grpcPort.ListAdsRequest{Status: []string{"deleted"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func TestMessages(t *testing.T) {
+	client := getTestHTTPClient()
+
+	seller, err := client.createUser("jenny", "jenny@gmail.com")
+	assert.NoError(t, err)
+	buyer, err := client.createUser("oleg", "oleg@gmail.com")
+	assert.NoError(t, err)
+	other, err := client.createUser("polly", "polly@gmail.com")
+	assert.NoError(t, err)
+
+	bike, err := client.createAd(seller.Data.ID, "Продам велосипед", "Почти новый")
+	assert.NoError(t, err)
+
+	// пока объявление не опубликовано, написать автору нельзя
+	_, err = client.contactSeller(buyer.Data.ID, bike.Data.ID, "Здравствуйте")
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	_, err = client.changeAdStatus(seller.Data.ID, bike.Data.ID, true)
+	assert.NoError(t, err)
+
+	_, err = client.contactSeller(seller.Data.ID, bike.Data.ID, "Здравствуйте")
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.contactSeller(buyer.Data.ID, bike.Data.ID, "")
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	first, err := client.contactSeller(buyer.Data.ID, bike.Data.ID, "Здравствуйте")
+	assert.NoError(t, err)
+	assert.Equal(t, buyer.Data.ID, first.Data.SenderID)
+
+	// следующее сообщение покупателя попадает в ту же переписку
+	second, err := client.contactSeller(buyer.Data.ID, bike.Data.ID, "Ещё продаёте?")
+	assert.NoError(t, err)
+	assert.Equal(t, first.Data.ThreadID, second.Data.ThreadID)
+	threadID := first.Data.ThreadID
+
+	threads, err := client.listThreads(seller.Data.ID, seller.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, threads.UnreadTotal)
+	if assert.Len(t, threads.Data, 1) {
+		assert.Equal(t, bike.Data.ID, threads.Data[0].AdID)
+		assert.Equal(t, buyer.Data.ID, threads.Data[0].BuyerID)
+		assert.Equal(t, 2, threads.Data[0].Unread)
+	}
+
+	// переписки и сообщения видят только участники
+	_, err = client.listThreads(other.Data.ID, seller.Data.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.listMessages(other.Data.ID, threadID, nil)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.sendMessage(other.Data.ID, threadID, "Я тоже хочу")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	page, err := client.listMessages(seller.Data.ID, threadID, map[string]string{"limit": "1"})
+	assert.NoError(t, err)
+	if assert.Len(t, page.Data, 1) {
+		assert.Equal(t, second.Data.ID, page.Data[0].ID)
+	}
+	assert.NotEmpty(t, page.NextCursor)
+
+	page, err = client.listMessages(seller.Data.ID, threadID, map[string]string{"limit": "1", "cursor": page.NextCursor})
+	assert.NoError(t, err)
+	if assert.Len(t, page.Data, 1) {
+		assert.Equal(t, "Здравствуйте", page.Data[0].Text)
+	}
+	assert.Empty(t, page.NextCursor)
+
+	_, err = client.listMessages(seller.Data.ID, threadID, map[string]string{"cursor": "bad"})
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	// прочитанная переписка больше не считается непрочитанной
+	threads, err = client.listThreads(seller.Data.ID, seller.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, threads.UnreadTotal)
+
+	_, err = client.sendMessage(seller.Data.ID, threadID, "Да, продаю")
+	assert.NoError(t, err)
+
+	threads, err = client.listThreads(buyer.Data.ID, buyer.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, threads.UnreadTotal)
+
+	_, err = client.sendMessage(buyer.Data.ID, 100, "Привет")
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.listMessages(buyer.Data.ID, threadID, map[string]string{"limit": "-1"})
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestGRPCMessages(t *testing.T) {
+	ctx, client := getTestGRCPClient(t)
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@gmail.com", Password: testPassword})
+	assert.NoError(t, err)
+	_, err = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Polly", Email: "polly@gmail.com", Password: testPassword})
+	assert.NoError(t, err)
+	olegCtx := loginGRPC(t, ctx, client, 0)
+	pollyCtx := loginGRPC(t, ctx, client, 1)
+
+	ad, err := client.CreateAd(olegCtx, &grpcPort.CreateAdRequest{Title: "title", Text: "text", CategoryId: testCategoryID})
+	assert.NoError(t, err)
+	_, err = client.ChangeAdStatus(olegCtx, &grpcPort.ChangeAdStatusRequest{AdId: ad.Id, Published: true})
+	assert.NoError(t, err)
+
+	_, err = client.ContactSeller(ctx, &grpcPort.ContactSellerRequest{AdId: ad.Id, Text: "Здравствуйте"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	m, err := client.ContactSeller(pollyCtx, &grpcPort.ContactSellerRequest{AdId: ad.Id, Text: "Здравствуйте"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), m.SenderId)
+
+	threads, err := client.ListThreads(olegCtx, &grpcPort.ListThreadsRequest{UserId: 0})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), threads.UnreadTotal)
+	if assert.Len(t, threads.List, 1) {
+		assert.Equal(t, m.ThreadId, threads.List[0].Id)
+	}
+
+	_, err = client.ListThreads(pollyCtx, &grpcPort.ListThreadsRequest{UserId: 0})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	reply, err := client.SendMessage(olegCtx, &grpcPort.SendMessageRequest{ThreadId: m.ThreadId, Text: "Да"})
+	assert.NoError(t, err)
+
+	list, err := client.ListMessages(pollyCtx, &grpcPort.ListMessagesRequest{ThreadId: m.ThreadId})
+	assert.NoError(t, err)
+	if assert.Len(t, list.List, 2) {
+		assert.Equal(t, reply.Id, list.List[0].Id)
+		assert.Equal(t, m.Id, list.List[1].Id)
+	}
+	assert.Empty(t, list.NextCursor)
+
+	threads, err = client.ListThreads(pollyCtx, &grpcPort.ListThreadsRequest{UserId: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), threads.UnreadTotal)
+}
@@ -0,0 +1,33 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/app"
+	"homework10/internal/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	m := metrics.New()
+	client := getTestHTTPClientWithMetrics(app.Observe(newTestApp(), m.AppObserver()), m)
+
+	user, err := client.createUser("jenny", "jenny@gmail.com")
+	assert.NoError(t, err)
+	_, err = client.createAd(user.Data.ID, "", "world")
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	resp, err := client.client.Get(client.baseURL + "/metrics")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	assert.Contains(t, string(body), `adapp_http_requests_total{code="200",method="POST",route="/api/v1/users"} 1`)
+	assert.Contains(t, string(body), `adapp_http_requests_total{code="400",method="POST",route="/api/v1/ads"} 1`)
+	assert.Contains(t, string(body), `adapp_app_errors_total{kind="bad_request",method="CreateAd"} 1`)
+}
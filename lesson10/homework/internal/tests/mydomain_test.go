@@ -15,7 +15,7 @@ func TestCreateAdByNonExistentUser(t *testing.T) {
 
 	// added
 	_, err = client.createAd(123, "hello", "world")
-	assert.ErrorIs(t, err, ErrBadRequest)
+	assert.ErrorIs(t, err, ErrUnauthorized)
 }
 
 func TestChangeStatusAdOfNonExistentUser(t *testing.T) {
@@ -29,7 +29,7 @@ func TestChangeStatusAdOfNonExistentUser(t *testing.T) {
 	assert.NoError(t, err)
 
 	resp, err = client.changeAdStatus(1, resp.Data.ID, true)
-	assert.ErrorIs(t, err, ErrBadRequest)
+	assert.ErrorIs(t, err, ErrUnauthorized)
 }
 
 func TestUpdateAdOfNonExistentUser(t *testing.T) {
@@ -47,5 +47,5 @@ func TestUpdateAdOfNonExistentUser(t *testing.T) {
 	assert.NoError(t, err)
 
 	_, err = client.updateAd(123, resp.Data.ID, "title", "text")
-	assert.ErrorIs(t, err, ErrBadRequest)
+	assert.ErrorIs(t, err, ErrUnauthorized)
 }
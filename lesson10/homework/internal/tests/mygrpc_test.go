@@ -13,10 +13,10 @@ import (
 func TestGRPCCreateAd(t *testing.T) {
 	ctx, client := getTestGRCPClient(t)
 
-	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com"})
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com", Password: testPassword})
 	assert.NoError(t, err, "client.CreateUser")
 
-	res, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "Title", Text: "Text", UserId: 0})
+	res, err := client.CreateAd(loginGRPC(t, ctx, client, 0), &grpcPort.CreateAdRequest{Title: "Title", Text: "Text", CategoryId: testCategoryID})
 	assert.NoError(t, err, "client.CreateAd")
 
 	assert.Zero(t, res.Id)
@@ -29,13 +29,13 @@ func TestGRPCCreateAd(t *testing.T) {
 func TestGRPCChangeAdStatus(t *testing.T) {
 	ctx, client := getTestGRCPClient(t)
 
-	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com"})
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com", Password: testPassword})
 	assert.NoError(t, err, "client.CreateUser")
 
-	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "Title", Text: "Text", UserId: 0})
+	_, err = client.CreateAd(loginGRPC(t, ctx, client, 0), &grpcPort.CreateAdRequest{Title: "Title", Text: "Text", CategoryId: testCategoryID})
 	assert.NoError(t, err, "client.CreateAd")
 
-	res, err := client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{AdId: 0, UserId: 0, Published: true})
+	res, err := client.ChangeAdStatus(loginGRPC(t, ctx, client, 0), &grpcPort.ChangeAdStatusRequest{AdId: 0, Published: true})
 	assert.NoError(t, err, "client.ChangeAdStatus")
 
 	assert.Zero(t, res.Id)
@@ -48,13 +48,13 @@ func TestGRPCChangeAdStatus(t *testing.T) {
 func TestGRPCUpdateAd(t *testing.T) {
 	ctx, client := getTestGRCPClient(t)
 
-	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com"})
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com", Password: testPassword})
 	assert.NoError(t, err, "client.CreateUser")
 
-	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "Title", Text: "Text", UserId: 0})
+	_, err = client.CreateAd(loginGRPC(t, ctx, client, 0), &grpcPort.CreateAdRequest{Title: "Title", Text: "Text", CategoryId: testCategoryID})
 	assert.NoError(t, err, "client.CreateAd")
 
-	res, err := client.UpdateAd(ctx, &grpcPort.UpdateAdRequest{AdId: 0, Title: "New title", Text: "New text", UserId: 0})
+	res, err := client.UpdateAd(loginGRPC(t, ctx, client, 0), &grpcPort.UpdateAdRequest{AdId: 0, Title: "New title", Text: "New text"})
 	assert.NoError(t, err, "client.UpdateAd")
 
 	assert.Zero(t, res.Id)
@@ -67,13 +67,13 @@ func TestGRPCUpdateAd(t *testing.T) {
 func TestGRPCGetAd(t *testing.T) {
 	ctx, client := getTestGRCPClient(t)
 
-	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com"})
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com", Password: testPassword})
 	assert.NoError(t, err, "client.CreateUser")
 
-	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "First title", Text: "First text", UserId: 0})
+	_, err = client.CreateAd(loginGRPC(t, ctx, client, 0), &grpcPort.CreateAdRequest{Title: "First title", Text: "First text", CategoryId: testCategoryID})
 	assert.NoError(t, err, "client.CreateAd")
 
-	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "Second title", Text: "Second text", UserId: 0})
+	_, err = client.CreateAd(loginGRPC(t, ctx, client, 0), &grpcPort.CreateAdRequest{Title: "Second title", Text: "Second text", CategoryId: testCategoryID})
 	assert.NoError(t, err, "client.CreateAd")
 
 	res, err := client.GetAd(ctx, &grpcPort.GetAdRequest{Id: 1})
@@ -89,27 +89,27 @@ func TestGRPCGetAd(t *testing.T) {
 func TestGRPCListAds(t *testing.T) {
 	ctx, client := getTestGRCPClient(t)
 
-	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com"})
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com", Password: testPassword})
 	assert.NoError(t, err, "client.CreateUser")
 
-	_, err = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Polly", Email: "polly@gmail.com"})
+	_, err = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Polly", Email: "polly@gmail.com", Password: testPassword})
 	assert.NoError(t, err, "client.CreateUser")
 
 	tc := time.Now().UTC()
 
-	ad0, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", UserId: 0})
+	ad0, err := client.CreateAd(loginGRPC(t, ctx, client, 0), &grpcPort.CreateAdRequest{Title: "hello", Text: "world", CategoryId: testCategoryID})
 	assert.NoError(t, err, "client.CreateAd")
 
-	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "hello", Text: "friend", UserId: 1})
+	_, err = client.CreateAd(loginGRPC(t, ctx, client, 1), &grpcPort.CreateAdRequest{Title: "hello", Text: "friend", CategoryId: testCategoryID})
 	assert.NoError(t, err, "client.CreateAd")
 
-	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "hey", Text: "привет", UserId: 0})
+	_, err = client.CreateAd(loginGRPC(t, ctx, client, 0), &grpcPort.CreateAdRequest{Title: "hey", Text: "привет", CategoryId: testCategoryID})
 	assert.NoError(t, err, "client.CreateAd")
 
-	res, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "goodbye", Text: "friend", UserId: 1})
+	res, err := client.CreateAd(loginGRPC(t, ctx, client, 1), &grpcPort.CreateAdRequest{Title: "goodbye", Text: "friend", CategoryId: testCategoryID})
 	assert.NoError(t, err, "client.CreateAd")
 
-	_, err = client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{AdId: res.Id, UserId: res.UserId, Published: true})
+	_, err = client.ChangeAdStatus(loginGRPC(t, ctx, client, res.UserId), &grpcPort.ChangeAdStatusRequest{AdId: res.Id, Published: true})
 	assert.NoError(t, err, "client.ChangeAdStatus")
 
 	published := false
@@ -130,13 +130,13 @@ func TestGRPCListAds(t *testing.T) {
 func TestGRPCDeleteAd(t *testing.T) {
 	ctx, client := getTestGRCPClient(t)
 
-	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com"})
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com", Password: testPassword})
 	assert.NoError(t, err, "client.CreateUser")
 
-	ad, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "Title", Text: "Text", UserId: 0})
+	ad, err := client.CreateAd(loginGRPC(t, ctx, client, 0), &grpcPort.CreateAdRequest{Title: "Title", Text: "Text", CategoryId: testCategoryID})
 	assert.NoError(t, err, "client.CreateAd")
 
-	res, err := client.DeleteAd(ctx, &grpcPort.DeleteAdRequest{AdId: ad.Id, UserId: ad.UserId})
+	res, err := client.DeleteAd(loginGRPC(t, ctx, client, ad.UserId), &grpcPort.DeleteAdRequest{AdId: ad.Id})
 	assert.NoError(t, err, "client.DeleteAd")
 
 	assert.Equal(t, ad.Id, res.Id)
@@ -149,10 +149,10 @@ func TestGRPCDeleteAd(t *testing.T) {
 func TestGRPCGetUser(t *testing.T) {
 	ctx, client := getTestGRCPClient(t)
 
-	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com"})
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com", Password: testPassword})
 	assert.NoError(t, err, "client.CreateUser")
 
-	_, err = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Polly", Email: "polly@gmail.com"})
+	_, err = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Polly", Email: "polly@gmail.com", Password: testPassword})
 	assert.NoError(t, err, "client.CreateUser")
 
 	res, err := client.GetUser(ctx, &grpcPort.GetUserRequest{Id: 1})
@@ -166,10 +166,10 @@ func TestGRPCGetUser(t *testing.T) {
 func TestGRPCUpdateUser(t *testing.T) {
 	ctx, client := getTestGRCPClient(t)
 
-	u, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com"})
+	u, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com", Password: testPassword})
 	assert.NoError(t, err, "client.CreateUser")
 
-	res, err := client.UpdateUser(ctx, &grpcPort.UpdateUserRequest{Id: u.Id, Nickname: "Polly", Email: "polly@gmail.com"})
+	res, err := client.UpdateUser(loginGRPC(t, ctx, client, u.Id), &grpcPort.UpdateUserRequest{Id: u.Id, Nickname: "Polly", Email: "polly@gmail.com"})
 	assert.NoError(t, err, "client.DeleteUser")
 
 	assert.Equal(t, u.Id, res.Id)
@@ -180,10 +180,10 @@ func TestGRPCUpdateUser(t *testing.T) {
 func TestGRPCDeleteUser(t *testing.T) {
 	ctx, client := getTestGRCPClient(t)
 
-	u, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com"})
+	u, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com", Password: testPassword})
 	assert.NoError(t, err, "client.CreateUser")
 
-	res, err := client.DeleteUser(ctx, &grpcPort.DeleteUserRequest{Id: u.Id})
+	res, err := client.DeleteUser(loginGRPC(t, ctx, client, u.Id), &grpcPort.DeleteUserRequest{Id: u.Id})
 	assert.NoError(t, err, "client.DeleteUser")
 
 	assert.Equal(t, u.Id, res.Id)
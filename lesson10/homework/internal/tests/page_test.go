@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func TestListAds_Pages(t *testing.T) {
+	client := getTestHTTPClient()
+
+	_, err := client.createUser("jenny", "jenny@gmail.com")
+	assert.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, err = client.createAd(0, fmt.Sprintf("title %d", i), "text")
+		assert.NoError(t, err)
+	}
+
+	var titles []string
+	params := map[string]string{"limit": "2", "sort": "title:desc"}
+	for pages := 0; ; pages++ {
+		assert.Less(t, pages, 3)
+
+		resp, err := client.listAds(params)
+		assert.NoError(t, err)
+		assert.LessOrEqual(t, len(resp.Data), 2)
+		for _, ad := range resp.Data {
+			titles = append(titles, ad.Title)
+		}
+
+		if resp.NextCursor == "" {
+			break
+		}
+		params["cursor"] = resp.NextCursor
+	}
+	assert.Equal(t, []string{"title 4", "title 3", "title 2", "title 1", "title 0"}, titles)
+
+	// курсор выдан для другой сортировки
+	params["sort"] = "title:asc"
+	_, err = client.listAds(params)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	_, err = client.listAds(map[string]string{"sort": "author"})
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	_, err = client.listAds(map[string]string{"limit": "-1"})
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestGRPCListAds_Pages(t *testing.T) {
+	ctx, client := getTestGRCPClient(t)
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@gmail.com", Password: testPassword})
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = client.CreateAd(loginGRPC(t, ctx, client, 0), &grpcPort.CreateAdRequest{Title: fmt.Sprintf("title %d", i), Text: "text", CategoryId: testCategoryID})
+		assert.NoError(t, err)
+	}
+
+	res, err := client.ListAds(ctx, &grpcPort.ListAdsRequest{Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, res.List, 2)
+	assert.Equal(t, int64(0), res.List[0].Id)
+	assert.Equal(t, int64(1), res.List[1].Id)
+	assert.NotEmpty(t, res.NextCursor)
+
+	res, err = client.ListAds(ctx, &grpcPort.ListAdsRequest{Limit: 2, Cursor: res.NextCursor})
+	assert.NoError(t, err)
+	assert.Len(t, res.List, 1)
+	assert.Equal(t, int64(2), res.List[0].Id)
+	assert.Empty(t, res.NextCursor)
+
+	_, err = client.ListAds(ctx, &grpcPort.ListAdsRequest{Sort: "created:up"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.ListAds(ctx, &grpcPort.ListAdsRequest{Cursor: "not a cursor"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
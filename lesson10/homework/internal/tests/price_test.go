@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func TestPrice(t *testing.T) {
+	client := getTestHTTPClient()
+
+	user, err := client.createUser("jenny", "jenny@gmail.com")
+	assert.NoError(t, err)
+
+	_, err = client.createPricedAd(user.Data.ID, "Без валюты", 1000, "")
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.createPricedAd(user.Data.ID, "Неизвестная валюта", 1000, "ABC")
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.createPricedAd(user.Data.ID, "Отрицательная цена", -1, "RUB")
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	bike, err := client.createPricedAd(user.Data.ID, "Велосипед", 1500000, "RUB")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1500000), bike.Data.Price)
+	assert.Equal(t, "RUB", bike.Data.Currency)
+
+	sofa, err := client.createPricedAd(user.Data.ID, "Диван", 2500000, "RUB")
+	assert.NoError(t, err)
+	phone, err := client.createPricedAd(user.Data.ID, "Телефон", 30000, "USD")
+	assert.NoError(t, err)
+	noPrice, err := client.createAd(user.Data.ID, "Котята", "Отдам в добрые руки")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), noPrice.Data.Price)
+	assert.Empty(t, noPrice.Data.Currency)
+
+	res, err := client.listAds(map[string]string{"currency": "rub", "sort": "price:desc"})
+	assert.NoError(t, err)
+	if assert.Len(t, res.Data, 2) {
+		assert.Equal(t, sofa.Data.ID, res.Data[0].ID)
+		assert.Equal(t, bike.Data.ID, res.Data[1].ID)
+	}
+
+	// объявления без цены не попадают ни в какой ценовой диапазон
+	res, err = client.listAds(map[string]string{"price_max": "2000000", "sort": "price"})
+	assert.NoError(t, err)
+	if assert.Len(t, res.Data, 2) {
+		assert.Equal(t, phone.Data.ID, res.Data[0].ID)
+		assert.Equal(t, bike.Data.ID, res.Data[1].ID)
+	}
+
+	res, err = client.listAds(map[string]string{"price_min": "1000000", "price_max": "2000000", "currency": "RUB"})
+	assert.NoError(t, err)
+	if assert.Len(t, res.Data, 1) {
+		assert.Equal(t, bike.Data.ID, res.Data[0].ID)
+	}
+
+	res, err = client.listAds(map[string]string{"filter": "price >= 1000000"})
+	assert.NoError(t, err)
+	assert.Len(t, res.Data, 2)
+
+	_, err = client.listAds(map[string]string{"currency": "рубли"})
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestGRPCPrice(t *testing.T) {
+	ctx, client := getTestGRCPClient(t)
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@gmail.com", Password: testPassword})
+	assert.NoError(t, err)
+	ctx = loginGRPC(t, ctx, client, 0)
+
+	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "title", Text: "text", CategoryId: testCategoryID, Price: 100, Currency: "XXX"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	ad, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "title", Text: "text", CategoryId: testCategoryID, Price: 1000, Currency: "EUR"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), ad.Price)
+	assert.Equal(t, "EUR", ad.Currency)
+
+	price, currency := int64(900), "EUR"
+	ad, err = client.UpdateAd(ctx, &grpcPort.UpdateAdRequest{AdId: ad.Id, Title: "title", Text: "text", Price: &price, Currency: &currency})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(900), ad.Price)
+
+	// без цены в запросе цена остаётся прежней
+	ad, err = client.UpdateAd(ctx, &grpcPort.UpdateAdRequest{AdId: ad.Id, Title: "new title", Text: "text"})
+	assert.NoError(t, err)
+	assert.Equal(t, "new title", ad.Title)
+	assert.Equal(t, int64(900), ad.Price)
+	assert.Equal(t, "EUR", ad.Currency)
+
+	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "title", Text: "text", CategoryId: testCategoryID, Price: 5000, Currency: "EUR"})
+	assert.NoError(t, err)
+
+	priceMax := int64(1000)
+	list, err := client.ListAds(ctx, &grpcPort.ListAdsRequest{PriceMax: &priceMax, Currency: "EUR", Sort: "price"})
+	assert.NoError(t, err)
+	if assert.Len(t, list.List, 1) {
+		assert.Equal(t, ad.Id, list.List[0].Id)
+	}
+
+	_, err = client.ListAds(ctx, &grpcPort.ListAdsRequest{Currency: "евро"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/ratelimit"
+)
+
+func TestRateLimit(t *testing.T) {
+	client := getTestHTTPClientWithLimiter(newTestApp(), ratelimit.New(ratelimit.Rules{
+		Default: ratelimit.Limit{Count: 100, Per: time.Minute},
+		Routes:  map[string]ratelimit.Limit{"POST /api/v1/ads": {Count: 2, Per: time.Minute}},
+	}, 0))
+
+	jenny, err := client.createUser("jenny", "jenny@gmail.com")
+	assert.NoError(t, err)
+	oleg, err := client.createUser("oleg", "oleg@gmail.com")
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, err = client.createAd(jenny.Data.ID, "hello", "world")
+		assert.NoError(t, err)
+	}
+	_, err = client.createAd(jenny.Data.ID, "hello", "world")
+	assert.ErrorIs(t, err, ErrTooMany)
+
+	req, err := http.NewRequest(http.MethodPost, client.baseURL+"/api/v1/ads", nil)
+	assert.NoError(t, err)
+	client.authorize(req, jenny.Data.ID)
+	resp, err := client.client.Do(req)
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "30", resp.Header.Get("Retry-After"))
+
+	// лимит считается отдельно для каждого пользователя и маршрута
+	_, err = client.createAd(oleg.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+	_, err = client.listAds(nil)
+	assert.NoError(t, err)
+}
+
+func TestGRPCRateLimit(t *testing.T) {
+	ctx, client := getTestGRCPClientWithLimiter(t, newTestApp(), ratelimit.New(ratelimit.Rules{
+		Routes: map[string]ratelimit.Limit{"/ad.AdService/CreateAd": {Count: 1, Per: time.Minute}},
+	}, 0))
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@gmail.com", Password: testPassword})
+	assert.NoError(t, err)
+	olegCtx := loginGRPC(t, ctx, client, 0)
+
+	_, err = client.CreateAd(olegCtx, &grpcPort.CreateAdRequest{Title: "title", Text: "text", CategoryId: testCategoryID})
+	assert.NoError(t, err)
+
+	var header metadata.MD
+	_, err = client.CreateAd(olegCtx, &grpcPort.CreateAdRequest{Title: "title", Text: "text", CategoryId: testCategoryID}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"60"}, header.Get("retry-after"))
+
+	_, err = client.ListAds(olegCtx, &grpcPort.ListAdsRequest{})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/ports/httpgin"
+)
+
+func TestRequestID(t *testing.T) {
+	client := getTestHTTPClient()
+	do := func(id, key string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, client.baseURL+"/api/v1/users",
+			strings.NewReader(`{"nickname":"jenny","email":"jenny@gmail.com","password":"`+testPassword+`"}`))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if id != "" {
+			req.Header.Set(httpgin.HeaderRequestID, id)
+		}
+		if key != "" {
+			req.Header.Set(httpgin.HeaderIdempotencyKey, key)
+		}
+		resp, err := client.client.Do(req)
+		assert.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp
+	}
+
+	assert.Equal(t, "client-id-1", do("client-id-1", "user-1").Header.Get(httpgin.HeaderRequestID))
+
+	// у повторённого ответа ID повтора, а не первого запроса
+	replayed := do("", "user-1")
+	assert.Equal(t, "true", replayed.Header.Get(httpgin.HeaderIdempotentReplayed))
+	assert.Len(t, replayed.Header.Get(httpgin.HeaderRequestID), 32)
+}
+
+func TestGRPCRequestID(t *testing.T) {
+	ctx, client := getTestGRCPClient(t)
+
+	var header metadata.MD
+	idCtx := metadata.AppendToOutgoingContext(ctx, grpcPort.MetadataRequestID, "client-id-1")
+	_, err := client.CreateUser(idCtx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@gmail.com", Password: testPassword}, grpc.Header(&header))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"client-id-1"}, header.Get(grpcPort.MetadataRequestID))
+
+	header = nil
+	_, err = client.GetUser(ctx, &grpcPort.GetUserRequest{Id: 100}, grpc.Header(&header))
+	assert.Error(t, err)
+	assert.Len(t, header.Get(grpcPort.MetadataRequestID), 1)
+	assert.Len(t, header.Get(grpcPort.MetadataRequestID)[0], 32)
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"homework10/internal/adapters/userrepo"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/users"
+)
+
+// promote назначает роль в обход API - так же, как это делает флаг -admin
+func promote(t *testing.T, userRepo users.Repository, userID int64, role users.Role) {
+	t.Helper()
+
+	u, err := userRepo.UserByID(context.Background(), userID)
+	assert.NoError(t, err)
+	u.Role = role
+	assert.NoError(t, userRepo.UpdateUser(context.Background(), u))
+}
+
+func TestRoles(t *testing.T) {
+	userRepo := userrepo.New()
+	client := getTestHTTPClientWithApp(newTestAppWithUsers(userRepo))
+
+	admin, err := client.createUser("admin", "admin@gmail.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "user", admin.Data.Role)
+	promote(t, userRepo, admin.Data.ID, users.RoleAdmin)
+
+	author, err := client.createUser("jenny", "jenny@gmail.com")
+	assert.NoError(t, err)
+
+	moderator, err := client.createUser("polly", "polly@gmail.com")
+	assert.NoError(t, err)
+
+	ad, err := client.createAd(author.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(author.Data.ID, ad.Data.ID, true)
+	assert.NoError(t, err)
+
+	// пока polly обычный пользователь, чужое объявление ей не снять
+	_, err = client.changeAdStatus(moderator.Data.ID, ad.Data.ID, false)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	// назначать роли может только администратор
+	_, err = client.changeUserRole(moderator.Data.ID, moderator.Data.ID, "moderator")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = client.changeUserRole(admin.Data.ID, moderator.Data.ID, "king")
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	res, err := client.changeUserRole(admin.Data.ID, moderator.Data.ID, "moderator")
+	assert.NoError(t, err)
+	assert.Equal(t, "moderator", res.Data.Role)
+
+	// модератор снимает объявление с публикации, но не может его править или опубликовать
+	_, err = client.updateAd(moderator.Data.ID, ad.Data.ID, "title", "text")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	unpublished, err := client.changeAdStatus(moderator.Data.ID, ad.Data.ID, false)
+	assert.NoError(t, err)
+	assert.False(t, unpublished.Data.Published)
+
+	_, err = client.changeAdStatus(moderator.Data.ID, ad.Data.ID, true)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	err = client.deleteAd(moderator.Data.ID, ad.Data.ID)
+	assert.NoError(t, err)
+
+	// модератор не управляет пользователями, администратор - управляет
+	_, err = client.updateUserAs(moderator.Data.ID, author.Data.ID, "jane", "jane@gmail.com")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	updated, err := client.updateUserAs(admin.Data.ID, author.Data.ID, "jane", "jane@gmail.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "jane", updated.Data.Nickname)
+}
+
+func TestGRPCRoles(t *testing.T) {
+	userRepo := userrepo.New()
+	ctx, client := getTestGRCPClientWithApp(t, newTestAppWithUsers(userRepo))
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com", Password: testPassword})
+	assert.NoError(t, err, "client.CreateUser")
+
+	_, err = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Polly", Email: "polly@gmail.com", Password: testPassword})
+	assert.NoError(t, err, "client.CreateUser")
+
+	ad, err := client.CreateAd(loginGRPC(t, ctx, client, 0), &grpcPort.CreateAdRequest{Title: "Title", Text: "Text", CategoryId: testCategoryID})
+	assert.NoError(t, err, "client.CreateAd")
+
+	_, err = client.DeleteAd(loginGRPC(t, ctx, client, 1), &grpcPort.DeleteAdRequest{AdId: ad.Id})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.ChangeUserRole(loginGRPC(t, ctx, client, 1), &grpcPort.ChangeUserRoleRequest{Id: 1, Role: "admin"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	u, err := client.GetUser(ctx, &grpcPort.GetUserRequest{Id: 1})
+	assert.NoError(t, err, "client.GetUser")
+	assert.Equal(t, "user", u.Role)
+
+	promote(t, userRepo, 1, users.RoleModerator)
+
+	_, err = client.UpdateAd(loginGRPC(t, ctx, client, 1), &grpcPort.UpdateAdRequest{AdId: ad.Id, Title: "title", Text: "text"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	deleted, err := client.DeleteAd(loginGRPC(t, ctx, client, 1), &grpcPort.DeleteAdRequest{AdId: ad.Id})
+	assert.NoError(t, err, "client.DeleteAd")
+	assert.Equal(t, ad.Id, deleted.Id)
+}
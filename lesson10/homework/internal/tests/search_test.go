@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func TestListAds_Query(t *testing.T) {
+	client := getTestHTTPClient()
+
+	_, err := client.createUser("jenny", "jenny@gmail.com")
+	assert.NoError(t, err)
+
+	_, err = client.createAd(0, "Продам горный велосипед", "Почти новый")
+	assert.NoError(t, err)
+	_, err = client.createAd(0, "Продам диван", "Раскладной")
+	assert.NoError(t, err)
+	_, err = client.createAd(0, "Велосипед детский", "Детский велосипед, велосипедный шлем в подарок")
+	assert.NoError(t, err)
+
+	resp, err := client.listAds(map[string]string{"q": "велосипеды"})
+	assert.NoError(t, err)
+	if assert.Len(t, resp.Data, 2) {
+		assert.Equal(t, int64(2), resp.Data[0].ID)
+		assert.Equal(t, int64(0), resp.Data[1].ID)
+	}
+
+	resp, err = client.listAds(map[string]string{"q": "велосипеды", "sort": "created"})
+	assert.NoError(t, err)
+	if assert.Len(t, resp.Data, 2) {
+		assert.Equal(t, int64(0), resp.Data[0].ID)
+		assert.Equal(t, int64(2), resp.Data[1].ID)
+	}
+
+	resp, err = client.listAds(map[string]string{"q": "продам", "limit": "1"})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data, 1)
+	assert.NotEmpty(t, resp.NextCursor)
+
+	resp, err = client.listAds(map[string]string{"q": "продам", "limit": "1", "cursor": resp.NextCursor})
+	assert.NoError(t, err)
+	assert.Len(t, resp.Data, 1)
+	assert.Empty(t, resp.NextCursor)
+
+	resp, err = client.listAds(map[string]string{"q": "самокат"})
+	assert.NoError(t, err)
+	assert.Empty(t, resp.Data)
+}
+
+func TestGRPCListAds_Query(t *testing.T) {
+	ctx, client := getTestGRCPClient(t)
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@gmail.com", Password: testPassword})
+	assert.NoError(t, err)
+
+	_, err = client.CreateAd(loginGRPC(t, ctx, client, 0), &grpcPort.CreateAdRequest{Title: "Mountain bike", Text: "Almost new", CategoryId: testCategoryID})
+	assert.NoError(t, err)
+	_, err = client.CreateAd(loginGRPC(t, ctx, client, 0), &grpcPort.CreateAdRequest{Title: "Sofa", Text: "Comfortable sofa", CategoryId: testCategoryID})
+	assert.NoError(t, err)
+
+	res, err := client.ListAds(ctx, &grpcPort.ListAdsRequest{Query: "bikes"})
+	assert.NoError(t, err)
+	if assert.Len(t, res.List, 1) {
+		assert.Equal(t, "Mountain bike", res.List[0].Title)
+	}
+}
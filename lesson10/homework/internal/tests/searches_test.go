@@ -0,0 +1,151 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func TestSavedSearches(t *testing.T) {
+	client := getTestHTTPClient()
+
+	author, err := client.createUser("jenny", "jenny@gmail.com")
+	assert.NoError(t, err)
+	buyer, err := client.createUser("oleg", "oleg@gmail.com")
+	assert.NoError(t, err)
+
+	_, err = client.createSavedSearch(buyer.Data.ID, "", map[string]string{"q": "велосипед"})
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.createSavedSearch(buyer.Data.ID, "Плохой", map[string]string{"filter": "title"})
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	bikes, err := client.createSavedSearch(buyer.Data.ID, "Велосипеды", map[string]string{"q": "велосипед"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Велосипеды", bikes.Data.Name)
+	assert.Equal(t, map[string]any{"q": "велосипед"}, bikes.Data.Query)
+	published, err := client.createSavedSearch(buyer.Data.ID, "Опубликованные", map[string]string{
+		"published": "true",
+		"category":  fmt.Sprint(testCategoryID),
+	})
+	assert.NoError(t, err)
+
+	// черновик подходит только под поиск по словам, своё объявление и неподходящее - ни под какой
+	bike, err := client.createAd(author.Data.ID, "Продам велосипед", "Почти новый")
+	assert.NoError(t, err)
+	_, err = client.createAd(author.Data.ID, "Продам диван", "Раскладной")
+	assert.NoError(t, err)
+	_, err = client.createAd(buyer.Data.ID, "Куплю велосипед", "Горный")
+	assert.NoError(t, err)
+
+	list, err := client.listNotifications(buyer.Data.ID, buyer.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, list.UnreadTotal)
+	if assert.Len(t, list.Data, 1) {
+		assert.Equal(t, bikes.Data.ID, list.Data[0].SearchID)
+		assert.Equal(t, bike.Data.ID, list.Data[0].AdID)
+		assert.Equal(t, "Продам велосипед", list.Data[0].Title)
+	}
+
+	// публикация уведомляет по второму поиску, по первому повторно не уведомляет
+	_, err = client.changeAdStatus(author.Data.ID, bike.Data.ID, true)
+	assert.NoError(t, err)
+
+	list, err = client.listNotifications(buyer.Data.ID, buyer.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, list.UnreadTotal)
+	if assert.Len(t, list.Data, 2) {
+		assert.Equal(t, published.Data.ID, list.Data[0].SearchID)
+		assert.Equal(t, bike.Data.ID, list.Data[0].AdID)
+	}
+
+	list, err = client.readNotifications(buyer.Data.ID, list.Data[1].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, list.UnreadTotal)
+	if assert.Len(t, list.Data, 2) {
+		assert.False(t, list.Data[0].Read)
+		assert.True(t, list.Data[1].Read)
+	}
+
+	// чужие поиски и уведомления не видны
+	_, err = client.listSavedSearches(author.Data.ID, buyer.Data.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.listNotifications(author.Data.ID, buyer.Data.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.deleteSavedSearch(author.Data.ID, bikes.Data.ID)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	// по удалённому поиску уведомления больше не приходят
+	_, err = client.deleteSavedSearch(buyer.Data.ID, bikes.Data.ID)
+	assert.NoError(t, err)
+	searches, err := client.listSavedSearches(buyer.Data.ID, buyer.Data.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, searches.Data, 1) {
+		assert.Equal(t, published.Data.ID, searches.Data[0].ID)
+	}
+
+	_, err = client.createAd(author.Data.ID, "Велосипед детский", "Трёхколёсный")
+	assert.NoError(t, err)
+	list, err = client.listNotifications(buyer.Data.ID, buyer.Data.ID)
+	assert.NoError(t, err)
+	assert.Len(t, list.Data, 2)
+}
+
+func TestGRPCSavedSearches(t *testing.T) {
+	ctx, client := getTestGRCPClient(t)
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@gmail.com", Password: testPassword})
+	assert.NoError(t, err)
+	_, err = client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Polly", Email: "polly@gmail.com", Password: testPassword})
+	assert.NoError(t, err)
+	olegCtx := loginGRPC(t, ctx, client, 0)
+	pollyCtx := loginGRPC(t, ctx, client, 1)
+
+	_, err = client.CreateSavedSearch(olegCtx, &grpcPort.CreateSavedSearchRequest{UserId: 1, Name: "Самокаты"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	priceMax := int64(5000)
+	found, err := client.CreateSavedSearch(pollyCtx, &grpcPort.CreateSavedSearchRequest{
+		UserId: 1,
+		Name:   "Самокаты",
+		Filter: &grpcPort.ListAdsRequest{Query: "самокат", PriceMax: &priceMax, Currency: "RUB"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "самокат", found.Filter.Query)
+	assert.Equal(t, priceMax, found.Filter.GetPriceMax())
+
+	_, err = client.CreateAd(olegCtx, &grpcPort.CreateAdRequest{Title: "Самокат", Text: "дорогой", CategoryId: testCategoryID, Price: 10000, Currency: "RUB"})
+	assert.NoError(t, err)
+	ad, err := client.CreateAd(olegCtx, &grpcPort.CreateAdRequest{Title: "Самокат", Text: "дешёвый", CategoryId: testCategoryID, Price: 3000, Currency: "RUB"})
+	assert.NoError(t, err)
+
+	list, err := client.ListNotifications(pollyCtx, &grpcPort.ListNotificationsRequest{UserId: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), list.UnreadTotal)
+	if assert.Len(t, list.List, 1) {
+		assert.Equal(t, found.Id, list.List[0].SearchId)
+		assert.Equal(t, ad.Id, list.List[0].AdId)
+	}
+
+	list, err = client.ReadNotifications(pollyCtx, &grpcPort.ReadNotificationsRequest{UserId: 1, UpTo: list.List[0].Id})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), list.UnreadTotal)
+
+	_, err = client.UpdateSavedSearch(pollyCtx, &grpcPort.UpdateSavedSearchRequest{UserId: 1, Id: found.Id, Name: "Всё"})
+	assert.NoError(t, err)
+	searches, err := client.ListSavedSearches(pollyCtx, &grpcPort.ListSavedSearchesRequest{UserId: 1})
+	assert.NoError(t, err)
+	if assert.Len(t, searches.List, 1) {
+		assert.Equal(t, "Всё", searches.List[0].Name)
+		assert.Empty(t, searches.List[0].Filter.Query)
+	}
+
+	_, err = client.DeleteSavedSearch(pollyCtx, &grpcPort.DeleteSavedSearchRequest{UserId: 1, Id: found.Id})
+	assert.NoError(t, err)
+	_, err = client.DeleteSavedSearch(pollyCtx, &grpcPort.DeleteSavedSearchRequest{UserId: 1, Id: found.Id})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
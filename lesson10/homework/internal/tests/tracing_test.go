@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc/metadata"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/hookrepo"
+	"homework10/internal/adapters/outboxrepo"
+	"homework10/internal/adapters/userrepo"
+	"homework10/internal/app"
+	"homework10/internal/feed"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/tracing"
+)
+
+const traceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+// spanNames возвращает имена спанов, вложенных в спан parent (включая его самого), в порядке завершения
+func spanNames(spans []trace.ReadOnlySpan, parent trace.ReadOnlySpan) []string {
+	children := make(map[string][]trace.ReadOnlySpan)
+	for _, span := range spans {
+		children[span.Parent().SpanID().String()] = append(children[span.Parent().SpanID().String()], span)
+	}
+
+	var names []string
+	var walk func(span trace.ReadOnlySpan)
+	walk = func(span trace.ReadOnlySpan) {
+		for _, child := range children[span.SpanContext().SpanID().String()] {
+			walk(child)
+		}
+		names = append(names, span.Name())
+	}
+	walk(parent)
+	return names
+}
+
+func TestTracing(t *testing.T) {
+	tp, sr := tracing.NewRecorder()
+	a := newTestAppWithRepos(tracing.Ads(adrepo.New(), tp), tracing.Users(userrepo.New(), tp), outboxrepo.New(), hookrepo.New(), feed.NewHub(0))
+	client := getTestHTTPClientWithTracing(app.Observe(a, tracing.AppObserver(tp)), tp)
+
+	req, err := http.NewRequest(http.MethodPost, client.baseURL+"/api/v1/users",
+		strings.NewReader(`{"nickname":"jenny","email":"jenny@gmail.com","password":"`+testPassword+`"}`))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("traceparent", traceParent)
+	resp, err := client.client.Do(req)
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+
+	spans := sr.Ended()
+	server := spans[len(spans)-1]
+	assert.Equal(t, "POST /api/v1/users", server.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	// запрос -> вызов приложения -> вызовы хранилища
+	assert.Equal(t, []string{"users.AddUser", "app.CreateUser", "POST /api/v1/users"}, spanNames(spans, server))
+}
+
+func TestGRPCTracing(t *testing.T) {
+	tp, sr := tracing.NewRecorder()
+	a := newTestAppWithRepos(tracing.Ads(adrepo.New(), tp), tracing.Users(userrepo.New(), tp), outboxrepo.New(), hookrepo.New(), feed.NewHub(0))
+	ctx, client := getTestGRCPClientWithTracing(t, app.Observe(a, tracing.AppObserver(tp)), tp)
+
+	ctx = metadata.AppendToOutgoingContext(ctx, "traceparent", traceParent)
+	_, err := client.GetUser(ctx, &grpcPort.GetUserRequest{Id: 100})
+	assert.Error(t, err)
+
+	spans := sr.Ended()
+	server := spans[len(spans)-1]
+	assert.Equal(t, "/ad.AdService/GetUser", server.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String())
+	assert.Equal(t, []string{"users.UserByID", "app.UserByID", "/ad.AdService/GetUser"}, spanNames(spans, server))
+}
@@ -1,43 +1,194 @@
 package tests
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"golang.org/x/crypto/bcrypt"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/test/bufconn"
 
 	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/auditrepo"
+	"homework10/internal/adapters/blobstore"
+	"homework10/internal/adapters/catrepo"
+	"homework10/internal/adapters/favrepo"
+	"homework10/internal/adapters/hookrepo"
+	"homework10/internal/adapters/msgrepo"
+	"homework10/internal/adapters/outboxrepo"
+	"homework10/internal/adapters/searchrepo"
 	"homework10/internal/adapters/userrepo"
+	"homework10/internal/ads"
 	"homework10/internal/app"
+	"homework10/internal/auth"
+	"homework10/internal/categories"
+	"homework10/internal/events"
+	"homework10/internal/feed"
+	"homework10/internal/health"
+	"homework10/internal/idempotency"
+	"homework10/internal/logging"
+	"homework10/internal/metrics"
 	grpcPort "homework10/internal/ports/grpc"
 	"homework10/internal/ports/httpgin"
+	"homework10/internal/ratelimit"
+	"homework10/internal/users"
+	"homework10/internal/webhooks"
 )
 
 type adData struct {
-	ID        int64  `json:"id"`
-	Title     string `json:"title"`
-	Text      string `json:"text"`
-	AuthorID  int64  `json:"author_id"`
-	Published bool   `json:"published"`
+	ID        int64      `json:"id"`
+	Title     string     `json:"title"`
+	Text      string     `json:"text"`
+	AuthorID  int64      `json:"author_id"`
+	Category  int64      `json:"category_id"`
+	Published bool       `json:"published"`
+	Status    string     `json:"status"`
+	Reason    string     `json:"status_reason"`
+	Expires   *time.Time `json:"expires"`
+	Images    []struct {
+		ID           string `json:"id"`
+		URL          string `json:"url"`
+		ThumbnailURL string `json:"thumbnail_url"`
+		Width        int    `json:"width"`
+		Height       int    `json:"height"`
+	} `json:"images"`
+	Favorites int    `json:"favorites"`
+	Price     int64  `json:"price"`
+	Currency  string `json:"currency"`
+	Location  *struct {
+		Lat  float64 `json:"lat"`
+		Lon  float64 `json:"lon"`
+		City string  `json:"city"`
+	} `json:"location"`
+	Distance *float64 `json:"distance"`
+}
+
+type historyEntryData struct {
+	ID       int64   `json:"id"`
+	Revision int64   `json:"revision"`
+	ActorID  int64   `json:"actor_id"`
+	Op       string  `json:"op"`
+	Before   *adData `json:"before"`
+	After    *adData `json:"after"`
+}
+
+type historyResponse struct {
+	Data []historyEntryData `json:"data"`
+}
+
+type webhookData struct {
+	ID     int64    `json:"id"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	Secret string   `json:"secret"`
+}
+
+type webhookResponse struct {
+	Data webhookData `json:"data"`
+}
+
+type webhooksResponse struct {
+	Data []webhookData `json:"data"`
+}
+
+type deliveriesResponse struct {
+	Data []struct {
+		ID       int64  `json:"id"`
+		EventID  int64  `json:"event_id"`
+		Event    string `json:"event"`
+		Status   string `json:"status"`
+		Attempts []struct {
+			StatusCode int    `json:"status_code"`
+			Error      string `json:"error"`
+		} `json:"attempts"`
+	} `json:"data"`
+}
+
+type favoritesResponse struct {
+	Data []adData `json:"data"`
+}
+
+type messageData struct {
+	ID       int64  `json:"id"`
+	ThreadID int64  `json:"thread_id"`
+	SenderID int64  `json:"sender_id"`
+	Text     string `json:"text"`
+}
+
+type messageResponse struct {
+	Data messageData `json:"data"`
+}
+
+type messagesResponse struct {
+	Data       []messageData `json:"data"`
+	NextCursor string        `json:"next_cursor"`
+}
+
+type threadData struct {
+	ID       int64 `json:"id"`
+	AdID     int64 `json:"ad_id"`
+	BuyerID  int64 `json:"buyer_id"`
+	SellerID int64 `json:"seller_id"`
+	Unread   int   `json:"unread"`
+}
+
+type threadsResponse struct {
+	Data        []threadData `json:"data"`
+	UnreadTotal int          `json:"unread_total"`
+}
+
+type savedSearchData struct {
+	ID     int64          `json:"id"`
+	UserID int64          `json:"user_id"`
+	Name   string         `json:"name"`
+	Query  map[string]any `json:"query"`
+}
+
+type savedSearchResponse struct {
+	Data savedSearchData `json:"data"`
+}
+
+type savedSearchesResponse struct {
+	Data []savedSearchData `json:"data"`
+}
+
+type notificationData struct {
+	ID       int64  `json:"id"`
+	SearchID int64  `json:"search_id"`
+	AdID     int64  `json:"ad_id"`
+	Title    string `json:"title"`
+	Read     bool   `json:"read"`
+}
+
+type notificationsResponse struct {
+	Data        []notificationData `json:"data"`
+	UnreadTotal int                `json:"unread_total"`
 }
 
 type userData struct {
 	ID       int64  `json:"id"`
 	Nickname string `json:"nickname"`
 	Email    string `json:"email"`
+	Role     string `json:"role"`
 }
 
 type adResponse struct {
@@ -48,31 +199,223 @@ type userResponse struct {
 	Data userData `json:"data"`
 }
 
+type categoryData struct {
+	ID       int64    `json:"id"`
+	ParentID int64    `json:"parent_id"`
+	Name     string   `json:"name"`
+	Path     []string `json:"path"`
+}
+
+type categoryResponse struct {
+	Data categoryData `json:"data"`
+}
+
+type categoriesResponse struct {
+	Data []categoryData `json:"data"`
+}
+
+type categoryCount struct {
+	ID    int64    `json:"id"`
+	Name  string   `json:"name"`
+	Path  []string `json:"path"`
+	Count int      `json:"count"`
+}
+
 type adsResponse struct {
-	Data []adData `json:"data"`
+	Data           []adData        `json:"data"`
+	NextCursor     string          `json:"next_cursor"`
+	CategoryCounts []categoryCount `json:"category_counts"`
+}
+
+type tokensResponse struct {
+	Data struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int64  `json:"expires_in"`
+	} `json:"data"`
 }
 
 var (
-	ErrBadRequest = fmt.Errorf("bad request")
-	ErrForbidden  = fmt.Errorf("forbidden")
+	ErrBadRequest   = fmt.Errorf("bad request")
+	ErrForbidden    = fmt.Errorf("forbidden")
+	ErrUnauthorized = fmt.Errorf("unauthorized")
+	ErrConflict     = fmt.Errorf("conflict")
+	ErrTooLarge     = fmt.Errorf("too large")
+	ErrNotFound     = fmt.Errorf("not found")
+	ErrUnavailable  = fmt.Errorf("unavailable")
+	ErrTooMany      = fmt.Errorf("too many requests")
 )
 
+const (
+	// testPassword - пароль, с которым тестовые клиенты создают пользователей
+	testPassword = "secret password"
+	// testCategoryID - категория, которая есть в каждом тестовом приложении и в которой по умолчанию создаются объявления
+	testCategoryID = 1
+)
+
+func init() {
+	// в тестах хэширование паролей не должно тормозить
+	auth.PasswordCost = bcrypt.MinCost
+}
+
+func newTestApp() app.App {
+	return newTestAppWithUsers(userrepo.New())
+}
+
+// newTestAppWithUsers позволяет тесту напрямую менять пользователей, например назначать роли
+func newTestAppWithUsers(userRepo users.Repository) app.App {
+	return newTestAppWithEvents(userRepo, outboxrepo.New(), hookrepo.New())
+}
+
+// newTestAppWithEvents позволяет тесту рассылать события приложения, запустив dispatcher над outbox и подписками
+func newTestAppWithEvents(userRepo users.Repository, outbox events.Outbox, hookRepo webhooks.Repository) app.App {
+	return newTestAppWithFeed(userRepo, outbox, hookRepo, feed.NewHub(0))
+}
+
+// newTestAppWithFeed позволяет тесту закрыть ленту изменений объявлений, как при остановке сервиса
+func newTestAppWithFeed(userRepo users.Repository, outbox events.Outbox, hookRepo webhooks.Repository, hub *feed.Hub) app.App {
+	return newTestAppWithRepos(adrepo.New(), userRepo, outbox, hookRepo, hub)
+}
+
+// newTestAppWithRepos позволяет тесту подменить хранилище объявлений, например обернуть его трассировкой
+func newTestAppWithRepos(adRepo ads.Repository, userRepo users.Repository, outbox events.Outbox, hookRepo webhooks.Repository, hub *feed.Hub) app.App {
+	issuer := auth.NewIssuer([]byte("test secret"), auth.DefaultAccessTTL, auth.DefaultRefreshTTL)
+	catRepo := catrepo.New()
+	_, _ = catRepo.AddCategory(context.Background(), &categories.Category{Name: "Разное"})
+
+	return app.NewApp(adRepo, userRepo, catRepo, favrepo.New(), msgrepo.New(), auditrepo.New(), outbox, hookRepo, searchrepo.New(), hub, blobstore.New(), issuer, 0, logging.Discard())
+}
+
 type testHTTPClient struct {
 	client  *http.Client
 	baseURL string
+
+	mu     sync.Mutex
+	tokens map[int64]string // access-токены созданных через клиент пользователей
 }
 
 func getTestHTTPClient() *testHTTPClient {
-	server := httpgin.NewHTTPServer(":18080", app.NewApp(adrepo.New(), userrepo.New()))
+	return getTestHTTPClientWithApp(newTestApp())
+}
+
+func getTestHTTPClientWithApp(a app.App) *testHTTPClient {
+	return getTestHTTPClientWithLimiter(a, ratelimit.New(ratelimit.Rules{}, 0))
+}
+
+func getTestHTTPClientWithLimiter(a app.App, l *ratelimit.Limiter) *testHTTPClient {
+	return newTestHTTPClient(a, l, metrics.New(), noop.NewTracerProvider(), health.New())
+}
+
+// getTestHTTPClientWithMetrics позволяет тесту проверить метрики, которые сервер отдаёт по /metrics
+func getTestHTTPClientWithMetrics(a app.App, m *metrics.Metrics) *testHTTPClient {
+	return newTestHTTPClient(a, ratelimit.New(ratelimit.Rules{}, 0), m, noop.NewTracerProvider(), health.New())
+}
+
+// getTestHTTPClientWithTracing позволяет тесту проверить спаны, которые сервер создаёт провайдером tp
+func getTestHTTPClientWithTracing(a app.App, tp trace.TracerProvider) *testHTTPClient {
+	return newTestHTTPClient(a, ratelimit.New(ratelimit.Rules{}, 0), metrics.New(), tp, health.New())
+}
+
+// getTestHTTPClientWithProbe позволяет тесту проверить, как сервер сообщает о своей готовности по p
+func getTestHTTPClientWithProbe(a app.App, p *health.Probe) *testHTTPClient {
+	return newTestHTTPClient(a, ratelimit.New(ratelimit.Rules{}, 0), metrics.New(), noop.NewTracerProvider(), p)
+}
+
+func newTestHTTPClient(a app.App, l *ratelimit.Limiter, m *metrics.Metrics, tp trace.TracerProvider, p *health.Probe) *testHTTPClient {
+	server := httpgin.NewHTTPServer(":18080", a, l, idempotency.DefaultWindow, logging.Discard(), m, tp, p)
 	testServer := httptest.NewServer(server.Handler)
 
 	return &testHTTPClient{
 		client:  testServer.Client(),
 		baseURL: testServer.URL,
+		tokens:  make(map[int64]string),
+	}
+}
+
+// authorize подписывает запрос токеном пользователя userID; запрос от имени
+// пользователя, который не создавался через клиент, уходит анонимным
+func (tc *testHTTPClient) authorize(req *http.Request, userID int64) {
+	tc.mu.Lock()
+	token, ok := tc.tokens[userID]
+	tc.mu.Unlock()
+
+	if ok {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+func (tc *testHTTPClient) login(userID int64, password string) (tokensResponse, error) {
+	body := map[string]any{
+		"user_id":  userID,
+		"password": password,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return tokensResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, tc.baseURL+"/api/v1/auth/login", bytes.NewReader(data))
+	if err != nil {
+		return tokensResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	var response tokensResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return tokensResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testHTTPClient) refresh(refreshToken string) (tokensResponse, error) {
+	body := map[string]any{
+		"refresh_token": refreshToken,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return tokensResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, tc.baseURL+"/api/v1/auth/refresh", bytes.NewReader(data))
+	if err != nil {
+		return tokensResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	var response tokensResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return tokensResponse{}, err
 	}
+
+	return response, nil
 }
 
 func getTestGRCPClient(t *testing.T) (context.Context, grpcPort.AdServiceClient) {
+	return getTestGRCPClientWithApp(t, newTestApp())
+}
+
+func getTestGRCPClientWithApp(t *testing.T, a app.App) (context.Context, grpcPort.AdServiceClient) {
+	return getTestGRCPClientWithLimiter(t, a, ratelimit.New(ratelimit.Rules{}, 0))
+}
+
+func getTestGRCPClientWithLimiter(t *testing.T, a app.App, l *ratelimit.Limiter) (context.Context, grpcPort.AdServiceClient) {
+	return newTestGRPCClient(t, a, l, noop.NewTracerProvider())
+}
+
+// getTestGRCPClientWithTracing позволяет тесту проверить спаны, которые сервер создаёт провайдером tp
+func getTestGRCPClientWithTracing(t *testing.T, a app.App, tp trace.TracerProvider) (context.Context, grpcPort.AdServiceClient) {
+	return newTestGRPCClient(t, a, ratelimit.New(ratelimit.Rules{}, 0), tp)
+}
+
+func newTestGRPCClient(t *testing.T, a app.App, l *ratelimit.Limiter, tp trace.TracerProvider) (context.Context, grpcPort.AdServiceClient) {
 	lis := bufconn.Listen(1024 * 1024)
 	t.Cleanup(func() {
 		_ = lis.Close()
@@ -80,14 +423,26 @@ func getTestGRCPClient(t *testing.T) (context.Context, grpcPort.AdServiceClient)
 
 	s := grpc.NewServer(
 		grpc.ChainUnaryInterceptor(
+			grpcPort.UnaryTraceInterceptor(tp),
+			grpcPort.UnaryLogInterceptor(logging.Discard()),
 			recovery.UnaryServerInterceptor(),
+			grpcPort.UnaryAuthInterceptor(a),
+			grpcPort.UnaryRateLimitInterceptor(l),
+			grpcPort.UnaryIdempotencyInterceptor(idempotency.DefaultWindow),
+		),
+		grpc.ChainStreamInterceptor(
+			grpcPort.StreamTraceInterceptor(tp),
+			grpcPort.StreamLogInterceptor(logging.Discard()),
+			recovery.StreamServerInterceptor(),
+			grpcPort.StreamAuthInterceptor(a),
+			grpcPort.StreamRateLimitInterceptor(l),
 		),
 	)
 	t.Cleanup(func() {
 		s.Stop()
 	})
 
-	srv := grpcPort.NewService(app.NewApp(adrepo.New(), userrepo.New()))
+	srv := grpcPort.NewService(a)
 	grpcPort.RegisterAdServiceServer(s, srv)
 
 	go func() {
@@ -128,6 +483,21 @@ func (tc *testHTTPClient) getResponse(req *http.Request, out any) error {
 		if resp.StatusCode == http.StatusForbidden {
 			return ErrForbidden
 		}
+		if resp.StatusCode == http.StatusUnauthorized {
+			return ErrUnauthorized
+		}
+		if resp.StatusCode == http.StatusConflict {
+			return ErrConflict
+		}
+		if resp.StatusCode == http.StatusRequestEntityTooLarge {
+			return ErrTooLarge
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			return ErrNotFound
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return ErrTooMany
+		}
 		return fmt.Errorf("unexpected status code: %s", resp.Status)
 	}
 
@@ -145,12 +515,42 @@ func (tc *testHTTPClient) getResponse(req *http.Request, out any) error {
 }
 
 func (tc *testHTTPClient) createAd(userID int64, title string, text string) (adResponse, error) {
-	body := map[string]any{
-		"user_id": userID,
-		"title":   title,
-		"text":    text,
-	}
+	return tc.createAdIn(userID, testCategoryID, title, text)
+}
+
+func (tc *testHTTPClient) createAdIn(userID, categoryID int64, title string, text string) (adResponse, error) {
+	return tc.postAd(userID, map[string]any{
+		"title":       title,
+		"text":        text,
+		"category_id": categoryID,
+	})
+}
+
+func (tc *testHTTPClient) createPricedAd(userID int64, title string, price int64, currency string) (adResponse, error) {
+	return tc.postAd(userID, map[string]any{
+		"title":       title,
+		"text":        "text",
+		"category_id": testCategoryID,
+		"price":       price,
+		"currency":    currency,
+	})
+}
+
+func (tc *testHTTPClient) createLocatedAd(userID int64, title string, lat, lon float64, city string) (adResponse, error) {
+	return tc.postAd(userID, map[string]any{
+		"title":       title,
+		"text":        "text",
+		"category_id": testCategoryID,
+		"location":    map[string]any{"lat": lat, "lon": lon, "city": city},
+	})
+}
 
+func (tc *testHTTPClient) postAd(userID int64, body map[string]any) (adResponse, error) {
+	return tc.postAdWithKey(userID, "", body)
+}
+
+// postAdWithKey создаёт объявление с ключом идемпотентности key; пустой key - без ключа
+func (tc *testHTTPClient) postAdWithKey(userID int64, key string, body map[string]any) (adResponse, error) {
 	data, err := json.Marshal(body)
 	if err != nil {
 		return adResponse{}, fmt.Errorf("unable to marshal: %w", err)
@@ -162,6 +562,10 @@ func (tc *testHTTPClient) createAd(userID int64, title string, text string) (adR
 	}
 
 	req.Header.Add("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Add("Idempotency-Key", key)
+	}
+	tc.authorize(req, userID)
 
 	var response adResponse
 	err = tc.getResponse(req, &response)
@@ -174,7 +578,6 @@ func (tc *testHTTPClient) createAd(userID int64, title string, text string) (adR
 
 func (tc *testHTTPClient) changeAdStatus(userID int64, adID int64, published bool) (adResponse, error) {
 	body := map[string]any{
-		"user_id":   userID,
 		"published": published,
 	}
 
@@ -189,6 +592,7 @@ func (tc *testHTTPClient) changeAdStatus(userID int64, adID int64, published boo
 	}
 
 	req.Header.Add("Content-Type", "application/json")
+	tc.authorize(req, userID)
 
 	var response adResponse
 	err = tc.getResponse(req, &response)
@@ -199,11 +603,10 @@ func (tc *testHTTPClient) changeAdStatus(userID int64, adID int64, published boo
 	return response, nil
 }
 
-func (tc *testHTTPClient) updateAd(userID int64, adID int64, title string, text string) (adResponse, error) {
+func (tc *testHTTPClient) transitionAd(userID int64, adID int64, event, reason string) (adResponse, error) {
 	body := map[string]any{
-		"user_id": userID,
-		"title":   title,
-		"text":    text,
+		"event":  event,
+		"reason": reason,
 	}
 
 	data, err := json.Marshal(body)
@@ -211,12 +614,13 @@ func (tc *testHTTPClient) updateAd(userID int64, adID int64, title string, text
 		return adResponse{}, fmt.Errorf("unable to marshal: %w", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d", adID), bytes.NewReader(data))
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d/transitions", adID), bytes.NewReader(data))
 	if err != nil {
 		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
 	}
 
 	req.Header.Add("Content-Type", "application/json")
+	tc.authorize(req, userID)
 
 	var response adResponse
 	err = tc.getResponse(req, &response)
@@ -227,12 +631,24 @@ func (tc *testHTTPClient) updateAd(userID int64, adID int64, title string, text
 	return response, nil
 }
 
-func (tc *testHTTPClient) showAd(adID int64) (adResponse, error) {
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d", adID), nil)
+func (tc *testHTTPClient) renewAd(userID int64, adID int64, ttl time.Duration) (adResponse, error) {
+	body := map[string]any{
+		"ttl": int64(ttl.Seconds()),
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d/renew", adID), bytes.NewReader(data))
 	if err != nil {
 		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
 	}
 
+	req.Header.Add("Content-Type", "application/json")
+	tc.authorize(req, userID)
+
 	var response adResponse
 	err = tc.getResponse(req, &response)
 	if err != nil {
@@ -242,132 +658,789 @@ func (tc *testHTTPClient) showAd(adID int64) (adResponse, error) {
 	return response, nil
 }
 
-func (tc *testHTTPClient) listAds(params map[string]string) (adsResponse, error) {
-	p := url.Values{}
-	for k, v := range params {
-		p.Add(k, v)
-	}
-
-	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/ads?"+p.Encode(), nil)
-
+func (tc *testHTTPClient) adHistory(userID int64, adID int64) (historyResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d/history", adID), nil)
 	if err != nil {
-		return adsResponse{}, fmt.Errorf("unable to create request: %w", err)
+		return historyResponse{}, fmt.Errorf("unable to create request: %w", err)
 	}
 
-	var response adsResponse
+	tc.authorize(req, userID)
+
+	var response historyResponse
 	err = tc.getResponse(req, &response)
 	if err != nil {
-		return adsResponse{}, err
+		return historyResponse{}, err
 	}
 
 	return response, nil
 }
 
-func (tc *testHTTPClient) deleteAd(userID, adID int64) error {
+func (tc *testHTTPClient) revertAd(userID int64, adID int64, revision int64) (adResponse, error) {
 	body := map[string]any{
-		"user_id": userID,
+		"revision": revision,
 	}
 
 	data, err := json.Marshal(body)
 	if err != nil {
-		return fmt.Errorf("unable to marshal: %w", err)
+		return adResponse{}, fmt.Errorf("unable to marshal: %w", err)
 	}
 
-	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d", adID), bytes.NewReader(data))
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d/revert", adID), bytes.NewReader(data))
 	if err != nil {
-		return fmt.Errorf("unable to create request: %w", err)
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
 	}
 
-	var response userResponse
+	req.Header.Add("Content-Type", "application/json")
+	tc.authorize(req, userID)
+
+	var response adResponse
 	err = tc.getResponse(req, &response)
 	if err != nil {
-		return err
+		return adResponse{}, err
 	}
 
-	return nil
+	return response, nil
 }
 
-func (tc *testHTTPClient) createUser(nick, email string) (userResponse, error) {
+func (tc *testHTTPClient) updateAd(userID int64, adID int64, title string, text string) (adResponse, error) {
 	body := map[string]any{
-		"nickname": nick,
-		"email":    email,
+		"title": title,
+		"text":  text,
 	}
 
 	data, err := json.Marshal(body)
 	if err != nil {
-		return userResponse{}, fmt.Errorf("unable to marshal: %w", err)
+		return adResponse{}, fmt.Errorf("unable to marshal: %w", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, tc.baseURL+"/api/v1/users", bytes.NewReader(data))
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d", adID), bytes.NewReader(data))
 	if err != nil {
-		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
 	}
 
 	req.Header.Add("Content-Type", "application/json")
+	tc.authorize(req, userID)
 
-	var response userResponse
+	var response adResponse
 	err = tc.getResponse(req, &response)
 	if err != nil {
-		return userResponse{}, err
+		return adResponse{}, err
 	}
 
 	return response, nil
 }
 
-func (tc *testHTTPClient) updateUser(userId int64, nick, email string) (userResponse, error) {
-	body := map[string]any{
-		"user_id":  userId,
-		"nickname": nick,
-		"email":    email,
-	}
-
-	data, err := json.Marshal(body)
-	if err != nil {
-		return userResponse{}, fmt.Errorf("unable to marshal: %w", err)
-	}
-
-	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d", userId), bytes.NewReader(data))
+func (tc *testHTTPClient) showAd(adID int64) (adResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d", adID), nil)
 	if err != nil {
-		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
 	}
 
-	req.Header.Add("Content-Type", "application/json")
-
-	var response userResponse
+	var response adResponse
 	err = tc.getResponse(req, &response)
 	if err != nil {
-		return userResponse{}, err
+		return adResponse{}, err
 	}
 
 	return response, nil
 }
 
-func (tc *testHTTPClient) showUser(userID int64) (userResponse, error) {
-	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d", userID), nil)
+func (tc *testHTTPClient) listAds(params map[string]string) (adsResponse, error) {
+	p := url.Values{}
+	for k, v := range params {
+		p.Add(k, v)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/ads?"+p.Encode(), nil)
+
 	if err != nil {
-		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
+		return adsResponse{}, fmt.Errorf("unable to create request: %w", err)
 	}
 
-	var response userResponse
+	var response adsResponse
 	err = tc.getResponse(req, &response)
 	if err != nil {
-		return userResponse{}, err
+		return adsResponse{}, err
 	}
 
 	return response, nil
 }
 
-func (tc *testHTTPClient) deleteUser(userID int64) error {
-	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d", userID), nil)
-	if err != nil {
-		return fmt.Errorf("unable to create request: %w", err)
-	}
+// sseStream - открытый поток Server-Sent Events
+type sseStream struct {
+	body   io.ReadCloser
+	reader *bufio.Reader
+}
 
-	var response userResponse
-	err = tc.getResponse(req, &response)
-	if err != nil {
-		return err
+// sseEvent - событие потока; Data у событий объявлений разбирается в adEventResponse, у error - в errorResponse
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  []byte
+}
+
+type adEventResponse struct {
+	Data struct {
+		ID      int64  `json:"id"`
+		Type    string `json:"type"`
+		ActorID int64  `json:"actor_id"`
+		Ad      adData `json:"ad"`
+	} `json:"data"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// next читает следующее событие, пропуская комментарии; io.EOF - сервис закрыл поток
+func (s *sseStream) next() (sseEvent, error) {
+	var e sseEvent
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return sseEvent{}, err
+		}
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "":
+			if e.Event != "" {
+				return e, nil
+			}
+		case strings.HasPrefix(line, ":"):
+		case strings.HasPrefix(line, "id: "):
+			e.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			e.Event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			e.Data = []byte(strings.TrimPrefix(line, "data: "))
+		}
+	}
+}
+
+func (s *sseStream) Close() error {
+	return s.body.Close()
+}
+
+// watchAds подписывается на изменения объявлений; подписка действует уже к возврату из метода
+func (tc *testHTTPClient) watchAds(params map[string]string) (*sseStream, error) {
+	p := url.Values{}
+	for k, v := range params {
+		p.Add(k, v)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/ads/watch?"+p.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	resp, err := tc.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		if resp.StatusCode == http.StatusBadRequest {
+			return nil, ErrBadRequest
+		}
+		if resp.StatusCode == http.StatusServiceUnavailable {
+			return nil, ErrUnavailable
+		}
+		return nil, fmt.Errorf("unexpected status code: %s", resp.Status)
+	}
+
+	return &sseStream{body: resp.Body, reader: bufio.NewReader(resp.Body)}, nil
+}
+
+func (tc *testHTTPClient) deleteAd(userID, adID int64) error {
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d", adID), nil)
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, userID)
+
+	var response userResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (tc *testHTTPClient) addAdImage(userID, adID int64, image []byte) (adResponse, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	fw, err := w.CreateFormFile("image", "photo")
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create form: %w", err)
+	}
+	if _, err = fw.Write(image); err != nil {
+		return adResponse{}, fmt.Errorf("unable to create form: %w", err)
+	}
+	if err = w.Close(); err != nil {
+		return adResponse{}, fmt.Errorf("unable to create form: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d/images", adID), &body)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", w.FormDataContentType())
+	tc.authorize(req, userID)
+
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testHTTPClient) deleteAdImage(userID, adID int64, imageID string) (adResponse, error) {
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d/images/%s", adID, imageID), nil)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, userID)
+
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+
+	return response, nil
+}
+
+// getImage скачивает файл по пути из ответа API (url или thumbnail_url)
+func (tc *testHTTPClient) getImage(path string) ([]byte, error) {
+	resp, err := tc.client.Get(tc.baseURL + path)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %s", resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+func (tc *testHTTPClient) createUser(nick, email string) (userResponse, error) {
+	return tc.createUserWithKey(nick, email, "")
+}
+
+// createUserWithKey создаёт пользователя с ключом идемпотентности key; пустой key - без ключа
+func (tc *testHTTPClient) createUserWithKey(nick, email, key string) (userResponse, error) {
+	body := map[string]any{
+		"nickname": nick,
+		"email":    email,
+		"password": testPassword,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, tc.baseURL+"/api/v1/users", bytes.NewReader(data))
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Add("Idempotency-Key", key)
+	}
+
+	var response userResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return userResponse{}, err
+	}
+
+	tokens, err := tc.login(response.Data.ID, testPassword)
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to login: %w", err)
+	}
+
+	tc.mu.Lock()
+	tc.tokens[response.Data.ID] = tokens.Data.AccessToken
+	tc.mu.Unlock()
+
+	return response, nil
+}
+
+func (tc *testHTTPClient) updateUser(userId int64, nick, email string) (userResponse, error) {
+	return tc.updateUserAs(userId, userId, nick, email)
+}
+
+func (tc *testHTTPClient) updateUserAs(actorID, userId int64, nick, email string) (userResponse, error) {
+	body := map[string]any{
+		"nickname": nick,
+		"email":    email,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d", userId), bytes.NewReader(data))
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	tc.authorize(req, actorID)
+
+	var response userResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return userResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testHTTPClient) showUser(userID int64) (userResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d", userID), nil)
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response userResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return userResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testHTTPClient) deleteUser(userID int64) error {
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d", userID), nil)
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, userID)
+
+	var response userResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return err
 	}
 
 	return nil
 }
+
+// loginGRPC возвращает контекст, вызовы с которым выполняются от имени пользователя userID
+func loginGRPC(t *testing.T, ctx context.Context, client grpcPort.AdServiceClient, userID int64) context.Context {
+	t.Helper()
+
+	res, err := client.Login(ctx, &grpcPort.LoginRequest{UserId: userID, Password: testPassword})
+	assert.NoError(t, err, "client.Login")
+
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+res.GetAccessToken())
+}
+
+func (tc *testHTTPClient) changeUserRole(actorID, userID int64, role string) (userResponse, error) {
+	body := map[string]any{
+		"role": role,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/role", userID), bytes.NewReader(data))
+	if err != nil {
+		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	tc.authorize(req, actorID)
+
+	var response userResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return userResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testHTTPClient) createCategory(actorID int64, name string, parentID int64) (categoryResponse, error) {
+	return tc.sendCategory(http.MethodPost, tc.baseURL+"/api/v1/categories", actorID, name, parentID)
+}
+
+func (tc *testHTTPClient) updateCategory(actorID, categoryID int64, name string, parentID int64) (categoryResponse, error) {
+	return tc.sendCategory(http.MethodPut, fmt.Sprintf(tc.baseURL+"/api/v1/categories/%d", categoryID), actorID, name, parentID)
+}
+
+func (tc *testHTTPClient) sendCategory(method, url string, actorID int64, name string, parentID int64) (categoryResponse, error) {
+	body := map[string]any{
+		"name":      name,
+		"parent_id": parentID,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return categoryResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(method, url, bytes.NewReader(data))
+	if err != nil {
+		return categoryResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	tc.authorize(req, actorID)
+
+	var response categoryResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return categoryResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testHTTPClient) createWebhook(actorID int64, url string, events ...string) (webhookResponse, error) {
+	body := map[string]any{
+		"url":    url,
+		"events": events,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return webhookResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, tc.baseURL+"/api/v1/webhooks", bytes.NewReader(data))
+	if err != nil {
+		return webhookResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	tc.authorize(req, actorID)
+
+	var response webhookResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return webhookResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testHTTPClient) listWebhooks(actorID int64) (webhooksResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/webhooks", nil)
+	if err != nil {
+		return webhooksResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, actorID)
+
+	var response webhooksResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return webhooksResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testHTTPClient) deleteWebhook(actorID, webhookID int64) error {
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf(tc.baseURL+"/api/v1/webhooks/%d", webhookID), nil)
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, actorID)
+
+	var response webhookResponse
+	return tc.getResponse(req, &response)
+}
+
+func (tc *testHTTPClient) listWebhookDeliveries(actorID, webhookID int64) (deliveriesResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/webhooks/%d/deliveries", webhookID), nil)
+	if err != nil {
+		return deliveriesResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, actorID)
+
+	var response deliveriesResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return deliveriesResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testHTTPClient) deleteCategory(actorID, categoryID int64) error {
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf(tc.baseURL+"/api/v1/categories/%d", categoryID), nil)
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, actorID)
+
+	var response categoryResponse
+	return tc.getResponse(req, &response)
+}
+
+func (tc *testHTTPClient) listCategories() (categoriesResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, tc.baseURL+"/api/v1/categories", nil)
+	if err != nil {
+		return categoriesResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response categoriesResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return categoriesResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testHTTPClient) addFavorite(userID, adID int64) (adResponse, error) {
+	return tc.changeFavorite(http.MethodPut, userID, adID)
+}
+
+func (tc *testHTTPClient) deleteFavorite(userID, adID int64) (adResponse, error) {
+	return tc.changeFavorite(http.MethodDelete, userID, adID)
+}
+
+func (tc *testHTTPClient) changeFavorite(method string, userID, adID int64) (adResponse, error) {
+	req, err := http.NewRequest(method, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/favorites/%d", userID, adID), nil)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, userID)
+
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testHTTPClient) listFavorites(actorID, userID int64) (favoritesResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/favorites", userID), nil)
+	if err != nil {
+		return favoritesResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, actorID)
+
+	var response favoritesResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return favoritesResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testHTTPClient) contactSeller(userID, adID int64, text string) (messageResponse, error) {
+	return tc.postMessage(fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d/messages", adID), userID, text)
+}
+
+func (tc *testHTTPClient) sendMessage(userID, threadID int64, text string) (messageResponse, error) {
+	return tc.postMessage(fmt.Sprintf(tc.baseURL+"/api/v1/threads/%d/messages", threadID), userID, text)
+}
+
+func (tc *testHTTPClient) postMessage(endpoint string, userID int64, text string) (messageResponse, error) {
+	body := map[string]any{
+		"text": text,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return messageResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return messageResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	tc.authorize(req, userID)
+
+	var response messageResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return messageResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testHTTPClient) listThreads(actorID, userID int64) (threadsResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/threads", userID), nil)
+	if err != nil {
+		return threadsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, actorID)
+
+	var response threadsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return threadsResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testHTTPClient) listMessages(actorID, threadID int64, params map[string]string) (messagesResponse, error) {
+	p := url.Values{}
+	for k, v := range params {
+		p.Add(k, v)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/threads/%d/messages?", threadID)+p.Encode(), nil)
+	if err != nil {
+		return messagesResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, actorID)
+
+	var response messagesResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return messagesResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testHTTPClient) createSavedSearch(userID int64, name string, params map[string]string) (savedSearchResponse, error) {
+	p := url.Values{}
+	for k, v := range params {
+		p.Add(k, v)
+	}
+
+	body := map[string]any{
+		"name": name,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return savedSearchResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/searches?", userID)+p.Encode(), bytes.NewReader(data))
+	if err != nil {
+		return savedSearchResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	tc.authorize(req, userID)
+
+	var response savedSearchResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return savedSearchResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testHTTPClient) listSavedSearches(actorID, userID int64) (savedSearchesResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/searches", userID), nil)
+	if err != nil {
+		return savedSearchesResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, actorID)
+
+	var response savedSearchesResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return savedSearchesResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testHTTPClient) deleteSavedSearch(userID, searchID int64) (savedSearchResponse, error) {
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/searches/%d", userID, searchID), nil)
+	if err != nil {
+		return savedSearchResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, userID)
+
+	var response savedSearchResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return savedSearchResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testHTTPClient) listNotifications(actorID, userID int64) (notificationsResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/notifications", userID), nil)
+	if err != nil {
+		return notificationsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	tc.authorize(req, actorID)
+
+	var response notificationsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return notificationsResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testHTTPClient) readNotifications(userID, upTo int64) (notificationsResponse, error) {
+	body := map[string]any{
+		"up_to": upTo,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return notificationsResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.baseURL+"/api/v1/users/%d/notifications/read", userID), bytes.NewReader(data))
+	if err != nil {
+		return notificationsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	tc.authorize(req, userID)
+
+	var response notificationsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return notificationsResponse{}, err
+	}
+
+	return response, nil
+}
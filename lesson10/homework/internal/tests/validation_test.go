@@ -47,7 +47,7 @@ func TestCreateAd_TooLongText(t *testing.T) {
 
 	text := strings.Repeat("a", 501)
 
-	_, err = client.createAd(123, "title", text)
+	_, err = client.createAd(0, "title", text)
 	assert.ErrorIs(t, err, ErrBadRequest)
 }
 
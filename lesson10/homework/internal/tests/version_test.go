@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func (tc *testHTTPClient) updateAdIfMatch(userID, adID int64, title, text, ifMatch string) (*http.Response, error) {
+	data, err := json.Marshal(map[string]any{
+		"title": title,
+		"text":  text,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(tc.baseURL+"/api/v1/ads/%d", adID), bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	tc.authorize(req, userID)
+	if ifMatch != "" {
+		req.Header.Add("If-Match", ifMatch)
+	}
+
+	resp, err := tc.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	_ = resp.Body.Close()
+
+	return resp, nil
+}
+
+func TestUpdateAd_IfMatch(t *testing.T) {
+	client := getTestHTTPClient()
+
+	_, err := client.createUser("jenny", "jenny@gmail.com")
+	assert.NoError(t, err)
+
+	ad, err := client.createAd(0, "hello", "world")
+	assert.NoError(t, err)
+
+	resp, err := client.client.Get(fmt.Sprintf(client.baseURL+"/api/v1/ads/%d", ad.Data.ID))
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, `"1"`, resp.Header.Get("ETag"))
+
+	resp, err = client.updateAdIfMatch(0, ad.Data.ID, "new title", "new text", `"1"`)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+
+	// второй писатель всё ещё думает, что версия 1
+	resp, err = client.updateAdIfMatch(0, ad.Data.ID, "other title", "other text", `"1"`)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+	resp, err = client.updateAdIfMatch(0, ad.Data.ID, "other title", "other text", "")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"3"`, resp.Header.Get("ETag"))
+
+	got, err := client.showAd(ad.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "other title", got.Data.Title)
+}
+
+func TestGRPCUpdateAd_Version(t *testing.T) {
+	ctx, client := getTestGRCPClient(t)
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Jenny", Email: "jenny@gmail.com", Password: testPassword})
+	assert.NoError(t, err, "client.CreateUser")
+
+	ad, err := client.CreateAd(loginGRPC(t, ctx, client, 0), &grpcPort.CreateAdRequest{Title: "Title", Text: "Text", CategoryId: testCategoryID})
+	assert.NoError(t, err, "client.CreateAd")
+	assert.Equal(t, int64(1), ad.Version)
+
+	res, err := client.UpdateAd(loginGRPC(t, ctx, client, 0), &grpcPort.UpdateAdRequest{AdId: ad.Id, Title: "New title", Text: "New text", Version: ad.Version})
+	assert.NoError(t, err, "client.UpdateAd")
+	assert.Equal(t, int64(2), res.Version)
+
+	_, err = client.ChangeAdStatus(loginGRPC(t, ctx, client, 0), &grpcPort.ChangeAdStatusRequest{AdId: ad.Id, Published: true, Version: ad.Version})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+
+	res, err = client.ChangeAdStatus(loginGRPC(t, ctx, client, 0), &grpcPort.ChangeAdStatusRequest{AdId: ad.Id, Published: true, Version: res.Version})
+	assert.NoError(t, err, "client.ChangeAdStatus")
+	assert.True(t, res.Published)
+	assert.Equal(t, int64(3), res.Version)
+
+	u, err := client.UpdateUser(loginGRPC(t, ctx, client, 0), &grpcPort.UpdateUserRequest{Id: 0, Nickname: "Polly", Email: "polly@gmail.com", Version: 1})
+	assert.NoError(t, err, "client.UpdateUser")
+	assert.Equal(t, int64(2), u.Version)
+
+	_, err = client.UpdateUser(loginGRPC(t, ctx, client, 0), &grpcPort.UpdateUserRequest{Id: 0, Nickname: "Molly", Email: "molly@gmail.com", Version: 1})
+	assert.Equal(t, codes.Aborted, status.Code(err))
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"homework10/internal/adapters/hookrepo"
+	"homework10/internal/adapters/outboxrepo"
+	"homework10/internal/adapters/userrepo"
+	"homework10/internal/feed"
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+func TestWatchAds(t *testing.T) {
+	hub := feed.NewHub(0)
+	client := getTestHTTPClientWithApp(newTestAppWithFeed(userrepo.New(), outboxrepo.New(), hookrepo.New(), hub))
+
+	user, err := client.createUser("Oleg", "oleg@gmail.com")
+	assert.NoError(t, err)
+
+	_, err = client.watchAds(map[string]string{"filter": "title"})
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	stream, err := client.watchAds(map[string]string{"q": "велосипед"})
+	assert.NoError(t, err)
+	defer stream.Close()
+
+	_, err = client.createAd(user.Data.ID, "Стол", "дубовый")
+	assert.NoError(t, err)
+	bike, err := client.createAd(user.Data.ID, "Велосипед", "горный")
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(user.Data.ID, bike.Data.ID, true)
+	assert.NoError(t, err)
+
+	// создание черновика в ленту не попадает: первое событие - публикация
+	e, err := stream.next()
+	assert.NoError(t, err)
+	assert.Equal(t, "ad.published", e.Event)
+
+	var event adEventResponse
+	assert.NoError(t, json.Unmarshal(e.Data, &event))
+	assert.Equal(t, "ad.published", event.Data.Type)
+	assert.Equal(t, strconv.FormatInt(event.Data.ID, 10), e.ID)
+	assert.Equal(t, user.Data.ID, event.Data.ActorID)
+	assert.Equal(t, bike.Data.ID, event.Data.Ad.ID)
+	assert.Equal(t, "Велосипед", event.Data.Ad.Title)
+
+	// при остановке сервиса поток завершается событием error
+	hub.Close()
+	e, err = stream.next()
+	assert.NoError(t, err)
+	assert.Equal(t, "error", e.Event)
+	var resp errorResponse
+	assert.NoError(t, json.Unmarshal(e.Data, &resp))
+	assert.Equal(t, feed.ErrClosed.Error(), resp.Error)
+
+	_, err = stream.next()
+	assert.ErrorIs(t, err, io.EOF)
+
+	_, err = client.watchAds(nil)
+	assert.ErrorIs(t, err, ErrUnavailable)
+}
+
+func TestGRPCWatchAds(t *testing.T) {
+	hub := feed.NewHub(0)
+	ctx, client := getTestGRCPClientWithApp(t, newTestAppWithFeed(userrepo.New(), outboxrepo.New(), hookrepo.New(), hub))
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@gmail.com", Password: testPassword})
+	assert.NoError(t, err)
+	authCtx := loginGRPC(t, ctx, client, 0)
+
+	stream, err := client.WatchAds(ctx, &grpcPort.WatchAdsRequest{Filter: &grpcPort.ListAdsRequest{Filter: "title"}})
+	assert.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	stream, err = client.WatchAds(ctx, &grpcPort.WatchAdsRequest{Filter: &grpcPort.ListAdsRequest{Query: "велосипед"}})
+	assert.NoError(t, err)
+	// у потока нет ответа на открытие: ждём, пока сервис подпишет его на ленту
+	assert.Eventually(t, func() bool { return hub.Len() == 1 }, time.Second, time.Millisecond)
+
+	_, err = client.CreateAd(authCtx, &grpcPort.CreateAdRequest{Title: "Стол", Text: "дубовый", CategoryId: testCategoryID})
+	assert.NoError(t, err)
+	bike, err := client.CreateAd(authCtx, &grpcPort.CreateAdRequest{Title: "Велосипед", Text: "горный", CategoryId: testCategoryID})
+	assert.NoError(t, err)
+	_, err = client.ChangeAdStatus(authCtx, &grpcPort.ChangeAdStatusRequest{AdId: bike.Id, Published: true})
+	assert.NoError(t, err)
+
+	e, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, "ad.published", e.Type)
+	assert.Equal(t, bike.Id, e.Ad.Id)
+	assert.Equal(t, int64(0), e.ActorId)
+
+	hub.Close()
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -0,0 +1,232 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"homework10/internal/adapters/hookrepo"
+	"homework10/internal/adapters/outboxrepo"
+	"homework10/internal/adapters/userrepo"
+	"homework10/internal/dispatcher"
+	"homework10/internal/logging"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/users"
+	"homework10/internal/webhooks"
+)
+
+// hookReceiver - подписчик, который принимает только запросы с верной подписью
+type hookReceiver struct {
+	secret string
+
+	m      sync.Mutex
+	events []dispatcher.Payload
+}
+
+func (rc *hookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	ts, _ := strconv.ParseInt(r.Header.Get(webhooks.HeaderTimestamp), 10, 64)
+
+	rc.m.Lock()
+	defer rc.m.Unlock()
+	if !webhooks.Verify(rc.secret, ts, body, r.Header.Get(webhooks.HeaderSignature)) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var p dispatcher.Payload
+	if err := json.Unmarshal(body, &p); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	rc.events = append(rc.events, p)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (rc *hookReceiver) received() []dispatcher.Payload {
+	rc.m.Lock()
+	defer rc.m.Unlock()
+	return append([]dispatcher.Payload(nil), rc.events...)
+}
+
+func (rc *hookReceiver) setSecret(secret string) {
+	rc.m.Lock()
+	defer rc.m.Unlock()
+	rc.secret = secret
+}
+
+func TestWebhooks(t *testing.T) {
+	userRepo, outbox, hookRepo := userrepo.New(), outboxrepo.New(), hookrepo.New()
+	client := getTestHTTPClientWithApp(newTestAppWithEvents(userRepo, outbox, hookRepo))
+
+	rc := &hookReceiver{}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	admin, err := client.createUser("admin", "admin@gmail.com")
+	assert.NoError(t, err)
+	promote(t, userRepo, admin.Data.ID, users.RoleAdmin)
+	author, err := client.createUser("jenny", "jenny@gmail.com")
+	assert.NoError(t, err)
+
+	_, err = client.createWebhook(author.Data.ID, srv.URL)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = client.createWebhook(admin.Data.ID, "not a url")
+	assert.ErrorIs(t, err, ErrBadRequest)
+	_, err = client.createWebhook(admin.Data.ID, srv.URL, "ad.viewed")
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	hook, err := client.createWebhook(admin.Data.ID, srv.URL, "ad.created", "ad.published", "ad.deleted")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ad.created", "ad.published", "ad.deleted"}, hook.Data.Events)
+	assert.NotEmpty(t, hook.Data.Secret)
+	rc.setSecret(hook.Data.Secret)
+
+	// секрет показывается только при создании
+	list, err := client.listWebhooks(admin.Data.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, list.Data, 1) {
+		assert.Empty(t, list.Data[0].Secret)
+	}
+
+	ad, err := client.createAd(author.Data.ID, "Велосипед", "Горный")
+	assert.NoError(t, err)
+	_, err = client.updateAd(author.Data.ID, ad.Data.ID, "Велосипед", "Горный, почти новый")
+	assert.NoError(t, err)
+	_, err = client.changeAdStatus(author.Data.ID, ad.Data.ID, true)
+	assert.NoError(t, err)
+	assert.NoError(t, client.deleteAd(author.Data.ID, ad.Data.ID))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = dispatcher.New(outbox, hookRepo, dispatcher.Config{Interval: time.Millisecond}, logging.Discard()).Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	assert.Eventually(t, func() bool { return len(rc.received()) == 3 }, time.Second, time.Millisecond)
+	got := rc.received()
+	if assert.Len(t, got, 3) {
+		assert.Equal(t, "ad.created", got[0].Type)
+		assert.Equal(t, author.Data.ID, got[0].ActorID)
+		assert.Equal(t, ad.Data.ID, got[0].Ad.ID)
+		assert.Equal(t, "Горный", got[0].Ad.Text)
+		assert.Equal(t, "ad.published", got[1].Type)
+		assert.True(t, got[1].Ad.Published)
+		assert.Equal(t, "ad.deleted", got[2].Type)
+		assert.Equal(t, "Горный, почти новый", got[2].Ad.Text)
+	}
+
+	// подписчик получает запрос раньше, чем рассылка сохраняет результат попытки
+	var deliveries deliveriesResponse
+	assert.Eventually(t, func() bool {
+		deliveries, err = client.listWebhookDeliveries(admin.Data.ID, hook.Data.ID)
+		return err == nil && len(deliveries.Data) == 3 && deliveries.Data[0].Status == "delivered"
+	}, time.Second, time.Millisecond)
+	if assert.Len(t, deliveries.Data, 3) {
+		assert.Equal(t, "ad.deleted", deliveries.Data[0].Event)
+		assert.Equal(t, "delivered", deliveries.Data[0].Status)
+		if assert.Len(t, deliveries.Data[0].Attempts, 1) {
+			assert.Equal(t, http.StatusNoContent, deliveries.Data[0].Attempts[0].StatusCode)
+		}
+	}
+	_, err = client.listWebhookDeliveries(author.Data.ID, hook.Data.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	assert.NoError(t, client.deleteWebhook(admin.Data.ID, hook.Data.ID))
+	assert.ErrorIs(t, client.deleteWebhook(admin.Data.ID, hook.Data.ID), ErrBadRequest)
+	_, err = client.listWebhookDeliveries(admin.Data.ID, hook.Data.ID)
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestWebhooks_WrongSecret(t *testing.T) {
+	userRepo, outbox, hookRepo := userrepo.New(), outboxrepo.New(), hookrepo.New()
+	client := getTestHTTPClientWithApp(newTestAppWithEvents(userRepo, outbox, hookRepo))
+
+	// подписчик не узнаёт подпись и отвечает ошибкой - доставка повторяется позже
+	rc := &hookReceiver{secret: "not the subscription secret"}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	admin, err := client.createUser("admin", "admin@gmail.com")
+	assert.NoError(t, err)
+	promote(t, userRepo, admin.Data.ID, users.RoleAdmin)
+	hook, err := client.createWebhook(admin.Data.ID, srv.URL, "user.created")
+	assert.NoError(t, err)
+	_, err = client.createUser("jenny", "jenny@gmail.com")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = dispatcher.New(outbox, hookRepo, dispatcher.Config{Interval: time.Millisecond, BaseBackoff: time.Hour}, logging.Discard()).Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// событие о самом администраторе ещё лежало в outbox, когда появилась подписка, поэтому доставляется и оно
+	var deliveries deliveriesResponse
+	assert.Eventually(t, func() bool {
+		deliveries, err = client.listWebhookDeliveries(admin.Data.ID, hook.Data.ID)
+		return err == nil && len(deliveries.Data) == 2 && len(deliveries.Data[0].Attempts) == 1 && len(deliveries.Data[1].Attempts) == 1
+	}, time.Second, time.Millisecond)
+	assert.Empty(t, rc.received())
+	for _, d := range deliveries.Data {
+		assert.Equal(t, "pending", d.Status)
+		assert.Equal(t, "user.created", d.Event)
+		assert.Equal(t, http.StatusUnauthorized, d.Attempts[0].StatusCode)
+	}
+}
+
+func TestGRPCWebhooks(t *testing.T) {
+	userRepo := userrepo.New()
+	ctx, client := getTestGRCPClientWithApp(t, newTestAppWithUsers(userRepo))
+
+	_, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Nickname: "Oleg", Email: "oleg@gmail.com", Password: testPassword})
+	assert.NoError(t, err)
+	ctx = loginGRPC(t, ctx, client, 0)
+
+	_, err = client.CreateWebhook(ctx, &grpcPort.CreateWebhookRequest{Url: "http://example.com"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	promote(t, userRepo, 0, users.RoleAdmin)
+	_, err = client.CreateWebhook(ctx, &grpcPort.CreateWebhookRequest{Url: "ftp://example.com"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	hook, err := client.CreateWebhook(ctx, &grpcPort.CreateWebhookRequest{Url: "http://example.com", Events: []string{"user.created"}})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, hook.Secret)
+
+	list, err := client.ListWebhooks(ctx, &grpcPort.ListWebhooksRequest{})
+	assert.NoError(t, err)
+	if assert.Len(t, list.List, 1) {
+		assert.Equal(t, hook.Id, list.List[0].Id)
+		assert.Empty(t, list.List[0].Secret)
+	}
+
+	deliveries, err := client.ListWebhookDeliveries(ctx, &grpcPort.ListWebhookDeliveriesRequest{WebhookId: hook.Id})
+	assert.NoError(t, err)
+	assert.Empty(t, deliveries.List)
+
+	_, err = client.DeleteWebhook(ctx, &grpcPort.DeleteWebhookRequest{Id: hook.Id})
+	assert.NoError(t, err)
+	_, err = client.DeleteWebhook(ctx, &grpcPort.DeleteWebhookRequest{Id: hook.Id})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,25 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"homework10/internal/app"
+)
+
+// AppObserver создаёт для каждого вызова приложения дочерний спан app.<метод>; подключается через app.Observe.
+// У ошибочных вызовов в спане есть вид ошибки (см. app.ErrorKind)
+func AppObserver(tp trace.TracerProvider) app.Observer {
+	tracer := tp.Tracer(Instrumentation)
+	return func(ctx context.Context, method string) (context.Context, func(error)) {
+		ctx, span := tracer.Start(ctx, "app."+method)
+		return ctx, func(err error) {
+			if err != nil {
+				span.SetAttributes(attribute.String("app.error_kind", app.ErrorKind(err)))
+			}
+			End(span, err)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"homework10/internal/ads"
+	"homework10/internal/users"
+)
+
+// adRepo - хранилище объявлений, каждый вызов которого - дочерний спан ads.<метод>
+type adRepo struct {
+	repo   ads.Repository
+	tracer trace.Tracer
+}
+
+// Ads возвращает хранилище, которое выполняет вызовы r, создавая для каждого спан
+func Ads(r ads.Repository, tp trace.TracerProvider) ads.Repository {
+	return &adRepo{repo: r, tracer: tp.Tracer(Instrumentation)}
+}
+
+func (r *adRepo) AdByID(ctx context.Context, ID int64) (*ads.Ad, error) {
+	ctx, span := r.tracer.Start(ctx, "ads.AdByID")
+	res, err := r.repo.AdByID(ctx, ID)
+	End(span, err)
+	return res, err
+}
+
+func (r *adRepo) AddAd(ctx context.Context, ad *ads.Ad) (int64, error) {
+	ctx, span := r.tracer.Start(ctx, "ads.AddAd")
+	res, err := r.repo.AddAd(ctx, ad)
+	End(span, err)
+	return res, err
+}
+
+func (r *adRepo) AdsByPattern(ctx context.Context, p *ads.Pattern, page ads.Page) ([]*ads.Ad, string, error) {
+	ctx, span := r.tracer.Start(ctx, "ads.AdsByPattern")
+	res, next, err := r.repo.AdsByPattern(ctx, p, page)
+	End(span, err)
+	return res, next, err
+}
+
+func (r *adRepo) CountByCategory(ctx context.Context, p *ads.Pattern) (map[int64]int, error) {
+	ctx, span := r.tracer.Start(ctx, "ads.CountByCategory")
+	res, err := r.repo.CountByCategory(ctx, p)
+	End(span, err)
+	return res, err
+}
+
+func (r *adRepo) UpdateAd(ctx context.Context, ad *ads.Ad) error {
+	ctx, span := r.tracer.Start(ctx, "ads.UpdateAd")
+	err := r.repo.UpdateAd(ctx, ad)
+	End(span, err)
+	return err
+}
+
+func (r *adRepo) DeleteAd(ctx context.Context, ID int64) error {
+	ctx, span := r.tracer.Start(ctx, "ads.DeleteAd")
+	err := r.repo.DeleteAd(ctx, ID)
+	End(span, err)
+	return err
+}
+
+// userRepo - хранилище пользователей, каждый вызов которого - дочерний спан users.<метод>
+type userRepo struct {
+	repo   users.Repository
+	tracer trace.Tracer
+}
+
+// Users возвращает хранилище, которое выполняет вызовы r, создавая для каждого спан
+func Users(r users.Repository, tp trace.TracerProvider) users.Repository {
+	return &userRepo{repo: r, tracer: tp.Tracer(Instrumentation)}
+}
+
+func (r *userRepo) UserByID(ctx context.Context, ID int64) (*users.User, error) {
+	ctx, span := r.tracer.Start(ctx, "users.UserByID")
+	res, err := r.repo.UserByID(ctx, ID)
+	End(span, err)
+	return res, err
+}
+
+func (r *userRepo) AddUser(ctx context.Context, u *users.User) (int64, error) {
+	ctx, span := r.tracer.Start(ctx, "users.AddUser")
+	res, err := r.repo.AddUser(ctx, u)
+	End(span, err)
+	return res, err
+}
+
+func (r *userRepo) UpdateUser(ctx context.Context, u *users.User) error {
+	ctx, span := r.tracer.Start(ctx, "users.UpdateUser")
+	err := r.repo.UpdateUser(ctx, u)
+	End(span, err)
+	return err
+}
+
+func (r *userRepo) DeleteUser(ctx context.Context, ID int64) error {
+	ctx, span := r.tracer.Start(ctx, "users.DeleteUser")
+	err := r.repo.DeleteUser(ctx, ID)
+	End(span, err)
+	return err
+}
+
+func (r *userRepo) CountUsers(ctx context.Context) (int, error) {
+	ctx, span := r.tracer.Start(ctx, "users.CountUsers")
+	res, err := r.repo.CountUsers(ctx)
+	End(span, err)
+	return res, err
+}
@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Instrumentation - имя, под которым сервис создаёт спаны
+const Instrumentation = "homework10"
+
+// Propagator передаёт контекст трассировки между сервисами в формате W3C Trace Context (заголовки traceparent и tracestate)
+var Propagator propagation.TextMapPropagator = propagation.TraceContext{}
+
+// New создаёт провайдер трассировки сервиса service и функцию, которая отправляет накопленные спаны при остановке.
+// Экспортёр выбирается по имени: stdout пишет спаны в w в формате JSON, none отключает трассировку
+func New(exporter, service string, w io.Writer) (trace.TracerProvider, func(context.Context) error, error) {
+	switch exporter {
+	case "none":
+		return noop.NewTracerProvider(), func(context.Context) error { return nil }, nil
+	case "stdout":
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(w))
+		if err != nil {
+			return nil, nil, err
+		}
+		tp := sdktrace.NewTracerProvider(
+			sdktrace.WithBatcher(exp),
+			sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(service))),
+		)
+		return tp, tp.Shutdown, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown trace exporter %q", exporter)
+	}
+}
+
+// NewRecorder создаёт провайдер, который сохраняет завершённые спаны в памяти, - для тестов
+func NewRecorder() (trace.TracerProvider, *tracetest.SpanRecorder) {
+	sr := tracetest.NewSpanRecorder()
+	return sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)), sr
+}
+
+// End завершает спан; если вызов завершился ошибкой, она записывается в спан и он помечается как ошибочный
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
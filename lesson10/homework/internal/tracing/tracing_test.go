@@ -0,0 +1,96 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+
+	"homework10/internal/adapters/adrepo"
+	"homework10/internal/adapters/userrepo"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/users"
+)
+
+func TestNew(t *testing.T) {
+	var out bytes.Buffer
+	tp, shutdown, err := New("stdout", "adapp-test", &out)
+	assert.NoError(t, err)
+	_, span := tp.Tracer(Instrumentation).Start(context.Background(), "app.CreateAd")
+	span.End()
+	// спаны отправляются пачками, остаток - при остановке
+	assert.NoError(t, shutdown(context.Background()))
+	assert.Contains(t, out.String(), `"Name":"app.CreateAd"`)
+	assert.Contains(t, out.String(), `"Value":"adapp-test"`)
+
+	tp, shutdown, err = New("none", "adapp-test", &out)
+	assert.NoError(t, err)
+	_, span = tp.Tracer(Instrumentation).Start(context.Background(), "app.CreateAd")
+	assert.False(t, span.IsRecording())
+	assert.NoError(t, shutdown(context.Background()))
+
+	_, _, err = New("jaeger", "adapp-test", &out)
+	assert.Error(t, err)
+}
+
+func TestAppObserver(t *testing.T) {
+	tp, sr := NewRecorder()
+	observe := AppObserver(tp)
+
+	_, done := observe(context.Background(), "CreateAd")
+	done(nil)
+	_, done = observe(context.Background(), "UpdateAd")
+	done(app.ErrForbidden)
+
+	spans := sr.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "app.CreateAd", spans[0].Name())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, "app.UpdateAd", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Contains(t, spans[1].Attributes(), attribute.String("app.error_kind", "forbidden"))
+}
+
+func TestRepos(t *testing.T) {
+	tp, sr := NewRecorder()
+	ctx, parent := tp.Tracer(Instrumentation).Start(context.Background(), "app.CreateAd")
+	adRepo, userRepo := Ads(adrepo.New(), tp), Users(userrepo.New(), tp)
+
+	id, err := adRepo.AddAd(ctx, &ads.Ad{ID: -1, Title: "title", Text: "text"})
+	assert.NoError(t, err)
+	_, err = adRepo.AdByID(ctx, id)
+	assert.NoError(t, err)
+	_, err = userRepo.AddUser(ctx, &users.User{ID: -1, Nickname: "jenny", Email: "jenny@gmail.com"})
+	assert.NoError(t, err)
+	_, err = userRepo.UserByID(ctx, 100)
+	assert.Error(t, err)
+	parent.End()
+
+	spans := sr.Ended()
+	assert.Len(t, spans, 5)
+	names := make([]string, 0, len(spans))
+	for _, span := range spans[:4] {
+		names = append(names, span.Name())
+		// вызовы хранилищ - дочерние спаны вызова приложения
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+	}
+	assert.Equal(t, []string{"ads.AddAd", "ads.AdByID", "users.AddUser", "users.UserByID"}, names)
+	assert.Equal(t, codes.Error, spans[3].Status().Code)
+	assert.Len(t, spans[3].Events(), 1)
+}
+
+func TestEnd(t *testing.T) {
+	tp, sr := NewRecorder()
+	_, span := tp.Tracer(Instrumentation).Start(context.Background(), "ads.AdByID")
+	End(span, errors.New("repo is down"))
+
+	spans := sr.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "repo is down", spans[0].Status().Description)
+}
@@ -38,6 +38,30 @@ func (_m *Repository) AddUser(ctx context.Context, ad *users.User) (int64, error
 	return r0, r1
 }
 
+// CountUsers provides a mock function with given fields: ctx
+func (_m *Repository) CountUsers(ctx context.Context) (int, error) {
+	ret := _m.Called(ctx)
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // DeleteUser provides a mock function with given fields: ctx, ID
 func (_m *Repository) DeleteUser(ctx context.Context, ID int64) error {
 	ret := _m.Called(ctx, ID)
@@ -52,6 +76,20 @@ func (_m *Repository) DeleteUser(ctx context.Context, ID int64) error {
 	return r0
 }
 
+// UpdateUser provides a mock function with given fields: ctx, u
+func (_m *Repository) UpdateUser(ctx context.Context, u *users.User) error {
+	ret := _m.Called(ctx, u)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *users.User) error); ok {
+		r0 = rf(ctx, u)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UserByID provides a mock function with given fields: ctx, ID
 func (_m *Repository) UserByID(ctx context.Context, ID int64) (*users.User, error) {
 	ret := _m.Called(ctx, ID)
@@ -6,5 +6,8 @@ import "context"
 type Repository interface {
 	UserByID(ctx context.Context, ID int64) (*User, error)
 	AddUser(ctx context.Context, ad *User) (int64, error)
+	UpdateUser(ctx context.Context, u *User) error
 	DeleteUser(ctx context.Context, ID int64) error
+	// CountUsers возвращает число пользователей в хранилище
+	CountUsers(ctx context.Context) (int, error)
 }
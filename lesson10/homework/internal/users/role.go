@@ -0,0 +1,27 @@
+package users
+
+// Role определяет, что пользователь может делать с чужими объявлениями и пользователями
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+// Valid проверяет, что роль одна из известных
+func (r Role) Valid() bool {
+	switch r {
+	case RoleUser, RoleModerator, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// RoleOf возвращает роль пользователя; у пользователей, созданных до появления ролей, она пустая и означает RoleUser
+func (u *User) RoleOf() Role {
+	if u.Role == "" {
+		return RoleUser
+	}
+	return u.Role
+}
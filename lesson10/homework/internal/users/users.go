@@ -4,4 +4,8 @@ type User struct {
 	ID       int64
 	Nickname string `validate:"min:1;max:50"`
 	Email    string `validate:"min:1;max:50;email"`
+	Version  int64
+	Role     Role
+	// PasswordHash - bcrypt-хэш пароля, сам пароль нигде не хранится
+	PasswordHash string
 }
@@ -0,0 +1,18 @@
+package webhooks
+
+import "time"
+
+// Backoff - пауза после attempt неудачных попыток: base, затем вдвое больше после каждой следующей, но не больше limit
+func Backoff(attempt int, base, limit time.Duration) time.Duration {
+	d := base
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= limit {
+			return limit
+		}
+	}
+	if d > limit {
+		return limit
+	}
+	return d
+}
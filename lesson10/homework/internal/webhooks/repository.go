@@ -0,0 +1,24 @@
+package webhooks
+
+import (
+	"context"
+	"time"
+)
+
+//go:generate mockery --name Repository
+type Repository interface {
+	AddSubscription(ctx context.Context, s *Subscription) (int64, error)
+	SubscriptionByID(ctx context.Context, ID int64) (*Subscription, error)
+	// Subscriptions возвращает все подписки в порядке создания
+	Subscriptions(ctx context.Context) ([]*Subscription, error)
+	// DeleteSubscription удаляет подписку вместе с журналом её доставок
+	DeleteSubscription(ctx context.Context, ID int64) error
+
+	AddDelivery(ctx context.Context, d *Delivery) (int64, error)
+	UpdateDelivery(ctx context.Context, d *Delivery) error
+	// DueDeliveries возвращает до limit недоставленных событий, следующая попытка которых наступила к now,
+	// начиная с самых давних
+	DueDeliveries(ctx context.Context, now time.Time, limit int) ([]*Delivery, error)
+	// Deliveries возвращает до limit последних доставок подписки, начиная с самых новых
+	Deliveries(ctx context.Context, subscriptionID int64, limit int) ([]*Delivery, error)
+}
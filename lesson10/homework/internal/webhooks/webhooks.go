@@ -0,0 +1,90 @@
+package webhooks
+
+import (
+	"net/url"
+	"time"
+
+	"homework10/internal/events"
+)
+
+// Subscription - подписка на события: о каждом подходящем событии сервис отправляет POST на URL,
+// подписывая тело секретом Secret (см. Sign)
+type Subscription struct {
+	ID  int64
+	URL string `validate:"min:1;max:2000"`
+	// Types - на какие события подписка, пустой - на все
+	Types  []events.Type
+	Secret string
+	// OwnerID - администратор, создавший подписку
+	OwnerID int64
+	Created time.Time
+}
+
+// Matches сообщает, нужно ли отправлять подписчику событие вида t
+func (s *Subscription) Matches(t events.Type) bool {
+	if len(s.Types) == 0 {
+		return true
+	}
+	for _, st := range s.Types {
+		if st == t {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidURL сообщает, можно ли доставлять события на адрес: нужен абсолютный http(s) URL
+func ValidURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+type DeliveryStatus string
+
+const (
+	// DeliveryPending - событие ещё не доставлено, следующая попытка - в Delivery.NextAttempt
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	// DeliveryFailed - все попытки исчерпаны, больше событие не отправляется
+	DeliveryFailed DeliveryStatus = "failed"
+)
+
+// Attempt - попытка доставки
+type Attempt struct {
+	At time.Time
+	// StatusCode - код ответа подписчика, 0 - ответа не было
+	StatusCode int
+	// Error - почему попытка не удалась, пустая - событие доставлено
+	Error    string
+	Duration time.Duration
+}
+
+// Delivery - доставка события одному подписчику вместе с журналом её попыток
+type Delivery struct {
+	ID             int64
+	SubscriptionID int64
+	// Event - копия события: из неё строится тело запроса
+	Event       events.Event
+	Status      DeliveryStatus
+	Attempts    []Attempt
+	NextAttempt time.Time
+	Created     time.Time
+}
+
+// Copy возвращает копию доставки, которую можно отдать наружу
+func (d *Delivery) Copy() *Delivery {
+	cp := *d
+	cp.Event = *d.Event.Copy()
+	cp.Attempts = append([]Attempt(nil), d.Attempts...)
+	return &cp
+}
+
+// Copy возвращает копию подписки, которую можно отдать наружу
+func (s *Subscription) Copy() *Subscription {
+	cp := *s
+	cp.Types = append([]events.Type(nil), s.Types...)
+	return &cp
+}
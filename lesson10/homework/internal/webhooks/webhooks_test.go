@@ -0,0 +1,74 @@
+package webhooks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/events"
+)
+
+func TestSubscription_Matches(t *testing.T) {
+	all := &Subscription{}
+	assert.True(t, all.Matches(events.AdCreated))
+	assert.True(t, all.Matches(events.UserDeleted))
+
+	ads := &Subscription{Types: []events.Type{events.AdCreated, events.AdPublished}}
+	assert.True(t, ads.Matches(events.AdPublished))
+	assert.False(t, ads.Matches(events.AdDeleted))
+}
+
+func TestValidURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{url: "https://example.com/hooks", want: true},
+		{url: "http://127.0.0.1:8080", want: true},
+		{url: "ftp://example.com", want: false},
+		{url: "/hooks", want: false},
+		{url: "https://", want: false},
+		{url: "://bad", want: false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ValidURL(tt.url), tt.url)
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	sig := Sign("secret", 1682955000, body)
+	assert.Regexp(t, "^sha256=[0-9a-f]{64}$", sig)
+
+	assert.True(t, Verify("secret", 1682955000, body, sig))
+	assert.False(t, Verify("other", 1682955000, body, sig))
+	assert.False(t, Verify("secret", 1682955001, body, sig))
+	assert.False(t, Verify("secret", 1682955000, []byte(`{"id":2}`), sig))
+}
+
+func TestNewSecret(t *testing.T) {
+	a, err := NewSecret()
+	assert.NoError(t, err)
+	b, err := NewSecret()
+	assert.NoError(t, err)
+	assert.Len(t, a, 64)
+	assert.NotEqual(t, a, b)
+}
+
+func TestBackoff(t *testing.T) {
+	base, limit := time.Second, 10*time.Second
+	assert.Equal(t, time.Second, Backoff(1, base, limit))
+	assert.Equal(t, 2*time.Second, Backoff(2, base, limit))
+	assert.Equal(t, 8*time.Second, Backoff(4, base, limit))
+	assert.Equal(t, limit, Backoff(5, base, limit))
+	assert.Equal(t, limit, Backoff(100, base, limit))
+}
+
+func TestDelivery_Copy(t *testing.T) {
+	d := &Delivery{ID: 1, Attempts: []Attempt{{StatusCode: 500}}}
+	cp := d.Copy()
+	cp.Attempts[0].StatusCode = 200
+	assert.Equal(t, 500, d.Attempts[0].StatusCode)
+}